@@ -4,22 +4,72 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/estrados/simply-aws/internal/audit"
 	"github.com/estrados/simply-aws/internal/awscli"
 	"github.com/estrados/simply-aws/internal/cli"
+	"github.com/estrados/simply-aws/internal/cmdb"
+	"github.com/estrados/simply-aws/internal/export"
+	"github.com/estrados/simply-aws/internal/graph"
+	"github.com/estrados/simply-aws/internal/handoff"
+	"github.com/estrados/simply-aws/internal/hooks"
+	_ "github.com/estrados/simply-aws/internal/providers/azure"
+	_ "github.com/estrados/simply-aws/internal/providers/gcp"
 	"github.com/estrados/simply-aws/internal/server"
 	"github.com/estrados/simply-aws/internal/sync"
+	"github.com/estrados/simply-aws/internal/warehouse"
 	"github.com/spf13/cobra"
 )
 
 func main() {
 	var port int
 
+	var account, dataDir string
+	var readOnly bool
 	rootCmd := &cobra.Command{
 		Use:   "saws",
 		Short: "simply-aws — local-first AWS infrastructure designer",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if account != "" {
+				sync.UseAccount(account)
+			}
+			if dataDir != "" {
+				sync.SetDataDir(dataDir)
+			}
+			if readOnly {
+				sync.SetReadOnly()
+			}
+			sync.PassphrasePrompt = cli.ReadPassphrase
+			if err := sync.UseProjectProfile(); err != nil {
+				log.Fatal(err)
+			}
+		},
 	}
+	rootCmd.PersistentFlags().StringVar(&account, "account", "", "cached account to use (default: the one the AWS CLI is signed in to)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "directory holding the cache (default: SAWS_DATA_DIR, data_dir in .saws.yaml, or ~/.local/share/saws)")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "serve the existing cache only: never call AWS or change the cache")
+
+	accountsCmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "List the AWS accounts with cached data",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
 
+			if err := cli.RunAccounts(awscli.Detect().AccountID); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	var authUser, authPassword, authToken string
 	upCmd := &cobra.Command{
 		Use:   "up",
 		Short: "Start the saws web server",
@@ -30,12 +80,36 @@ func main() {
 			defer sync.CloseDB()
 
 			status := awscli.Detect()
-			if status.Installed {
+			if status.ReadOnly {
+				fmt.Println("Read-only mode — serving cached data, sync disabled")
+			} else if status.Installed {
 				fmt.Printf("AWS CLI detected: %s\n", status.Version)
 				fmt.Printf("Region: %s | Account: %s\n", status.Region, status.AccountID)
 			} else {
 				fmt.Println("AWS CLI not found — sync features will be unavailable")
 			}
+			fmt.Printf("Cache: %s\n", sync.DBPath())
+
+			a := server.Auth{User: authUser, Password: authPassword, Token: authToken}
+			if a.User == "" {
+				a.User = os.Getenv("SAWS_AUTH_USER")
+			}
+			if a.Password == "" {
+				a.Password = os.Getenv("SAWS_AUTH_PASSWORD")
+			}
+			if a.Token == "" {
+				a.Token = os.Getenv("SAWS_AUTH_TOKEN")
+			}
+			if a.User != "" && a.Password == "" {
+				log.Fatal("--auth-user needs a password: set --auth-password or SAWS_AUTH_PASSWORD")
+			}
+			if a.Password != "" && a.User == "" {
+				a.User = "saws"
+			}
+			server.SetAuth(a)
+			if a.Enabled() {
+				fmt.Printf("Sign-in: %s\n", a.Method())
+			}
 
 			addr := fmt.Sprintf(":%d", port)
 			fmt.Printf("\nsaws is running at http://localhost%s\n", addr)
@@ -47,6 +121,9 @@ func main() {
 	}
 
 	upCmd.Flags().IntVarP(&port, "port", "p", 3131, "port to listen on")
+	upCmd.Flags().StringVar(&authUser, "auth-user", "", "require sign-in as this user (default: SAWS_AUTH_USER, else saws)")
+	upCmd.Flags().StringVar(&authPassword, "auth-password", "", "require sign-in with this password (default: SAWS_AUTH_PASSWORD)")
+	upCmd.Flags().StringVar(&authToken, "auth-token", "", "require this bearer token or sign-in with it (default: SAWS_AUTH_TOKEN)")
 
 	var viewRegion string
 	viewCmd := &cobra.Command{
@@ -73,6 +150,7 @@ func main() {
 	viewCmd.Flags().StringVar(&viewRegion, "region", "", "AWS region to view")
 
 	var syncRegion string
+	var syncMetrics bool
 	syncCmd := &cobra.Command{
 		Use:   "sync",
 		Short: "Sync AWS infrastructure to local cache",
@@ -82,27 +160,1117 @@ func main() {
 			}
 			defer sync.CloseDB()
 
+			if sync.ReadOnly() {
+				log.Fatal("read-only mode — cannot sync")
+			}
 			status := awscli.Detect()
 			if !status.Installed {
 				log.Fatal("AWS CLI not found — cannot sync")
 			}
+			if !sync.SignedIn() {
+				log.Fatalf("the AWS CLI is signed in to account %s, not %s — sync with that account's profile instead", status.AccountID, sync.Account())
+			}
 
-			region := syncRegion
-			if region == "" {
-				region = status.Region
+			regions := []string{syncRegion}
+			if syncRegion == "" {
+				regions = sync.SyncRegions()
 			}
-			if region == "" {
-				region = "us-east-1"
+			if len(regions) == 0 && status.Region != "" {
+				regions = []string{status.Region}
+			}
+			if len(regions) == 0 {
+				regions = []string{"us-east-1"}
+			}
+			if syncMetrics {
+				sync.EnableMetrics()
+			}
+
+			for i, region := range regions {
+				if i > 0 {
+					fmt.Println()
+				}
+				cli.RunSync(region)
+			}
+		},
+	}
+	syncCmd.Flags().StringVar(&syncRegion, "region", "", "AWS region to sync (default: the regions in .saws.yaml, or the AWS CLI's region)")
+	syncCmd.Flags().BoolVar(&syncMetrics, "metrics", false, "also fetch CloudWatch metrics (Lambda errors/throttles/p95, EC2/ECS utilization)")
+
+	var handoffRegion, handoffMatch, handoffOut string
+	var handoffTags []string
+	handoffCmd := &cobra.Command{
+		Use:   "handoff",
+		Short: "Export a Markdown handoff document for an application or team",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			regions := []string{handoffRegion}
+			if handoffRegion == "" {
+				regions = cachedRegions()
+			}
+
+			sel := handoff.Selector{Tags: map[string]string{}, Match: handoffMatch}
+			for _, t := range handoffTags {
+				k, v, ok := strings.Cut(t, "=")
+				if !ok {
+					log.Fatalf("invalid --tag %q, expected key=value", t)
+				}
+				sel.Tags[k] = v
+			}
+
+			out := os.Stdout
+			if handoffOut != "" {
+				f, err := os.Create(handoffOut)
+				if err != nil {
+					log.Fatal(err)
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := handoff.Write(out, regions, sel); err != nil {
+				log.Fatal(err)
+			}
+			if handoffOut != "" {
+				fmt.Printf("Wrote %s\n", handoffOut)
+			}
+		},
+	}
+	handoffCmd.Flags().StringVar(&handoffRegion, "region", "", "AWS region to export (default: all cached regions)")
+	handoffCmd.Flags().StringArrayVar(&handoffTags, "tag", nil, "select resources by tag (key=value, repeatable)")
+	handoffCmd.Flags().StringVar(&handoffMatch, "match", "", "select resources whose name or ID contains this string")
+	handoffCmd.Flags().StringVarP(&handoffOut, "out", "o", "", "write to file instead of stdout")
+
+	var savingsRegion string
+	savingsCmd := &cobra.Command{
+		Use:   "savings",
+		Short: "Suggest right-sized resources from synced CloudWatch metrics",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			cli.RunSavings(resolveRegion(savingsRegion))
+		},
+	}
+	savingsCmd.Flags().StringVar(&savingsRegion, "region", "", "AWS region to analyze")
+
+	var drRegion string
+	drCmd := &cobra.Command{
+		Use:   "dr",
+		Short: "Show which data stores have cross-region replicas, global tables or backup copies",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunDR(resolveRegion(drRegion)); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	drCmd.Flags().StringVar(&drRegion, "region", "", "AWS region to check")
+
+	var quotasRegion, quotasFormat string
+	var quotasThreshold int
+	quotasCmd := &cobra.Command{
+		Use:   "quotas",
+		Short: "Compare service quotas (VPCs, EIPs, On-Demand vCPUs, Lambda concurrency, ...) with cached usage",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			threshold := quotasThreshold
+			if threshold == 0 {
+				threshold = sync.QuotaWarningPct()
+			}
+			if threshold < 1 || threshold > 100 {
+				log.Fatalf("invalid --threshold %d, expected 1-100", threshold)
+			}
+			if err := cli.RunQuotas(resolveRegion(quotasRegion), threshold, quotasFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	quotasCmd.Flags().StringVar(&quotasRegion, "region", "", "AWS region to check")
+	quotasCmd.Flags().IntVar(&quotasThreshold, "threshold", 0, "flag quotas at or over this percent used (default: quota_warning_pct setting, or 80)")
+	quotasCmd.Flags().StringVar(&quotasFormat, "format", "text", "output format: text or csv")
+
+	providersCmd := &cobra.Command{
+		Use:   "providers",
+		Short: "List the experimental providers for other clouds and whether their CLIs are ready",
+		Long: `Other clouds sync read-only through their own CLI (gcloud, az) into the
+same cache, and show up on the matching tabs, in 'saws export graph' and
+in handoff documents. Turn them on with:
+
+  saws config set providers gcp,azure`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			enabled := map[string]bool{}
+			for _, p := range sync.EnabledProviders() {
+				enabled[p.Name()] = true
+			}
+			for _, p := range sync.RegisteredProviders() {
+				state := "off"
+				if enabled[p.Name()] {
+					state = "on"
+				}
+				ready := "ready"
+				if err := p.Available(); err != nil {
+					ready = err.Error()
+				}
+				fmt.Printf("%-8s %-14s %-4s %s\n", p.Name(), p.Title(), state, ready)
+			}
+		},
+	}
+
+	var endpointsRegion, endpointsFormat string
+	var endpointsProbe bool
+	endpointsCmd := &cobra.Command{
+		Use:   "endpoints",
+		Short: "List internet-facing endpoints (load balancers, CloudFront, API Gateway, function URLs, public IPs)",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunEndpoints(resolveRegion(endpointsRegion), endpointsProbe, endpointsFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	endpointsCmd.Flags().StringVar(&endpointsRegion, "region", "", "AWS region to list")
+	endpointsCmd.Flags().BoolVar(&endpointsProbe, "probe", false, "connect to each endpoint and report TLS version, certificate and HTTP status")
+	endpointsCmd.Flags().StringVar(&endpointsFormat, "format", "text", "output format: text or csv")
+
+	var imagesRegion, imagesFormat string
+	var imagesStaleDays int
+	imagesCmd := &cobra.Command{
+		Use:   "images",
+		Short: "Show ECS and Lambda workloads running images with critical CVEs or images not rebuilt recently",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunImages(resolveRegion(imagesRegion), imagesStaleDays, imagesFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	imagesCmd.Flags().StringVar(&imagesRegion, "region", "", "AWS region to check")
+	imagesCmd.Flags().IntVar(&imagesStaleDays, "stale-days", 90, "flag images pushed more than this many days ago")
+	imagesCmd.Flags().StringVar(&imagesFormat, "format", "text", "output format: text or csv")
+
+	var tagsRegion, tagsService, tagsFormat string
+	tagsCmd := &cobra.Command{
+		Use:   "tags [key[=value]]",
+		Short: "List tag keys, or the resources carrying a tag, across services and regions",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			f := sync.TagFilter{Service: tagsService, Region: tagsRegion}
+			if len(args) == 1 {
+				f.Key, f.Value, _ = strings.Cut(args[0], "=")
+			}
+			if err := cli.RunTags(f, tagsFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	tagsCmd.Flags().StringVar(&tagsRegion, "region", "", "only this region (default: all synced regions)")
+	tagsCmd.Flags().StringVar(&tagsService, "service", "", "only this service, as named in ARNs (ec2, lambda, s3, ...)")
+	tagsCmd.Flags().StringVar(&tagsFormat, "format", "text", "output format: text or csv")
+
+	var resourcesRegion, resourcesVpc, resourcesRefs, resourcesFormat string
+	var resourcesTypes []string
+	resourcesCmd := &cobra.Command{
+		Use:   "resources [search]",
+		Short: "Search synced resources by name, ID or ARN, type, VPC or what they depend on",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			f := sync.ResourceFilter{Region: resourcesRegion, Types: resourcesTypes, VpcId: resourcesVpc, RefersTo: resourcesRefs}
+			if len(args) == 1 {
+				f.Search = args[0]
+			}
+			if err := cli.RunResources(f, resourcesFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	resourcesCmd.Flags().StringVar(&resourcesRegion, "region", "", "only this region (default: all synced regions)")
+	resourcesCmd.Flags().StringSliceVar(&resourcesTypes, "type", nil, "only these inventory types (vpc, subnet, ec2, lambda, rds, ...)")
+	resourcesCmd.Flags().StringVar(&resourcesVpc, "vpc", "", "only resources in this VPC")
+	resourcesCmd.Flags().StringVar(&resourcesRefs, "refs", "", "only resources that depend on this type/id, e.g. sg/sg-0abc")
+	resourcesCmd.Flags().StringVar(&resourcesFormat, "format", "text", "output format: text or csv")
+
+	var topologyRegion, topologyVPC, topologyFormat string
+	graphCmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Print resources and their relationships as a Graphviz DOT graph",
+		Long: `Print every synced resource of a region and the relationships between
+them (placement, security groups, load balancer targets and other
+references) as a Graphviz digraph with a cluster per VPC, e.g.
+
+  saws graph --vpc vpc-0abc123 | dot -Tsvg -o vpc.svg`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunGraph(resolveRegion(topologyRegion), topologyVPC, topologyFormat); err != nil {
+				log.Fatal(err)
 			}
+		},
+	}
+	graphCmd.Flags().StringVar(&topologyRegion, "region", "", "AWS region to print")
+	graphCmd.Flags().StringVar(&topologyVPC, "vpc", "", "only resources in this VPC")
+	graphCmd.Flags().StringVar(&topologyFormat, "format", "dot", "output format: dot")
+
+	var diffRegion, diffFormat string
+	var diffFrom, diffTo int64
+	var diffList bool
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Show resources added, removed or changed between two syncs",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
 
-			cli.RunSync(region)
+			if (diffFrom == 0) != (diffTo == 0) {
+				log.Fatal("--from and --to must be given together")
+			}
+			if err := cli.RunDiff(resolveRegion(diffRegion), diffFrom, diffTo, diffList, diffFormat); err != nil {
+				log.Fatal(err)
+			}
 		},
 	}
-	syncCmd.Flags().StringVar(&syncRegion, "region", "", "AWS region to sync")
+	diffCmd.Flags().StringVar(&diffRegion, "region", "", "AWS region to compare")
+	diffCmd.Flags().Int64Var(&diffFrom, "from", 0, "snapshot ID to compare from (default: second-to-last sync)")
+	diffCmd.Flags().Int64Var(&diffTo, "to", 0, "snapshot ID to compare to (default: last sync)")
+	diffCmd.Flags().BoolVar(&diffList, "list", false, "list the region's snapshots instead")
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "output format: text or csv")
 
-	rootCmd.AddCommand(upCmd, viewCmd, syncCmd)
+	var keysRegion, keysFormat string
+	keysCmd := &cobra.Command{
+		Use:   "keys [key-id|alias]",
+		Short: "Show which cached resources each KMS key protects",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			key := ""
+			if len(args) == 1 {
+				key = args[0]
+			}
+			if err := cli.RunKeys(resolveRegion(keysRegion), key, keysFormat); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	keysCmd.Flags().StringVar(&keysRegion, "region", "", "AWS region to check")
+	keysCmd.Flags().StringVar(&keysFormat, "format", "text", "output format: text or csv")
+
+	var scheduleRegion, scheduleTZ, scheduleCFN string
+	var scheduleStart, scheduleStop int
+	scheduleCmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Find non-production instances running 24/7 and propose office-hours schedules",
+		Run: func(cmd *cobra.Command, args []string) {
+			if scheduleStart < 0 || scheduleStop > 23 || scheduleStart >= scheduleStop {
+				log.Fatalf("invalid hours %d-%d, expected 0 <= start < stop <= 23", scheduleStart, scheduleStop)
+			}
+			if _, err := time.LoadLocation(scheduleTZ); err != nil {
+				log.Fatalf("invalid --timezone: %v", err)
+			}
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			hours := sync.OfficeHours{Start: scheduleStart, Stop: scheduleStop, Timezone: scheduleTZ}
+			if err := cli.RunSchedule(resolveRegion(scheduleRegion), hours, scheduleCFN); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	scheduleCmd.Flags().StringVar(&scheduleRegion, "region", "", "AWS region to analyze")
+	scheduleCmd.Flags().IntVar(&scheduleStart, "start", sync.DefaultOfficeHours.Start, "hour to start instances on weekdays")
+	scheduleCmd.Flags().IntVar(&scheduleStop, "stop", sync.DefaultOfficeHours.Stop, "hour to stop instances on weekdays")
+	scheduleCmd.Flags().StringVar(&scheduleTZ, "timezone", sync.DefaultOfficeHours.Timezone, "IANA timezone for the schedule")
+	scheduleCmd.Flags().StringVar(&scheduleCFN, "cfn", "", "write an EventBridge Scheduler + Lambda CloudFormation template to this file")
+
+	annotateCmd := &cobra.Command{
+		Use:   "annotate <type/id> <note>",
+		Short: "Attach a runbook note to a resource (e.g. ec2/i-0abc123)",
+		Args:  cobra.MinimumNArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if !strings.Contains(args[0], "/") {
+				log.Fatalf("resource must be type/id, got %q", args[0])
+			}
+			if err := sync.AddAnnotation(args[0], strings.Join(args[1:], " ")); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Annotated %s\n", args[0])
+		},
+	}
+
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Get or set saws settings",
+	}
+	configGetCmd := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print a setting (or all known settings)",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			keys := sync.SettingKeys()
+			if len(args) == 1 {
+				keys = args
+			}
+			for _, k := range keys {
+				v, err := sync.GetSetting(k)
+				if err != nil {
+					log.Fatal(err)
+				}
+				if v == "" {
+					v = "(default)"
+				}
+				fmt.Printf("%s = %s\n", k, v)
+			}
+		},
+	}
+	configSetCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := sync.ValidateSetting(args[0], args[1]); err != nil {
+				log.Fatal(err)
+			}
+			if err := sync.SetSetting(args[0], args[1]); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s = %s\n", args[0], args[1])
+		},
+	}
+	configCmd.AddCommand(configGetCmd, configSetCmd)
+
+	cmdbCmd := &cobra.Command{
+		Use:   "cmdb",
+		Short: "Reconcile cached AWS inventory against a CMDB export",
+	}
+	cmdbImportCmd := &cobra.Command{
+		Use:   "import <file.csv>",
+		Short: "Import a CMDB CSV (columns: hostname and/or arn, optional owner)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			n, err := cmdb.Import(args[0])
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("Imported %d CMDB records\n", n)
+		},
+	}
+	var cmdbRegion, cmdbFormat string
+	var cmdbTypes []string
+	cmdbReconcileCmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "Report resources in AWS but not the CMDB, and vice versa",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			records, err := cmdb.Load()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if records == nil {
+				log.Fatal("no CMDB records imported — run 'saws cmdb import <file.csv>' first")
+			}
+			// Without --region every cached region is reconciled, so a
+			// record is only reported missing if no region has it.
+			var scope []string
+			regions := cachedRegions()
+			if cmdbRegion != "" {
+				scope = []string{cmdbRegion}
+				regions = scope
+			}
+			items, err := sync.LoadInventories(regions)
+			if err != nil {
+				log.Fatal(err)
+			}
+			rep := cmdb.Reconcile(items, records, cmdbTypes, scope)
+			if cmdbFormat == "csv" {
+				if err := cmdb.WriteCSV(os.Stdout, rep); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+			cmdb.WriteText(os.Stdout, rep)
+		},
+	}
+	cmdbReconcileCmd.Flags().StringVar(&cmdbRegion, "region", "", "reconcile one AWS region (default: all cached regions)")
+	cmdbReconcileCmd.Flags().StringVar(&cmdbFormat, "format", "text", "output format: text or csv")
+	cmdbReconcileCmd.Flags().StringSliceVar(&cmdbTypes, "types", cmdb.DefaultTypes, "resource types treated as assets")
+	cmdbCmd.AddCommand(cmdbImportCmd, cmdbReconcileCmd)
+
+	var exportOut, exportFormat string
+	var exportRegions, exportServices, exportColumns []string
+	var exportAnonymize bool
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export cached data as a bundle for 'saws import', or for other tools",
+		Long: `With --out and no --format, writes a bundle of the cache for 'saws import'.
+--anonymize rewrites account IDs, IP addresses, ARNs and names in it, the
+same way on every export, so it can be shared to reproduce a bug.
+
+With --format csv, json or parquet, writes the synced inventory as a table,
+one row per resource, to --out or stdout. Columns are region, type, id, name,
+arn, vpc_id, refs and synced_at, then a detail:<key> and tag:<key> column for
+each detail and tag the exported resources have; --columns picks and orders
+them. --service selects inventory types (ec2, lambda, rds, ...). --anonymize
+rewrites the names, IDs, ARNs, addresses and tag values in it as for a bundle.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if exportOut == "" && exportFormat == "" {
+				cmd.Help()
+				return
+			}
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if exportFormat != "" {
+				opts := export.Options{Regions: exportRegions, Types: exportServices, Columns: exportColumns, Anonymize: exportAnonymize}
+				if err := cli.RunExportTable(exportOut, exportFormat, opts); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+			f := sync.BundleFilter{Regions: exportRegions, Services: exportServices}
+			if err := cli.RunExport(exportOut, f, exportAnonymize); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	exportCmd.Flags().StringVar(&exportOut, "out", "", "write a bundle of the cache to this file, e.g. infra.saws")
+	exportCmd.Flags().StringSliceVar(&exportRegions, "region", nil, "only these regions (global services like IAM and S3 are always included)")
+	exportCmd.Flags().StringSliceVar(&exportServices, "service", nil, "only these services: cache key prefixes for a bundle, inventory types for --format (lambda, rds, iam, s3, ...)")
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "write the inventory as a table: csv, json or parquet")
+	exportCmd.Flags().StringSliceVar(&exportColumns, "columns", nil, "table columns, in order (default: all); e.g. id,name,detail:state,tag:env")
+	exportCmd.Flags().BoolVar(&exportAnonymize, "anonymize", false, "rewrite account IDs, IP addresses, ARNs and names in the bundle or table, e.g. to attach it to a bug report")
+
+	encryptCmd := &cobra.Command{
+		Use:       "encrypt [passphrase|keychain|off]",
+		Short:     "Encrypt the cache at rest, change its passphrase, or decrypt it",
+		Long:      "Encrypt cached API responses (security group rules, IAM and resource policies, ...) with AES-256-GCM.\nThe key is derived from a passphrase (asked for on start, or SAWS_PASSPHRASE) or kept in the OS keychain\n(macOS Keychain, or the Secret Service via secret-tool on Linux). With no argument, shows the current setting.",
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"passphrase", "keychain", "off"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			mode := ""
+			if len(args) == 1 {
+				mode = args[0]
+			}
+			if err := cli.RunEncrypt(mode); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Show what the cache holds, prune old data and compact the file",
+	}
+	var cacheStatsAll bool
+	cacheStatsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the cache file's size and each region's and key's share of it",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunCacheStats(cacheStatsAll); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cacheStatsCmd.Flags().BoolVar(&cacheStatsAll, "all", false, "list every key, not just the largest")
+	var cachePruneOlderThan string
+	cachePruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete regional data and snapshots not synced within --older-than, then vacuum",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunCachePrune(cachePruneOlderThan); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cachePruneCmd.Flags().StringVar(&cachePruneOlderThan, "older-than", "30d", "age of the data to delete: 30d, 2w, 12h, ...")
+	cacheVacuumCmd := &cobra.Command{
+		Use:   "vacuum",
+		Short: "Compact the cache file, giving back the space of deleted data",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunCacheVacuum(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	cacheCmd.AddCommand(cacheStatsCmd, cachePruneCmd, cacheVacuumCmd)
+
+	importCmd := &cobra.Command{
+		Use:   "import <bundle>",
+		Short: "Merge a bundle from 'saws export --out' into the local cache",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := cli.RunImport(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	var graphRegion, graphFormat, graphOut string
+	var graphAllRegions bool
+	exportGraphCmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Export the resource relationship graph as Cypher (Neo4j) or GraphML (Gephi, yEd)",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			write := graph.WriteCypher
+			switch graphFormat {
+			case "cypher":
+			case "graphml":
+				write = graph.WriteGraphML
+			default:
+				log.Fatalf("unknown format %q, expected cypher or graphml", graphFormat)
+			}
+
+			regions := []string{resolveRegion(graphRegion)}
+			if graphAllRegions {
+				cached, err := sync.CachedRegions()
+				if err != nil {
+					log.Fatal(err)
+				}
+				regions = nil
+				for _, r := range cached {
+					if _, ok := awscli.RegionNames[r]; ok {
+						regions = append(regions, r)
+					}
+				}
+			}
+			g, err := graph.Build(regions)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			out := os.Stdout
+			if graphOut != "" {
+				f, err := os.Create(graphOut)
+				if err != nil {
+					log.Fatal(err)
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := write(out, g); err != nil {
+				log.Fatal(err)
+			}
+			if graphOut != "" {
+				fmt.Printf("Wrote %s (%d resources, %d relationships)\n", graphOut, len(g.Nodes), len(g.Edges))
+			}
+		},
+	}
+	exportGraphCmd.Flags().StringVar(&graphRegion, "region", "", "AWS region to export")
+	exportGraphCmd.Flags().BoolVar(&graphAllRegions, "all-regions", false, "export every cached region")
+	exportGraphCmd.Flags().StringVar(&graphFormat, "format", "cypher", "output format: cypher or graphml")
+	exportGraphCmd.Flags().StringVarP(&graphOut, "out", "o", "", "write to file instead of stdout")
+
+	var diagramRegion, diagramVPC, diagramFormat, diagramOut string
+	exportDiagramCmd := &cobra.Command{
+		Use:   "diagram",
+		Short: "Export the architecture diagram of a region or VPC as SVG, PNG or draw.io",
+		Long: `Export the architecture drawn in the web UI's Graph tab: a box per VPC
+holding its subnets, load balancers, compute and databases, joined by
+targets, security group rules and references.
+
+The format defaults to the extension of --out (.svg, .png or .drawio),
+else svg.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			format := diagramFormat
+			if format == "" {
+				format = strings.TrimPrefix(filepath.Ext(diagramOut), ".")
+				if !slices.Contains(graph.DiagramFormats, format) {
+					format = "svg"
+				}
+			}
+			if !slices.Contains(graph.DiagramFormats, format) {
+				log.Fatalf("unknown format %q, expected %s", format, strings.Join(graph.DiagramFormats, ", "))
+			}
+
+			d, err := graph.ArchitectureDiagram(resolveRegion(diagramRegion), diagramVPC)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(d.Groups) == 0 {
+				log.Fatal("nothing to draw; run 'saws sync' first or check --region/--vpc")
+			}
+
+			out := os.Stdout
+			if diagramOut != "" {
+				f, err := os.Create(diagramOut)
+				if err != nil {
+					log.Fatal(err)
+				}
+				defer f.Close()
+				out = f
+			}
+			if err := graph.WriteDiagram(out, format, d); err != nil {
+				log.Fatal(err)
+			}
+			if diagramOut != "" {
+				fmt.Printf("Wrote %s (%d resources, %d relationships)\n", diagramOut, len(d.Nodes), len(d.Edges))
+			}
+		},
+	}
+	exportDiagramCmd.Flags().StringVar(&diagramRegion, "region", "", "AWS region to draw")
+	exportDiagramCmd.Flags().StringVar(&diagramVPC, "vpc", "", "draw only this VPC")
+	exportDiagramCmd.Flags().StringVar(&diagramFormat, "format", "", "output format: svg, png or drawio")
+	exportDiagramCmd.Flags().StringVarP(&diagramOut, "out", "o", "", "write to file instead of stdout")
+
+	var whRegion, whURI, whDatabase string
+	var whAllRegions, whCreateTable, whDDL bool
+	exportWarehouseCmd := &cobra.Command{
+		Use:   "warehouse",
+		Short: "Append the cached inventory to S3 as partitioned JSON for historical queries in Athena",
+		Long: `Uploads the cached inventory as gzipped JSON lines to
+<uri>/resources/dt=YYYY-MM-DD/region=<region>/, one object per run, so
+history builds up in S3 instead of the local cache. With warehouse_uri
+set, 'saws sync' appends after every sync.
+
+--create-table creates the Glue table (with partition projection, so new
+days need no crawler); --ddl prints the equivalent Athena DDL instead.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			uri := whURI
+			if uri == "" {
+				uri, _ = sync.GetSetting("warehouse_uri")
+			}
+			region := resolveRegion(whRegion)
+
+			if whDDL {
+				ddl, err := warehouse.DDL(uri, whDatabase)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Print(ddl)
+				return
+			}
+			if whCreateTable {
+				if err := warehouse.CreateTable(uri, whDatabase, region); err != nil {
+					log.Fatal(err)
+				}
+				fmt.Printf("Glue table %s.%s ready in %s\n", whDatabase, warehouse.Table, region)
+			}
+
+			regions := []string{region}
+			if whAllRegions {
+				cached, err := sync.CachedRegions()
+				if err != nil {
+					log.Fatal(err)
+				}
+				regions = nil
+				for _, r := range cached {
+					if _, ok := awscli.RegionNames[r]; ok {
+						regions = append(regions, r)
+					}
+				}
+			}
+			objs, err := warehouse.Export(uri, regions, time.Now())
+			for _, o := range objs {
+				fmt.Printf("Wrote %s (%d resources)\n", o.URI, o.Rows)
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	exportWarehouseCmd.Flags().StringVar(&whRegion, "region", "", "AWS region to export (and of the Glue catalog)")
+	exportWarehouseCmd.Flags().BoolVar(&whAllRegions, "all-regions", false, "export every cached region")
+	exportWarehouseCmd.Flags().StringVar(&whURI, "uri", "", "s3://bucket/prefix (default: the warehouse_uri setting)")
+	exportWarehouseCmd.Flags().StringVar(&whDatabase, "database", warehouse.DefaultDatabase, "Glue database for the table")
+	exportWarehouseCmd.Flags().BoolVar(&whCreateTable, "create-table", false, "create or update the Glue table before exporting")
+	exportWarehouseCmd.Flags().BoolVar(&whDDL, "ddl", false, "print the Athena CREATE TABLE statement and exit")
+	exportCmd.AddCommand(exportGraphCmd, exportDiagramCmd, exportWarehouseCmd)
+
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "Run commands or webhooks on sync, diff and audit events",
+		Long: `Hooks receive a JSON payload when an event fires:
+
+  sync.completed     after 'saws sync' or a sync from the web UI, with per-service results
+  diff.detected      when a sync added, removed or changed cached resources
+  audit.finding.new  when an audit finding appears that the previous check did not have
+
+Targets starting with http:// or https:// get the payload as a POST. Anything
+else is run with 'sh -c', gets the payload on stdin and SAWS_EVENT in its
+environment.`,
+	}
+	hooksListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List configured hooks",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			list, err := hooks.List()
+			if err != nil {
+				log.Fatal(err)
+			}
+			if len(list) == 0 {
+				fmt.Println("No hooks configured. Add one with 'saws hooks add <event> <command|url>'.")
+				return
+			}
+			for i, h := range list {
+				fmt.Printf("%d  %-18s %s\n", i+1, h.Event, h.Target)
+			}
+		},
+	}
+	hooksAddCmd := &cobra.Command{
+		Use:   "add <event> <command|url>",
+		Short: "Run a command or call a webhook when event fires",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			if err := hooks.Add(args[0], args[1]); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Printf("%s → %s\n", args[0], args[1])
+		},
+	}
+	hooksRemoveCmd := &cobra.Command{
+		Use:   "remove <number>",
+		Short: "Remove a hook by its number in 'saws hooks list'",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			n, err := strconv.Atoi(args[0])
+			if err != nil {
+				log.Fatalf("expected a hook number, got %q", args[0])
+			}
+			if err := hooks.Remove(n - 1); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	var hooksRegion string
+	hooksTestCmd := &cobra.Command{
+		Use:   "test <event>",
+		Short: "Send an empty payload for event to its hooks",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			deliveries := hooks.Fire(hooks.Payload{Event: args[0], Region: resolveRegion(hooksRegion)})
+			if len(deliveries) == 0 {
+				fmt.Printf("No hooks for %s\n", args[0])
+			}
+			failed := false
+			for _, d := range deliveries {
+				if d.Err != nil {
+					failed = true
+					fmt.Printf("✗ %s: %v\n", d.Hook.Target, d.Err)
+				} else {
+					fmt.Printf("✓ %s\n", d.Hook.Target)
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+	hooksTestCmd.Flags().StringVar(&hooksRegion, "region", "", "region to put in the test payload")
+	hooksCmd.AddCommand(hooksListCmd, hooksAddCmd, hooksRemoveCmd, hooksTestCmd)
+
+	var auditRegion, auditFormat string
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Check cached resources against policy (allowed regions, ...)",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			region := resolveRegion(auditRegion)
+			findings, err := audit.Run(region)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+			for _, d := range hooks.AuditCompleted(region, findings) {
+				if d.Err != nil {
+					fmt.Fprintf(os.Stderr, "warning: hook %s %q: %v\n", d.Hook.Event, d.Hook.Target, d.Err)
+				}
+			}
+			if auditFormat == "csv" {
+				if err := audit.WriteCSV(os.Stdout, findings); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+			audit.WriteText(os.Stdout, findings)
+		},
+	}
+	auditCmd.PersistentFlags().StringVar(&auditRegion, "region", "", "AWS region to audit (account-wide rules look at every cached region)")
+	auditCmd.PersistentFlags().StringVar(&auditFormat, "format", "text", "output format: text or csv")
+	auditEncryptionCmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Report at-rest encryption across EBS, RDS, DynamoDB, S3, SQS, SNS and Redshift",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			rep := audit.Encryption(resolveRegion(auditRegion))
+			if auditFormat == "csv" {
+				if err := audit.WriteEncryptionCSV(os.Stdout, rep); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+			audit.WriteEncryptionText(os.Stdout, rep)
+		},
+	}
+	auditExposureCmd := &cobra.Command{
+		Use:   "exposure",
+		Short: "Report public data stores, ports open to the internet, AMIs/snapshots shared outside the account and Access Analyzer findings",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			rep := audit.Exposure(resolveRegion(auditRegion))
+			if auditFormat == "csv" {
+				if err := audit.WriteCSV(os.Stdout, append(append(append(rep.Public, rep.Network...), rep.Shared...), rep.External...)); err != nil {
+					log.Fatal(err)
+				}
+				return
+			}
+			audit.WriteExposureText(os.Stdout, rep)
+		},
+	}
+	auditWhatIfCmd := &cobra.Command{
+		Use:   "whatif <change>...",
+		Short: "Show what staged security group rule changes would open up or break, without touching AWS",
+		Long: `Applies hypothetical security group changes to the cached rules and
+compares reachability and internet exposure before and after. Each change is
+"<sg-id> add|remove in|out <protocol> <ports> <source>", for example:
+
+  saws audit whatif "sg-0abc add in tcp 22 0.0.0.0/0" "sg-0def remove out all all 0.0.0.0/0"`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			var changes []audit.SGChange
+			for _, a := range args {
+				c, err := audit.ParseSGChange("", a)
+				if err != nil {
+					log.Fatal(err)
+				}
+				changes = append(changes, c)
+			}
+			res, err := audit.WhatIf(resolveRegion(auditRegion), changes)
+			if err != nil {
+				log.Fatal(err)
+			}
+			audit.WriteWhatIfText(os.Stdout, res)
+		},
+	}
+	auditCmd.AddCommand(auditEncryptionCmd, auditExposureCmd, auditWhatIfCmd)
+
+	var newOpts cli.NewOptions
+	newCmd := &cobra.Command{
+		Use:   "new",
+		Short: "Generate a CloudFormation template pre-filled from the cached environment",
+	}
+	newCmd.PersistentFlags().StringVar(&newOpts.Region, "region", "", "AWS region whose cache pre-fills the template")
+	newCmd.PersistentFlags().StringVarP(&newOpts.Out, "output", "o", "", "file to write (default <name>.yaml)")
+	newCmd.PersistentFlags().BoolVar(&newOpts.Force, "force", false, "overwrite an existing file")
+	newRun := func(kind string) func(cmd *cobra.Command, args []string) {
+		return func(cmd *cobra.Command, args []string) {
+			if err := sync.InitDB(); err != nil {
+				log.Fatalf("failed to init database: %v", err)
+			}
+			defer sync.CloseDB()
+
+			name := kind
+			if len(args) == 1 {
+				name = args[0]
+			}
+			newOpts.Region = resolveRegion(newOpts.Region)
+			if err := cli.RunNew(kind, name, newOpts); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+	newVPCCmd := &cobra.Command{
+		Use:   "vpc [name]",
+		Short: "VPC with public and private subnets in two AZs, on a CIDR clear of the cached VPCs",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   newRun("vpc"),
+	}
+	newVPCCmd.Flags().BoolVar(&newOpts.NAT, "nat", false, "add a NAT gateway for the private subnets")
+	newFargateCmd := &cobra.Command{
+		Use:   "fargate-service [name]",
+		Short: "Fargate service behind an ALB in a cached VPC and ECS cluster",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   newRun("fargate-service"),
+	}
+	newFargateCmd.Flags().StringVar(&newOpts.VpcId, "vpc", "", "VPC to deploy into (default: a cached VPC with public subnets in two AZs)")
+	newFargateCmd.Flags().StringVar(&newOpts.Cluster, "cluster", "", "ECS cluster (default: the first active cached cluster, else a new one)")
+	newFargateCmd.Flags().StringVar(&newOpts.Image, "image", "public.ecr.aws/nginx/nginx:latest", "container image")
+	newFargateCmd.Flags().IntVar(&newOpts.Port, "port", 80, "container port")
+	newSiteCmd := &cobra.Command{
+		Use:   "static-site [name]",
+		Short: "Private S3 bucket behind CloudFront, with a cached certificate and hosted zone for --domain",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   newRun("static-site"),
+	}
+	newSiteCmd.Flags().StringVar(&newOpts.Domain, "domain", "", "site hostname, e.g. www.example.com")
+	newSiteCmd.Flags().StringVar(&newOpts.Certificate, "certificate", "", "us-east-1 ACM certificate ARN (default: a cached one covering --domain)")
+	newCmd.AddCommand(newVPCCmd, newFargateCmd, newSiteCmd)
+
+	rootCmd.AddCommand(upCmd, viewCmd, syncCmd, newCmd, savingsCmd, scheduleCmd, drCmd, quotasCmd, providersCmd, endpointsCmd, imagesCmd, tagsCmd, resourcesCmd, graphCmd, diffCmd, accountsCmd, keysCmd, handoffCmd, annotateCmd, configCmd, cmdbCmd, auditCmd, hooksCmd, exportCmd, importCmd, encryptCmd, cacheCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
 }
+
+// cachedRegions lists the AWS regions with cached data, for commands that
+// cover every region unless --region is given.
+func cachedRegions() []string {
+	cached, err := sync.CachedRegions()
+	if err != nil {
+		log.Fatal(err)
+	}
+	var regions []string
+	for _, r := range cached {
+		if _, ok := awscli.RegionNames[r]; ok {
+			regions = append(regions, r)
+		}
+	}
+	return regions
+}
+
+// resolveRegion falls back to the CLI's configured region, then us-east-1.
+func resolveRegion(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return sync.DefaultRegion(awscli.Detect().Region)
+}
@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// Severity ranks how urgently a finding needs attention.
+type Severity string
+
+const (
+	High   Severity = "high"
+	Medium Severity = "medium"
+	Low    Severity = "low"
+)
+
+var severityOrder = map[Severity]int{High: 0, Medium: 1, Low: 2}
+
+// Finding is one problem a rule found in the cache. Resource is the
+// "type/id" key from sync.InventoryItem so findings link to detail views.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Resource string   `json:"resource"`
+	Name     string   `json:"name"`
+	Region   string   `json:"region"`
+	Message  string   `json:"message"`
+}
+
+// Rule checks cached data and reports findings. Check receives the region
+// being audited; account-wide rules may look beyond it.
+type Rule struct {
+	ID    string
+	Title string
+	Check func(region string) ([]Finding, error)
+}
+
+// Rules is every check Run performs, in report order.
+var Rules = []Rule{
+	{ID: "region-residency", Title: "Resources outside allowed regions", Check: checkResidency},
+	{ID: "encryption-at-rest", Title: "Unencrypted data stores", Check: checkEncryption},
+	{ID: "public-sharing", Title: "Public or shared AMIs and snapshots", Check: checkSharing},
+	{ID: "dangling-dns", Title: "DNS records pointing at deleted resources", Check: checkDanglingDNS},
+	{ID: "secret-hygiene", Title: "Secrets never rotated or unused", Check: checkSecrets},
+}
+
+// Run evaluates all rules against the cache and returns findings sorted by
+// severity, then rule. A failing rule is skipped and its error returned
+// alongside the findings from the others.
+func Run(region string) ([]Finding, error) {
+	var out []Finding
+	var firstErr error
+	for _, r := range Rules {
+		found, err := r.Check(region)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", r.ID, err)
+			}
+			continue
+		}
+		for _, f := range found {
+			f.Rule = r.ID
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Severity != out[j].Severity {
+			return severityOrder[out[i].Severity] < severityOrder[out[j].Severity]
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out, firstErr
+}
+
+// WriteText prints findings grouped by rule.
+func WriteText(w io.Writer, findings []Finding) {
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No findings.")
+		return
+	}
+	byRule := map[string][]Finding{}
+	for _, f := range findings {
+		byRule[f.Rule] = append(byRule[f.Rule], f)
+	}
+	for _, r := range Rules {
+		fs := byRule[r.ID]
+		if len(fs) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d)\n", r.Title, len(fs))
+		for _, f := range fs {
+			fmt.Fprintf(w, "  %-6s %-40s %-14s %s\n", f.Severity, f.Resource, f.Region, f.Message)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// WriteCSV emits one row per finding.
+func WriteCSV(w io.Writer, findings []Finding) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rule", "severity", "resource", "name", "region", "message"})
+	for _, f := range findings {
+		cw.Write([]string{f.Rule, string(f.Severity), f.Resource, f.Name, f.Region, f.Message})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// inventoryAllRegions loads the inventory of every cached region, keeping
+// global and cross-region items (IAM, S3) only once.
+func inventoryAllRegions() ([]sync.InventoryItem, error) {
+	regions, err := sync.CachedRegions()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var out []sync.InventoryItem
+	for _, region := range regions {
+		items, err := sync.LoadInventory(region)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			k := it.Region + "|" + it.Key()
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			out = append(out, it)
+		}
+	}
+	return out, nil
+}
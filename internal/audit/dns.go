@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// checkDanglingDNS flags public Route 53 records that point at resources
+// the cache says are gone: released Elastic IPs, deleted load balancers,
+// and S3 website buckets that no longer exist. Each check only runs when
+// the data it compares against has been synced, so an unsynced region
+// never looks like a deleted one.
+func checkDanglingDNS(string) ([]Finding, error) {
+	zones, err := sync.LoadHostedZones()
+	if err != nil || len(zones) == 0 {
+		return nil, err
+	}
+	regions, err := sync.CachedRegions()
+	if err != nil {
+		return nil, err
+	}
+
+	// Elastic IPs held now, and every one seen before with its region.
+	current := map[string]bool{}
+	released := map[string]sync.EIPSighting{}
+	releasedRegion := map[string]string{}
+	for _, r := range regions {
+		eips, _ := sync.LoadElasticIPs(r)
+		for _, e := range eips {
+			current[e.PublicIp] = true
+		}
+		history, _ := sync.LoadEIPHistory(r)
+		for _, h := range history {
+			released[h.PublicIp] = h
+			releasedRegion[h.PublicIp] = r
+		}
+	}
+
+	var buckets map[string]bool
+	if s3, err := sync.LoadS3DataEnriched(); err == nil && s3 != nil {
+		buckets = map[string]bool{}
+		for _, b := range s3.Buckets {
+			buckets[b.Name] = true
+		}
+	}
+	lbCache := map[string]map[string]bool{} // region → lowercase DNS names; nil when not synced
+	lbNames := func(region string) map[string]bool {
+		if names, ok := lbCache[region]; ok {
+			return names
+		}
+		var names map[string]bool
+		if sync.CacheExists(region + ":load-balancers") {
+			names = map[string]bool{}
+			if v, err := sync.LoadVPCData(region); err == nil && v != nil {
+				for _, lb := range v.LoadBalancers {
+					names[strings.ToLower(lb.DNSName)] = true
+				}
+			}
+		}
+		lbCache[region] = names
+		return names
+	}
+
+	var out []Finding
+	flag := func(rec sync.DNSRecord, msg string) {
+		out = append(out, Finding{
+			Severity: High,
+			Resource: "route53/" + rec.Name,
+			Name:     rec.Name + " " + rec.Type,
+			Region:   "global",
+			Message:  msg,
+		})
+	}
+	for _, z := range zones {
+		if z.Private {
+			continue
+		}
+		for _, rec := range z.Records {
+			targets := rec.Values
+			if rec.AliasTarget != "" {
+				targets = []string{rec.AliasTarget}
+			}
+			for _, t := range targets {
+				t = strings.ToLower(t)
+				switch {
+				case rec.Type == "A" && rec.AliasTarget == "":
+					if h, ok := released[t]; ok && !current[t] {
+						seen, _, _ := strings.Cut(h.LastSeen, "T")
+						flag(rec, "points at released Elastic IP "+t+" (last seen in "+releasedRegion[t]+" on "+seen+")")
+					}
+				case strings.HasSuffix(t, ".amazonaws.com") && strings.Contains(t, ".elb."):
+					region := elbRegion(t)
+					names := lbNames(region)
+					if names != nil && !names[strings.TrimPrefix(t, "dualstack.")] {
+						flag(rec, "points at load balancer "+t+" which no longer exists")
+					}
+				case strings.Contains(t, "s3-website"):
+					bucket := s3WebsiteBucket(rec.Name, t)
+					if buckets != nil && !buckets[bucket] {
+						flag(rec, "points at S3 website bucket "+bucket+" which does not exist in this account")
+					}
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// elbRegion extracts the region from an ELB hostname:
+// name-123.us-east-1.elb.amazonaws.com (ALB/CLB) or
+// name-123.elb.us-east-1.amazonaws.com (NLB).
+func elbRegion(host string) string {
+	labels := strings.Split(strings.TrimSuffix(host, ".amazonaws.com"), ".")
+	n := len(labels)
+	switch {
+	case n >= 2 && labels[n-1] == "elb":
+		return labels[n-2]
+	case n >= 2 && labels[n-2] == "elb":
+		return labels[n-1]
+	}
+	return ""
+}
+
+// s3WebsiteBucket returns the bucket a website record serves. A CNAME
+// names it (bucket.s3-website-us-east-1.amazonaws.com); an alias points at
+// the regional endpoint and the bucket must match the record name.
+func s3WebsiteBucket(record, target string) string {
+	if i := strings.Index(target, ".s3-website"); i > 0 {
+		return target[:i]
+	}
+	return strings.ToLower(record)
+}
@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// ServiceEncryption counts resources of one service by encryption state.
+// Unknown covers resources synced before encryption was recorded.
+type ServiceEncryption struct {
+	Service     string `json:"service"`
+	Total       int    `json:"total"`
+	Encrypted   int    `json:"encrypted"`
+	Unencrypted int    `json:"unencrypted"`
+	Unknown     int    `json:"unknown"`
+}
+
+// EncryptionReport is the at-rest encryption state of every cached data
+// store in a region (S3 buckets are account-wide).
+type EncryptionReport struct {
+	Services    []ServiceEncryption `json:"services"`
+	Unencrypted []Finding           `json:"unencrypted"`
+}
+
+// encryptionState is the result of checking one resource.
+type encryptionState int
+
+const (
+	stateUnknown encryptionState = iota
+	stateEncrypted
+	stateUnencrypted
+)
+
+func boolState(encrypted bool) encryptionState {
+	if encrypted {
+		return stateEncrypted
+	}
+	return stateUnencrypted
+}
+
+// Encryption builds the report from the cache for region.
+func Encryption(region string) EncryptionReport {
+	var rep EncryptionReport
+	var cur *ServiceEncryption
+	section := func(name string) {
+		rep.Services = append(rep.Services, ServiceEncryption{Service: name})
+		cur = &rep.Services[len(rep.Services)-1]
+	}
+	add := func(state encryptionState, sev Severity, resource, name, where, msg string) {
+		cur.Total++
+		switch state {
+		case stateEncrypted:
+			cur.Encrypted++
+		case stateUnknown:
+			cur.Unknown++
+		case stateUnencrypted:
+			cur.Unencrypted++
+			rep.Unencrypted = append(rep.Unencrypted, Finding{
+				Rule: "encryption-at-rest", Severity: sev, Resource: resource, Name: name, Region: where, Message: msg,
+			})
+		}
+	}
+
+	section("EBS")
+	if vols, err := sync.LoadEBSVolumes(region); err == nil {
+		for _, v := range vols {
+			msg := fmt.Sprintf("%d GiB %s volume not encrypted", v.Size, v.VolumeType)
+			if v.InstanceId != "" {
+				msg += ", attached to " + v.InstanceId
+			}
+			add(boolState(v.Encrypted), Medium, "ebs/"+v.VolumeId, v.VolumeId, region, msg)
+		}
+	}
+
+	db, _ := sync.LoadDatabaseData(region)
+	section("RDS")
+	if db != nil {
+		for _, r := range db.RDS {
+			// DocumentDB and Neptune instances are counted per cluster below.
+			if r.Engine == "docdb" || r.Engine == "neptune" {
+				continue
+			}
+			add(boolState(r.StorageEncrypted), High, "rds/"+r.DBInstanceId, r.DBInstanceId, region,
+				"storage not encrypted (can only be fixed by restoring an encrypted snapshot)")
+		}
+	}
+	for _, fam := range []struct{ service, family string }{{"DocumentDB", "docdb"}, {"Neptune", "neptune"}} {
+		section(fam.service)
+		if db != nil {
+			for _, c := range db.ClustersOf(fam.family) {
+				add(boolState(c.StorageEncrypted), High, fam.family+"/"+c.ClusterId, c.ClusterId, region,
+					"cluster storage not encrypted")
+			}
+		}
+	}
+	// DynamoDB encrypts every table; only the key owner differs.
+	section("DynamoDB")
+	if db != nil {
+		for _, t := range db.DynamoDB {
+			add(stateEncrypted, Low, "dynamodb/"+t.TableName, t.TableName, region, "")
+		}
+	}
+
+	section("S3")
+	if s3, err := sync.LoadS3DataEnriched(); err == nil && s3 != nil {
+		for _, b := range s3.Buckets {
+			state := stateUnknown
+			switch b.Encryption {
+			case "none":
+				state = stateUnencrypted
+			case "":
+			default:
+				state = stateEncrypted
+			}
+			add(state, High, "s3/"+b.Name, b.Name, b.Region, "no default encryption configured")
+		}
+	}
+
+	fs, _ := sync.LoadStorageData(region)
+	section("EFS")
+	if fs != nil {
+		for _, f := range fs.EFS {
+			add(boolState(f.Encrypted), High, "efs/"+f.FileSystemId, f.FileSystemId, region,
+				"file system not encrypted (encryption can only be set at creation)")
+		}
+	}
+	// FSx always encrypts at rest.
+	section("FSx")
+	if fs != nil {
+		for _, f := range fs.FSx {
+			add(stateEncrypted, Low, "fsx/"+f.FileSystemId, f.FileSystemId, region, "")
+		}
+	}
+
+	st, _ := sync.LoadStreamingData(region)
+	section("SQS")
+	if st != nil {
+		for _, q := range st.SQS {
+			state := stateUnknown
+			switch q.Encryption {
+			case "none":
+				state = stateUnencrypted
+			case "":
+			default:
+				state = stateEncrypted
+			}
+			add(state, Medium, "sqs/"+q.QueueName, q.QueueName, region, "server-side encryption disabled")
+		}
+	}
+	section("SNS")
+	if st != nil {
+		for _, t := range st.SNS {
+			add(boolState(t.KmsKeyId != ""), Low, "sns/"+t.Name, t.Name, region, "no KMS key for server-side encryption")
+		}
+	}
+
+	section("Redshift")
+	if dw, err := sync.LoadDataWarehouseData(region); err == nil && dw != nil {
+		for _, c := range dw.Redshift {
+			add(boolState(c.Encrypted), High, "redshift/"+c.ClusterIdentifier, c.ClusterIdentifier, region, "cluster not encrypted")
+		}
+	}
+
+	// Drop services with nothing cached so the report stays short.
+	services := rep.Services[:0]
+	for _, s := range rep.Services {
+		if s.Total > 0 {
+			services = append(services, s)
+		}
+	}
+	rep.Services = services
+	return rep
+}
+
+// checkEncryption feeds unencrypted resources into the audit.
+func checkEncryption(region string) ([]Finding, error) {
+	return Encryption(region).Unencrypted, nil
+}
+
+// WriteEncryptionText prints per-service counts followed by every
+// unencrypted resource.
+func WriteEncryptionText(w io.Writer, rep EncryptionReport) {
+	if len(rep.Services) == 0 {
+		fmt.Fprintln(w, "No cached data stores. Run 'saws sync' first.")
+		return
+	}
+	fmt.Fprintf(w, "%-10s %7s %10s %12s %8s\n", "Service", "Total", "Encrypted", "Unencrypted", "Unknown")
+	var total, unenc int
+	for _, s := range rep.Services {
+		fmt.Fprintf(w, "%-10s %7d %10d %12d %8d\n", s.Service, s.Total, s.Encrypted, s.Unencrypted, s.Unknown)
+		total += s.Total
+		unenc += s.Unencrypted
+	}
+	fmt.Fprintf(w, "\n%d of %d resources unencrypted at rest\n", unenc, total)
+	if len(rep.Unencrypted) > 0 {
+		fmt.Fprintln(w, "\nUnencrypted resources:")
+		for _, f := range rep.Unencrypted {
+			fmt.Fprintf(w, "  %-6s %-40s %-14s %s\n", f.Severity, f.Resource, f.Region, f.Message)
+		}
+	}
+	if hasUnknown(rep) {
+		fmt.Fprintln(w, "\nUnknown: synced before encryption was recorded; re-run 'saws sync'.")
+	}
+}
+
+// WriteEncryptionCSV emits the per-service counts.
+func WriteEncryptionCSV(w io.Writer, rep EncryptionReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"service", "total", "encrypted", "unencrypted", "unknown"})
+	for _, s := range rep.Services {
+		cw.Write([]string{s.Service, strconv.Itoa(s.Total), strconv.Itoa(s.Encrypted),
+			strconv.Itoa(s.Unencrypted), strconv.Itoa(s.Unknown)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func hasUnknown(rep EncryptionReport) bool {
+	for _, s := range rep.Services {
+		if s.Unknown > 0 {
+			return true
+		}
+	}
+	return false
+}
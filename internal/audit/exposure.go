@@ -0,0 +1,235 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// ExposureReport lists what can be reached or copied from outside the
+// account: publicly accessible data stores, and AMIs or snapshots that
+// are public or shared with accounts not in known_accounts. Network is
+// ports security groups open to the internet on interfaces with a public
+// IP. External is IAM Access Analyzer's own view of resource policies.
+type ExposureReport struct {
+	Public   []Finding `json:"public"`
+	Network  []Finding `json:"network"`
+	Shared   []Finding `json:"shared"`
+	External []Finding `json:"external"`
+}
+
+// Exposure builds the report from the cache for region (S3 buckets are
+// account-wide).
+func Exposure(region string) ExposureReport {
+	var rep ExposureReport
+	public := func(sev Severity, resource, name, where, msg string) {
+		rep.Public = append(rep.Public, Finding{
+			Rule: "public-access", Severity: sev, Resource: resource, Name: name, Region: where, Message: msg,
+		})
+	}
+
+	if s3, err := sync.LoadS3DataEnriched(); err == nil && s3 != nil {
+		for _, b := range s3.Buckets {
+			switch {
+			case b.PolicyPublic:
+				public(High, "s3/"+b.Name, b.Name, b.Region, "bucket policy allows public access")
+			case b.ACLPublic:
+				public(High, "s3/"+b.Name, b.Name, b.Region, "bucket ACL grants public access")
+			}
+			for _, ap := range b.AccessPoints {
+				switch {
+				case ap.PolicyPublic:
+					public(High, "s3/"+b.Name, b.Name, b.Region, "access point "+ap.Name+" policy allows public access")
+				case !ap.VPCOnly():
+					if others := foreignPrincipals(ap.Policies, arnAccount(ap.Arn)); len(others) > 0 {
+						public(Medium, "s3/"+b.Name, b.Name, b.Region, "internet access point "+ap.Name+" grants "+strings.Join(others, ", "))
+					}
+				}
+			}
+		}
+		for _, m := range s3.MRAPs {
+			if m.PolicyPublic {
+				public(High, "s3-mrap/"+m.Name, m.Name, "global", "multi-region access point policy allows public access")
+			}
+		}
+	}
+	if db, err := sync.LoadDatabaseData(region); err == nil && db != nil {
+		for _, r := range db.RDS {
+			if r.PubliclyAccessible {
+				public(High, "rds/"+r.DBInstanceId, r.DBInstanceId, region, "publicly accessible endpoint")
+			}
+		}
+	}
+	if dw, err := sync.LoadDataWarehouseData(region); err == nil && dw != nil {
+		for _, c := range dw.Redshift {
+			if c.PubliclyAccessible {
+				public(High, "redshift/"+c.ClusterIdentifier, c.ClusterIdentifier, region, "publicly accessible endpoint")
+			}
+		}
+		for _, w := range dw.RedshiftWorkgroups {
+			if w.PubliclyAccessible {
+				public(High, "redshift-serverless/"+w.WorkgroupName, w.WorkgroupName, region, "publicly accessible workgroup endpoint")
+			}
+		}
+		for _, d := range dw.OpenSearch {
+			if d.PubliclyAccessible {
+				public(Medium, "opensearch/"+d.DomainName, d.DomainName, region, "internet endpoint instead of VPC")
+			}
+		}
+	}
+	if st, err := sync.LoadStreamingData(region); err == nil && st != nil {
+		for _, b := range st.MQ {
+			if b.PubliclyAccessible {
+				public(Medium, "mq/"+b.BrokerId, b.BrokerName, region, "publicly accessible broker")
+			}
+		}
+	}
+
+	if groups, _ := sync.LoadSGRules(region); groups != nil {
+		if vpc, err := sync.LoadVPCData(region); err == nil && vpc != nil {
+			rep.Network = internetFindings(region, Reachability(vpc.ENIs, groups))
+		}
+	}
+
+	rep.Shared = sharingFindings(region)
+	rep.External = externalAccessFindings(region)
+	return rep
+}
+
+// externalAccessFindings turns active Access Analyzer findings into
+// exposure findings: High when public, Medium when granted to another
+// account.
+func externalAccessFindings(region string) []Finding {
+	access, _ := sync.LoadExternalAccess(region)
+	var out []Finding
+	for _, a := range access {
+		f := Finding{
+			Rule:     "external-access",
+			Severity: Medium,
+			Resource: a.Resource,
+			Name:     a.DetailID(),
+			Region:   region,
+			Message:  a.Grantee() + " can " + strings.Join(a.Actions, ", "),
+		}
+		if typ := a.DetailType(); typ != "" {
+			f.Resource = typ + "/" + a.DetailID()
+		}
+		if a.IsPublic {
+			f.Severity = High
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// foreignPrincipals returns the principals an Allow statement grants that
+// belong to neither owner nor known_accounts. Service principals are
+// skipped.
+func foreignPrincipals(policies []sync.ResourcePolicy, owner string) []string {
+	known := sync.KnownAccounts()
+	var out []string
+	for _, p := range policies {
+		if p.Effect != "Allow" || p.Principal == "" || p.Principal == "*" {
+			continue
+		}
+		account := p.Principal
+		if strings.HasPrefix(account, "arn:") {
+			account = arnAccount(account)
+		}
+		if account == "" || strings.Contains(account, ".") || account == owner || known[account] {
+			continue
+		}
+		out = append(out, p.Principal)
+	}
+	return out
+}
+
+// arnAccount returns the account ID field of an ARN.
+func arnAccount(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 5 {
+		return ""
+	}
+	return parts[4]
+}
+
+// sharingFindings flags public AMIs and snapshots, and those shared with
+// accounts outside known_accounts.
+func sharingFindings(region string) []Finding {
+	artifacts, _ := sync.LoadSharedArtifacts(region)
+	known := sync.KnownAccounts()
+	var out []Finding
+	for _, a := range artifacts {
+		f := Finding{Rule: "public-sharing", Resource: a.Resource(), Name: a.Name, Region: region}
+		var unknown []string
+		for _, id := range a.SharedWith {
+			if !known[id] {
+				unknown = append(unknown, id)
+			}
+		}
+		switch {
+		case a.Public:
+			f.Severity = High
+			f.Message = "public: any AWS account can " + artifactVerb(a.Kind) + " it"
+		case len(unknown) > 0:
+			f.Severity = Medium
+			f.Message = fmt.Sprintf("shared with unknown account%s %s", plural(len(unknown)), strings.Join(unknown, ", "))
+		default:
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func artifactVerb(kind string) string {
+	if kind == "ami" {
+		return "launch"
+	}
+	return "restore"
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// checkSharing feeds public and unknown-account sharing into the audit.
+func checkSharing(region string) ([]Finding, error) {
+	return sharingFindings(region), nil
+}
+
+// WriteExposureText prints public data stores, internet-facing ports,
+// shared AMIs and snapshots, then Access Analyzer findings.
+func WriteExposureText(w io.Writer, rep ExposureReport) {
+	if len(rep.Public) == 0 && len(rep.Network) == 0 && len(rep.Shared) == 0 && len(rep.External) == 0 {
+		fmt.Fprintln(w, "No exposure found in the cache.")
+		return
+	}
+	sections := []struct {
+		title    string
+		findings []Finding
+	}{
+		{"Publicly accessible", rep.Public},
+		{"Open to the internet (security groups)", rep.Network},
+		{"Public or shared AMIs and snapshots", rep.Shared},
+		{"External access (IAM Access Analyzer)", rep.External},
+	}
+	for _, s := range sections {
+		if len(s.findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d)\n", s.title, len(s.findings))
+		for _, f := range s.findings {
+			fmt.Fprintf(w, "  %-6s %-40s %-14s %s\n", f.Severity, f.Resource, f.Region, f.Message)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(rep.Shared) > 0 && len(sync.KnownAccounts()) == 0 {
+		fmt.Fprintln(w, "Tip: list partner accounts with 'saws config set known_accounts <id>,<id>' to stop flagging them.")
+	}
+}
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// Path is one way traffic can reach a network interface through the
+// security groups attached to it. From is "internet" for an open CIDR on
+// an interface with a public IP, otherwise the CIDR, prefix list or
+// security group the inbound rule names.
+type Path struct {
+	From     string `json:"from"`
+	To       string `json:"to"` // the instance or service the interface belongs to
+	ENI      string `json:"eni"`
+	Group    string `json:"group"` // the target's group whose rule allows it
+	Protocol string `json:"protocol"`
+	Ports    string `json:"ports"`
+}
+
+// Internet reports whether the path is open to anyone.
+func (p Path) Internet() bool { return p.From == "internet" }
+
+func (p Path) key() string {
+	return p.From + "|" + p.ENI + "|" + p.Protocol + "|" + p.Ports
+}
+
+// Resource is the "type/id" key of the path's target for findings:
+// the instance when the interface belongs to one, else the group.
+func (p Path) Resource() string {
+	if strings.HasPrefix(p.To, "i-") {
+		return "ec2/" + p.To
+	}
+	return "sg/" + p.Group
+}
+
+// Reachability lists every inbound path to the region's network interfaces
+// under the given security group rules. A rule naming another group only
+// counts when that group's outbound rules let the traffic leave, so paths
+// carry the ports both sides allow.
+func Reachability(enis []sync.ENI, groups map[string]*sync.SGRules) []Path {
+	seen := map[string]bool{}
+	var out []Path
+	add := func(p Path) {
+		if !seen[p.key()] {
+			seen[p.key()] = true
+			out = append(out, p)
+		}
+	}
+	for _, eni := range enis {
+		to := eni.AttachedTo
+		if to == "" {
+			to = eni.NetworkInterfaceId
+		}
+		for _, gid := range eni.SecurityGroups {
+			g := groups[gid]
+			if g == nil {
+				continue
+			}
+			for _, in := range g.Inbound {
+				p := Path{To: to, ENI: eni.NetworkInterfaceId, Group: gid}
+				switch {
+				case openCIDR(in.Peer):
+					p.From = in.Peer
+					if eni.PublicIp != "" {
+						p.From = "internet"
+					}
+					p.Protocol, p.Ports = in.ProtocolName(), in.Ports()
+					add(p)
+				case strings.HasPrefix(in.Peer, "sg-"):
+					src := groups[in.Peer]
+					if src == nil {
+						continue
+					}
+					for _, eg := range src.Outbound {
+						if !egressCovers(eg.Peer, eni) {
+							continue
+						}
+						if r, ok := intersectRules(in, eg); ok {
+							p.From = in.Peer
+							p.Protocol, p.Ports = r.ProtocolName(), r.Ports()
+							add(p)
+						}
+					}
+				default:
+					p.From = in.Peer
+					p.Protocol, p.Ports = in.ProtocolName(), in.Ports()
+					add(p)
+				}
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].To != out[j].To {
+			return out[i].To < out[j].To
+		}
+		return out[i].key() < out[j].key()
+	})
+	return out
+}
+
+func openCIDR(peer string) bool {
+	return peer == "0.0.0.0/0" || peer == "::/0"
+}
+
+// egressCovers reports whether an outbound rule's peer includes the
+// interface: an open CIDR, one of its groups, or a CIDR holding its IP.
+func egressCovers(peer string, eni sync.ENI) bool {
+	if openCIDR(peer) {
+		return true
+	}
+	if strings.HasPrefix(peer, "sg-") {
+		for _, g := range eni.SecurityGroups {
+			if g == peer {
+				return true
+			}
+		}
+		return false
+	}
+	prefix, err := netip.ParsePrefix(peer)
+	if err != nil {
+		return false
+	}
+	ip, err := netip.ParseAddr(eni.PrivateIp)
+	return err == nil && prefix.Contains(ip)
+}
+
+// intersectRules returns the traffic both rules allow, if any.
+func intersectRules(a, b sync.SGRule) (sync.SGRule, bool) {
+	r := sync.SGRule{FromPort: -1, ToPort: -1}
+	switch {
+	case a.Protocol == "-1":
+		r.Protocol = b.Protocol
+	case b.Protocol == "-1" || a.Protocol == b.Protocol:
+		r.Protocol = a.Protocol
+	default:
+		return r, false
+	}
+	if r.Protocol == "-1" {
+		return r, true
+	}
+	switch {
+	case a.FromPort < 0 || a.Protocol == "-1":
+		r.FromPort, r.ToPort = b.FromPort, b.ToPort
+	case b.FromPort < 0 || b.Protocol == "-1":
+		r.FromPort, r.ToPort = a.FromPort, a.ToPort
+	default:
+		r.FromPort, r.ToPort = max(a.FromPort, b.FromPort), min(a.ToPort, b.ToPort)
+		if r.FromPort > r.ToPort {
+			return r, false
+		}
+	}
+	return r, true
+}
+
+// sensitivePorts are admin and data store ports that should never face
+// the internet.
+var sensitivePorts = []int{22, 3389, 3306, 5432, 1433, 1521, 6379, 9200, 11211, 27017}
+
+// internetFindings rates each internet-facing path: High for all ports or
+// an admin/database port, Low for plain web traffic, Medium otherwise.
+func internetFindings(region string, paths []Path) []Finding {
+	var out []Finding
+	for _, p := range paths {
+		if !p.Internet() {
+			continue
+		}
+		f := Finding{Rule: "open-port", Severity: Medium, Resource: p.Resource(), Name: p.To, Region: region,
+			Message: p.Protocol + "/" + p.Ports + " open to the internet via " + p.Group}
+		r, _ := sync.ParseSGRule(p.Protocol + " " + p.Ports + " 0.0.0.0/0")
+		switch {
+		case r.Protocol == "-1" || r.FromPort < 0:
+			f.Severity = High
+		case r.Protocol == "tcp" && (r.FromPort == 80 || r.FromPort == 443) && r.FromPort == r.ToPort:
+			f.Severity = Low
+		default:
+			for _, port := range sensitivePorts {
+				if port >= r.FromPort && port <= r.ToPort {
+					f.Severity = High
+					break
+				}
+			}
+		}
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// checkResidency flags resources in any cached region that the
+// allowed_regions setting does not permit. It is account-wide: data
+// residency is about where anything lives, not the region being viewed.
+func checkResidency(string) ([]Finding, error) {
+	allowed := sync.AllowedRegions()
+	if len(allowed) == 0 {
+		return nil, nil
+	}
+	items, err := inventoryAllRegions()
+	if err != nil {
+		return nil, err
+	}
+	var out []Finding
+	for _, it := range items {
+		if it.Region == "" || sync.RegionAllowed(it.Region) {
+			continue
+		}
+		sev := Medium
+		// Stored data leaving the approved regions is the real residency risk.
+		if dataStoreTypes[it.Type] {
+			sev = High
+		}
+		out = append(out, Finding{
+			Severity: sev,
+			Resource: it.Key(),
+			Name:     it.Name,
+			Region:   it.Region,
+			Message:  "not in allowed regions (" + strings.Join(allowed, ", ") + ")",
+		})
+	}
+	return out, nil
+}
+
+// dataStoreTypes are inventory types that hold customer data at rest.
+var dataStoreTypes = map[string]bool{
+	"s3": true, "ebs": true, "rds": true, "rds-cluster": true, "docdb": true, "neptune": true, "dynamodb": true, "elasticache": true, "redshift": true, "redshift-serverless": true,
+	"opensearch": true, "efs": true, "fsx": true, "glue": true, "kinesis": true, "sqs": true, "msk": true, "mq": true,
+}
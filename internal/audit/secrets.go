@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// checkSecrets flags Secrets Manager secrets that have never been rotated
+// and secrets nobody has read in sync.SecretStaleDays, which are usually
+// leftovers that still grant access to whatever they hold.
+func checkSecrets(region string) ([]Finding, error) {
+	secrets, err := sync.LoadSecrets(region)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var out []Finding
+	for _, s := range secrets {
+		if s.NeverRotated() {
+			msg := "rotation disabled, never rotated"
+			if s.RotationEnabled {
+				msg = "rotation enabled but has never completed"
+			}
+			if days := s.DaysSinceRotation(now); days >= 0 {
+				msg += fmt.Sprintf(" (created %d days ago)", days)
+			}
+			out = append(out, Finding{Severity: Medium, Resource: "secret/" + s.Name, Name: s.Name, Region: region, Message: msg})
+		}
+		if days := s.DaysSinceAccess(now); days >= sync.SecretStaleDays {
+			msg := fmt.Sprintf("not accessed in %d days", days)
+			if s.LastAccessed == "" {
+				msg = fmt.Sprintf("never accessed (created %d days ago)", days)
+			}
+			out = append(out, Finding{Severity: Low, Resource: "secret/" + s.Name, Name: s.Name, Region: region, Message: msg})
+		}
+	}
+	return out, nil
+}
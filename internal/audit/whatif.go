@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// SGChange is a hypothetical edit to one security group rule.
+type SGChange struct {
+	Group     string      `json:"group"`
+	Remove    bool        `json:"remove"`
+	Direction string      `json:"direction"` // "in" or "out"
+	Rule      sync.SGRule `json:"rule"`
+}
+
+func (c SGChange) String() string {
+	verb := "add"
+	if c.Remove {
+		verb = "remove"
+	}
+	return c.Group + " " + verb + " " + c.Direction + " " + c.Rule.String()
+}
+
+// ParseSGChange reads "[sg-id] add|remove in|out <protocol> <ports> <source>",
+// e.g. "add in tcp 22 0.0.0.0/0". The group defaults to group.
+func ParseSGChange(group, line string) (SGChange, error) {
+	f := strings.Fields(line)
+	if len(f) > 0 && strings.HasPrefix(f[0], "sg-") {
+		group, f = f[0], f[1:]
+	}
+	if group == "" {
+		return SGChange{}, fmt.Errorf("%q: no security group", line)
+	}
+	if len(f) != 5 {
+		return SGChange{}, fmt.Errorf("%q: expected \"add|remove in|out <protocol> <ports> <source>\"", line)
+	}
+	c := SGChange{Group: group, Direction: f[1]}
+	switch f[0] {
+	case "add":
+	case "remove":
+		c.Remove = true
+	default:
+		return SGChange{}, fmt.Errorf("%q: expected add or remove, got %q", line, f[0])
+	}
+	if c.Direction != "in" && c.Direction != "out" {
+		return SGChange{}, fmt.Errorf("%q: expected in or out, got %q", line, f[1])
+	}
+	r, err := sync.ParseSGRule(strings.Join(f[2:], " "))
+	if err != nil {
+		return SGChange{}, fmt.Errorf("%q: %w", line, err)
+	}
+	c.Rule = r
+	return c, nil
+}
+
+// WhatIfResult compares reachability and internet exposure before and
+// after a set of staged changes.
+type WhatIfResult struct {
+	Changes  []SGChange `json:"changes"`
+	Opened   []Path     `json:"opened"`   // paths the changes would allow
+	Closed   []Path     `json:"closed"`   // paths the changes would break
+	Exposed  []Finding  `json:"exposed"`  // new internet exposure
+	Resolved []Finding  `json:"resolved"` // internet exposure that would go away
+}
+
+// WhatIf applies changes to an in-memory copy of the region's cached
+// security groups and diffs the reachability and exposure analyses.
+// Nothing is sent to AWS. Removing a rule that does not exist is an error
+// so typos don't pass for a harmless change.
+func WhatIf(region string, changes []SGChange) (WhatIfResult, error) {
+	res := WhatIfResult{Changes: changes}
+	groups, err := sync.LoadSGRules(region)
+	if err != nil {
+		return res, err
+	}
+	if groups == nil {
+		return res, fmt.Errorf("no security groups cached for %s; run 'saws sync' first", region)
+	}
+	var enis []sync.ENI
+	if vpc, err := sync.LoadVPCData(region); err == nil && vpc != nil {
+		enis = vpc.ENIs
+	}
+
+	staged := map[string]*sync.SGRules{}
+	for id, g := range groups {
+		cp := *g
+		cp.Inbound = append([]sync.SGRule(nil), g.Inbound...)
+		cp.Outbound = append([]sync.SGRule(nil), g.Outbound...)
+		staged[id] = &cp
+	}
+	for _, c := range changes {
+		g := staged[c.Group]
+		if g == nil {
+			return res, fmt.Errorf("%s: security group not in the %s cache", c.Group, region)
+		}
+		rules := &g.Inbound
+		if c.Direction == "out" {
+			rules = &g.Outbound
+		}
+		if !c.Remove {
+			*rules = append(*rules, c.Rule)
+			continue
+		}
+		found := false
+		for i, r := range *rules {
+			if r == c.Rule {
+				*rules = append((*rules)[:i], (*rules)[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return res, fmt.Errorf("%s has no %sbound rule %s", c.Group, c.Direction, c.Rule)
+		}
+	}
+
+	before, after := Reachability(enis, groups), Reachability(enis, staged)
+	res.Opened, res.Closed = diffPaths(before, after), diffPaths(after, before)
+	res.Exposed = internetFindings(region, diffPaths(before, after))
+	res.Resolved = internetFindings(region, diffPaths(after, before))
+	return res, nil
+}
+
+// diffPaths returns the paths in b that are not in a.
+func diffPaths(a, b []Path) []Path {
+	in := map[string]bool{}
+	for _, p := range a {
+		in[p.key()] = true
+	}
+	var out []Path
+	for _, p := range b {
+		if !in[p.key()] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// WriteWhatIfText prints the staged changes and what they would open or
+// break.
+func WriteWhatIfText(w io.Writer, res WhatIfResult) {
+	fmt.Fprintln(w, "Staged changes")
+	for _, c := range res.Changes {
+		fmt.Fprintf(w, "  %s\n", c)
+	}
+	fmt.Fprintln(w)
+	if len(res.Opened) == 0 && len(res.Closed) == 0 {
+		fmt.Fprintln(w, "No change in reachability.")
+		return
+	}
+	sections := []struct {
+		title string
+		paths []Path
+	}{
+		{"Would open", res.Opened},
+		{"Would break", res.Closed},
+	}
+	for _, s := range sections {
+		if len(s.paths) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d)\n", s.title, len(s.paths))
+		for _, p := range s.paths {
+			fmt.Fprintf(w, "  %-24s -> %-30s %s/%s via %s\n", p.From, p.To, p.Protocol, p.Ports, p.Group)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, f := range res.Exposed {
+		fmt.Fprintf(w, "New exposure: %-6s %s %s\n", f.Severity, f.Name, f.Message)
+	}
+	for _, f := range res.Resolved {
+		fmt.Fprintf(w, "Resolved:     %-6s %s %s\n", f.Severity, f.Name, f.Message)
+	}
+}
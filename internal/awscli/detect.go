@@ -15,6 +15,13 @@ type Status struct {
 	Region    string `json:"region,omitempty"`
 	AccountID string `json:"accountId,omitempty"`
 	Profile   string `json:"profile,omitempty"`
+	// AuthError is the CLI's message when get-caller-identity fails, e.g.
+	// missing or expired credentials.
+	AuthError  string `json:"authError,omitempty"`
+	SSOExpired bool   `json:"ssoExpired,omitempty"`
+	// ReadOnly is set in read-only mode, where the CLI is never run and
+	// Installed is always false.
+	ReadOnly bool `json:"readOnly,omitempty"`
 }
 
 const cacheTTL = 60 * time.Second
@@ -24,6 +31,9 @@ func cacheFile() string {
 }
 
 func Detect() Status {
+	if readOnly {
+		return Status{ReadOnly: true}
+	}
 	// Try reading from cache
 	if info, err := os.Stat(cacheFile()); err == nil {
 		if time.Since(info.ModTime()) < cacheTTL {
@@ -85,7 +95,18 @@ func detect() Status {
 		if json.Unmarshal(identityOut, &identity) == nil {
 			s.AccountID = identity.Account
 		}
+	} else if exitErr, ok := err.(*exec.ExitError); ok {
+		s.AuthError = strings.TrimSpace(string(exitErr.Stderr))
+		s.SSOExpired = SSOExpired(s.AuthError)
 	}
 
 	return s
 }
+
+// SSOExpired recognises the CLI's errors for an expired or missing IAM
+// Identity Center session, which 'aws sso login' fixes.
+func SSOExpired(msg string) bool {
+	lower := strings.ToLower(msg)
+	return strings.Contains(lower, "sso") &&
+		(strings.Contains(lower, "expired") || strings.Contains(lower, "refresh failed") || strings.Contains(lower, "error loading sso token"))
+}
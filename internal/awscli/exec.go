@@ -2,12 +2,62 @@ package awscli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os/exec"
 )
 
+// Runner executes AWS CLI commands. Sync code goes through Run, so tests
+// can swap in a Fake instead of shelling out to the real CLI.
+type Runner interface {
+	Run(args ...string) (json.RawMessage, error)
+}
+
+// Default is the Runner that Run uses.
+var Default Runner = execRunner{}
+
+// Use makes r the Default runner and returns a func restoring the old one:
+//
+//	defer awscli.Use(fake)()
+func Use(r Runner) (restore func()) {
+	prev := Default
+	Default = r
+	return func() { Default = prev }
+}
+
 // Run executes an AWS CLI command and returns the raw JSON output.
 func Run(args ...string) (json.RawMessage, error) {
+	return Default.Run(args...)
+}
+
+// ErrReadOnly is what Run returns in read-only mode instead of calling AWS.
+var ErrReadOnly = errors.New("read-only mode: saws does not call AWS")
+
+// readOnly is set by SetReadOnly.
+var readOnly bool
+
+// SetReadOnly stops saws calling the AWS CLI for the rest of the process:
+// Run fails with ErrReadOnly and Detect reports the CLI unavailable.
+func SetReadOnly() {
+	readOnly = true
+	Default = readOnlyRunner{}
+}
+
+// ReadOnly reports whether SetReadOnly was called.
+func ReadOnly() bool {
+	return readOnly
+}
+
+type readOnlyRunner struct{}
+
+func (readOnlyRunner) Run(args ...string) (json.RawMessage, error) {
+	return nil, ErrReadOnly
+}
+
+// execRunner runs the aws binary on PATH.
+type execRunner struct{}
+
+func (execRunner) Run(args ...string) (json.RawMessage, error) {
 	args = append(args, "--output", "json")
 	cmd := exec.Command("aws", args...)
 	out, err := cmd.Output()
@@ -0,0 +1,116 @@
+package awscli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Fake is a Runner that serves canned JSON instead of calling AWS. A
+// response is registered against a command such as "ec2 describe-vpcs";
+// extra words narrow the match, e.g. "logs describe-log-groups --region
+// eu-west-1". The most specific registered command whose words all appear
+// in order in the call wins. Calls with no match fail the way the CLI
+// does when a service is unavailable.
+type Fake struct {
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	calls     [][]string
+}
+
+type fakeResponse struct {
+	body json.RawMessage
+	err  error
+}
+
+// NewFake returns a Fake with no responses registered.
+func NewFake() *Fake {
+	return &Fake{responses: map[string]fakeResponse{}}
+}
+
+// On serves body for command.
+func (f *Fake) On(command, body string) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[command] = fakeResponse{body: json.RawMessage(body)}
+	return f
+}
+
+// OnFile serves the contents of path for command, panicking if it can't
+// be read so a missing fixture fails loudly.
+func (f *Fake) OnFile(command, path string) *Fake {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		panic(err)
+	}
+	return f.On(command, string(b))
+}
+
+// Fail makes command return err.
+func (f *Fake) Fail(command string, err error) *Fake {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[command] = fakeResponse{err: err}
+	return f
+}
+
+// Calls returns every call made so far, without the "--output json" that
+// the real runner appends.
+func (f *Fake) Calls() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]string(nil), f.calls...)
+}
+
+// Called reports whether any call matched command.
+func (f *Fake) Called(command string) bool {
+	want := strings.Fields(command)
+	for _, args := range f.Calls() {
+		if matchWords(args, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) Run(args ...string) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, args)
+
+	best, bestLen := "", 0
+	for command := range f.responses {
+		words := strings.Fields(command)
+		if len(words) > bestLen && matchWords(args, words) {
+			best, bestLen = command, len(words)
+		}
+	}
+	if bestLen == 0 {
+		return nil, fmt.Errorf("aws %s: fake has no response for %q", first(args), strings.Join(args, " "))
+	}
+	r := f.responses[best]
+	if r.err != nil {
+		return nil, fmt.Errorf("aws %s: %w", first(args), r.err)
+	}
+	return r.body, nil
+}
+
+// matchWords reports whether words appear in args in order.
+func matchWords(args, words []string) bool {
+	i := 0
+	for _, a := range args {
+		if i < len(words) && a == words[i] {
+			i++
+		}
+	}
+	return i == len(words)
+}
+
+func first(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
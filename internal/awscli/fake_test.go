@@ -0,0 +1,51 @@
+package awscli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFakeMostSpecificMatch(t *testing.T) {
+	f := NewFake().
+		On("logs describe-log-groups", `{"logGroups": []}`).
+		On("logs describe-log-groups --region eu-west-1", `{"logGroups": [{"logGroupName": "/eu"}]}`)
+
+	got, err := f.Run("logs", "describe-log-groups", "--region", "eu-west-1")
+	if err != nil || !strings.Contains(string(got), "/eu") {
+		t.Errorf("eu-west-1 call = %s, %v; want the region-specific response", got, err)
+	}
+	got, err = f.Run("logs", "describe-log-groups", "--region", "us-east-1")
+	if err != nil || strings.Contains(string(got), "/eu") {
+		t.Errorf("us-east-1 call = %s, %v; want the general response", got, err)
+	}
+	if n := len(f.Calls()); n != 2 {
+		t.Errorf("recorded %d calls, want 2", n)
+	}
+}
+
+func TestFakeUnmatchedAndFail(t *testing.T) {
+	f := NewFake().Fail("s3api get-bucket-policy", errors.New("NoSuchBucketPolicy"))
+
+	if _, err := f.Run("s3api", "get-bucket-policy", "--bucket", "b"); err == nil || !strings.Contains(err.Error(), "NoSuchBucketPolicy") {
+		t.Errorf("failing command error = %v", err)
+	}
+	if _, err := f.Run("ec2", "describe-vpcs"); err == nil {
+		t.Error("unregistered command succeeded")
+	}
+	if !f.Called("s3api get-bucket-policy") || f.Called("ec2 describe-subnets") {
+		t.Error("Called doesn't reflect the calls made")
+	}
+}
+
+func TestUseRestores(t *testing.T) {
+	f := NewFake().On("sts get-caller-identity", `{"Account": "123456789012"}`)
+	restore := Use(f)
+	if out, err := Run("sts", "get-caller-identity"); err != nil || !strings.Contains(string(out), "123456789012") {
+		t.Errorf("Run through fake = %s, %v", out, err)
+	}
+	restore()
+	if Default == Runner(f) {
+		t.Error("restore left the fake installed")
+	}
+}
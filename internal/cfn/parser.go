@@ -17,20 +17,33 @@ type Template struct {
 
 type Resource struct {
 	Type       string                 `json:"type"`
+	DependsOn  []string               `json:"dependsOn,omitempty"`
 	Properties map[string]interface{} `json:"properties,omitempty"`
 }
 
 type rawTemplate struct {
 	AWSVersion  string                            `yaml:"AWSTemplateFormatVersion"`
 	Description string                            `yaml:"Description"`
-	Parameters  map[string]interface{}             `yaml:"Parameters"`
+	Parameters  map[string]interface{}             `yaml:"Parameters,omitempty"`
 	Resources   map[string]rawResource             `yaml:"Resources"`
-	Outputs     map[string]interface{}             `yaml:"Outputs"`
+	Outputs     map[string]interface{}             `yaml:"Outputs,omitempty"`
 }
 
 type rawResource struct {
 	Type       string                 `yaml:"Type"`
-	Properties map[string]interface{} `yaml:"Properties"`
+	DependsOn  dependsOn              `yaml:"DependsOn,omitempty"`
+	Properties map[string]interface{} `yaml:"Properties,omitempty"`
+}
+
+// dependsOn accepts CloudFormation's single name or list of names.
+type dependsOn []string
+
+func (d *dependsOn) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*d = dependsOn{n.Value}
+		return nil
+	}
+	return n.Decode((*[]string)(d))
 }
 
 func ParseFile(path string) (*Template, error) {
@@ -59,6 +72,7 @@ func Parse(data []byte, filename string) (*Template, error) {
 	for name, r := range raw.Resources {
 		t.Resources[name] = Resource{
 			Type:       r.Type,
+			DependsOn:  r.DependsOn,
 			Properties: r.Properties,
 		}
 	}
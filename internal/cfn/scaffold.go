@@ -0,0 +1,373 @@
+package cfn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scaffolds are the template kinds 'saws new' can generate.
+var Scaffolds = []string{"vpc", "fargate-service", "static-site"}
+
+// VPCParams describes a two-AZ VPC with public and private subnets.
+type VPCParams struct {
+	Name string
+	Cidr string // a /16 that does not overlap the cached VPCs
+	NAT  bool   // route private subnets through a NAT gateway
+}
+
+// FargateServiceParams describes a Fargate service behind a public ALB in
+// an existing VPC. Cluster is an existing ECS cluster, or "" to create one.
+type FargateServiceParams struct {
+	Name             string
+	VpcId            string
+	PublicSubnetIds  []string
+	PrivateSubnetIds []string
+	Cluster          string
+	Image            string
+	Port             int
+}
+
+// StaticSiteParams describes a private S3 bucket served by CloudFront.
+// Domain, CertificateArn and HostedZoneId are optional; without a domain
+// the site is served on the distribution's own hostname.
+type StaticSiteParams struct {
+	Name           string
+	Domain         string
+	CertificateArn string // ACM certificate in us-east-1
+	HostedZoneId   string
+}
+
+// Long-form intrinsics, so the output is plain YAML that Parse reads back.
+func ref(name string) map[string]interface{} { return map[string]interface{}{"Ref": name} }
+func sub(s string) map[string]interface{}    { return map[string]interface{}{"Fn::Sub": s} }
+func getAtt(res, attr string) map[string]interface{} {
+	return map[string]interface{}{"Fn::GetAtt": []string{res, attr}}
+}
+func az(i int) map[string]interface{} {
+	return map[string]interface{}{"Fn::Select": []interface{}{i, map[string]interface{}{"Fn::GetAZs": ""}}}
+}
+func nameTag(suffix string) []interface{} {
+	return []interface{}{map[string]interface{}{"Key": "Name", "Value": sub("${Name}" + suffix)}}
+}
+
+func param(typ, desc string, def interface{}) map[string]interface{} {
+	p := map[string]interface{}{"Type": typ, "Description": desc}
+	if def != nil && def != "" {
+		p["Default"] = def
+	}
+	return p
+}
+
+func output(desc string, value interface{}, export string) map[string]interface{} {
+	o := map[string]interface{}{"Description": desc, "Value": value}
+	if export != "" {
+		o["Export"] = map[string]interface{}{"Name": sub("${AWS::StackName}-" + export)}
+	}
+	return o
+}
+
+// WriteYAML renders a template as CloudFormation YAML.
+func (t *Template) WriteYAML(w io.Writer) error {
+	raw := rawTemplate{
+		AWSVersion:  t.AWSVersion,
+		Description: t.Description,
+		Parameters:  t.Parameters,
+		Outputs:     t.Outputs,
+		Resources:   map[string]rawResource{},
+	}
+	for name, r := range t.Resources {
+		raw.Resources[name] = rawResource{Type: r.Type, DependsOn: r.DependsOn, Properties: r.Properties}
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	defer enc.Close()
+	return enc.Encode(raw)
+}
+
+func newTemplate(desc string) *Template {
+	return &Template{
+		AWSVersion:  "2010-09-09",
+		Description: desc + " (generated by saws)",
+		Parameters:  map[string]interface{}{},
+		Resources:   map[string]Resource{},
+		Outputs:     map[string]interface{}{},
+	}
+}
+
+// ScaffoldVPC builds a VPC across two AZs with a public and a private
+// subnet in each, an internet gateway and, optionally, a NAT gateway.
+func ScaffoldVPC(p VPCParams) (*Template, error) {
+	if p.Name == "" || p.Cidr == "" {
+		return nil, fmt.Errorf("vpc needs a name and a CIDR")
+	}
+	t := newTemplate("VPC " + p.Name + " with public and private subnets in two AZs")
+	t.Parameters["Name"] = param("String", "Name tag prefix", p.Name)
+	t.Parameters["VpcCidr"] = param("String", "VPC CIDR block, split into /20 subnets", p.Cidr)
+
+	res := t.Resources
+	res["VPC"] = Resource{Type: "AWS::EC2::VPC", Properties: map[string]interface{}{
+		"CidrBlock": ref("VpcCidr"), "EnableDnsSupport": true, "EnableDnsHostnames": true, "Tags": nameTag(""),
+	}}
+	res["InternetGateway"] = Resource{Type: "AWS::EC2::InternetGateway", Properties: map[string]interface{}{"Tags": nameTag("-igw")}}
+	res["GatewayAttachment"] = Resource{Type: "AWS::EC2::VPCGatewayAttachment", Properties: map[string]interface{}{
+		"VpcId": ref("VPC"), "InternetGatewayId": ref("InternetGateway"),
+	}}
+	res["PublicRouteTable"] = Resource{Type: "AWS::EC2::RouteTable", Properties: map[string]interface{}{"VpcId": ref("VPC"), "Tags": nameTag("-public")}}
+	res["PublicDefaultRoute"] = Resource{Type: "AWS::EC2::Route", DependsOn: []string{"GatewayAttachment"}, Properties: map[string]interface{}{
+		"RouteTableId": ref("PublicRouteTable"), "DestinationCidrBlock": "0.0.0.0/0", "GatewayId": ref("InternetGateway"),
+	}}
+	res["PrivateRouteTable"] = Resource{Type: "AWS::EC2::RouteTable", Properties: map[string]interface{}{"VpcId": ref("VPC"), "Tags": nameTag("-private")}}
+
+	cidrs := map[string]interface{}{"Fn::Cidr": []interface{}{ref("VpcCidr"), 4, 12}}
+	var public, private []interface{}
+	for i := 0; i < 2; i++ {
+		for j, kind := range []string{"Public", "Private"} {
+			name := fmt.Sprintf("%sSubnet%d", kind, i+1)
+			props := map[string]interface{}{
+				"VpcId":            ref("VPC"),
+				"AvailabilityZone": az(i),
+				"CidrBlock":        map[string]interface{}{"Fn::Select": []interface{}{i*2 + j, cidrs}},
+				"Tags":             nameTag(fmt.Sprintf("-%s-%d", strings.ToLower(kind), i+1)),
+			}
+			if kind == "Public" {
+				props["MapPublicIpOnLaunch"] = true
+				public = append(public, ref(name))
+			} else {
+				private = append(private, ref(name))
+			}
+			res[name] = Resource{Type: "AWS::EC2::Subnet", Properties: props}
+			res[name+"RouteTableAssociation"] = Resource{Type: "AWS::EC2::SubnetRouteTableAssociation", Properties: map[string]interface{}{
+				"SubnetId": ref(name), "RouteTableId": ref(kind + "RouteTable"),
+			}}
+		}
+	}
+	if p.NAT {
+		res["NatEIP"] = Resource{Type: "AWS::EC2::EIP", DependsOn: []string{"GatewayAttachment"}, Properties: map[string]interface{}{"Domain": "vpc"}}
+		res["NatGateway"] = Resource{Type: "AWS::EC2::NatGateway", Properties: map[string]interface{}{
+			"AllocationId": getAtt("NatEIP", "AllocationId"), "SubnetId": ref("PublicSubnet1"), "Tags": nameTag("-nat"),
+		}}
+		res["PrivateDefaultRoute"] = Resource{Type: "AWS::EC2::Route", Properties: map[string]interface{}{
+			"RouteTableId": ref("PrivateRouteTable"), "DestinationCidrBlock": "0.0.0.0/0", "NatGatewayId": ref("NatGateway"),
+		}}
+	}
+
+	t.Outputs["VpcId"] = output("VPC ID", ref("VPC"), "VpcId")
+	t.Outputs["PublicSubnetIds"] = output("Public subnet IDs", map[string]interface{}{"Fn::Join": []interface{}{",", public}}, "PublicSubnetIds")
+	t.Outputs["PrivateSubnetIds"] = output("Private subnet IDs", map[string]interface{}{"Fn::Join": []interface{}{",", private}}, "PrivateSubnetIds")
+	return t, nil
+}
+
+// ScaffoldFargateService builds a Fargate service with its task
+// definition, log group, roles, security groups and a public ALB.
+func ScaffoldFargateService(p FargateServiceParams) (*Template, error) {
+	switch {
+	case p.Name == "":
+		return nil, fmt.Errorf("fargate-service needs a name")
+	case p.VpcId == "":
+		return nil, fmt.Errorf("fargate-service needs a VPC; sync one or pass --vpc")
+	case len(p.PublicSubnetIds) < 2:
+		return nil, fmt.Errorf("the load balancer needs public subnets in two AZs in %s", p.VpcId)
+	}
+	if len(p.PrivateSubnetIds) == 0 {
+		p.PrivateSubnetIds = p.PublicSubnetIds
+	}
+	t := newTemplate("Fargate service " + p.Name + " behind an Application Load Balancer")
+	t.Parameters["Name"] = param("String", "Service name", p.Name)
+	t.Parameters["VpcId"] = param("AWS::EC2::VPC::Id", "VPC for the service", p.VpcId)
+	t.Parameters["PublicSubnetIds"] = param("List<AWS::EC2::Subnet::Id>", "Public subnets for the load balancer", strings.Join(p.PublicSubnetIds, ","))
+	t.Parameters["ServiceSubnetIds"] = param("List<AWS::EC2::Subnet::Id>", "Subnets for the tasks", strings.Join(p.PrivateSubnetIds, ","))
+	t.Parameters["Image"] = param("String", "Container image", p.Image)
+	t.Parameters["ContainerPort"] = param("Number", "Port the container listens on", p.Port)
+	t.Parameters["Cpu"] = param("String", "Task CPU units", "256")
+	t.Parameters["Memory"] = param("String", "Task memory (MiB)", "512")
+	t.Parameters["DesiredCount"] = param("Number", "Number of tasks", 2)
+
+	res := t.Resources
+	cluster := ref("Cluster")
+	if p.Cluster != "" {
+		t.Parameters["ClusterName"] = param("String", "Existing ECS cluster", p.Cluster)
+		cluster = ref("ClusterName")
+	} else {
+		res["Cluster"] = Resource{Type: "AWS::ECS::Cluster", Properties: map[string]interface{}{
+			"ClusterName":     ref("Name"),
+			"ClusterSettings": []interface{}{map[string]interface{}{"Name": "containerInsights", "Value": "enabled"}},
+		}}
+	}
+	// A private task subnet reaches ECR and CloudWatch through NAT or VPC
+	// endpoints; with public subnets only, tasks get a public IP instead.
+	assignPublicIP := "DISABLED"
+	if strings.Join(p.PrivateSubnetIds, ",") == strings.Join(p.PublicSubnetIds, ",") {
+		assignPublicIP = "ENABLED"
+	}
+
+	res["LogGroup"] = Resource{Type: "AWS::Logs::LogGroup", Properties: map[string]interface{}{
+		"LogGroupName": sub("/ecs/${Name}"), "RetentionInDays": 30,
+	}}
+	assume := func(service string) map[string]interface{} {
+		return map[string]interface{}{"Version": "2012-10-17", "Statement": []interface{}{map[string]interface{}{
+			"Effect": "Allow", "Principal": map[string]interface{}{"Service": service}, "Action": "sts:AssumeRole",
+		}}}
+	}
+	res["ExecutionRole"] = Resource{Type: "AWS::IAM::Role", Properties: map[string]interface{}{
+		"AssumeRolePolicyDocument": assume("ecs-tasks.amazonaws.com"),
+		"ManagedPolicyArns":        []string{"arn:aws:iam::aws:policy/service-role/AmazonECSTaskExecutionRolePolicy"},
+	}}
+	res["TaskRole"] = Resource{Type: "AWS::IAM::Role", Properties: map[string]interface{}{
+		"AssumeRolePolicyDocument": assume("ecs-tasks.amazonaws.com"),
+	}}
+	res["TaskDefinition"] = Resource{Type: "AWS::ECS::TaskDefinition", Properties: map[string]interface{}{
+		"Family":                  ref("Name"),
+		"RequiresCompatibilities": []string{"FARGATE"},
+		"NetworkMode":             "awsvpc",
+		"Cpu":                     ref("Cpu"),
+		"Memory":                  ref("Memory"),
+		"ExecutionRoleArn":        getAtt("ExecutionRole", "Arn"),
+		"TaskRoleArn":             getAtt("TaskRole", "Arn"),
+		"ContainerDefinitions": []interface{}{map[string]interface{}{
+			"Name":         ref("Name"),
+			"Image":        ref("Image"),
+			"Essential":    true,
+			"PortMappings": []interface{}{map[string]interface{}{"ContainerPort": ref("ContainerPort")}},
+			"LogConfiguration": map[string]interface{}{"LogDriver": "awslogs", "Options": map[string]interface{}{
+				"awslogs-group": ref("LogGroup"), "awslogs-region": ref("AWS::Region"), "awslogs-stream-prefix": "app",
+			}},
+		}},
+	}}
+	res["LoadBalancerSecurityGroup"] = Resource{Type: "AWS::EC2::SecurityGroup", Properties: map[string]interface{}{
+		"GroupDescription": sub("${Name} load balancer"),
+		"VpcId":            ref("VpcId"),
+		"SecurityGroupIngress": []interface{}{map[string]interface{}{
+			"IpProtocol": "tcp", "FromPort": 80, "ToPort": 80, "CidrIp": "0.0.0.0/0",
+		}},
+	}}
+	res["ServiceSecurityGroup"] = Resource{Type: "AWS::EC2::SecurityGroup", Properties: map[string]interface{}{
+		"GroupDescription": sub("${Name} tasks"),
+		"VpcId":            ref("VpcId"),
+		"SecurityGroupIngress": []interface{}{map[string]interface{}{
+			"IpProtocol": "tcp", "FromPort": ref("ContainerPort"), "ToPort": ref("ContainerPort"),
+			"SourceSecurityGroupId": ref("LoadBalancerSecurityGroup"),
+		}},
+	}}
+	res["LoadBalancer"] = Resource{Type: "AWS::ElasticLoadBalancingV2::LoadBalancer", Properties: map[string]interface{}{
+		"Scheme": "internet-facing", "Type": "application",
+		"Subnets": ref("PublicSubnetIds"), "SecurityGroups": []interface{}{ref("LoadBalancerSecurityGroup")},
+	}}
+	res["TargetGroup"] = Resource{Type: "AWS::ElasticLoadBalancingV2::TargetGroup", Properties: map[string]interface{}{
+		"VpcId": ref("VpcId"), "Port": ref("ContainerPort"), "Protocol": "HTTP", "TargetType": "ip",
+		"HealthCheckPath": "/",
+	}}
+	res["Listener"] = Resource{Type: "AWS::ElasticLoadBalancingV2::Listener", Properties: map[string]interface{}{
+		"LoadBalancerArn": ref("LoadBalancer"), "Port": 80, "Protocol": "HTTP",
+		"DefaultActions": []interface{}{map[string]interface{}{"Type": "forward", "TargetGroupArn": ref("TargetGroup")}},
+	}}
+	res["Service"] = Resource{Type: "AWS::ECS::Service", DependsOn: []string{"Listener"}, Properties: map[string]interface{}{
+		"ServiceName":    ref("Name"),
+		"Cluster":        cluster,
+		"LaunchType":     "FARGATE",
+		"TaskDefinition": ref("TaskDefinition"),
+		"DesiredCount":   ref("DesiredCount"),
+		"NetworkConfiguration": map[string]interface{}{"AwsvpcConfiguration": map[string]interface{}{
+			"Subnets": ref("ServiceSubnetIds"), "SecurityGroups": []interface{}{ref("ServiceSecurityGroup")},
+			"AssignPublicIp": assignPublicIP,
+		}},
+		"LoadBalancers": []interface{}{map[string]interface{}{
+			"ContainerName": ref("Name"), "ContainerPort": ref("ContainerPort"), "TargetGroupArn": ref("TargetGroup"),
+		}},
+		"DeploymentConfiguration": map[string]interface{}{
+			"DeploymentCircuitBreaker": map[string]interface{}{"Enable": true, "Rollback": true},
+		},
+	}}
+
+	t.Outputs["ServiceUrl"] = output("Load balancer URL", sub("http://${LoadBalancer.DNSName}"), "")
+	return t, nil
+}
+
+// ScaffoldStaticSite builds a private, encrypted S3 bucket behind a
+// CloudFront distribution with origin access control, plus the alias
+// record when a hosted zone is known.
+func ScaffoldStaticSite(p StaticSiteParams) (*Template, error) {
+	if p.Name == "" {
+		return nil, fmt.Errorf("static-site needs a name")
+	}
+	if p.Domain != "" && p.CertificateArn == "" {
+		return nil, fmt.Errorf("no issued us-east-1 ACM certificate covers %s; request one or pass --certificate", p.Domain)
+	}
+	desc := "Static site " + p.Name + " on S3 and CloudFront"
+	if p.Domain != "" {
+		desc += " at " + p.Domain
+	}
+	t := newTemplate(desc)
+	t.Parameters["Name"] = param("String", "Site name", p.Name)
+
+	res := t.Resources
+	res["Bucket"] = Resource{Type: "AWS::S3::Bucket", Properties: map[string]interface{}{
+		"PublicAccessBlockConfiguration": map[string]interface{}{
+			"BlockPublicAcls": true, "BlockPublicPolicy": true, "IgnorePublicAcls": true, "RestrictPublicBuckets": true,
+		},
+		"BucketEncryption": map[string]interface{}{"ServerSideEncryptionConfiguration": []interface{}{map[string]interface{}{
+			"ServerSideEncryptionByDefault": map[string]interface{}{"SSEAlgorithm": "AES256"},
+		}}},
+		"OwnershipControls": map[string]interface{}{"Rules": []interface{}{map[string]interface{}{"ObjectOwnership": "BucketOwnerEnforced"}}},
+	}}
+	res["OriginAccessControl"] = Resource{Type: "AWS::CloudFront::OriginAccessControl", Properties: map[string]interface{}{
+		"OriginAccessControlConfig": map[string]interface{}{
+			"Name": ref("Name"), "OriginAccessControlOriginType": "s3", "SigningBehavior": "always", "SigningProtocol": "sigv4",
+		},
+	}}
+	dist := map[string]interface{}{
+		"Enabled":           true,
+		"DefaultRootObject": "index.html",
+		"HttpVersion":       "http2and3",
+		"Origins": []interface{}{map[string]interface{}{
+			"Id":                    "s3",
+			"DomainName":            getAtt("Bucket", "RegionalDomainName"),
+			"OriginAccessControlId": getAtt("OriginAccessControl", "Id"),
+			"S3OriginConfig":        map[string]interface{}{"OriginAccessIdentity": ""},
+		}},
+		"DefaultCacheBehavior": map[string]interface{}{
+			"TargetOriginId":       "s3",
+			"ViewerProtocolPolicy": "redirect-to-https",
+			"CachePolicyId":        "658327ea-f89d-4fab-a63d-7e88639e58f6", // Managed-CachingOptimized
+			"Compress":             true,
+		},
+	}
+	if p.Domain != "" {
+		t.Parameters["DomainName"] = param("String", "Site hostname", p.Domain)
+		t.Parameters["CertificateArn"] = param("String", "ACM certificate in us-east-1 covering the hostname", p.CertificateArn)
+		dist["Aliases"] = []interface{}{ref("DomainName")}
+		dist["ViewerCertificate"] = map[string]interface{}{
+			"AcmCertificateArn": ref("CertificateArn"), "SslSupportMethod": "sni-only", "MinimumProtocolVersion": "TLSv1.2_2021",
+		}
+	}
+	res["Distribution"] = Resource{Type: "AWS::CloudFront::Distribution", Properties: map[string]interface{}{"DistributionConfig": dist}}
+	res["BucketPolicy"] = Resource{Type: "AWS::S3::BucketPolicy", Properties: map[string]interface{}{
+		"Bucket": ref("Bucket"),
+		"PolicyDocument": map[string]interface{}{"Version": "2012-10-17", "Statement": []interface{}{map[string]interface{}{
+			"Effect":    "Allow",
+			"Principal": map[string]interface{}{"Service": "cloudfront.amazonaws.com"},
+			"Action":    "s3:GetObject",
+			"Resource":  sub("${Bucket.Arn}/*"),
+			"Condition": map[string]interface{}{"StringEquals": map[string]interface{}{
+				"AWS:SourceArn": sub("arn:${AWS::Partition}:cloudfront::${AWS::AccountId}:distribution/${Distribution}"),
+			}},
+		}}},
+	}}
+	if p.Domain != "" && p.HostedZoneId != "" {
+		t.Parameters["HostedZoneId"] = param("AWS::Route53::HostedZone::Id", "Hosted zone for the alias record", p.HostedZoneId)
+		res["AliasRecord"] = Resource{Type: "AWS::Route53::RecordSet", Properties: map[string]interface{}{
+			"HostedZoneId": ref("HostedZoneId"), "Name": ref("DomainName"), "Type": "A",
+			"AliasTarget": map[string]interface{}{
+				"DNSName":      getAtt("Distribution", "DomainName"),
+				"HostedZoneId": "Z2FDTNDATAQYW2", // CloudFront's fixed zone
+			},
+		}}
+	}
+
+	t.Outputs["BucketName"] = output("Upload the site here", ref("Bucket"), "")
+	t.Outputs["DistributionId"] = output("Invalidate this distribution after uploads", ref("Distribution"), "")
+	t.Outputs["SiteUrl"] = output("Site URL", sub("https://${Distribution.DomainName}"), "")
+	return t, nil
+}
@@ -0,0 +1,134 @@
+package cfn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// ScheduleParams describes an office-hours start/stop schedule.
+type ScheduleParams struct {
+	InstanceIds []string
+	Start       int    // hour of day
+	Stop        int    // hour of day, after Start
+	Timezone    string // IANA name
+}
+
+var scheduleTemplate = template.Must(template.New("schedule").Parse(`AWSTemplateFormatVersion: '2010-09-09'
+Description: Start/stop {{len .InstanceIds}} instance(s) on weekdays {{.Start}}:00-{{.Stop}}:00 {{.Timezone}} (generated by saws)
+
+Parameters:
+  InstanceIds:
+    Type: CommaDelimitedList
+    Default: {{.IDList}}
+
+Resources:
+  SchedulerFunctionRole:
+    Type: AWS::IAM::Role
+    Properties:
+      AssumeRolePolicyDocument:
+        Version: '2012-10-17'
+        Statement:
+          - Effect: Allow
+            Principal:
+              Service: lambda.amazonaws.com
+            Action: sts:AssumeRole
+      ManagedPolicyArns:
+        - arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole
+      Policies:
+        - PolicyName: start-stop-instances
+          PolicyDocument:
+            Version: '2012-10-17'
+            Statement:
+              - Effect: Allow
+                Action:
+                  - ec2:StartInstances
+                  - ec2:StopInstances
+                Resource:
+{{- range .InstanceIds}}
+                  - !Sub arn:${AWS::Partition}:ec2:${AWS::Region}:${AWS::AccountId}:instance/{{.}}
+{{- end}}
+
+  SchedulerFunction:
+    Type: AWS::Lambda::Function
+    Properties:
+      Runtime: python3.12
+      Handler: index.handler
+      Timeout: 60
+      Role: !GetAtt SchedulerFunctionRole.Arn
+      Environment:
+        Variables:
+          INSTANCE_IDS: !Join [",", !Ref InstanceIds]
+      Code:
+        ZipFile: |
+          import os
+          import boto3
+
+          ec2 = boto3.client("ec2")
+
+          def handler(event, context):
+              ids = os.environ["INSTANCE_IDS"].split(",")
+              if event.get("action") == "start":
+                  ec2.start_instances(InstanceIds=ids)
+              else:
+                  ec2.stop_instances(InstanceIds=ids)
+              return {"action": event.get("action"), "instances": ids}
+
+  SchedulerInvokeRole:
+    Type: AWS::IAM::Role
+    Properties:
+      AssumeRolePolicyDocument:
+        Version: '2012-10-17'
+        Statement:
+          - Effect: Allow
+            Principal:
+              Service: scheduler.amazonaws.com
+            Action: sts:AssumeRole
+      Policies:
+        - PolicyName: invoke-scheduler-function
+          PolicyDocument:
+            Version: '2012-10-17'
+            Statement:
+              - Effect: Allow
+                Action: lambda:InvokeFunction
+                Resource: !GetAtt SchedulerFunction.Arn
+
+  StartSchedule:
+    Type: AWS::Scheduler::Schedule
+    Properties:
+      Description: Start instances at the beginning of office hours
+      ScheduleExpression: cron(0 {{.Start}} ? * MON-FRI *)
+      ScheduleExpressionTimezone: {{.Timezone}}
+      FlexibleTimeWindow:
+        Mode: 'OFF'
+      Target:
+        Arn: !GetAtt SchedulerFunction.Arn
+        RoleArn: !GetAtt SchedulerInvokeRole.Arn
+        Input: '{"action": "start"}'
+
+  StopSchedule:
+    Type: AWS::Scheduler::Schedule
+    Properties:
+      Description: Stop instances at the end of office hours
+      ScheduleExpression: cron(0 {{.Stop}} ? * MON-FRI *)
+      ScheduleExpressionTimezone: {{.Timezone}}
+      FlexibleTimeWindow:
+        Mode: 'OFF'
+      Target:
+        Arn: !GetAtt SchedulerFunction.Arn
+        RoleArn: !GetAtt SchedulerInvokeRole.Arn
+        Input: '{"action": "stop"}'
+`))
+
+// WriteSchedule renders a CloudFormation template with an EventBridge
+// Scheduler pair and a small Lambda that starts and stops the instances.
+func WriteSchedule(w io.Writer, p ScheduleParams) error {
+	if len(p.InstanceIds) == 0 {
+		return fmt.Errorf("no instances to schedule")
+	}
+	return scheduleTemplate.Execute(w, struct {
+		ScheduleParams
+		IDList string
+	}{p, strings.Join(p.InstanceIds, ",")})
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunAccounts lists the accounts with cached data, marking the one shown
+// and the one the AWS CLI is signed in to.
+func RunAccounts(signedIn string) error {
+	accounts, err := sync.CachedAccounts()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n\n", bold("saws accounts"))
+	if len(accounts) == 0 {
+		if sync.CacheUpdatedAt() != "" {
+			fmt.Println(dim("  The cache predates accounts; it moves under the account the AWS CLI is signed in to on the next run."))
+		} else {
+			fmt.Println(dim("  Nothing cached yet. Run 'saws sync' first."))
+		}
+		return nil
+	}
+	for _, a := range accounts {
+		mark := " "
+		if a.ID == sync.Account() {
+			mark = green("●")
+		}
+		note := ""
+		if a.ID == signedIn {
+			note = cyan("signed in")
+		}
+		fmt.Printf("  %s %s  %-14s %s\n", mark, a.ID, dim(sync.FormatAge(time.Since(a.SyncedAt))), note)
+	}
+	fmt.Println(dim("\nShow another account with --account <id> on any command, or SAWS_ACCOUNT."))
+	return nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunExport writes the cache entries matching f to a bundle at path. With
+// anonymize, account IDs, addresses, ARNs and names are rewritten first.
+func RunExport(path string, f sync.BundleFilter, anonymize bool) error {
+	b, err := sync.BuildBundle(f)
+	if err != nil {
+		return err
+	}
+	if anonymize {
+		s, err := sync.NewSanitizer()
+		if err != nil {
+			return err
+		}
+		if err := s.Bundle(b); err != nil {
+			return err
+		}
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = sync.WriteBundle(file, b)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+	if len(b.Entries) == 0 {
+		os.Remove(path)
+		return fmt.Errorf("nothing cached matches; run 'saws sync' first or widen --region/--service")
+	}
+
+	fmt.Printf("Wrote %s (%d cache entries, %d tagged resources)\n", path, len(b.Entries), len(b.Tags))
+	if b.Account != "" {
+		fmt.Println(dim("  account: " + b.Account))
+	}
+	if anonymize {
+		fmt.Println(dim("  anonymized: account IDs, IP addresses, ARNs and names are rewritten"))
+	}
+	if len(b.Regions) > 0 {
+		fmt.Println(dim("  regions: " + strings.Join(b.Regions, ", ")))
+	}
+	fmt.Println(dim("  Teammates can browse it without AWS access: saws import " + path + " && saws up"))
+	return nil
+}
+
+// RunImport merges the bundle at path into the local cache.
+func RunImport(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	b, err := sync.ReadBundle(file)
+	if err != nil {
+		return err
+	}
+	res, err := sync.ImportBundle(b)
+	if err != nil {
+		return err
+	}
+
+	source := "exported " + b.CreatedAt.Local().Format("2006-01-02 15:04")
+	if b.Account != "" {
+		source = "account " + b.Account + ", " + source
+	}
+	fmt.Printf("%s  %s\n\n", bold("saws import"), dim(source))
+	fmt.Printf("  %s cache entries imported", green(fmt.Sprint(res.Imported)))
+	if res.Skipped > 0 {
+		fmt.Printf(", %s kept (synced here more recently)", yellow(fmt.Sprint(res.Skipped)))
+	}
+	fmt.Println()
+	if res.Tags > 0 {
+		fmt.Printf("  %d tagged resources\n", res.Tags)
+	}
+	if len(b.Regions) > 0 {
+		fmt.Printf("  regions: %s\n", strings.Join(b.Regions, ", "))
+	}
+	fmt.Println(dim("\nRun 'saws up' or 'saws view' to browse it."))
+	return nil
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// cacheTopKeys is how many of the largest keys RunCacheStats lists
+// without all.
+const cacheTopKeys = 15
+
+// RunCacheStats prints the cache file's size and what takes up the space:
+// each region's entries, then the largest keys (every key with all).
+func RunCacheStats(all bool) error {
+	s, err := sync.GetCacheStats()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s  %s\n\n", bold("saws cache"), dim(s.Path))
+	fmt.Printf("File        %s, schema version %d", formatBytes(s.FileSize), s.Schema)
+	if s.FreeBytes > 0 {
+		fmt.Printf("  %s", dim(formatBytes(s.FreeBytes)+" free; 'saws cache vacuum' reclaims it"))
+	}
+	fmt.Println()
+	fmt.Printf("Cache       %d entries, %s\n", s.Entries, formatBytes(s.Bytes))
+	fmt.Printf("Snapshots   %d, %d entries, %s\n", s.Snapshots, s.SnapshotEntries, formatBytes(s.SnapshotBytes))
+	fmt.Printf("Resources   %d indexed\n", s.Resources)
+	if s.Entries == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n%s\n", bold("Regions"))
+	for _, r := range s.Regions {
+		fmt.Printf("  %-16s %5d entries %10s   last sync %s\n", r.Region, r.Entries, formatBytes(r.Bytes), ago(r.LastSync))
+	}
+
+	keys := s.Keys
+	title := bold("Keys")
+	if !all && len(keys) > cacheTopKeys {
+		keys = keys[:cacheTopKeys]
+		title = bold("Largest keys") + " " + dim(fmt.Sprintf("(%d of %d; --all lists every key)", cacheTopKeys, s.Entries))
+	}
+	fmt.Printf("\n%s\n", title)
+	for _, k := range keys {
+		fmt.Printf("  %-48s %10s   %s\n", k.Key, formatBytes(k.Bytes), dim(ago(k.SyncedAt)))
+	}
+	return nil
+}
+
+// ago is sync.FormatAge of t, "never" when zero.
+func ago(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return sync.FormatAge(time.Since(t))
+}
+
+// RunCachePrune deletes cached data not synced within olderThan ("30d",
+// "2w", "12h"), then vacuums the file.
+func RunCachePrune(olderThan string) error {
+	age, err := ageArg(olderThan)
+	if err != nil {
+		return err
+	}
+	res, err := sync.PruneCache(age)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Pruned data older than %s: %d cache entries, %d indexed resources, %d snapshots\n",
+		green("✓"), olderThan, res.Entries, res.Resources, res.Snapshots)
+	return RunCacheVacuum()
+}
+
+// RunCacheVacuum compacts the cache file.
+func RunCacheVacuum() error {
+	before, after, err := sync.VacuumCache()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Vacuumed the cache: %s → %s\n", green("✓"), formatBytes(before), formatBytes(after))
+	return nil
+}
+
+// ageArg reads a duration in days ("30d") or weeks ("2w") as well as
+// anything time.ParseDuration takes.
+func ageArg(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			if v, err := strconv.Atoi(n); err == nil && v > 0 {
+				return time.Duration(v) * unit, nil
+			}
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("--older-than: expected an age such as 30d, 2w or 12h, got %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	"github.com/estrados/simply-aws/internal/diff"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunDiff prints what changed in region between two snapshots, the last
+// two syncs when from and to are zero. With list it prints the snapshots
+// to choose from instead.
+func RunDiff(region string, from, to int64, list bool, format string) error {
+	if list {
+		return printSnapshots(region)
+	}
+
+	var report *diff.Report
+	var err error
+	if from == 0 && to == 0 {
+		report, err = diff.Latest(region)
+	} else {
+		report, err = diff.Between(region, from, to)
+	}
+	if err != nil {
+		return err
+	}
+
+	if format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"change", "type", "id", "name", "field", "before", "after"})
+		if report != nil {
+			for _, c := range report.Changes {
+				if len(c.Fields) == 0 {
+					cw.Write([]string{string(c.Kind), c.Item.Type, c.Item.ID, c.Item.Name, "", "", ""})
+				}
+				for _, f := range c.Fields {
+					cw.Write([]string{string(c.Kind), c.Item.Type, c.Item.ID, c.Item.Name, f.Field, f.Before, f.After})
+				}
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	fmt.Printf("%s  %s\n\n", bold("saws diff"), dim(region))
+	if report == nil {
+		fmt.Println(dim("  Fewer than two syncs recorded. Run 'saws sync' again later to see what changed."))
+		return nil
+	}
+	fmt.Printf("%s #%d %s  →  #%d %s\n\n", dim("Between"),
+		report.From.ID, report.From.StartedAt.Format("2006-01-02 15:04"), report.To.ID, report.To.StartedAt.Format("2006-01-02 15:04"))
+
+	if len(report.Changes) == 0 {
+		fmt.Println(green("  No changes."))
+		return nil
+	}
+	for _, g := range report.ByType() {
+		fmt.Println(bold(g.Type))
+		for _, c := range g.Changes {
+			switch c.Kind {
+			case diff.Added:
+				fmt.Printf("  %s %s\n", green("+"), c.Item.Label())
+			case diff.Removed:
+				fmt.Printf("  %s %s\n", red("-"), c.Item.Label())
+			default:
+				fmt.Printf("  %s %s\n", yellow("~"), c.Item.Label())
+				for _, f := range c.Fields {
+					fmt.Printf("      %s\n", dim(f.String()))
+				}
+			}
+		}
+		fmt.Println()
+	}
+	added, removed, changed := report.Counts()
+	fmt.Printf("%s added, %s removed, %s changed\n",
+		green(fmt.Sprint(added)), red(fmt.Sprint(removed)), yellow(fmt.Sprint(changed)))
+	return nil
+}
+
+func printSnapshots(region string) error {
+	snaps, err := sync.ListSnapshots(region)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s  %s\n\n", bold("saws diff --list"), dim(region))
+	if len(snaps) == 0 {
+		fmt.Println(dim("  No snapshots yet. Run 'saws sync' first."))
+		return nil
+	}
+	for _, s := range snaps {
+		state := green("finished")
+		if s.FinishedAt.IsZero() {
+			state = yellow("incomplete")
+		}
+		fmt.Printf("  #%-5d %s  %-10s %s\n", s.ID, s.StartedAt.Format("2006-01-02 15:04"), s.Scope, state)
+	}
+	fmt.Println(dim("\nRun 'saws diff --from <id> --to <id>' to compare two of them."))
+	return nil
+}
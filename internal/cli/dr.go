@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunDR prints which cached data stores have a copy in another region and
+// which would be lost with the region.
+func RunDR(region string) error {
+	fmt.Printf("%s  %s\n\n", bold("saws dr"), dim(region))
+
+	statuses, err := sync.DRReadiness(region)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		fmt.Println(dim("  No data stores cached. Run 'saws sync' first."))
+		return nil
+	}
+
+	var exposed, covered []sync.DRStatus
+	for _, s := range statuses {
+		if s.CrossRegion() {
+			covered = append(covered, s)
+		} else {
+			exposed = append(exposed, s)
+		}
+	}
+
+	section := func(title string, list []sync.DRStatus, detail func(sync.DRStatus) string) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Printf("%s (%d)\n", bold(title), len(list))
+		for i, s := range list {
+			prefix := "├─"
+			if i == len(list)-1 {
+				prefix = "└─"
+			}
+			fmt.Printf("%s %s %-36s %s\n", prefix, dim(fmt.Sprintf("%-12s", s.Type)), cyan(s.Name), detail(s))
+		}
+		fmt.Println()
+	}
+	section("No cross-region copy", exposed, func(s sync.DRStatus) string {
+		if len(s.SameRegion) == 0 {
+			return red("single copy")
+		}
+		return yellow("in-region only: " + strings.Join(s.SameRegion, ", "))
+	})
+	section("Cross-region copy", covered, func(s sync.DRStatus) string {
+		return green(strings.Join(s.Copies, ", "))
+	})
+
+	fmt.Printf("%d of %d data stores have a copy outside %s\n", len(covered), len(statuses), region)
+	return nil
+}
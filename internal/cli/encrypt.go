@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunEncrypt converts the cache to mode ("passphrase", "keychain" or
+// "off"), or prints how it is encrypted when mode is empty.
+func RunEncrypt(mode string) error {
+	if mode == "" {
+		switch m := sync.EncryptionMode(); m {
+		case sync.EncryptionOff:
+			fmt.Println("The cache is not encrypted.")
+			fmt.Println(dim("  Encrypt it with 'saws encrypt passphrase' or 'saws encrypt keychain'."))
+		default:
+			fmt.Printf("The cache is encrypted (AES-256-GCM, key from the %s).\n", m)
+		}
+		fmt.Println(dim("  " + sync.DBPath()))
+		return nil
+	}
+	if mode == "off" {
+		mode = sync.EncryptionOff
+	}
+	if err := sync.SetEncryption(mode); err != nil {
+		return err
+	}
+	switch mode {
+	case sync.EncryptionOff:
+		fmt.Println(green("✓") + " The cache is no longer encrypted.")
+	case sync.EncryptionPassphrase:
+		fmt.Println(green("✓") + " The cache is encrypted with your passphrase.")
+		fmt.Println(dim("  saws will ask for it on start; set SAWS_PASSPHRASE to skip the prompt."))
+		fmt.Println(dim("  There is no way to recover the cache without it (a fresh sync rebuilds it)."))
+	case sync.EncryptionKeychain:
+		fmt.Println(green("✓") + " The cache is encrypted with a key kept in the OS keychain.")
+	}
+	return nil
+}
+
+// ReadPassphrase asks for the cache passphrase on the terminal without
+// echoing it; confirm asks twice. Set as sync.PassphrasePrompt.
+func ReadPassphrase(confirm bool) (string, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return "", errors.New("the cache is encrypted with a passphrase; set SAWS_PASSPHRASE")
+	}
+	defer tty.Close()
+
+	read := func(prompt string) (string, error) {
+		fmt.Fprint(tty, prompt)
+		stty := func(arg string) {
+			cmd := exec.Command("stty", arg)
+			cmd.Stdin = tty
+			cmd.Run()
+		}
+		stty("-echo")
+		line, err := bufio.NewReader(tty).ReadString('\n')
+		stty("echo")
+		fmt.Fprintln(tty)
+		return strings.TrimRight(line, "\r\n"), err
+	}
+	pass, err := read("Cache passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := read("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != pass {
+			return "", errors.New("the passphrases don't match")
+		}
+	}
+	return pass, nil
+}
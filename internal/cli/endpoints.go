@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/estrados/simply-aws/internal/probe"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunEndpoints lists the externally reachable endpoints in the cache and,
+// with doProbe, connects to each one for its TLS and HTTP status.
+func RunEndpoints(region string, doProbe bool, format string) error {
+	endpoints, err := sync.LoadEndpoints(region)
+	if err != nil {
+		return err
+	}
+
+	var results []probe.Result
+	if doProbe && len(endpoints) > 0 {
+		fmt.Fprintf(os.Stderr, "Probing %d endpoints...\n", len(endpoints))
+		targets := make([]probe.Target, len(endpoints))
+		for i, e := range endpoints {
+			targets[i] = probe.Target{Host: e.Host, URL: e.URL}
+		}
+		results = probe.All(targets, 8)
+	}
+
+	if format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"kind", "resource", "name", "host", "url",
+			"tls_version", "cert_subject", "cert_expires", "cert_error", "http_status", "error"})
+		for i, e := range endpoints {
+			row := []string{e.Kind, e.Resource, e.Name, e.Host, e.URL}
+			if results != nil {
+				r := results[i]
+				expires, status := "", ""
+				if !r.CertExpires.IsZero() {
+					expires = r.CertExpires.Format("2006-01-02")
+				}
+				if r.HTTPStatus != 0 {
+					status = strconv.Itoa(r.HTTPStatus)
+				}
+				row = append(row, r.TLSVersion, r.CertSubject, expires, r.CertError, status, r.Error)
+			} else {
+				row = append(row, "", "", "", "", "", "")
+			}
+			cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	fmt.Printf("%s  %s\n\n", bold("saws endpoints"), dim(region))
+	if len(endpoints) == 0 {
+		fmt.Println(dim("  No external endpoints cached. Sync the Network and Compute tabs first."))
+		return nil
+	}
+	for i, e := range endpoints {
+		if i == 0 || endpoints[i-1].Kind != e.Kind {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(bold(e.Kind))
+		}
+		prefix := "├─"
+		if i == len(endpoints)-1 || endpoints[i+1].Kind != e.Kind {
+			prefix = "└─"
+		}
+		line := fmt.Sprintf("%s %-30s %s", prefix, cyan(e.Name), e.Host)
+		if results != nil {
+			line += "  " + probeSummary(results[i])
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("\n%d endpoints\n", len(endpoints))
+	if results == nil {
+		fmt.Println(dim("Run with --probe to check TLS and HTTP status."))
+	}
+	return nil
+}
+
+func probeSummary(r probe.Result) string {
+	if r.Error != "" {
+		return red("unreachable: " + r.Error)
+	}
+	out := ""
+	switch {
+	case r.TLSVersion == "":
+		out = yellow("no TLS")
+	case r.TLSVersion == "TLS 1.0" || r.TLSVersion == "TLS 1.1":
+		out = red(r.TLSVersion)
+	default:
+		out = green(r.TLSVersion)
+	}
+	if r.CertError != "" {
+		out += " " + red("cert "+r.CertError)
+	} else if !r.CertExpires.IsZero() {
+		out += " " + dim("cert until "+r.CertExpires.Format("2006-01-02"))
+	}
+	if r.HTTPStatus != 0 {
+		status := strconv.Itoa(r.HTTPStatus)
+		if r.HTTPStatus >= 500 {
+			status = red(status)
+		}
+		out += " " + status
+	}
+	return out
+}
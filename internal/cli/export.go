@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/export"
+)
+
+// RunExportTable writes the inventory selected by opts as a table in
+// format to path, or to stdout when path is empty.
+func RunExportTable(path, format string, opts export.Options) error {
+	if !slices.Contains(export.Formats, format) {
+		return fmt.Errorf("unknown format %q: expected %s", format, strings.Join(export.Formats, ", "))
+	}
+	t, err := export.Inventory(opts)
+	if err != nil {
+		return err
+	}
+	if len(t.Rows) == 0 {
+		return errors.New("no synced resources match; run 'saws sync' first or widen --region/--service")
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := export.Write(w, format, t); err != nil {
+		if path != "" {
+			os.Remove(path)
+		}
+		return err
+	}
+	if path != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d resources, %d columns)\n", path, len(t.Rows), len(t.Columns))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/estrados/simply-aws/internal/graph"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunGraph prints the resources of region and the relationships between
+// them, only those inside vpcId when it is set, in format (dot).
+func RunGraph(region, vpcId, format string) error {
+	if format != "dot" {
+		return fmt.Errorf("unknown format %q: expected dot", format)
+	}
+	t, err := sync.LoadTopology(region)
+	if err != nil {
+		return err
+	}
+	if vpcId != "" {
+		t = t.InVPC(vpcId)
+	}
+	if len(t.Items) == 0 {
+		return errors.New("no synced resources match; run 'saws sync' first or check --region/--vpc")
+	}
+	return graph.WriteDOT(os.Stdout, t)
+}
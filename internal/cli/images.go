@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunImages joins ECR scan results and push dates with the images running
+// in ECS services and Lambda functions, and prints the workloads that run
+// images with critical CVEs or images older than staleDays.
+func RunImages(region string, staleDays int, format string) error {
+	usage, err := sync.ImageUsage(region)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+
+	if format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"workload", "image", "repository", "pushed", "age_days", "scan_status", "critical", "high", "critical_cves"})
+		for _, u := range usage {
+			row := []string{u.Workload, u.Ref, u.Repo}
+			if u.Image != nil {
+				row = append(row, u.Image.PushedAt, strconv.Itoa(u.AgeDays(now)), u.Image.ScanStatus,
+					strconv.Itoa(u.Image.Critical), strconv.Itoa(u.Image.High), strings.Join(u.Image.CriticalCVEs, " "))
+			} else {
+				row = append(row, "", "", "", "", "", "")
+			}
+			cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	fmt.Printf("%s  %s\n\n", bold("saws images"), dim(region))
+	if len(usage) == 0 {
+		fmt.Println(dim("  No ECS service or Lambda container images cached. Sync the Compute tab first."))
+		return nil
+	}
+
+	var critical, stale, unscanned, unresolved []sync.WorkloadImage
+	for _, u := range usage {
+		switch {
+		case u.Image == nil:
+			unresolved = append(unresolved, u)
+			continue
+		case u.Image.Critical > 0:
+			critical = append(critical, u)
+		case u.Image.ScanStatus != "COMPLETE" && u.Image.ScanStatus != "ACTIVE":
+			unscanned = append(unscanned, u)
+		}
+		if age := u.AgeDays(now); age >= staleDays {
+			stale = append(stale, u)
+		}
+	}
+
+	section := func(title string, list []sync.WorkloadImage, detail func(sync.WorkloadImage) string) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Printf("%s (%d)\n", bold(title), len(list))
+		for i, u := range list {
+			prefix := "├─"
+			if i == len(list)-1 {
+				prefix = "└─"
+			}
+			fmt.Printf("%s %-40s %s  %s\n", prefix, cyan(u.Workload), imageLabel(u), detail(u))
+		}
+		fmt.Println()
+	}
+	section("Critical CVEs", critical, func(u sync.WorkloadImage) string {
+		out := red(fmt.Sprintf("%d critical", u.Image.Critical))
+		if u.Image.High > 0 {
+			out += " " + yellow(fmt.Sprintf("%d high", u.Image.High))
+		}
+		if cves := u.Image.CriticalCVEs; len(cves) > 0 {
+			if len(cves) > 3 {
+				cves = append(cves[:3:3], fmt.Sprintf("+%d more", len(u.Image.CriticalCVEs)-3))
+			}
+			out += " " + dim(strings.Join(cves, ", "))
+		}
+		return out
+	})
+	section(fmt.Sprintf("Not rebuilt in %d+ days", staleDays), stale, func(u sync.WorkloadImage) string {
+		return yellow(fmt.Sprintf("%d days old", u.AgeDays(now)))
+	})
+	section("Not scanned", unscanned, func(u sync.WorkloadImage) string {
+		if u.Image.ScanStatus == "" {
+			return yellow("never scanned")
+		}
+		return yellow(strings.ToLower(u.Image.ScanStatus))
+	})
+	section("No ECR scan data", unresolved, func(u sync.WorkloadImage) string {
+		if u.Repo != "" {
+			return dim("tag not found in " + u.Repo)
+		}
+		return dim("not in a synced ECR registry")
+	})
+
+	fmt.Printf("%d images in use: %d with critical CVEs, %d older than %d days\n",
+		len(usage), len(critical), len(stale), staleDays)
+	return nil
+}
+
+// imageLabel shows repo:tag for ECR images, otherwise the reference as
+// configured.
+func imageLabel(u sync.WorkloadImage) string {
+	if u.Image == nil || u.Repo == "" {
+		return u.Ref
+	}
+	if len(u.Image.Tags) > 0 {
+		return u.Repo + ":" + u.Image.Tags[0]
+	}
+	digest := strings.TrimPrefix(u.Image.Digest, "sha256:")
+	if len(digest) > 12 {
+		digest = digest[:12]
+	}
+	return u.Repo + "@" + digest
+}
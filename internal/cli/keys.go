@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunKeys prints what each KMS key in region protects, from the key
+// references in cached resource configs. With key set (ID, ARN or alias)
+// only that key is shown.
+func RunKeys(region, key, format string) error {
+	usage, err := sync.KeyUsage(region)
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		var match []sync.KMSKeyUsage
+		for _, u := range usage {
+			if u.Matches(key) {
+				match = append(match, u)
+			}
+		}
+		if len(match) == 0 {
+			return fmt.Errorf("no key %q referenced or synced in %s", key, region)
+		}
+		usage = match
+	}
+
+	if format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"key_id", "alias", "manager", "state", "resource_type", "resource_id", "resource_name"})
+		for _, u := range usage {
+			alias := ""
+			if len(u.Key.Aliases) > 0 {
+				alias = u.Key.Aliases[0]
+			}
+			if len(u.Resources) == 0 {
+				cw.Write([]string{u.Key.KeyId, alias, u.Key.KeyManager, u.Key.KeyState, "", "", ""})
+			}
+			for _, r := range u.Resources {
+				cw.Write([]string{u.Key.KeyId, alias, u.Key.KeyManager, u.Key.KeyState, r.Type, r.ID, r.Name})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	fmt.Printf("%s  %s\n\n", bold("saws keys"), dim(region))
+	if len(usage) == 0 {
+		fmt.Println(dim("  No KMS keys cached or referenced. Sync the IAM tab and the tabs holding your data stores first."))
+		return nil
+	}
+
+	unused := 0
+	for _, u := range usage {
+		label := u.Key.Label()
+		meta := dim(u.Key.KeyManager)
+		if !u.Synced {
+			meta = dim("not synced")
+		}
+		switch u.Key.KeyState {
+		case "", "Enabled":
+		case "PendingDeletion":
+			meta += " " + red("pending deletion "+dateOnly(u.Key.DeletionDate))
+		default:
+			meta += " " + yellow(u.Key.KeyState)
+		}
+		fmt.Printf("%s (%d)  %s\n", bold(label), len(u.Resources), meta)
+		if label != u.Key.KeyId && u.Key.KeyId != "" {
+			fmt.Printf("   %s\n", dim(u.Key.KeyId))
+		}
+		if len(u.Resources) == 0 {
+			unused++
+			fmt.Printf("└─ %s\n", dim("no cached resource references this key"))
+		}
+		for i, r := range u.Resources {
+			prefix := "├─"
+			if i == len(u.Resources)-1 {
+				prefix = "└─"
+			}
+			name := ""
+			if r.Name != "" && r.Name != r.ID {
+				name = "  " + dim(r.Name)
+			}
+			fmt.Printf("%s %s %s%s\n", prefix, dim(fmt.Sprintf("%-14s", r.Type)), cyan(r.ID), name)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%d keys, %d with no cached references\n", len(usage), unused)
+	fmt.Println(dim("Only synced resource types are checked; grants and key policies can allow other use."))
+	return nil
+}
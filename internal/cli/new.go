@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/cfn"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// NewOptions are the 'saws new' flags. Empty values are filled in from the
+// cache where it has an answer.
+type NewOptions struct {
+	Region      string
+	Out         string // defaults to <name>.yaml in the project directory
+	Force       bool
+	NAT         bool
+	VpcId       string
+	Cluster     string
+	Image       string
+	Port        int
+	Domain      string
+	Certificate string
+}
+
+// RunNew writes a CloudFormation template of the given kind, pre-filled
+// with IDs from the cached environment, and lists what it picked.
+func RunNew(kind, name string, opts NewOptions) error {
+	fmt.Printf("%s  %s\n\n", bold("saws new "+kind), dim(opts.Region))
+
+	var t *cfn.Template
+	var err error
+	var picked [][2]string
+	pick := func(label, value string) {
+		if value != "" {
+			picked = append(picked, [2]string{label, value})
+		}
+	}
+
+	switch kind {
+	case "vpc":
+		vpcData, _ := sync.LoadVPCData(opts.Region)
+		cidr := freeVPCCidr(vpcData)
+		if cidr == "" {
+			return fmt.Errorf("no free 10.x.0.0/16 left beside the cached VPCs")
+		}
+		pick("CIDR", cidr+" (clear of cached VPCs)")
+		t, err = cfn.ScaffoldVPC(cfn.VPCParams{Name: name, Cidr: cidr, NAT: opts.NAT})
+
+	case "fargate-service":
+		p := cfn.FargateServiceParams{Name: name, Cluster: opts.Cluster, Image: opts.Image, Port: opts.Port}
+		if vpcData, _ := sync.LoadVPCData(opts.Region); vpcData != nil {
+			p.VpcId = opts.VpcId
+			if p.VpcId == "" {
+				p.VpcId = pickServiceVPC(vpcData)
+				if p.VpcId == "" && len(vpcData.VPCs) > 0 {
+					return fmt.Errorf("no cached VPC has public subnets in two AZs; pass --vpc")
+				}
+			}
+			p.PublicSubnetIds, p.PrivateSubnetIds = splitSubnets(vpcData, p.VpcId)
+			pick("VPC", p.VpcId+nameSuffix(vpcData.VPCName(p.VpcId)))
+			pick("Public subnets", strings.Join(p.PublicSubnetIds, ", "))
+			if len(p.PrivateSubnetIds) > 0 {
+				pick("Task subnets", strings.Join(p.PrivateSubnetIds, ", "))
+			} else {
+				pick("Task subnets", "public, with public IPs (no private subnet has a NAT route)")
+			}
+		}
+		if p.Cluster == "" {
+			if compute, _ := sync.LoadComputeData(opts.Region); compute != nil {
+				for _, c := range compute.ECS {
+					if c.Status == "ACTIVE" {
+						p.Cluster = c.ClusterName
+						break
+					}
+				}
+			}
+		}
+		if p.Cluster != "" {
+			pick("Cluster", p.Cluster)
+		} else {
+			pick("Cluster", "new")
+		}
+		t, err = cfn.ScaffoldFargateService(p)
+
+	case "static-site":
+		p := cfn.StaticSiteParams{Name: name, Domain: opts.Domain, CertificateArn: opts.Certificate}
+		if p.Domain != "" {
+			if p.CertificateArn == "" {
+				// CloudFront only takes certificates from us-east-1.
+				certs, _ := sync.LoadCertificates("us-east-1")
+				p.CertificateArn = certificateFor(certs, p.Domain)
+			}
+			zones, _ := sync.LoadHostedZones()
+			p.HostedZoneId = hostedZoneFor(zones, p.Domain)
+			pick("Certificate", p.CertificateArn)
+			pick("Hosted zone", p.HostedZoneId)
+		}
+		t, err = cfn.ScaffoldStaticSite(p)
+
+	default:
+		return fmt.Errorf("unknown template %q (available: %s)", kind, strings.Join(cfn.Scaffolds, ", "))
+	}
+	if err != nil {
+		return err
+	}
+
+	path := opts.Out
+	if path == "" {
+		path = name + ".yaml"
+	}
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		return fmt.Errorf("%s already exists; pass --force to overwrite", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := t.WriteYAML(f); err != nil {
+		return err
+	}
+
+	for _, p := range picked {
+		fmt.Printf("  %-16s %s\n", p[0], cyan(p[1]))
+	}
+	fmt.Printf("\nWrote %s (%d resources)\n", path, len(t.Resources))
+	fmt.Println(dim("  Review the parameters, then deploy with 'aws cloudformation deploy --template-file " + path + " --stack-name " + name + " --capabilities CAPABILITY_IAM'"))
+	return nil
+}
+
+func nameSuffix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " (" + name + ")"
+}
+
+// freeVPCCidr returns the first 10.x.0.0/16 that overlaps no cached VPC.
+func freeVPCCidr(data *sync.VPCData) string {
+	var used []netip.Prefix
+	if data != nil {
+		for _, v := range data.VPCs {
+			if p, err := netip.ParsePrefix(v.CidrBlock); err == nil {
+				used = append(used, p)
+			}
+		}
+	}
+	for x := 0; x < 256; x++ {
+		cand := netip.MustParsePrefix(fmt.Sprintf("10.%d.0.0/16", x))
+		free := true
+		for _, u := range used {
+			if u.Overlaps(cand) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return cand.String()
+		}
+	}
+	return ""
+}
+
+// pickServiceVPC prefers a non-default VPC with public subnets in at least
+// two AZs, then the default VPC.
+func pickServiceVPC(data *sync.VPCData) string {
+	best, fallback := "", ""
+	bestCount := 0
+	for _, v := range data.VPCs {
+		public, _ := splitSubnets(data, v.VpcId)
+		if len(public) < 2 {
+			continue
+		}
+		if v.IsDefault {
+			fallback = v.VpcId
+			continue
+		}
+		n := 0
+		for _, s := range data.Subnets {
+			if s.VpcId == v.VpcId {
+				n++
+			}
+		}
+		if n > bestCount {
+			best, bestCount = v.VpcId, n
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return fallback
+}
+
+// splitSubnets returns a VPC's public subnets and its private subnets with
+// a NAT route, one per AZ, ordered by AZ. Isolated subnets are left out
+// since tasks there could not pull images without VPC endpoints.
+func splitSubnets(data *sync.VPCData, vpcId string) (public, private []string) {
+	subnets := append([]sync.Subnet(nil), data.Subnets...)
+	sort.Slice(subnets, func(i, j int) bool { return subnets[i].AvailabilityZone < subnets[j].AvailabilityZone })
+	pubAZ, privAZ := map[string]bool{}, map[string]bool{}
+	for _, s := range subnets {
+		if s.VpcId != vpcId {
+			continue
+		}
+		if data.SubnetIsPublic(s.SubnetId) {
+			if !pubAZ[s.AvailabilityZone] {
+				pubAZ[s.AvailabilityZone] = true
+				public = append(public, s.SubnetId)
+			}
+		} else if !privAZ[s.AvailabilityZone] && subnetHasNAT(data, s.SubnetId) {
+			privAZ[s.AvailabilityZone] = true
+			private = append(private, s.SubnetId)
+		}
+	}
+	return public, private
+}
+
+func subnetHasNAT(data *sync.VPCData, subnetId string) bool {
+	if rt := data.RouteTableForSubnet(subnetId); rt != nil {
+		for _, r := range rt.Routes {
+			if r.NatGatewayId != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// certificateFor returns an issued certificate covering domain, directly
+// or through a wildcard one level up.
+func certificateFor(certs []sync.Certificate, domain string) string {
+	wildcard := ""
+	if i := strings.Index(domain, "."); i > 0 {
+		wildcard = "*" + domain[i:]
+	}
+	for _, c := range certs {
+		if c.Status != "ISSUED" {
+			continue
+		}
+		for _, n := range append([]string{c.DomainName}, c.AltNames...) {
+			if n == domain || n == wildcard {
+				return c.CertificateArn
+			}
+		}
+	}
+	return ""
+}
+
+// hostedZoneFor returns the most specific public zone holding domain.
+func hostedZoneFor(zones []sync.HostedZone, domain string) string {
+	best, bestLen := "", 0
+	for _, z := range zones {
+		name := strings.TrimSuffix(z.Name, ".")
+		if z.Private || (domain != name && !strings.HasSuffix(domain, "."+name)) {
+			continue
+		}
+		if len(name) > bestLen {
+			best, bestLen = z.Id, len(name)
+		}
+	}
+	return best
+}
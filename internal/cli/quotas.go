@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunQuotas prints each cached service quota with its usage counted from
+// the cache, flagging those at or over threshold percent, and the date
+// each quota and subnet is projected to run out at its current growth.
+func RunQuotas(region string, threshold int, format string) error {
+	quotas, err := sync.LoadQuotas(region)
+	if err != nil {
+		return err
+	}
+	forecasts, _ := sync.UsageForecasts(region)
+	fullBy := map[string]string{}
+	for _, f := range forecasts {
+		fullBy[f.Key] = f.FullBy
+	}
+
+	if format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"service", "quota_code", "name", "usage", "value", "unit", "used_pct", "near_limit", "projected_full"})
+		for _, q := range quotas {
+			cw.Write([]string{q.ServiceCode, q.QuotaCode, q.Name, fmt.Sprint(q.Usage), fmt.Sprintf("%.0f", q.Value),
+				q.Unit, fmt.Sprintf("%.0f", q.UsedPercent()), fmt.Sprint(q.NearLimitAt(threshold)), fullBy[q.QuotaCode]})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	fmt.Printf("%s  %s  %s\n\n", bold("saws quotas"), dim(region), dim(fmt.Sprintf("warn at %d%%", threshold)))
+	if len(quotas) == 0 {
+		fmt.Println(dim("  No quotas cached. Sync the Network tab first (or 'saws sync')."))
+		return nil
+	}
+
+	near := 0
+	for _, q := range quotas {
+		pct := fmt.Sprintf("%3.0f%%", q.UsedPercent())
+		switch {
+		case q.NearLimitAt(threshold):
+			pct = red(pct)
+			near++
+		case q.UsedPercent() >= float64(threshold)/2:
+			pct = yellow(pct)
+		default:
+			pct = green(pct)
+		}
+		forecast := ""
+		if d := fullBy[q.QuotaCode]; d != "" {
+			forecast = "  " + yellow("full by "+d)
+		}
+		fmt.Printf("  %s  %-64s %s  %s%s\n", pct, q.Name, q.UsageText(), dim(q.ServiceCode+" "+q.QuotaCode), forecast)
+	}
+	fmt.Printf("\n%d of %d quotas at or over %d%%\n", near, len(quotas), threshold)
+
+	var subnets []sync.Forecast
+	for _, f := range forecasts {
+		if strings.HasPrefix(f.Key, "subnet/") && f.Growing() {
+			subnets = append(subnets, f)
+		}
+	}
+	if len(subnets) > 0 {
+		fmt.Printf("\n%s\n", bold("Subnets filling up"))
+		now := time.Now()
+		for _, f := range subnets {
+			when := fmt.Sprintf("full by %s (%d days)", f.FullBy, f.DaysLeft(now))
+			if f.DaysLeft(now) <= 30 {
+				when = red(when)
+			} else {
+				when = yellow(when)
+			}
+			fmt.Printf("  %-48s %d of %d IPs  +%.1f/day  %s\n", f.Name, f.Usage, f.Limit, f.PerDay, when)
+		}
+	}
+	if len(forecasts) == 0 {
+		fmt.Println(dim("\n  Forecasts need usage from syncs on at least two days."))
+	}
+	return nil
+}
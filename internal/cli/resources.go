@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunResources lists the synced resources matching f, grouped by type.
+func RunResources(f sync.ResourceFilter, format string) error {
+	resources, err := sync.QueryResources(f)
+	if err != nil {
+		return err
+	}
+
+	if format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"region", "type", "id", "name", "arn", "vpc", "synced_at"})
+		for _, r := range resources {
+			cw.Write([]string{r.Region, r.Type, r.ID, r.Name, r.Arn, r.VpcId, r.SyncedAt.Format("2006-01-02 15:04")})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	scope := "all regions"
+	if f.Region != "" {
+		scope = f.Region
+	}
+	fmt.Printf("%s  %s\n\n", bold("saws resources"), dim(scope))
+	if len(resources) == 0 {
+		fmt.Println(dim("  No matching resources. Run 'saws sync' if the region hasn't been synced."))
+		return nil
+	}
+	for i, r := range resources {
+		if i == 0 || resources[i-1].Type != r.Type {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(bold(r.Type))
+		}
+		prefix := "├─"
+		if i == len(resources)-1 || resources[i+1].Type != r.Type {
+			prefix = "└─"
+		}
+		fmt.Printf("%s %-48s %-14s %s\n", prefix, cyan(r.Label()), dim(r.Region), dim(r.VpcId))
+	}
+	fmt.Printf("\n%d resources\n", len(resources))
+	return nil
+}
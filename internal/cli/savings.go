@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunSavings prints right-sizing suggestions from the cached metrics snapshot.
+func RunSavings(region string) {
+	fmt.Printf("%s  %s\n\n", bold("saws savings"), dim(region))
+
+	data, err := sync.LoadComputeData(region)
+	if err != nil {
+		fmt.Println(red("  Error loading compute data: " + err.Error()))
+		return
+	}
+
+	ec2 := sync.EC2RightsizingSuggestions(data)
+	ecs := sync.ECSRightsizingSuggestions(data)
+	if len(ec2) == 0 && len(ecs) == 0 {
+		fmt.Println(dim("  No suggestions. Right-sizing needs metrics: run 'saws sync --metrics' first."))
+		return
+	}
+
+	var total float64
+	if len(ec2) > 0 {
+		fmt.Printf("%s (%d)\n", bold("EC2 Instances under 10% CPU"), len(ec2))
+		for i, r := range ec2 {
+			prefix := "├─"
+			if i == len(ec2)-1 {
+				prefix = "└─"
+			}
+			name := r.Name
+			if name == "" {
+				name = r.InstanceId
+			}
+			action := yellow("schedule office hours")
+			if r.Action == "downsize" {
+				action = green("→ " + r.SuggestedType)
+			}
+			mem := "mem n/a"
+			if r.HasMemory {
+				mem = fmt.Sprintf("mem peak %.0f%%", r.MemoryMax)
+			}
+			savings := dim("price unknown")
+			if r.Priced() {
+				total += r.MonthlySavings
+				savings = green(fmt.Sprintf("-$%.2f/mo", r.MonthlySavings))
+			}
+			fmt.Printf("%s %-30s %-12s %s  %s  %s\n", prefix, cyan(name), dim(r.InstanceType), action,
+				dim(fmt.Sprintf("cpu avg %.1f%% peak %.0f%% · %s", r.CPUAvg, r.CPUMax, mem)), savings)
+		}
+		fmt.Println()
+	}
+
+	if len(ecs) > 0 {
+		fmt.Printf("%s (%d)\n", bold("ECS Services"), len(ecs))
+		for i, r := range ecs {
+			prefix := "├─"
+			if i == len(ecs)-1 {
+				prefix = "└─"
+			}
+			arrow := green("↓")
+			if r.Direction() == "higher" {
+				arrow = yellow("↑")
+			}
+			savings := dim("EC2 launch type")
+			if r.Priced() {
+				total += r.MonthlySavings
+				if r.MonthlySavings > 0 {
+					savings = green(fmt.Sprintf("-$%.2f/mo", r.MonthlySavings))
+				} else {
+					savings = yellow(fmt.Sprintf("+$%.2f/mo", -r.MonthlySavings))
+				}
+			}
+			fmt.Printf("%s %-34s %s %4d/%-5d → %4d/%-5d  %s  %s\n", prefix,
+				cyan(r.Cluster+"/"+r.Service), arrow, r.Cpu, r.Memory, r.SuggestedCpu, r.SuggestedMemory,
+				dim(fmt.Sprintf("peak cpu %.0f%% mem %.0f%% · %d tasks", r.CPUMax, r.MemoryMax, r.Tasks)), savings)
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("%s %s\n", bold("Estimated savings:"), green(fmt.Sprintf("$%.2f/month", total)))
+	fmt.Println(dim("  14-day peak + 25% headroom at us-east-1 on-demand prices; schedules assume 12h weekdays"))
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/estrados/simply-aws/internal/cfn"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunSchedule lists non-production instances running 24/7 and, when cfnPath
+// is set, writes a CloudFormation template that schedules them.
+func RunSchedule(region string, hours sync.OfficeHours, cfnPath string) error {
+	fmt.Printf("%s  %s\n\n", bold("saws schedule"), dim(region))
+
+	data, err := sync.LoadComputeData(region)
+	if err != nil {
+		return err
+	}
+	candidates := sync.ScheduleCandidates(data, hours)
+	if len(candidates) == 0 {
+		fmt.Println(dim("  No non-production instances found running around the clock."))
+		return nil
+	}
+
+	var total float64
+	var ids []string
+	fmt.Printf("%s (%d)\n", bold("Always-on non-production instances"), len(candidates))
+	for i, c := range candidates {
+		prefix := "├─"
+		if i == len(candidates)-1 {
+			prefix = "└─"
+		}
+		name := c.Name
+		if name == "" {
+			name = c.InstanceId
+		}
+		savings := dim("price unknown")
+		if c.Priced {
+			total += c.MonthlySavings
+			savings = green(fmt.Sprintf("-$%.2f/mo", c.MonthlySavings))
+		}
+		fmt.Printf("%s %-30s %-12s %s  %s\n", prefix, cyan(name), dim(c.InstanceType),
+			dim(fmt.Sprintf("up %dd · %s", c.RunningDays, c.Reason)), savings)
+		ids = append(ids, c.InstanceId)
+	}
+	fmt.Println()
+	fmt.Printf("%s weekdays %02d:00-%02d:00 %s (%dh/week)\n", bold("Proposed schedule:"),
+		hours.Start, hours.Stop, hours.Timezone, hours.HoursPerWeek())
+	fmt.Printf("%s %s\n", bold("Estimated savings:"), green(fmt.Sprintf("$%.2f/month", total)))
+
+	if cfnPath == "" {
+		fmt.Println(dim("  Use --cfn schedule.yaml to generate an EventBridge Scheduler template"))
+		return nil
+	}
+	f, err := os.Create(cfnPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := cfn.WriteSchedule(f, cfn.ScheduleParams{
+		InstanceIds: ids, Start: hours.Start, Stop: hours.Stop, Timezone: hours.Timezone,
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("\nWrote %s\n", cfnPath)
+	return nil
+}
@@ -2,76 +2,96 @@ package cli
 
 import (
 	"fmt"
+	"strings"
+	gosync "sync"
 	"time"
 
+	"github.com/estrados/simply-aws/internal/hooks"
 	"github.com/estrados/simply-aws/internal/sync"
+	"github.com/estrados/simply-aws/internal/warehouse"
 )
 
-// RunSync syncs all AWS resources for the given region and prints progress.
+// RunSync syncs the service groups .saws.yaml selects (all of them by
+// default) for the given region and prints progress.
 func RunSync(region string) {
 	start := time.Now()
 	fmt.Printf("%s  %s\n\n", bold("saws sync"), dim(region))
 
-	step := func(label string) {
-		fmt.Printf("  %s %s\n", green("✓"), label)
+	if !sync.RegionAllowed(region) {
+		fmt.Printf("%s %s\n\n", yellow("⚠"), yellow(fmt.Sprintf("%s is not in allowed_regions (%s); anything found here will be flagged by 'saws audit'",
+			region, strings.Join(sync.AllowedRegions(), ", "))))
 	}
 
-	// Network
-	printSyncSection("Network", func() ([]sync.SyncResult, error) {
-		return sync.SyncVPCData(region, step)
-	})
-
-	// S3 & Data
-	printSyncSection("S3 & Data", func() ([]sync.SyncResult, error) {
-		var all []sync.SyncResult
-		if r, err := sync.SyncS3WithRegions(step); err == nil {
-			all = append(all, *r)
-		} else {
-			all = append(all, sync.SyncResult{Service: "s3", Error: err.Error()})
+	run, err := hooks.Begin(region, "all")
+	if err != nil {
+		fmt.Printf("%s Sync not started: %s\n", red("✗"), dim(err.Error()))
+		return
+	}
+	// Groups running side by side print their section when done.
+	live := sync.SyncConcurrency() == 1
+	var mu gosync.Mutex
+	sync.RunSyncGroups(sync.SelectedSyncGroups(), func(g sync.SyncGroup) {
+		var out strings.Builder
+		step := func(label string) {
+			fmt.Fprintf(&out, "  %s %s\n", green("✓"), label)
 		}
-		dw, err := sync.SyncDataWarehouseData(region, step)
-		if err == nil {
-			all = append(all, dw...)
+		if live {
+			fmt.Printf("%s\n", bold("━━ "+g.Title))
+			step = func(label string) {
+				fmt.Printf("  %s %s\n", green("✓"), label)
+			}
 		}
-		return all, nil
-	})
+		results, err := g.Sync(run.Stage(), region, step)
 
-	// Database
-	printSyncSection("Database", func() ([]sync.SyncResult, error) {
-		return sync.SyncDatabaseData(region, step)
-	})
-
-	// Compute
-	printSyncSection("Compute", func() ([]sync.SyncResult, error) {
-		return sync.SyncComputeData(region, step)
+		mu.Lock()
+		defer mu.Unlock()
+		if !live {
+			fmt.Printf("%s\n%s", bold("━━ "+g.Title), out.String())
+		}
+		printSyncResults(run, region, results, err)
 	})
 
-	// Streaming
-	printSyncSection("Queues & Streaming", func() ([]sync.SyncResult, error) {
-		return sync.SyncStreamingData(region, step)
-	})
+	// Commit before exporting, so the warehouse gets what was synced.
+	deliveries := run.Finish()
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err := run.Err(); err != nil {
+		fmt.Printf("\n%s Sync failed after %s; the cache was rolled back: %s\n", red("✗"), elapsed, dim(err.Error()))
+		return
+	}
+	fmt.Printf("\n%s in %s\n", bold("Done"), dim(elapsed.String()))
 
-	// AI
-	printSyncSection("AI & ML", func() ([]sync.SyncResult, error) {
-		return sync.SyncAIData(region, step)
-	})
+	if uri, _ := sync.GetSetting("warehouse_uri"); uri != "" {
+		if objs, err := warehouse.Export(uri, []string{region}, start); err != nil {
+			fmt.Printf("%s warehouse export: %s\n", red("✗"), dim(err.Error()))
+		} else {
+			for _, o := range objs {
+				fmt.Printf("%s warehouse %s %s\n", green("✓"), o.URI, dim(fmt.Sprintf("(%d resources)", o.Rows)))
+			}
+		}
+	}
 
-	// IAM (global)
-	printSyncSection("IAM", func() ([]sync.SyncResult, error) {
-		return sync.SyncIAMData(step)
-	})
+	printDeliveries(deliveries)
+}
 
-	elapsed := time.Since(start).Round(time.Millisecond)
-	fmt.Printf("\n%s in %s\n", bold("Done"), dim(elapsed.String()))
+// printDeliveries reports each hook run after a sync or audit.
+func printDeliveries(deliveries []hooks.Delivery) {
+	for _, d := range deliveries {
+		if d.Err != nil {
+			fmt.Printf("%s hook %s %s: %s\n", red("✗"), d.Hook.Event, d.Hook.Target, dim(d.Err.Error()))
+		} else {
+			fmt.Printf("%s hook %s %s\n", green("✓"), d.Hook.Event, dim(d.Hook.Target))
+		}
+	}
 }
 
-func printSyncSection(name string, fn func() ([]sync.SyncResult, error)) {
-	fmt.Printf("%s\n", bold("━━ "+name))
-	results, err := fn()
+func printSyncResults(run *hooks.Run, region string, results []sync.SyncResult, err error) {
 	if err != nil {
+		run.Fail(err)
 		fmt.Printf("  %s %s\n", red("✗"), err.Error())
 		return
 	}
+	sync.RecordSyncResults(region, results)
+	run.Add(results)
 
 	total := 0
 	errors := 0
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// RunTags lists the synced resources matching f with all of their tags,
+// or the tag keys in use when f names no key.
+func RunTags(f sync.TagFilter, format string) error {
+	resources, err := sync.QueryTags(f)
+	if err != nil {
+		return err
+	}
+
+	if format == "csv" {
+		cw := csv.NewWriter(os.Stdout)
+		cw.Write([]string{"region", "service", "type", "id", "arn", "key", "value"})
+		for _, r := range resources {
+			for _, t := range r.Tags {
+				cw.Write([]string{r.Region, r.Service, r.ResourceType, r.ResourceId, r.Arn, t.Key, t.Value})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	scope := "all regions"
+	if f.Region != "" {
+		scope = f.Region
+	}
+	fmt.Printf("%s  %s\n\n", bold("saws tags"), dim(scope))
+
+	if f.Key == "" && f.Service == "" {
+		keys, err := sync.TagKeys(f.Region)
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			fmt.Println(dim("  No tags synced. Run 'saws sync' first."))
+			return nil
+		}
+		for i, k := range keys {
+			prefix := "├─"
+			if i == len(keys)-1 {
+				prefix = "└─"
+			}
+			values := strings.Join(k.Values, ", ")
+			if len(values) > 60 {
+				values = values[:57] + "..."
+			}
+			fmt.Printf("%s %-32s %s  %s\n", prefix, cyan(k.Key), dim(fmt.Sprintf("%d resources", k.Count)), values)
+		}
+		fmt.Printf("\n%d keys\n", len(keys))
+		fmt.Println(dim("Run 'saws tags <key>' or 'saws tags <key>=<value>' to list resources."))
+		return nil
+	}
+
+	if len(resources) == 0 {
+		fmt.Println(dim("  No matching resources."))
+		return nil
+	}
+	for i, r := range resources {
+		if i == 0 || resources[i-1].Service != r.Service {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(bold(r.Service))
+		}
+		prefix := "├─"
+		if i == len(resources)-1 || resources[i+1].Service != r.Service {
+			prefix = "└─"
+		}
+		var tags []string
+		for _, t := range r.Tags {
+			tags = append(tags, t.Key+"="+t.Value)
+		}
+		name := r.ResourceId
+		if r.ResourceType != "" {
+			name = r.ResourceType + "/" + name
+		}
+		fmt.Printf("%s %-40s %-14s %s\n", prefix, cyan(name), dim(r.Region), strings.Join(tags, "  "))
+	}
+	fmt.Printf("\n%d resources\n", len(resources))
+	return nil
+}
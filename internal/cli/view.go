@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/estrados/simply-aws/internal/sync"
 )
@@ -18,6 +20,17 @@ func yellow(s string) string  { return "\033[33m" + s + "\033[0m" }
 func red(s string) string     { return "\033[31m" + s + "\033[0m" }
 func magenta(s string) string { return "\033[35m" + s + "\033[0m" }
 
+// runState colors the outcome of a job or crawler run.
+func runState(state string) string {
+	switch state {
+	case "SUCCEEDED":
+		return green(state)
+	case "FAILED", "ERROR", "TIMEOUT", "TIMED_OUT", "FAULT":
+		return red(state)
+	}
+	return yellow(state)
+}
+
 func truncID(id string, n int) string {
 	if len(id) <= n {
 		return id
@@ -33,7 +46,26 @@ func header(title string) {
 func printMenu(region string) {
 	line := strings.Repeat("━", 35)
 	fmt.Printf("\n%s %s %s\n\n", bold("simply-aws"), bold("━━"), dim(region+" "+line[:35-len(region)]))
+	if banners := sync.Banners(sync.BannerContext{Region: region}); len(banners) > 0 {
+		for _, b := range banners {
+			mark := yellow("⚠")
+			if b.Level == "error" {
+				mark = red("⚠")
+			}
+			fmt.Printf("  %s %s\n", mark, b.Title)
+			if b.Hint != "" {
+				fmt.Printf("      %s\n", dim(b.Hint))
+			}
+			for _, item := range b.Items {
+				fmt.Printf("      %s  %s\n", item.Label, dim(strings.TrimSpace(item.Tag+"  "+item.Note)))
+			}
+		}
+		fmt.Println()
+	}
 	fmt.Printf("  %s  Region [%s]\n", bold("0"), cyan(region))
+	if accounts, _ := sync.CachedAccounts(); len(accounts) > 1 {
+		fmt.Printf("  %s  Account [%s]\n", bold("a"), cyan(sync.Account()))
+	}
 	fmt.Printf("  %s  Network\n", bold("1"))
 	fmt.Printf("  %s  Compute\n", bold("2"))
 	fmt.Printf("  %s  Database\n", bold("3"))
@@ -41,6 +73,7 @@ func printMenu(region string) {
 	fmt.Printf("  %s  Queues & Streaming\n", bold("5"))
 	fmt.Printf("  %s  AI & ML\n", bold("6"))
 	fmt.Printf("  %s  IAM\n", bold("7"))
+	fmt.Printf("  %s  CI/CD\n", bold("8"))
 	fmt.Printf("  %s  Quit\n", bold("q"))
 	fmt.Printf("\n%s ", bold("▸"))
 }
@@ -67,6 +100,32 @@ func switchRegion(scanner *bufio.Scanner) string {
 	return ""
 }
 
+// switchAccount lists the cached accounts and shows the one picked.
+func switchAccount(scanner *bufio.Scanner) {
+	accounts, err := sync.CachedAccounts()
+	if err != nil || len(accounts) == 0 {
+		return
+	}
+	fmt.Println()
+	for i, a := range accounts {
+		fmt.Printf("  %s  %s  %s\n", bold(fmt.Sprintf("%d", i+1)), a.ID, dim("synced "+sync.FormatAge(time.Since(a.SyncedAt))))
+	}
+	fmt.Printf("\n%s ", bold("▸"))
+	if !scanner.Scan() {
+		return
+	}
+	var idx int
+	if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "%d", &idx); err == nil && idx >= 1 && idx <= len(accounts) {
+		sync.UseAccount(accounts[idx-1].ID)
+	}
+}
+
+// viewTabs maps menu choices to the web tab whose cache keys they show.
+var viewTabs = map[string]string{
+	"1": "net", "2": "compute", "3": "database", "4": "s3",
+	"5": "streaming", "6": "ai", "7": "iam", "8": "cicd",
+}
+
 // RunView starts the interactive CLI view loop.
 func RunView(defaultRegion string) {
 	region := defaultRegion
@@ -78,28 +137,79 @@ func RunView(defaultRegion string) {
 			break
 		}
 		choice := strings.TrimSpace(scanner.Text())
+		tab, isSection := viewTabs[choice]
+		if isSection {
+			offerResync(scanner, tab, region)
+		}
 		switch choice {
 		case "0":
 			if r := switchRegion(scanner); r != "" {
 				region = r
 			}
+		case "a", "A":
+			switchAccount(scanner)
 		case "1":
 			printNetwork(region)
+			printProviderItems("net")
 		case "2":
 			printCompute(region)
+			printProviderItems("compute")
 		case "3":
 			printDatabase(region)
+			printProviderItems("database")
 		case "4":
 			printS3(region)
+			printProviderItems("s3")
 		case "5":
 			printStreaming(region)
 		case "6":
 			printAI(region)
 		case "7":
-			printIAM()
+			printIAM(region)
+		case "8":
+			printCICD(region)
 		case "q", "Q":
 			return
 		}
+		if isSection {
+			printSyncAge(tab, region)
+		}
+	}
+}
+
+// offerResync asks to re-sync the region when a section's data is past
+// stale_after_hours, or re-syncs straight away when auto_resync is on.
+func offerResync(scanner *bufio.Scanner, tab, region string) {
+	age, ok := sync.TabAge(tab, region)
+	if !ok || !sync.IsStale(age) || sync.ReadOnly() {
+		return
+	}
+	if _, err := exec.LookPath("aws"); err != nil {
+		return
+	}
+	if !sync.SignedIn() {
+		return
+	}
+	if !sync.AutoResync() {
+		fmt.Printf("\n%s %s %s ", yellow("⚠"), "Synced "+sync.FormatAge(age)+". Re-sync "+region+" first?", dim("[y/N]"))
+		if !scanner.Scan() || !strings.EqualFold(strings.TrimSpace(scanner.Text()), "y") {
+			return
+		}
+	}
+	fmt.Println()
+	RunSync(region)
+}
+
+// printSyncAge ends a section with how old its data is.
+func printSyncAge(tab, region string) {
+	age, ok := sync.TabAge(tab, region)
+	switch {
+	case !ok:
+		fmt.Println(dim("\n  not synced yet — run 'saws sync'"))
+	case sync.IsStale(age):
+		fmt.Println("\n  " + yellow("synced "+sync.FormatAge(age)+" — stale, run 'saws sync'"))
+	default:
+		fmt.Println(dim("\n  synced " + sync.FormatAge(age)))
 	}
 }
 
@@ -126,7 +236,11 @@ func printNetwork(region string) {
 		if vpc.IsDefault {
 			name += dim(" (default)")
 		}
-		fmt.Printf("%s  %-30s %s  %s\n", bold("VPC"), cyan(name), vpc.CidrBlock, green(vpc.State))
+		flowTag := ""
+		if data.MissingFlowLogs(vpc.VpcId) {
+			flowTag = "  " + red("no flow logs")
+		}
+		fmt.Printf("%s  %-30s %s  %s%s\n", bold("VPC"), cyan(name), vpc.CidrBlock, green(vpc.State), flowTag)
 
 		// Subnets
 		subnets := filterByVPC(data.Subnets, vpc.VpcId)
@@ -145,7 +259,14 @@ func printNetwork(region string) {
 				if len(az) > 2 {
 					az = az[len(az)-2:]
 				}
-				fmt.Printf("%s %-22s %s  %s  %d IPs\n", prefix, cyan(name), s.CidrBlock, dim(az), s.AvailableIPs)
+				extra := ""
+				if enis := data.ENIsInSubnet(s.SubnetId); len(enis) > 0 {
+					extra += dim(fmt.Sprintf("  %d ENIs", len(enis)))
+				}
+				for _, e := range data.EIPsInSubnet(s.SubnetId) {
+					extra += "  " + green("eip "+e.PublicIp)
+				}
+				fmt.Printf("%s %-22s %s  %s  %d IPs%s\n", prefix, cyan(name), s.CidrBlock, dim(az), s.AvailableIPs, extra)
 			}
 		}
 
@@ -195,6 +316,99 @@ func printNetwork(region string) {
 			}
 		}
 
+		// Peering
+		for _, p := range data.PeeringsForVPC(vpc.VpcId) {
+			peer := p.Peer(vpc.VpcId)
+			label := data.VPCName(peer.VpcId)
+			if label == "" {
+				label = peer.VpcId
+			}
+			extra := ""
+			if peer.Region != "" && peer.Region != region {
+				extra += "  " + dim(peer.Region)
+			}
+			if p.Requester.OwnerId != p.Accepter.OwnerId {
+				extra += "  " + dim("account "+peer.OwnerId)
+			}
+			status := green(p.Status)
+			if p.Status != "active" {
+				status = yellow(p.Status)
+			}
+			fmt.Printf("├─ PCX  ⇄ %s  %s  %s%s\n", cyan(label), peer.CidrBlock, status, extra)
+		}
+
+		// VPC Endpoints
+		if eps := data.EndpointsForVPC(vpc.VpcId); len(eps) > 0 {
+			var gateway, iface []string
+			for _, e := range eps {
+				if e.Type == "Gateway" {
+					gateway = append(gateway, e.Service())
+				} else {
+					iface = append(iface, e.Service())
+				}
+			}
+			if len(gateway) > 0 {
+				fmt.Printf("├─ VPCE gateway    %s\n", cyan(strings.Join(gateway, ", ")))
+			}
+			if len(iface) > 0 {
+				fmt.Printf("├─ VPCE interface  %s\n", cyan(strings.Join(iface, ", ")))
+			}
+		}
+
+		// Transit Gateways
+		for _, link := range data.TGWAttachmentsForVPC(vpc.VpcId) {
+			label := link.Gateway.Name
+			if label == "" {
+				label = link.Gateway.TransitGatewayId
+			}
+			var peers []string
+			for _, a := range link.Gateway.Reachable(link.Attachment.AttachmentId) {
+				peer := a.ResourceId
+				if a.ResourceType == "vpc" {
+					if name := data.VPCName(a.ResourceId); name != "" {
+						peer = name
+					}
+				} else {
+					peer = a.ResourceType + " " + peer
+				}
+				peers = append(peers, peer)
+			}
+			reaches := ""
+			if len(peers) > 0 {
+				reaches = "  " + dim("→ "+strings.Join(peers, ", "))
+			}
+			fmt.Printf("├─ TGW  %s  %s%s\n", cyan(label), green(link.Attachment.State), reaches)
+		}
+
+		// VPN gateways with their VPN and Direct Connect links
+		for _, link := range data.VPNGatewaysForVPC(vpc.VpcId) {
+			label := link.Gateway.Name
+			if label == "" {
+				label = link.Gateway.VpnGatewayId
+			}
+			var parts []string
+			for _, v := range link.VPNs {
+				parts = append(parts, tunnelSummary(v))
+			}
+			for _, vif := range link.Interfaces {
+				parts = append(parts, "DX "+vif.State)
+			}
+			extra := ""
+			if len(parts) > 0 {
+				extra = "  " + strings.Join(parts, ", ")
+			}
+			fmt.Printf("├─ VGW  %s  %s%s\n", cyan(label), green(link.Gateway.State), extra)
+		}
+
+		// Flow logs
+		for _, f := range data.FlowLogsForVPC(vpc.VpcId) {
+			status := green(f.Status)
+			if f.Failing() {
+				status = red("delivery failing")
+			}
+			fmt.Printf("├─ FLOW %-8s %s  %s  %s\n", f.ResourceType(), cyan(f.Destination), dim(f.DestinationType+" · "+f.TrafficType), status)
+		}
+
 		// Route Tables
 		rts := filterRTsByVPC(data.RouteTables, vpc.VpcId)
 		if len(rts) > 0 {
@@ -226,12 +440,188 @@ func printNetwork(region string) {
 				if i == len(lbs)-1 {
 					prefix = "   └─"
 				}
-				fmt.Printf("%s %-22s %-6s %s  %s\n", prefix, cyan(lb.Name), dim(lb.Type), dim(lb.Scheme), green(lb.State))
+				waf := ""
+				if acl := data.WAF.WebACLFor(lb.Arn); acl != nil {
+					waf = "  " + green("waf:"+acl.Name)
+				}
+				var ports []string
+				for _, l := range lb.Listeners {
+					ports = append(ports, fmt.Sprintf("%s:%d", l.Protocol, l.Port))
+				}
+				listeners := ""
+				if len(ports) > 0 {
+					listeners = "  " + dim(strings.Join(ports, ","))
+				}
+				healthy, targets := 0, 0
+				for _, tg := range data.TargetGroups {
+					if tg.AttachedTo(lb.Arn) {
+						healthy += tg.HealthyTargets()
+						targets += len(tg.Targets)
+					}
+				}
+				if targets > 0 {
+					health := fmt.Sprintf("%d/%d healthy", healthy, targets)
+					if healthy < targets {
+						listeners += "  " + yellow(health)
+					} else {
+						listeners += "  " + green(health)
+					}
+				}
+				fmt.Printf("%s %-22s %-6s %s  %s%s%s\n", prefix, cyan(lb.Name), dim(lb.Type), dim(lb.Scheme), green(lb.State), waf, listeners)
+			}
+		}
+
+		fmt.Println()
+	}
+
+	if h := data.Hybrid; !h.Empty() {
+		fmt.Printf("%s (%d VPN, %d Direct Connect)\n", bold("Hybrid Connectivity"), len(h.VPNConnections), len(h.DXConnections))
+		total := len(h.VPNConnections) + len(h.DXConnections)
+		n := 0
+		for _, v := range h.VPNConnections {
+			n++
+			prefix := "├─"
+			if n == total {
+				prefix = "└─"
+			}
+			label := v.Name
+			if label == "" {
+				label = v.VpnConnectionId
+			}
+			target := v.VpnGatewayId
+			if v.TransitGatewayId != "" {
+				target = v.TransitGatewayId
+			}
+			peer := v.CustomerGatewayId
+			if cgw := h.CustomerGateway(v.CustomerGatewayId); cgw != nil {
+				peer = cgw.IpAddress
+			}
+			fmt.Printf("%s VPN %-22s %s  %s  %s\n", prefix, cyan(label), green(v.State), tunnelSummary(v), dim(peer+" → "+target))
+		}
+		for _, c := range h.DXConnections {
+			n++
+			prefix, indent := "├─", "│  "
+			if n == total {
+				prefix, indent = "└─", "   "
+			}
+			label := c.Name
+			if label == "" {
+				label = c.ConnectionId
+			}
+			state := green(c.State)
+			if c.State != "available" {
+				state = red(c.State)
+			}
+			fmt.Printf("%s DX  %-22s %s  %s\n", prefix, cyan(label), state, dim(c.Bandwidth+" · "+c.Location))
+			vifs := h.InterfacesOn(c.ConnectionId)
+			for j, vif := range vifs {
+				p := "├─"
+				if j == len(vifs)-1 {
+					p = "└─"
+				}
+				name := vif.Name
+				if name == "" {
+					name = vif.VirtualInterfaceId
+				}
+				bgp := ""
+				if vif.BGPStatus == "up" {
+					bgp = dim("BGP up")
+				} else if vif.BGPStatus != "" {
+					bgp = red("BGP " + vif.BGPStatus)
+				}
+				fmt.Printf("%s%s %-8s %s  %s  %s\n", indent, p, dim(vif.Type), name, green(vif.State), bgp)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(data.TransitGateways) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Transit Gateways"), len(data.TransitGateways))
+		for i, g := range data.TransitGateways {
+			prefix, indent := "├─", "│  "
+			if i == len(data.TransitGateways)-1 {
+				prefix, indent = "└─", "   "
+			}
+			label := g.Name
+			if label == "" {
+				label = g.TransitGatewayId
+			}
+			fmt.Printf("%s %-22s %s  %s\n", prefix, cyan(label), green(g.State), dim(fmt.Sprintf("%d attachments", len(g.Attachments))))
+			for j, a := range g.Attachments {
+				p := "├─"
+				if j == len(g.Attachments)-1 && len(g.RouteTables) == 0 {
+					p = "└─"
+				}
+				target := a.ResourceId
+				if a.ResourceType == "vpc" {
+					if name := data.VPCName(a.ResourceId); name != "" {
+						target += " (" + name + ")"
+					}
+				}
+				fmt.Printf("%s%s %-8s %s  %s\n", indent, p, dim(a.ResourceType), target, green(a.State))
+			}
+			for j, rt := range g.RouteTables {
+				p := "├─"
+				if j == len(g.RouteTables)-1 {
+					p = "└─"
+				}
+				name := rt.Name
+				if name == "" {
+					name = rt.RouteTableId
+				}
+				extra := ""
+				if n := rt.Blackholes(); n > 0 {
+					extra = "  " + red(fmt.Sprintf("%d blackhole", n))
+				}
+				fmt.Printf("%s%s %-8s %s  %s%s\n", indent, p, dim("rt"), name, dim(fmt.Sprintf("%d routes", len(rt.Routes))), extra)
 			}
 		}
+		fmt.Println()
+	}
 
+	if eips := data.UnassociatedEIPs(); len(eips) > 0 {
+		fmt.Printf("%s (%d)  %s\n", bold("Unassociated Elastic IPs"), len(eips), dim("billed hourly"))
+		for i, e := range eips {
+			prefix := "├─"
+			if i == len(eips)-1 {
+				prefix = "└─"
+			}
+			fmt.Printf("%s %-22s %s\n", prefix, yellow(e.PublicIp), dim(e.AllocationId))
+		}
 		fmt.Println()
 	}
+
+	printCertificates(data.Certificates)
+}
+
+func printCertificates(certs []sync.Certificate) {
+	if len(certs) == 0 {
+		return
+	}
+	warn := sync.CertWarningDays()
+	fmt.Printf("%s (%d)\n", bold("Certificates"), len(certs))
+	for i, c := range certs {
+		prefix := "├─"
+		if i == len(certs)-1 {
+			prefix = "└─"
+		}
+		expiry := dim("not issued")
+		if !c.ExpiresAt().IsZero() {
+			days := c.DaysLeft()
+			label := fmt.Sprintf("%s (%dd)", c.ExpiresAt().Format("2006-01-02"), days)
+			if days < 0 {
+				label = c.ExpiresAt().Format("2006-01-02") + " (expired)"
+			}
+			if c.ExpiringWithin(warn) {
+				expiry = red(label)
+			} else {
+				expiry = green(label)
+			}
+		}
+		fmt.Printf("%s %-34s %-10s %s  %s\n", prefix, cyan(c.DomainName), dim(c.Status), expiry,
+			dim(fmt.Sprintf("%d in use", len(c.InUseBy))))
+	}
+	fmt.Println()
 }
 
 func filterByVPC(subnets []sync.Subnet, vpcId string) []sync.Subnet {
@@ -306,12 +696,55 @@ func printCompute(region string) {
 			if inst.PublicIP != "" {
 				ip = inst.PublicIP
 			}
-			fmt.Printf("%s %-24s %-14s %s  %s\n", prefix, cyan(name), dim(inst.InstanceType), stateColor(inst.State), dim(ip))
+			advice := ""
+			if r := sync.SuggestEC2Size(inst); r != nil {
+				advice = "  " + yellow(fmt.Sprintf("%.0f%% cpu → schedule", r.CPUAvg))
+				if r.Action == "downsize" {
+					advice = "  " + yellow(fmt.Sprintf("%.0f%% cpu → %s", r.CPUAvg, r.SuggestedType))
+				}
+			}
+			if inst.AutoScalingGroup != "" {
+				advice += "  " + dim("asg:"+inst.AutoScalingGroup)
+			}
+			fmt.Printf("%s %-24s %-14s %s  %s%s\n", prefix, cyan(name), dim(inst.InstanceType), stateColor(inst.State), dim(ip), advice)
+		}
+		fmt.Println()
+	}
+
+	// Auto Scaling groups with their launch source and members
+	if asg := data.AutoScaling; asg != nil && len(asg.Groups) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Auto Scaling Groups"), len(asg.Groups))
+		for _, g := range asg.Groups {
+			mixed := ""
+			if mi := g.MixedInstances; mi != nil {
+				mixed = "  " + yellow(fmt.Sprintf("mixed %s, %d%% on-demand above %d",
+					strings.Join(mi.InstanceTypes, "/"), mi.OnDemandPercentAbove, mi.OnDemandBase))
+			}
+			fmt.Printf("├─ %s  %d/%d/%d  %s%s\n", cyan(g.Name),
+				g.DesiredCapacity, g.MinSize, g.MaxSize, dim(g.LaunchSource()), mixed)
+			for _, tg := range g.TargetGroupNames() {
+				fmt.Printf("│  ├─ tg %s\n", tg)
+			}
+			for j, inst := range g.Instances {
+				prefix := "│  ├─"
+				if j == len(g.Instances)-1 {
+					prefix = "│  └─"
+				}
+				state := green(inst.LifecycleState)
+				if inst.LifecycleState != "InService" {
+					state = yellow(inst.LifecycleState)
+				}
+				if inst.HealthStatus != "Healthy" {
+					state += " " + red(inst.HealthStatus)
+				}
+				fmt.Printf("%s %s  %s  %s\n", prefix, inst.InstanceId, dim(inst.InstanceType), state)
+			}
 		}
 		fmt.Println()
 	}
 
 	// ECS
+	mesh, _ := sync.LoadServiceMeshData(region)
 	if len(data.ECS) > 0 {
 		fmt.Printf("%s (%d)\n", bold("ECS Clusters"), len(data.ECS))
 		for _, cluster := range data.ECS {
@@ -323,23 +756,107 @@ func printCompute(region string) {
 				if j == len(cluster.ECSServices)-1 && len(cluster.Tasks) == 0 {
 					prefix = "│  └─"
 				}
-				fmt.Printf("%s svc %s  %d/%d  %s\n", prefix,
-					yellow(svc.ServiceName), svc.RunningCount, svc.DesiredCount, dim(svc.LaunchType))
+				size := ""
+				if r := sync.SuggestECSTaskSize(svc); r != nil {
+					size = fmt.Sprintf("  %d/%d → %d/%d", r.Cpu, r.Memory, r.SuggestedCpu, r.SuggestedMemory)
+					if r.Direction() == "lower" {
+						size = green(size)
+					} else {
+						size = yellow(size)
+					}
+				}
+				fmt.Printf("%s svc %s  %d/%d  %s%s\n", prefix,
+					yellow(svc.ServiceName), svc.RunningCount, svc.DesiredCount, dim(svc.LaunchType), size)
+				if svc.ServiceConnect != nil {
+					var serves, calls []string
+					for _, e := range svc.ServiceConnect.Endpoints {
+						serves = append(serves, e.Address())
+					}
+					for _, p := range data.ServiceConnectPeers(svc) {
+						calls = append(calls, p.Service)
+					}
+					line := "service connect " + mesh.NamespaceName(svc.ServiceConnect.Namespace)
+					if len(serves) > 0 {
+						line += "  serves " + strings.Join(serves, ", ")
+					}
+					if len(calls) > 0 {
+						line += "  → " + strings.Join(calls, ", ")
+					}
+					indent := "│  │    "
+					if prefix == "│  └─" {
+						indent = "│       "
+					}
+					fmt.Printf("%s%s\n", indent, dim(line))
+				}
 			}
 			for j, task := range cluster.Tasks {
 				prefix := "│  ├─"
 				if j == len(cluster.Tasks)-1 {
 					prefix = "│  └─"
 				}
-				fmt.Printf("%s task %s  %s  %s\n", prefix,
-					dim(truncID(task.TaskArn, 16)), task.LastStatus, dim(task.LaunchType))
+				names := ""
+				if n := mesh.CloudMapNamesFor(task.PrivateIP); len(n) > 0 {
+					names = "  " + cyan(strings.Join(n, ", "))
+				}
+				fmt.Printf("%s task %s  %s  %s%s\n", prefix,
+					dim(truncID(task.TaskArn, 16)), task.LastStatus, dim(task.LaunchType), names)
 			}
 		}
 		fmt.Println()
 	}
 
-	// Lambda
+	// Cloud Map: each discoverable name and what its registrations resolve to
+	if mesh != nil {
+		for _, ns := range mesh.Namespaces {
+			if len(ns.Services) == 0 {
+				continue
+			}
+			fmt.Printf("%s %s  %s\n", bold("Cloud Map"), cyan(ns.Name), dim(ns.Type))
+			for i, svc := range ns.Services {
+				prefix, cont := "├─", "│  "
+				if i == len(ns.Services)-1 {
+					prefix, cont = "└─", "   "
+				}
+				fmt.Printf("%s %s  %s\n", prefix, yellow(ns.DNSName(svc)), dim(fmt.Sprintf("%d instances", len(svc.Instances))))
+				for j, inst := range svc.Instances {
+					p := "├─"
+					if j == len(svc.Instances)-1 {
+						p = "└─"
+					}
+					target := dim("not cached")
+					if t := data.ResolveCloudMapInstance(inst); t != nil {
+						target = t.Type + " " + t.Label
+					}
+					fmt.Printf("%s%s %-21s %s\n", cont, p, inst.Address(), target)
+				}
+			}
+			fmt.Println()
+		}
+	}
+
+	// App Mesh: virtual services and the nodes they route to
+	if mesh != nil {
+		for _, m := range mesh.Meshes {
+			fmt.Printf("%s %s (%d)\n", bold("App Mesh"), cyan(m.Name), len(m.Services))
+			for i, vs := range m.Services {
+				prefix := "├─"
+				if i == len(m.Services)-1 {
+					prefix = "└─"
+				}
+				nodes := m.NodesFor(vs.Name)
+				target := dim("no provider")
+				if len(nodes) > 0 {
+					target = "→ " + strings.Join(nodes, ", ")
+				}
+				fmt.Printf("%s %s  %s\n", prefix, yellow(vs.Name), target)
+			}
+			fmt.Println()
+		}
+	}
+
+	// Lambda — noisiest first when metrics were synced
 	if len(data.Lambda) > 0 {
+		sync.SortLambdas(data.Lambda, "errors")
 		fmt.Printf("%s (%d)\n", bold("Lambda Functions"), len(data.Lambda))
 		for i, fn := range data.Lambda {
 			prefix := "├─"
@@ -350,13 +867,116 @@ func printCompute(region string) {
 			if runtime == "" {
 				runtime = "container"
 			}
-			fmt.Printf("%s %-30s %-14s %dMB  %ds\n", prefix,
-				cyan(fn.FunctionName), dim(runtime), fn.MemorySize, fn.Timeout)
+			metrics := ""
+			if m := fn.Metrics; m != nil {
+				errs := fmt.Sprintf("%.0f err (%.1f%%)", m.Errors, m.ErrorRate())
+				if m.Errors > 0 {
+					errs = red(errs)
+				} else {
+					errs = dim(errs)
+				}
+				thr := dim(fmt.Sprintf("%.0f thr", m.Throttles))
+				if m.Throttles > 0 {
+					thr = yellow(fmt.Sprintf("%.0f thr", m.Throttles))
+				}
+				metrics = fmt.Sprintf("  %s  %s  %s", errs, thr, dim(fmt.Sprintf("p95 %.0fms", m.DurationP95)))
+			}
+			if fn.ReservedConcurrency != nil {
+				metrics += dim(fmt.Sprintf("  reserved %d", *fn.ReservedConcurrency))
+			}
+			if n := fn.ProvisionedTotal(); n > 0 {
+				metrics += dim(fmt.Sprintf("  provisioned %d", n))
+			}
+			fmt.Printf("%s %-30s %-14s %dMB  %ds%s\n", prefix,
+				cyan(fn.FunctionName), dim(runtime), fn.MemorySize, fn.Timeout, metrics)
+		}
+		fmt.Println()
+	}
+
+	if len(data.AppRunner) > 0 {
+		fmt.Printf("%s (%d)\n", bold("App Runner Services"), len(data.AppRunner))
+		for i, svc := range data.AppRunner {
+			prefix := "├─"
+			if i == len(data.AppRunner)-1 {
+				prefix = "└─"
+			}
+			stateColor := green
+			if svc.Status != "RUNNING" {
+				stateColor = yellow
+			}
+			fmt.Printf("%s %-30s %-16s %s  %s\n", prefix, cyan(svc.Name),
+				dim(svc.Cpu+" · "+svc.Memory), dim(svc.Scaling()), stateColor(svc.Status))
+		}
+		fmt.Println()
+	}
+
+	if len(data.Lightsail) > 0 || len(data.LightsailContainers) > 0 {
+		fmt.Printf("%s (%d instances, %d container services)\n", bold("Lightsail"), len(data.Lightsail), len(data.LightsailContainers))
+		total := len(data.Lightsail) + len(data.LightsailContainers)
+		for i, inst := range data.Lightsail {
+			prefix := "├─"
+			if i == total-1 {
+				prefix = "└─"
+			}
+			stateColor := green
+			if inst.State != "running" {
+				stateColor = yellow
+			}
+			fmt.Printf("%s %-30s %-28s %-15s %s\n", prefix, cyan(inst.Name), dim(inst.Size()), inst.PublicIP, stateColor(inst.State))
+		}
+		for i, c := range data.LightsailContainers {
+			prefix := "├─"
+			if len(data.Lightsail)+i == total-1 {
+				prefix = "└─"
+			}
+			fmt.Printf("%s %-30s %-28s %s\n", prefix, cyan(c.Name),
+				dim(fmt.Sprintf("%s × %d (%s)", c.Power, c.Scale, c.Size())), green(c.State))
+		}
+		fmt.Println()
+	}
+
+	// EBS — list only volumes that need attention, totals for the rest
+	if len(data.EBS) > 0 || len(data.Snapshots) > 0 {
+		t := sync.SumEBS(region, data.EBS, data.Snapshots)
+		fmt.Printf("%s (%d, %d GiB)  %s\n", bold("EBS Volumes"), t.Volumes, t.VolumeGiB,
+			dim(fmt.Sprintf("%d snapshots, %d GiB source", t.Snapshots, t.SnapshotGiB)))
+		var flagged []sync.EBSVolume
+		for _, v := range data.EBS {
+			if v.Unattached() || !v.Encrypted {
+				flagged = append(flagged, v)
+			}
+		}
+		for i, v := range flagged {
+			prefix := "├─"
+			if i == len(flagged)-1 {
+				prefix = "└─"
+			}
+			name := v.Name
+			if name == "" {
+				name = v.VolumeId
+			}
+			var flags []string
+			if v.Unattached() {
+				flags = append(flags, yellow("unattached"))
+			}
+			if !v.Encrypted {
+				flags = append(flags, red("unencrypted"))
+			}
+			fmt.Printf("%s %-30s %s  %s\n", prefix, cyan(name),
+				dim(fmt.Sprintf("%5d GiB %-4s", v.Size, v.VolumeType)), strings.Join(flags, " "))
+		}
+		if totals, _ := sync.EBSTotalsByRegion(); len(totals) > 1 {
+			fmt.Println(dim("By region:"))
+			for _, rt := range totals {
+				fmt.Printf("  %-16s %3d volumes %6d GiB  %3d unattached %6d GiB  %3d snapshots\n",
+					rt.Region, rt.Volumes, rt.VolumeGiB, rt.Unattached, rt.UnattachedGiB, rt.Snapshots)
+			}
 		}
 		fmt.Println()
 	}
 
-	if len(data.EC2) == 0 && len(data.ECS) == 0 && len(data.Lambda) == 0 {
+	if len(data.EC2) == 0 && len(data.ECS) == 0 && len(data.Lambda) == 0 && len(data.EBS) == 0 &&
+		len(data.AppRunner) == 0 && len(data.Lightsail) == 0 && len(data.LightsailContainers) == 0 {
 		fmt.Println(dim("  No compute resources found"))
 	}
 }
@@ -371,11 +991,63 @@ func printDatabase(region string) {
 	}
 	header("Database")
 
-	if len(data.RDS) > 0 {
-		fmt.Printf("%s (%d)\n", bold("RDS Instances"), len(data.RDS))
-		for i, db := range data.RDS {
+	standalone := data.StandaloneRDS()
+
+	families := []struct{ title, family string }{
+		{"Aurora Clusters", "aurora"}, {"DocumentDB Clusters", "docdb"}, {"Neptune Clusters", "neptune"},
+	}
+	for f, fam := range families {
+		clusters := data.ClustersOf(fam.family)
+		if len(clusters) == 0 {
+			continue
+		}
+		laterClusters := 0
+		for _, later := range families[f+1:] {
+			laterClusters += len(data.ClustersOf(later.family))
+		}
+		fmt.Printf("%s (%d)\n", bold(fam.title), len(clusters))
+		for i, c := range clusters {
+			last := i == len(clusters)-1 && laterClusters == 0 && len(standalone) == 0 && len(data.DynamoDB) == 0 && len(data.ElastiCache) == 0
+			prefix, indent := "├─", "│ "
+			if last {
+				prefix, indent = "└─", "  "
+			}
+			extra := ""
+			if c.MaxACU > 0 {
+				unit := "ACU"
+				if fam.family == "neptune" {
+					unit = "NCU"
+				}
+				extra += fmt.Sprintf(" %g-%g %s", c.MinACU, c.MaxACU, unit)
+			}
+			if c.GlobalClusterId != "" {
+				extra += " global:" + c.GlobalClusterId
+			}
+			if !c.StorageEncrypted {
+				extra += " " + yellow("unencrypted")
+			}
+			fmt.Printf("%s %-28s %-10s %s%s\n", prefix,
+				cyan(c.ClusterId), dim(c.Engine+" "+c.EngineVersion), green(c.Status), dim(extra))
+			for j, m := range c.Members {
+				sub := "├─"
+				if j == len(c.Members)-1 {
+					sub = "└─"
+				}
+				role := "reader"
+				if m.IsWriter {
+					role = "writer"
+				}
+				fmt.Printf("%s %s %-26s %s\n", indent, sub, m.InstanceId, dim(role))
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(standalone) > 0 {
+		fmt.Printf("%s (%d)\n", bold("RDS Instances"), len(standalone))
+		for i, db := range standalone {
 			prefix := "├─"
-			if i == len(data.RDS)-1 && len(data.DynamoDB) == 0 && len(data.ElastiCache) == 0 {
+			if i == len(standalone)-1 && len(data.DynamoDB) == 0 && len(data.ElastiCache) == 0 {
 				prefix = "└─"
 			}
 			multiAZ := ""
@@ -417,7 +1089,7 @@ func printDatabase(region string) {
 		fmt.Println()
 	}
 
-	if len(data.RDS) == 0 && len(data.DynamoDB) == 0 && len(data.ElastiCache) == 0 {
+	if len(data.RDS) == 0 && len(data.RDSClusters) == 0 && len(data.DynamoDB) == 0 && len(data.ElastiCache) == 0 {
 		fmt.Println(dim("  No database resources found"))
 	}
 }
@@ -462,12 +1134,85 @@ func printS3(region string) {
 				ver = " " + dim("versioned")
 			}
 			fmt.Printf("%s %-36s %s  %s%s\n", prefix, cyan(b.Name), dim(b.Region), access, ver)
+			indent := "│  "
+			if i == len(s3data.Buckets)-1 {
+				indent = "   "
+			}
+			for _, ap := range b.AccessPoints {
+				origin := yellow("internet")
+				if ap.VPCOnly() {
+					origin = green("vpc " + ap.VpcId)
+				}
+				if ap.PolicyPublic {
+					origin = red("PUBLIC")
+				}
+				fmt.Printf("%s%s %s  %s\n", indent, dim("access point"), ap.Name, origin)
+			}
 		}
 		fmt.Println()
+		if len(s3data.MRAPs) > 0 {
+			fmt.Printf("%s (%d)\n", bold("Multi-Region Access Points"), len(s3data.MRAPs))
+			for i, m := range s3data.MRAPs {
+				prefix := "├─"
+				if i == len(s3data.MRAPs)-1 {
+					prefix = "└─"
+				}
+				var buckets []string
+				for _, r := range m.Regions {
+					buckets = append(buckets, r.Bucket+" ("+r.Region+")")
+				}
+				access := green("private")
+				if m.PolicyPublic {
+					access = red("PUBLIC")
+				}
+				fmt.Printf("%s %-36s %s  %s\n", prefix, cyan(m.Name), access, dim(strings.Join(buckets, ", ")))
+			}
+			fmt.Println()
+		}
 	} else if err != nil {
 		fmt.Println(dim("  No S3 data cached"))
 	}
 
+	// File systems
+	storage, _ := sync.LoadStorageData(region)
+	if storage != nil && len(storage.EFS) > 0 {
+		fmt.Printf("%s (%d)\n", bold("EFS File Systems"), len(storage.EFS))
+		for i, fs := range storage.EFS {
+			prefix := "├─"
+			if i == len(storage.EFS)-1 {
+				prefix = "└─"
+			}
+			name := fs.Name
+			if name == "" {
+				name = fs.FileSystemId
+			}
+			enc := ""
+			if !fs.Encrypted {
+				enc = " " + yellow("unencrypted")
+			}
+			fmt.Printf("%s %-28s %s  %s  %d mount targets%s\n", prefix,
+				cyan(name), dim(fmt.Sprintf("%-12s", fs.ThroughputMode)), formatBytes(fs.SizeBytes), len(fs.MountTargets), enc)
+		}
+		fmt.Println()
+	}
+	if storage != nil && len(storage.FSx) > 0 {
+		fmt.Printf("%s (%d)\n", bold("FSx File Systems"), len(storage.FSx))
+		for i, fs := range storage.FSx {
+			prefix := "├─"
+			if i == len(storage.FSx)-1 {
+				prefix = "└─"
+			}
+			name := fs.Name
+			if name == "" {
+				name = fs.FileSystemId
+			}
+			fmt.Printf("%s %-28s %s  %d GiB  %s\n", prefix,
+				cyan(name), dim(fmt.Sprintf("%-8s", fs.Type)), fs.StorageCapacity, green(fs.State))
+		}
+		fmt.Println()
+	}
+	hasStorage := storage != nil && (len(storage.EFS) > 0 || len(storage.FSx) > 0)
+
 	// Data warehouse
 	dw, err := sync.LoadDataWarehouseData(region)
 	if err != nil {
@@ -481,20 +1226,91 @@ func printS3(region string) {
 			if i == len(dw.Redshift)-1 {
 				prefix = "└─"
 			}
-			fmt.Printf("%s %-28s %-14s %d nodes  %s\n", prefix,
-				cyan(c.ClusterIdentifier), dim(c.NodeType), c.NumberOfNodes, green(c.Status))
+			health := ""
+			if h := c.Health; h != nil {
+				if h.HasDisk {
+					disk := fmt.Sprintf("disk %.0f%%", h.DiskUsedPct)
+					if c.DiskWarning() {
+						disk = red(disk)
+					} else {
+						disk = dim(disk)
+					}
+					health += "  " + disk
+				}
+				if h.QueuedQueries > 0 {
+					health += "  " + yellow(fmt.Sprintf("%d queued (max %.0fs)", h.QueuedQueries, h.MaxQueueWait))
+				}
+				if h.LongRunning > 0 {
+					health += "  " + yellow(fmt.Sprintf("%d long-running", h.LongRunning))
+				}
+			}
+			fmt.Printf("%s %-28s %-14s %d nodes  %s%s\n", prefix,
+				cyan(c.ClusterIdentifier), dim(c.NodeType), c.NumberOfNodes, green(c.Status), health)
 		}
 		fmt.Println()
 	}
 
-	if len(dw.Athena) > 0 {
-		fmt.Printf("%s (%d)\n", bold("Athena Workgroups"), len(dw.Athena))
-		for i, a := range dw.Athena {
+	if len(dw.RedshiftWorkgroups) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Redshift Serverless"), len(dw.RedshiftWorkgroups))
+		for i, w := range dw.RedshiftWorkgroups {
+			prefix := "├─"
+			if i == len(dw.RedshiftWorkgroups)-1 {
+				prefix = "└─"
+			}
+			access := dim("private")
+			if w.PubliclyAccessible {
+				access = yellow("public")
+			}
+			fmt.Printf("%s %-28s %-14s %s  %s  %s\n", prefix,
+				cyan(w.WorkgroupName), dim(w.Capacity()), dim("ns "+w.NamespaceName), green(w.Status), access)
+		}
+		fmt.Println()
+	}
+
+	if len(dw.OpenSearch) > 0 {
+		fmt.Printf("%s (%d)\n", bold("OpenSearch Domains"), len(dw.OpenSearch))
+		for i, d := range dw.OpenSearch {
+			prefix := "├─"
+			if i == len(dw.OpenSearch)-1 {
+				prefix = "└─"
+			}
+			access := green("vpc")
+			if d.PubliclyAccessible {
+				access = red("PUBLIC")
+			}
+			enc := ""
+			if !d.EncryptedAtRest {
+				enc = " " + yellow("unencrypted")
+			}
+			fmt.Printf("%s %-28s %-18s %-18s %d nodes  %s  %s%s\n", prefix,
+				cyan(d.DomainName), dim(d.EngineVersion), dim(d.InstanceType), d.InstanceCount,
+				green(d.Status), access, enc)
+		}
+		fmt.Println()
+	}
+
+	if len(dw.Athena) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Athena Workgroups"), len(dw.Athena))
+		for i, a := range dw.Athena {
 			prefix := "├─"
 			if i == len(dw.Athena)-1 {
 				prefix = "└─"
 			}
-			fmt.Printf("%s %-28s %s\n", prefix, cyan(a.Name), green(a.State))
+			stats := ""
+			if st := a.Recent; st != nil {
+				stats = dim(fmt.Sprintf("  %d recent queries, %s scanned, ~$%.2f", st.Queries, formatBytes(st.BytesScanned), st.EstimatedCost()))
+			}
+			if len(a.NamedQueries) > 0 {
+				stats += dim(fmt.Sprintf("  %d saved queries", len(a.NamedQueries)))
+			}
+			fmt.Printf("%s %-28s %s%s\n", prefix, cyan(a.Name), green(a.State), stats)
+		}
+		if len(dw.AthenaCatalogs) > 0 {
+			var catalogs []string
+			for _, c := range dw.AthenaCatalogs {
+				catalogs = append(catalogs, c.Name+" "+dim("("+c.Type+")"))
+			}
+			fmt.Printf("   %s %s\n", dim("Data catalogs:"), strings.Join(catalogs, ", "))
 		}
 		fmt.Println()
 	}
@@ -506,12 +1322,76 @@ func printS3(region string) {
 			if i == len(dw.Glue)-1 {
 				prefix = "└─"
 			}
-			fmt.Printf("%s %-28s %s\n", prefix, cyan(g.Name), dim(g.Description))
+			fmt.Printf("%s %-28s %s  %s\n", prefix, cyan(g.Name), dim(fmt.Sprintf("%d tables", len(g.Tables))), dim(g.Description))
+		}
+		fmt.Println()
+	}
+
+	if len(dw.GlueCrawlers) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Glue Crawlers"), len(dw.GlueCrawlers))
+		for i, c := range dw.GlueCrawlers {
+			prefix := "├─"
+			if i == len(dw.GlueCrawlers)-1 {
+				prefix = "└─"
+			}
+			schedule := c.Schedule
+			if schedule == "" {
+				schedule = "on demand"
+			}
+			last := ""
+			if c.LastRun != "" {
+				last = "  last " + c.LastRun + " " + runState(c.LastStatus)
+			}
+			fmt.Printf("%s %-28s → %-20s %s%s\n", prefix, cyan(c.Name), c.DatabaseName, dim(schedule), last)
+		}
+		fmt.Println()
+	}
+
+	if len(dw.GlueJobs) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Glue Jobs"), len(dw.GlueJobs))
+		for i, j := range dw.GlueJobs {
+			prefix := "├─"
+			if i == len(dw.GlueJobs)-1 {
+				prefix = "└─"
+			}
+			last := dim("never run")
+			if j.LastRun != "" {
+				last = "last " + j.LastRun + " " + runState(j.LastRunState)
+			}
+			fmt.Printf("%s %-28s %-14s %-12s %s\n", prefix, cyan(j.Name), dim(j.Type), j.Capacity(), last)
 		}
 		fmt.Println()
 	}
 
-	if (s3data == nil || len(s3data.Buckets) == 0) && len(dw.Redshift) == 0 && len(dw.Athena) == 0 && len(dw.Glue) == 0 {
+	if lineage, _ := sync.DataLineage(region); len(lineage) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Data Lineage"), len(lineage))
+		for i, p := range lineage {
+			prefix := "├─"
+			if i == len(lineage)-1 {
+				prefix = "└─"
+			}
+			var steps []string
+			if p.Stream != "" {
+				if p.SourceType != "" {
+					steps = append(steps, p.SourceType+"/"+p.SourceId)
+				}
+				steps = append(steps, "firehose/"+p.Stream)
+			}
+			steps = append(steps, cyan(p.S3URI()))
+			if p.Table != "" {
+				steps = append(steps, "glue/"+p.Database+"."+p.Table)
+				for _, wg := range p.Workgroups {
+					steps = append(steps, "athena/"+wg)
+				}
+			} else {
+				steps = append(steps, yellow("no Glue table"))
+			}
+			fmt.Printf("%s %s\n", prefix, strings.Join(steps, dim(" → ")))
+		}
+		fmt.Println()
+	}
+
+	if (s3data == nil || len(s3data.Buckets) == 0) && !hasStorage && len(dw.Redshift) == 0 && len(dw.RedshiftWorkgroups) == 0 && len(dw.OpenSearch) == 0 && len(dw.Athena) == 0 && len(dw.Glue) == 0 && len(dw.GlueCrawlers) == 0 && len(dw.GlueJobs) == 0 {
 		fmt.Println(dim("  No S3 or data resources found"))
 	}
 }
@@ -530,14 +1410,20 @@ func printStreaming(region string) {
 		fmt.Printf("%s (%d)\n", bold("SQS Queues"), len(data.SQS))
 		for i, q := range data.SQS {
 			prefix := "├─"
-			if i == len(data.SQS)-1 && len(data.SNS) == 0 && len(data.Kinesis) == 0 && len(data.EventBridge) == 0 {
+			if i == len(data.SQS)-1 && len(data.SNS) == 0 && len(data.Kinesis) == 0 && len(data.EventBridge) == 0 && len(data.MSK) == 0 && len(data.MQ) == 0 && len(data.Firehose) == 0 {
 				prefix = "└─"
 			}
-			fifo := ""
+			extra := ""
 			if q.IsFIFO {
-				fifo = dim(" FIFO")
+				extra = dim(" FIFO")
 			}
-			fmt.Printf("%s %-34s ~%s msgs%s\n", prefix, cyan(q.QueueName), q.ApproximateMessages, fifo)
+			if q.DLQArn != "" {
+				extra += dim(fmt.Sprintf("  → DLQ %s after %d receives", q.DLQName, q.MaxReceiveCount))
+			}
+			if len(q.DLQFor) > 0 {
+				extra += "  " + yellow("DLQ for "+strings.Join(q.DLQFor, ", "))
+			}
+			fmt.Printf("%s %-34s ~%s msgs%s\n", prefix, cyan(q.QueueName), q.ApproximateMessages, extra)
 		}
 		fmt.Println()
 	}
@@ -546,10 +1432,21 @@ func printStreaming(region string) {
 		fmt.Printf("%s (%d)\n", bold("SNS Topics"), len(data.SNS))
 		for i, t := range data.SNS {
 			prefix := "├─"
-			if i == len(data.SNS)-1 && len(data.Kinesis) == 0 && len(data.EventBridge) == 0 {
+			if i == len(data.SNS)-1 && len(data.Kinesis) == 0 && len(data.EventBridge) == 0 && len(data.MSK) == 0 && len(data.MQ) == 0 && len(data.Firehose) == 0 {
 				prefix = "└─"
 			}
 			fmt.Printf("%s %-34s %d subs\n", prefix, cyan(t.Name), t.Subscriptions)
+			for j, sub := range t.SubscriptionList {
+				sp := "│  ├─"
+				if j == len(t.SubscriptionList)-1 {
+					sp = "│  └─"
+				}
+				pending := ""
+				if sub.Pending() {
+					pending = "  " + yellow("pending confirmation")
+				}
+				fmt.Printf("%s %-7s %s%s\n", sp, dim(sub.Protocol), sub.Target(), pending)
+			}
 		}
 		fmt.Println()
 	}
@@ -558,7 +1455,7 @@ func printStreaming(region string) {
 		fmt.Printf("%s (%d)\n", bold("Kinesis Streams"), len(data.Kinesis))
 		for i, s := range data.Kinesis {
 			prefix := "├─"
-			if i == len(data.Kinesis)-1 && len(data.EventBridge) == 0 {
+			if i == len(data.Kinesis)-1 && len(data.EventBridge) == 0 && len(data.MSK) == 0 && len(data.MQ) == 0 && len(data.Firehose) == 0 {
 				prefix = "└─"
 			}
 			fmt.Printf("%s %-34s %d shards  %dh retention  %s\n", prefix,
@@ -571,7 +1468,7 @@ func printStreaming(region string) {
 		fmt.Printf("%s (%d)\n", bold("EventBridge Buses"), len(data.EventBridge))
 		for i, b := range data.EventBridge {
 			prefix := "├─"
-			if i == len(data.EventBridge)-1 {
+			if i == len(data.EventBridge)-1 && len(data.MSK) == 0 && len(data.MQ) == 0 && len(data.Firehose) == 0 {
 				prefix = "└─"
 			}
 			fmt.Printf("%s %-34s %d rules\n", prefix, cyan(b.Name), len(b.Rules))
@@ -585,12 +1482,125 @@ func printStreaming(region string) {
 					sched = " " + dim(r.Schedule)
 				}
 				fmt.Printf("%s %-30s %s%s\n", rprefix, yellow(r.Name), green(r.State), sched)
+				indent := "│  │  "
+				if j == len(b.Rules)-1 {
+					indent = "│     "
+				}
+				for k, t := range r.Targets {
+					tp := "├─"
+					if k == len(r.Targets)-1 {
+						tp = "└─"
+					}
+					kind := t.TargetType
+					if kind == "" {
+						kind = "target"
+					}
+					fmt.Printf("%s%s %-7s %s\n", indent, tp, dim(kind), t.Target())
+				}
 			}
 		}
 		fmt.Println()
 	}
 
-	if len(data.SQS) == 0 && len(data.SNS) == 0 && len(data.Kinesis) == 0 && len(data.EventBridge) == 0 {
+	if jobs := data.ScheduledJobs(); len(jobs) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Scheduled Jobs"), len(jobs))
+		for i, job := range jobs {
+			prefix := "├─"
+			if i == len(jobs)-1 {
+				prefix = "└─"
+			}
+			var targets []string
+			for _, t := range job.Rule.Targets {
+				targets = append(targets, t.Target())
+			}
+			to := ""
+			if len(targets) > 0 {
+				to = "  → " + strings.Join(targets, ", ")
+			}
+			fmt.Printf("%s %-30s %-22s %s%s\n", prefix, yellow(job.Rule.Name), dim(job.Rule.Schedule), green(job.Rule.State), to)
+		}
+		fmt.Println()
+	}
+
+	if len(data.MSK) > 0 {
+		fmt.Printf("%s (%d)\n", bold("MSK Clusters"), len(data.MSK))
+		for i, c := range data.MSK {
+			prefix := "├─"
+			if i == len(data.MSK)-1 && len(data.MQ) == 0 && len(data.Firehose) == 0 {
+				prefix = "└─"
+			}
+			size := "serverless"
+			if c.ClusterType != "SERVERLESS" {
+				size = fmt.Sprintf("%d × %s", c.BrokerCount, c.InstanceType)
+			}
+			transit := dim(c.ClientBroker)
+			if c.ClientBroker == "PLAINTEXT" {
+				transit = red(c.ClientBroker)
+			}
+			fmt.Printf("%s %-34s %-10s %-22s %s  %s\n", prefix,
+				cyan(c.ClusterName), dim(c.KafkaVersion), dim(size), transit, green(c.State))
+		}
+		fmt.Println()
+	}
+
+	if len(data.MQ) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Amazon MQ Brokers"), len(data.MQ))
+		for i, b := range data.MQ {
+			prefix := "├─"
+			if i == len(data.MQ)-1 && len(data.Firehose) == 0 {
+				prefix = "└─"
+			}
+			access := green("private")
+			if b.PubliclyAccessible {
+				access = red("PUBLIC")
+			}
+			fmt.Printf("%s %-34s %-18s %-16s %s  %s\n", prefix,
+				cyan(b.BrokerName), dim(b.EngineType+" "+b.EngineVersion), dim(b.InstanceType), access, green(b.State))
+		}
+		fmt.Println()
+	}
+
+	if len(data.Firehose) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Firehose Delivery Streams"), len(data.Firehose))
+		for i, f := range data.Firehose {
+			prefix := "├─"
+			if i == len(data.Firehose)-1 {
+				prefix = "└─"
+			}
+			source := dim("direct put")
+			if f.SourceType != "" {
+				source = f.SourceType + "/" + f.SourceId
+			}
+			health := ""
+			if f.ErrorObjects > 0 {
+				more := ""
+				if f.ErrorObjectsMore {
+					more = "+"
+				}
+				health += "  " + red(fmt.Sprintf("%d%s error objects", f.ErrorObjects, more))
+			}
+			if m := f.Metrics; m != nil {
+				if m.HasDelivery {
+					delivered := fmt.Sprintf("%.1f%% delivered", m.SuccessPercent())
+					if m.DeliverySuccess < 1 {
+						delivered = red(delivered)
+					} else {
+						delivered = dim(delivered)
+					}
+					health += "  " + delivered
+				}
+				if m.ThrottledRecords > 0 {
+					health += "  " + yellow(fmt.Sprintf("%.0f throttled", m.ThrottledRecords))
+				}
+				health += "  " + dim(fmt.Sprintf("%.0f rec/24h", m.IncomingRecords))
+			}
+			fmt.Printf("%s %-34s %s → %s  %s%s\n", prefix,
+				cyan(f.Name), source, f.DestinationType+"/"+f.DestinationId, green(f.Status), health)
+		}
+		fmt.Println()
+	}
+
+	if len(data.SQS) == 0 && len(data.SNS) == 0 && len(data.Kinesis) == 0 && len(data.EventBridge) == 0 && len(data.MSK) == 0 && len(data.MQ) == 0 && len(data.Firehose) == 0 {
 		fmt.Println(dim("  No streaming resources found"))
 	}
 }
@@ -646,6 +1656,59 @@ func printAI(region string) {
 		fmt.Println()
 	}
 
+	if len(data.TrainingJobs) > 0 {
+		fmt.Printf("%s (%d)\n", bold("SageMaker Training Jobs"), len(data.TrainingJobs))
+		for i, j := range data.TrainingJobs {
+			prefix := "├─"
+			if i == len(data.TrainingJobs)-1 {
+				prefix = "└─"
+			}
+			stateColor := green
+			switch j.Status {
+			case "Failed":
+				stateColor = red
+			case "InProgress", "Stopping", "Stopped":
+				stateColor = yellow
+			}
+			fmt.Printf("%s %-28s %-20s %-10s %s\n", prefix, cyan(j.Name), dim(j.Capacity()), j.Duration(), stateColor(j.Status))
+		}
+		fmt.Println()
+	}
+
+	if len(data.Pipelines) > 0 {
+		fmt.Printf("%s (%d)\n", bold("SageMaker Pipelines"), len(data.Pipelines))
+		for i, p := range data.Pipelines {
+			prefix := "├─"
+			if i == len(data.Pipelines)-1 {
+				prefix = "└─"
+			}
+			last := dim("never run")
+			if p.LastExecution != "" {
+				stateColor := green
+				if p.LastStatus == "Failed" {
+					stateColor = red
+				} else if p.LastStatus != "Succeeded" {
+					stateColor = yellow
+				}
+				last = stateColor(p.LastStatus) + dim(" "+p.LastExecution)
+			}
+			fmt.Printf("%s %-28s %s\n", prefix, cyan(p.Name), last)
+		}
+		fmt.Println()
+	}
+
+	if len(data.Domains) > 0 {
+		fmt.Printf("%s (%d)\n", bold("SageMaker Studio Domains"), len(data.Domains))
+		for i, d := range data.Domains {
+			prefix := "├─"
+			if i == len(data.Domains)-1 {
+				prefix = "└─"
+			}
+			fmt.Printf("%s %-28s %-12s %d users  %s\n", prefix, cyan(d.Name), dim(d.NetworkAccess), len(d.UserProfiles), green(d.Status))
+		}
+		fmt.Println()
+	}
+
 	if len(data.BedrockModels) > 0 {
 		// Group by provider
 		providers := make(map[string][]sync.BedrockModel)
@@ -687,21 +1750,177 @@ func printAI(region string) {
 		fmt.Println()
 	}
 
+	if len(data.BedrockAgents) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Bedrock Agents"), len(data.BedrockAgents))
+		for i, a := range data.BedrockAgents {
+			prefix := "├─"
+			if i == len(data.BedrockAgents)-1 {
+				prefix = "└─"
+			}
+			stateColor := green
+			if a.Status != "PREPARED" {
+				stateColor = yellow
+			}
+			fmt.Printf("%s %-28s %-40s %s\n", prefix, cyan(a.Name), dim(a.FoundationModel), stateColor(a.Status))
+		}
+		fmt.Println()
+	}
+
+	if len(data.KnowledgeBases) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Bedrock Knowledge Bases"), len(data.KnowledgeBases))
+		for i, kb := range data.KnowledgeBases {
+			prefix, indent := "├─", "│  "
+			if i == len(data.KnowledgeBases)-1 {
+				prefix, indent = "└─", "   "
+			}
+			fmt.Printf("%s %-28s %s\n", prefix, cyan(kb.Name), dim(kb.StorageType+" "+kb.VectorStore))
+			for j, ds := range kb.DataSources {
+				dprefix := "├─"
+				if j == len(kb.DataSources)-1 {
+					dprefix = "└─"
+				}
+				name, source := ds.Name, ds.Type
+				if name == "" {
+					name = ds.Id
+				}
+				if ds.Bucket != "" {
+					source = "s3://" + ds.Bucket
+				}
+				fmt.Printf("%s%s %-25s %s\n", indent, dprefix, name, dim(source))
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(data.Guardrails) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Bedrock Guardrails"), len(data.Guardrails))
+		for i, g := range data.Guardrails {
+			prefix := "├─"
+			if i == len(data.Guardrails)-1 {
+				prefix = "└─"
+			}
+			fmt.Printf("%s %-28s %s\n", prefix, cyan(g.Name), dim(strings.Join(g.Policies, ", ")))
+		}
+		fmt.Println()
+	}
+
+	if len(data.ProvisionedThroughput) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Bedrock Provisioned Throughput"), len(data.ProvisionedThroughput))
+		for i, p := range data.ProvisionedThroughput {
+			prefix := "├─"
+			if i == len(data.ProvisionedThroughput)-1 {
+				prefix = "└─"
+			}
+			commitment := "no commitment"
+			if p.Commitment != "" {
+				commitment = p.Commitment + " until " + p.CommitmentExpires
+			}
+			fmt.Printf("%s %-28s %d MU  %s  %s\n", prefix, cyan(p.Name), p.ModelUnits, dim(p.Model), yellow(commitment))
+		}
+		fmt.Println()
+	}
+
 	if len(data.SageMakerNotebooks) == 0 && len(data.SageMakerEndpoints) == 0 &&
-		len(data.SageMakerModels) == 0 && len(data.BedrockModels) == 0 && len(data.BedrockCustom) == 0 {
+		len(data.SageMakerModels) == 0 && len(data.BedrockModels) == 0 && len(data.BedrockCustom) == 0 &&
+		len(data.TrainingJobs) == 0 && len(data.Pipelines) == 0 && len(data.Domains) == 0 &&
+		len(data.BedrockAgents) == 0 && len(data.KnowledgeBases) == 0 && len(data.Guardrails) == 0 && len(data.ProvisionedThroughput) == 0 {
 		fmt.Println(dim("  No AI/ML resources found"))
 	}
 }
 
+// ── CI/CD ────────────────────────────────────────────
+
+func printCICD(region string) {
+	data, err := sync.LoadCICDData(region)
+	if err != nil {
+		fmt.Println(red("  Error loading CI/CD data: " + err.Error()))
+		return
+	}
+	header("CI/CD")
+
+	if len(data.Pipelines) > 0 {
+		fmt.Printf("%s (%d)\n", bold("CodePipeline Pipelines"), len(data.Pipelines))
+		for i, p := range data.Pipelines {
+			prefix, indent := "├─", "│  "
+			if i == len(data.Pipelines)-1 {
+				prefix, indent = "└─", "   "
+			}
+			status := dim("never run")
+			switch p.LastStatus {
+			case "":
+			case "Succeeded":
+				status = green(p.LastStatus)
+			case "Failed":
+				status = red(p.LastStatus)
+			default:
+				status = yellow(p.LastStatus)
+			}
+			fmt.Printf("%s %-28s %s  %s\n", prefix, cyan(p.Name), status, dim(p.LastStarted))
+			if s := p.Summary(); s != "" {
+				fmt.Printf("%s%s\n", indent, dim(s))
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(data.Projects) > 0 {
+		fmt.Printf("%s (%d)\n", bold("CodeBuild Projects"), len(data.Projects))
+		for i, p := range data.Projects {
+			prefix := "├─"
+			if i == len(data.Projects)-1 {
+				prefix = "└─"
+			}
+			last := dim("no builds")
+			if b := p.LastBuild; b != nil {
+				last = runState(b.Status) + "  " + dim(b.Started)
+			}
+			fmt.Printf("%s %-28s %-14s %s  %s\n", prefix, cyan(p.Name), dim(p.SourceType), dim(p.Image), last)
+		}
+		fmt.Println()
+	}
+
+	if len(data.Stacks) > 0 {
+		fmt.Printf("%s (%d)\n", bold("CloudFormation Stacks"), len(data.Stacks))
+		for i, s := range data.Stacks {
+			prefix := "├─"
+			if i == len(data.Stacks)-1 {
+				prefix = "└─"
+			}
+			var status string
+			switch s.Health() {
+			case "complete":
+				status = green(s.Status)
+			case "failed":
+				status = red(s.Status)
+			default:
+				status = yellow(s.Status)
+			}
+			drift := ""
+			if s.Drift == "DRIFTED" {
+				drift = "  " + red("drifted")
+			}
+			fmt.Printf("%s %-28s %s  %s%s\n", prefix, cyan(s.Name), status, dim(fmt.Sprintf("%d resources", len(s.Resources))), drift)
+		}
+		fmt.Println()
+	}
+
+	if len(data.Pipelines) == 0 && len(data.Projects) == 0 && len(data.Stacks) == 0 {
+		fmt.Println(dim("  No pipelines, build projects or stacks found"))
+	}
+}
+
 // ── IAM ──────────────────────────────────────────────
 
-func printIAM() {
+func printIAM(region string) {
 	data, err := sync.LoadIAMData()
 	if err != nil {
 		fmt.Println(red("  Error loading IAM data: " + err.Error()))
 		return
 	}
 	header("IAM")
+	if data == nil {
+		data = &sync.IAMData{}
+	}
 
 	if len(data.Roles) > 0 {
 		// Group roles by principal
@@ -764,7 +1983,286 @@ func printIAM() {
 		fmt.Println()
 	}
 
-	if len(data.Roles) == 0 && len(data.Groups) == 0 {
+	if len(data.Users) > 0 {
+		now := time.Now()
+		fmt.Printf("%s (%d)\n", bold("Users"), len(data.Users))
+		for i, u := range data.Users {
+			prefix := "├─"
+			if i == len(data.Users)-1 {
+				prefix = "└─"
+			}
+			var flags []string
+			if u.ConsoleWithoutMFA() {
+				flags = append(flags, red("console without MFA"))
+			} else if len(u.MFADevices) > 0 {
+				flags = append(flags, green("MFA"))
+			}
+			if n := len(u.StaleKeys(now)); n > 0 {
+				flags = append(flags, yellow(fmt.Sprintf("%d stale key(s)", n)))
+			}
+			fmt.Printf("%s %-28s %d keys  %s\n", prefix, cyan(u.UserName), len(u.AccessKeys), strings.Join(flags, "  "))
+		}
+		fmt.Println()
+	}
+
+	if len(data.Providers) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Identity Providers"), len(data.Providers))
+		for i, p := range data.Providers {
+			prefix, indent := "├─", "│  "
+			if i == len(data.Providers)-1 {
+				prefix, indent = "└─", "   "
+			}
+			fmt.Printf("%s %-40s %s  %d roles\n", prefix, cyan(p.Name), dim(p.Type), len(p.TrustingRoles))
+			for _, t := range p.TrustingRoles {
+				cond := dim(strings.Join(t.Conditions, " · "))
+				if len(t.Conditions) == 0 {
+					cond = red("unconditioned")
+				}
+				fmt.Printf("%s%s %s  %s\n", indent, magenta("→"), t.RoleName, cond)
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(data.Roles) == 0 && len(data.Groups) == 0 && len(data.Users) == 0 && len(data.Providers) == 0 {
 		fmt.Println(dim("  No IAM data cached"))
+		fmt.Println()
+	}
+
+	printCognito(region)
+	printSecrets(region)
+	printGuardDuty(region)
+	printConfigRules(region)
+	printTrails(region)
+	printExternalAccess(region)
+}
+
+func printSecrets(region string) {
+	secrets, err := sync.LoadSecrets(region)
+	if err != nil || len(secrets) == 0 {
+		return
+	}
+	now := time.Now()
+	fmt.Printf("%s (%d)\n", bold("Secrets Manager"), len(secrets))
+	for i, s := range secrets {
+		prefix := "├─"
+		if i == len(secrets)-1 {
+			prefix = "└─"
+		}
+		rotation := dim("no rotation")
+		if s.RotationEnabled {
+			rotation = green(fmt.Sprintf("rotates every %dd", s.RotationDays))
+		}
+		if s.NeverRotated() {
+			rotation += " " + red("never rotated")
+		}
+		access := dim("accessed " + dateOnly(s.LastAccessed))
+		if days := s.DaysSinceAccess(now); days >= sync.SecretStaleDays {
+			access = yellow(fmt.Sprintf("unused %dd", days))
+		}
+		fmt.Printf("%s %s %s  %s\n", prefix, cyan(fmt.Sprintf("%-36s", s.Name)), rotation, access)
+	}
+	fmt.Println()
+}
+
+func printGuardDuty(region string) {
+	gd, err := sync.LoadGuardDutyData(region)
+	if err != nil || gd == nil {
+		return
+	}
+	if gd.DetectorId == "" {
+		fmt.Printf("%s  %s\n\n", bold("GuardDuty"), red("not enabled"))
+		return
+	}
+	var counts []string
+	for _, c := range gd.SeverityCounts() {
+		counts = append(counts, severityColor(c.Label)(fmt.Sprintf("%d %s", c.Count, c.Label)))
+	}
+	if len(counts) == 0 {
+		counts = append(counts, green("no active findings"))
+	}
+	fmt.Printf("%s (%d)  %s  %s\n", bold("GuardDuty"), gd.Total(), dim(gd.Status), strings.Join(counts, ", "))
+	for i, f := range gd.Findings {
+		prefix := "├─"
+		if i == len(gd.Findings)-1 {
+			prefix = "└─"
+		}
+		resource := dim(f.Resource())
+		if f.Link != nil {
+			resource = cyan(f.Link.Type + " " + f.Link.Label)
+		}
+		fmt.Printf("%s %s %s  %s\n", prefix, severityColor(f.SeverityLabel())(fmt.Sprintf("%-8s", f.SeverityLabel())), f.Title, resource)
+	}
+	fmt.Println()
+}
+
+func printConfigRules(region string) {
+	cfg, err := sync.LoadConfigData(region)
+	if err != nil || cfg == nil || len(cfg.Rules) == 0 {
+		return
+	}
+	summary := green("all compliant")
+	if n := cfg.NoncompliantResources(); n > 0 {
+		summary = red(fmt.Sprintf("%d noncompliant", n))
+	}
+	fmt.Printf("%s (%d)  %s\n", bold("AWS Config Rules"), len(cfg.Rules), summary)
+	for i, rule := range cfg.Rules {
+		prefix := "├─"
+		if i == len(cfg.Rules)-1 {
+			prefix = "└─"
+		}
+		status := dim("not evaluated")
+		switch rule.Compliance {
+		case "COMPLIANT":
+			status = green("compliant")
+		case "NON_COMPLIANT":
+			status = red(fmt.Sprintf("%d noncompliant", rule.NoncompliantCount))
+		case "":
+		default:
+			status = dim(strings.ToLower(rule.Compliance))
+		}
+		fmt.Printf("%s %s %s\n", prefix, cyan(fmt.Sprintf("%-40s", rule.Name)), status)
+	}
+	fmt.Println()
+}
+
+func printTrails(region string) {
+	trails, err := sync.LoadTrails(region)
+	if err != nil || trails == nil {
+		return
+	}
+	if active, _ := sync.HasActiveTrail(region); !active {
+		fmt.Printf("%s  %s\n", bold("CloudTrail"), red("no active trail records "+region))
+		if len(trails) == 0 {
+			fmt.Println()
+			return
+		}
+	} else {
+		fmt.Printf("%s (%d)\n", bold("CloudTrail"), len(trails))
+	}
+	for i, t := range trails {
+		prefix := "├─"
+		if i == len(trails)-1 {
+			prefix = "└─"
+		}
+		state := green("logging")
+		if !t.IsLogging {
+			state = red("stopped")
+		}
+		scope := dim("home " + t.HomeRegion)
+		if t.IsMultiRegion {
+			scope = dim("multi-region")
+		}
+		fmt.Printf("%s %s %s  %s  %s\n", prefix, cyan(fmt.Sprintf("%-28s", t.Name)), state, scope, dim("→ s3://"+t.S3BucketName))
+	}
+	fmt.Println()
+}
+
+func printExternalAccess(region string) {
+	access, err := sync.LoadExternalAccess(region)
+	if err != nil || len(access) == 0 {
+		return
+	}
+	fmt.Printf("%s (%d)\n", bold("Access Analyzer"), len(access))
+	for i, a := range access {
+		prefix := "├─"
+		if i == len(access)-1 {
+			prefix = "└─"
+		}
+		grantee := yellow(a.Grantee())
+		if a.IsPublic {
+			grantee = red("public")
+		}
+		fmt.Printf("%s %s %s  %s\n", prefix, cyan(fmt.Sprintf("%-32s", a.DetailID())), grantee, dim(a.ResourceType))
+	}
+	fmt.Println()
+}
+
+func severityColor(label string) func(string) string {
+	switch label {
+	case "Critical", "High":
+		return red
+	case "Medium":
+		return yellow
+	}
+	return dim
+}
+
+// dateOnly trims an ISO timestamp to its date, or returns "never".
+func dateOnly(ts string) string {
+	if ts == "" {
+		return "never"
+	}
+	d, _, _ := strings.Cut(ts, "T")
+	return d
+}
+
+func printCognito(region string) {
+	data, err := sync.LoadCognitoData(region)
+	if err != nil || data == nil {
+		return
+	}
+
+	if len(data.UserPools) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Cognito User Pools"), len(data.UserPools))
+		for i, p := range data.UserPools {
+			prefix, cont := "├─", "│  "
+			if i == len(data.UserPools)-1 && len(data.IdentityPools) == 0 {
+				prefix, cont = "└─", "   "
+			}
+			mfa := "MFA " + p.MfaConfiguration
+			if p.MfaConfiguration == "OFF" {
+				mfa = red(mfa)
+			} else {
+				mfa = green(mfa)
+			}
+			fmt.Printf("%s %-30s ~%d users  %d clients  %s\n", prefix, cyan(p.Name),
+				p.EstimatedUsers, len(p.AppClients), mfa)
+			for _, u := range p.UsedBy {
+				fmt.Printf("%s%s\n", cont, dim("used by "+u))
+			}
+		}
+		fmt.Println()
+	}
+
+	if len(data.IdentityPools) > 0 {
+		fmt.Printf("%s (%d)\n", bold("Cognito Identity Pools"), len(data.IdentityPools))
+		for i, p := range data.IdentityPools {
+			prefix := "├─"
+			if i == len(data.IdentityPools)-1 {
+				prefix = "└─"
+			}
+			guest := ""
+			if p.AllowUnauthenticated {
+				guest = yellow(" guest access")
+			}
+			fmt.Printf("%s %-30s %d user pools%s\n", prefix, cyan(p.IdentityPoolName), len(p.UserPoolIds), guest)
+		}
+		fmt.Println()
+	}
+}
+
+// tunnelSummary renders a VPN connection's tunnel status, red when a tunnel
+// of an available connection is down.
+func tunnelSummary(v sync.VPNConnection) string {
+	text := fmt.Sprintf("%d/%d tunnels up", v.TunnelsUp(), len(v.Tunnels))
+	if v.Degraded() {
+		return red(text)
+	}
+	return dim(text)
+}
+
+// printProviderItems lists the tab's resources from other clouds.
+func printProviderItems(tab string) {
+	for _, g := range sync.ProviderItems(tab) {
+		fmt.Printf("%s (%d)  %s\n", bold(g.Title), len(g.Items), yellow("experimental"))
+		for i, it := range g.Items {
+			prefix := "├─"
+			if i == len(g.Items)-1 {
+				prefix = "└─"
+			}
+			fmt.Printf("%s %-36s %s  %s\n", prefix, cyan(it.Name), dim(it.Type), dim(it.Region))
+		}
+		fmt.Println()
 	}
 }
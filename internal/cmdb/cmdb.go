@@ -0,0 +1,291 @@
+package cmdb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// cacheKey holds the last imported CMDB records (global, not per region).
+const cacheKey = "cmdb:records"
+
+// DefaultTypes are the inventory types treated as assets during
+// reconciliation. Plumbing like subnets and route tables is left out.
+var DefaultTypes = []string{
+	"ec2", "ecs", "lambda", "apprunner", "lightsail", "lightsail-container", "rds", "rds-cluster", "docdb", "neptune", "dynamodb", "elasticache", "redshift", "redshift-serverless", "opensearch",
+	"lb", "s3", "efs", "fsx", "sqs", "sns", "kinesis", "msk", "mq", "sagemaker-notebook", "sagemaker-endpoint", "sagemaker-domain", "bedrock-agent",
+}
+
+// Record is one row from the CMDB export.
+type Record struct {
+	Hostname string `json:"hostname"`
+	Arn      string `json:"arn"`
+	Owner    string `json:"owner"`
+}
+
+func (r Record) label() string {
+	if r.Arn != "" {
+		return r.Arn
+	}
+	return r.Hostname
+}
+
+// column aliases accepted in the CSV header (case-insensitive).
+var columns = map[string][]string{
+	"hostname": {"hostname", "host", "name", "ci_name", "asset"},
+	"arn":      {"arn", "resource_arn", "resource_id", "id"},
+	"owner":    {"owner", "team", "owned_by", "support_group"},
+}
+
+// Parse reads CMDB records from CSV. The header row must contain a
+// hostname or ARN column; an owner column is optional.
+func Parse(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	head, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	idx := map[string]int{"hostname": -1, "arn": -1, "owner": -1}
+	for i, h := range head {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		for field, aliases := range columns {
+			for _, a := range aliases {
+				if h == a && idx[field] < 0 {
+					idx[field] = i
+				}
+			}
+		}
+	}
+	if idx["hostname"] < 0 && idx["arn"] < 0 {
+		return nil, fmt.Errorf("CSV header needs a hostname or arn column, got %v", head)
+	}
+
+	get := func(row []string, field string) string {
+		i := idx[field]
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	var records []Record
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := Record{Hostname: get(row, "hostname"), Arn: get(row, "arn"), Owner: get(row, "owner")}
+		if rec.Hostname == "" && rec.Arn == "" {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// Import parses a CSV file and replaces the stored CMDB records.
+func Import(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	records, err := Parse(f)
+	if err != nil {
+		return 0, err
+	}
+	b, _ := json.Marshal(records)
+	if err := sync.WriteCache(cacheKey, b); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// Load returns the stored CMDB records, or nil if none were imported.
+func Load() ([]Record, error) {
+	raw, err := sync.ReadCache(cacheKey)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var records []Record
+	json.Unmarshal(raw, &records)
+	return records, nil
+}
+
+// Match pairs a CMDB record with the AWS resource it describes.
+type Match struct {
+	Record Record
+	Item   sync.InventoryItem
+}
+
+// Report is the result of reconciling AWS inventory against the CMDB.
+type Report struct {
+	Matched    []Match
+	AWSOnly    []sync.InventoryItem // in AWS, missing from the CMDB
+	CMDBOnly   []Record             // in the CMDB, not found in AWS
+	NoOwner    []Match              // matched but the CMDB row has no owner
+	OutOfScope []Record             // may describe a type or region that was not reconciled
+}
+
+// arnRegion returns the region field of an ARN, or "" for global ARNs
+// and values that are not ARNs.
+func arnRegion(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[3]
+}
+
+// Reconcile compares cached inventory against CMDB records. Only items of
+// the given types in the given regions are reported; regions is nil when
+// items cover every cached region. Records match on ARN first, then on
+// hostname against resource name or ID, across all items, so a record for
+// a resource of another type or region is never reported as missing.
+func Reconcile(items []sync.InventoryItem, records []Record, types, regions []string) Report {
+	want := map[string]bool{}
+	for _, t := range types {
+		want[t] = true
+	}
+	inRegion := func(region string) bool {
+		if regions == nil || region == "" || region == "global" {
+			return true
+		}
+		for _, r := range regions {
+			if r == region {
+				return true
+			}
+		}
+		return false
+	}
+	byArn := map[string]int{}
+	byHost := map[string]int{}
+	for i, rec := range records {
+		if rec.Arn != "" {
+			byArn[rec.Arn] = i
+		}
+		if rec.Hostname != "" {
+			byHost[strings.ToLower(rec.Hostname)] = i
+		}
+	}
+
+	var rep Report
+	used := map[int]bool{}
+	elsewhere := map[int]bool{}
+	for _, it := range items {
+		i, ok := -1, false
+		for _, key := range []string{it.Arn, it.ID} {
+			if key == "" {
+				continue
+			}
+			if i, ok = byArn[key]; ok {
+				break
+			}
+		}
+		if !ok {
+			for _, key := range []string{it.Name, it.ID} {
+				if key == "" {
+					continue
+				}
+				if i, ok = byHost[strings.ToLower(key)]; ok {
+					break
+				}
+			}
+		}
+		if !want[it.Type] || !inRegion(it.Region) {
+			if ok {
+				elsewhere[i] = true
+			}
+			continue
+		}
+		if !ok {
+			rep.AWSOnly = append(rep.AWSOnly, it)
+			continue
+		}
+		used[i] = true
+		m := Match{Record: records[i], Item: it}
+		rep.Matched = append(rep.Matched, m)
+		if records[i].Owner == "" {
+			rep.NoOwner = append(rep.NoOwner, m)
+		}
+	}
+	for i, rec := range records {
+		switch {
+		case used[i]:
+		case elsewhere[i]:
+			rep.OutOfScope = append(rep.OutOfScope, rec)
+		case regions != nil && (rec.Arn == "" || !inRegion(arnRegion(rec.Arn))):
+			// A bare hostname may belong to a region that was not loaded.
+			rep.OutOfScope = append(rep.OutOfScope, rec)
+		default:
+			rep.CMDBOnly = append(rep.CMDBOnly, rec)
+		}
+	}
+	sort.Slice(rep.CMDBOnly, func(a, b int) bool { return rep.CMDBOnly[a].label() < rep.CMDBOnly[b].label() })
+	return rep
+}
+
+// WriteText prints a human-readable summary of the report.
+func WriteText(w io.Writer, rep Report) {
+	fmt.Fprintf(w, "Matched:            %d\n", len(rep.Matched))
+	fmt.Fprintf(w, "In AWS, not CMDB:   %d\n", len(rep.AWSOnly))
+	fmt.Fprintf(w, "In CMDB, not AWS:   %d\n", len(rep.CMDBOnly))
+	fmt.Fprintf(w, "Matched, no owner:  %d\n", len(rep.NoOwner))
+	if len(rep.OutOfScope) > 0 {
+		fmt.Fprintf(w, "Outside the scope:  %d (other types or regions)\n", len(rep.OutOfScope))
+	}
+
+	if len(rep.AWSOnly) > 0 {
+		fmt.Fprintln(w, "\nIn AWS but missing from the CMDB:")
+		for _, it := range rep.AWSOnly {
+			fmt.Fprintf(w, "  %-20s %s\n", it.Type, it.Label())
+		}
+	}
+	if len(rep.CMDBOnly) > 0 {
+		fmt.Fprintln(w, "\nIn the CMDB but not found in AWS:")
+		for _, rec := range rep.CMDBOnly {
+			owner := rec.Owner
+			if owner == "" {
+				owner = "-"
+			}
+			fmt.Fprintf(w, "  %-50s owner: %s\n", rec.label(), owner)
+		}
+	}
+	if len(rep.NoOwner) > 0 {
+		fmt.Fprintln(w, "\nMatched but without an owner:")
+		for _, m := range rep.NoOwner {
+			fmt.Fprintf(w, "  %-20s %s\n", m.Item.Type, m.Item.Label())
+		}
+	}
+}
+
+// WriteCSV emits one row per resource or record with its reconciliation status.
+func WriteCSV(w io.Writer, rep Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"status", "type", "id", "name", "arn", "region", "cmdb_hostname", "cmdb_arn", "owner"})
+	for _, m := range rep.Matched {
+		cw.Write([]string{"matched", m.Item.Type, m.Item.ID, m.Item.Name, m.Item.Arn, m.Item.Region,
+			m.Record.Hostname, m.Record.Arn, m.Record.Owner})
+	}
+	for _, it := range rep.AWSOnly {
+		cw.Write([]string{"aws_only", it.Type, it.ID, it.Name, it.Arn, it.Region, "", "", ""})
+	}
+	for _, rec := range rep.CMDBOnly {
+		cw.Write([]string{"cmdb_only", "", "", "", "", "", rec.Hostname, rec.Arn, rec.Owner})
+	}
+	for _, rec := range rep.OutOfScope {
+		cw.Write([]string{"out_of_scope", "", "", "", "", "", rec.Hostname, rec.Arn, rec.Owner})
+	}
+	cw.Flush()
+	return cw.Error()
+}
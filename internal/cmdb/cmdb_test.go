@@ -0,0 +1,57 @@
+package cmdb
+
+import (
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+func TestReconcileScope(t *testing.T) {
+	items := []sync.InventoryItem{
+		{Type: "ec2", ID: "i-1", Name: "web-1", Region: "eu-west-1"},
+		{Type: "ec2", ID: "i-2", Name: "web-2", Region: "us-east-1"},
+		{Type: "subnet", ID: "subnet-1", Name: "private-a", Region: "eu-west-1"},
+	}
+	records := []Record{
+		{Hostname: "web-1", Owner: "web"},
+		{Hostname: "web-2", Owner: "web"},
+		{Hostname: "private-a"},
+		{Arn: "arn:aws:ec2:ap-south-1:111111111111:instance/i-9"},
+		{Arn: "arn:aws:ec2:eu-west-1:111111111111:instance/i-8"},
+		{Hostname: "ghost"},
+	}
+
+	label := func(recs []Record) []string {
+		var out []string
+		for _, r := range recs {
+			out = append(out, r.label())
+		}
+		return out
+	}
+
+	// Every cached region: only records no region or type has are missing.
+	rep := Reconcile(items, records, []string{"ec2"}, nil)
+	if len(rep.Matched) != 2 || len(rep.AWSOnly) != 0 {
+		t.Errorf("all regions: %d matched, %d AWS-only", len(rep.Matched), len(rep.AWSOnly))
+	}
+	got := label(rep.CMDBOnly)
+	want := []string{"arn:aws:ec2:ap-south-1:111111111111:instance/i-9", "arn:aws:ec2:eu-west-1:111111111111:instance/i-8", "ghost"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("all regions: CMDB-only = %v, want %v", got, want)
+	}
+	if got := label(rep.OutOfScope); len(got) != 1 || got[0] != "private-a" {
+		t.Errorf("all regions: out of scope = %v", got)
+	}
+
+	// One region: records for other regions, or that could be, are not missing.
+	rep = Reconcile(items, records, []string{"ec2"}, []string{"eu-west-1"})
+	if len(rep.Matched) != 1 || rep.Matched[0].Item.ID != "i-1" {
+		t.Errorf("eu-west-1: matched = %+v", rep.Matched)
+	}
+	if got := label(rep.CMDBOnly); len(got) != 1 || got[0] != "arn:aws:ec2:eu-west-1:111111111111:instance/i-8" {
+		t.Errorf("eu-west-1: CMDB-only = %v", got)
+	}
+	if len(rep.OutOfScope) != 4 {
+		t.Errorf("eu-west-1: out of scope = %v", label(rep.OutOfScope))
+	}
+}
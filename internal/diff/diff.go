@@ -0,0 +1,261 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// Kind is what happened to a resource between two syncs.
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change is one resource that differs between two inventories. Item is
+// the resource as it is after the change, or as it was if removed.
+type Change struct {
+	Kind   Kind               `json:"kind"`
+	Item   sync.InventoryItem `json:"item"`
+	Fields []FieldChange      `json:"fields,omitempty"`
+}
+
+// FieldChange is one attribute of a changed resource. For Refs, Before
+// lists the links that went away and After the new ones.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func (f FieldChange) String() string {
+	if f.Field == "Refs" {
+		var parts []string
+		if f.Before != "" {
+			parts = append(parts, "-"+f.Before)
+		}
+		if f.After != "" {
+			parts = append(parts, "+"+f.After)
+		}
+		return "links " + strings.Join(parts, " ")
+	}
+	return fmt.Sprintf("%s %s → %s", f.Field, orDash(f.Before), orDash(f.After))
+}
+
+// String renders the change as a changelog line, e.g.
+// "lambda resize changed: Runtime python3.11 → python3.12".
+func (c Change) String() string {
+	line := fmt.Sprintf("%s %s %s", c.Item.Type, c.Item.Label(), c.Kind)
+	if len(c.Fields) > 0 {
+		fields := make([]string, len(c.Fields))
+		for i, f := range c.Fields {
+			fields[i] = f.String()
+		}
+		line += ": " + strings.Join(fields, "; ")
+	}
+	return line
+}
+
+// Report is the changelog of a region between two snapshots.
+type Report struct {
+	Region  string        `json:"region"`
+	From    sync.Snapshot `json:"from"`
+	To      sync.Snapshot `json:"to"`
+	Changes []Change      `json:"changes"`
+}
+
+// TypeChanges groups a report's changes by resource type.
+type TypeChanges struct {
+	Type    string
+	Changes []Change
+}
+
+// Counts returns how many resources were added, removed and changed.
+func (r Report) Counts() (added, removed, changed int) {
+	return r.Count(Added), r.Count(Removed), r.Count(Changed)
+}
+
+// Count returns how many changes are of kind k.
+func (r Report) Count(k Kind) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Kind == k {
+			n++
+		}
+	}
+	return n
+}
+
+// ByType groups the changes by resource type, in type order.
+func (r Report) ByType() []TypeChanges {
+	var out []TypeChanges
+	for _, c := range r.Changes {
+		if n := len(out); n > 0 && out[n-1].Type == c.Item.Type {
+			out[n-1].Changes = append(out[n-1].Changes, c)
+			continue
+		}
+		out = append(out, TypeChanges{Type: c.Item.Type, Changes: []Change{c}})
+	}
+	return out
+}
+
+// Compare returns the resources added, removed or changed from before to
+// after, sorted by type and ID.
+func Compare(before, after []sync.InventoryItem) []Change {
+	old := make(map[string]sync.InventoryItem, len(before))
+	for _, it := range before {
+		old[it.Key()] = it
+	}
+	seen := make(map[string]bool, len(after))
+
+	var changes []Change
+	for _, it := range after {
+		seen[it.Key()] = true
+		prev, ok := old[it.Key()]
+		if !ok {
+			changes = append(changes, Change{Kind: Added, Item: it})
+			continue
+		}
+		if fields := compareItems(prev, it); len(fields) > 0 {
+			changes = append(changes, Change{Kind: Changed, Item: it, Fields: fields})
+		}
+	}
+	for _, it := range before {
+		if !seen[it.Key()] {
+			changes = append(changes, Change{Kind: Removed, Item: it})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].Item, changes[j].Item
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ID < b.ID
+	})
+	return changes
+}
+
+func compareItems(before, after sync.InventoryItem) []FieldChange {
+	var fields []FieldChange
+	field := func(name, a, b string) {
+		if a != b {
+			fields = append(fields, FieldChange{Field: name, Before: a, After: b})
+		}
+	}
+	field("Name", before.Name, after.Name)
+	field("Region", before.Region, after.Region)
+	field("ARN", before.Arn, after.Arn)
+	field("VPC", before.VpcId, after.VpcId)
+	for _, k := range unionKeys(before.Details, after.Details) {
+		field(k, before.Details[k], after.Details[k])
+	}
+	for _, k := range unionKeys(before.Tags, after.Tags) {
+		field("Tag "+k, before.Tags[k], after.Tags[k])
+	}
+
+	if gone, added := refDelta(before.Refs, after.Refs); gone != "" || added != "" {
+		fields = append(fields, FieldChange{Field: "Refs", Before: gone, After: added})
+	}
+	return fields
+}
+
+// unionKeys returns the keys of a and b, sorted.
+func unionKeys(a, b map[string]string) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range []map[string]string{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// refDelta lists the refs only in before and only in after.
+func refDelta(before, after []string) (gone, added string) {
+	in := func(list []string, s string) bool {
+		for _, x := range list {
+			if x == s {
+				return true
+			}
+		}
+		return false
+	}
+	var g, a []string
+	for _, r := range before {
+		if !in(after, r) {
+			g = append(g, r)
+		}
+	}
+	for _, r := range after {
+		if !in(before, r) {
+			a = append(a, r)
+		}
+	}
+	return strings.Join(g, ", "), strings.Join(a, ", ")
+}
+
+// Between compares region's inventory as of two snapshots.
+func Between(region string, from, to int64) (*Report, error) {
+	snaps, err := sync.ListSnapshots(region)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{Region: region}
+	for _, s := range snaps {
+		if s.ID == from {
+			r.From = s
+		}
+		if s.ID == to {
+			r.To = s
+		}
+	}
+	if r.From.ID == 0 || r.To.ID == 0 {
+		return nil, fmt.Errorf("no snapshots %d and %d in %s (see 'saws diff --list')", from, to, region)
+	}
+	before, err := sync.InventoryAsOf(region, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := sync.InventoryAsOf(region, to)
+	if err != nil {
+		return nil, err
+	}
+	r.Changes = Compare(before, after)
+	return r, nil
+}
+
+// Latest compares region's last two finished syncs, or returns nil when
+// it has fewer than two.
+func Latest(region string) (*Report, error) {
+	snaps, err := sync.ListSnapshots(region)
+	if err != nil {
+		return nil, err
+	}
+	var finished []sync.Snapshot
+	for _, s := range snaps {
+		if !s.FinishedAt.IsZero() {
+			finished = append(finished, s)
+		}
+	}
+	if len(finished) < 2 {
+		return nil, nil
+	}
+	return Between(region, finished[1].ID, finished[0].ID)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+func TestCompare(t *testing.T) {
+	before := []sync.InventoryItem{
+		{Type: "ec2", ID: "i-1", Name: "web", Refs: []string{"sg/sg-a", "subnet/subnet-1"}, Details: map[string]string{"State": "running", "Type": "t3.small"}},
+		{Type: "lambda", ID: "resize", Name: "resize", Details: map[string]string{"Runtime": "python3.11"}, Tags: map[string]string{"team": "media"}},
+		{Type: "sqs", ID: "jobs", Name: "jobs"},
+	}
+	after := []sync.InventoryItem{
+		{Type: "ec2", ID: "i-1", Name: "web", Refs: []string{"subnet/subnet-1", "sg/sg-b"}, Details: map[string]string{"State": "stopped", "Type": "t3.small"}},
+		{Type: "lambda", ID: "resize", Name: "resize", Details: map[string]string{"Runtime": "python3.11"}, Tags: map[string]string{"team": "platform"}},
+		{Type: "lambda", ID: "thumbnail", Name: "thumbnail"},
+	}
+
+	changes := Compare(before, after)
+	if len(changes) != 4 {
+		t.Fatalf("changes = %+v", changes)
+	}
+	want := []string{
+		"ec2 web (i-1) changed: State running → stopped; links -sg/sg-a +sg/sg-b",
+		"lambda resize changed: Tag team media → platform",
+		"lambda thumbnail added",
+		"sqs jobs removed",
+	}
+	for i, c := range changes {
+		if got := c.String(); got != want[i] {
+			t.Errorf("change %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	r := Report{Changes: changes}
+	if a, rm, ch := r.Counts(); a != 1 || rm != 1 || ch != 2 {
+		t.Errorf("counts = %d %d %d", a, rm, ch)
+	}
+	if groups := r.ByType(); len(groups) != 3 || groups[1].Type != "lambda" {
+		t.Errorf("by type = %+v", groups)
+	}
+}
@@ -0,0 +1,301 @@
+// Package export flattens the synced inventory into a table, one row per
+// resource, for spreadsheets and BI tools: CSV, JSON or Parquet.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/sync"
+	"github.com/parquet-go/parquet-go"
+)
+
+// Formats lists the formats Write takes.
+var Formats = []string{"csv", "json", "parquet"}
+
+// BaseColumns are the columns every resource has, in default order. Tags
+// and details add a "tag:<key>" and "detail:<key>" column per key.
+var BaseColumns = []string{"region", "type", "id", "name", "arn", "vpc_id", "refs", "synced_at"}
+
+// Options selects what Inventory exports. Empty fields select everything.
+type Options struct {
+	Regions []string
+	Types   []string // inventory types: ec2, lambda, rds, ...
+	Columns []string // default: BaseColumns, then the detail and tag columns of the resources exported
+	// Anonymize rewrites names, IDs, ARNs, addresses and tag values the
+	// way 'saws export --anonymize' does for a bundle.
+	Anonymize bool
+}
+
+// Table is the inventory flattened. Nil cells are resources without the
+// tag or detail, written as null in JSON and Parquet and empty in CSV; a
+// tag whose value is empty is an empty string.
+type Table struct {
+	Columns []string
+	Rows    [][]*string
+}
+
+// Inventory reads the resources index into a table.
+func Inventory(opts Options) (*Table, error) {
+	listed := map[string]bool{}
+	for _, c := range opts.Columns {
+		if !validColumn(c) {
+			return nil, fmt.Errorf("unknown column %q: expected one of %s, or tag:<key> or detail:<key>", c, strings.Join(BaseColumns, ", "))
+		}
+		if listed[c] {
+			return nil, fmt.Errorf("column %q listed twice", c)
+		}
+		listed[c] = true
+	}
+	resources, err := query(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = append(columns, BaseColumns...)
+		details, tags := map[string]bool{}, map[string]bool{}
+		for _, r := range resources {
+			for k := range r.Details {
+				details[k] = true
+			}
+			for k := range r.Tags {
+				tags[k] = true
+			}
+		}
+		columns = append(columns, prefixed("detail:", details)...)
+		columns = append(columns, prefixed("tag:", tags)...)
+	}
+
+	t := &Table{Columns: columns}
+	for _, r := range resources {
+		row := make([]*string, len(columns))
+		for i, c := range columns {
+			if v, ok := cell(r, c); ok {
+				row[i] = &v
+			}
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	if opts.Anonymize {
+		s, err := sync.NewSanitizer()
+		if err != nil {
+			return nil, err
+		}
+		t.anonymize(s)
+	}
+	return t, nil
+}
+
+// anonymize rewrites t's cells with s. Names, IDs and tags go first, so
+// the names are also replaced where they appear inside details, such as
+// endpoint hostnames.
+func (t *Table) anonymize(s *sync.Sanitizer) {
+	for _, text := range []bool{false, true} {
+		for i, c := range t.Columns {
+			detail := strings.HasPrefix(c, "detail:")
+			if detail != text {
+				continue
+			}
+			for _, row := range t.Rows {
+				if row[i] == nil {
+					continue
+				}
+				var v string
+				switch {
+				case detail:
+					v = s.String(*row[i])
+				case c == "refs":
+					refs := strings.Fields(*row[i])
+					for j, ref := range refs {
+						if typ, id, ok := strings.Cut(ref, "/"); ok {
+							refs[j] = typ + "/" + s.Name(id)
+						}
+					}
+					v = strings.Join(refs, " ")
+				case c == "id", c == "name", c == "arn", c == "vpc_id", strings.HasPrefix(c, "tag:"):
+					v = s.Name(*row[i])
+				default:
+					continue
+				}
+				row[i] = &v
+			}
+		}
+	}
+}
+
+// query returns the resources of the selected regions, each once: global
+// resources are indexed under every region.
+func query(opts Options) ([]sync.Resource, error) {
+	regions := opts.Regions
+	if len(regions) == 0 {
+		regions = []string{""}
+	}
+	seen := map[string]bool{}
+	var out []sync.Resource
+	for _, region := range regions {
+		resources, err := sync.QueryResources(sync.ResourceFilter{Region: region, Types: opts.Types})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resources {
+			if k := r.Region + "|" + r.Key(); !seen[k] {
+				seen[k] = true
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+func validColumn(c string) bool {
+	if k, ok := strings.CutPrefix(c, "tag:"); ok {
+		return k != ""
+	}
+	if k, ok := strings.CutPrefix(c, "detail:"); ok {
+		return k != ""
+	}
+	for _, b := range BaseColumns {
+		if c == b {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixed(prefix string, keys map[string]bool) []string {
+	var out []string
+	for k := range keys {
+		out = append(out, prefix+k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cell returns r's value in column, false if r has no such tag or detail.
+func cell(r sync.Resource, column string) (string, bool) {
+	switch column {
+	case "region":
+		return r.Region, true
+	case "type":
+		return r.Type, true
+	case "id":
+		return r.ID, true
+	case "name":
+		return r.Name, true
+	case "arn":
+		return r.Arn, true
+	case "vpc_id":
+		return r.VpcId, true
+	case "refs":
+		return strings.Join(r.Refs, " "), true
+	case "synced_at":
+		return r.SyncedAt.UTC().Format(time.RFC3339), true
+	}
+	if k, ok := strings.CutPrefix(column, "tag:"); ok {
+		v, ok := r.Tags[k]
+		return v, ok
+	}
+	if k, ok := strings.CutPrefix(column, "detail:"); ok {
+		v, ok := r.Details[k]
+		return v, ok
+	}
+	return "", false
+}
+
+// Write writes t to w in format: "csv", "json" (an array of objects, keys
+// in column order) or "parquet" (every column an optional string).
+func Write(w io.Writer, format string, t *Table) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(t.Columns)
+		record := make([]string, len(t.Columns))
+		for _, row := range t.Rows {
+			for i, v := range row {
+				record[i] = ""
+				if v != nil {
+					record[i] = *v
+				}
+			}
+			cw.Write(record)
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		return writeJSON(w, t)
+	case "parquet":
+		return writeParquet(w, t)
+	}
+	return fmt.Errorf("unknown format %q: expected %s", format, strings.Join(Formats, ", "))
+}
+
+func writeJSON(w io.Writer, t *Table) error {
+	keys := make([][]byte, len(t.Columns))
+	for i, c := range t.Columns {
+		keys[i], _ = json.Marshal(c)
+	}
+	var b strings.Builder
+	b.WriteString("[")
+	for n, row := range t.Rows {
+		if n > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for i, v := range row {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.Write(keys[i])
+			b.WriteString(": ")
+			if v == nil {
+				b.WriteString("null")
+				continue
+			}
+			value, _ := json.Marshal(*v)
+			b.Write(value)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("\n]\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeParquet(w io.Writer, t *Table) error {
+	group := parquet.Group{}
+	for _, c := range t.Columns {
+		group[c] = parquet.Optional(parquet.String())
+	}
+	schema := parquet.NewSchema("inventory", group)
+	// The schema orders columns by name; place each cell accordingly.
+	index := map[string]int{}
+	for i, path := range schema.Columns() {
+		index[path[0]] = i
+	}
+
+	pw := parquet.NewWriter(w, schema)
+	rows := make([]parquet.Row, 0, len(t.Rows))
+	for _, r := range t.Rows {
+		row := make(parquet.Row, len(t.Columns))
+		for i, v := range r {
+			col := index[t.Columns[i]]
+			if v == nil {
+				row[col] = parquet.NullValue().Level(0, 0, col)
+			} else {
+				row[col] = parquet.ByteArrayValue([]byte(*v)).Level(0, 1, col)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if _, err := pw.WriteRows(rows); err != nil {
+		return err
+	}
+	return pw.Close()
+}
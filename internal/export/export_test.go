@@ -0,0 +1,244 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+	"github.com/estrados/simply-aws/internal/sync"
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "saws-export-test")
+	if err != nil {
+		panic(err)
+	}
+	restore := awscli.Use(awscli.NewFake())
+	sync.UseAccount("111111111111")
+	sync.SetDataDir(dir)
+	if err := sync.InitDB(); err != nil {
+		panic(err)
+	}
+	seed()
+
+	code := m.Run()
+
+	restore()
+	sync.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// seed caches an RDS instance per region, one with a tag whose value is
+// empty, and an IAM role, which is global.
+func seed() {
+	sync.WriteCache("eu-west-1:rds", []byte(`{"DBInstances": [
+		{"DBInstanceIdentifier": "orders", "Engine": "postgres", "DBInstanceArn": "arn:aws:rds:eu-west-1:111111111111:db:orders",
+		 "TagList": [{"Key": "env", "Value": "prod"}, {"Key": "owner", "Value": ""}]}]}`))
+	sync.WriteCache("us-east-1:rds", []byte(`{"DBInstances": [{"DBInstanceIdentifier": "billing", "Engine": "mysql"}]}`))
+	sync.WriteCache("iam:enriched", []byte(`{"roles": [{"RoleName": "deploy", "Arn": "arn:aws:iam::111111111111:role/deploy"}]}`))
+	for _, r := range []string{"eu-west-1", "us-east-1"} {
+		if err := sync.IndexResources(r); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestInventoryColumns(t *testing.T) {
+	tbl, err := Inventory(Options{Types: []string{"rds"}, Columns: []string{"name", "tag:owner", "region", "tag:env"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tbl.Columns, []string{"name", "tag:owner", "region", "tag:env"}) {
+		t.Errorf("columns = %v", tbl.Columns)
+	}
+	got := rowsOf(tbl)
+	want := [][]string{{"billing", "<nil>", "us-east-1", "<nil>"}, {"orders", "", "eu-west-1", "prod"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+
+	for _, cols := range [][]string{{"name", "colour"}, {"tag:"}, {"id", "id"}} {
+		if _, err := Inventory(Options{Columns: cols}); err == nil {
+			t.Errorf("columns %v accepted", cols)
+		}
+	}
+}
+
+func TestInventoryGlobalOnce(t *testing.T) {
+	tbl, err := Inventory(Options{Regions: []string{"eu-west-1", "us-east-1"}, Columns: []string{"type", "id", "region"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, row := range rowsOf(tbl) {
+		if row[0] == "iam-role" {
+			n++
+			if row[2] != "global" {
+				t.Errorf("role region = %s", row[2])
+			}
+		}
+	}
+	if n != 1 {
+		t.Errorf("role exported %d times, want once", n)
+	}
+	if len(tbl.Rows) != 3 {
+		t.Errorf("%d rows, want 3", len(tbl.Rows))
+	}
+}
+
+func TestInventoryAnonymize(t *testing.T) {
+	tbl, err := Inventory(Options{Columns: []string{"type", "region", "name", "arn", "tag:env"}, Anonymize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range rowsOf(tbl) {
+		for _, v := range row[2:] {
+			for _, raw := range []string{"orders", "billing", "deploy", "111111111111", "prod"} {
+				if strings.Contains(v, raw) {
+					t.Errorf("row %q leaks %q", row, raw)
+				}
+			}
+		}
+		if row[0] == "iam-role" && !strings.HasSuffix(row[3], ":role/"+row[2]) {
+			t.Errorf("role arn %s doesn't name the role %s", row[3], row[2])
+		}
+	}
+	regions := map[string]bool{}
+	for _, row := range rowsOf(tbl) {
+		regions[row[0]+" "+row[1]] = true
+	}
+	if !regions["rds eu-west-1"] || !regions["rds us-east-1"] || !regions["iam-role global"] {
+		t.Errorf("types and regions = %v, want them kept", regions)
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	tbl, err := Inventory(Options{Types: []string{"rds"}, Columns: []string{"name", "tag:owner", "arn", "tag:env"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := rowsOf(tbl)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, "csv", tbl); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(records[0], tbl.Columns) {
+		t.Errorf("csv header = %v", records[0])
+	}
+	for i, rec := range records[1:] {
+		for j, v := range rec {
+			if w := strings.Replace(want[i][j], "<nil>", "", 1); v != w {
+				t.Errorf("csv row %d %s = %q, want %q", i, tbl.Columns[j], v, w)
+			}
+		}
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "json", tbl); err != nil {
+		t.Fatal(err)
+	}
+	var objects []map[string]*string
+	if err := json.Unmarshal(buf.Bytes(), &objects); err != nil {
+		t.Fatalf("%v in %s", err, buf.String())
+	}
+	if len(objects) != len(want) {
+		t.Fatalf("json rows = %d", len(objects))
+	}
+	if keys := jsonKeyOrder(t, buf.Bytes()); !reflect.DeepEqual(keys, tbl.Columns) {
+		t.Errorf("json keys = %v, want column order", keys)
+	}
+	for i, obj := range objects {
+		for j, c := range tbl.Columns {
+			if got := show(obj[c]); got != want[i][j] {
+				t.Errorf("json row %d %s = %s, want %s", i, c, got, want[i][j])
+			}
+		}
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "parquet", tbl); err != nil {
+		t.Fatal(err)
+	}
+	r := parquet.NewReader(bytes.NewReader(buf.Bytes()))
+	defer r.Close()
+	names := map[int]string{}
+	for i, path := range r.Schema().Columns() {
+		names[i] = path[0]
+	}
+	rows := make([]parquet.Row, len(want)+1)
+	n, err := r.ReadRows(rows)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if n != len(want) {
+		t.Fatalf("parquet rows = %d", n)
+	}
+	for i, row := range rows[:n] {
+		got := map[string]string{}
+		for _, v := range row {
+			got[names[v.Column()]] = "<nil>"
+			if !v.IsNull() {
+				got[names[v.Column()]] = string(v.ByteArray())
+			}
+		}
+		for j, c := range tbl.Columns {
+			if got[c] != want[i][j] {
+				t.Errorf("parquet row %d %s = %q, want %q", i, c, got[c], want[i][j])
+			}
+		}
+	}
+
+	if err := Write(&buf, "xlsx", tbl); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+func rowsOf(t *Table) [][]string {
+	var out [][]string
+	for _, row := range t.Rows {
+		var r []string
+		for _, v := range row {
+			r = append(r, show(v))
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func show(v *string) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return *v
+}
+
+// jsonKeyOrder returns the keys of the first object in an array, in order.
+func jsonKeyOrder(t *testing.T, b []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.Token() // [
+	dec.Token() // {
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, tok.(string))
+		var v interface{}
+		dec.Decode(&v)
+	}
+	return keys
+}
@@ -0,0 +1,315 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// DiagramFormats are the formats WriteDiagram accepts.
+var DiagramFormats = []string{"svg", "png", "drawio"}
+
+// Colours of the exported diagrams, light so they sit in documentation.
+var (
+	layerColors = map[string]string{
+		"network": "#0891b2", "load-balancing": "#7c3aed", "compute": "#ea580c", "database": "#2563eb",
+	}
+	edgeColors = map[string]string{
+		"target": "#7c3aed", "security-group": "#ea580c", "placement": "#9ca3af", "reference": "#2563eb",
+	}
+	edgeDashes = map[string]string{"placement": "4 3", "reference": "2 3"}
+)
+
+const (
+	groupFill   = "#f8fafc"
+	groupStroke = "#94a3b8"
+	nodeFill    = "#ffffff"
+	textColor   = "#111827"
+	dimColor    = "#6b7280"
+	maxLabel    = 22 // characters of a name that fit in a node
+)
+
+// WriteDiagram writes d as svg, png or drawio (draw.io / diagrams.net).
+func WriteDiagram(w io.Writer, format string, d *Diagram) error {
+	switch format {
+	case "svg":
+		return WriteSVG(w, d)
+	case "png":
+		return WritePNG(w, d)
+	case "drawio":
+		return WriteDrawIO(w, d)
+	}
+	return fmt.Errorf("unknown diagram format %q: expected %s", format, strings.Join(DiagramFormats, ", "))
+}
+
+// WriteSVG writes d as a standalone SVG image.
+func WriteSVG(w io.Writer, d *Diagram) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		d.Width, d.Height, d.Width, d.Height)
+	fmt.Fprintf(&b, `<defs><marker id="arrow" viewBox="0 0 10 10" refX="9" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0 0L10 5L0 10z" fill="%s"/></marker></defs>`+"\n", dimColor)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	for _, g := range d.Groups {
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="8" fill="%s" stroke="%s" stroke-dasharray="6 4"/>`+"\n",
+			g.X, g.Y, g.W, g.H, groupFill, groupStroke)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="12" fill="%s">%s</text>`+"\n", g.X+12, g.Y+20, dimColor, escape(g.Label))
+	}
+	nodes := nodesByKey(d)
+	for _, e := range d.Edges {
+		p := route(nodes[e.From].Rect, nodes[e.To].Rect)
+		dash := ""
+		if v, ok := edgeDashes[e.Kind]; ok {
+			dash = fmt.Sprintf(` stroke-dasharray="%s"`, v)
+		}
+		fmt.Fprintf(&b, `<path d="M%g %gC%g %g %g %g %g %g" fill="none" stroke="%s" stroke-width="1.5"%s marker-end="url(#arrow)"/>`+"\n",
+			p[0], p[1], p[2], p[3], p[4], p[5], p[6], p[7], edgeColors[e.Kind], dash)
+	}
+	for _, n := range d.Nodes {
+		fmt.Fprintf(&b, `<g transform="translate(%d %d)"><title>%s</title>`, n.X, n.Y, escape(n.Type+" "+fullName(n)))
+		fmt.Fprintf(&b, `<rect width="%d" height="%d" rx="6" fill="%s" stroke="%s" stroke-width="1.5"/>`, n.W, n.H, nodeFill, layerColors[n.Layer])
+		fmt.Fprintf(&b, `<text x="10" y="16" font-size="10" fill="%s">%s</text>`, dimColor, escape(strings.ToUpper(n.Type)))
+		fmt.Fprintf(&b, `<text x="10" y="34" font-size="12" fill="%s">%s</text></g>`+"\n", textColor, escape(shortName(n)))
+	}
+	b.WriteString("</svg>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WritePNG writes d as a PNG image, drawn with a fixed bitmap font.
+func WritePNG(w io.Writer, d *Diagram) error {
+	img := image.NewRGBA(image.Rect(0, 0, max(d.Width, 1), max(d.Height, 1)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for _, g := range d.Groups {
+		box(img, g.Rect, hex(groupFill), hex(groupStroke))
+		text(img, g.X+12, g.Y+20, g.Label, hex(dimColor))
+	}
+	nodes := nodesByKey(d)
+	for _, e := range d.Edges {
+		p := route(nodes[e.From].Rect, nodes[e.To].Rect)
+		curve(img, p, hex(edgeColors[e.Kind]), e.Kind)
+	}
+	for _, n := range d.Nodes {
+		box(img, n.Rect, hex(nodeFill), hex(layerColors[n.Layer]))
+		text(img, n.X+10, n.Y+17, strings.ToUpper(n.Type), hex(dimColor))
+		text(img, n.X+10, n.Y+35, shortName(n), hex(textColor))
+	}
+	return png.Encode(w, img)
+}
+
+// WriteDrawIO writes d as a draw.io (diagrams.net) file: a container per
+// group holding its resources, and edges between them that stay attached
+// when shapes are moved.
+func WriteDrawIO(w io.Writer, d *Diagram) error {
+	type geometry struct {
+		X        int    `xml:"x,attr,omitempty"`
+		Y        int    `xml:"y,attr,omitempty"`
+		Width    int    `xml:"width,attr,omitempty"`
+		Height   int    `xml:"height,attr,omitempty"`
+		Relative string `xml:"relative,attr,omitempty"`
+		As       string `xml:"as,attr"`
+	}
+	type cell struct {
+		ID       string    `xml:"id,attr"`
+		Value    string    `xml:"value,attr,omitempty"`
+		Style    string    `xml:"style,attr,omitempty"`
+		Vertex   string    `xml:"vertex,attr,omitempty"`
+		Edge     string    `xml:"edge,attr,omitempty"`
+		Parent   string    `xml:"parent,attr,omitempty"`
+		Source   string    `xml:"source,attr,omitempty"`
+		Target   string    `xml:"target,attr,omitempty"`
+		Geometry *geometry `xml:"mxGeometry"`
+	}
+	type file struct {
+		XMLName xml.Name `xml:"mxfile"`
+		Host    string   `xml:"host,attr"`
+		Diagram struct {
+			Name  string `xml:"name,attr"`
+			ID    string `xml:"id,attr"`
+			Model struct {
+				Cells []cell `xml:"root>mxCell"`
+			} `xml:"mxGraphModel"`
+		} `xml:"diagram"`
+	}
+
+	var f file
+	f.Host = "saws"
+	f.Diagram.Name = d.Region
+	f.Diagram.ID = "saws-" + d.Region
+	cells := []cell{{ID: "0"}, {ID: "1", Parent: "0"}}
+	for i, g := range d.Groups {
+		cells = append(cells, cell{
+			ID: fmt.Sprintf("g%d", i), Value: g.Label, Vertex: "1", Parent: "1",
+			Style:    fmt.Sprintf("rounded=1;arcSize=4;dashed=1;container=1;collapsible=0;verticalAlign=top;align=left;spacingLeft=8;fillColor=%s;strokeColor=%s;fontColor=%s;", groupFill, groupStroke, dimColor),
+			Geometry: &geometry{X: g.X, Y: g.Y, Width: g.W, Height: g.H, As: "geometry"},
+		})
+	}
+	ids := map[string]string{}
+	for i, n := range d.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n.Key] = id
+		parent, gx, gy := "1", 0, 0
+		if g := groupOf(d, n); g >= 0 {
+			parent, gx, gy = fmt.Sprintf("g%d", g), d.Groups[g].X, d.Groups[g].Y
+		}
+		cells = append(cells, cell{
+			ID: id, Value: strings.ToUpper(n.Type) + "\n" + fullName(n), Vertex: "1", Parent: parent,
+			Style:    fmt.Sprintf("rounded=1;whiteSpace=wrap;align=left;spacingLeft=8;fontSize=11;fillColor=%s;strokeColor=%s;fontColor=%s;", nodeFill, layerColors[n.Layer], textColor),
+			Geometry: &geometry{X: n.X - gx, Y: n.Y - gy, Width: n.W, Height: n.H, As: "geometry"},
+		})
+	}
+	for i, e := range d.Edges {
+		style := fmt.Sprintf("edgeStyle=orthogonalEdgeStyle;curved=1;endArrow=block;endSize=4;strokeColor=%s;", edgeColors[e.Kind])
+		if dash, ok := edgeDashes[e.Kind]; ok {
+			style += "dashed=1;dashPattern=" + dash + ";"
+		}
+		cells = append(cells, cell{
+			ID: fmt.Sprintf("e%d", i), Value: e.Kind, Style: style, Edge: "1", Parent: "1",
+			Source: ids[e.From], Target: ids[e.To], Geometry: &geometry{Relative: "1", As: "geometry"},
+		})
+	}
+	f.Diagram.Model.Cells = cells
+
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func nodesByKey(d *Diagram) map[string]DiagramNode {
+	nodes := map[string]DiagramNode{}
+	for _, n := range d.Nodes {
+		nodes[n.Key] = n
+	}
+	return nodes
+}
+
+// groupOf returns the index of the group box holding n, or -1.
+func groupOf(d *Diagram, n DiagramNode) int {
+	for i, g := range d.Groups {
+		if n.X >= g.X && n.Y >= g.Y && n.X+n.W <= g.X+g.W && n.Y+n.H <= g.Y+g.H {
+			return i
+		}
+	}
+	return -1
+}
+
+// route returns the cubic Bézier joining two nodes side to side, or
+// looping out to the right when they share a column: start, two control
+// points and end, as x, y pairs. The web graph draws edges the same way.
+func route(a, b Rect) [8]float64 {
+	ay, by := float64(a.Y)+float64(a.H)/2, float64(b.Y)+float64(b.H)/2
+	var x1, x2 float64
+	switch {
+	case a.X+a.W < b.X:
+		x1, x2 = float64(a.X+a.W), float64(b.X)
+	case b.X+b.W < a.X:
+		x1, x2 = float64(a.X), float64(b.X+b.W)
+	default:
+		x1, x2 = float64(a.X+a.W), float64(b.X+b.W)
+		return [8]float64{x1, ay, x1 + 40, ay, x2 + 40, by, x2, by}
+	}
+	mid := (x1 + x2) / 2
+	return [8]float64{x1, ay, mid, ay, mid, by, x2, by}
+}
+
+func fullName(n DiagramNode) string {
+	if n.Name != "" && n.Name != n.ID {
+		return n.Name + " (" + n.ID + ")"
+	}
+	return n.ID
+}
+
+func shortName(n DiagramNode) string {
+	s := n.Name
+	if s == "" {
+		s = n.ID
+	}
+	if r := []rune(s); len(r) > maxLabel {
+		return string(r[:maxLabel-1]) + "…"
+	}
+	return s
+}
+
+func hex(s string) color.RGBA {
+	var c color.RGBA
+	fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	c.A = 0xff
+	return c
+}
+
+// box fills r and outlines it two pixels wide.
+func box(img *image.RGBA, r Rect, fill, stroke color.RGBA) {
+	rect := image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H)
+	draw.Draw(img, rect, image.NewUniform(stroke), image.Point{}, draw.Src)
+	draw.Draw(img, rect.Inset(2), image.NewUniform(fill), image.Point{}, draw.Src)
+}
+
+// text draws s at (x, y). The bitmap font only covers ASCII, so the
+// ellipsis of a shortened name is spelled out.
+func text(img *image.RGBA, x, y int, s string, c color.RGBA) {
+	s = strings.ReplaceAll(s, "…", "...")
+	d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: basicfont.Face7x13, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+// curve strokes the Bézier p, dashed like the SVG for placement and
+// reference edges, and ends it with an arrowhead.
+func curve(img *image.RGBA, p [8]float64, c color.RGBA, kind string) {
+	at := func(t float64) (float64, float64) {
+		u := 1 - t
+		x := u*u*u*p[0] + 3*u*u*t*p[2] + 3*u*t*t*p[4] + t*t*t*p[6]
+		y := u*u*u*p[1] + 3*u*u*t*p[3] + 3*u*t*t*p[5] + t*t*t*p[7]
+		return x, y
+	}
+	on, off := math.Inf(1), 0.0
+	switch kind {
+	case "placement":
+		on, off = 4, 3
+	case "reference":
+		on, off = 2, 3
+	}
+	src := image.NewUniform(c)
+	px, py := at(0)
+	travelled := 0.0
+	for i := 1; i <= 400; i++ {
+		x, y := at(float64(i) / 400)
+		travelled += math.Hypot(x-px, y-py)
+		if math.Mod(travelled, on+off) < on {
+			draw.Draw(img, image.Rect(int(x)-1, int(y)-1, int(x)+1, int(y)+1), src, image.Point{}, draw.Over)
+		}
+		px, py = x, y
+	}
+
+	// Arrowhead along the last control point's tangent.
+	dx, dy := p[6]-p[4], p[7]-p[5]
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return
+	}
+	dx, dy = dx/l, dy/l
+	tip := [2]float64{p[6], p[7]}
+	b := img.Bounds()
+	r := vector.NewRasterizer(b.Dx(), b.Dy())
+	r.MoveTo(float32(tip[0]), float32(tip[1]))
+	r.LineTo(float32(tip[0]-8*dx-4*dy), float32(tip[1]-8*dy+4*dx))
+	r.LineTo(float32(tip[0]-8*dx+4*dy), float32(tip[1]-8*dy-4*dx))
+	r.ClosePath()
+	r.Draw(img, b, src, image.Point{})
+}
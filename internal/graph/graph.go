@@ -0,0 +1,117 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// Node is one resource. Resources that are referenced but not cached,
+// such as a role in another account, get a node with Cached false.
+type Node struct {
+	Key    string // region/type/id, unique across regions
+	Item   sync.InventoryItem
+	Cached bool
+}
+
+// Edge says From depends on To. Kind is the sync.Relationship kind:
+// placement, security-group, target or reference.
+type Edge struct {
+	From, To, Kind string
+}
+
+// Graph is the cached resources and their relationships as a property
+// graph for Neo4j or Gephi, sorted for stable output.
+type Graph struct {
+	Nodes []Node
+	Edges []Edge
+}
+
+// Build merges the topology of each region (see sync.LoadTopology) into
+// one graph, so the export has the same relationships as the Graph tab.
+// Global resources (S3, IAM, CloudFront) appear in every region's
+// inventory and are merged into one node.
+func Build(regions []string) (*Graph, error) {
+	nodes := map[string]*Node{}
+	byRef := map[string][]string{} // type/id -> node keys
+	type pending struct {
+		from, region string
+		rel          sync.Relationship
+	}
+	var dangling []pending
+
+	g := &Graph{}
+	seen := map[Edge]bool{}
+	add := func(e Edge) {
+		if !seen[e] {
+			seen[e] = true
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	for _, region := range regions {
+		t, err := sync.LoadTopology(region)
+		if err != nil {
+			return nil, err
+		}
+		keyOf := map[string]string{} // type/id -> node key
+		for _, it := range t.Items {
+			key := it.Region + "/" + it.Key()
+			keyOf[it.Key()] = key
+			if _, ok := nodes[key]; ok {
+				continue
+			}
+			nodes[key] = &Node{Key: key, Item: it, Cached: true}
+			byRef[it.Key()] = append(byRef[it.Key()], key)
+		}
+		for _, r := range t.Relationships {
+			add(Edge{keyOf[r.From], keyOf[r.To], r.Kind})
+		}
+		for _, r := range t.Dangling {
+			dangling = append(dangling, pending{keyOf[r.From], region, r})
+		}
+	}
+
+	// A reference not cached in its own region may be cached in another.
+	for _, p := range dangling {
+		to := resolve(byRef[p.rel.To], p.region)
+		if to == "" {
+			to = p.region + "/" + p.rel.To
+			if _, ok := nodes[to]; !ok {
+				typ, id, _ := strings.Cut(p.rel.To, "/")
+				nodes[to] = &Node{Key: to, Item: sync.InventoryItem{Type: typ, ID: id, Region: p.region}}
+			}
+		}
+		add(Edge{p.from, to, p.rel.Kind})
+	}
+
+	for _, n := range nodes {
+		g.Nodes = append(g.Nodes, *n)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].Key < g.Nodes[j].Key })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return g, nil
+}
+
+// resolve picks the node a reference from region points at: the one in
+// the same region, else the only candidate.
+func resolve(candidates []string, region string) string {
+	for _, k := range candidates {
+		if strings.HasPrefix(k, region+"/") {
+			return k
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return ""
+}
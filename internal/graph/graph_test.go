@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "saws-graph-test")
+	if err != nil {
+		panic(err)
+	}
+	restore := awscli.Use(awscli.NewFake())
+	sync.UseAccount("111111111111")
+	sync.SetDataDir(dir)
+	if err := sync.InitDB(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	restore()
+	sync.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// TestBuildMatchesTopology checks that the export carries the same
+// security-group and target-group relationships as the Graph tab.
+func TestBuildMatchesTopology(t *testing.T) {
+	region := "eu-west-1"
+	sync.WriteCache(region+":security-groups", []byte(`{"SecurityGroups": [
+		{"GroupId": "sg-lb", "VpcId": "vpc-1"},
+		{"GroupId": "sg-app", "VpcId": "vpc-1", "IpPermissions": [{"IpProtocol": "tcp", "FromPort": 80, "ToPort": 80, "UserIdGroupPairs": [{"GroupId": "sg-lb"}]}]}]}`))
+	sync.WriteCache(region+":load-balancers", []byte(`[{"Name": "web", "Arn": "arn:lb/web", "VpcId": "vpc-1", "SecurityGroups": ["sg-lb"]}]`))
+	sync.WriteCache(region+":target-groups", []byte(`[{"Name": "web-tg", "VpcId": "vpc-1", "LoadBalancerArn": "arn:lb/web", "Targets": [{"Id": "i-1", "Port": 80}]}]`))
+	sync.WriteCache(region+":ec2-enriched", []byte(`[{"InstanceId": "i-1", "VpcId": "vpc-1", "SecurityGroups": ["sg-app"], "IamRole": "missing-role"}]`))
+
+	g, err := Build([]string{region})
+	if err != nil {
+		t.Fatal(err)
+	}
+	topo, err := sync.LoadTopology(region)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges := map[Edge]bool{}
+	for _, e := range g.Edges {
+		edges[e] = true
+	}
+	for _, r := range topo.Relationships {
+		if e := (Edge{region + "/" + r.From, region + "/" + r.To, r.Kind}); !edges[e] {
+			t.Errorf("export is missing %+v", e)
+		}
+	}
+	for _, e := range []Edge{
+		{region + "/sg/sg-app", region + "/sg/sg-lb", sync.RelSecurityGroup},
+		{region + "/tg/web-tg", region + "/ec2/i-1", sync.RelTarget},
+		{region + "/ec2/i-1", region + "/iam-role/missing-role", sync.RelReference},
+	} {
+		if !edges[e] {
+			t.Errorf("missing %+v", e)
+		}
+	}
+
+	var buf bytes.Buffer
+	WriteCypher(&buf, g)
+	if !strings.Contains(buf.String(), "[:DEPENDS_ON {kind: 'target'}]") {
+		t.Errorf("cypher has no edge kinds:\n%s", buf.String())
+	}
+}
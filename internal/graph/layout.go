@@ -0,0 +1,142 @@
+package graph
+
+import "github.com/estrados/simply-aws/internal/sync"
+
+// Diagram geometry, in pixels.
+const (
+	nodeW, nodeH = 180, 44
+	colGap       = 60
+	rowGap       = 14
+	groupPad     = 24
+	groupHeader  = 32
+	groupGap     = 40
+	margin       = 20
+)
+
+// layerOrder is the left-to-right order of the columns in a group.
+var layerOrder = []string{"network", "load-balancing", "compute", "database"}
+
+// Rect is a position and size in pixels.
+type Rect struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+// DiagramNode is a resource placed on a diagram. Type and ID match the
+// /detail/{type}/{id} routes.
+type DiagramNode struct {
+	Key   string `json:"key"`
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Layer string `json:"layer"`
+	Rect
+}
+
+// DiagramGroup boxes the resources of one VPC, or those outside any VPC.
+type DiagramGroup struct {
+	VpcId string `json:"vpcId,omitempty"`
+	Label string `json:"label"`
+	Rect
+}
+
+// Diagram is an architecture topology laid out for drawing.
+type Diagram struct {
+	Region string              `json:"region"`
+	Width  int                 `json:"width"`
+	Height int                 `json:"height"`
+	Groups []DiagramGroup      `json:"groups"`
+	Nodes  []DiagramNode       `json:"nodes"`
+	Edges  []sync.Relationship `json:"edges"`
+}
+
+// Layout places an architecture topology (see Topology.Architecture) on
+// a canvas: a box per VPC, top to bottom, holding columns of subnets and
+// gateways, load balancers, compute and databases from left to right.
+// Resources outside any VPC share a last box. VPCs are drawn as their
+// boxes, so edges to them are dropped.
+func Layout(t *sync.Topology) *Diagram {
+	d := &Diagram{Region: t.Region}
+	vpcOf := t.VPCOf()
+
+	type group struct {
+		DiagramGroup
+		columns map[string][]sync.InventoryItem
+	}
+	var groups []*group
+	byVPC := map[string]*group{}
+	for _, it := range t.Items {
+		if it.Type == "vpc" {
+			g := &group{DiagramGroup: DiagramGroup{VpcId: it.ID, Label: "VPC " + it.Label()}, columns: map[string][]sync.InventoryItem{}}
+			groups = append(groups, g)
+			byVPC[it.ID] = g
+		}
+	}
+	regional := &group{DiagramGroup: DiagramGroup{Label: "Outside a VPC"}, columns: map[string][]sync.InventoryItem{}}
+	for _, it := range t.Items {
+		if it.Type == "vpc" {
+			continue
+		}
+		g := byVPC[vpcOf[it.Key()]]
+		if g == nil {
+			g = regional
+		}
+		layer := sync.ArchitectureLayer(it.Type)
+		g.columns[layer] = append(g.columns[layer], it)
+	}
+	if len(regional.columns) > 0 {
+		groups = append(groups, regional)
+	}
+
+	placed := map[string]bool{}
+	y := margin
+	for _, g := range groups {
+		x := margin + groupPad
+		rows := 0
+		for _, layer := range layerOrder {
+			items := g.columns[layer]
+			if len(items) == 0 {
+				continue
+			}
+			for i, it := range items {
+				d.Nodes = append(d.Nodes, DiagramNode{
+					Key: it.Key(), Type: it.Type, ID: it.ID, Name: it.Name, Layer: layer,
+					Rect: Rect{X: x, Y: y + groupHeader + i*(nodeH+rowGap), W: nodeW, H: nodeH},
+				})
+				placed[it.Key()] = true
+			}
+			rows = max(rows, len(items))
+			x += nodeW + colGap
+		}
+		w := max(x-colGap+groupPad-margin, 2*groupPad+nodeW)
+		h := groupHeader + max(rows*(nodeH+rowGap)-rowGap, nodeH) + groupPad
+		g.Rect = Rect{X: margin, Y: y, W: w, H: h}
+		d.Groups = append(d.Groups, g.DiagramGroup)
+		d.Width = max(d.Width, w+2*margin)
+		y += h + groupGap
+	}
+	d.Height = y - groupGap + margin
+
+	for _, r := range t.Relationships {
+		if placed[r.From] && placed[r.To] {
+			d.Edges = append(d.Edges, r)
+		}
+	}
+	return d
+}
+
+// ArchitectureDiagram lays out the cached architecture of a region, or of
+// one VPC in it when vpcId is set.
+func ArchitectureDiagram(region, vpcId string) (*Diagram, error) {
+	t, err := sync.LoadTopology(region)
+	if err != nil {
+		return nil, err
+	}
+	t = t.Architecture()
+	if vpcId != "" {
+		t = t.InVPC(vpcId)
+	}
+	return Layout(t), nil
+}
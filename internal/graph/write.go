@@ -0,0 +1,214 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// WriteCypher writes MERGE statements that can be run repeatedly with
+// cypher-shell. Every node gets the Resource label plus one for its type;
+// edges are DEPENDS_ON with the relationship kind as a property.
+func WriteCypher(w io.Writer, g *Graph) error {
+	fmt.Fprintln(w, "CREATE CONSTRAINT saws_resource_key IF NOT EXISTS FOR (n:Resource) REQUIRE n.key IS UNIQUE;")
+	for _, n := range g.Nodes {
+		var sets []string
+		for _, p := range properties(n) {
+			sets = append(sets, fmt.Sprintf("n.`%s` = %s", strings.ReplaceAll(p.name, "`", ""), cypherString(p.value)))
+		}
+		sets = append(sets, fmt.Sprintf("n.cached = %t", n.Cached))
+		fmt.Fprintf(w, "MERGE (n:Resource {key: %s}) SET n:`%s`, %s;\n",
+			cypherString(n.Key), labelFor(n.Item.Type), strings.Join(sets, ", "))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(w, "MATCH (a:Resource {key: %s}), (b:Resource {key: %s}) MERGE (a)-[:DEPENDS_ON {kind: %s}]->(b);\n",
+			cypherString(e.From), cypherString(e.To), cypherString(e.Kind))
+	}
+	return nil
+}
+
+// WriteGraphML writes a directed GraphML document with the same node and
+// edge properties as WriteCypher.
+func WriteGraphML(w io.Writer, g *Graph) error {
+	// GraphML needs every attribute declared up front.
+	names := map[string]bool{}
+	for _, n := range g.Nodes {
+		for _, p := range properties(n) {
+			names[p.name] = true
+		}
+	}
+	keys := make([]string, 0, len(names))
+	for k := range names {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ids := map[string]string{}
+	for i, k := range keys {
+		ids[k] = fmt.Sprintf("d%d", i)
+	}
+
+	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(w, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  <key id=\"%s\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", ids[k], escape(k))
+	}
+	fmt.Fprintln(w, `  <key id="cached" for="node" attr.name="cached" attr.type="boolean"/>`)
+	fmt.Fprintln(w, `  <key id="label" for="node" attr.name="label" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <key id="kind" for="edge" attr.name="kind" attr.type="string"/>`)
+	fmt.Fprintln(w, `  <graph id="saws" edgedefault="directed">`)
+	for _, n := range g.Nodes {
+		fmt.Fprintf(w, "    <node id=\"%s\">\n", escape(n.Key))
+		fmt.Fprintf(w, "      <data key=\"label\">%s</data>\n", escape(n.Item.Label()))
+		fmt.Fprintf(w, "      <data key=\"cached\">%t</data>\n", n.Cached)
+		for _, p := range properties(n) {
+			fmt.Fprintf(w, "      <data key=\"%s\">%s</data>\n", ids[p.name], escape(p.value))
+		}
+		fmt.Fprintln(w, "    </node>")
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(w, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, escape(e.From), escape(e.To))
+		fmt.Fprintf(w, "      <data key=\"kind\">%s</data>\n", escape(e.Kind))
+		fmt.Fprintln(w, "    </edge>")
+	}
+	fmt.Fprintln(w, "  </graph>")
+	fmt.Fprintln(w, "</graphml>")
+	return nil
+}
+
+// WriteDOT writes a topology as a Graphviz digraph: a cluster per VPC
+// holding the resources placed in it, and an edge per relationship labelled
+// and styled by its kind. Architecture resources are outlined in the
+// colour of their layer, as in the Graph tab.
+func WriteDOT(w io.Writer, t *sync.Topology) error {
+	fmt.Fprintln(w, "digraph saws {")
+	fmt.Fprintf(w, "  graph [rankdir=LR, fontname=Helvetica, labelloc=t, label=%s];\n", dotString(t.Region))
+	fmt.Fprintln(w, `  node [shape=box, style=rounded, fontname=Helvetica, fontsize=10];`)
+	fmt.Fprintln(w, `  edge [fontname=Helvetica, fontsize=8];`)
+
+	vpcOf := t.VPCOf()
+	clusters := map[string][]sync.InventoryItem{}
+	labels := map[string]string{}
+	var outside []sync.InventoryItem
+	for _, it := range t.Items {
+		vpc := vpcOf[it.Key()]
+		if it.Type == "vpc" {
+			vpc = it.ID
+			labels[vpc] = it.Label()
+		}
+		if vpc == "" {
+			outside = append(outside, it)
+			continue
+		}
+		clusters[vpc] = append(clusters[vpc], it)
+	}
+	node := func(indent string, it sync.InventoryItem) {
+		attrs := fmt.Sprintf("label=%s", dotString(it.Type+"\n"+it.Label()))
+		if c, ok := layerColors[sync.ArchitectureLayer(it.Type)]; ok {
+			attrs += fmt.Sprintf(", color=%s", dotString(c))
+		}
+		fmt.Fprintf(w, "%s%s [%s];\n", indent, dotString(it.Key()), attrs)
+	}
+	vpcs := make([]string, 0, len(clusters))
+	for vpc := range clusters {
+		vpcs = append(vpcs, vpc)
+	}
+	sort.Strings(vpcs)
+	for _, vpc := range vpcs {
+		label := labels[vpc]
+		if label == "" {
+			label = vpc
+		}
+		fmt.Fprintf(w, "  subgraph %s {\n", dotString("cluster_"+vpc))
+		fmt.Fprintf(w, "    label=%s; style=dashed; color=%s;\n", dotString("VPC "+label), dotString(groupStroke))
+		for _, it := range clusters[vpc] {
+			node("    ", it)
+		}
+		fmt.Fprintln(w, "  }")
+	}
+	for _, it := range outside {
+		node("  ", it)
+	}
+
+	for _, r := range t.Relationships {
+		attrs := fmt.Sprintf("label=%s, color=%s", dotString(r.Kind), dotString(edgeColors[r.Kind]))
+		switch r.Kind {
+		case sync.RelPlacement:
+			attrs += ", style=dashed"
+		case sync.RelReference:
+			attrs += ", style=dotted"
+		}
+		fmt.Fprintf(w, "  %s -> %s [%s];\n", dotString(r.From), dotString(r.To), attrs)
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
+
+// dotString quotes s as a DOT ID; newlines become line breaks in labels.
+func dotString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return `"` + s + `"`
+}
+
+type property struct{ name, value string }
+
+// properties flattens a node's inventory fields, details and tags. Empty
+// values are left out.
+func properties(n Node) []property {
+	it := n.Item
+	var out []property
+	add := func(name, value string) {
+		if value != "" {
+			out = append(out, property{name, value})
+		}
+	}
+	add("type", it.Type)
+	add("id", it.ID)
+	add("name", it.Name)
+	add("region", it.Region)
+	add("arn", it.Arn)
+	add("vpcId", it.VpcId)
+	for _, k := range sortedKeys(it.Details) {
+		add(k, it.Details[k])
+	}
+	for _, k := range sortedKeys(it.Tags) {
+		add("tag:"+k, it.Tags[k])
+	}
+	return out
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelFor turns an inventory type such as "rds-cluster" into a label
+// that does not need escaping beyond backticks.
+func labelFor(typ string) string {
+	if typ == "" {
+		return "Unknown"
+	}
+	return strings.ReplaceAll(typ, "`", "")
+}
+
+func cypherString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return "'" + s + "'"
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,241 @@
+package handoff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// Selector picks the resources that make up an application or team.
+// A resource matches if it carries every tag in Tags and, when Match is
+// set, its name or ID contains Match.
+type Selector struct {
+	Tags  map[string]string
+	Match string
+}
+
+func (s Selector) matches(it sync.InventoryItem) bool {
+	for k, v := range s.Tags {
+		if it.Tags[k] != v {
+			return false
+		}
+	}
+	if s.Match != "" {
+		m := strings.ToLower(s.Match)
+		if !strings.Contains(strings.ToLower(it.Name), m) && !strings.Contains(strings.ToLower(it.ID), m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	var parts []string
+	for k, v := range s.Tags {
+		parts = append(parts, "tag "+k+"="+v)
+	}
+	sort.Strings(parts)
+	if s.Match != "" {
+		parts = append(parts, fmt.Sprintf("name contains %q", s.Match))
+	}
+	if len(parts) == 0 {
+		return "all resources"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Write renders a Markdown handoff document for the selected resources
+// across the given regions.
+func Write(w io.Writer, regions []string, sel Selector) error {
+	items, err := sync.LoadInventories(regions)
+	if err != nil {
+		return err
+	}
+	notes, err := sync.GetAnnotations()
+	if err != nil {
+		return err
+	}
+
+	byKey := map[string]sync.InventoryItem{}
+	var selected []sync.InventoryItem
+	inScope := map[string]bool{}
+	for _, it := range items {
+		byKey[it.Key()] = it
+		if sel.matches(it) {
+			selected = append(selected, it)
+			inScope[it.Key()] = true
+		}
+	}
+
+	fmt.Fprintf(w, "# Handoff: %s\n\n", sel)
+	fmt.Fprintf(w, "Regions: %s  \nGenerated: %s\n\n", strings.Join(regions, ", "), time.Now().Format("2006-01-02 15:04"))
+	if len(selected) == 0 {
+		fmt.Fprintln(w, "No cached resources match this selection. Run `saws sync` first or widen the selector.")
+		return nil
+	}
+
+	// Resources
+	fmt.Fprintf(w, "## Resources (%d)\n\n", len(selected))
+	fmt.Fprintln(w, "| Type | Name | ID | Region | VPC |")
+	fmt.Fprintln(w, "|------|------|----|--------|-----|")
+	for _, it := range selected {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n", cell(it.Type), cell(it.Name), cell(it.ID), cell(it.Region), cell(dash(it.VpcId)))
+	}
+	fmt.Fprintln(w)
+
+	// Configuration
+	fmt.Fprintln(w, "## Configuration")
+	fmt.Fprintln(w)
+	for _, it := range selected {
+		fmt.Fprintf(w, "### %s `%s`\n\n", it.Type, it.Label())
+		for _, k := range sortedKeys(it.Details) {
+			if it.Details[k] != "" {
+				fmt.Fprintf(w, "- **%s:** %s\n", k, it.Details[k])
+			}
+		}
+		for _, k := range sortedKeys(it.Tags) {
+			fmt.Fprintf(w, "- **tag %s:** %s\n", k, it.Tags[k])
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Dependencies
+	fmt.Fprintln(w, "## Dependencies")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Resources outside this selection that the service relies on, or that rely on it.")
+	fmt.Fprintln(w)
+	depends := map[string][]string{}
+	dependents := map[string][]string{}
+	for _, it := range selected {
+		for _, r := range it.Refs {
+			if !inScope[r] {
+				depends[r] = append(depends[r], it.Key())
+			}
+		}
+	}
+	for _, it := range items {
+		if inScope[it.Key()] {
+			continue
+		}
+		for _, r := range it.Refs {
+			if inScope[r] {
+				dependents[it.Key()] = append(dependents[it.Key()], r)
+			}
+		}
+	}
+	writeDeps(w, "Depends on", "needed by", depends, byKey)
+	writeDeps(w, "Used by", "uses", dependents, byKey)
+
+	// Runbooks
+	fmt.Fprintln(w, "## Runbook notes")
+	fmt.Fprintln(w)
+	found := false
+	for _, it := range selected {
+		for _, n := range notes[it.Key()] {
+			found = true
+			fmt.Fprintf(w, "- `%s` (%s): %s\n", it.Key(), n.CreatedAt.Format("2006-01-02"), n.Note)
+		}
+	}
+	if !found {
+		fmt.Fprintln(w, "_No annotations. Add one with `saws annotate <type/id> <note>`._")
+	}
+	fmt.Fprintln(w)
+
+	// Costs
+	fmt.Fprintln(w, "## Costs (estimated)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "On-demand list prices for EC2 instances and Fargate services, per month. saws does not read billing data, so storage, transfer and discounts are not included.")
+	fmt.Fprintln(w)
+	var total float64
+	priced := 0
+	unpriced := map[string]int{}
+	for _, it := range selected {
+		monthly, ok := sync.MonthlyEstimate(it)
+		if !ok {
+			key := it.Type
+			for _, d := range []string{"Instance type", "Class", "Node type"} {
+				if v := it.Details[d]; v != "" {
+					key += " " + v
+				}
+			}
+			unpriced[key]++
+			continue
+		}
+		if priced == 0 {
+			fmt.Fprintln(w, "| Resource | Region | Size | Monthly |")
+			fmt.Fprintln(w, "|----------|--------|------|---------|")
+		}
+		priced++
+		total += monthly
+		size := it.Details["Instance type"]
+		if size == "" {
+			_, desired, _ := strings.Cut(it.Details["Tasks"], "/")
+			size = it.Details["Task size"] + " × " + desired + " tasks"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s | $%.2f |\n", cell(it.Type+" "+it.Label()), cell(it.Region), cell(size), monthly)
+	}
+	if priced > 0 {
+		fmt.Fprintf(w, "| **Total** | | | **$%.2f** |\n\n", total)
+	} else {
+		fmt.Fprintln(w, "_Nothing in this selection has a list price in saws._")
+		fmt.Fprintln(w)
+	}
+	if len(unpriced) > 0 {
+		fmt.Fprintln(w, "Not priced:")
+		fmt.Fprintln(w)
+		for _, k := range sortedKeys(unpriced) {
+			fmt.Fprintf(w, "- %d × %s\n", unpriced[k], k)
+		}
+	}
+	return nil
+}
+
+func writeDeps(w io.Writer, title, verb string, deps map[string][]string, byKey map[string]sync.InventoryItem) {
+	fmt.Fprintf(w, "**%s**\n\n", title)
+	if len(deps) == 0 {
+		fmt.Fprintln(w, "_None_")
+		fmt.Fprintln(w)
+		return
+	}
+	keys := make([]string, 0, len(deps))
+	for k := range deps {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		label := k
+		if it, ok := byKey[k]; ok {
+			label = it.Type + " " + it.Label()
+		}
+		fmt.Fprintf(w, "- %s (%s %s)\n", label, verb, strings.Join(deps[k], ", "))
+	}
+	fmt.Fprintln(w)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+// cell escapes a value for a Markdown table cell: pipes end the cell and
+// newlines end the row.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,67 @@
+package handoff
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "saws-handoff-test")
+	if err != nil {
+		panic(err)
+	}
+	restore := awscli.Use(awscli.NewFake())
+	sync.UseAccount("111111111111")
+	sync.SetDataDir(dir)
+	if err := sync.InitDB(); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	restore()
+	sync.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestWrite(t *testing.T) {
+	sync.WriteCache("eu-west-1:ec2-enriched", []byte(`[
+		{"InstanceId": "i-1", "Name": "web|blue", "InstanceType": "t3.large", "State": "running", "Tags": {"team": "web"}},
+		{"InstanceId": "i-2", "Name": "batch", "InstanceType": "x9.large", "State": "running", "Tags": {"team": "data"}}]`))
+	sync.WriteCache("us-east-1:ec2-enriched", []byte(`[
+		{"InstanceId": "i-3", "Name": "web-dr", "InstanceType": "t3.large", "State": "stopped", "Tags": {"team": "web"}},
+		{"InstanceId": "i-4", "Name": "odd", "InstanceType": "x9.large", "State": "running", "Tags": {"team": "web"}}]`))
+
+	var buf bytes.Buffer
+	if err := Write(&buf, []string{"eu-west-1", "us-east-1"}, Selector{Tags: map[string]string{"team": "web"}}); err != nil {
+		t.Fatal(err)
+	}
+	doc := buf.String()
+	for _, want := range []string{
+		"| ec2 | web\\|blue | i-1 | eu-west-1 | — |",
+		"| ec2 | web-dr | i-3 | us-east-1 | — |",
+		"| ec2 web\\|blue (i-1) | eu-west-1 | t3.large | $60.74 |",
+		"| ec2 web-dr (i-3) | us-east-1 | t3.large | $0.00 |",
+		"| **Total** | | | **$60.74** |",
+		"- 1 × ec2 x9.large",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("missing %q in:\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "batch") {
+		t.Errorf("another team's instance is included:\n%s", doc)
+	}
+}
+
+func TestCell(t *testing.T) {
+	if got := cell("a|b\nc\r\nd"); got != `a\|b<br>c<br>d` {
+		t.Errorf("cell = %q", got)
+	}
+}
@@ -0,0 +1,183 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/audit"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// Events a hook can subscribe to.
+const (
+	SyncCompleted   = "sync.completed"
+	DiffDetected    = "diff.detected"
+	AuditFindingNew = "audit.finding.new"
+)
+
+// settingKey holds the configured hooks as JSON. It is not one of the
+// 'saws config' settings; hooks are edited with 'saws hooks'.
+const settingKey = "hooks"
+
+// deliveryTimeout bounds each command or webhook so a stuck hook cannot
+// hold up the next sync.
+const deliveryTimeout = 30 * time.Second
+
+// Events lists the event names in the order they fire after a sync.
+var Events = []string{SyncCompleted, DiffDetected, AuditFindingNew}
+
+// Hook runs Target when Event fires. Targets starting with http:// or
+// https:// receive the payload as a POST; anything else is run with
+// sh -c and gets it on stdin.
+type Hook struct {
+	Event  string `json:"event"`
+	Target string `json:"target"`
+}
+
+// IsWebhook reports whether the target is a URL rather than a command.
+func (h Hook) IsWebhook() bool {
+	return strings.HasPrefix(h.Target, "http://") || strings.HasPrefix(h.Target, "https://")
+}
+
+// Payload is the JSON a hook receives. Only the fields of its event are
+// set.
+type Payload struct {
+	Event    string               `json:"event"`
+	Time     time.Time            `json:"time"`
+	Region   string               `json:"region"`
+	Scope    string               `json:"scope,omitempty"` // tab synced, or "all"
+	Results  []sync.SyncResult    `json:"results,omitempty"`
+	Added    []sync.InventoryItem `json:"added,omitempty"`
+	Removed  []sync.InventoryItem `json:"removed,omitempty"`
+	Changed  []sync.InventoryItem `json:"changed,omitempty"`
+	Findings []audit.Finding      `json:"findings,omitempty"`
+}
+
+// Delivery is the outcome of running one hook.
+type Delivery struct {
+	Hook Hook
+	Err  error
+}
+
+// List returns the configured hooks.
+func List() ([]Hook, error) {
+	raw, err := sync.GetSetting(settingKey)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	var hooks []Hook
+	if err := json.Unmarshal([]byte(raw), &hooks); err != nil {
+		return nil, fmt.Errorf("hooks setting is corrupt: %w", err)
+	}
+	return hooks, nil
+}
+
+// Add registers target for event.
+func Add(event, target string) error {
+	if !validEvent(event) {
+		return fmt.Errorf("unknown event %q (known: %s)", event, strings.Join(Events, ", "))
+	}
+	if strings.TrimSpace(target) == "" {
+		return fmt.Errorf("empty hook target")
+	}
+	hooks, err := List()
+	if err != nil {
+		return err
+	}
+	return save(append(hooks, Hook{Event: event, Target: target}))
+}
+
+// Remove deletes the hook at index i of List.
+func Remove(i int) error {
+	hooks, err := List()
+	if err != nil {
+		return err
+	}
+	if i < 0 || i >= len(hooks) {
+		return fmt.Errorf("no hook #%d (%d configured)", i+1, len(hooks))
+	}
+	return save(append(hooks[:i], hooks[i+1:]...))
+}
+
+func save(hooks []Hook) error {
+	b, _ := json.Marshal(hooks)
+	return sync.SetSetting(settingKey, string(b))
+}
+
+func validEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribed returns the hooks for event.
+func subscribed(hooks []Hook, event string) []Hook {
+	var out []Hook
+	for _, h := range hooks {
+		if h.Event == event {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// Fire delivers p to every hook subscribed to p.Event, one at a time.
+func Fire(p Payload) []Delivery {
+	hooks, err := List()
+	if err != nil {
+		return []Delivery{{Err: err}}
+	}
+	return fire(subscribed(hooks, p.Event), p)
+}
+
+func fire(hooks []Hook, p Payload) []Delivery {
+	if len(hooks) == 0 {
+		return nil
+	}
+	if p.Time.IsZero() {
+		p.Time = time.Now().UTC()
+	}
+	body, _ := json.Marshal(p)
+	var out []Delivery
+	for _, h := range hooks {
+		out = append(out, Delivery{Hook: h, Err: deliver(h, p.Event, body)})
+	}
+	return out
+}
+
+func deliver(h Hook, event string, body []byte) error {
+	if h.IsWebhook() {
+		client := &http.Client{Timeout: deliveryTimeout}
+		resp, err := client.Post(h.Target, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s returned %s", h.Target, resp.Status)
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Target)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), "SAWS_EVENT="+event)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("timed out after %s", deliveryTimeout)
+		}
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
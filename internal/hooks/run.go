@@ -0,0 +1,168 @@
+package hooks
+
+import (
+	"encoding/json"
+
+	"github.com/estrados/simply-aws/internal/audit"
+	"github.com/estrados/simply-aws/internal/diff"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// seenFindingsKey holds the audit findings each region had at its last
+// check, keyed by region. It has no region prefix so CachedRegions does
+// not pick it up.
+const seenFindingsKey = "hooks-audit-seen"
+
+// Run collects the results of one sync, records it as a cache snapshot
+// and fires the hooks when it finishes. The inventory is only compared
+// when a diff.detected hook is configured. A run marked failed is rolled
+// back instead: none of its cache writes land and no hooks fire.
+type Run struct {
+	region  string
+	scope   string
+	hooks   []Hook
+	before  []sync.InventoryItem
+	results []sync.SyncResult
+	stage   *sync.Stage
+	err     error
+}
+
+// Begin starts tracking a sync of scope ("all" or a tab) in region and
+// opens its snapshot. Call it before the first service is synced, and
+// sync through Stage.
+func Begin(region, scope string) (*Run, error) {
+	hooks, _ := List()
+	r := &Run{region: region, scope: scope, hooks: hooks}
+	if len(subscribed(hooks, DiffDetected)) > 0 {
+		r.before, _ = sync.LoadInventory(region)
+	}
+	stage, err := sync.BeginSnapshot(region, scope)
+	if err != nil {
+		return nil, err
+	}
+	r.stage = stage
+	return r, nil
+}
+
+// Stage returns the stage the run's services write the cache through.
+func (r *Run) Stage() *sync.Stage {
+	return r.stage
+}
+
+// Add records the results of one service's sync.
+func (r *Run) Add(results []sync.SyncResult) {
+	r.results = append(r.results, results...)
+}
+
+// Fail marks the run failed, keeping the first error.
+func (r *Run) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns why the run was rolled back: the error it was failed with,
+// or committing its cache writes failing. Nil before Finish.
+func (r *Run) Err() error {
+	return r.err
+}
+
+// Finish commits the sync's cache writes and closes the snapshot, then
+// fires sync.completed, diff.detected if the inventory changed and
+// audit.finding.new if the audit found anything new. A failed run is
+// rolled back and fires nothing.
+func (r *Run) Finish() []Delivery {
+	if r.err != nil {
+		sync.AbortSnapshot(r.stage)
+		return nil
+	}
+	if err := sync.CommitSnapshot(r.stage); err != nil {
+		r.err = err
+		return nil
+	}
+	sync.FinishSnapshot(r.stage)
+	if len(r.hooks) == 0 {
+		return nil
+	}
+	out := fire(subscribed(r.hooks, SyncCompleted), Payload{
+		Event: SyncCompleted, Region: r.region, Scope: r.scope, Results: r.results,
+	})
+
+	// A first sync has nothing to compare against.
+	if hooks := subscribed(r.hooks, DiffDetected); len(hooks) > 0 && len(r.before) > 0 {
+		after, _ := sync.LoadInventory(r.region)
+		var added, removed, changed []sync.InventoryItem
+		for _, c := range diff.Compare(r.before, after) {
+			switch c.Kind {
+			case diff.Added:
+				added = append(added, c.Item)
+			case diff.Removed:
+				removed = append(removed, c.Item)
+			case diff.Changed:
+				changed = append(changed, c.Item)
+			}
+		}
+		if len(added)+len(removed)+len(changed) > 0 {
+			out = append(out, fire(hooks, Payload{
+				Event: DiffDetected, Region: r.region, Scope: r.scope,
+				Added: added, Removed: removed, Changed: changed,
+			})...)
+		}
+	}
+
+	if hooks := subscribed(r.hooks, AuditFindingNew); len(hooks) > 0 {
+		findings, _ := audit.Run(r.region)
+		out = append(out, fireNewFindings(hooks, r.region, findings)...)
+	}
+	return out
+}
+
+// AuditCompleted fires audit.finding.new for findings of an audit run
+// outside a sync, such as 'saws audit'.
+func AuditCompleted(region string, findings []audit.Finding) []Delivery {
+	hooks, err := List()
+	if err != nil {
+		return []Delivery{{Err: err}}
+	}
+	return fireNewFindings(subscribed(hooks, AuditFindingNew), region, findings)
+}
+
+// fireNewFindings sends the findings not seen at the region's previous
+// check. The first check only records a baseline.
+func fireNewFindings(hooks []Hook, region string, findings []audit.Finding) []Delivery {
+	if len(hooks) == 0 {
+		return nil
+	}
+	seen := map[string][]string{}
+	if raw, err := sync.ReadCache(seenFindingsKey); err == nil && raw != nil {
+		json.Unmarshal(raw, &seen)
+	}
+	prev, hadBaseline := seen[region]
+	known := map[string]bool{}
+	for _, k := range prev {
+		known[k] = true
+	}
+
+	var fresh []audit.Finding
+	var keys []string
+	for _, f := range findings {
+		// The message is left out: it often carries a count of days
+		// that changes between runs.
+		k := f.Rule + "|" + f.Region + "|" + f.Resource
+		keys = append(keys, k)
+		if !known[k] {
+			fresh = append(fresh, f)
+		}
+	}
+	if keys == nil {
+		keys = []string{} // an empty baseline still counts as one
+	}
+	seen[region] = keys
+	b, _ := json.Marshal(seen)
+	sync.WriteCache(seenFindingsKey, b)
+
+	if !hadBaseline || len(fresh) == 0 {
+		return nil
+	}
+	return fire(hooks, Payload{Event: AuditFindingNew, Region: region, Findings: fresh})
+}
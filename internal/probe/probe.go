@@ -0,0 +1,157 @@
+// Package probe checks TLS and HTTP on external endpoints. It is the only
+// part of saws that talks to something other than the AWS CLI, so it only
+// runs when asked for.
+package probe
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timeout bounds each connection attempt.
+var Timeout = 5 * time.Second
+
+// transport is shared by every probe. Probes go to different hosts, so
+// keep-alives would only leave idle connections behind.
+var transport = &http.Transport{
+	Proxy:             http.ProxyFromEnvironment,
+	DisableKeepAlives: true,
+	// Certificate problems are reported from the handshake in Probe.
+	TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+}
+
+// Result is what one probe found. TLSVersion is empty when nothing
+// answered TLS on 443; CertError is set when the certificate would be
+// rejected by a browser (expired, wrong host, untrusted).
+type Result struct {
+	TLSVersion  string    `json:"tlsVersion"`
+	CertSubject string    `json:"certSubject"`
+	CertExpires time.Time `json:"certExpires"`
+	CertError   string    `json:"certError"`
+	HTTPStatus  int       `json:"httpStatus"`
+	Error       string    `json:"error"`
+}
+
+// Target is a host to probe and the URL to request; an empty URL probes
+// the host root.
+type Target struct {
+	Host string
+	URL  string
+}
+
+// Probe handshakes TLS on host:443 and then requests url (or the host
+// root over https, falling back to http when there is no TLS).
+func Probe(t Target) Result {
+	var r Result
+	dialer := &net.Dialer{Timeout: Timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(t.Host, "443"),
+		&tls.Config{ServerName: serverName(t.Host), InsecureSkipVerify: true})
+	if err == nil {
+		state := conn.ConnectionState()
+		conn.Close()
+		r.TLSVersion = tls.VersionName(state.Version)
+		if len(state.PeerCertificates) > 0 {
+			leaf := state.PeerCertificates[0]
+			r.CertSubject = leaf.Subject.CommonName
+			r.CertExpires = leaf.NotAfter
+			r.CertError = verify(t.Host, state.PeerCertificates)
+		}
+	}
+
+	url := t.URL
+	if url == "" {
+		scheme := "https://"
+		if r.TLSVersion == "" {
+			scheme = "http://"
+		}
+		url = scheme + hostForURL(t.Host) + "/"
+	}
+	client := &http.Client{
+		Timeout: Timeout,
+		// Report the first response, not wherever a redirect leads.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		Transport:     transport,
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		if r.TLSVersion == "" {
+			r.Error = shortError(err)
+		}
+		return r
+	}
+	resp.Body.Close()
+	r.HTTPStatus = resp.StatusCode
+	return r
+}
+
+// All probes targets concurrently and returns results in the same order.
+func All(targets []Target, workers int) []Result {
+	results := make([]Result, len(targets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = Probe(targets[i])
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func verify(host string, chain []*x509.Certificate) string {
+	opts := x509.VerifyOptions{DNSName: host, Intermediates: x509.NewCertPool()}
+	for _, c := range chain[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	_, err := chain[0].Verify(opts)
+	switch e := err.(type) {
+	case nil:
+		return ""
+	case x509.CertificateInvalidError:
+		if e.Reason == x509.Expired {
+			return "expired or not yet valid"
+		}
+	case x509.HostnameError:
+		return "hostname mismatch"
+	case x509.UnknownAuthorityError:
+		return "untrusted issuer"
+	}
+	return err.Error()
+}
+
+// serverName leaves SNI empty for IP addresses, as browsers do.
+func serverName(host string) string {
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	return host
+}
+
+func hostForURL(host string) string {
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// shortError drops Go's "Get \"url\": dial tcp ...:" prefixes.
+func shortError(err error) string {
+	msg := err.Error()
+	if i := strings.LastIndex(msg, ": "); i >= 0 {
+		msg = msg[i+2:]
+	}
+	return msg
+}
@@ -0,0 +1,181 @@
+// Package azure is an experimental, read-only Azure provider. It syncs
+// virtual networks, virtual machines, storage accounts and SQL servers
+// through the az CLI. Enable it with 'saws config set providers azure';
+// 'saws config set azure_subscription <id>' picks a subscription other
+// than az's default.
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+func init() {
+	sync.RegisterProvider(provider{})
+	sync.RegisterSetting("azure_subscription", func(v string) error {
+		if v != "" && !subscriptionPattern.MatchString(v) {
+			return fmt.Errorf("expected a subscription ID (GUID), got %q", v)
+		}
+		return nil
+	})
+}
+
+var subscriptionPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type provider struct{}
+
+func (provider) Name() string  { return "azure" }
+func (provider) Title() string { return "Azure" }
+
+func (provider) Available() error {
+	if _, err := az("account", "show"); err != nil {
+		return fmt.Errorf("az CLI not available or not signed in ('az login'): %w", err)
+	}
+	return nil
+}
+
+func (provider) Tab(itemType string) string {
+	switch itemType {
+	case "azure-vnet":
+		return "net"
+	case "azure-vm":
+		return "compute"
+	case "azure-sql":
+		return "database"
+	case "azure-storage":
+		return "s3"
+	}
+	return ""
+}
+
+func az(args ...string) (json.RawMessage, error) {
+	args = append(args, "--output", "json")
+	if v, _ := sync.GetSetting("azure_subscription"); v != "" {
+		args = append(args, "--subscription", v)
+	}
+	return sync.RunCLI("az", args...)
+}
+
+// services maps cache keys to the az list commands that fill them.
+var services = []struct {
+	service string
+	args    []string
+}{
+	{"vnets", []string{"network", "vnet", "list"}},
+	{"vms", []string{"vm", "list", "--show-details"}},
+	{"storage", []string{"storage", "account", "list"}},
+	{"sql", []string{"sql", "server", "list"}},
+}
+
+func (p provider) Sync(st *sync.Stage, step func(string)) ([]sync.SyncResult, error) {
+	if err := p.Available(); err != nil {
+		return []sync.SyncResult{{Service: "azure", Error: err.Error()}}, nil
+	}
+	var results []sync.SyncResult
+	for _, s := range services {
+		data, err := az(s.args...)
+		if err != nil {
+			results = append(results, sync.SyncResult{Service: "azure-" + s.service, Error: err.Error()})
+		} else {
+			var list []json.RawMessage
+			json.Unmarshal(data, &list)
+			st.WriteCache("azure:"+s.service, data)
+			results = append(results, sync.SyncResult{Service: "azure-" + s.service, Count: len(list)})
+		}
+		step("azure " + s.service)
+	}
+	return results, nil
+}
+
+// resource is the part every az list result shares.
+type resource struct {
+	Id            string            `json:"id"`
+	Name          string            `json:"name"`
+	Location      string            `json:"location"`
+	ResourceGroup string            `json:"resourceGroup"`
+	Tags          map[string]string `json:"tags"`
+}
+
+func (r resource) item(typ string, details map[string]string) sync.InventoryItem {
+	details["Resource group"] = r.ResourceGroup
+	return sync.InventoryItem{Type: typ, ID: r.Name, Name: r.Name, Region: r.Location, Arn: r.Id, Tags: r.Tags, Details: details}
+}
+
+func (provider) Inventory() ([]sync.InventoryItem, error) {
+	var items []sync.InventoryItem
+
+	if raw, err := sync.ReadCache("azure:vnets"); err == nil && raw != nil {
+		var vnets []struct {
+			resource
+			AddressSpace struct {
+				AddressPrefixes []string `json:"addressPrefixes"`
+			} `json:"addressSpace"`
+			Subnets []json.RawMessage `json:"subnets"`
+		}
+		json.Unmarshal(raw, &vnets)
+		for _, v := range vnets {
+			items = append(items, v.item("azure-vnet", map[string]string{
+				"Address space": strings.Join(v.AddressSpace.AddressPrefixes, ", "), "Subnets": fmt.Sprint(len(v.Subnets))}))
+		}
+	}
+
+	if raw, err := sync.ReadCache("azure:vms"); err == nil && raw != nil {
+		var vms []struct {
+			resource
+			PowerState      string `json:"powerState"`
+			PrivateIps      string `json:"privateIps"`
+			PublicIps       string `json:"publicIps"`
+			HardwareProfile struct {
+				VmSize string `json:"vmSize"`
+			} `json:"hardwareProfile"`
+		}
+		json.Unmarshal(raw, &vms)
+		for _, vm := range vms {
+			it := vm.item("azure-vm", map[string]string{"Size": vm.HardwareProfile.VmSize, "Power state": vm.PowerState, "Private IP": vm.PrivateIps})
+			if vm.PublicIps != "" {
+				it.Details["Public IP"] = vm.PublicIps
+			}
+			items = append(items, it)
+		}
+	}
+
+	if raw, err := sync.ReadCache("azure:storage"); err == nil && raw != nil {
+		var accounts []struct {
+			resource
+			Kind string `json:"kind"`
+			Sku  struct {
+				Name string `json:"name"`
+			} `json:"sku"`
+			AllowBlobPublicAccess *bool  `json:"allowBlobPublicAccess"`
+			PublicNetworkAccess   string `json:"publicNetworkAccess"`
+		}
+		json.Unmarshal(raw, &accounts)
+		for _, a := range accounts {
+			public := "unknown"
+			if a.AllowBlobPublicAccess != nil {
+				public = fmt.Sprint(*a.AllowBlobPublicAccess)
+			}
+			items = append(items, a.item("azure-storage", map[string]string{"Kind": a.Kind, "SKU": a.Sku.Name,
+				"Blob public access": public, "Public network access": a.PublicNetworkAccess}))
+		}
+	}
+
+	if raw, err := sync.ReadCache("azure:sql"); err == nil && raw != nil {
+		var servers []struct {
+			resource
+			FullyQualifiedDomainName string `json:"fullyQualifiedDomainName"`
+			Version                  string `json:"version"`
+			PublicNetworkAccess      string `json:"publicNetworkAccess"`
+		}
+		json.Unmarshal(raw, &servers)
+		for _, s := range servers {
+			items = append(items, s.item("azure-sql", map[string]string{"Endpoint": s.FullyQualifiedDomainName,
+				"Version": s.Version, "Public network access": s.PublicNetworkAccess}))
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,225 @@
+// Package gcp is an experimental, read-only Google Cloud provider. It
+// syncs VPC networks, Compute Engine instances, Cloud Storage buckets and
+// Cloud SQL instances through the gcloud CLI. Enable it with
+// 'saws config set providers gcp'; 'saws config set gcp_project <id>'
+// picks a project other than gcloud's default.
+package gcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+func init() {
+	sync.RegisterProvider(provider{})
+	sync.RegisterSetting("gcp_project", func(v string) error {
+		if v != "" && !projectPattern.MatchString(v) {
+			return fmt.Errorf("expected a GCP project ID, got %q", v)
+		}
+		return nil
+	})
+}
+
+var projectPattern = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+
+type provider struct{}
+
+func (provider) Name() string  { return "gcp" }
+func (provider) Title() string { return "Google Cloud" }
+
+func (provider) Available() error {
+	out, err := sync.RunCLI("gcloud", "config", "list", "--format=json")
+	if err != nil {
+		return fmt.Errorf("gcloud CLI not available: %w", err)
+	}
+	var cfg struct {
+		Core struct {
+			Account string `json:"account"`
+			Project string `json:"project"`
+		} `json:"core"`
+	}
+	json.Unmarshal(out, &cfg)
+	if cfg.Core.Account == "" {
+		return fmt.Errorf("gcloud is not signed in; run 'gcloud auth login'")
+	}
+	if cfg.Core.Project == "" && project() == "" {
+		return fmt.Errorf("no project; set gcp_project or run 'gcloud config set project <id>'")
+	}
+	return nil
+}
+
+func (provider) Tab(itemType string) string {
+	switch itemType {
+	case "gcp-network":
+		return "net"
+	case "gcp-vm":
+		return "compute"
+	case "gcp-sql":
+		return "database"
+	case "gcp-bucket":
+		return "s3"
+	}
+	return ""
+}
+
+// project returns the gcp_project setting, "" for gcloud's default.
+func project() string {
+	v, _ := sync.GetSetting("gcp_project")
+	return v
+}
+
+func gcloud(args ...string) (json.RawMessage, error) {
+	args = append(args, "--format=json")
+	if p := project(); p != "" {
+		args = append(args, "--project", p)
+	}
+	return sync.RunCLI("gcloud", args...)
+}
+
+// services maps cache keys to the gcloud list commands that fill them.
+var services = []struct {
+	service string
+	args    []string
+}{
+	{"networks", []string{"compute", "networks", "list"}},
+	{"instances", []string{"compute", "instances", "list"}},
+	{"buckets", []string{"storage", "buckets", "list"}},
+	{"sql", []string{"sql", "instances", "list"}},
+}
+
+func (p provider) Sync(st *sync.Stage, step func(string)) ([]sync.SyncResult, error) {
+	if err := p.Available(); err != nil {
+		return []sync.SyncResult{{Service: "gcp", Error: err.Error()}}, nil
+	}
+	var results []sync.SyncResult
+	for _, s := range services {
+		data, err := gcloud(s.args...)
+		if err != nil {
+			results = append(results, sync.SyncResult{Service: "gcp-" + s.service, Error: err.Error()})
+		} else {
+			var list []json.RawMessage
+			json.Unmarshal(data, &list)
+			st.WriteCache("gcp:"+s.service, data)
+			results = append(results, sync.SyncResult{Service: "gcp-" + s.service, Count: len(list)})
+		}
+		step("gcp " + s.service)
+	}
+	return results, nil
+}
+
+// last returns the final path segment of a GCP resource URL, e.g. the zone
+// name of ".../zones/europe-west1-b".
+func last(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
+
+// zoneRegion turns a zone such as europe-west1-b into its region.
+func zoneRegion(zone string) string {
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		return zone[:i]
+	}
+	return zone
+}
+
+func (provider) Inventory() ([]sync.InventoryItem, error) {
+	var items []sync.InventoryItem
+
+	if raw, err := sync.ReadCache("gcp:networks"); err == nil && raw != nil {
+		var networks []struct {
+			Id          string   `json:"id"`
+			Name        string   `json:"name"`
+			SelfLink    string   `json:"selfLink"`
+			Subnetworks []string `json:"subnetworks"`
+			AutoCreate  bool     `json:"autoCreateSubnetworks"`
+		}
+		json.Unmarshal(raw, &networks)
+		for _, n := range networks {
+			items = append(items, sync.InventoryItem{Type: "gcp-network", ID: n.Name, Name: n.Name, Region: "global", Arn: n.SelfLink,
+				Details: map[string]string{"Subnets": fmt.Sprint(len(n.Subnetworks)), "Auto mode": fmt.Sprint(n.AutoCreate)}})
+		}
+	}
+
+	if raw, err := sync.ReadCache("gcp:instances"); err == nil && raw != nil {
+		var instances []struct {
+			Id                string            `json:"id"`
+			Name              string            `json:"name"`
+			Zone              string            `json:"zone"`
+			Status            string            `json:"status"`
+			MachineType       string            `json:"machineType"`
+			SelfLink          string            `json:"selfLink"`
+			Labels            map[string]string `json:"labels"`
+			NetworkInterfaces []struct {
+				Network       string `json:"network"`
+				NetworkIP     string `json:"networkIP"`
+				AccessConfigs []struct {
+					NatIP string `json:"natIP"`
+				} `json:"accessConfigs"`
+			} `json:"networkInterfaces"`
+		}
+		json.Unmarshal(raw, &instances)
+		for _, vm := range instances {
+			it := sync.InventoryItem{Type: "gcp-vm", ID: vm.Name, Name: vm.Name, Region: zoneRegion(last(vm.Zone)), Arn: vm.SelfLink, Tags: vm.Labels,
+				Details: map[string]string{"Zone": last(vm.Zone), "Machine type": last(vm.MachineType), "Status": vm.Status}}
+			for _, ni := range vm.NetworkInterfaces {
+				it.Refs = append(it.Refs, "gcp-network/"+last(ni.Network))
+				it.Details["Private IP"] = ni.NetworkIP
+				for _, ac := range ni.AccessConfigs {
+					if ac.NatIP != "" {
+						it.Details["Public IP"] = ac.NatIP
+					}
+				}
+			}
+			items = append(items, it)
+		}
+	}
+
+	if raw, err := sync.ReadCache("gcp:buckets"); err == nil && raw != nil {
+		var buckets []struct {
+			Name         string            `json:"name"`
+			Location     string            `json:"location"`
+			StorageClass string            `json:"default_storage_class"`
+			Labels       map[string]string `json:"labels"`
+			UniformIAM   bool              `json:"uniform_bucket_level_access"`
+			PublicAccess string            `json:"public_access_prevention"`
+		}
+		json.Unmarshal(raw, &buckets)
+		for _, b := range buckets {
+			items = append(items, sync.InventoryItem{Type: "gcp-bucket", ID: b.Name, Name: b.Name, Region: strings.ToLower(b.Location),
+				Arn: "gs://" + b.Name, Tags: b.Labels,
+				Details: map[string]string{"Storage class": b.StorageClass, "Uniform access": fmt.Sprint(b.UniformIAM), "Public access prevention": b.PublicAccess}})
+		}
+	}
+
+	if raw, err := sync.ReadCache("gcp:sql"); err == nil && raw != nil {
+		var instances []struct {
+			Name            string `json:"name"`
+			Region          string `json:"region"`
+			DatabaseVersion string `json:"databaseVersion"`
+			State           string `json:"state"`
+			SelfLink        string `json:"selfLink"`
+			Settings        struct {
+				Tier       string            `json:"tier"`
+				UserLabels map[string]string `json:"userLabels"`
+				IpConfig   struct {
+					Ipv4Enabled    bool   `json:"ipv4Enabled"`
+					PrivateNetwork string `json:"privateNetwork"`
+				} `json:"ipConfiguration"`
+			} `json:"settings"`
+		}
+		json.Unmarshal(raw, &instances)
+		for _, db := range instances {
+			it := sync.InventoryItem{Type: "gcp-sql", ID: db.Name, Name: db.Name, Region: db.Region, Arn: db.SelfLink, Tags: db.Settings.UserLabels,
+				Details: map[string]string{"Engine": db.DatabaseVersion, "Tier": db.Settings.Tier, "State": db.State,
+					"Public IP": fmt.Sprint(db.Settings.IpConfig.Ipv4Enabled)}}
+			if n := db.Settings.IpConfig.PrivateNetwork; n != "" {
+				it.Refs = append(it.Refs, "gcp-network/"+last(n))
+			}
+			items = append(items, it)
+		}
+	}
+	return items, nil
+}
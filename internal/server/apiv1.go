@@ -0,0 +1,372 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+	sawsSync "github.com/estrados/simply-aws/internal/sync"
+)
+
+// The /api/v1 endpoints are the stable JSON API for other tools. Every
+// response is an envelope: {"data": ..., "meta": ...} on success, where
+// lists carry their paging in meta, and {"error": {"status", "code",
+// "message"}} on failure. Lists page with ?limit= and ?offset=.
+
+const (
+	v1DefaultLimit = 100
+	v1MaxLimit     = 1000
+)
+
+// v1Page is the meta of a list: the matches in all, this page's window and
+// the URL of the next page, if any.
+type v1Page struct {
+	Total  int    `json:"total"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Next   string `json:"next,omitempty"`
+}
+
+type v1Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// v1Routes adds the /api/v1 endpoints to mux.
+func v1Routes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/", func(w http.ResponseWriter, r *http.Request) {
+		writeV1Error(w, http.StatusNotFound, "not_found", "no such endpoint: "+r.URL.Path)
+	})
+	mux.HandleFunc("/api/v1/regions", handleV1Regions)
+	mux.HandleFunc("/api/v1/resources", handleV1Resources)
+	mux.HandleFunc("/api/v1/resources/", handleV1Resource)
+	mux.HandleFunc("/api/v1/search", handleV1Search)
+	mux.HandleFunc("/api/v1/sync", handleV1Sync)
+}
+
+func writeV1(w http.ResponseWriter, status int, data, meta interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // keep the & in next page URLs readable
+	enc.Encode(struct {
+		Data interface{} `json:"data"`
+		Meta interface{} `json:"meta,omitempty"`
+	}{data, meta})
+}
+
+func writeV1Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error v1Error `json:"error"`
+	}{v1Error{status, code, message}})
+}
+
+// v1Allow answers 405 unless r uses one of methods.
+func v1Allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	if slices.Contains(methods, r.Method) {
+		return true
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeV1Error(w, http.StatusMethodNotAllowed, "method_not_allowed", "use "+strings.Join(methods, " or "))
+	return false
+}
+
+// v1Paginate returns the page of items ?limit= and ?offset= ask for, or
+// false after answering 400 for a bad value.
+func v1Paginate[T any](w http.ResponseWriter, r *http.Request, items []T, defaultLimit int) ([]T, *v1Page, bool) {
+	q := r.URL.Query()
+	page := &v1Page{Total: len(items), Limit: defaultLimit}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > v1MaxLimit {
+			writeV1Error(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("limit must be a number from 1 to %d", v1MaxLimit))
+			return nil, nil, false
+		}
+		page.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeV1Error(w, http.StatusBadRequest, "invalid_parameter", "offset must be a number from 0")
+			return nil, nil, false
+		}
+		page.Offset = n
+	}
+	start := min(page.Offset, len(items))
+	end := min(start+page.Limit, len(items))
+	if end < len(items) {
+		q.Set("offset", strconv.Itoa(end))
+		q.Set("limit", strconv.Itoa(page.Limit))
+		page.Next = r.URL.Path + "?" + q.Encode()
+	}
+	out := items[start:end]
+	if out == nil {
+		out = []T{}
+	}
+	return out, page, true
+}
+
+// v1List splits a comma-separated query parameter.
+func v1List(r *http.Request, name string) []string {
+	var out []string
+	for _, v := range strings.Split(r.URL.Query().Get(name), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+type v1Region struct {
+	Name     string     `json:"name"`
+	Display  string     `json:"displayName"`
+	Enabled  bool       `json:"enabled"`
+	Cached   bool       `json:"cached"`
+	SyncedAt *time.Time `json:"syncedAt"` // time of the newest cached data, or null
+}
+
+// handleV1Regions lists the known regions, whether each is enabled, and
+// whether and when it was last synced.
+func handleV1Regions(w http.ResponseWriter, r *http.Request) {
+	if !v1Allow(w, r, http.MethodGet) {
+		return
+	}
+	ensureRegionsSeeded()
+	known, err := sawsSync.GetRegions()
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	cached, _ := sawsSync.CachedRegions()
+	regions := map[string]*v1Region{}
+	var names []string
+	add := func(name string) *v1Region {
+		if regions[name] == nil {
+			regions[name] = &v1Region{Name: name, Display: awscli.RegionDisplayName(name)}
+			names = append(names, name)
+		}
+		return regions[name]
+	}
+	for _, k := range known {
+		add(k.Name).Enabled = k.Enabled
+	}
+	for _, name := range cached {
+		reg := add(name)
+		reg.Cached = true
+		var keys []string
+		for _, tab := range []string{"net", "compute", "database", "s3", "iam", "streaming", "ai", "cicd"} {
+			keys = append(keys, sawsSync.TabCacheKeys(tab, name)...)
+		}
+		reg.SyncedAt = sawsSync.CacheSyncedAt(keys...)
+	}
+	slices.Sort(names)
+	out := make([]*v1Region, len(names))
+	for i, name := range names {
+		out[i] = regions[name]
+	}
+	writeV1(w, http.StatusOK, out, nil)
+}
+
+// handleV1Resources lists cached resources, across every synced region
+// unless ?region= is set. Filters: ?service= (inventory types, comma
+// separated: ec2,lambda,...), ?vpc=, ?q= (name, ID or ARN substring),
+// ?refs= (type/id a resource depends on) and ?tag=key or key=value.
+func handleV1Resources(w http.ResponseWriter, r *http.Request) {
+	if !v1Allow(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	resources, err := sawsSync.QueryResources(sawsSync.ResourceFilter{
+		Region: q.Get("region"), Types: v1List(r, "service"), VpcId: q.Get("vpc"),
+		Search: q.Get("q"), RefersTo: q.Get("refs"),
+	})
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	if tag := q.Get("tag"); tag != "" {
+		key, value, hasValue := strings.Cut(tag, "=")
+		var tagged []sawsSync.Resource
+		for _, res := range resources {
+			if v, ok := res.Tags[key]; ok && (!hasValue || v == value) {
+				tagged = append(tagged, res)
+			}
+		}
+		resources = tagged
+	}
+	page, meta, ok := v1Paginate(w, r, resources, v1DefaultLimit)
+	if ok {
+		writeV1(w, http.StatusOK, page, meta)
+	}
+}
+
+// v1Resource is a resource with the resources that depend on it.
+type v1Resource struct {
+	sawsSync.Resource
+	ReferencedBy []string `json:"referencedBy"`
+}
+
+// handleV1Resource returns one resource by ARN, /api/v1/resources/{arn},
+// or for resources without one by type and ID,
+// /api/v1/resources/{type}/{id}?region=.
+func handleV1Resource(w http.ResponseWriter, r *http.Request) {
+	if !v1Allow(w, r, http.MethodGet) {
+		return
+	}
+	ref := strings.TrimPrefix(r.URL.Path, "/api/v1/resources/")
+	f := sawsSync.ResourceFilter{Arn: ref}
+	var id string
+	if !strings.HasPrefix(ref, "arn:") {
+		typ, rest, ok := strings.Cut(ref, "/")
+		if !ok || typ == "" || rest == "" {
+			writeV1Error(w, http.StatusBadRequest, "invalid_parameter", "expected /api/v1/resources/{arn} or /api/v1/resources/{type}/{id}")
+			return
+		}
+		f = sawsSync.ResourceFilter{Region: r.URL.Query().Get("region"), Types: []string{typ}}
+		if f.Region == "" {
+			f.Region = defaultRegion()
+		}
+		id = rest
+	}
+	resources, err := sawsSync.QueryResources(f)
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	var found *sawsSync.Resource
+	for i := range resources {
+		if id == "" || resources[i].ID == id {
+			found = &resources[i]
+			break
+		}
+	}
+	if found == nil {
+		writeV1Error(w, http.StatusNotFound, "not_found", "no cached resource "+ref)
+		return
+	}
+
+	detail := v1Resource{Resource: *found, ReferencedBy: []string{}}
+	region := found.Region
+	if region == "global" {
+		region = ""
+	}
+	users, _ := sawsSync.QueryResources(sawsSync.ResourceFilter{Region: region, RefersTo: found.Key()})
+	for _, u := range users {
+		detail.ReferencedBy = append(detail.ReferencedBy, u.Key())
+	}
+	writeV1(w, http.StatusOK, detail, nil)
+}
+
+// handleV1Search finds resources by name, ID, ARN or IP address across
+// every synced region, best matches first: ?q=, paged like the lists.
+func handleV1Search(w http.ResponseWriter, r *http.Request) {
+	if !v1Allow(w, r, http.MethodGet) {
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeV1Error(w, http.StatusBadRequest, "invalid_parameter", "q is required")
+		return
+	}
+	hits, err := sawsSync.SearchResources(q, 0)
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	page, meta, ok := v1Paginate(w, r, hits, 20)
+	if ok {
+		writeV1(w, http.StatusOK, page, meta)
+	}
+}
+
+// handleV1Sync reports the running or last sync job on GET. POST starts a
+// sync of ?region= (default: the CLI's region) in the background, of every
+// service group or only those in ?services= (network, compute, iam, ...);
+// the parameters may also be sent as a JSON object. It answers 202 with
+// the job, which GET then follows, or 409 while another sync runs.
+func handleV1Sync(w http.ResponseWriter, r *http.Request) {
+	if !v1Allow(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+	if r.Method == http.MethodGet {
+		if job := sawsSync.GetSyncProgress(); job != nil {
+			writeV1(w, http.StatusOK, job, nil)
+		} else {
+			writeV1(w, http.StatusOK, map[string]string{"status": "idle"}, nil)
+		}
+		return
+	}
+
+	var req struct {
+		Region   string   `json:"region"`
+		Services []string `json:"services"`
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeV1Error(w, http.StatusBadRequest, "invalid_body", err.Error())
+			return
+		}
+	} else {
+		r.ParseForm()
+		req.Region = r.FormValue("region")
+		for _, s := range strings.Split(r.FormValue("services"), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				req.Services = append(req.Services, s)
+			}
+		}
+	}
+	if req.Region == "" {
+		req.Region = defaultRegion()
+	}
+	if _, ok := awscli.RegionNames[req.Region]; !ok {
+		writeV1Error(w, http.StatusBadRequest, "invalid_parameter", "unknown region "+req.Region)
+		return
+	}
+	groups := sawsSync.SelectedSyncGroups()
+	scope := "all"
+	if len(req.Services) > 0 {
+		groups = nil
+		for _, s := range req.Services {
+			if !slices.Contains(sawsSync.SyncGroupNames(), s) {
+				writeV1Error(w, http.StatusBadRequest, "invalid_parameter",
+					fmt.Sprintf("unknown service %q: expected %s", s, strings.Join(sawsSync.SyncGroupNames(), ", ")))
+				return
+			}
+		}
+		for _, g := range sawsSync.SyncGroups {
+			if slices.Contains(req.Services, g.Name) {
+				groups = append(groups, g)
+			}
+		}
+		scope = strings.Join(req.Services, ",")
+	}
+
+	if status, code, msg := syncRefusal(); status != 0 {
+		writeV1Error(w, status, code, msg)
+		return
+	}
+	started, err := startGroupSync(scope, req.Region, groups)
+	if err != nil {
+		writeV1Error(w, http.StatusInternalServerError, "internal", err.Error())
+		return
+	}
+	if !started {
+		writeV1Error(w, http.StatusConflict, "sync_running", "a sync is running — try again when it finishes")
+		return
+	}
+	w.Header().Set("Location", "/api/v1/sync")
+	writeV1(w, http.StatusAccepted, sawsSync.GetSyncProgress(), nil)
+}
+
+// v1Unauthorized answers a request the sign-in check turned away.
+func v1Unauthorized(w http.ResponseWriter) {
+	writeV1Error(w, http.StatusUnauthorized, "unauthorized", "sign in with basic auth or a bearer token")
+}
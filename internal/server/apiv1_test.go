@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+	sawsSync "github.com/estrados/simply-aws/internal/sync"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "saws-server-test")
+	if err != nil {
+		panic(err)
+	}
+	restore := awscli.Use(awscli.NewFake())
+	sawsSync.UseAccount("111111111111")
+	sawsSync.SetDataDir(dir)
+	if err := sawsSync.InitDB(); err != nil {
+		panic(err)
+	}
+	sawsSync.WriteCache("eu-west-1:rds", []byte(`{"DBInstances": [
+		{"DBInstanceIdentifier": "orders", "Engine": "postgres", "TagList": [{"Key": "env", "Value": "prod"}]},
+		{"DBInstanceIdentifier": "billing", "Engine": "mysql"},
+		{"DBInstanceIdentifier": "audit", "Engine": "mysql"}]}`))
+	sawsSync.WriteCache("eu-west-1:ecs-enriched", []byte(`[{"ClusterName": "web", "ClusterArn": "arn:aws:ecs:eu-west-1:111111111111:cluster/web"}]`))
+	if err := sawsSync.IndexResources("eu-west-1"); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+
+	restore()
+	sawsSync.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// v1Response is the union of the success and error envelopes.
+type v1Response struct {
+	Data  json.RawMessage `json:"data"`
+	Meta  *v1Page         `json:"meta"`
+	Error *v1Error        `json:"error"`
+}
+
+func getV1(t *testing.T, method, path string) (int, v1Response) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	routes().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s %s: Content-Type = %q", method, path, ct)
+	}
+	var resp v1Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("%s %s: %v: %s", method, path, err, rec.Body)
+	}
+	return rec.Code, resp
+}
+
+func TestV1Resources(t *testing.T) {
+	code, resp := getV1(t, http.MethodGet, "/api/v1/resources?service=rds&region=eu-west-1&limit=2")
+	var page []sawsSync.Resource
+	json.Unmarshal(resp.Data, &page)
+	if code != http.StatusOK || len(page) != 2 || resp.Meta == nil || resp.Meta.Total != 3 {
+		t.Fatalf("first page = %d %d items, meta %+v", code, len(page), resp.Meta)
+	}
+	if resp.Meta.Next != "/api/v1/resources?limit=2&offset=2&region=eu-west-1&service=rds" {
+		t.Errorf("next = %q", resp.Meta.Next)
+	}
+	_, resp = getV1(t, http.MethodGet, resp.Meta.Next)
+	json.Unmarshal(resp.Data, &page)
+	if len(page) != 1 || resp.Meta.Next != "" {
+		t.Errorf("last page = %d items, next %q", len(page), resp.Meta.Next)
+	}
+
+	_, resp = getV1(t, http.MethodGet, "/api/v1/resources?tag=env=prod")
+	json.Unmarshal(resp.Data, &page)
+	if len(page) != 1 || page[0].ID != "orders" {
+		t.Errorf("tag filter = %+v", page)
+	}
+	_, resp = getV1(t, http.MethodGet, "/api/v1/resources?service=lambda")
+	if string(resp.Data) != "[]" {
+		t.Errorf("empty list = %s", resp.Data)
+	}
+}
+
+func TestV1Resource(t *testing.T) {
+	for path, id := range map[string]string{
+		"/api/v1/resources/arn:aws:ecs:eu-west-1:111111111111:cluster/web": "web",
+		"/api/v1/resources/rds/billing?region=eu-west-1":                   "billing",
+	} {
+		code, resp := getV1(t, http.MethodGet, path)
+		var res v1Resource
+		json.Unmarshal(resp.Data, &res)
+		if code != http.StatusOK || res.ID != id || res.ReferencedBy == nil {
+			t.Errorf("GET %s = %d %+v", path, code, res)
+		}
+	}
+}
+
+func TestV1Errors(t *testing.T) {
+	prev := awsStatus
+	awsStatus = awscli.Status{ReadOnly: true}
+	defer func() { awsStatus = prev }()
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/api/v1/resources?limit=0", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/api/v1/resources?offset=x", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/api/v1/resources/rds/missing?region=eu-west-1", http.StatusNotFound, "not_found"},
+		{http.MethodGet, "/api/v1/search", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/api/v1/nope", http.StatusNotFound, "not_found"},
+		{http.MethodPost, "/api/v1/regions", http.StatusMethodNotAllowed, "method_not_allowed"},
+		{http.MethodPost, "/api/v1/sync?region=mars-1", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodPost, "/api/v1/sync?services=nope", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodPost, "/api/v1/sync?region=eu-west-1", http.StatusForbidden, "read_only"},
+	} {
+		code, resp := getV1(t, tc.method, tc.path)
+		if code != tc.status || resp.Error == nil || resp.Error.Code != tc.code || resp.Error.Status != tc.status {
+			t.Errorf("%s %s = %d %+v, want %d %s", tc.method, tc.path, code, resp.Error, tc.status, tc.code)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth guards the web server when it is reachable by others, e.g. on a
+// shared jump host. With a user and password, requests need HTTP basic
+// auth; with a token, an "Authorization: Bearer" header. Browsers sign in
+// once at /login instead and get a session cookie. The zero value leaves
+// the server open.
+type Auth struct {
+	User     string
+	Password string
+	Token    string
+}
+
+// Enabled reports whether any credential is set.
+func (a Auth) Enabled() bool {
+	return a.Password != "" || a.Token != ""
+}
+
+// Method names the credentials a accepts, for the startup banner.
+func (a Auth) Method() string {
+	switch {
+	case a.Password != "" && a.Token != "":
+		return "basic auth (user " + a.User + ") or token"
+	case a.Password != "":
+		return "basic auth (user " + a.User + ")"
+	case a.Token != "":
+		return "token"
+	}
+	return "none"
+}
+
+// check reports whether the credentials match a, in constant time.
+func (a Auth) check(user, password, token string) bool {
+	if a.Password != "" && password != "" &&
+		subtle.ConstantTimeCompare([]byte(user), []byte(a.User))&
+			subtle.ConstantTimeCompare([]byte(password), []byte(a.Password)) == 1 {
+		return true
+	}
+	return a.Token != "" && token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1
+}
+
+const (
+	sessionCookie = "saws_session"
+	sessionTTL    = 12 * time.Hour
+)
+
+var (
+	auth Auth
+
+	sessionsMu sync.Mutex
+	sessions   = map[string]time.Time{} // session ID -> expiry
+)
+
+// SetAuth requires the credentials in a for every page, API and sync
+// endpoint. Call it before Start.
+func SetAuth(a Auth) {
+	auth = a
+}
+
+// authenticate wraps the routes so only signed-in requests reach them.
+// Static assets and the login page stay open. Pages redirect to /login,
+// htmx requests are told to, and everything else answers 401.
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !auth.Enabled() || strings.HasPrefix(r.URL.Path, "/static/") ||
+			r.URL.Path == "/login" || authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		login := "/login?next=" + url.QueryEscape(r.URL.RequestURI())
+		switch {
+		case r.Header.Get("HX-Request") == "true":
+			if u, err := url.Parse(r.Header.Get("HX-Current-URL")); err == nil {
+				login = "/login?next=" + url.QueryEscape(u.RequestURI())
+			}
+			w.Header().Set("HX-Redirect", login)
+			http.Error(w, "sign in to saws", http.StatusUnauthorized)
+		case r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html"):
+			http.Redirect(w, r, login, http.StatusSeeOther)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/"):
+			setChallenge(w)
+			v1Unauthorized(w)
+		default:
+			setChallenge(w)
+			http.Error(w, "sign in to saws", http.StatusUnauthorized)
+		}
+	})
+}
+
+// setChallenge names the scheme a client should answer a 401 with.
+func setChallenge(w http.ResponseWriter) {
+	if auth.Password != "" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="saws"`)
+	} else {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="saws"`)
+	}
+}
+
+// authorized reports whether r carries a session cookie, basic auth or a
+// bearer token.
+func authorized(r *http.Request) bool {
+	if c, err := r.Cookie(sessionCookie); err == nil && validSession(c.Value) {
+		return true
+	}
+	if user, password, ok := r.BasicAuth(); ok {
+		return auth.check(user, password, "")
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return auth.check("", "", token)
+	}
+	return false
+}
+
+func newSession() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	now := time.Now()
+	for s, expiry := range sessions {
+		if now.After(expiry) {
+			delete(sessions, s)
+		}
+	}
+	sessions[id] = now.Add(sessionTTL)
+	return id
+}
+
+func validSession(id string) bool {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	expiry, ok := sessions[id]
+	return ok && time.Now().Before(expiry)
+}
+
+func endSession(id string) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	delete(sessions, id)
+}
+
+// localPath returns next if it is a path on this server, else "/", so a
+// login link can't send the browser elsewhere.
+func localPath(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, `/\`) {
+		return "/"
+	}
+	return next
+}
+
+type loginData struct {
+	Auth  Auth
+	Next  string
+	User  string
+	Error string
+}
+
+// handleLogin shows the sign-in form and, on POST, starts a session for a
+// matching user and password or token.
+func handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !auth.Enabled() {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	data := loginData{Auth: auth, Next: localPath(r.FormValue("next"))}
+	if r.Method == http.MethodPost {
+		data.User = r.FormValue("user")
+		if auth.check(data.User, r.FormValue("password"), r.FormValue("token")) {
+			http.SetCookie(w, &http.Cookie{
+				Name: sessionCookie, Value: newSession(), Path: "/",
+				MaxAge: int(sessionTTL.Seconds()), HttpOnly: true, Secure: r.TLS != nil, SameSite: http.SameSiteLaxMode,
+			})
+			http.Redirect(w, r, data.Next, http.StatusSeeOther)
+			return
+		}
+		time.Sleep(500 * time.Millisecond) // slow down guessing
+		data.Error = "Wrong credentials"
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	tmpl.ExecuteTemplate(w, "login", data)
+}
+
+// handleLogout ends the browser's session.
+func handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		endSession(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1, HttpOnly: true})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
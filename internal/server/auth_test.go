@@ -0,0 +1,105 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAuth(t *testing.T) {
+	SetAuth(Auth{User: "saws", Password: "hunter2", Token: "tok"})
+	defer SetAuth(Auth{})
+	prev := tmpl
+	tmpl = template.Must(template.New("").Parse(`{{define "login"}}{{.Error}}{{end}}`))
+	defer func() { tmpl = prev }()
+
+	h := authenticate(routes())
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			r.Header.Set(header[i], header[i+1])
+		}
+		return serve(r)
+	}
+
+	if rec := get("/sync/progress"); rec.Code != http.StatusUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("anonymous API = %d %v", rec.Code, rec.Header())
+	}
+	if rec := get("/us-east-1/net", "Accept", "text/html"); rec.Code != http.StatusSeeOther ||
+		rec.Header().Get("Location") != "/login?next=%2Fus-east-1%2Fnet" {
+		t.Errorf("anonymous page = %d, Location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := get("/detail/vpc/vpc-1", "HX-Request", "true", "HX-Current-URL", "http://jump:3131/us-east-1/net"); rec.Header().Get("HX-Redirect") != "/login?next=%2Fus-east-1%2Fnet" {
+		t.Errorf("htmx HX-Redirect = %q", rec.Header().Get("HX-Redirect"))
+	}
+	if rec := get("/static/styles.css"); rec.Code != http.StatusOK {
+		t.Errorf("static = %d", rec.Code)
+	}
+	if rec := get("/sync/progress", "Authorization", "Bearer tok"); rec.Code != http.StatusOK {
+		t.Errorf("bearer = %d", rec.Code)
+	}
+	if rec := get("/sync/progress", "Authorization", "Bearer nope"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong bearer = %d", rec.Code)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/sync/progress", nil)
+	r.SetBasicAuth("saws", "hunter2")
+	if rec := serve(r); rec.Code != http.StatusOK {
+		t.Errorf("basic auth = %d", rec.Code)
+	}
+
+	login := func(form url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(r)
+	}
+	if rec := login(url.Values{"user": {"saws"}, "password": {"wrong"}}); rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("wrong password = %d", rec.Code)
+	}
+	rec := login(url.Values{"user": {"saws"}, "password": {"hunter2"}, "next": {"//evil.example"}})
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Fatalf("login = %d, Location %q", rec.Code, rec.Header().Get("Location"))
+	}
+	session := rec.Result().Cookies()[0]
+	withSession := func(method, path string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		r.AddCookie(session)
+		return serve(r)
+	}
+	if rec := withSession(http.MethodGet, "/sync/progress"); rec.Code != http.StatusOK {
+		t.Errorf("session = %d", rec.Code)
+	}
+	withSession(http.MethodPost, "/logout")
+	if rec := withSession(http.MethodGet, "/sync/progress"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("after sign-out = %d", rec.Code)
+	}
+}
+
+func TestAccountSwitchRedirect(t *testing.T) {
+	prev := awsStatus
+	awsStatus.AccountID = "111111111111"
+	defer func() { awsStatus = prev }()
+
+	for next, want := range map[string]string{
+		"/eu-west-1/net":    "/eu-west-1/net",
+		"//evil.example":    "/",
+		`/\evil.example`:    "/",
+		"https://evil.test": "/",
+	} {
+		form := url.Values{"id": {"111111111111"}, "next": {next}}
+		r := httptest.NewRequest(http.MethodPost, "/account", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		routes().ServeHTTP(rec, r)
+		if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != want {
+			t.Errorf("next=%q: %d, Location %q, want %q", next, rec.Code, rec.Header().Get("Location"), want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"container/list"
+	"encoding/json"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	sawsSync "github.com/estrados/simply-aws/internal/sync"
+)
+
+// DefaultServerCacheMB is used when the server_cache_mb setting is unset.
+const DefaultServerCacheMB = 64
+
+// dataCacheIdle is how long parsed data stays in memory without being
+// viewed before the reaper drops it.
+const dataCacheIdle = 10 * time.Minute
+
+// serverCacheBytes returns the server_cache_mb setting in bytes. Zero
+// turns the cache off, so every page parses the SQLite cache again.
+func serverCacheBytes() int {
+	if v, err := sawsSync.GetSetting("server_cache_mb"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n << 20
+		}
+	}
+	return DefaultServerCacheMB << 20
+}
+
+// dataCache keeps recently viewed parsed and enriched data, keyed by
+// loader and region, so switching between tabs doesn't re-parse large
+// caches. It is bounded by server_cache_mb, least recently used first,
+// and emptied of anything idle for dataCacheIdle. Entries are dropped as
+// soon as any cache row is written, deleted or imported.
+type dataCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	size    int
+	version int64 // sawsSync.CacheGeneration the entries were loaded at
+}
+
+type dataCacheEntry struct {
+	key      string
+	value    any
+	size     int
+	lastUsed time.Time
+}
+
+var parsed = &dataCache{entries: map[string]*list.Element{}, order: list.New()}
+
+// cachedLoad returns load(region) from the data cache, loading and
+// caching it on a miss. Callers must treat the value as read-only since
+// it is shared between requests.
+func cachedLoad[T any](name, region string, load func(string) (T, error)) T {
+	key := sawsSync.Account() + "/" + name + "/" + region
+	if v, ok := parsed.get(key); ok {
+		return v.(T)
+	}
+	v, err := load(region)
+	if err == nil {
+		parsed.put(key, v)
+	}
+	return v
+}
+
+// loadVPCData is LoadVPCData through the data cache. Detail panels and
+// template helpers look up the network on almost every click, so they
+// share one parse per cache generation instead of re-reading it each time.
+func loadVPCData(region string) *sawsSync.VPCData {
+	return cachedLoad("vpc", region, sawsSync.LoadVPCData)
+}
+
+func (c *dataCache) get(key string) (any, bool) {
+	version := sawsSync.CacheGeneration()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version != c.version {
+		c.clear()
+		c.version = version
+		return nil, false
+	}
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*dataCacheEntry)
+	e.lastUsed = time.Now()
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// put stores v with its size estimated from its JSON encoding, then
+// evicts from the back until the cache is under its cap. Values larger
+// than the whole cap are not kept.
+func (c *dataCache) put(key string, v any) {
+	limit := serverCacheBytes()
+	b, err := json.Marshal(v)
+	if err != nil || len(b) > limit {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	c.entries[key] = c.order.PushFront(&dataCacheEntry{key: key, value: v, size: len(b), lastUsed: time.Now()})
+	c.size += len(b)
+	for c.size > limit {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *dataCache) remove(el *list.Element) {
+	e := el.Value.(*dataCacheEntry)
+	c.order.Remove(el)
+	delete(c.entries, e.key)
+	c.size -= e.size
+}
+
+func (c *dataCache) clear() {
+	c.entries = map[string]*list.Element{}
+	c.order.Init()
+	c.size = 0
+}
+
+// reap drops entries idle since before cutoff and reports whether the
+// cache ended up empty after holding something.
+func (c *dataCache) reap(cutoff time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.order.Len() == 0 {
+		return false
+	}
+	for el := c.order.Back(); el != nil; {
+		prev := el.Prev()
+		if el.Value.(*dataCacheEntry).lastUsed.Before(cutoff) {
+			c.remove(el)
+		}
+		el = prev
+	}
+	return c.order.Len() == 0
+}
+
+// reapIdleData runs for the life of the server. Once nothing has been
+// viewed for dataCacheIdle the parsed data is released and the memory
+// handed back to the OS, so an idle server shrinks to its baseline.
+func reapIdleData() {
+	for range time.Tick(time.Minute) {
+		if parsed.reap(time.Now().Add(-dataCacheIdle)) {
+			debug.FreeOSMemory()
+		}
+	}
+}
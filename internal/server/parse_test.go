@@ -0,0 +1,42 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files under testdata")
+
+// TestParseSGPermsGolden checks the rule rows shown for a security group
+// against testdata/parse/parseSGPerms.golden; run with -update to rewrite.
+func TestParseSGPermsGolden(t *testing.T) {
+	in, err := os.ReadFile(filepath.Join("testdata", "parse", "parseSGPerms.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var perms []sgPermission
+	if err := json.Unmarshal(in, &perms); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := json.MarshalIndent(parseSGPerms(perms), "", "  ")
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "parse", "parseSGPerms.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s; got:\n%s", golden, got)
+	}
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestReadOnlyRefusesSync(t *testing.T) {
+	prev := awsStatus
+	awsStatus = awscli.Status{ReadOnly: true}
+	defer func() { awsStatus = prev }()
+
+	mux := routes()
+	for _, path := range []string{
+		"/sync/vpc", "/sync/s3", "/sync/database", "/sync/compute", "/sync/iam",
+		"/sync/streaming", "/sync/ai", "/sync/cicd", "/sync/all", "/api/sync",
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, nil))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("POST %s = %d, want 403", path, rec.Code)
+		}
+	}
+}
@@ -1,18 +1,28 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"html/template"
 	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/estrados/simply-aws/internal/audit"
 	"github.com/estrados/simply-aws/internal/awscli"
 	"github.com/estrados/simply-aws/internal/cfn"
+	"github.com/estrados/simply-aws/internal/diff"
+	"github.com/estrados/simply-aws/internal/export"
+	"github.com/estrados/simply-aws/internal/graph"
+	"github.com/estrados/simply-aws/internal/hooks"
 	"github.com/estrados/simply-aws/internal/project"
 	sawsSync "github.com/estrados/simply-aws/internal/sync"
 	"github.com/estrados/simply-aws/web"
@@ -28,27 +38,38 @@ func Start(addr string, status awscli.Status) error {
 
 	iconClassMap := map[string]string{
 		"VPC": "resource-icon-vpc", "SUBNET": "resource-icon-sub", "SG": "resource-icon-sg",
-		"IGW": "resource-icon-igw", "NAT": "resource-icon-nat", "RT": "resource-icon-rt",
-		"RDS": "resource-icon-rds", "DDB": "resource-icon-ddb", "CACHE": "resource-icon-cache",
-		"S3": "resource-icon-s3", "RS": "resource-icon-rs", "ATH": "resource-icon-ath",
+		"IGW": "resource-icon-igw", "NAT": "resource-icon-nat", "RT": "resource-icon-rt", "EIP": "resource-icon-eip",
+		"TGW": "resource-icon-tgw", "TGWRT": "resource-icon-tgw", "PCX": "resource-icon-pcx", "VPCE": "resource-icon-vpce",
+		"VPN": "resource-icon-vpn", "FLOW": "resource-icon-flow", "VGW": "resource-icon-vpn", "DX": "resource-icon-dx",
+		"EFS": "resource-icon-efs", "FSX": "resource-icon-fsx", "RDS": "resource-icon-rds", "AUR": "resource-icon-aurora", "DOC": "resource-icon-docdb", "NEP": "resource-icon-neptune", "DDB": "resource-icon-ddb", "CACHE": "resource-icon-cache",
+		"S3": "resource-icon-s3", "RS": "resource-icon-rs", "OS": "resource-icon-os", "ATH": "resource-icon-ath",
 		"GLUE": "resource-icon-glue", "SNG": "resource-icon-sng",
 		"EC2": "resource-icon-ec2", "ECS": "resource-icon-ecs", "LN": "resource-icon-lambda",
-		"ROLE": "resource-icon-role", "GRP": "resource-icon-grp",
+		"ASG": "resource-icon-asg", "LT": "resource-icon-lt", "LC": "resource-icon-lt",
+		"ROLE": "resource-icon-role", "GRP": "resource-icon-grp", "FED": "resource-icon-fed", "USR": "resource-icon-usr", "POL": "resource-icon-pol",
+		"AR": "resource-icon-ar", "LS": "resource-icon-ls", "CP": "resource-icon-cp", "CB": "resource-icon-cb", "CFN": "resource-icon-cfn",
 		"SQS": "resource-icon-sqs", "SNS": "resource-icon-sns",
 		"KIN": "resource-icon-kinesis", "EB": "resource-icon-eb",
+		"MSK": "resource-icon-msk", "MQ": "resource-icon-mq", "FH": "resource-icon-fh",
 		"ALB": "resource-icon-alb", "NLB": "resource-icon-nlb", "TG": "resource-icon-tg",
 		"EBS": "resource-icon-ebs",
 		"SM": "resource-icon-sm", "BR": "resource-icon-br",
+		"ACM": "resource-icon-acm", "WAF": "resource-icon-waf",
+		"COG": "resource-icon-cog", "IDP": "resource-icon-cog", "SEC": "resource-icon-secret", "KMS": "resource-icon-kms", "GD": "resource-icon-gd", "CFG": "resource-icon-cfg", "CT": "resource-icon-ct", "AA": "resource-icon-aa",
+		"GCP": "resource-icon-provider", "AZURE": "resource-icon-provider",
 	}
 	funcMap := template.FuncMap{
 		"not":           func(b bool) bool { return !b },
 		"regionDisplay": awscli.RegionDisplayName,
+		"regionAllowed": sawsSync.RegionAllowed,
 		"iconClass": func(t string) string {
 			if c, ok := iconClassMap[t]; ok {
 				return c
 			}
 			return ""
 		},
+		"providerItems": sawsSync.ProviderItems,
+		"providerIcon":  providerIcon,
 		"hasVPCData": func(v *sawsSync.VPCData) bool {
 			return v != nil && len(v.VPCs) > 0
 		},
@@ -56,22 +77,42 @@ func Start(addr string, status awscli.Status) error {
 			return v != nil && len(v.Buckets) > 0
 		},
 		"hasDWData": func(v *sawsSync.DataWarehouseData) bool {
-			return v != nil && (len(v.Redshift) > 0 || len(v.Athena) > 0 || len(v.Glue) > 0)
+			return v != nil && (len(v.Redshift) > 0 || len(v.RedshiftWorkgroups) > 0 || len(v.RedshiftNamespaces) > 0 || len(v.Athena) > 0 || len(v.Glue) > 0 || len(v.GlueCrawlers) > 0 || len(v.GlueJobs) > 0 || len(v.OpenSearch) > 0)
+		},
+		"hasStorageData": func(v *sawsSync.StorageData) bool {
+			return v != nil && (len(v.EFS) > 0 || len(v.FSx) > 0)
+		},
+		"fileSystemsInSubnet": func(subnetId string, region string) []string {
+			storage, _ := sawsSync.LoadStorageData(region)
+			return storage.FileSystemsInSubnet(subnetId)
 		},
 		"hasDBData": func(v *sawsSync.DatabaseData) bool {
-			return v != nil && (len(v.RDS) > 0 || len(v.DynamoDB) > 0 || len(v.ElastiCache) > 0)
+			return v != nil && (len(v.RDS) > 0 || len(v.RDSClusters) > 0 || len(v.DynamoDB) > 0 || len(v.ElastiCache) > 0)
 		},
 		"hasComputeData": func(v *sawsSync.ComputeData) bool {
-			return v != nil && (len(v.EC2) > 0 || len(v.ECS) > 0 || len(v.Lambda) > 0)
+			return v != nil && (len(v.EC2) > 0 || len(v.ECS) > 0 || len(v.Lambda) > 0 || len(v.EBS) > 0 || len(v.Snapshots) > 0 ||
+				(v.AutoScaling != nil && len(v.AutoScaling.Groups) > 0) ||
+				len(v.AppRunner) > 0 || len(v.Lightsail) > 0 || len(v.LightsailContainers) > 0)
+		},
+		"ebsTotals": func(v *sawsSync.ComputeData, region string) sawsSync.EBSTotals {
+			return sawsSync.SumEBS(region, v.EBS, v.Snapshots)
+		},
+		"ebsTotalsByRegion": func() []sawsSync.EBSTotals {
+			totals, _ := sawsSync.EBSTotalsByRegion()
+			return totals
 		},
 		"hasIAMData": func(v *sawsSync.IAMData) bool {
-			return v != nil && (len(v.Roles) > 0 || len(v.Groups) > 0)
+			return v != nil && (len(v.Roles) > 0 || len(v.Groups) > 0 || len(v.Users) > 0 || len(v.Providers) > 0)
 		},
 		"hasStreamingData": func(v *sawsSync.StreamingData) bool {
-			return v != nil && (len(v.SQS) > 0 || len(v.SNS) > 0 || len(v.Kinesis) > 0 || len(v.EventBridge) > 0)
+			return v != nil && (len(v.SQS) > 0 || len(v.SNS) > 0 || len(v.Kinesis) > 0 || len(v.EventBridge) > 0 || len(v.MSK) > 0 || len(v.MQ) > 0 || len(v.Firehose) > 0)
 		},
 		"hasAIData": func(v *sawsSync.AIData) bool {
-			return v != nil && (len(v.SageMakerNotebooks) > 0 || len(v.SageMakerEndpoints) > 0 || len(v.SageMakerModels) > 0 || len(v.BedrockModels) > 0 || len(v.BedrockCustom) > 0)
+			return v != nil && (len(v.SageMakerNotebooks) > 0 || len(v.SageMakerEndpoints) > 0 || len(v.SageMakerModels) > 0 || len(v.BedrockModels) > 0 || len(v.BedrockCustom) > 0 || len(v.TrainingJobs) > 0 || len(v.Pipelines) > 0 || len(v.Domains) > 0 ||
+				len(v.BedrockAgents) > 0 || len(v.KnowledgeBases) > 0 || len(v.Guardrails) > 0 || len(v.ProvisionedThroughput) > 0)
+		},
+		"hasCICDData": func(v *sawsSync.CICDData) bool {
+			return v != nil && (len(v.Pipelines) > 0 || len(v.Projects) > 0 || len(v.Stacks) > 0)
 		},
 		"groupBedrockByProvider": func(models []sawsSync.BedrockModel) []bedrockProviderGroup {
 			order := []string{}
@@ -132,6 +173,7 @@ func Start(addr string, status awscli.Status) error {
 					"eks":                "EKS",
 					"ecr":                "ECR",
 					"elasticache":        "ElastiCache",
+					"es":                 "OpenSearch",
 					"autoscaling":        "Auto Scaling",
 					"application-autoscaling": "App Auto Scaling",
 					"cognito-idp":        "Cognito",
@@ -204,6 +246,17 @@ func Start(addr string, status awscli.Status) error {
 			}
 			return result
 		},
+		"hasLambdaMetrics": func(fns []sawsSync.LambdaFunction) bool {
+			for _, fn := range fns {
+				if fn.Metrics != nil {
+					return true
+				}
+			}
+			return false
+		},
+		"lambdaSortKeys": func() []string { return sawsSync.LambdaSortKeys },
+		"ecsRightsize": sawsSync.SuggestECSTaskSize,
+		"ec2Rightsize": sawsSync.SuggestEC2Size,
 		"hasFargate": func(providers []string) bool {
 			for _, p := range providers {
 				if p == "FARGATE" {
@@ -212,9 +265,10 @@ func Start(addr string, status awscli.Status) error {
 			}
 			return false
 		},
+		"taskID": ecsTaskID,
 		"vpcName": func(vpcId string, region string) string {
-			vpcData, err := sawsSync.LoadVPCData(region)
-			if err != nil || vpcData == nil {
+			vpcData := loadVPCData(region)
+			if vpcData == nil {
 				return ""
 			}
 			for _, v := range vpcData.VPCs {
@@ -257,6 +311,80 @@ func Start(addr string, status awscli.Status) error {
 			}
 			return out
 		},
+		"secretIdleDays": func(sec sawsSync.Secret) int {
+			if d := sec.DaysSinceAccess(time.Now()); d >= sawsSync.SecretStaleDays {
+				return d
+			}
+			return 0
+		},
+		"formatIAMTime": formatIAMTime,
+		"keyAgeDays": func(k sawsSync.IAMAccessKey) int {
+			return k.AgeDays(time.Now())
+		},
+		"keyStale": func(k sawsSync.IAMAccessKey) bool {
+			return k.Stale(time.Now())
+		},
+		"staleKeyCount": func(u sawsSync.IAMUser) int {
+			return len(u.StaleKeys(time.Now()))
+		},
+		"enisFor": func(subnetId string, data *sawsSync.VPCData) []sawsSync.ENI {
+			return data.ENIsInSubnet(subnetId)
+		},
+		"peeringsFor": func(vpcId string, data *sawsSync.VPCData) []sawsSync.VPCPeering {
+			return data.PeeringsForVPC(vpcId)
+		},
+		"endpointsFor": func(vpcId string, data *sawsSync.VPCData) []sawsSync.VPCEndpoint {
+			return data.EndpointsForVPC(vpcId)
+		},
+		"tgwLinksFor": func(vpcId string, data *sawsSync.VPCData) []sawsSync.TGWVPCLink {
+			return data.TGWAttachmentsForVPC(vpcId)
+		},
+		"noncompliantIn": func(region string) int {
+			cfg, _ := sawsSync.LoadConfigData(region)
+			if cfg == nil {
+				return 0
+			}
+			return cfg.NoncompliantResources()
+		},
+		"s3Cached": func(bucket string) bool {
+			s3Data, _ := sawsSync.LoadS3Data()
+			if s3Data == nil {
+				return false
+			}
+			for _, b := range s3Data.Buckets {
+				if b.Name == bucket {
+					return true
+				}
+			}
+			return false
+		},
+		"serviceConnectPeers": func(svc sawsSync.ECSService, data *sawsSync.ComputeData) []sawsSync.ServiceConnectPeer {
+			return data.ServiceConnectPeers(svc)
+		},
+		"resolveCloudMap": func(inst sawsSync.CloudMapInstance, data *sawsSync.ComputeData) *sawsSync.CloudMapTarget {
+			return data.ResolveCloudMapInstance(inst)
+		},
+		"cloudMapNames": func(ip string, data *sawsSync.ServiceMeshData) []string {
+			return data.CloudMapNamesFor(ip)
+		},
+		"namespaceName": func(ns string, data *sawsSync.ServiceMeshData) string {
+			return data.NamespaceName(ns)
+		},
+		"externalAccess": func(region, typ, id string) []sawsSync.ExternalAccess {
+			return sawsSync.ExternalAccessFor(region, typ, id)
+		},
+		"flowLogsFor": func(vpcId string, data *sawsSync.VPCData) []sawsSync.FlowLog {
+			return data.FlowLogsForVPC(vpcId)
+		},
+		"vgwLinksFor": func(vpcId string, data *sawsSync.VPCData) []sawsSync.VPNGatewayLink {
+			return data.VPNGatewaysForVPC(vpcId)
+		},
+		"tgwReachable": func(link sawsSync.TGWVPCLink) []sawsSync.TGWAttachment {
+			return link.Gateway.Reachable(link.Attachment.AttachmentId)
+		},
+		"eipsFor": func(subnetId string, data *sawsSync.VPCData) []sawsSync.ElasticIP {
+			return data.EIPsInSubnet(subnetId)
+		},
 		"natgwsFor": func(vpcId string, data *sawsSync.VPCData) []sawsSync.NATGW {
 			var out []sawsSync.NATGW
 			for _, n := range data.NATGWs {
@@ -317,12 +445,15 @@ func Start(addr string, status awscli.Status) error {
 		"tgsForLB": func(lbArn string, data *sawsSync.VPCData) []sawsSync.TargetGroup {
 			var out []sawsSync.TargetGroup
 			for _, tg := range data.TargetGroups {
-				if tg.LoadBalancerArn == lbArn {
+				if tg.AttachedTo(lbArn) {
 					out = append(out, tg)
 				}
 			}
 			return out
 		},
+		"wafFor": func(lbArn string, data *sawsSync.VPCData) *sawsSync.WebACL {
+			return data.WAF.WebACLFor(lbArn)
+		},
 		"lbIcon": func(lbType string) string {
 			if lbType == "network" {
 				return "NLB"
@@ -378,6 +509,13 @@ func Start(addr string, status awscli.Status) error {
 		return err
 	}
 
+	go reapIdleData()
+
+	return http.ListenAndServe(addr, authenticate(routes()))
+}
+
+// routes maps the server's paths to their handlers.
+func routes() *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Static assets
@@ -389,53 +527,136 @@ func Start(addr string, status awscli.Status) error {
 	mux.HandleFunc("/settings/regions", handleRegionSettings)
 	mux.HandleFunc("/settings/regions/", handleRegionToggle)
 	mux.HandleFunc("/profile", handleProfile)
+	mux.HandleFunc("/login", handleLogin)
+	mux.HandleFunc("/logout", handleLogout)
+	mux.HandleFunc("/account", handleAccountSwitch)
+	mux.HandleFunc("/tags", handleTags)
+	mux.HandleFunc("/tags/results", handleTagResults)
 	mux.HandleFunc("/vpc", handleVPC)
-	mux.HandleFunc("/sync/vpc", handleSyncVPC)
-	mux.HandleFunc("/sync/s3", handleSyncS3)
-	mux.HandleFunc("/sync/database", handleSyncDatabase)
-	mux.HandleFunc("/sync/compute", handleSyncCompute)
-	mux.HandleFunc("/sync/iam", handleSyncIAM)
-	mux.HandleFunc("/sync/streaming", handleSyncStreaming)
-	mux.HandleFunc("/sync/ai", handleSyncAI)
-	mux.HandleFunc("/sync/all", handleSyncAll)
+	mux.HandleFunc("/sync/vpc", requireCLI(handleSyncVPC))
+	mux.HandleFunc("/sync/s3", requireCLI(handleSyncS3))
+	mux.HandleFunc("/sync/database", requireCLI(handleSyncDatabase))
+	mux.HandleFunc("/sync/compute", requireCLI(handleSyncCompute))
+	mux.HandleFunc("/sync/iam", requireCLI(handleSyncIAM))
+	mux.HandleFunc("/sync/streaming", requireCLI(handleSyncStreaming))
+	mux.HandleFunc("/sync/ai", requireCLI(handleSyncAI))
+	mux.HandleFunc("/sync/cicd", requireCLI(handleSyncCICD))
+	mux.HandleFunc("/sync/all", requireCLI(handleSyncAll))
 	mux.HandleFunc("/sync/progress", handleSyncProgress)
+	mux.HandleFunc("/sync/events", handleSyncEvents)
 	mux.HandleFunc("/sync/content", handleSyncContent)
 	mux.HandleFunc("/detail/", handleDetail)
+	mux.HandleFunc("/whatif/sg", handleWhatIfSG)
 
 	// JSON APIs (kept for sync/templates)
 	mux.HandleFunc("/api/status", handleAPIStatus)
 	mux.HandleFunc("/api/templates", handleAPITemplates)
 	mux.HandleFunc("/api/resources", handleAPIResources)
-	mux.HandleFunc("/api/sync", handleAPISync)
+	mux.HandleFunc("/api/sync", requireCLI(handleAPISync))
 	mux.HandleFunc("/api/aws/", handleAPIAWSCache)
-
-	return http.ListenAndServe(addr, mux)
+	mux.HandleFunc("/api/export", handleAPIExport)
+	mux.HandleFunc("/api/graph", handleAPIGraph)
+	mux.HandleFunc("/api/search", handleAPISearch)
+	mux.HandleFunc("/api/export/diagram", handleAPIExportDiagram)
+	v1Routes(mux)
+	return mux
 }
 
 type pageData struct {
 	CurrentRegion  string
 	EnabledRegions []string
 	Regions        []sawsSync.RegionInfo
+	RegionsConfig  string // the .saws.yaml that sets the regions, if one does
 	AWS            awscli.Status
+	Account        string // account whose cache is shown
+	Accounts       []sawsSync.CachedAccount
 	Region         string
 	Tab            string
 	VPC            *sawsSync.VPCData
 	S3             *sawsSync.S3Data
 	DW             *sawsSync.DataWarehouseData
+	Storage        *sawsSync.StorageData
+	Lineage        []sawsSync.LineagePath
+	Secrets        []sawsSync.Secret
+	KMSKeys        []sawsSync.KMSKeyUsage
+	GuardDuty      *sawsSync.GuardDutyData
+	Config         *sawsSync.ConfigData
+	Trails         []sawsSync.Trail
+	ExternalAccess []sawsSync.ExternalAccess
 	DB             *sawsSync.DatabaseData
 	Compute        *sawsSync.ComputeData
+	ServiceMesh    *sawsSync.ServiceMeshData
 	IAM            *sawsSync.IAMData
+	Cognito        *sawsSync.CognitoData
 	Streaming      *sawsSync.StreamingData
 	AI             *sawsSync.AIData
-	SyncedAt       string
+	CICD           *sawsSync.CICDData
+	Changes        *diff.Report
+	Snapshots      []sawsSync.Snapshot
+	SyncedAt       syncLabel
+	AutoResync     bool
+	SignedIn       bool // behind auth, so a sign-out button is shown
+	Banners         []sawsSync.Banner
+	CertWarningDays int
+	LambdaSort      string
+}
+
+// defaultRegion is the region used when a request doesn't name one.
+func defaultRegion() string {
+	return sawsSync.DefaultRegion(awsStatus.Region)
+}
+
+// ForeignAccount reports whether the page shows a cached account other
+// than the one the AWS CLI is signed in to, which can't be synced.
+func (d pageData) ForeignAccount() bool {
+	return d.AWS.AccountID != "" && d.Account != d.AWS.AccountID
+}
+
+// requireCLI wraps handlers that call AWS. Without the CLI they answer 503
+// instead of starting a sync that can only fail; cached data stays usable.
+// While another account's cache is shown they answer 409, as a sync would
+// write the signed-in account's resources into it. In read-only mode they
+// answer 403.
+func requireCLI(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if status, _, msg := syncRefusal(); status != 0 {
+			http.Error(w, msg, status)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// syncRefusal says why a sync can't start, as requireCLI answers it: the
+// status, a short code for the JSON API and a message, or a zero status.
+func syncRefusal() (int, string, string) {
+	if awsStatus.ReadOnly {
+		return http.StatusForbidden, "read_only", "saws is running read-only — showing cached data only"
+	}
+	if !awsStatus.Installed {
+		return http.StatusServiceUnavailable, "aws_cli_unavailable", "AWS CLI not available — showing cached data only"
+	}
+	if a := sawsSync.Account(); awsStatus.AccountID != "" && a != awsStatus.AccountID {
+		return http.StatusConflict, "foreign_account", fmt.Sprintf("Showing cached account %s — switch to %s to sync", a, awsStatus.AccountID)
+	}
+	return 0, "", ""
 }
 
 func newPageData() pageData {
 	enabled, _ := sawsSync.GetEnabledRegions()
+	if len(enabled) == 0 && !awsStatus.Installed {
+		// Offline the regions table is never seeded; offer what's cached.
+		enabled, _ = sawsSync.CachedRegions()
+	}
+	accounts, _ := sawsSync.CachedAccounts()
 	return pageData{
-		CurrentRegion:  awsStatus.Region,
-		EnabledRegions: enabled,
-		AWS:            awsStatus,
+		CurrentRegion:   defaultRegion(),
+		EnabledRegions:  enabled,
+		AWS:             awsStatus,
+		Account:         sawsSync.Account(),
+		Accounts:        accounts,
+		CertWarningDays: sawsSync.CertWarningDays(),
+		SignedIn:        auth.Enabled(),
 	}
 }
 
@@ -443,7 +664,7 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/")
 
 	// Known routes — skip
-	for _, prefix := range []string{"static", "settings", "profile", "vpc", "sync", "api", "detail"} {
+	for _, prefix := range []string{"static", "settings", "profile", "tags", "vpc", "sync", "api", "detail"} {
 		if strings.HasPrefix(path, prefix) {
 			http.NotFound(w, r)
 			return
@@ -454,17 +675,8 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 
 	// / → redirect to /{default-region}/net
 	if path == "" {
-		region := awsStatus.Region
-		if region == "" {
-			enabled, _ := sawsSync.GetEnabledRegions()
-			if len(enabled) > 0 {
-				region = enabled[0]
-			}
-		}
-		if region != "" {
-			http.Redirect(w, r, "/"+region+"/net", http.StatusFound)
-			return
-		}
+		http.Redirect(w, r, "/"+defaultRegion()+"/net", http.StatusFound)
+		return
 	}
 
 	// Parse /{region} or /{region}/{tab}
@@ -481,7 +693,7 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	validTabs := map[string]bool{"net": true, "compute": true, "database": true, "s3": true, "streaming": true, "ai": true, "iam": true}
+	validTabs := map[string]bool{"net": true, "compute": true, "database": true, "s3": true, "streaming": true, "ai": true, "iam": true, "cicd": true, "changes": true, "graph": true}
 	if !validTabs[tab] {
 		http.NotFound(w, r)
 		return
@@ -492,32 +704,49 @@ func handleHome(w http.ResponseWriter, r *http.Request) {
 	data.Region = region
 	data.Tab = tab
 
+	// Only the open tab's data is loaded, through the parsed data cache.
 	switch tab {
 	case "net":
-		vpcData, _ := sawsSync.LoadVPCData(region)
-		data.VPC = vpcData
+		data.VPC = loadVPCData(region)
 	case "database":
-		dbData, _ := sawsSync.LoadDatabaseData(region)
-		data.DB = dbData
+		data.DB = cachedLoad("database", region, sawsSync.LoadDatabaseData)
 	case "compute":
-		computeData, _ := sawsSync.LoadComputeData(region)
+		computeData := cachedLoad("compute", region, sawsSync.LoadComputeData)
+		if computeData != nil {
+			// Sort a copy: the cached data is shared between requests.
+			cp := *computeData
+			cp.Lambda = append([]sawsSync.LambdaFunction(nil), computeData.Lambda...)
+			computeData = &cp
+		}
 		data.Compute = computeData
+		data.ServiceMesh = cachedLoad("servicemesh", region, sawsSync.LoadServiceMeshData)
+		data.LambdaSort = sortLambdas(computeData, r.URL.Query().Get("lambda_sort"))
 	case "s3":
-		s3Data, _ := sawsSync.LoadS3DataEnriched()
-		data.S3 = s3Data
-		dwData, _ := sawsSync.LoadDataWarehouseData(region)
-		data.DW = dwData
+		data.S3 = cachedLoad("s3", "", func(string) (*sawsSync.S3Data, error) { return sawsSync.LoadS3DataEnriched() })
+		data.DW = cachedLoad("dw", region, sawsSync.LoadDataWarehouseData)
+		data.Storage = cachedLoad("storage", region, sawsSync.LoadStorageData)
+		data.Lineage = cachedLoad("lineage", region, sawsSync.DataLineage)
 	case "iam":
-		iamData, _ := sawsSync.LoadIAMData()
-		data.IAM = iamData
+		data.IAM = cachedLoad("iam", "", func(string) (*sawsSync.IAMData, error) { return sawsSync.LoadIAMData() })
+		data.Cognito = cachedLoad("cognito", region, sawsSync.LoadCognitoData)
+		data.Secrets = cachedLoad("secrets", region, sawsSync.LoadSecrets)
+		data.KMSKeys = cachedLoad("kms", region, sawsSync.KeyUsage)
+		data.GuardDuty = cachedLoad("guardduty", region, sawsSync.LoadGuardDutyData)
+		data.Config = cachedLoad("config", region, sawsSync.LoadConfigData)
+		data.Trails = cachedLoad("trails", region, sawsSync.LoadTrails)
+		data.ExternalAccess = cachedLoad("external-access", region, sawsSync.LoadExternalAccess)
 	case "streaming":
-		streamData, _ := sawsSync.LoadStreamingData(region)
-		data.Streaming = streamData
+		data.Streaming = cachedLoad("streaming", region, sawsSync.LoadStreamingData)
 	case "ai":
-		aiData, _ := sawsSync.LoadAIData(region)
-		data.AI = aiData
+		data.AI = cachedLoad("ai", region, sawsSync.LoadAIData)
+	case "cicd":
+		data.CICD = cachedLoad("cicd", region, sawsSync.LoadCICDData)
+	case "changes":
+		loadChanges(&data, r)
 	}
 	data.SyncedAt = syncedAtForTab(tab, region)
+	data.AutoResync = sawsSync.AutoResync()
+	data.Banners = sawsSync.Banners(sawsSync.BannerContext{Region: region, AWS: awsStatus, Offline: !awsStatus.Installed})
 
 	tmpl.ExecuteTemplate(w, "layout", data)
 }
@@ -527,6 +756,9 @@ func handleRegionSettings(w http.ResponseWriter, r *http.Request) {
 	regions, _ := sawsSync.GetRegions()
 	data := newPageData()
 	data.Regions = regions
+	if cfg, _ := sawsSync.LoadProjectConfig(); len(cfg.Regions) > 0 {
+		data.RegionsConfig = cfg.Path()
+	}
 	tmpl.ExecuteTemplate(w, "region-settings", data)
 }
 
@@ -535,12 +767,70 @@ func handleProfile(w http.ResponseWriter, r *http.Request) {
 	tmpl.ExecuteTemplate(w, "profile", data)
 }
 
+// handleAccountSwitch shows another cached account's data, then returns
+// to the page the switch was made from.
+func handleAccountSwitch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if sawsSync.IsSyncing() {
+		http.Error(w, "A sync is running — switch accounts when it finishes", http.StatusConflict)
+		return
+	}
+	r.ParseForm()
+	id := r.FormValue("id")
+	accounts, _ := sawsSync.CachedAccounts()
+	known := id == awsStatus.AccountID
+	for _, a := range accounts {
+		known = known || a.ID == id
+	}
+	if id == "" || !known {
+		http.Error(w, "no cached data for account "+id, http.StatusNotFound)
+		return
+	}
+	sawsSync.UseAccount(id)
+
+	http.Redirect(w, r, localPath(r.FormValue("next")), http.StatusSeeOther)
+}
+
+// tagsData feeds the Tags panel: the filter choices and the resources
+// matching the current filter.
+type tagsData struct {
+	Keys      []sawsSync.TagKey
+	Services  []string
+	Regions   []string
+	Filter    sawsSync.TagFilter
+	Resources []sawsSync.TaggedResource
+}
+
+func handleTags(w http.ResponseWriter, r *http.Request) {
+	var data tagsData
+	data.Keys, _ = sawsSync.TagKeys("")
+	data.Services, _ = sawsSync.TagServices()
+	data.Regions, _ = sawsSync.TagRegions()
+	data.Resources, _ = sawsSync.QueryTags(data.Filter)
+	tmpl.ExecuteTemplate(w, "tags-panel", data)
+}
+
+func handleTagResults(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	data := tagsData{Filter: sawsSync.TagFilter{
+		Key:     q.Get("key"),
+		Value:   strings.TrimSpace(q.Get("value")),
+		Service: q.Get("service"),
+		Region:  q.Get("region"),
+	}}
+	data.Resources, _ = sawsSync.QueryTags(data.Filter)
+	tmpl.ExecuteTemplate(w, "tag-results", data)
+}
+
 func handleVPC(w http.ResponseWriter, r *http.Request) {
 	region := r.URL.Query().Get("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	vpcData, _ := sawsSync.LoadVPCData(region)
+	vpcData := loadVPCData(region)
 	data := newPageData()
 	data.Region = region
 	data.VPC = vpcData
@@ -549,7 +839,8 @@ func handleVPC(w http.ResponseWriter, r *http.Request) {
 
 func writeSyncedAtOOB(w http.ResponseWriter, tab, region string) {
 	label := syncedAtForTab(tab, region)
-	fmt.Fprintf(w, `<span id="synced-at-label" hx-swap-oob="true" class="synced-at-label">%s</span>`, label)
+	label.OOB = true
+	tmpl.ExecuteTemplate(w, "synced-at-label", label)
 }
 
 func handleSyncVPC(w http.ResponseWriter, r *http.Request) {
@@ -560,17 +851,23 @@ func handleSyncVPC(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("net", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	jobID := sawsSync.StartSync("net", region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "net")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncVPCData(region, onStep)
+		record(sawsSync.SyncVPCData(run.Stage(), region, onStep))
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
@@ -585,18 +882,25 @@ func handleSyncS3(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("s3", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	jobID := sawsSync.StartSync("s3", region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "s3")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncS3WithRegions(onStep)
-		sawsSync.SyncDataWarehouseData(region, onStep)
+		record(syncS3(run.Stage(), onStep))
+		record(sawsSync.SyncDataWarehouseData(run.Stage(), region, onStep))
+		record(sawsSync.SyncStorageData(run.Stage(), region, onStep))
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
@@ -611,17 +915,23 @@ func handleSyncDatabase(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("database", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	jobID := sawsSync.StartSync("database", region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "database")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncDatabaseData(region, onStep)
+		record(sawsSync.SyncDatabaseData(run.Stage(), region, onStep))
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
@@ -636,17 +946,24 @@ func handleSyncCompute(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("compute", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	jobID := sawsSync.StartSync("compute", region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "compute")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncComputeData(region, onStep)
+		record(sawsSync.SyncComputeData(run.Stage(), region, onStep))
+		record(sawsSync.SyncServiceMeshData(run.Stage(), region, onStep))
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
@@ -661,17 +978,33 @@ func handleSyncIAM(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("iam", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	jobID := sawsSync.StartSync("iam", region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "iam")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncIAMData(onStep)
+		record(sawsSync.SyncIAMData(run.Stage(), onStep))
+		record(sawsSync.SyncCognitoData(run.Stage(), region, onStep))
+		record(sawsSync.SyncSecretsData(run.Stage(), region, onStep))
+		record(sawsSync.SyncKMSData(run.Stage(), region, onStep))
+		record(sawsSync.SyncGuardDutyData(run.Stage(), region, onStep))
+		record(sawsSync.SyncConfigData(run.Stage(), region, onStep))
+		record(sawsSync.SyncCloudTrailData(run.Stage(), region, onStep))
+		record(sawsSync.SyncAccessAnalyzerData(run.Stage(), region, onStep))
+		for _, p := range sawsSync.EnabledProviders() {
+			record(p.Sync(run.Stage(), onStep))
+		}
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
@@ -686,17 +1019,23 @@ func handleSyncStreaming(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("streaming", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	jobID := sawsSync.StartSync("streaming", region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "streaming")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncStreamingData(region, onStep)
+		record(sawsSync.SyncStreamingData(run.Stage(), region, onStep))
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
@@ -711,24 +1050,30 @@ func handleSyncAI(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("ai", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	jobID := sawsSync.StartSync("ai", region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "ai")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncAIData(region, onStep)
+		record(sawsSync.SyncAIData(run.Stage(), region, onStep))
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 }
 
-func handleSyncAll(w http.ResponseWriter, r *http.Request) {
+func handleSyncCICD(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "use POST", http.StatusMethodNotAllowed)
 		return
@@ -736,31 +1081,161 @@ func handleSyncAll(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	region := r.FormValue("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
-	if sawsSync.IsSyncing() {
+	jobID, ok := sawsSync.StartSync("cicd", region)
+	if !ok {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 		return
 	}
-	tab := r.FormValue("tab")
-	jobID := sawsSync.StartSync(tab, region)
 	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "cicd")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
 	go func() {
-		sawsSync.SyncVPCData(region, onStep)
-		sawsSync.SyncS3WithRegions(onStep)
-		sawsSync.SyncDatabaseData(region, onStep)
-		sawsSync.SyncComputeData(region, onStep)
-		sawsSync.SyncDataWarehouseData(region, onStep)
-		sawsSync.SyncStreamingData(region, onStep)
-		sawsSync.SyncAIData(region, onStep)
-		sawsSync.SyncIAMData(onStep)
+		record(sawsSync.SyncCICDData(run.Stage(), region, onStep))
+		finishRun(run)
 		sawsSync.FinishSync(jobID)
 	}()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
 }
 
+func handleSyncAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	r.ParseForm()
+	region := r.FormValue("region")
+	if region == "" {
+		region = defaultRegion()
+	}
+	if _, err := startGroupSync("all", region, sawsSync.SelectedSyncGroups()); err != nil {
+		http.Error(w, "sync not started: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sawsSync.GetSyncProgress())
+}
+
+// startGroupSync syncs groups in region in the background as one job
+// labelled tab. It reports false if another job is running, or an error if
+// the sync's snapshot could not be opened.
+func startGroupSync(tab, region string, groups []sawsSync.SyncGroup) (bool, error) {
+	jobID, ok := sawsSync.StartSync(tab, region)
+	if !ok {
+		return false, nil
+	}
+	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, err := hooks.Begin(region, tab)
+	if err != nil {
+		sawsSync.FinishSync(jobID)
+		return false, err
+	}
+	record := recordSync(region, run)
+	go func() {
+		var mu sync.Mutex
+		sawsSync.RunSyncGroups(groups, func(g sawsSync.SyncGroup) {
+			results, err := g.Sync(run.Stage(), region, onStep)
+			mu.Lock()
+			defer mu.Unlock()
+			record(results, err)
+		})
+		finishRun(run)
+		sawsSync.FinishSync(jobID)
+	}()
+	return true, nil
+}
+
+// beginRun opens the snapshot of job jobID's sync of tab, or ends the job
+// and answers 500 if it can't be opened.
+func beginRun(w http.ResponseWriter, jobID, region, tab string) (*hooks.Run, bool) {
+	run, err := hooks.Begin(region, tab)
+	if err != nil {
+		sawsSync.FinishSync(jobID)
+		http.Error(w, "sync not started: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return run, true
+}
+
+// configComplianceFields lists the AWS Config compliance of a resource and
+// the rules it fails, or nothing when Config has not evaluated it.
+func configComplianceFields(region, resId string) []detailField {
+	cfg, _ := sawsSync.LoadConfigData(region)
+	if cfg == nil {
+		return nil
+	}
+	compliance, failing, ok := cfg.ComplianceFor(resId)
+	if !ok {
+		return nil
+	}
+	fields := []detailField{{"Config Compliance", nameOr(compliance, "NON_COMPLIANT")}}
+	for _, e := range failing {
+		fields = append(fields, detailField{"  " + e.Rule, nameOr(e.Annotation, "noncompliant")})
+	}
+	return fields
+}
+
+// externalAccessFields lists the Access Analyzer findings on a resource,
+// or nothing when it has none.
+func externalAccessFields(region, resType, resId string) []detailField {
+	if resType == "kms" {
+		// KMS panels may be keyed by alias; findings name the key ID.
+		usage, _ := sawsSync.KeyUsage(region)
+		for _, u := range usage {
+			if u.Matches(resId) {
+				resId = u.Key.KeyId
+				break
+			}
+		}
+	}
+	var fields []detailField
+	for _, f := range sawsSync.ExternalAccessFor(region, resType, resId) {
+		fields = append(fields, detailField{"External Access", f.Grantee() + ": " + strings.Join(f.Actions, ", ")})
+	}
+	return fields
+}
+
+// recordSync returns a sink that stores a sync's results for the sync
+// failure banner and collects them for the run's hooks. A service group
+// that fails outright fails the run, which is then rolled back.
+func recordSync(region string, run *hooks.Run) func([]sawsSync.SyncResult, error) {
+	return func(results []sawsSync.SyncResult, err error) {
+		if err != nil {
+			run.Fail(err)
+		}
+		sawsSync.RecordSyncResults(region, results)
+		run.Add(results)
+	}
+}
+
+// finishRun commits the sync and reports a rollback and hooks that failed.
+func finishRun(run *hooks.Run) {
+	deliveries := run.Finish()
+	if err := run.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "sync rolled back: %v\n", err)
+	}
+	for _, d := range deliveries {
+		if d.Err != nil {
+			fmt.Fprintf(os.Stderr, "hook %s %q: %v\n", d.Hook.Event, d.Hook.Target, d.Err)
+		}
+	}
+}
+
+// syncS3 runs the S3 sync with the result shape of the other syncs.
+func syncS3(st *sawsSync.Stage, onStep func(string)) ([]sawsSync.SyncResult, error) {
+	r, err := sawsSync.SyncS3WithRegions(st, onStep)
+	if err != nil {
+		return []sawsSync.SyncResult{{Service: "s3", Error: err.Error()}}, nil
+	}
+	return []sawsSync.SyncResult{*r}, nil
+}
+
 func handleSyncProgress(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	job := sawsSync.GetSyncProgress()
@@ -771,11 +1246,47 @@ func handleSyncProgress(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(job)
 }
 
+// handleSyncEvents streams the sync job as server-sent events: its state
+// on connect (or idle), then every change until the client goes away.
+func handleSyncEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	updates, cancel := sawsSync.SubscribeSync()
+	defer cancel()
+	if sawsSync.GetSyncProgress() == nil {
+		fmt.Fprint(w, "data: {\"status\":\"idle\"}\n\n")
+	}
+	flusher.Flush()
+
+	// Comments keep proxies from closing a quiet stream.
+	heartbeat := time.NewTicker(15 * time.Second)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case job := <-updates:
+			b, _ := json.Marshal(job)
+			fmt.Fprintf(w, "data: %s\n\n", b)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
 func handleSyncContent(w http.ResponseWriter, r *http.Request) {
 	tab := r.URL.Query().Get("tab")
 	region := r.URL.Query().Get("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
 
 	data := newPageData()
@@ -785,20 +1296,31 @@ func handleSyncContent(w http.ResponseWriter, r *http.Request) {
 
 	switch tab {
 	case "net":
-		data.VPC, _ = sawsSync.LoadVPCData(region)
+		data.VPC = loadVPCData(region)
 		tmpl.ExecuteTemplate(w, "vpc-panel", data)
 	case "database":
 		data.DB, _ = sawsSync.LoadDatabaseData(region)
 		tmpl.ExecuteTemplate(w, "database-content", data)
 	case "compute":
 		data.Compute, _ = sawsSync.LoadComputeData(region)
+		data.ServiceMesh, _ = sawsSync.LoadServiceMeshData(region)
+		data.LambdaSort = sortLambdas(data.Compute, r.URL.Query().Get("lambda_sort"))
 		tmpl.ExecuteTemplate(w, "compute-content", data)
 	case "s3":
 		data.S3, _ = sawsSync.LoadS3DataEnriched()
 		data.DW, _ = sawsSync.LoadDataWarehouseData(region)
+		data.Storage, _ = sawsSync.LoadStorageData(region)
+		data.Lineage, _ = sawsSync.DataLineage(region)
 		tmpl.ExecuteTemplate(w, "s3-content", data)
 	case "iam":
 		data.IAM, _ = sawsSync.LoadIAMData()
+		data.Cognito, _ = sawsSync.LoadCognitoData(region)
+		data.Secrets, _ = sawsSync.LoadSecrets(region)
+		data.KMSKeys, _ = sawsSync.KeyUsage(region)
+		data.GuardDuty, _ = sawsSync.LoadGuardDutyData(region)
+		data.Config, _ = sawsSync.LoadConfigData(region)
+		data.Trails, _ = sawsSync.LoadTrails(region)
+		data.ExternalAccess, _ = sawsSync.LoadExternalAccess(region)
 		tmpl.ExecuteTemplate(w, "iam-content", data)
 	case "streaming":
 		data.Streaming, _ = sawsSync.LoadStreamingData(region)
@@ -806,13 +1328,41 @@ func handleSyncContent(w http.ResponseWriter, r *http.Request) {
 	case "ai":
 		data.AI, _ = sawsSync.LoadAIData(region)
 		tmpl.ExecuteTemplate(w, "ai-content", data)
+	case "cicd":
+		data.CICD, _ = sawsSync.LoadCICDData(region)
+		tmpl.ExecuteTemplate(w, "cicd-content", data)
+	case "changes":
+		loadChanges(&data, r)
+		tmpl.ExecuteTemplate(w, "changes-content", data)
+	case "graph":
+		tmpl.ExecuteTemplate(w, "graph-content", data)
 	default:
-		data.VPC, _ = sawsSync.LoadVPCData(region)
+		data.VPC = loadVPCData(region)
 		tmpl.ExecuteTemplate(w, "vpc-panel", data)
 	}
 	writeSyncedAtOOB(w, tab, region)
 }
 
+// loadChanges fills the Changes tab: the region's snapshots and the
+// changelog between the from and to snapshots picked, or the last two syncs.
+func loadChanges(data *pageData, r *http.Request) {
+	all, _ := sawsSync.ListSnapshots(data.Region)
+	for _, s := range all {
+		if !s.FinishedAt.IsZero() {
+			data.Snapshots = append(data.Snapshots, s)
+		}
+	}
+	from, _ := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if from > 0 && to > 0 {
+		if report, err := diff.Between(data.Region, from, to); err == nil {
+			data.Changes = report
+			return
+		}
+	}
+	data.Changes, _ = diff.Latest(data.Region)
+}
+
 type detailData struct {
 	Type          string
 	Title         string
@@ -822,6 +1372,10 @@ type detailData struct {
 	Outbound      [][]string
 	OutboundTitle string
 	Routes        [][]string
+	Region        string
+	WhatIfGroup   string // security group the what-if form stages changes on
+	Statements    []sawsSync.PolicyStatement
+	Document      string // indented policy JSON
 }
 
 type detailField struct {
@@ -829,6 +1383,11 @@ type detailField struct {
 	Value string
 }
 
+// ecsTaskID returns the task ID at the end of an ECS task ARN.
+func ecsTaskID(arn string) string {
+	return arn[strings.LastIndex(arn, "/")+1:]
+}
+
 type iamRoleGroup struct {
 	Principal string
 	Roles     []sawsSync.IAMRole
@@ -850,10 +1409,10 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 	resType, resId := parts[0], parts[1]
 	region := r.URL.Query().Get("region")
 	if region == "" {
-		region = awsStatus.Region
+		region = defaultRegion()
 	}
 
-	vpcData, _ := sawsSync.LoadVPCData(region)
+	vpcData := loadVPCData(region)
 	if vpcData == nil {
 		vpcData = &sawsSync.VPCData{}
 	}
@@ -906,6 +1465,58 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 						{"Available IPs", fmt.Sprintf("%d", s.AvailableIPs)},
 					},
 				}
+				for _, e := range vpcData.EIPsInSubnet(s.SubnetId) {
+					detail.Fields = append(detail.Fields, detailField{"Elastic IP " + e.PublicIp, e.NetworkInterfaceId + " (" + e.PrivateIpAddress + ")"})
+				}
+				for _, n := range vpcData.ENIsInSubnet(s.SubnetId) {
+					desc := n.InterfaceType + " · " + n.PrivateIp
+					if n.AttachedTo != "" {
+						desc += " · " + n.AttachedTo
+					} else {
+						desc += " · " + n.Status
+					}
+					detail.Fields = append(detail.Fields, detailField{"ENI " + n.NetworkInterfaceId, desc})
+				}
+				storage, _ := sawsSync.LoadStorageData(region)
+				if fsIds := storage.FileSystemsInSubnet(s.SubnetId); len(fsIds) > 0 {
+					detail.Fields = append(detail.Fields, detailField{"File Systems", strings.Join(fsIds, ", ")})
+				}
+				if sgs := vpcData.SecurityGroupsInSubnet(s.SubnetId); len(sgs) > 0 {
+					detail.Fields = append(detail.Fields, detailField{"Security Groups", strings.Join(sgs, ", ")})
+				}
+				if acl := vpcData.NACLForSubnet(s.SubnetId); acl != nil {
+					label := nameOr(acl.Name, acl.NetworkAclId)
+					if acl.IsDefault {
+						label += " (default)"
+					}
+					detail.Fields = append(detail.Fields, detailField{"Network ACL", label})
+					detail.RulesTitle = "NACL Inbound Rules"
+					detail.Rules = naclRules(acl.Inbound())
+					detail.OutboundTitle = "NACL Outbound Rules"
+					detail.Outbound = naclRules(acl.Outbound())
+				}
+				break
+			}
+		}
+	case "eip":
+		for _, e := range vpcData.ElasticIPs {
+			if e.PublicIp == resId {
+				status := "unassociated (billed hourly)"
+				if e.Associated() {
+					status = "associated"
+				}
+				detail = detailData{
+					Type:  "EIP",
+					Title: e.PublicIp,
+					Fields: []detailField{
+						{"Public IP", e.PublicIp},
+						{"Allocation ID", e.AllocationId},
+						{"Status", status},
+						{"Instance", orDash(e.InstanceId)},
+						{"Network Interface", orDash(e.NetworkInterfaceId)},
+						{"Private IP", orDash(e.PrivateIpAddress)},
+					},
+				}
 				break
 			}
 		}
@@ -928,10 +1539,76 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 					Rules:         inbound,
 					OutboundTitle: "Outbound Rules",
 					Outbound:      outbound,
+					Region:        region,
+					WhatIfGroup:   sg.GroupId,
+				}
+				break
+			}
+		}
+	case "acm":
+		for _, c := range vpcData.Certificates {
+			if c.CertificateArn == resId {
+				expires := "—"
+				if !c.ExpiresAt().IsZero() {
+					expires = fmt.Sprintf("%s (%d days)", c.ExpiresAt().Format("2006-01-02"), c.DaysLeft())
+				}
+				inUse := "—"
+				if len(c.InUseBy) > 0 {
+					inUse = strings.Join(c.InUseBy, ", ")
+				}
+				altNames := "—"
+				if len(c.AltNames) > 0 {
+					altNames = strings.Join(c.AltNames, ", ")
+				}
+				detail = detailData{
+					Type:  "ACM",
+					Title: c.DomainName,
+					Fields: []detailField{
+						{"Domain", c.DomainName},
+						{"Alternative Names", altNames},
+						{"Status", c.Status},
+						{"Type", c.Type},
+						{"Issuer", nameOr(c.Issuer, "—")},
+						{"Key Algorithm", nameOr(c.KeyAlgorithm, "—")},
+						{"Expires", expires},
+						{"Renewal", nameOr(c.RenewalStatus, "—")},
+						{"In Use By", inUse},
+						{"ARN", c.CertificateArn},
+					},
 				}
 				break
 			}
 		}
+
+	case "waf":
+		if vpcData.WAF != nil {
+			for _, acl := range vpcData.WAF.WebACLs {
+				if acl.Id == resId {
+					resources := "—"
+					if len(acl.Resources) > 0 {
+						resources = strings.Join(acl.Resources, ", ")
+					}
+					detail = detailData{
+						Type:  "WAF",
+						Title: acl.Name,
+						Fields: []detailField{
+							{"Name", acl.Name},
+							{"Scope", acl.Scope},
+							{"Default Action", acl.DefaultAction},
+							{"Capacity (WCU)", fmt.Sprintf("%d", acl.Capacity)},
+							{"Description", nameOr(acl.Description, "—")},
+							{"Protects", resources},
+							{"ARN", acl.Arn},
+						},
+						RulesTitle: "Rules",
+					}
+					for _, rule := range acl.Rules {
+						detail.Rules = append(detail.Rules, []string{fmt.Sprintf("%d", rule.Priority), rule.Name, rule.Statement, rule.Action})
+					}
+					break
+				}
+			}
+		}
 	case "rt":
 		for _, rt := range vpcData.RouteTables {
 			if rt.RouteTableId == resId {
@@ -961,50 +1638,326 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 					if target == "" {
 						target = route.NatGatewayId
 					}
+					if target == "" {
+						target = route.TransitGatewayId
+					}
+					if target == "" {
+						target = route.VpcPeeringConnectionId
+					}
+					dest := route.Destination
+					if dest == "" {
+						dest = route.PrefixListId
+					}
 					if target == "" {
 						target = "—"
 					}
-					detail.Routes = append(detail.Routes, []string{route.Destination, target, route.State})
+					detail.Routes = append(detail.Routes, []string{dest, target, route.State})
 				}
 				break
 			}
 		}
-	case "igw":
-		for _, g := range vpcData.IGWs {
-			if g.InternetGatewayId == resId {
-				vpcs := strings.Join(g.AttachedVpcIds, ", ")
-				if vpcs == "" {
-					vpcs = "—"
+	case "pcx":
+		for _, p := range vpcData.Peerings {
+			if p.PeeringId == resId {
+				side := func(s sawsSync.PeeringSide) string {
+					v := s.VpcId
+					if name := vpcData.VPCName(s.VpcId); name != "" {
+						v += " (" + name + ")"
+					}
+					return fmt.Sprintf("%s · %s · account %s · %s", v, orDash(s.CidrBlock), orDash(s.OwnerId), orDash(s.Region))
+				}
+				var routes []string
+				for _, rt := range vpcData.RouteTables {
+					for _, r := range rt.Routes {
+						if r.VpcPeeringConnectionId == p.PeeringId {
+							routes = append(routes, rt.RouteTableId+" "+r.Destination)
+						}
+					}
 				}
 				detail = detailData{
-					Type:  "IGW",
-					Title: nameOr(g.Name, g.InternetGatewayId),
+					Type:  "PCX",
+					Title: nameOr(p.Name, p.PeeringId),
 					Fields: []detailField{
-						{"IGW ID", g.InternetGatewayId},
-						{"Attached VPCs", vpcs},
+						{"Peering ID", p.PeeringId},
+						{"Status", p.Status},
+						{"Requester", side(p.Requester)},
+						{"Accepter", side(p.Accepter)},
+						{"Routes", orDash(strings.Join(routes, ", "))},
 					},
 				}
 				break
 			}
 		}
-	case "natgw":
-		for _, n := range vpcData.NATGWs {
-			if n.NatGatewayId == resId {
-				detail = detailData{
-					Type:  "NAT",
-					Title: nameOr(n.Name, n.NatGatewayId),
-					Fields: []detailField{
-						{"NAT Gateway ID", n.NatGatewayId},
-						{"VPC ID", n.VpcId},
-						{"Subnet ID", n.SubnetId},
-						{"State", n.State},
+	case "vpce":
+		for _, e := range vpcData.Endpoints {
+			if e.VpcEndpointId == resId {
+				fields := []detailField{
+					{"Endpoint ID", e.VpcEndpointId},
+					{"Service", e.ServiceName},
+					{"Type", e.Type},
+					{"State", e.State},
+					{"VPC ID", e.VpcId},
+				}
+				if e.Type == "Gateway" {
+					fields = append(fields, detailField{"Route Tables", orDash(strings.Join(e.RouteTableIds, ", "))})
+				} else {
+					fields = append(fields,
+						detailField{"Private DNS", boolStr(e.PrivateDns)},
+						detailField{"Subnets", orDash(strings.Join(e.SubnetIds, ", "))},
+						detailField{"Security Groups", orDash(strings.Join(e.SecurityGroups, ", "))},
+					)
+				}
+				detail = detailData{Type: "VPCE", Title: nameOr(e.Name, e.Service()), Fields: fields}
+				break
+			}
+		}
+	case "tgw":
+		for _, g := range vpcData.TransitGateways {
+			if g.TransitGatewayId == resId {
+				fields := []detailField{
+					{"Transit Gateway ID", g.TransitGatewayId},
+					{"ARN", orDash(g.Arn)},
+					{"State", orDash(g.State)},
+					{"Owner", orDash(g.OwnerId)},
+					{"Amazon Side ASN", fmt.Sprint(g.AmazonSideAsn)},
+					{"Description", orDash(g.Description)},
+					{"Attachments", fmt.Sprint(len(g.Attachments))},
+				}
+				for _, a := range g.Attachments {
+					target := a.ResourceType + " " + a.ResourceId
+					if a.ResourceType == "vpc" {
+						if name := vpcData.VPCName(a.ResourceId); name != "" {
+							target += " (" + name + ")"
+						}
+					}
+					rt := "no route table"
+					if a.RouteTableId != "" {
+						rt = "rt " + a.RouteTableId
+					}
+					fields = append(fields, detailField{"  " + a.AttachmentId, target + " · " + a.State + " · " + rt})
+				}
+				fields = append(fields, detailField{"Route Tables", fmt.Sprint(len(g.RouteTables))})
+				for _, rt := range g.RouteTables {
+					fields = append(fields, detailField{"  " + rt.RouteTableId, fmt.Sprintf("%s · %d routes", nameOr(rt.Name, rt.State), len(rt.Routes))})
+				}
+				detail = detailData{Type: "TGW", Title: nameOr(g.Name, g.TransitGatewayId), Fields: fields}
+				break
+			}
+		}
+	case "tgw-rt":
+		for _, g := range vpcData.TransitGateways {
+			for _, rt := range g.RouteTables {
+				if rt.RouteTableId != resId {
+					continue
+				}
+				var associated []string
+				for _, a := range g.Attachments {
+					if a.RouteTableId == rt.RouteTableId {
+						associated = append(associated, a.ResourceType+" "+a.ResourceId)
+					}
+				}
+				detail = detailData{
+					Type:  "TGWRT",
+					Title: nameOr(rt.Name, rt.RouteTableId),
+					Fields: []detailField{
+						{"Route Table ID", rt.RouteTableId},
+						{"Transit Gateway", nameOr(g.Name, g.TransitGatewayId)},
+						{"State", rt.State},
+						{"Default Association", boolStr(rt.DefaultAssociation)},
+						{"Default Propagation", boolStr(rt.DefaultPropagation)},
+						{"Associated", orDash(strings.Join(associated, ", "))},
+					},
+				}
+				for _, r := range rt.Routes {
+					var targets []string
+					for _, id := range r.AttachmentIds {
+						if a := g.Attachment(id); a != nil {
+							targets = append(targets, a.ResourceType+" "+a.ResourceId)
+						} else {
+							targets = append(targets, id)
+						}
+					}
+					target := strings.Join(targets, ", ")
+					if r.State == "blackhole" {
+						target = "blackhole"
+					}
+					detail.Routes = append(detail.Routes, []string{r.Destination, target, r.Type})
+				}
+			}
+		}
+	case "flowlog":
+		for _, f := range vpcData.FlowLogs {
+			if f.FlowLogId != resId {
+				continue
+			}
+			delivery := orDash(f.DeliverStatus)
+			if f.DeliverError != "" {
+				delivery += " · " + f.DeliverError
+			}
+			format := "default"
+			if f.CustomFormat() {
+				format = f.LogFormat
+			}
+			detail = detailData{
+				Type:  "FLOW",
+				Title: f.FlowLogId,
+				Fields: []detailField{
+					{"Flow Log ID", f.FlowLogId},
+					{"Resource", f.ResourceId},
+					{"Status", orDash(f.Status)},
+					{"Traffic", orDash(f.TrafficType)},
+					{"Destination Type", orDash(f.DestinationType)},
+					{"Destination", orDash(f.Destination)},
+					{"Delivery", delivery},
+					{"Log Format", format},
+				},
+			}
+			break
+		}
+	case "vpn":
+		if vpcData.Hybrid == nil {
+			break
+		}
+		for _, v := range vpcData.Hybrid.VPNConnections {
+			if v.VpnConnectionId != resId {
+				continue
+			}
+			terminates := v.VpnGatewayId
+			if v.TransitGatewayId != "" {
+				terminates = v.TransitGatewayId
+			}
+			customer := v.CustomerGatewayId
+			if cgw := vpcData.Hybrid.CustomerGateway(v.CustomerGatewayId); cgw != nil {
+				customer += " · " + cgw.IpAddress
+				if cgw.BgpAsn != "" {
+					customer += " · ASN " + cgw.BgpAsn
+				}
+				if cgw.DeviceName != "" {
+					customer += " · " + cgw.DeviceName
+				}
+			}
+			routing := "BGP"
+			if v.StaticRoutesOnly {
+				routing = "static"
+			}
+			fields := []detailField{
+				{"VPN Connection ID", v.VpnConnectionId},
+				{"State", v.State},
+				{"Type", orDash(v.Type)},
+				{"Terminates On", orDash(terminates)},
+				{"Customer Gateway", orDash(customer)},
+				{"Routing", routing},
+				{"Tunnels Up", fmt.Sprintf("%d of %d", v.TunnelsUp(), len(v.Tunnels))},
+			}
+			for _, t := range v.Tunnels {
+				status := t.Status
+				if t.StatusMessage != "" {
+					status += " · " + t.StatusMessage
+				}
+				status += fmt.Sprintf(" · %d routes · since %s", t.AcceptedRouteCount, orDash(t.LastStatusChange))
+				fields = append(fields, detailField{"  " + t.OutsideIpAddress, status})
+			}
+			detail = detailData{Type: "VPN", Title: nameOr(v.Name, v.VpnConnectionId), Fields: fields}
+			break
+		}
+	case "vgw":
+		if vpcData.Hybrid == nil {
+			break
+		}
+		for _, g := range vpcData.Hybrid.VPNGateways {
+			if g.VpnGatewayId != resId {
+				continue
+			}
+			var vpcs []string
+			for _, id := range g.VpcIds {
+				if name := vpcData.VPCName(id); name != "" {
+					id += " (" + name + ")"
+				}
+				vpcs = append(vpcs, id)
+			}
+			fields := []detailField{
+				{"VGW ID", g.VpnGatewayId},
+				{"State", g.State},
+				{"Amazon Side ASN", fmt.Sprint(g.AmazonSideAsn)},
+				{"Attached VPCs", orDash(strings.Join(vpcs, ", "))},
+			}
+			for _, v := range vpcData.Hybrid.VPNConnections {
+				if v.VpnGatewayId == g.VpnGatewayId {
+					fields = append(fields, detailField{"  VPN " + v.VpnConnectionId, fmt.Sprintf("%s · %d/%d tunnels up", v.State, v.TunnelsUp(), len(v.Tunnels))})
+				}
+			}
+			for _, vif := range vpcData.Hybrid.DXInterfaces {
+				if vif.VirtualGatewayId == g.VpnGatewayId {
+					fields = append(fields, detailField{"  VIF " + vif.VirtualInterfaceId, vif.State + " · BGP " + orDash(vif.BGPStatus)})
+				}
+			}
+			detail = detailData{Type: "VGW", Title: nameOr(g.Name, g.VpnGatewayId), Fields: fields}
+			break
+		}
+	case "dx":
+		if vpcData.Hybrid == nil {
+			break
+		}
+		for _, c := range vpcData.Hybrid.DXConnections {
+			if c.ConnectionId != resId {
+				continue
+			}
+			fields := []detailField{
+				{"Connection ID", c.ConnectionId},
+				{"State", c.State},
+				{"Location", orDash(c.Location)},
+				{"Bandwidth", orDash(c.Bandwidth)},
+				{"Provider", orDash(c.ProviderName)},
+			}
+			vifs := vpcData.Hybrid.InterfacesOn(c.ConnectionId)
+			fields = append(fields, detailField{"Virtual Interfaces", fmt.Sprint(len(vifs))})
+			for _, vif := range vifs {
+				gw := vif.VirtualGatewayId
+				if gw == "" {
+					gw = vif.DirectConnectGatewayId
+				}
+				fields = append(fields, detailField{"  " + vif.VirtualInterfaceId,
+					fmt.Sprintf("%s · %s · VLAN %d · BGP %s · %s", nameOr(vif.Name, vif.Type), vif.State, vif.Vlan, orDash(vif.BGPStatus), orDash(gw))})
+			}
+			detail = detailData{Type: "DX", Title: nameOr(c.Name, c.ConnectionId), Fields: fields}
+			break
+		}
+	case "igw":
+		for _, g := range vpcData.IGWs {
+			if g.InternetGatewayId == resId {
+				vpcs := strings.Join(g.AttachedVpcIds, ", ")
+				if vpcs == "" {
+					vpcs = "—"
+				}
+				detail = detailData{
+					Type:  "IGW",
+					Title: nameOr(g.Name, g.InternetGatewayId),
+					Fields: []detailField{
+						{"IGW ID", g.InternetGatewayId},
+						{"Attached VPCs", vpcs},
+					},
+				}
+				break
+			}
+		}
+	case "natgw":
+		for _, n := range vpcData.NATGWs {
+			if n.NatGatewayId == resId {
+				detail = detailData{
+					Type:  "NAT",
+					Title: nameOr(n.Name, n.NatGatewayId),
+					Fields: []detailField{
+						{"NAT Gateway ID", n.NatGatewayId},
+						{"VPC ID", n.VpcId},
+						{"Subnet ID", n.SubnetId},
+						{"State", n.State},
 					},
 				}
 				break
 			}
 		}
 	case "lb":
-		vpcData, _ := sawsSync.LoadVPCData(r.URL.Query().Get("region"))
+		vpcData := loadVPCData(r.URL.Query().Get("region"))
 		if vpcData != nil {
 			for _, lb := range vpcData.LoadBalancers {
 				if lb.Name == resId {
@@ -1020,6 +1973,14 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 					if lb.Type == "network" {
 						iconType = "NLB"
 					}
+					waf := "Not protected"
+					if lb.Type == "network" {
+						waf = "— (not supported for NLB)"
+					} else if vpcData.WAF == nil {
+						waf = "— (not synced)"
+					} else if acl := vpcData.WAF.WebACLFor(lb.Arn); acl != nil {
+						waf = acl.Name + " (" + fmt.Sprintf("%d rules", len(acl.Rules)) + ")"
+					}
 					detail = detailData{
 						Type:  iconType,
 						Title: lb.Name,
@@ -1032,14 +1993,17 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							{"VPC ID", lb.VpcId},
 							{"Availability Zones", azs},
 							{"Security Groups", sgs},
+							{"WAF Web ACL", waf},
 						},
 					}
+					compute, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+					detail.Fields = append(detail.Fields, lbPathFields(lb, vpcData, compute)...)
 					break
 				}
 			}
 		}
 	case "tg":
-		vpcData, _ := sawsSync.LoadVPCData(r.URL.Query().Get("region"))
+		vpcData := loadVPCData(r.URL.Query().Get("region"))
 		if vpcData != nil {
 			for _, tg := range vpcData.TargetGroups {
 				if tg.Name == resId {
@@ -1059,6 +2023,20 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							{"Health Check Path", healthPath},
 						},
 					}
+					for _, lb := range vpcData.LoadBalancers {
+						if tg.AttachedTo(lb.Arn) {
+							detail.Fields = append(detail.Fields, detailField{"Load Balancer", lb.Name})
+						}
+					}
+					compute, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+					detail.Fields = append(detail.Fields, detailField{"Targets", fmt.Sprintf("%d/%d healthy", tg.HealthyTargets(), len(tg.Targets))})
+					for _, t := range tg.Targets {
+						state := t.State
+						if t.Reason != "" && t.State != "healthy" {
+							state += " (" + strings.TrimPrefix(t.Reason, "Target.") + ")"
+						}
+						detail.Fields = append(detail.Fields, detailField{"  " + sawsSync.ResolveTarget(tg, t, compute, vpcData.ENIs), state})
+					}
 					break
 				}
 			}
@@ -1068,6 +2046,7 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 		if s3Data != nil {
 			for _, b := range s3Data.Buckets {
 				if b.Name == resId {
+					trails := sawsSync.TrailsLoggingTo(region, b.Name)
 					region := b.Region
 					if region == "" {
 						region = "—"
@@ -1077,6 +2056,7 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 						{"Region", region},
 						{"Access", b.Access},
 						{"Versioning", b.Versioning},
+						{"Default Encryption", orDash(b.Encryption)},
 						{"Created", b.CreationDate},
 						{"Policy Public", boolStr(b.PolicyPublic)},
 						{"ACL Public", boolStr(b.ACLPublic)},
@@ -1093,6 +2073,33 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 					for _, pol := range b.Policies {
 						fields = append(fields, detailField{pol.Effect + " " + pol.Sid, pol.Action + " (" + pol.Principal + ")"})
 					}
+					for _, ap := range b.AccessPoints {
+						origin := "internet"
+						if ap.VPCOnly() {
+							origin = "VPC " + ap.VpcId
+						}
+						if ap.PolicyPublic {
+							origin += ", public policy"
+						}
+						fields = append(fields, detailField{"Access Point " + ap.Name, origin})
+						for _, pol := range ap.Policies {
+							fields = append(fields, detailField{"  " + pol.Effect + " " + pol.Sid, pol.Action + " (" + pol.Principal + ")"})
+						}
+					}
+					for _, m := range s3Data.MRAPsFor(b.Name) {
+						state := m.Status
+						if m.PolicyPublic {
+							state += ", public policy"
+						}
+						fields = append(fields, detailField{"Multi-Region Access Point", m.Name + " (" + state + ")"})
+					}
+					for _, t := range trails {
+						state := "logging"
+						if !t.IsLogging {
+							state = "stopped"
+						}
+						fields = append(fields, detailField{"CloudTrail Logs", t.Name + " (" + state + ")"})
+					}
 					detail = detailData{
 						Type:   "S3",
 						Title:  b.Name,
@@ -1102,6 +2109,62 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+	case "efs", "fsx":
+		storage, _ := sawsSync.LoadStorageData(r.URL.Query().Get("region"))
+		if storage != nil && resType == "efs" {
+			for _, fs := range storage.EFS {
+				if fs.FileSystemId == resId {
+					throughput := fs.ThroughputMode
+					if fs.ThroughputMode == "provisioned" {
+						throughput = fmt.Sprintf("provisioned (%.0f MiB/s)", fs.ProvisionedMibps)
+					}
+					fields := []detailField{
+						{"File System ID", fs.FileSystemId},
+						{"State", fs.State},
+						{"Performance Mode", fs.PerformanceMode},
+						{"Throughput Mode", throughput},
+						{"Size", formatBytes(fs.SizeBytes)},
+						{"Encrypted", boolStr(fs.Encrypted)},
+						{"KMS Key", orDash(fs.KmsKeyId)},
+						{"Lifecycle Policies", orDash(strings.Join(fs.LifecyclePolicies, ", "))},
+						{"VPC ID", orDash(fs.VpcId())},
+					}
+					for _, mt := range fs.MountTargets {
+						fields = append(fields, detailField{"Mount Target " + mt.AvailabilityZone,
+							mt.SubnetId + " " + mt.IpAddress + " (" + orDash(strings.Join(mt.SecurityGroups, ", ")) + ")"})
+					}
+					detail = detailData{Type: "EFS", Title: nameOr(fs.Name, fs.FileSystemId), Fields: fields}
+					break
+				}
+			}
+		}
+		if storage != nil && resType == "fsx" {
+			for _, fs := range storage.FSx {
+				if fs.FileSystemId == resId {
+					throughput := "—"
+					if fs.Throughput > 0 {
+						throughput = fmt.Sprintf("%d MB/s", fs.Throughput)
+					}
+					detail = detailData{
+						Type:  "FSX",
+						Title: nameOr(fs.Name, fs.FileSystemId),
+						Fields: []detailField{
+							{"File System ID", fs.FileSystemId},
+							{"Type", fs.Type},
+							{"State", fs.State},
+							{"Deployment", orDash(fs.DeploymentType)},
+							{"Capacity", fmt.Sprintf("%d GiB %s", fs.StorageCapacity, fs.StorageType)},
+							{"Throughput", throughput},
+							{"DNS Name", orDash(fs.DNSName)},
+							{"VPC ID", fs.VpcId},
+							{"Subnets", strings.Join(fs.SubnetIds, ", ")},
+							{"KMS Key", orDash(fs.KmsKeyId)},
+						},
+					}
+					break
+				}
+			}
+		}
 	case "rds":
 		dbData, _ := sawsSync.LoadDatabaseData(r.URL.Query().Get("region"))
 		if dbData != nil {
@@ -1133,6 +2196,7 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							{"Status", inst.Status},
 							{"Storage", fmt.Sprintf("%d GB %s", inst.AllocatedStorage, inst.StorageType)},
 							{"Multi-AZ", boolStr(inst.MultiAZ)},
+							{"Storage Encrypted", boolStr(inst.StorageEncrypted)},
 							{"Publicly Accessible", boolStr(inst.PubliclyAccessible)},
 							{"Endpoint", endpoint},
 							{"Port", fmt.Sprintf("%d", inst.Port)},
@@ -1141,6 +2205,68 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							{"Security Groups", sgs},
 						},
 					}
+					if inst.ClusterId != "" {
+						detail.Fields = append(detail.Fields, detailField{"Cluster", inst.ClusterId})
+					}
+					if inst.ReplicaOf != "" {
+						detail.Fields = append(detail.Fields, detailField{"Replica Of", inst.ReplicaOf})
+					}
+					if len(inst.Replicas) > 0 {
+						detail.Fields = append(detail.Fields, detailField{"Read Replicas", strings.Join(inst.Replicas, ", ")})
+					}
+					break
+				}
+			}
+		}
+	case "rds-cluster", "docdb", "neptune":
+		dbData, _ := sawsSync.LoadDatabaseData(r.URL.Query().Get("region"))
+		if dbData != nil {
+			for _, c := range dbData.RDSClusters {
+				if c.ClusterId == resId {
+					fields := []detailField{
+						{"Cluster ID", c.ClusterId},
+						{"Engine", c.Engine + " " + c.EngineVersion},
+						{"Status", c.Status},
+						{"Writer", orDash(c.Writer())},
+						{"Readers", orDash(strings.Join(c.Readers(), ", "))},
+					}
+					if c.MaxACU > 0 {
+						unit := "ACU"
+						if c.Family() == "neptune" {
+							unit = "NCU"
+						}
+						fields = append(fields, detailField{"Serverless Capacity", fmt.Sprintf("%g – %g %s", c.MinACU, c.MaxACU, unit)})
+					} else if c.EngineMode != "" && c.EngineMode != "provisioned" {
+						fields = append(fields, detailField{"Engine Mode", c.EngineMode})
+					}
+					global := "—"
+					if c.GlobalClusterId != "" {
+						role := "secondary"
+						if c.GlobalPrimary {
+							role = "primary"
+						}
+						global = c.GlobalClusterId + " (" + role + ")"
+					}
+					sgs := "—"
+					if len(c.SecurityGroups) > 0 {
+						sgs = strings.Join(c.SecurityGroups, ", ")
+					}
+					fields = append(fields,
+						detailField{"Global Database", global},
+						detailField{"Multi-AZ", boolStr(c.MultiAZ)},
+						detailField{"Storage Encrypted", boolStr(c.StorageEncrypted)},
+						detailField{"Writer Endpoint", orDash(c.Endpoint)},
+						detailField{"Reader Endpoint", orDash(c.ReaderEndpoint)},
+						detailField{"Port", fmt.Sprintf("%d", c.Port)},
+						detailField{"VPC ID", orDash(c.VpcId)},
+						detailField{"Subnet Group", orDash(c.SubnetGroupName)},
+						detailField{"Security Groups", sgs},
+					)
+					detail = detailData{
+						Type:   clusterIcons[c.Family()],
+						Title:  c.ClusterId,
+						Fields: fields,
+					}
 					break
 				}
 			}
@@ -1150,17 +2276,58 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 		if dbData != nil {
 			for _, t := range dbData.DynamoDB {
 				if t.TableName == resId {
+					fields := []detailField{
+						{"Table Name", t.TableName},
+						{"Status", t.Status},
+						{"Partition Key", orDash(t.PartitionKey)},
+						{"Sort Key", orDash(t.SortKey)},
+						{"Item Count", fmt.Sprintf("%d", t.ItemCount)},
+						{"Size", formatBytes(t.SizeBytes)},
+						{"Billing Mode", t.BillingMode},
+						{"Table Class", t.TableClass},
+					}
+					if t.BillingMode == "PROVISIONED" {
+						fields = append(fields,
+							detailField{"Read Capacity", ddbCapacity(t, "", "read", t.ReadCapacity)},
+							detailField{"Write Capacity", ddbCapacity(t, "", "write", t.WriteCapacity)},
+						)
+					}
+					stream := "disabled"
+					if t.StreamViewType != "" {
+						stream = t.StreamViewType + " (" + t.StreamArn + ")"
+					}
+					ttl := orDash(t.TTLStatus)
+					if t.TTLAttribute != "" {
+						ttl += " on " + t.TTLAttribute
+					}
+					fields = append(fields,
+						detailField{"Stream", stream},
+						detailField{"TTL", ttl},
+						detailField{"Point-in-Time Recovery", orDash(t.PITR)},
+						detailField{"Deletion Protection", boolStr(t.DeletionProtection)},
+						detailField{"Encryption", ddbEncryption(t)},
+					)
+					if len(t.ReplicaRegions) > 0 {
+						fields = append(fields, detailField{"Replicas", strings.Join(t.ReplicaRegions, ", ")})
+					}
+					var indexes [][]string
+					for _, idx := range t.Indexes {
+						keys := idx.PartitionKey
+						if idx.SortKey != "" {
+							keys += " / " + idx.SortKey
+						}
+						capacity := fmt.Sprintf("%d items · %s", idx.ItemCount, formatBytes(idx.SizeBytes))
+						if idx.Kind == "GSI" && t.BillingMode == "PROVISIONED" {
+							capacity = ddbCapacity(t, idx.Name, "read", idx.ReadCapacity) + " · " + ddbCapacity(t, idx.Name, "write", idx.WriteCapacity)
+						}
+						indexes = append(indexes, []string{idx.Kind, idx.Name, keys, idx.Projection, capacity})
+					}
 					detail = detailData{
-						Type:  "DDB",
-						Title: t.TableName,
-						Fields: []detailField{
-							{"Table Name", t.TableName},
-							{"Status", t.Status},
-							{"Item Count", fmt.Sprintf("%d", t.ItemCount)},
-							{"Size", formatBytes(t.SizeBytes)},
-							{"Billing Mode", t.BillingMode},
-							{"Table Class", t.TableClass},
-						},
+						Type:       "DDB",
+						Title:      t.TableName,
+						Fields:     fields,
+						Rules:      indexes,
+						RulesTitle: "Secondary Indexes",
 					}
 					break
 				}
@@ -1190,6 +2357,59 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+	case "opensearch":
+		dwData, _ := sawsSync.LoadDataWarehouseData(r.URL.Query().Get("region"))
+		if dwData != nil {
+			for _, d := range dwData.OpenSearch {
+				if d.DomainName == resId {
+					endpoint := d.Endpoint
+					if endpoint == "" {
+						endpoint = "—"
+					}
+					vpcId := d.VpcId
+					if vpcId == "" {
+						vpcId = "—"
+					}
+					sgs := "—"
+					if len(d.SecurityGroups) > 0 {
+						sgs = strings.Join(d.SecurityGroups, ", ")
+					}
+					masters := "—"
+					if d.MasterCount > 0 {
+						masters = fmt.Sprintf("%d × %s", d.MasterCount, d.MasterType)
+					}
+					fields := []detailField{
+						{"Domain", d.DomainName},
+						{"Version", d.EngineVersion},
+						{"Status", d.Status},
+						{"Data Nodes", fmt.Sprintf("%d × %s", d.InstanceCount, d.InstanceType)},
+						{"Dedicated Masters", masters},
+					}
+					if d.WarmCount > 0 {
+						fields = append(fields, detailField{"UltraWarm Nodes", fmt.Sprintf("%d × %s", d.WarmCount, d.WarmType)})
+					}
+					fields = append(fields,
+						detailField{"Zone Awareness", boolStr(d.ZoneAwareness)},
+						detailField{"Publicly Accessible", boolStr(d.PubliclyAccessible)},
+						detailField{"Endpoint", endpoint},
+						detailField{"Encrypted at Rest", boolStr(d.EncryptedAtRest)},
+						detailField{"Node-to-Node Encryption", boolStr(d.NodeToNodeEncrypted)},
+						detailField{"Enforce HTTPS", boolStr(d.EnforceHTTPS)},
+						detailField{"VPC ID", vpcId},
+						detailField{"Security Groups", sgs},
+					)
+					if d.KmsKeyId != "" {
+						fields = append(fields, detailField{"KMS Key", d.KmsKeyId})
+					}
+					detail = detailData{
+						Type:   "OS",
+						Title:  d.DomainName,
+						Fields: fields,
+					}
+					break
+				}
+			}
+		}
 	case "redshift":
 		dwData, _ := sawsSync.LoadDataWarehouseData(r.URL.Query().Get("region"))
 		if dwData != nil {
@@ -1233,6 +2453,68 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							{"Security Groups", sgs},
 						},
 					}
+					if h := c.Health; h != nil {
+						disk := "—"
+						if h.HasDisk {
+							disk = fmt.Sprintf("%.0f%%", h.DiskUsedPct)
+							if c.DiskWarning() {
+								disk += fmt.Sprintf(" (over %d%%, near capacity)", sawsSync.RedshiftDiskWarnPct)
+							}
+						}
+						detail.Fields = append(detail.Fields, detailField{"Disk Used", disk})
+						if h.HasWLM {
+							detail.Fields = append(detail.Fields,
+								detailField{"Queued Queries", fmt.Sprint(h.QueuedQueries)},
+								detailField{"Max Queue Wait", fmt.Sprintf("%.0fs", h.MaxQueueWait)},
+								detailField{"Long-Running Queries", fmt.Sprint(h.LongRunning)},
+							)
+						} else if h.WLMError != "" {
+							detail.Fields = append(detail.Fields, detailField{"WLM State", "unavailable: " + h.WLMError})
+						}
+						detail.Fields = append(detail.Fields, detailField{"Metrics At", h.CollectedAt})
+					}
+					break
+				}
+			}
+		}
+	case "redshift-serverless":
+		dwData, _ := sawsSync.LoadDataWarehouseData(r.URL.Query().Get("region"))
+		if dwData != nil {
+			for _, wg := range dwData.RedshiftWorkgroups {
+				if wg.WorkgroupName == resId {
+					endpoint := "—"
+					if wg.Endpoint != "" {
+						endpoint = fmt.Sprintf("%s:%d", wg.Endpoint, wg.Port)
+					}
+					maxRPU := "—"
+					if wg.MaxCapacity > 0 {
+						maxRPU = fmt.Sprintf("%d RPU", wg.MaxCapacity)
+					}
+					detail = detailData{
+						Type:  "RS",
+						Title: wg.WorkgroupName,
+						Fields: []detailField{
+							{"Workgroup", wg.WorkgroupName},
+							{"Namespace", wg.NamespaceName},
+							{"Status", wg.Status},
+							{"Base Capacity", fmt.Sprintf("%d RPU", wg.BaseCapacity)},
+							{"Max Capacity", maxRPU},
+							{"Endpoint", endpoint},
+							{"Publicly Accessible", boolStr(wg.PubliclyAccessible)},
+							{"Enhanced VPC Routing", boolStr(wg.EnhancedVpcRouting)},
+							{"VPC ID", orDash(wg.VpcId)},
+							{"Subnets", orDash(strings.Join(wg.SubnetIds, ", "))},
+							{"Security Groups", orDash(strings.Join(wg.SecurityGroups, ", "))},
+						},
+					}
+					if ns := wg.Namespace; ns != nil {
+						detail.Fields = append(detail.Fields,
+							detailField{"Database", orDash(ns.DbName)},
+							detailField{"Admin User", orDash(ns.AdminUsername)},
+							detailField{"KMS Key", orDash(ns.KmsKeyId)},
+							detailField{"IAM Roles", orDash(strings.Join(ns.IamRoles, ", "))},
+						)
+					}
 					break
 				}
 			}
@@ -1255,8 +2537,29 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							{"Engine", wg.EngineVersion},
 							{"Description", desc},
 							{"Created", wg.CreationTime},
+							{"Output Location", orDash(wg.OutputLocation)},
+							{"Recently Queried", orDash(strings.Join(wg.QueriedDatabases, ", "))},
 						},
 					}
+					cutoff := "none"
+					if wg.BytesScannedCutoff > 0 {
+						cutoff = formatBytes(wg.BytesScannedCutoff) + " per query"
+					}
+					detail.Fields = append(detail.Fields, detailField{"Scan Limit", cutoff})
+					if st := wg.Recent; st != nil {
+						detail.Fields = append(detail.Fields,
+							detailField{"Recent Queries", fmt.Sprintf("%d since %s (%d succeeded, %d failed)", st.Queries, st.Since, st.Succeeded, st.Failed)},
+							detailField{"Data Scanned", formatBytes(st.BytesScanned) + " (largest " + formatBytes(st.LargestScan) + ")"},
+							detailField{"Estimated Cost", fmt.Sprintf("~$%.2f at $5/TB", st.EstimatedCost())},
+							detailField{"Engine Time", (time.Duration(st.EngineMillis) * time.Millisecond).Round(time.Second).String()},
+						)
+					}
+					if len(wg.NamedQueries) > 0 {
+						detail.RulesTitle = "Saved Queries"
+						for _, q := range wg.NamedQueries {
+							detail.Rules = append(detail.Rules, []string{q.Name, orDash(q.Database), orDash(q.Description)})
+						}
+					}
 					break
 				}
 			}
@@ -1283,51 +2586,212 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							{"Location URI", loc},
 							{"Catalog ID", db.CatalogId},
 							{"Created", db.CreateTime},
+							{"Tables", fmt.Sprint(len(db.Tables))},
 						},
 					}
+					for _, t := range db.Tables {
+						desc := t.TableType
+						if t.Classification != "" {
+							desc += " · " + t.Classification
+						}
+						if t.Location != "" {
+							desc += " · " + t.Location
+						}
+						detail.Fields = append(detail.Fields, detailField{"  " + t.Name, desc})
+					}
+					var crawlers []string
+					for _, c := range dwData.GlueCrawlers {
+						if c.DatabaseName == db.Name {
+							crawlers = append(crawlers, c.Name)
+						}
+					}
+					if len(crawlers) > 0 {
+						detail.Fields = append(detail.Fields, detailField{"Crawlers", strings.Join(crawlers, ", ")})
+					}
 					break
 				}
 			}
 		}
-	case "ec2":
-		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
-		if computeData != nil {
-			for _, inst := range computeData.EC2 {
-				if inst.InstanceId == resId {
-					publicIP := inst.PublicIP
-					if publicIP == "" {
-						publicIP = "—"
+	case "glue-crawler":
+		dwData, _ := sawsSync.LoadDataWarehouseData(r.URL.Query().Get("region"))
+		if dwData != nil {
+			for _, c := range dwData.GlueCrawlers {
+				if c.Name == resId {
+					schedule := "on demand"
+					if c.Schedule != "" {
+						schedule = c.Schedule + " (" + strings.ToLower(c.ScheduleState) + ")"
 					}
-					privateIP := inst.PrivateIP
-					if privateIP == "" {
-						privateIP = "—"
+					detail = detailData{
+						Type:  "GLUE",
+						Title: c.Name,
+						Fields: []detailField{
+							{"Crawler", c.Name},
+							{"State", c.State},
+							{"Database", orDash(c.DatabaseName)},
+							{"Schedule", schedule},
+							{"S3 Targets", orDash(strings.Join(c.S3Targets, ", "))},
+							{"IAM Role", orDash(c.Role)},
+							{"Last Run", orDash(c.LastRun)},
+							{"Last Status", orDash(c.LastStatus)},
+						},
 					}
-					vpcId := inst.VpcId
-					if vpcId == "" {
-						vpcId = "—"
+					if c.LastError != "" {
+						detail.Fields = append(detail.Fields, detailField{"Last Error", c.LastError})
 					}
-					sgs := "—"
-					if len(inst.SecurityGroups) > 0 {
-						sgs = strings.Join(inst.SecurityGroups, ", ")
+					break
+				}
+			}
+		}
+	case "glue-job":
+		dwData, _ := sawsSync.LoadDataWarehouseData(r.URL.Query().Get("region"))
+		if dwData != nil {
+			for _, j := range dwData.GlueJobs {
+				if j.Name == resId {
+					duration := "—"
+					if j.LastRunSeconds > 0 {
+						duration = (time.Duration(j.LastRunSeconds) * time.Second).String()
 					}
-					fields := []detailField{
-						{"Instance ID", inst.InstanceId},
-						{"Name", nameOr(inst.Name, "—")},
-						{"Instance Type", inst.InstanceType},
-						{"State", inst.State},
-						{"Public IP", publicIP},
-						{"Private IP", privateIP},
-						{"VPC ID", vpcId},
+					detail = detailData{
+						Type:  "GLUE",
+						Title: j.Name,
+						Fields: []detailField{
+							{"Job", j.Name},
+							{"Type", j.Type},
+							{"Glue Version", orDash(j.GlueVersion)},
+							{"Capacity", orDash(j.Capacity())},
+							{"Script", orDash(j.ScriptLocation)},
+							{"IAM Role", orDash(j.Role)},
+							{"Last Run", orDash(j.LastRun)},
+							{"Last Run State", orDash(j.LastRunState)},
+							{"Last Run Duration", duration},
+						},
+					}
+					if j.LastError != "" {
+						detail.Fields = append(detail.Fields, detailField{"Last Error", j.LastError})
+					}
+					break
+				}
+			}
+		}
+	case "ebs":
+		vols, _ := sawsSync.LoadEBSVolumes(r.URL.Query().Get("region"))
+		for _, v := range vols {
+			if v.VolumeId == resId {
+				attachment := "unattached"
+				if v.InstanceId != "" {
+					attachment = v.InstanceId + " (" + v.AttachState + ")"
+				}
+				iops, throughput := "—", "—"
+				if v.Iops > 0 {
+					iops = fmt.Sprintf("%d", v.Iops)
+				}
+				if v.Throughput > 0 {
+					throughput = fmt.Sprintf("%d MiB/s", v.Throughput)
+				}
+				detail = detailData{
+					Type:  "EBS",
+					Title: nameOr(v.Name, v.VolumeId),
+					Fields: []detailField{
+						{"Volume ID", v.VolumeId},
+						{"State", v.State},
+						{"Attachment", attachment},
+						{"Size", fmt.Sprintf("%d GiB", v.Size)},
+						{"Type", v.VolumeType},
+						{"IOPS", iops},
+						{"Throughput", throughput},
+						{"Availability Zone", v.AvailabilityZone},
+						{"Encrypted", boolStr(v.Encrypted)},
+						{"KMS Key", orDash(v.KmsKeyId)},
+						{"Created", v.CreateTime},
+					},
+				}
+				break
+			}
+		}
+	case "ebs-snapshot":
+		snaps, _ := sawsSync.LoadEBSSnapshots(r.URL.Query().Get("region"))
+		for _, s := range snaps {
+			if s.SnapshotId == resId {
+				detail = detailData{
+					Type:  "EBS",
+					Title: nameOr(s.Name, s.SnapshotId),
+					Fields: []detailField{
+						{"Snapshot ID", s.SnapshotId},
+						{"State", s.State},
+						{"Source Volume", orDash(s.VolumeId)},
+						{"Volume Size", fmt.Sprintf("%d GiB", s.VolumeSize)},
+						{"Started", s.StartTime},
+						{"Encrypted", boolStr(s.Encrypted)},
+						{"Description", orDash(s.Description)},
+					},
+				}
+				break
+			}
+		}
+	case "ec2":
+		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+		if computeData != nil {
+			for _, inst := range computeData.EC2 {
+				if inst.InstanceId == resId {
+					publicIP := inst.PublicIP
+					if publicIP == "" {
+						publicIP = "—"
+					}
+					privateIP := inst.PrivateIP
+					if privateIP == "" {
+						privateIP = "—"
+					}
+					vpcId := inst.VpcId
+					if vpcId == "" {
+						vpcId = "—"
+					}
+					sgs := "—"
+					if len(inst.SecurityGroups) > 0 {
+						sgs = strings.Join(inst.SecurityGroups, ", ")
+					}
+					fields := []detailField{
+						{"Instance ID", inst.InstanceId},
+						{"Name", nameOr(inst.Name, "—")},
+						{"Instance Type", inst.InstanceType},
+						{"State", inst.State},
+						{"Public IP", publicIP},
+						{"Private IP", privateIP},
+						{"VPC ID", vpcId},
 						{"Subnet ID", nameOr(inst.SubnetId, "—")},
 						{"Security Groups", sgs},
 						{"Launch Time", inst.LaunchTime},
 					}
+					if inst.AutoScalingGroup != "" {
+						fields = append(fields, detailField{"Auto Scaling Group", inst.AutoScalingGroup})
+					}
 					if inst.IamRole != "" {
 						fields = append(fields, detailField{"IAM Role", inst.IamRole})
 						if len(inst.IamPolicies) > 0 {
 							fields = append(fields, detailField{"IAM Policies", strings.Join(inst.IamPolicies, ", ")})
 						}
 					}
+					if m := inst.Metrics; m != nil {
+						mem := "— (no CloudWatch agent)"
+						if m.HasMemory {
+							mem = fmt.Sprintf("avg %.0f%% / max %.0f%%", m.MemoryAvg, m.MemoryMax)
+						}
+						fields = append(fields,
+							detailField{fmt.Sprintf("CPU (%dd)", m.Days), fmt.Sprintf("avg %.1f%% / max %.0f%%", m.CPUAvg, m.CPUMax)},
+							detailField{"Memory", mem},
+							detailField{"Network In/Out", formatBytes(int64(m.NetworkIn)) + " / " + formatBytes(int64(m.NetworkOut)) + " per day"},
+							detailField{"Metrics At", m.CollectedAt},
+						)
+					}
+					if rs := sawsSync.SuggestEC2Size(inst); rs != nil {
+						suggestion := "Stop outside office hours"
+						if rs.Action == "downsize" {
+							suggestion = "Downsize to " + rs.SuggestedType
+						}
+						if rs.Priced() {
+							suggestion += fmt.Sprintf(" (~$%.2f/month)", rs.MonthlySavings)
+						}
+						fields = append(fields, detailField{"Right-size", suggestion})
+					}
 					detail = detailData{
 						Type:   "EC2",
 						Title:  nameOr(inst.Name, inst.InstanceId),
@@ -1337,6 +2801,95 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+	case "asg":
+		asgData, _ := sawsSync.LoadAutoScalingData(r.URL.Query().Get("region"))
+		if asgData != nil {
+			for _, g := range asgData.Groups {
+				if g.Name == resId {
+					fields := []detailField{
+						{"Name", g.Name},
+						{"Desired / Min / Max", fmt.Sprintf("%d / %d / %d", g.DesiredCapacity, g.MinSize, g.MaxSize)},
+						{"Health Check", orDash(g.HealthCheckType)},
+						{"Launch From", orDash(g.LaunchSource())},
+						{"Subnets", orDash(strings.Join(g.SubnetIds, ", "))},
+						{"Target Groups", orDash(strings.Join(g.TargetGroupNames(), ", "))},
+					}
+					if len(g.LoadBalancerNames) > 0 {
+						fields = append(fields, detailField{"Classic ELBs", strings.Join(g.LoadBalancerNames, ", ")})
+					}
+					if mi := g.MixedInstances; mi != nil {
+						fields = append(fields,
+							detailField{"Instance Types", orDash(strings.Join(mi.InstanceTypes, ", "))},
+							detailField{"On-Demand", fmt.Sprintf("base %d, %d%% above base", mi.OnDemandBase, mi.OnDemandPercentAbove)},
+							detailField{"Spot Strategy", orDash(mi.SpotAllocationStrategy)},
+						)
+					}
+					for _, inst := range g.Instances {
+						state := inst.LifecycleState + " · " + inst.HealthStatus
+						if inst.ProtectedFromScaleIn {
+							state += " · scale-in protected"
+						}
+						fields = append(fields, detailField{"  " + inst.InstanceId, inst.InstanceType + " · " + inst.AvailabilityZone + " · " + state})
+					}
+					if g.Status != "" {
+						fields = append(fields, detailField{"Status", g.Status})
+					}
+					fields = append(fields, detailField{"Created", g.CreatedTime})
+					detail = detailData{Type: "ASG", Title: g.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "launch-template":
+		asgData, _ := sawsSync.LoadAutoScalingData(r.URL.Query().Get("region"))
+		if asgData != nil {
+			for _, lt := range asgData.LaunchTemplates {
+				if lt.Name == resId {
+					market := "on-demand"
+					if lt.Spot {
+						market = "spot"
+					}
+					detail = detailData{
+						Type:  "LT",
+						Title: lt.Name,
+						Fields: []detailField{
+							{"Template ID", lt.Id},
+							{"Default / Latest Version", fmt.Sprintf("%d / %d", lt.DefaultVersion, lt.LatestVersion)},
+							{"AMI", orDash(lt.ImageId)},
+							{"Instance Type", orDash(lt.InstanceType)},
+							{"Market", market},
+							{"Key Pair", orDash(lt.KeyName)},
+							{"Instance Profile", orDash(lt.IamProfile)},
+							{"Security Groups", orDash(strings.Join(lt.SecurityGroups, ", "))},
+							{"Created", orDash(lt.CreateTime)},
+						},
+					}
+					break
+				}
+			}
+		}
+	case "launch-config":
+		asgData, _ := sawsSync.LoadAutoScalingData(r.URL.Query().Get("region"))
+		if asgData != nil {
+			for _, lc := range asgData.LaunchConfigurations {
+				if lc.Name == resId {
+					detail = detailData{
+						Type:  "LC",
+						Title: lc.Name,
+						Fields: []detailField{
+							{"AMI", orDash(lc.ImageId)},
+							{"Instance Type", lc.InstanceType},
+							{"Spot Price", orDash(lc.SpotPrice)},
+							{"Key Pair", orDash(lc.KeyName)},
+							{"Instance Profile", orDash(lc.IamProfile)},
+							{"Security Groups", orDash(strings.Join(lc.SecurityGroups, ", "))},
+							{"Created", orDash(lc.CreatedTime)},
+						},
+					}
+					break
+				}
+			}
+		}
 	case "ecs":
 		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
 		if computeData != nil {
@@ -1429,6 +2982,23 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 									detailField{"  Desired/Running", fmt.Sprintf("%d/%d", svc.DesiredCount, svc.RunningCount)},
 									detailField{"  Network", networkMode},
 								)
+								if svc.Cpu > 0 {
+									fields = append(fields, detailField{"  Task Size", fmt.Sprintf("%d CPU / %d MiB", svc.Cpu, svc.Memory)})
+								}
+								for _, img := range svc.Images {
+									fields = append(fields, detailField{"  Image", img})
+								}
+								if m := svc.Metrics; m != nil {
+									fields = append(fields, detailField{"  Utilization", fmt.Sprintf("CPU avg %.0f%% / max %.0f%%, memory avg %.0f%% / max %.0f%% (%dd)",
+										m.CPUAvg, m.CPUMax, m.MemoryAvg, m.MemoryMax, m.Days)})
+								}
+								if rs := sawsSync.SuggestECSTaskSize(svc); rs != nil {
+									suggestion := fmt.Sprintf("%s to %d CPU / %d MiB", rs.Direction(), rs.SuggestedCpu, rs.SuggestedMemory)
+									if rs.Priced() {
+										suggestion += fmt.Sprintf(" (~$%.2f/month)", rs.MonthlySavings)
+									}
+									fields = append(fields, detailField{"  Right-size", suggestion})
+								}
 								if len(svc.SubnetIds) > 0 {
 									fields = append(fields, detailField{"  Subnets", strings.Join(svc.SubnetIds, ", ")})
 								}
@@ -1479,6 +3049,231 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+	case "ecs-service":
+		// resId is cluster/service: service names are only unique per cluster.
+		cluster, name, _ := strings.Cut(resId, "/")
+		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+		if computeData != nil {
+			for _, c := range computeData.ECS {
+				if c.ClusterName != cluster {
+					continue
+				}
+				for _, svc := range c.ECSServices {
+					if svc.ServiceName != name {
+						continue
+					}
+					networkMode := "private"
+					if svc.AssignPublicIP {
+						networkMode = "public"
+					}
+					launchType := svc.LaunchType
+					if launchType == "" {
+						launchType = "—"
+					}
+					fields := []detailField{
+						{"Service Name", svc.ServiceName},
+						{"Cluster", c.ClusterName},
+						{"Status", svc.Status},
+						{"Desired/Running", fmt.Sprintf("%d/%d", svc.DesiredCount, svc.RunningCount)},
+						{"Launch Type", launchType},
+						{"Network", networkMode},
+						{"Task Definition", svc.TaskDefinition},
+					}
+					if svc.Cpu > 0 {
+						fields = append(fields, detailField{"Task Size", fmt.Sprintf("%d CPU / %d MiB", svc.Cpu, svc.Memory)})
+					}
+					for _, img := range svc.Images {
+						fields = append(fields, detailField{"Image", img})
+					}
+					if m := svc.Metrics; m != nil {
+						fields = append(fields, detailField{"Utilization", fmt.Sprintf("CPU avg %.0f%% / max %.0f%%, memory avg %.0f%% / max %.0f%% (%dd)",
+							m.CPUAvg, m.CPUMax, m.MemoryAvg, m.MemoryMax, m.Days)})
+					}
+					if rs := sawsSync.SuggestECSTaskSize(svc); rs != nil {
+						suggestion := fmt.Sprintf("%s to %d CPU / %d MiB", rs.Direction(), rs.SuggestedCpu, rs.SuggestedMemory)
+						if rs.Priced() {
+							suggestion += fmt.Sprintf(" (~$%.2f/month)", rs.MonthlySavings)
+						}
+						fields = append(fields, detailField{"Right-size", suggestion})
+					}
+					if len(svc.SubnetIds) > 0 {
+						fields = append(fields, detailField{"Subnets", strings.Join(svc.SubnetIds, ", ")})
+					}
+					if len(svc.SecurityGroups) > 0 {
+						fields = append(fields, detailField{"Security Groups", strings.Join(svc.SecurityGroups, ", ")})
+					}
+					for _, tgArn := range svc.LBTargetGroups {
+						tgName := tgArn
+						if tgParts := strings.Split(tgArn, "/"); len(tgParts) >= 2 {
+							tgName = tgParts[1]
+						}
+						fields = append(fields, detailField{"Target Group", tgName})
+					}
+					if sc := svc.ServiceConnect; sc != nil {
+						fields = append(fields, detailField{"Service Connect", sc.Namespace})
+						for _, ep := range sc.Endpoints {
+							fields = append(fields, detailField{"  Serves", ep.Address()})
+						}
+					}
+
+					// Tasks started from the service's task definition
+					for _, task := range c.Tasks {
+						if task.TaskDefinition != svc.TaskDefinition {
+							continue
+						}
+						ip := task.PrivateIP
+						if ip == "" {
+							ip = "—"
+						}
+						fields = append(fields, detailField{"Task " + ecsTaskID(task.TaskArn), task.LastStatus + " · " + ip})
+					}
+
+					detail = detailData{
+						Type:   "ECS Service",
+						Title:  svc.ServiceName,
+						Fields: fields,
+					}
+					break
+				}
+			}
+		}
+	case "ecs-task":
+		// resId is cluster/task ID, the last part of the task ARN.
+		cluster, id, _ := strings.Cut(resId, "/")
+		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+		if computeData != nil {
+			for _, c := range computeData.ECS {
+				if c.ClusterName != cluster {
+					continue
+				}
+				for _, task := range c.Tasks {
+					if ecsTaskID(task.TaskArn) != id {
+						continue
+					}
+					fields := []detailField{
+						{"Task ID", id},
+						{"Cluster", c.ClusterName},
+						{"Status", task.LastStatus},
+					}
+					if task.LaunchType != "" {
+						fields = append(fields, detailField{"Launch Type", task.LaunchType})
+					}
+					fields = append(fields, detailField{"Task Definition", task.TaskDefinition})
+					for _, svc := range c.ECSServices {
+						if svc.TaskDefinition == task.TaskDefinition {
+							fields = append(fields, detailField{"Service", svc.ServiceName})
+						}
+					}
+					ip, pub := task.PrivateIP, task.PublicIP
+					if ip == "" {
+						ip = "—"
+					}
+					if pub == "" {
+						pub = "—"
+					}
+					fields = append(fields,
+						detailField{"Private IP", ip},
+						detailField{"Public IP", pub},
+					)
+					if task.SubnetId != "" {
+						fields = append(fields, detailField{"Subnet", task.SubnetId})
+					}
+					fields = append(fields, detailField{"Task ARN", task.TaskArn})
+
+					detail = detailData{
+						Type:   "ECS Task",
+						Title:  id,
+						Fields: fields,
+					}
+					break
+				}
+			}
+		}
+	case "apprunner":
+		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+		if computeData != nil {
+			for _, svc := range computeData.AppRunner {
+				if svc.Name == resId {
+					ingress := "private"
+					if svc.Public {
+						ingress = "public"
+					}
+					fields := []detailField{
+						{"Status", svc.Status},
+						{"URL", orDash(svc.Url)},
+						{"Instance Size", svc.Cpu + " · " + svc.Memory},
+						{"Auto Scaling", orDash(svc.AutoScalingConfig)},
+						{"Scaling Range", orDash(svc.Scaling())},
+						{"Source", orDash(svc.SourceType + " " + svc.Source)},
+						{"Auto Deploy", boolStr(svc.AutoDeploy)},
+						{"Ingress", ingress},
+						{"Egress", orDash(svc.EgressType)},
+					}
+					if svc.VpcConnector != "" {
+						fields = append(fields,
+							detailField{"VPC Connector", svc.VpcConnector},
+							detailField{"Subnets", orDash(strings.Join(svc.SubnetIds, ", "))},
+							detailField{"Security Groups", orDash(strings.Join(svc.SecurityGroups, ", "))},
+						)
+					}
+					fields = append(fields,
+						detailField{"Instance Role", orDash(svc.InstanceRole)},
+						detailField{"Created", svc.CreatedAt},
+					)
+					detail = detailData{Type: "AR", Title: svc.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "lightsail":
+		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+		if computeData != nil {
+			for _, inst := range computeData.Lightsail {
+				if inst.Name == resId {
+					publicIP := orDash(inst.PublicIP)
+					if inst.StaticIP {
+						publicIP += " (static)"
+					}
+					detail = detailData{
+						Type:  "LS",
+						Title: inst.Name,
+						Fields: []detailField{
+							{"State", inst.State},
+							{"Blueprint", inst.Blueprint},
+							{"Bundle", inst.Bundle},
+							{"Hardware", inst.Size()},
+							{"Public IP", publicIP},
+							{"Private IP", orDash(inst.PrivateIP)},
+							{"Availability Zone", inst.AvailabilityZone},
+							{"Created", inst.CreatedAt},
+						},
+					}
+					break
+				}
+			}
+		}
+	case "lightsail-container":
+		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
+		if computeData != nil {
+			for _, c := range computeData.LightsailContainers {
+				if c.Name == resId {
+					detail = detailData{
+						Type:  "LS",
+						Title: c.Name,
+						Fields: []detailField{
+							{"State", c.State},
+							{"Power", c.Power + " (" + c.Size() + " per node)"},
+							{"Scale", fmt.Sprintf("%d nodes (fixed, no auto scaling)", c.Scale)},
+							{"URL", orDash(c.Url)},
+							{"Images", orDash(strings.Join(c.Images, ", "))},
+							{"Disabled", boolStr(c.Disabled)},
+							{"Created", c.CreatedAt},
+						},
+					}
+					break
+				}
+			}
+		}
 	case "lambda":
 		computeData, _ := sawsSync.LoadComputeData(r.URL.Query().Get("region"))
 		if computeData != nil {
@@ -1500,6 +3295,9 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							fields = append(fields, detailField{"IAM Policies", strings.Join(fn.IamPolicies, ", ")})
 						}
 					}
+					if fn.ImageUri != "" {
+						fields = append(fields, detailField{"Image", fn.ImageUri})
+					}
 					if fn.FunctionUrl != "" {
 						fields = append(fields, detailField{"Function URL", fn.FunctionUrl})
 					}
@@ -1515,6 +3313,34 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 							fields = append(fields, detailField{"Security Groups", strings.Join(fn.SecurityGroups, ", ")})
 						}
 					}
+					if fn.ReservedConcurrency != nil {
+						fields = append(fields, detailField{"Reserved Concurrency", fmt.Sprint(*fn.ReservedConcurrency)})
+					} else {
+						fields = append(fields, detailField{"Reserved Concurrency", "— (unreserved, shares the account pool)"})
+					}
+					for _, p := range fn.Provisioned {
+						state := fmt.Sprintf("%d requested · %d allocated · %s", p.Requested, p.Allocated, p.Status)
+						if p.StatusReason != "" {
+							state += " (" + p.StatusReason + ")"
+						}
+						fields = append(fields, detailField{"Provisioned Concurrency " + p.Qualifier, state})
+					}
+					for _, a := range fn.Aliases {
+						fields = append(fields, detailField{"Alias " + a.Name, "→ " + a.Routing()})
+					}
+					if n := len(fn.Versions); n > 0 {
+						latest := fn.Versions[n-1]
+						fields = append(fields, detailField{"Versions", fmt.Sprintf("%d published, latest %s (%s)", n, latest.Version, latest.LastModified)})
+					}
+					if m := fn.Metrics; m != nil {
+						fields = append(fields,
+							detailField{"Invocations (24h)", fmt.Sprintf("%.0f", m.Invocations)},
+							detailField{"Errors (24h)", fmt.Sprintf("%.0f (%.1f%%)", m.Errors, m.ErrorRate())},
+							detailField{"Throttles (24h)", fmt.Sprintf("%.0f", m.Throttles)},
+							detailField{"Duration p95", fmt.Sprintf("%.0f ms", m.DurationP95)},
+							detailField{"Metrics At", m.CollectedAt},
+						)
+					}
 					detail = detailData{
 						Type:   "LN",
 						Title:  fn.FunctionName,
@@ -1540,11 +3366,19 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 						{"Visibility Timeout", q.VisibilityTimeout + "s"},
 						{"Max Message Size", q.MaxMessageSize},
 						{"FIFO", boolStr(q.IsFIFO)},
+						{"Encryption", orDash(q.Encryption)},
 						{"Created", q.CreatedTimestamp},
 					}
-					if q.RedrivePolicy != "" {
+					switch {
+					case q.DLQArn != "":
+						fields = append(fields, detailField{"Dead Letter Queue", q.DLQArn},
+							detailField{"Max Receives", fmt.Sprint(q.MaxReceiveCount)})
+					case q.RedrivePolicy != "":
 						fields = append(fields, detailField{"Dead Letter Queue", q.RedrivePolicy})
 					}
+					if len(q.DLQFor) > 0 {
+						fields = append(fields, detailField{"Dead Letter Queue For", strings.Join(q.DLQFor, ", ")})
+					}
 					for _, pol := range q.Policies {
 						fields = append(fields, detailField{pol.Effect + " " + pol.Sid, pol.Action + " (" + pol.Principal + ")"})
 					}
@@ -1571,14 +3405,17 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 						{"ARN", t.TopicArn},
 						{"Display Name", displayName},
 						{"Subscriptions", fmt.Sprintf("%d", t.Subscriptions)},
+						{"KMS Key", orDash(t.KmsKeyId)},
 					}
 					for _, pol := range t.Policies {
 						fields = append(fields, detailField{pol.Effect + " " + pol.Sid, pol.Action + " (" + pol.Principal + ")"})
 					}
 					detail = detailData{
-						Type:   "SNS",
-						Title:  t.Name,
-						Fields: fields,
+						Type:       "SNS",
+						Title:      t.Name,
+						Fields:     fields,
+						RulesTitle: "Fan-out",
+						Rules:      snsFanout(t, streamData.SQS),
 					}
 					break
 				}
@@ -1607,6 +3444,129 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
+	case "msk":
+		streamData, _ := sawsSync.LoadStreamingData(r.URL.Query().Get("region"))
+		if streamData != nil {
+			for _, c := range streamData.MSK {
+				if c.ClusterName == resId {
+					atRest := "AWS-owned key"
+					if c.KmsKeyId != "" {
+						atRest = c.KmsKeyId
+					}
+					fields := []detailField{
+						{"Cluster Name", c.ClusterName},
+						{"ARN", c.ClusterArn},
+						{"Type", c.ClusterType},
+						{"State", c.State},
+					}
+					if c.ClusterType != "SERVERLESS" {
+						fields = append(fields,
+							detailField{"Kafka Version", c.KafkaVersion},
+							detailField{"Brokers", fmt.Sprintf("%d × %s", c.BrokerCount, c.InstanceType)},
+							detailField{"Public Access", boolStr(c.PublicAccess)},
+						)
+					}
+					fields = append(fields,
+						detailField{"Encryption at Rest", atRest},
+						detailField{"Client-Broker Encryption", c.ClientBroker},
+						detailField{"In-Cluster TLS", boolStr(c.InClusterTLS)},
+						detailField{"Client Subnets", strings.Join(c.ClientSubnets, ", ")},
+						detailField{"Security Groups", strings.Join(c.SecurityGroups, ", ")},
+					)
+					detail = detailData{
+						Type:   "MSK",
+						Title:  c.ClusterName,
+						Fields: fields,
+					}
+					break
+				}
+			}
+		}
+	case "firehose":
+		streamData, _ := sawsSync.LoadStreamingData(r.URL.Query().Get("region"))
+		if streamData != nil {
+			for _, f := range streamData.Firehose {
+				if f.Name == resId {
+					source := "Direct PUT"
+					if f.SourceType != "" {
+						source = f.SourceType + "/" + f.SourceId
+					}
+					fields := []detailField{
+						{"Stream Name", f.Name},
+						{"ARN", f.Arn},
+						{"Status", f.Status},
+						{"Source", source},
+						{"Destination", f.DestinationType + "/" + f.DestinationId},
+						{"Backup Bucket", orDash(f.BackupBucket)},
+					}
+					if f.ErrorBucket != "" {
+						errors := fmt.Sprint(f.ErrorObjects)
+						switch {
+						case f.ErrorObjects < 0:
+							errors = "could not list"
+						case f.ErrorObjectsMore:
+							errors += "+"
+						}
+						fields = append(fields,
+							detailField{"Error Output", "s3://" + f.ErrorBucket + "/" + f.ErrorPrefix},
+							detailField{"Error Objects", errors},
+						)
+						if f.LastErrorObject != "" {
+							fields = append(fields, detailField{"Last Error Object", f.LastErrorObject})
+						}
+					}
+					if m := f.Metrics; m != nil {
+						success := "-"
+						if m.HasDelivery {
+							success = fmt.Sprintf("%.2f%%", m.SuccessPercent())
+						}
+						fields = append(fields,
+							detailField{"Incoming Records (24h)", fmt.Sprintf("%.0f", m.IncomingRecords)},
+							detailField{"Delivery Success (24h)", success},
+							detailField{"Max Data Freshness", fmt.Sprintf("%.0f s", m.DataFreshness)},
+							detailField{"Throttled Records (24h)", fmt.Sprintf("%.0f", m.ThrottledRecords)},
+							detailField{"Metrics At", m.CollectedAt},
+						)
+					}
+					detail = detailData{
+						Type:   "FH",
+						Title:  f.Name,
+						Fields: fields,
+					}
+					break
+				}
+			}
+		}
+	case "mq":
+		streamData, _ := sawsSync.LoadStreamingData(r.URL.Query().Get("region"))
+		if streamData != nil {
+			for _, b := range streamData.MQ {
+				if b.BrokerId == resId {
+					atRest := "AWS-owned key"
+					if b.KmsKeyId != "" {
+						atRest = b.KmsKeyId
+					}
+					detail = detailData{
+						Type:  "MQ",
+						Title: b.BrokerName,
+						Fields: []detailField{
+							{"Broker Name", b.BrokerName},
+							{"Broker ID", b.BrokerId},
+							{"ARN", b.BrokerArn},
+							{"State", b.State},
+							{"Engine", b.EngineType + " " + b.EngineVersion},
+							{"Instance Type", b.InstanceType},
+							{"Deployment Mode", b.DeploymentMode},
+							{"Publicly Accessible", boolStr(b.PubliclyAccessible)},
+							{"Encryption at Rest", atRest},
+							{"Subnets", strings.Join(b.SubnetIds, ", ")},
+							{"Security Groups", strings.Join(b.SecurityGroups, ", ")},
+						},
+					}
+					break
+				}
+			}
+		}
 	case "eventbridge":
 		streamData, _ := sawsSync.LoadStreamingData(r.URL.Query().Get("region"))
 		if streamData != nil {
@@ -1618,9 +3578,11 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 						{"Rules", fmt.Sprintf("%d", len(b.Rules))},
 					}
 					detail = detailData{
-						Type:   "EB",
-						Title:  b.Name,
-						Fields: fields,
+						Type:       "EB",
+						Title:      b.Name,
+						Fields:     fields,
+						RulesTitle: "Rule Targets",
+						Rules:      eventBridgeTargets(b),
 					}
 					break
 				}
@@ -1686,110 +3648,861 @@ func handleDetail(w http.ResponseWriter, r *http.Request) {
 				}
 			}
 		}
-	case "sagemaker-model":
-		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
-		if aiData != nil {
-			for _, m := range aiData.SageMakerModels {
-				if m.Name == resId {
-					fields := []detailField{
-						{"Model Name", m.Name},
-						{"Created", m.CreationTime},
-					}
-					if m.RoleName != "" {
-						fields = append(fields, detailField{"IAM Role", m.RoleName})
-					}
-					detail = detailData{
-						Type:   "SM",
-						Title:  m.Name,
-						Fields: fields,
-					}
-					break
+	case "sagemaker-model":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, m := range aiData.SageMakerModels {
+				if m.Name == resId {
+					fields := []detailField{
+						{"Model Name", m.Name},
+						{"Created", m.CreationTime},
+					}
+					if m.RoleName != "" {
+						fields = append(fields, detailField{"IAM Role", m.RoleName})
+					}
+					detail = detailData{
+						Type:   "SM",
+						Title:  m.Name,
+						Fields: fields,
+					}
+					break
+				}
+			}
+		}
+	case "sagemaker-training-job":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, j := range aiData.TrainingJobs {
+				if j.Name == resId {
+					status := j.Status
+					if j.SecondaryStatus != "" && j.SecondaryStatus != j.Status {
+						status += " · " + j.SecondaryStatus
+					}
+					fields := []detailField{
+						{"Status", status},
+						{"Instances", orDash(j.Capacity())},
+						{"Managed Spot", boolStr(j.Spot)},
+						{"Training Time", orDash(j.Duration())},
+					}
+					if j.BillableSeconds > 0 {
+						fields = append(fields, detailField{"Billable Time", (time.Duration(j.BillableSeconds) * time.Second).String()})
+					}
+					fields = append(fields,
+						detailField{"Created", j.CreationTime},
+						detailField{"Ended", orDash(j.EndTime)},
+						detailField{"IAM Role", orDash(j.RoleName)},
+						detailField{"Output", orDash(j.OutputPath)},
+					)
+					if j.FailureReason != "" {
+						fields = append(fields, detailField{"Failure Reason", j.FailureReason})
+					}
+					detail = detailData{Type: "SM", Title: j.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "sagemaker-pipeline":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, p := range aiData.Pipelines {
+				if p.Name == resId {
+					fields := []detailField{
+						{"Display Name", orDash(p.DisplayName)},
+						{"Created", p.CreationTime},
+						{"Last Execution", orDash(p.LastExecution)},
+						{"Last Status", orDash(p.LastStatus)},
+					}
+					if p.LastFailureReason != "" {
+						fields = append(fields, detailField{"Failure Reason", p.LastFailureReason})
+					}
+					detail = detailData{Type: "SM", Title: p.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "sagemaker-domain":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, d := range aiData.Domains {
+				if d.Id == resId {
+					fields := []detailField{
+						{"Domain ID", d.Id},
+						{"Status", d.Status},
+						{"Auth Mode", orDash(d.AuthMode)},
+						{"Network Access", orDash(d.NetworkAccess)},
+						{"VPC", orDash(d.VpcId)},
+						{"Subnets", orDash(strings.Join(d.SubnetIds, ", "))},
+						{"Execution Role", orDash(d.RoleName)},
+						{"Created", d.CreationTime},
+					}
+					if d.Url != "" {
+						fields = append(fields, detailField{"URL", d.Url})
+					}
+					fields = append(fields, detailField{"User Profiles", orDash(strings.Join(d.UserProfiles, ", "))})
+					detail = detailData{Type: "SM", Title: d.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "bedrock-agent":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, a := range aiData.BedrockAgents {
+				if a.Id == resId {
+					guardrail := "—"
+					if a.GuardrailId != "" {
+						guardrail = a.GuardrailId + " (version " + a.GuardrailVersion + ")"
+					}
+					fields := []detailField{
+						{"Agent ID", a.Id},
+						{"Status", a.Status},
+						{"Model", orDash(a.FoundationModel)},
+						{"Guardrail", guardrail},
+						{"Knowledge Bases", orDash(strings.Join(a.KnowledgeBaseIds, ", "))},
+						{"Session Timeout", fmt.Sprintf("%ds", a.IdleSessionTTL)},
+						{"IAM Role", orDash(a.RoleName)},
+						{"Updated", orDash(a.UpdatedAt)},
+					}
+					if a.Description != "" {
+						fields = append(fields, detailField{"Description", a.Description})
+					}
+					detail = detailData{Type: "BR", Title: a.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "bedrock-kb":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, kb := range aiData.KnowledgeBases {
+				if kb.Id == resId {
+					fields := []detailField{
+						{"Knowledge Base ID", kb.Id},
+						{"Status", kb.Status},
+						{"Embedding Model", orDash(kb.EmbeddingModel)},
+						{"Vector Store", orDash(kb.StorageType)},
+						{"Store", orDash(kb.VectorStore)},
+						{"Index", orDash(kb.VectorIndex)},
+						{"IAM Role", orDash(kb.RoleName)},
+						{"Updated", orDash(kb.UpdatedAt)},
+						{"Data Sources", fmt.Sprintf("%d", len(kb.DataSources))},
+					}
+					for _, ds := range kb.DataSources {
+						source := ds.Type
+						if ds.Bucket != "" {
+							source += " · s3://" + ds.Bucket
+							if len(ds.Prefixes) > 0 {
+								source += " (" + strings.Join(ds.Prefixes, ", ") + ")"
+							}
+						}
+						fields = append(fields, detailField{"  " + nameOr(ds.Name, ds.Id), orDash(source) + " · " + orDash(ds.Status)})
+					}
+					detail = detailData{Type: "BR", Title: kb.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "bedrock-guardrail":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, g := range aiData.Guardrails {
+				if g.Id == resId {
+					detail = detailData{
+						Type:  "BR",
+						Title: g.Name,
+						Fields: []detailField{
+							{"Guardrail ID", g.Id},
+							{"Status", g.Status},
+							{"Version", orDash(g.Version)},
+							{"Policies", orDash(strings.Join(g.Policies, ", "))},
+							{"Blocked Message", orDash(g.BlockedMessage)},
+							{"KMS Key", orDash(g.KmsKeyId)},
+							{"Updated", orDash(g.UpdatedAt)},
+						},
+					}
+					break
+				}
+			}
+		}
+	case "bedrock-provisioned":
+		aiData, _ := sawsSync.LoadAIData(r.URL.Query().Get("region"))
+		if aiData != nil {
+			for _, p := range aiData.ProvisionedThroughput {
+				if p.Name == resId {
+					commitment := "No commitment"
+					if p.Commitment != "" {
+						commitment = p.Commitment + ", expires " + p.CommitmentExpires
+					}
+					detail = detailData{
+						Type:  "BR",
+						Title: p.Name,
+						Fields: []detailField{
+							{"Status", p.Status},
+							{"Model", p.Model},
+							{"Model Units", fmt.Sprintf("%d", p.ModelUnits)},
+							{"Commitment", commitment},
+							{"Created", p.CreationTime},
+							{"ARN", p.Arn},
+						},
+					}
+					break
+				}
+			}
+		}
+	case "codepipeline":
+		cicdData, _ := sawsSync.LoadCICDData(r.URL.Query().Get("region"))
+		if cicdData != nil {
+			for _, p := range cicdData.Pipelines {
+				if p.Name == resId {
+					fields := []detailField{
+						{"Last Execution", orDash(p.LastStatus)},
+						{"Started", orDash(p.LastStarted)},
+						{"Trigger", orDash(p.LastTrigger)},
+						{"Revision", orDash(p.LastRevision)},
+						{"Type", orDash(p.PipelineType)},
+						{"Execution Mode", orDash(p.ExecutionMode)},
+						{"Version", fmt.Sprintf("%d", p.Version)},
+						{"Artifact Store", orDash(p.ArtifactStore)},
+						{"Role", orDash(p.RoleName)},
+						{"Updated", orDash(p.Updated)},
+					}
+					for _, st := range p.Stages {
+						fields = append(fields, detailField{"Stage " + st.Name, orDash(st.Status)})
+						for _, a := range st.Actions {
+							value := a.Category + " · " + a.Provider
+							if a.Target != "" {
+								value += " → " + a.Target
+							}
+							fields = append(fields, detailField{"  " + a.Name, value})
+						}
+					}
+					detail = detailData{Type: "CP", Title: p.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "codebuild":
+		cicdData, _ := sawsSync.LoadCICDData(r.URL.Query().Get("region"))
+		if cicdData != nil {
+			for _, p := range cicdData.Projects {
+				if p.Name == resId {
+					fields := []detailField{
+						{"Description", orDash(p.Description)},
+						{"Source", orDash(strings.TrimSpace(p.SourceType + " " + p.SourceLocation))},
+						{"Image", orDash(p.Image)},
+						{"Environment", orDash(p.Environment)},
+						{"Compute Type", orDash(p.ComputeType)},
+						{"Privileged", boolStr(p.Privileged)},
+						{"Timeout", fmt.Sprintf("%d min", p.TimeoutMinutes)},
+						{"Service Role", orDash(p.RoleName)},
+					}
+					if p.VpcId != "" {
+						fields = append(fields,
+							detailField{"VPC", p.VpcId},
+							detailField{"Subnets", orDash(strings.Join(p.SubnetIds, ", "))},
+							detailField{"Security Groups", orDash(strings.Join(p.SecurityGroups, ", "))},
+						)
+					}
+					if b := p.LastBuild; b != nil {
+						fields = append(fields,
+							detailField{"Last Build", fmt.Sprintf("#%d %s", b.Number, b.Status)},
+							detailField{"  Started", orDash(b.Started)},
+							detailField{"  Duration", orDash(b.Duration())},
+							detailField{"  Source Version", orDash(b.SourceVersion)},
+							detailField{"  Initiator", orDash(b.Initiator)},
+						)
+					} else {
+						fields = append(fields, detailField{"Last Build", "—"})
+					}
+					fields = append(fields, detailField{"ARN", p.Arn})
+					detail = detailData{Type: "CB", Title: p.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "cfn-stack":
+		stacks, _ := sawsSync.LoadCFStacks(region)
+		for _, s := range stacks {
+			if s.Name == resId {
+				fields := []detailField{
+					{"Status", s.Status},
+					{"Reason", orDash(s.StatusReason)},
+					{"Description", orDash(s.Description)},
+					{"Drift", orDash(s.Drift)},
+					{"Parent Stack", orDash(s.Parent)},
+					{"Created", orDash(s.Created)},
+					{"Updated", orDash(s.Updated)},
+				}
+				for _, p := range s.Parameters {
+					fields = append(fields, detailField{"Param " + p.Key, orDash(p.Value)})
+				}
+				fields = append(fields, detailField{"Stack ID", s.Id})
+				var resources, outputs [][]string
+				for _, res := range s.Resources {
+					resources = append(resources, []string{res.LogicalId, res.Type, orDash(res.PhysicalId), res.Status})
+				}
+				for _, o := range s.Outputs {
+					export := "—"
+					if o.Export != "" {
+						export = "export " + o.Export
+					}
+					outputs = append(outputs, []string{o.Key, o.Value, export})
+				}
+				detail = detailData{
+					Type:          "CFN",
+					Title:         s.Name,
+					Fields:        fields,
+					Rules:         resources,
+					RulesTitle:    fmt.Sprintf("Resources (%d)", len(resources)),
+					Outbound:      outputs,
+					OutboundTitle: "Outputs",
+				}
+				break
+			}
+		}
+	case "iam-role":
+		iamData, _ := sawsSync.LoadIAMData()
+		if iamData != nil {
+			for _, role := range iamData.Roles {
+				if role.RoleName == resId {
+					policies := "—"
+					if len(role.AttachedPolicies) > 0 {
+						policies = strings.Join(role.AttachedPolicies, ", ")
+					}
+					inline := "—"
+					if len(role.InlinePolicies) > 0 {
+						inline = strings.Join(role.InlinePolicies, ", ")
+					}
+					fields := []detailField{
+						{"Role Name", role.RoleName},
+						{"Role ID", role.RoleId},
+						{"ARN", role.Arn},
+						{"Created", role.CreateDate},
+					}
+					if role.Description != "" {
+						fields = append(fields, detailField{"Description", role.Description})
+					}
+					fields = append(fields,
+						detailField{"Service Linked", boolStr(role.IsServiceLinked)},
+						detailField{"Attached Policies", policies},
+						detailField{"Inline Policies", inline},
+					)
+					for _, tp := range role.TrustPolicy {
+						fields = append(fields, detailField{tp.Effect + " " + tp.Sid, tp.Action + " (" + tp.Principal + ")"})
+					}
+					detail = detailData{
+						Type:   "ROLE",
+						Title:  role.RoleName,
+						Fields: fields,
+					}
+					break
+				}
+			}
+		}
+	case "iam-group":
+		iamData, _ := sawsSync.LoadIAMData()
+		if iamData != nil {
+			for _, g := range iamData.Groups {
+				if g.GroupName == resId {
+					policies := "—"
+					if len(g.AttachedPolicies) > 0 {
+						policies = strings.Join(g.AttachedPolicies, ", ")
+					}
+					inline := "—"
+					if len(g.InlinePolicies) > 0 {
+						inline = strings.Join(g.InlinePolicies, ", ")
+					}
+					members := "—"
+					if len(g.Members) > 0 {
+						members = strings.Join(g.Members, ", ")
+					}
+					detail = detailData{
+						Type:  "GRP",
+						Title: g.GroupName,
+						Fields: []detailField{
+							{"Group Name", g.GroupName},
+							{"Group ID", g.GroupId},
+							{"ARN", g.Arn},
+							{"Created", g.CreateDate},
+							{"Attached Policies", policies},
+							{"Inline Policies", inline},
+							{"Members", members},
+						},
+					}
+					break
+				}
+			}
+		}
+	case "iam-user":
+		iamData, _ := sawsSync.LoadIAMData()
+		if iamData != nil {
+			now := time.Now()
+			for _, u := range iamData.Users {
+				if u.UserName == resId {
+					console := "No"
+					if u.ConsoleAccess {
+						console = "Yes, password last used " + orDash(formatIAMTime(u.PasswordLastUsed))
+						if u.PasswordLastUsed == "" {
+							console = "Yes, password never used"
+						}
+					}
+					mfa := "None"
+					if len(u.MFADevices) > 0 {
+						mfa = strings.Join(u.MFADevices, ", ")
+					}
+					if u.ConsoleWithoutMFA() {
+						mfa += " ⚠ console sign-in without MFA"
+					}
+					fields := []detailField{
+						{"User Name", u.UserName},
+						{"User ID", orDash(u.UserId)},
+						{"ARN", u.Arn},
+						{"Created", formatIAMTime(u.Created)},
+						{"Console Access", console},
+						{"MFA", mfa},
+						{"Groups", orDash(strings.Join(u.Groups, ", "))},
+						{"Attached Policies", orDash(strings.Join(u.AttachedPolicies, ", "))},
+						{"Inline Policies", orDash(strings.Join(u.InlinePolicies, ", "))},
+					}
+					if len(u.AccessKeys) == 0 {
+						fields = append(fields, detailField{"Access Keys", "—"})
+					}
+					for _, k := range u.AccessKeys {
+						value := fmt.Sprintf("%s, %d days old", k.Status, k.AgeDays(now))
+						if k.Stale(now) {
+							value += " ⚠ stale"
+						}
+						fields = append(fields, detailField{"Access Key " + k.AccessKeyId, value})
+						lastUsed := "never"
+						if k.LastUsed != "" {
+							lastUsed = fmt.Sprintf("%s (%d days ago)", formatIAMTime(k.LastUsed), k.IdleDays(now))
+							if k.LastUsedService != "" {
+								lastUsed += " · " + k.LastUsedService
+							}
+							if k.LastUsedRegion != "" {
+								lastUsed += " in " + k.LastUsedRegion
+							}
+						}
+						fields = append(fields, detailField{"  Last Used", lastUsed})
+					}
+					detail = detailData{Type: "USR", Title: u.UserName, Fields: fields}
+					break
+				}
+			}
+		}
+	case "iam-provider":
+		iamData, _ := sawsSync.LoadIAMData()
+		if iamData != nil {
+			for _, p := range iamData.Providers {
+				if p.Arn == resId {
+					fields := []detailField{
+						{"Type", p.Type},
+						{"ARN", p.Arn},
+					}
+					if p.Type == "OIDC" {
+						fields = append(fields,
+							detailField{"Issuer", p.Name},
+							detailField{"Audiences", orDash(strings.Join(p.Audiences, ", "))},
+							detailField{"Thumbprints", orDash(strings.Join(p.Thumbprints, ", "))},
+						)
+					} else {
+						fields = append(fields, detailField{"Metadata Valid Until", orDash(p.ValidUntil)})
+					}
+					fields = append(fields, detailField{"Created", orDash(p.Created)})
+					if len(p.TrustingRoles) == 0 {
+						fields = append(fields, detailField{"Trusted By", "— (no role trusts this provider)"})
+					}
+					for _, t := range p.TrustingRoles {
+						fields = append(fields, detailField{"Trusted By", t.RoleName})
+						for _, c := range t.Conditions {
+							fields = append(fields, detailField{"  Condition", c})
+						}
+						if len(t.Conditions) == 0 {
+							fields = append(fields, detailField{"  Condition", "none — any federated identity can assume this role"})
+						}
+					}
+					detail = detailData{Type: "FED", Title: p.Name, Fields: fields}
+					break
+				}
+			}
+		}
+	case "iam-policy":
+		policies, _ := sawsSync.LoadIAMPolicies()
+		for _, p := range policies {
+			if p.ID() == resId {
+				fields := []detailField{
+					{"Policy Name", p.Name},
+					{"Type", p.Kind},
+				}
+				if p.Owner != "" {
+					fields = append(fields, detailField{"Embedded In", p.Owner})
+				} else {
+					fields = append(fields,
+						detailField{"ARN", p.Arn},
+						detailField{"Default Version", orDash(p.VersionId)},
+					)
+				}
+				fields = append(fields, detailField{"Statements", fmt.Sprintf("%d", len(p.Statements))})
+				detail = detailData{Type: "POL", Title: p.Name, Fields: fields, Statements: p.Statements, Document: p.Document}
+				break
+			}
+		}
+	case "secret":
+		secrets, _ := sawsSync.LoadSecrets(region)
+		for _, sec := range secrets {
+			if sec.Name == resId {
+				rotation := "disabled"
+				if sec.RotationEnabled {
+					rotation = fmt.Sprintf("every %d days", sec.RotationDays)
+				}
+				detail = detailData{
+					Type:  "SEC",
+					Title: sec.Name,
+					Fields: []detailField{
+						{"Name", sec.Name},
+						{"ARN", sec.Arn},
+						{"Description", orDash(sec.Description)},
+						{"KMS Key", nameOr(sec.KmsKeyId, "aws/secretsmanager")},
+						{"Rotation", rotation},
+						{"Rotation Lambda", orDash(sec.RotationLambda)},
+						{"Last Rotated", nameOr(sec.LastRotated, "never")},
+						{"Last Accessed", nameOr(sec.LastAccessed, "never")},
+						{"Last Changed", orDash(sec.LastChanged)},
+						{"Created", orDash(sec.Created)},
+					},
+				}
+				break
+			}
+		}
+	case "cloudtrail":
+		trails, _ := sawsSync.LoadTrails(region)
+		for _, t := range trails {
+			if t.Name != resId {
+				continue
+			}
+			scope := "home region only"
+			if t.IsMultiRegion {
+				scope = "all regions"
+			}
+			if t.IsOrganization {
+				scope += ", organization"
+			}
+			logging := boolStr(t.IsLogging)
+			if t.LatestDeliveryErr != "" {
+				logging += " · " + t.LatestDeliveryErr
+			}
+			bucket := orDash(t.S3BucketName)
+			if t.S3KeyPrefix != "" {
+				bucket += "/" + t.S3KeyPrefix
+			}
+			detail = detailData{
+				Type:  "CT",
+				Title: t.Name,
+				Fields: []detailField{
+					{"Trail", t.Name},
+					{"ARN", t.Arn},
+					{"Home Region", orDash(t.HomeRegion)},
+					{"Records", scope},
+					{"Logging", logging},
+					{"Last Delivery", orDash(t.LatestDelivery)},
+					{"S3 Bucket", bucket},
+					{"KMS Key", nameOr(t.KmsKeyId, "none (SSE-S3)")},
+					{"Log File Validation", boolStr(t.LogFileValidation)},
+					{"CloudWatch Logs", orDash(t.CloudWatchLogGroup)},
+				},
+			}
+			break
+		}
+	case "access-analyzer":
+		findings, _ := sawsSync.LoadExternalAccess(region)
+		for _, f := range findings {
+			if f.Id != resId {
+				continue
+			}
+			var conditions []string
+			for k, v := range f.Conditions {
+				conditions = append(conditions, k+" = "+v)
+			}
+			sort.Strings(conditions)
+			detail = detailData{
+				Type:  "AA",
+				Title: f.DetailID(),
+				Fields: []detailField{
+					{"Resource", f.Resource},
+					{"Resource Type", f.ResourceType},
+					{"Granted To", f.Grantee()},
+					{"Public", boolStr(f.IsPublic)},
+					{"Actions", orDash(strings.Join(f.Actions, ", "))},
+					{"Conditions", orDash(strings.Join(conditions, "; "))},
+					{"Analyzer", orDash(f.Analyzer)},
+					{"Updated", orDash(f.UpdatedAt)},
+				},
+			}
+			break
+		}
+	case "config-rule":
+		cfg, _ := sawsSync.LoadConfigData(region)
+		if cfg != nil {
+			for _, rule := range cfg.Rules {
+				if rule.Name != resId {
+					continue
+				}
+				count := fmt.Sprint(rule.NoncompliantCount)
+				if rule.CapExceeded {
+					count += "+"
+				}
+				fields := []detailField{
+					{"Rule", rule.Name},
+					{"ARN", orDash(rule.Arn)},
+					{"Source", orDash(rule.Source)},
+					{"State", orDash(rule.State)},
+					{"Compliance", nameOr(rule.Compliance, "not evaluated")},
+					{"Noncompliant Resources", count},
+				}
+				for _, e := range cfg.EvaluationsForRule(rule.Name) {
+					fields = append(fields, detailField{"  " + e.ResourceType, e.ResourceId})
+				}
+				detail = detailData{Type: "CFG", Title: rule.Name, Fields: fields}
+				break
+			}
+		}
+	case "guardduty":
+		gd, _ := sawsSync.LoadGuardDutyData(region)
+		if gd != nil {
+			for _, f := range gd.Findings {
+				if f.Id != resId {
+					continue
+				}
+				fields := []detailField{
+					{"Finding ID", f.Id},
+					{"Type", f.Type},
+					{"Severity", fmt.Sprintf("%s (%.1f)", f.SeverityLabel(), f.Severity)},
+					{"Description", orDash(f.Description)},
+					{"Resource Type", orDash(f.ResourceType)},
+					{"Resource", f.Resource()},
 				}
+				if f.UserType != "" {
+					fields = append(fields, detailField{"  User Type", f.UserType})
+				}
+				if f.Link != nil {
+					fields = append(fields, detailField{"Cached As", f.Link.Type + " " + f.Link.Label})
+				}
+				fields = append(fields,
+					detailField{"Occurrences", fmt.Sprint(f.Count)},
+					detailField{"First Seen", orDash(f.FirstSeen)},
+					detailField{"Last Seen", orDash(f.LastSeen)},
+				)
+				detail = detailData{Type: "GD", Title: f.Title, Fields: fields}
+				break
 			}
 		}
-	case "iam-role":
-		iamData, _ := sawsSync.LoadIAMData()
-		if iamData != nil {
-			for _, role := range iamData.Roles {
-				if role.RoleName == resId {
-					policies := "—"
-					if len(role.AttachedPolicies) > 0 {
-						policies = strings.Join(role.AttachedPolicies, ", ")
-					}
-					inline := "—"
-					if len(role.InlinePolicies) > 0 {
-						inline = strings.Join(role.InlinePolicies, ", ")
-					}
-					fields := []detailField{
-						{"Role Name", role.RoleName},
-						{"Role ID", role.RoleId},
-						{"ARN", role.Arn},
-						{"Created", role.CreateDate},
+	case "kms":
+		usage, _ := sawsSync.KeyUsage(region)
+		for _, u := range usage {
+			if u.Matches(resId) {
+				state := u.Key.KeyState
+				if state == "PendingDeletion" {
+					state += " (" + u.Key.DeletionDate + ")"
+				}
+				fields := []detailField{
+					{"Key ID", orDash(u.Key.KeyId)},
+					{"Aliases", orDash(strings.Join(u.Key.Aliases, ", "))},
+					{"Description", orDash(u.Key.Description)},
+					{"Managed By", orDash(u.Key.KeyManager)},
+					{"State", orDash(state)},
+					{"Created", orDash(u.Key.CreationDate)},
+					{"Protected Resources", fmt.Sprint(len(u.Resources))},
+				}
+				if !u.Synced {
+					fields = append(fields, detailField{"Note", "Key not synced; referenced by the resources below"})
+				}
+				for _, res := range u.Resources {
+					fields = append(fields, detailField{"  " + res.Type, nameOr(res.Name, res.ID)})
+				}
+				detail = detailData{Type: "KMS", Title: u.Key.Label(), Fields: fields}
+				break
+			}
+		}
+	case "cognito-user-pool":
+		cognito, _ := sawsSync.LoadCognitoData(region)
+		if cognito != nil {
+			for _, p := range cognito.UserPools {
+				if p.Id == resId {
+					var clients []string
+					for _, c := range p.AppClients {
+						clients = append(clients, c.ClientName+" ("+c.ClientId+")")
 					}
-					if role.Description != "" {
-						fields = append(fields, detailField{"Description", role.Description})
+					clientStr := "—"
+					if len(clients) > 0 {
+						clientStr = strings.Join(clients, ", ")
 					}
-					fields = append(fields,
-						detailField{"Service Linked", boolStr(role.IsServiceLinked)},
-						detailField{"Attached Policies", policies},
-						detailField{"Inline Policies", inline},
-					)
-					for _, tp := range role.TrustPolicy {
-						fields = append(fields, detailField{tp.Effect + " " + tp.Sid, tp.Action + " (" + tp.Principal + ")"})
+					usedBy := "—"
+					if len(p.UsedBy) > 0 {
+						usedBy = strings.Join(p.UsedBy, ", ")
 					}
 					detail = detailData{
-						Type:   "ROLE",
-						Title:  role.RoleName,
-						Fields: fields,
+						Type:  "COG",
+						Title: p.Name,
+						Fields: []detailField{
+							{"Pool Name", p.Name},
+							{"Pool ID", p.Id},
+							{"ARN", p.Arn},
+							{"Status", p.Status},
+							{"Estimated Users", fmt.Sprintf("%d", p.EstimatedUsers)},
+							{"MFA", p.MfaConfiguration},
+							{"Created", p.CreationDate},
+							{"App Clients", clientStr},
+							{"Used By", usedBy},
+						},
 					}
 					break
 				}
 			}
 		}
-	case "iam-group":
-		iamData, _ := sawsSync.LoadIAMData()
-		if iamData != nil {
-			for _, g := range iamData.Groups {
-				if g.GroupName == resId {
-					policies := "—"
-					if len(g.AttachedPolicies) > 0 {
-						policies = strings.Join(g.AttachedPolicies, ", ")
-					}
-					inline := "—"
-					if len(g.InlinePolicies) > 0 {
-						inline = strings.Join(g.InlinePolicies, ", ")
+	case "cognito-identity-pool":
+		cognito, _ := sawsSync.LoadCognitoData(region)
+		if cognito != nil {
+			for _, p := range cognito.IdentityPools {
+				if p.IdentityPoolId == resId {
+					var pools []string
+					for _, id := range p.UserPoolIds {
+						name := id
+						for _, up := range cognito.UserPools {
+							if up.Id == id {
+								name = up.Name + " (" + id + ")"
+							}
+						}
+						pools = append(pools, name)
 					}
-					members := "—"
-					if len(g.Members) > 0 {
-						members = strings.Join(g.Members, ", ")
+					poolStr := "—"
+					if len(pools) > 0 {
+						poolStr = strings.Join(pools, ", ")
 					}
 					detail = detailData{
-						Type:  "GRP",
-						Title: g.GroupName,
+						Type:  "IDP",
+						Title: p.IdentityPoolName,
 						Fields: []detailField{
-							{"Group Name", g.GroupName},
-							{"Group ID", g.GroupId},
-							{"ARN", g.Arn},
-							{"Created", g.CreateDate},
-							{"Attached Policies", policies},
-							{"Inline Policies", inline},
-							{"Members", members},
+							{"Pool Name", p.IdentityPoolName},
+							{"Pool ID", p.IdentityPoolId},
+							{"Guest Access", boolStr(p.AllowUnauthenticated)},
+							{"User Pools", poolStr},
 						},
 					}
 					break
 				}
 			}
 		}
+	default:
+		if it := sawsSync.ProviderItem(resType, resId); it != nil {
+			detail = providerDetail(*it)
+		}
 	}
 
 	if detail.Type == "" {
 		http.Error(w, "not found", 404)
 		return
 	}
+	if resType != "config-rule" {
+		detail.Fields = append(detail.Fields, configComplianceFields(region, resId)...)
+	}
+	if resType != "access-analyzer" {
+		detail.Fields = append(detail.Fields, externalAccessFields(region, resType, resId)...)
+	}
+	if resType != "cfn-stack" {
+		if stack := sawsSync.StackIndex(region)[resId]; stack != "" {
+			detail.Fields = append(detail.Fields, detailField{"CloudFormation Stack", stack})
+		}
+	}
 
 	tmpl.ExecuteTemplate(w, "detail-panel", detail)
 }
 
+// snsFanout renders a topic's subscriptions as protocol, target and what
+// happens next: the queue's own dead-letter queue, or why the target is not
+// linked.
+func snsFanout(t sawsSync.SNSTopic, queues []sawsSync.SQSQueue) [][]string {
+	dlq := map[string]string{}
+	for _, q := range queues {
+		if q.DLQName != "" {
+			dlq[q.QueueName] = q.DLQName
+		}
+	}
+	var rows [][]string
+	for _, sub := range t.SubscriptionList {
+		note := "—"
+		switch {
+		case sub.Pending():
+			note = "pending confirmation"
+		case sub.TargetType != "" && !sub.TargetCached:
+			note = "not cached"
+		case sub.TargetType == "sqs" && dlq[sub.TargetId] != "":
+			note = "→ DLQ " + dlq[sub.TargetId]
+		}
+		rows = append(rows, []string{sub.Protocol, sub.Target(), note})
+	}
+	return rows
+}
+
+// eventBridgeTargets returns one [rule, schedule, target, note] row per
+// rule target, and a single row for rules without any.
+func eventBridgeTargets(b sawsSync.EventBridgeBus) [][]string {
+	var rows [][]string
+	for _, r := range b.Rules {
+		sched := orDash(r.Schedule)
+		if len(r.Targets) == 0 {
+			rows = append(rows, []string{r.Name, sched, "—", "no targets"})
+			continue
+		}
+		for _, t := range r.Targets {
+			note := "—"
+			switch {
+			case t.TargetType != "" && !t.TargetCached:
+				note = "not cached"
+			case t.DeadLetterArn != "":
+				note = "DLQ " + t.DeadLetterArn[strings.LastIndex(t.DeadLetterArn, ":")+1:]
+			}
+			rows = append(rows, []string{r.Name, sched, t.Target(), note})
+		}
+	}
+	return rows
+}
+
+// handleWhatIfSG runs the staged rule changes from the SG detail panel
+// against the cached security groups and renders what would open or break.
+func handleWhatIfSG(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	r.ParseForm()
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		region = defaultRegion()
+	}
+	var changes []audit.SGChange
+	for _, line := range strings.Split(r.FormValue("changes"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		c, err := audit.ParseSGChange(r.FormValue("group"), line)
+		if err != nil {
+			tmpl.ExecuteTemplate(w, "whatif-result", map[string]any{"Error": err.Error()})
+			return
+		}
+		changes = append(changes, c)
+	}
+	if len(changes) == 0 {
+		tmpl.ExecuteTemplate(w, "whatif-result", map[string]any{"Error": "stage at least one change"})
+		return
+	}
+	res, err := audit.WhatIf(region, changes)
+	if err != nil {
+		tmpl.ExecuteTemplate(w, "whatif-result", map[string]any{"Error": err.Error()})
+		return
+	}
+	tmpl.ExecuteTemplate(w, "whatif-result", map[string]any{"Result": res})
+}
+
 type sgPermission struct {
 	IpProtocol string `json:"IpProtocol"`
 	FromPort   *int   `json:"FromPort"`
@@ -1859,6 +4572,16 @@ func parseSGPerms(perms []sgPermission) [][]string {
 	return rules
 }
 
+// naclRules renders NACL entries in the column order of SG rules, with the
+// rule number first and the action last.
+func naclRules(entries []sawsSync.NACLEntry) [][]string {
+	var rules [][]string
+	for _, e := range entries {
+		rules = append(rules, []string{e.Rule(), e.ProtocolName(), e.Ports(), e.Cidr, strings.ToUpper(e.RuleAction)})
+	}
+	return rules
+}
+
 func loadSGRules(region, sgId string) (inbound, outbound [][]string) {
 	raw, err := sawsSync.ReadCache(region + ":security-groups")
 	if err != nil || raw == nil {
@@ -1883,6 +4606,25 @@ func loadSGRules(region, sgId string) (inbound, outbound [][]string) {
 	return nil, nil
 }
 
+// sortLambdas applies the requested Lambda ordering. Without an explicit
+// choice, functions with the most errors come first once metrics exist.
+func sortLambdas(data *sawsSync.ComputeData, by string) string {
+	if data == nil {
+		return by
+	}
+	if by == "" {
+		by = "name"
+		for _, fn := range data.Lambda {
+			if fn.Metrics != nil {
+				by = "errors"
+				break
+			}
+		}
+	}
+	sawsSync.SortLambdas(data.Lambda, by)
+	return by
+}
+
 func nameOr(name, fallback string) string {
 	if name != "" {
 		return name
@@ -1897,39 +4639,166 @@ func boolStr(b bool) string {
 	return "No"
 }
 
-func formatSyncTime(t *time.Time) string {
-	if t == nil {
-		return ""
+// syncLabel is the header's "synced 3h ago" label. Stale labels are
+// highlighted and start a sync when clicked.
+type syncLabel struct {
+	Text  string
+	Stale bool
+	Title string
+	OOB   bool // render as an hx-swap-oob update
+}
+
+func syncedAtForTab(tab, region string) syncLabel {
+	age, ok := sawsSync.TabAge(tab, region)
+	if !ok {
+		return syncLabel{}
 	}
-	now := time.Now()
-	if t.Year() == now.Year() && t.YearDay() == now.YearDay() {
-		return "synced " + t.Format("15:04")
+	label := syncLabel{Text: "synced " + sawsSync.FormatAge(age), Stale: sawsSync.IsStale(age)}
+	if label.Stale {
+		label.Title = fmt.Sprintf("Older than %dh (stale_after_hours) — click to sync", int(sawsSync.StaleAfter().Hours()))
 	}
-	return "synced " + t.Format("Jan 2 15:04")
+	return label
 }
 
-func syncedAtForTab(tab, region string) string {
-	var keys []string
-	switch tab {
-	case "net":
-		keys = []string{region + ":vpcs", region + ":subnets", region + ":security-groups", region + ":load-balancers"}
-	case "compute":
-		keys = []string{region + ":ec2-enriched", region + ":ecs-enriched", region + ":lambda"}
-	case "database":
-		keys = []string{region + ":rds", region + ":dynamodb", region + ":elasticache-enriched"}
-	case "s3":
-		keys = []string{"s3", "s3:enriched", region + ":redshift", region + ":athena"}
-	case "iam":
-		keys = []string{"iam:enriched"}
-	case "streaming":
-		keys = []string{region + ":streaming-enriched"}
-	case "ai":
-		keys = []string{region + ":sagemaker-notebooks", region + ":bedrock-models"}
+// lbPathFields renders a load balancer's traffic path as detail rows:
+// each listener and rule, the target groups it forwards to, and the
+// registered targets with their health, resolved against the compute cache.
+func lbPathFields(lb sawsSync.LoadBalancer, vpc *sawsSync.VPCData, compute *sawsSync.ComputeData) []detailField {
+	if len(lb.Listeners) == 0 {
+		return []detailField{{"Listeners", "— (not synced)"}}
+	}
+	var fields []detailField
+	forward := func(indent string, action sawsSync.ListenerAction) {
+		for _, arn := range action.TargetGroupArns {
+			tg := vpc.TargetGroupByArn(arn)
+			if tg == nil {
+				fields = append(fields, detailField{indent + "Target Group", arn})
+				continue
+			}
+			health := "no targets registered"
+			if len(tg.Targets) > 0 {
+				health = fmt.Sprintf("%d/%d healthy", tg.HealthyTargets(), len(tg.Targets))
+			}
+			fields = append(fields, detailField{indent + "Target Group " + tg.Name, fmt.Sprintf("%s:%d · %s", tg.Protocol, tg.Port, health)})
+			for _, t := range tg.Targets {
+				state := t.State
+				if t.Reason != "" && t.State != "healthy" {
+					state += " (" + strings.TrimPrefix(t.Reason, "Target.") + ")"
+				}
+				target := sawsSync.ResolveTarget(*tg, t, compute, vpc.ENIs)
+				if t.Port > 0 {
+					target += fmt.Sprintf(" :%d", t.Port)
+				}
+				fields = append(fields, detailField{indent + "  " + target, state})
+			}
+		}
+	}
+	actionLabel := func(a sawsSync.ListenerAction) string {
+		switch a.Type {
+		case "forward":
+			return fmt.Sprintf("forward to %d target group(s)", len(a.TargetGroupArns))
+		case "redirect":
+			return "redirect " + a.Detail
+		case "fixed-response":
+			return "fixed response " + a.Detail
+		}
+		return orDash(a.Type)
+	}
+	for _, l := range lb.Listeners {
+		fields = append(fields, detailField{fmt.Sprintf("Listener %s:%d", l.Protocol, l.Port), "default: " + actionLabel(l.DefaultAction)})
+		forward("  ", l.DefaultAction)
+		for _, rule := range l.Rules {
+			fields = append(fields, detailField{"  Rule " + rule.Priority + ": " + strings.Join(rule.Conditions, " & "), actionLabel(rule.Action)})
+			forward("    ", rule.Action)
+		}
+	}
+	return fields
+}
+
+// providerIcon labels another cloud's item by its provider, e.g. "GCP".
+func providerIcon(itemType string) string {
+	if p := sawsSync.ProviderFor(itemType); p != nil {
+		return strings.ToUpper(p.Name())
+	}
+	return "?"
+}
+
+// providerDetail shows another cloud's item from its inventory fields,
+// since providers have no dedicated detail case.
+func providerDetail(it sawsSync.InventoryItem) detailData {
+	fields := []detailField{
+		{"Provider", sawsSync.ProviderFor(it.Type).Title()},
+		{"Type", it.Type},
+		{"Name", it.Name},
+		{"Location", it.Region},
+	}
+	if it.Arn != "" {
+		fields = append(fields, detailField{"ID", it.Arn})
+	}
+	keys := make([]string, 0, len(it.Details))
+	for k := range it.Details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, detailField{k, orDash(it.Details[k])})
+	}
+	for _, ref := range it.Refs {
+		fields = append(fields, detailField{"Depends On", ref})
+	}
+	tags := make([]string, 0, len(it.Tags))
+	for k := range it.Tags {
+		tags = append(tags, k)
 	}
-	if len(keys) == 0 {
-		return ""
+	sort.Strings(tags)
+	for _, k := range tags {
+		fields = append(fields, detailField{"Tag " + k, it.Tags[k]})
 	}
-	return formatSyncTime(sawsSync.CacheSyncedAt(keys...))
+	return detailData{Type: providerIcon(it.Type), Title: it.Name, Fields: fields}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+// formatIAMTime renders an RFC 3339 timestamp from IAM as
+// "2006-01-02 15:04"; anything else is returned as-is.
+func formatIAMTime(s string) string {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Format("2006-01-02 15:04")
+	}
+	return s
+}
+
+// clusterIcons maps RDSCluster.Family to its icon label.
+var clusterIcons = map[string]string{"aurora": "AUR", "docdb": "DOC", "neptune": "NEP"}
+
+func ddbEncryption(t sawsSync.DynamoDBTable) string {
+	if t.SSEType == "KMS" {
+		return "KMS (" + t.KmsKeyId + ")"
+	}
+	return "AWS owned key"
+}
+
+// ddbCapacity shows provisioned read or write units and, when auto
+// scaling manages them, its range and target utilization.
+func ddbCapacity(t sawsSync.DynamoDBTable, index, dimension string, units int64) string {
+	unit := " RCU"
+	if dimension == "write" {
+		unit = " WCU"
+	}
+	out := fmt.Sprint(units) + unit
+	if sc := t.ScalingFor(index, dimension); sc != nil {
+		out += fmt.Sprintf(" (auto %d–%d", sc.Min, sc.Max)
+		if sc.TargetValue > 0 {
+			out += fmt.Sprintf(" @ %.0f%%", sc.TargetValue)
+		}
+		out += ")"
+	}
+	return out
 }
 
 func formatBytes(b int64) string {
@@ -1950,7 +4819,15 @@ func handleRegionToggle(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "use PUT", http.StatusMethodNotAllowed)
 		return
 	}
+	if sawsSync.ReadOnly() {
+		http.Error(w, sawsSync.ErrReadOnly.Error(), http.StatusForbidden)
+		return
+	}
 
+	if cfg, _ := sawsSync.LoadProjectConfig(); len(cfg.Regions) > 0 {
+		http.Error(w, "regions are set in "+cfg.Path(), http.StatusConflict)
+		return
+	}
 	name := strings.TrimPrefix(r.URL.Path, "/settings/regions/")
 	enabled := r.URL.Query().Get("enabled") == "true"
 
@@ -2070,13 +4947,35 @@ func handleAPISync(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "use POST", http.StatusMethodNotAllowed)
 		return
 	}
-	if !awsStatus.Installed {
-		http.Error(w, "AWS CLI not available", http.StatusServiceUnavailable)
+	r.ParseForm()
+	region := r.FormValue("region")
+	if region == "" {
+		region = defaultRegion()
+	}
+	jobID, ok := sawsSync.StartSync("all", region)
+	if !ok {
+		http.Error(w, "A sync is running — try again when it finishes", http.StatusConflict)
 		return
 	}
-	results, err := sawsSync.SyncAll()
-	if err != nil {
-		http.Error(w, err.Error(), 500)
+	onStep := func(label string) { sawsSync.IncrSync(jobID, label) }
+	run, ok := beginRun(w, jobID, region, "all")
+	if !ok {
+		return
+	}
+	record := recordSync(region, run)
+	var mu sync.Mutex
+	var results []sawsSync.SyncResult
+	sawsSync.RunSyncGroups(sawsSync.SelectedSyncGroups(), func(g sawsSync.SyncGroup) {
+		res, err := g.Sync(run.Stage(), region, onStep)
+		mu.Lock()
+		defer mu.Unlock()
+		record(res, err)
+		results = append(results, res...)
+	})
+	finishRun(run)
+	sawsSync.FinishSync(jobID)
+	if err := run.Err(); err != nil {
+		http.Error(w, "sync rolled back: "+err.Error(), 500)
 		return
 	}
 	writeJSON(w, results)
@@ -2123,6 +5022,168 @@ func resourceTypes(t *cfn.Template) []string {
 	return types
 }
 
+// handleAPIExport downloads the inventory as a table, like 'saws export
+// --format': ?format=csv|json|parquet (default csv), and optional
+// comma-separated region, service and columns.
+func handleAPIExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	list := func(name string) []string {
+		if v := q.Get(name); v != "" {
+			return strings.Split(v, ",")
+		}
+		return nil
+	}
+	t, err := export.Inventory(export.Options{Regions: list("region"), Types: list("service"), Columns: list("columns")})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var buf bytes.Buffer
+	if err := export.Write(&buf, format, t); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	contentType := map[string]string{"csv": "text/csv", "json": "application/json", "parquet": "application/vnd.apache.parquet"}[format]
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="saws-inventory.`+format+`"`)
+	w.Write(buf.Bytes())
+}
+
+// handleAPIGraph returns a region's architecture laid out for drawing:
+// ?region= and optionally ?vpc= to draw a single VPC.
+func handleAPIGraph(w http.ResponseWriter, r *http.Request) {
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		region = defaultRegion()
+	}
+	d, err := graph.ArchitectureDiagram(region, r.URL.Query().Get("vpc"))
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeJSON(w, d)
+}
+
+// handleAPIExportDiagram downloads the architecture diagram, like 'saws
+// export diagram': ?format=svg|png|drawio (default svg), ?region= and
+// optionally ?vpc=.
+func handleAPIExportDiagram(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "svg"
+	}
+	region := q.Get("region")
+	if region == "" {
+		region = defaultRegion()
+	}
+	d, err := graph.ArchitectureDiagram(region, q.Get("vpc"))
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	var buf bytes.Buffer
+	if err := graph.WriteDiagram(&buf, format, d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := "saws-" + region
+	if vpc := q.Get("vpc"); vpc != "" {
+		name += "-" + vpc
+	}
+	name = strings.Map(func(c rune) rune {
+		if c == '-' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+			return c
+		}
+		return '_'
+	}, name)
+	contentType := map[string]string{"svg": "image/svg+xml", "png": "image/png", "drawio": "application/vnd.jgraph.mxfile"}[format]
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.`+format+`"`)
+	w.Write(buf.Bytes())
+}
+
+// searchTabs is the tab listing each inventory type; the rest are on the
+// network tab.
+var searchTabs = map[string]string{
+	"ec2": "compute", "asg": "compute", "ebs": "compute", "ebs-snapshot": "compute", "ecs": "compute",
+	"ecs-service": "compute", "apprunner": "compute", "lambda": "compute", "lightsail": "compute",
+	"lightsail-container": "compute", "mesh": "compute", "mesh-node": "compute", "mesh-router": "compute",
+	"mesh-service": "compute", "cloudmap-namespace": "compute", "cloudmap-service": "compute",
+	"rds": "database", "rds-cluster": "database", "docdb": "database", "neptune": "database",
+	"dynamodb": "database", "elasticache": "database",
+	"s3": "s3", "s3-access-point": "s3", "s3-mrap": "s3", "efs": "s3", "fsx": "s3", "glue": "s3",
+	"glue-crawler": "s3", "glue-job": "s3", "athena": "s3", "redshift": "s3", "redshift-serverless": "s3",
+	"opensearch": "s3",
+	"kinesis": "streaming", "firehose": "streaming", "msk": "streaming", "sqs": "streaming",
+	"sns": "streaming", "mq": "streaming", "eventbridge": "streaming",
+	"sagemaker-domain": "ai", "sagemaker-endpoint": "ai", "sagemaker-model": "ai", "sagemaker-notebook": "ai",
+	"sagemaker-pipeline": "ai", "sagemaker-training-job": "ai", "bedrock-agent": "ai",
+	"bedrock-guardrail": "ai", "bedrock-knowledge-base": "ai", "bedrock-provisioned-throughput": "ai",
+	"iam-role": "iam", "iam-user": "iam", "iam-group": "iam", "iam-identity-provider": "iam",
+	"secret": "iam", "cloudtrail": "iam", "access-analyzer": "iam", "config-rule": "iam",
+	"guardduty": "iam", "cognito-user-pool": "iam", "cognito-identity-pool": "iam",
+	"codebuild": "cicd", "codepipeline": "cicd", "cfn-stack": "cicd",
+}
+
+// detailTypes are the inventory types whose detail route is named
+// differently.
+var detailTypes = map[string]string{
+	"bedrock-knowledge-base":         "bedrock-kb",
+	"bedrock-provisioned-throughput": "bedrock-provisioned",
+	"iam-identity-provider":          "iam-provider",
+}
+
+// handleAPISearch finds cached resources by name, ID, ARN or IP address
+// across every synced region: ?q=, optionally ?limit= (default 20) and
+// ?region= to open global resources in. Each hit carries the tab listing
+// it, the detail panel to open, and a URL opening both.
+func handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	hits, err := sawsSync.SearchResources(q.Get("q"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	current := q.Get("region")
+	if current == "" {
+		current = defaultRegion()
+	}
+	type result struct {
+		sawsSync.SearchHit
+		Tab    string `json:"tab"`
+		Detail string `json:"detail"`
+		URL    string `json:"url"`
+	}
+	results := []result{}
+	for _, h := range hits {
+		region := h.Region
+		if region == "" || region == "global" {
+			region = current
+		}
+		tab := searchTabs[h.Type]
+		if tab == "" {
+			tab = "net"
+		}
+		typ := h.Type
+		if t, ok := detailTypes[typ]; ok {
+			typ = t
+		}
+		detail := typ + "/" + h.ID
+		results = append(results, result{h, tab, detail,
+			"/" + region + "/" + tab + "?" + url.Values{"detail": {detail}}.Encode()})
+	}
+	writeJSON(w, results)
+}
+
 func writeJSON(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(v)
@@ -0,0 +1,173 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// activeAnalyzerFindings selects findings that have not been archived or
+// resolved.
+const activeAnalyzerFindings = `{"status":{"eq":["ACTIVE"]}}`
+
+// ExternalAccess is an active IAM Access Analyzer finding: a resource
+// policy that grants access to a principal outside the account (or the
+// organization, for an organization analyzer).
+type ExternalAccess struct {
+	Id           string            `json:"id"`
+	Analyzer     string            `json:"analyzer"`
+	Resource     string            `json:"resource"` // ARN
+	ResourceType string            `json:"resourceType"`
+	Principal    map[string]string `json:"principal"`
+	Actions      []string          `json:"action"`
+	Conditions   map[string]string `json:"condition"`
+	IsPublic     bool              `json:"isPublic"`
+	Owner        string            `json:"resourceOwnerAccount"`
+	UpdatedAt    string            `json:"updatedAt"`
+}
+
+// analyzerDetailTypes maps Access Analyzer resource types to /detail/
+// types.
+var analyzerDetailTypes = map[string]string{
+	"AWS::IAM::Role":              "iam-role",
+	"AWS::S3::Bucket":             "s3",
+	"AWS::KMS::Key":               "kms",
+	"AWS::Lambda::Function":       "lambda",
+	"AWS::SQS::Queue":             "sqs",
+	"AWS::SecretsManager::Secret": "secret",
+}
+
+// DetailType returns the /detail/ type of the resource, "" when it has no
+// detail panel.
+func (f ExternalAccess) DetailType() string {
+	return analyzerDetailTypes[f.ResourceType]
+}
+
+// DetailID returns the ID the detail panel of the resource is keyed by,
+// taken from the ARN.
+func (f ExternalAccess) DetailID() string {
+	arn := f.Resource
+	switch f.ResourceType {
+	case "AWS::IAM::Role", "AWS::KMS::Key":
+		return arn[strings.LastIndex(arn, "/")+1:]
+	case "AWS::S3::Bucket":
+		return strings.TrimPrefix(arn, "arn:aws:s3:::")
+	case "AWS::SecretsManager::Secret":
+		// Secret ARNs end in the name plus a random 6-character suffix.
+		name := arn[strings.Index(arn, ":secret:")+len(":secret:"):]
+		if i := strings.LastIndex(name, "-"); i > 0 && len(name)-i == 7 {
+			name = name[:i]
+		}
+		return name
+	}
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
+
+// Grantee summarizes who is granted access.
+func (f ExternalAccess) Grantee() string {
+	if f.IsPublic {
+		return "public"
+	}
+	keys := make([]string, 0, len(f.Principal))
+	for k := range f.Principal {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, f.Principal[k])
+	}
+	if len(parts) == 0 {
+		return "unknown principal"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SyncAccessAnalyzerData fetches the active findings of every analyzer in
+// the region.
+func SyncAccessAnalyzerData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("accessanalyzer", "list-analyzers", "--region", region)
+	if err != nil {
+		step("access analyzer")
+		return []SyncResult{{Service: "access-analyzer", Error: err.Error()}}, nil
+	}
+	var analyzers struct {
+		Analyzers []struct {
+			Arn  string `json:"arn"`
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"analyzers"`
+	}
+	json.Unmarshal(data, &analyzers)
+
+	findings := []ExternalAccess{}
+	var lastErr error
+	for _, a := range analyzers.Analyzers {
+		// Unused-access analyzers report unused roles and keys, not
+		// external access.
+		if a.Type != "ACCOUNT" && a.Type != "ORGANIZATION" {
+			continue
+		}
+		data, err := awscli.Run("accessanalyzer", "list-findings", "--analyzer-arn", a.Arn,
+			"--filter", activeAnalyzerFindings, "--region", region)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var resp struct {
+			Findings []ExternalAccess `json:"findings"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, f := range resp.Findings {
+			f.Analyzer = a.Name
+			findings = append(findings, f)
+		}
+	}
+	step("access analyzer")
+
+	if len(findings) == 0 && lastErr != nil {
+		return []SyncResult{{Service: "access-analyzer", Error: lastErr.Error()}}, nil
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].IsPublic != findings[j].IsPublic {
+			return findings[i].IsPublic
+		}
+		return findings[i].Resource < findings[j].Resource
+	})
+	b, _ := json.Marshal(findings)
+	st.WriteCache(region+":access-analyzer", b)
+	return []SyncResult{{Service: "access-analyzer", Count: len(findings)}}, nil
+}
+
+// LoadExternalAccess returns the cached Access Analyzer findings for
+// region.
+func LoadExternalAccess(region string) ([]ExternalAccess, error) {
+	raw, err := ReadCache(region + ":access-analyzer")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var findings []ExternalAccess
+	json.Unmarshal(raw, &findings)
+	return findings, nil
+}
+
+// ExternalAccessFor returns the findings on the resource shown at
+// /detail/{typ}/{id}.
+func ExternalAccessFor(region, typ, id string) []ExternalAccess {
+	findings, _ := LoadExternalAccess(region)
+	var out []ExternalAccess
+	for _, f := range findings {
+		if f.DetailType() == typ && f.DetailID() == id {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package sync
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// Cache keys are namespaced by AWS account: "123456789012/us-east-1:vpcs"
+// is stored for "us-east-1:vpcs" while that account is active. Callers
+// always use the plain key.
+
+// accountGlob matches a namespaced key; account IDs are 12 digits.
+const accountGlob = "[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]/*"
+
+// activeAccount is the account reads and writes go to; "" before any is
+// known, which uses keys without a namespace.
+var activeAccount atomic.Value
+
+// detectAccount returns the account the AWS CLI is signed in to. Tests
+// replace it so InitDB never shells out.
+var detectAccount = func() string { return awscli.Detect().AccountID }
+
+// Account returns the active account ID, or "" when none is known.
+func Account() string {
+	v, _ := activeAccount.Load().(string)
+	return v
+}
+
+// UseAccount switches reads and writes to the cached data of account id.
+// Called before InitDB, it overrides the account InitDB would pick.
+func UseAccount(id string) {
+	activeAccount.Store(id)
+}
+
+// SignedIn reports whether the AWS CLI is signed in to the active account
+// (or reports none), i.e. whether a sync would write where it should.
+func SignedIn() bool {
+	d := detectAccount()
+	return d == "" || d == Account()
+}
+
+// nsKey returns the stored form of key for the active account.
+func nsKey(key string) string {
+	if a := Account(); a != "" {
+		return a + "/" + key
+	}
+	return key
+}
+
+// nsKeys is nsKey over a list, as query arguments.
+func nsKeys(keys []string) []interface{} {
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = nsKey(k)
+	}
+	return args
+}
+
+// nsPattern is a GLOB matching every stored key of the active account.
+func nsPattern() string {
+	if a := Account(); a != "" {
+		return a + "/*"
+	}
+	return "*"
+}
+
+// plainKey strips the account namespace off a stored key.
+func plainKey(stored string) string {
+	if a := Account(); a != "" {
+		return strings.TrimPrefix(stored, a+"/")
+	}
+	return stored
+}
+
+// selectAccount picks the account InitDB opens: one chosen with
+// UseAccount or SAWS_ACCOUNT, else the one the AWS CLI is signed in to,
+// else the most recently synced one. Data cached before keys were
+// namespaced is moved under the signed-in account.
+func selectAccount() error {
+	detected := detectAccount()
+	if detected != "" {
+		if err := migrateAccountKeys(detected); err != nil {
+			return err
+		}
+	}
+	if Account() != "" {
+		return nil
+	}
+	if id := os.Getenv("SAWS_ACCOUNT"); id != "" {
+		UseAccount(id)
+		return nil
+	}
+	if detected != "" {
+		UseAccount(detected)
+		return nil
+	}
+	if accounts, _ := CachedAccounts(); len(accounts) > 0 {
+		UseAccount(accounts[0].ID)
+	}
+	return nil
+}
+
+// migrateAccountKeys moves cache entries, snapshots, tags and resources stored
+// without an account under account. Where the account already has a
+// newer copy of an entry, the old one is dropped.
+func migrateAccountKeys(account string) error {
+	var legacy int
+	db.QueryRow(`SELECT (SELECT COUNT(*) FROM cache WHERE key NOT GLOB ?)
+		+ (SELECT COUNT(*) FROM snapshots WHERE account = '')
+			+ (SELECT COUNT(*) FROM tags WHERE account = '')
+		+ (SELECT COUNT(*) FROM resources WHERE account = '')`, accountGlob).Scan(&legacy)
+	if legacy == 0 {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range []string{"cache", "snapshot_entries"} {
+		// Rows left after the update clash with a namespaced copy.
+		if _, err := tx.Exec(`UPDATE OR IGNORE `+table+` SET key = ? || '/' || key WHERE key NOT GLOB ?`, account, accountGlob); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE key NOT GLOB ?`, accountGlob); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`UPDATE snapshots SET account = ? WHERE account = ''`, account); err != nil {
+		return err
+	}
+	for _, table := range []string{"tags", "resources"} {
+		if _, err := tx.Exec(`UPDATE OR REPLACE `+table+` SET account = ? WHERE account = ''`, account); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CachedAccount is an account with data in the cache.
+type CachedAccount struct {
+	ID       string
+	Entries  int
+	SyncedAt time.Time // most recent write
+}
+
+// CachedAccounts lists the accounts with cached data, most recently
+// synced first.
+func CachedAccounts() ([]CachedAccount, error) {
+	rows, err := db.Query(`SELECT substr(key, 1, 12), COUNT(*), MAX(synced_at) FROM cache
+		WHERE key GLOB ? GROUP BY 1 ORDER BY 3 DESC`, accountGlob)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []CachedAccount
+	for rows.Next() {
+		var a CachedAccount
+		var syncedAt sql.NullString
+		if err := rows.Scan(&a.ID, &a.Entries, &syncedAt); err != nil {
+			return nil, err
+		}
+		a.SyncedAt, _ = syncedAtTime(syncedAt.String)
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
@@ -0,0 +1,49 @@
+package sync
+
+import "testing"
+
+func TestAccountNamespacing(t *testing.T) {
+	withTempCache(t)
+	SetDataDir(".")
+
+	// A cache from before keys were namespaced, synced without the CLI
+	// reporting an account.
+	if err := InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	WriteCache("us-east-1:lambda", []byte(`["legacy"]`))
+	CloseDB()
+
+	detectAccount = func() string { return "111111111111" }
+	if err := InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	if Account() != "111111111111" {
+		t.Fatalf("account = %q", Account())
+	}
+	if v, _ := ReadCache("us-east-1:lambda"); string(v) != `["legacy"]` {
+		t.Errorf("migrated lambda = %s", v)
+	}
+
+	UseAccount("222222222222")
+	if v, _ := ReadCache("us-east-1:lambda"); v != nil {
+		t.Errorf("other account sees %s", v)
+	}
+	WriteCache("eu-west-1:lambda", []byte(`["other"]`))
+	if regions, _ := CachedRegions(); len(regions) != 1 || regions[0] != "eu-west-1" {
+		t.Errorf("regions = %v", regions)
+	}
+
+	accounts, err := CachedAccounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 2 || accounts[0].ID != "222222222222" || accounts[1].ID != "111111111111" {
+		t.Errorf("accounts = %+v", accounts)
+	}
+
+	UseAccount("111111111111")
+	if v, _ := ReadCache("us-east-1:lambda"); string(v) != `["legacy"]` {
+		t.Errorf("lambda after switching back = %s", v)
+	}
+}
@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// DefaultCertWarningDays is used when the cert_warning_days setting is unset.
+const DefaultCertWarningDays = 30
+
+type Certificate struct {
+	CertificateArn string   `json:"CertificateArn"`
+	DomainName     string   `json:"DomainName"`
+	AltNames       []string `json:"SubjectAlternativeNames"`
+	Status         string   `json:"Status"`
+	Type           string   `json:"Type"`
+	Issuer         string   `json:"Issuer"`
+	KeyAlgorithm   string   `json:"KeyAlgorithm"`
+	RenewalStatus  string   `json:"RenewalEligibility"`
+	InUseBy        []string `json:"InUseBy"`
+	NotAfter       string   `json:"NotAfter"`
+}
+
+// ExpiresAt parses NotAfter. The zero time is returned for unissued certs.
+func (c Certificate) ExpiresAt() time.Time {
+	t, _ := time.Parse(time.RFC3339, c.NotAfter)
+	return t
+}
+
+// DaysLeft returns whole days until expiry, or -1 if there is no NotAfter.
+func (c Certificate) DaysLeft() int {
+	t := c.ExpiresAt()
+	if t.IsZero() {
+		return -1
+	}
+	return int(time.Until(t).Hours() / 24)
+}
+
+// ExpiringWithin reports whether the cert is issued and expires in the next n days
+// (already-expired certs count).
+func (c Certificate) ExpiringWithin(n int) bool {
+	if c.ExpiresAt().IsZero() {
+		return false
+	}
+	return c.DaysLeft() <= n
+}
+
+// SyncACMData lists ACM certificates and describes each one for InUseBy/NotAfter.
+func SyncACMData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("acm", "list-certificates", "--region", region)
+	if err != nil {
+		step("certificates")
+		return []SyncResult{{Service: "acm", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		CertificateSummaryList []struct {
+			CertificateArn string `json:"CertificateArn"`
+		} `json:"CertificateSummaryList"`
+	}
+	json.Unmarshal(data, &resp)
+
+	var certs []Certificate
+	for _, s := range resp.CertificateSummaryList {
+		desc, err := awscli.Run("acm", "describe-certificate", "--region", region,
+			"--certificate-arn", s.CertificateArn)
+		if err != nil {
+			continue
+		}
+		var d struct {
+			Certificate Certificate `json:"Certificate"`
+		}
+		json.Unmarshal(desc, &d)
+		certs = append(certs, d.Certificate)
+	}
+	enriched, _ := json.Marshal(certs)
+	st.WriteCache(region+":acm-enriched", enriched)
+	step("certificates")
+
+	return []SyncResult{{Service: "acm", Count: len(certs)}}, nil
+}
+
+// LoadCertificates returns cached certificates, soonest expiry first.
+func LoadCertificates(region string) ([]Certificate, error) {
+	raw, err := ReadCache(region + ":acm-enriched")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var certs []Certificate
+	json.Unmarshal(raw, &certs)
+	sort.SliceStable(certs, func(i, j int) bool {
+		a, b := certs[i].ExpiresAt(), certs[j].ExpiresAt()
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		return a.Before(b)
+	})
+	return certs, nil
+}
+
+// ExpiringCertificates returns cached certs expiring within the configured window.
+func ExpiringCertificates(region string) []Certificate {
+	certs, _ := LoadCertificates(region)
+	days := CertWarningDays()
+	var out []Certificate
+	for _, c := range certs {
+		if c.ExpiringWithin(days) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// CertWarningDays returns the cert_warning_days setting.
+func CertWarningDays() int {
+	if v, err := GetSetting("cert_warning_days"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return DefaultCertWarningDays
+}
@@ -2,6 +2,7 @@ package sync
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
@@ -14,8 +15,20 @@ type AIData struct {
 	SageMakerModels    []SageMakerModel    `json:"sagemakerModels"`
 	BedrockModels      []BedrockModel      `json:"bedrockModels"`
 	BedrockCustom      []BedrockCustomModel `json:"bedrockCustom"`
+	TrainingJobs       []SageMakerTrainingJob `json:"trainingJobs"`
+	Pipelines          []SageMakerPipeline    `json:"pipelines"`
+	Domains            []SageMakerDomain      `json:"domains"`
+
+	BedrockAgents         []BedrockAgent                 `json:"bedrockAgents"`
+	KnowledgeBases        []BedrockKnowledgeBase         `json:"knowledgeBases"`
+	Guardrails            []BedrockGuardrail             `json:"guardrails"`
+	ProvisionedThroughput []BedrockProvisionedThroughput `json:"provisionedThroughput"`
 }
 
+// sageMakerTrainingJobLimit caps how many recent training jobs are
+// described per sync; accounts with busy pipelines can have thousands.
+const sageMakerTrainingJobLimit = 25
+
 type SageMakerNotebook struct {
 	Name             string `json:"Name"`
 	Status           string `json:"Status"`
@@ -46,6 +59,62 @@ type SageMakerModel struct {
 	RoleName     string `json:"RoleName"`
 }
 
+type SageMakerTrainingJob struct {
+	Name            string `json:"Name"`
+	Status          string `json:"Status"` // InProgress, Completed, Failed, Stopping, Stopped
+	SecondaryStatus string `json:"SecondaryStatus"`
+	InstanceType    string `json:"InstanceType"`
+	InstanceCount   int    `json:"InstanceCount"`
+	Spot            bool   `json:"Spot"`
+	TrainingSeconds int    `json:"TrainingSeconds"`
+	BillableSeconds int    `json:"BillableSeconds"`
+	CreationTime    string `json:"CreationTime"`
+	EndTime         string `json:"EndTime"`
+	RoleName        string `json:"RoleName"`
+	OutputPath      string `json:"OutputPath"`
+	FailureReason   string `json:"FailureReason"`
+}
+
+// Duration renders the job's training time, e.g. "1h2m3s", or "" while
+// SageMaker has not reported one yet.
+func (j SageMakerTrainingJob) Duration() string {
+	if j.TrainingSeconds <= 0 {
+		return ""
+	}
+	return (time.Duration(j.TrainingSeconds) * time.Second).String()
+}
+
+// Capacity renders the job's instances, e.g. "2 × ml.g5.xlarge".
+func (j SageMakerTrainingJob) Capacity() string {
+	if j.InstanceType == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d × %s", j.InstanceCount, j.InstanceType)
+}
+
+type SageMakerPipeline struct {
+	Name              string `json:"Name"`
+	DisplayName       string `json:"DisplayName"`
+	CreationTime      string `json:"CreationTime"`
+	LastExecution     string `json:"LastExecution"`
+	LastStatus        string `json:"LastStatus"` // Executing, Stopping, Stopped, Failed, Succeeded
+	LastFailureReason string `json:"LastFailureReason"`
+}
+
+type SageMakerDomain struct {
+	Id            string   `json:"Id"`
+	Name          string   `json:"Name"`
+	Status        string   `json:"Status"`
+	AuthMode      string   `json:"AuthMode"`      // SSO or IAM
+	NetworkAccess string   `json:"NetworkAccess"` // PublicInternetOnly or VpcOnly
+	VpcId         string   `json:"VpcId"`
+	SubnetIds     []string `json:"SubnetIds"`
+	Url           string   `json:"Url"`
+	RoleName      string   `json:"RoleName"`
+	CreationTime  string   `json:"CreationTime"`
+	UserProfiles  []string `json:"UserProfiles"`
+}
+
 type BedrockModel struct {
 	ModelId      string `json:"ModelId"`
 	ModelName    string `json:"ModelName"`
@@ -62,7 +131,7 @@ type BedrockCustomModel struct {
 	CreationTime string `json:"CreationTime"`
 }
 
-func SyncAIData(region string, onStep ...func(string)) ([]SyncResult, error) {
+func SyncAIData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -72,7 +141,7 @@ func SyncAIData(region string, onStep ...func(string)) ([]SyncResult, error) {
 
 	// SageMaker Notebook Instances
 	if data, err := awscli.Run("sagemaker", "list-notebook-instances", "--region", region); err == nil {
-		WriteCache(region+":sagemaker-notebooks", data)
+		st.WriteCache(region+":sagemaker-notebooks", data)
 		results = append(results, SyncResult{Service: "sagemaker-notebooks", Count: countKey(data, "NotebookInstances")})
 	} else {
 		results = append(results, SyncResult{Service: "sagemaker-notebooks", Error: err.Error()})
@@ -81,7 +150,7 @@ func SyncAIData(region string, onStep ...func(string)) ([]SyncResult, error) {
 
 	// SageMaker Endpoints
 	if data, err := awscli.Run("sagemaker", "list-endpoints", "--region", region); err == nil {
-		WriteCache(region+":sagemaker-endpoints", data)
+		st.WriteCache(region+":sagemaker-endpoints", data)
 		results = append(results, SyncResult{Service: "sagemaker-endpoints", Count: countKey(data, "Endpoints")})
 	} else {
 		results = append(results, SyncResult{Service: "sagemaker-endpoints", Error: err.Error()})
@@ -90,7 +159,7 @@ func SyncAIData(region string, onStep ...func(string)) ([]SyncResult, error) {
 
 	// SageMaker Models
 	if data, err := awscli.Run("sagemaker", "list-models", "--region", region); err == nil {
-		WriteCache(region+":sagemaker-models", data)
+		st.WriteCache(region+":sagemaker-models", data)
 		results = append(results, SyncResult{Service: "sagemaker-models", Count: countKey(data, "Models")})
 	} else {
 		results = append(results, SyncResult{Service: "sagemaker-models", Error: err.Error()})
@@ -99,7 +168,7 @@ func SyncAIData(region string, onStep ...func(string)) ([]SyncResult, error) {
 
 	// Bedrock Foundation Models
 	if data, err := awscli.Run("bedrock", "list-foundation-models", "--region", region); err == nil {
-		WriteCache(region+":bedrock-models", data)
+		st.WriteCache(region+":bedrock-models", data)
 		results = append(results, SyncResult{Service: "bedrock-models", Count: countKey(data, "modelSummaries")})
 	} else {
 		results = append(results, SyncResult{Service: "bedrock-models", Error: err.Error()})
@@ -108,13 +177,117 @@ func SyncAIData(region string, onStep ...func(string)) ([]SyncResult, error) {
 
 	// Bedrock Custom Models
 	if data, err := awscli.Run("bedrock", "list-custom-models", "--region", region); err == nil {
-		WriteCache(region+":bedrock-custom", data)
+		st.WriteCache(region+":bedrock-custom", data)
 		results = append(results, SyncResult{Service: "bedrock-custom", Count: countKey(data, "modelSummaries")})
 	} else {
 		results = append(results, SyncResult{Service: "bedrock-custom", Error: err.Error()})
 	}
 	step("bedrock custom models")
 
+	// SageMaker Training Jobs (most recent first)
+	if data, err := awscli.Run("sagemaker", "list-training-jobs", "--sort-by", "CreationTime",
+		"--sort-order", "Descending", "--max-results", fmt.Sprint(sageMakerTrainingJobLimit), "--region", region); err == nil {
+		var resp struct {
+			TrainingJobSummaries []struct {
+				TrainingJobName string `json:"TrainingJobName"`
+			} `json:"TrainingJobSummaries"`
+		}
+		json.Unmarshal(data, &resp)
+		jobs := []SageMakerTrainingJob{}
+		for _, s := range resp.TrainingJobSummaries {
+			desc, err := awscli.Run("sagemaker", "describe-training-job",
+				"--training-job-name", s.TrainingJobName, "--region", region)
+			if err != nil {
+				jobs = append(jobs, SageMakerTrainingJob{Name: s.TrainingJobName})
+				continue
+			}
+			jobs = append(jobs, parseSageMakerTrainingJob(desc))
+		}
+		enriched, _ := json.Marshal(jobs)
+		st.WriteCache(region+":sagemaker-training-jobs", enriched)
+		results = append(results, SyncResult{Service: "sagemaker-training-jobs", Count: len(jobs)})
+	} else {
+		results = append(results, SyncResult{Service: "sagemaker-training-jobs", Error: err.Error()})
+	}
+	step("sagemaker training jobs")
+
+	// SageMaker Pipelines and their last execution
+	if data, err := awscli.Run("sagemaker", "list-pipelines", "--region", region); err == nil {
+		var resp struct {
+			PipelineSummaries []json.RawMessage `json:"PipelineSummaries"`
+		}
+		json.Unmarshal(data, &resp)
+		pipelines := []SageMakerPipeline{}
+		for _, raw := range resp.PipelineSummaries {
+			p := parseSageMakerPipeline(raw)
+			if exData, err := awscli.Run("sagemaker", "list-pipeline-executions",
+				"--pipeline-name", p.Name, "--max-results", "1", "--region", region); err == nil {
+				var ex struct {
+					PipelineExecutionSummaries []struct {
+						StartTime                      string `json:"StartTime"`
+						PipelineExecutionStatus        string `json:"PipelineExecutionStatus"`
+						PipelineExecutionFailureReason string `json:"PipelineExecutionFailureReason"`
+					} `json:"PipelineExecutionSummaries"`
+				}
+				json.Unmarshal(exData, &ex)
+				if len(ex.PipelineExecutionSummaries) > 0 {
+					last := ex.PipelineExecutionSummaries[0]
+					p.LastExecution = formatCLITime(last.StartTime)
+					p.LastStatus = last.PipelineExecutionStatus
+					p.LastFailureReason = last.PipelineExecutionFailureReason
+				}
+			}
+			pipelines = append(pipelines, p)
+		}
+		enriched, _ := json.Marshal(pipelines)
+		st.WriteCache(region+":sagemaker-pipelines", enriched)
+		results = append(results, SyncResult{Service: "sagemaker-pipelines", Count: len(pipelines)})
+	} else {
+		results = append(results, SyncResult{Service: "sagemaker-pipelines", Error: err.Error()})
+	}
+	step("sagemaker pipelines")
+
+	// SageMaker Studio Domains and user profiles
+	if data, err := awscli.Run("sagemaker", "list-domains", "--region", region); err == nil {
+		var resp struct {
+			Domains []struct {
+				DomainId   string `json:"DomainId"`
+				DomainName string `json:"DomainName"`
+				Status     string `json:"Status"`
+			} `json:"Domains"`
+		}
+		json.Unmarshal(data, &resp)
+		domains := []SageMakerDomain{}
+		for _, d := range resp.Domains {
+			domain := SageMakerDomain{Id: d.DomainId, Name: d.DomainName, Status: d.Status}
+			if desc, err := awscli.Run("sagemaker", "describe-domain",
+				"--domain-id", d.DomainId, "--region", region); err == nil {
+				domain = parseSageMakerDomain(desc)
+			}
+			if upData, err := awscli.Run("sagemaker", "list-user-profiles",
+				"--domain-id-equals", d.DomainId, "--region", region); err == nil {
+				var up struct {
+					UserProfiles []struct {
+						UserProfileName string `json:"UserProfileName"`
+					} `json:"UserProfiles"`
+				}
+				json.Unmarshal(upData, &up)
+				for _, u := range up.UserProfiles {
+					domain.UserProfiles = append(domain.UserProfiles, u.UserProfileName)
+				}
+			}
+			domains = append(domains, domain)
+		}
+		enriched, _ := json.Marshal(domains)
+		st.WriteCache(region+":sagemaker-domains", enriched)
+		results = append(results, SyncResult{Service: "sagemaker-domains", Count: len(domains)})
+	} else {
+		results = append(results, SyncResult{Service: "sagemaker-domains", Error: err.Error()})
+	}
+	step("sagemaker domains")
+
+	results = append(results, syncBedrockResources(st, region, step)...)
+
 	return results, nil
 }
 
@@ -176,6 +349,19 @@ func LoadAIData(region string) (*AIData, error) {
 		}
 	}
 
+	// SageMaker Training Jobs, Pipelines and Domains (enriched at sync time)
+	if raw, err := ReadCache(region + ":sagemaker-training-jobs"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.TrainingJobs)
+	}
+	if raw, err := ReadCache(region + ":sagemaker-pipelines"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.Pipelines)
+	}
+	if raw, err := ReadCache(region + ":sagemaker-domains"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.Domains)
+	}
+
+	loadBedrockResources(region, data)
+
 	return data, nil
 }
 
@@ -328,6 +514,105 @@ func parseBedrockCustomModel(raw json.RawMessage) BedrockCustomModel {
 	}
 }
 
+// parseSageMakerTrainingJob reads describe-training-job output.
+func parseSageMakerTrainingJob(raw json.RawMessage) SageMakerTrainingJob {
+	var j struct {
+		TrainingJobName   string `json:"TrainingJobName"`
+		TrainingJobStatus string `json:"TrainingJobStatus"`
+		SecondaryStatus   string `json:"SecondaryStatus"`
+		FailureReason     string `json:"FailureReason"`
+		RoleArn           string `json:"RoleArn"`
+		CreationTime      string `json:"CreationTime"`
+		TrainingEndTime   string `json:"TrainingEndTime"`
+		ResourceConfig    struct {
+			InstanceType  string `json:"InstanceType"`
+			InstanceCount int    `json:"InstanceCount"`
+		} `json:"ResourceConfig"`
+		OutputDataConfig struct {
+			S3OutputPath string `json:"S3OutputPath"`
+		} `json:"OutputDataConfig"`
+		EnableManagedSpotTraining bool `json:"EnableManagedSpotTraining"`
+		TrainingTimeInSeconds     int  `json:"TrainingTimeInSeconds"`
+		BillableTimeInSeconds     int  `json:"BillableTimeInSeconds"`
+	}
+	json.Unmarshal(raw, &j)
+
+	return SageMakerTrainingJob{
+		Name:            j.TrainingJobName,
+		Status:          j.TrainingJobStatus,
+		SecondaryStatus: j.SecondaryStatus,
+		InstanceType:    j.ResourceConfig.InstanceType,
+		InstanceCount:   j.ResourceConfig.InstanceCount,
+		Spot:            j.EnableManagedSpotTraining,
+		TrainingSeconds: j.TrainingTimeInSeconds,
+		BillableSeconds: j.BillableTimeInSeconds,
+		CreationTime:    formatCLITime(j.CreationTime),
+		EndTime:         formatCLITime(j.TrainingEndTime),
+		RoleName:        extractRoleName(j.RoleArn),
+		OutputPath:      j.OutputDataConfig.S3OutputPath,
+		FailureReason:   j.FailureReason,
+	}
+}
+
+// parseSageMakerPipeline reads one list-pipelines summary; the last
+// execution is filled in separately.
+func parseSageMakerPipeline(raw json.RawMessage) SageMakerPipeline {
+	var p struct {
+		PipelineName        string `json:"PipelineName"`
+		PipelineDisplayName string `json:"PipelineDisplayName"`
+		CreationTime        string `json:"CreationTime"`
+	}
+	json.Unmarshal(raw, &p)
+
+	return SageMakerPipeline{
+		Name:         p.PipelineName,
+		DisplayName:  p.PipelineDisplayName,
+		CreationTime: formatCLITime(p.CreationTime),
+	}
+}
+
+// parseSageMakerDomain reads describe-domain output; user profiles are
+// filled in separately.
+func parseSageMakerDomain(raw json.RawMessage) SageMakerDomain {
+	var d struct {
+		DomainId             string   `json:"DomainId"`
+		DomainName           string   `json:"DomainName"`
+		Status               string   `json:"Status"`
+		AuthMode             string   `json:"AuthMode"`
+		AppNetworkAccessType string   `json:"AppNetworkAccessType"`
+		VpcId                string   `json:"VpcId"`
+		SubnetIds            []string `json:"SubnetIds"`
+		Url                  string   `json:"Url"`
+		CreationTime         string   `json:"CreationTime"`
+		DefaultUserSettings  struct {
+			ExecutionRole string `json:"ExecutionRole"`
+		} `json:"DefaultUserSettings"`
+	}
+	json.Unmarshal(raw, &d)
+
+	return SageMakerDomain{
+		Id:            d.DomainId,
+		Name:          d.DomainName,
+		Status:        d.Status,
+		AuthMode:      d.AuthMode,
+		NetworkAccess: d.AppNetworkAccessType,
+		VpcId:         d.VpcId,
+		SubnetIds:     d.SubnetIds,
+		Url:           d.Url,
+		RoleName:      extractRoleName(d.DefaultUserSettings.ExecutionRole),
+		CreationTime:  formatCLITime(d.CreationTime),
+	}
+}
+
+// formatCLITime shortens the CLI's RFC 3339 timestamps to the minute,
+// leaving anything it can't parse as-is.
+func formatCLITime(s string) string {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.Format("2006-01-02 15:04")
+	}
+	return s
+}
+
 func extractRoleName(arn string) string {
 	// arn:aws:iam::123456789012:role/SageMakerRole → SageMakerRole
 	parts := strings.Split(arn, "/")
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestSyncAIDataSageMakerUsage(t *testing.T) {
+	parse := filepath.Join(testdata, "parse")
+	fake := awscli.NewFake().
+		On("sagemaker list-training-jobs", `{"TrainingJobSummaries": [{"TrainingJobName": "churn-xgb-2026-10-14-09-12"}, {"TrainingJobName": "gone"}]}`).
+		OnFile("sagemaker describe-training-job --training-job-name churn-xgb-2026-10-14-09-12", filepath.Join(parse, "parseSageMakerTrainingJob.json")).
+		On("sagemaker list-pipelines", `{"PipelineSummaries": [{"PipelineName": "churn-retrain"}]}`).
+		On("sagemaker list-pipeline-executions --pipeline-name churn-retrain", `{"PipelineExecutionSummaries": [{"StartTime": "2026-10-13T02:00:05+00:00", "PipelineExecutionStatus": "Failed", "PipelineExecutionFailureReason": "Step Train failed"}]}`).
+		On("sagemaker list-domains", `{"Domains": [{"DomainId": "d-abc123xyz", "DomainName": "data-science", "Status": "InService"}]}`).
+		OnFile("sagemaker describe-domain --domain-id d-abc123xyz", filepath.Join(parse, "parseSageMakerDomain.json")).
+		On("sagemaker list-user-profiles --domain-id-equals d-abc123xyz", `{"UserProfiles": [{"UserProfileName": "alice"}, {"UserProfileName": "bob"}]}`)
+	defer awscli.Use(fake)()
+
+	results, err := SyncAIData(nil, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Service] = r.Count
+	}
+	if counts["sagemaker-training-jobs"] != 2 || counts["sagemaker-pipelines"] != 1 || counts["sagemaker-domains"] != 1 {
+		t.Fatalf("results = %+v", results)
+	}
+
+	data, err := LoadAIData("us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j := data.TrainingJobs[0]; j.Capacity() != "2 × ml.m5.xlarge" || j.Duration() != "1h2m43s" {
+		t.Errorf("training job = %+v", j)
+	}
+	if j := data.TrainingJobs[1]; j.Name != "gone" || j.Status != "" {
+		t.Errorf("undescribable job = %+v, want name only", j)
+	}
+	if p := data.Pipelines[0]; p.LastStatus != "Failed" || p.LastExecution != "2026-10-13 02:00" || p.LastFailureReason == "" {
+		t.Errorf("pipeline = %+v", p)
+	}
+	if d := data.Domains[0]; d.VpcId != "vpc-0a1b2c3d" || len(d.UserProfiles) != 2 {
+		t.Errorf("domain = %+v", d)
+	}
+}
+
+func TestSyncAIDataBedrockKnowledgeBases(t *testing.T) {
+	parse := filepath.Join(testdata, "parse")
+	fake := awscli.NewFake().
+		On("bedrock-agent list-knowledge-bases", `{"knowledgeBaseSummaries": [{"knowledgeBaseId": "KB4QW8ZR1M", "name": "product-docs"}]}`).
+		OnFile("bedrock-agent get-knowledge-base --knowledge-base-id KB4QW8ZR1M", filepath.Join(parse, "parseBedrockKnowledgeBase.json")).
+		On("bedrock-agent list-data-sources --knowledge-base-id KB4QW8ZR1M", `{"dataSourceSummaries": [{"dataSourceId": "DS9HJ3KL2N"}, {"dataSourceId": "DSWEB"}]}`).
+		OnFile("bedrock-agent get-data-source --data-source-id DS9HJ3KL2N", filepath.Join(parse, "parseBedrockDataSource.json")).
+		On("bedrock-agent get-data-source --data-source-id DSWEB", `{"dataSource": {"dataSourceId": "DSWEB", "name": "site", "dataSourceConfiguration": {"type": "WEB"}}}`)
+	defer awscli.Use(fake)()
+
+	const region = "eu-west-3"
+	WriteCache(region+":opensearch", []byte(`{"DomainStatusList": [{"DomainName": "search-prod"}]}`))
+	WriteCache("s3", []byte(`{"Buckets": [{"Name": "acme-product-docs"}]}`))
+
+	if _, err := SyncAIData(nil, region); err != nil {
+		t.Fatal(err)
+	}
+	data, err := LoadAIData(region)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.KnowledgeBases) != 1 {
+		t.Fatalf("knowledge bases = %+v", data.KnowledgeBases)
+	}
+	kb := data.KnowledgeBases[0]
+	if !kb.VectorStoreCached {
+		t.Errorf("vector store %q not resolved to the cached OpenSearch domain", kb.VectorStore)
+	}
+	if len(kb.DataSources) != 2 || !kb.DataSources[0].BucketCached || kb.DataSources[1].BucketCached {
+		t.Errorf("data sources = %+v, want only the S3 source resolved", kb.DataSources)
+	}
+}
@@ -0,0 +1,399 @@
+package sync
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+)
+
+// A Sanitizer rewrites account IDs, IP addresses, ARNs and resource names
+// so a bundle can be shared, e.g. to reproduce a rendering bug, without
+// revealing the infrastructure it came from. Rewrites are deterministic:
+// the same value always becomes the same replacement, so a function's
+// name, its ARN and the queue policy naming it still line up. They are
+// keyed with a secret salt, so replacements can't be reversed by hashing
+// guessed names.
+//
+// Addresses are rewritten prefix-preserving: two addresses in one subnet
+// stay in one subnet, private addresses stay private, and CIDR blocks
+// keep their length. AWS resource IDs (vpc-0abc..., i-0abc...), AWS-owned
+// ARNs and service names are left as they are.
+type Sanitizer struct {
+	salt  []byte
+	names map[string]bool // plain tokens that were rewritten as names
+	addrs map[netip.Addr]netip.Addr
+	// done holds the account IDs and addresses already written, which a
+	// value passing through twice must not rewrite again.
+	done map[string]bool
+}
+
+// anonymizeSaltKey is the setting holding the cache's salt, so repeated
+// exports of one cache anonymize alike.
+const anonymizeSaltKey = "anonymize_salt"
+
+// NewSanitizer returns a Sanitizer keyed with the cache's salt, creating
+// one on first use. In read-only mode a salt that doesn't exist yet can't
+// be stored, and a fresh one is used for this export only.
+func NewSanitizer() (*Sanitizer, error) {
+	salt, err := GetSetting(anonymizeSaltKey)
+	if err != nil {
+		return nil, err
+	}
+	if salt == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		salt = hex.EncodeToString(b)
+		if err := SetSetting(anonymizeSaltKey, salt); err != nil && err != ErrReadOnly {
+			return nil, err
+		}
+	}
+	return newSanitizer(salt), nil
+}
+
+func newSanitizer(salt string) *Sanitizer {
+	return &Sanitizer{salt: []byte(salt), names: map[string]bool{}, addrs: map[netip.Addr]netip.Addr{}, done: map[string]bool{}}
+}
+
+var (
+	arnPattern     = regexp.MustCompile(`arn:aws[a-z-]*:[a-z0-9-]*:[a-z0-9-]*:[a-z0-9-]*:[^\s"',\\]+`)
+	accountPattern = regexp.MustCompile(`\b\d{12}\b`)
+	ipv4Pattern    = regexp.MustCompile(`\b\d{1,3}(\.\d{1,3}){3}(/\d{1,2})?\b`)
+	ipv6Pattern    = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(:[0-9A-Fa-f]{0,4}){2,7}(/\d{1,3})?`)
+	tokenPattern   = regexp.MustCompile(`[A-Za-z0-9_-]+`)
+	arnSeparator   = regexp.MustCompile(`[/:]`)
+	// awsID matches generated identifiers: vpc-0abc..., tgw-attach-0abc...,
+	// and plain hex or UUIDs.
+	awsID = regexp.MustCompile(`^([a-z]+(-[a-z]+)*-[0-9a-f]{8,17}|[0-9a-f-]{8,})$`)
+)
+
+// keepNameKeys are JSON keys, lower-cased, that look like names but name
+// things AWS defines rather than the account's resources.
+var keepNameKeys = map[string]bool{
+	"regionname": true, "zonename": true, "devicename": true, "enginename": true,
+	"metricname": true, "servicename": true, "namespace": true,
+}
+
+// arnKeepWords are ARN resource path segments that describe the resource
+// rather than name it.
+var arnKeepWords = map[string]bool{
+	"app": true, "net": true, "gwy": true, "LATEST": true,
+	"service-role": true, "aws-service-role": true, "aws-reserved": true,
+}
+
+// Bundle anonymizes b in place: its account, every cache entry and the
+// tagged resources. Names are gathered from every entry first, so a name
+// is also replaced where it appears inside other values, such as endpoint
+// hostnames.
+func (s *Sanitizer) Bundle(b *Bundle) error {
+	values := make([]interface{}, len(b.Entries))
+	for i, e := range b.Entries {
+		var v interface{}
+		if err := json.Unmarshal([]byte(e.Value), &v); err != nil {
+			// Not JSON; rewrite it as text in the second pass.
+			v = e.Value
+		} else {
+			v = s.rewriteNames(v, "")
+		}
+		values[i] = v
+	}
+	for i := range b.Tags {
+		t := &b.Tags[i]
+		t.ResourceId = s.Name(t.ResourceId)
+		for j := range t.Tags {
+			t.Tags[j].Value = s.Name(t.Tags[j].Value)
+		}
+	}
+
+	for i, v := range values {
+		v = s.text(v)
+		if str, ok := v.(string); ok {
+			b.Entries[i].Value = str
+			continue
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("anonymizing %s: %w", b.Entries[i].Key, err)
+		}
+		b.Entries[i].Value = string(out)
+	}
+	for i := range b.Tags {
+		b.Tags[i].Arn = s.String(b.Tags[i].Arn)
+	}
+	if b.Account != "" {
+		b.Account = s.account(b.Account)
+	}
+	return nil
+}
+
+// rewriteNames rewrites the values of name keys and tags in v, recording
+// the names it replaces.
+func (s *Sanitizer) rewriteNames(v interface{}, key string) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		// A tag in list form: {"Key": "Name", "Value": "orders"}.
+		if _, hasKey := x["Key"].(string); hasKey {
+			if val, ok := x["Value"].(string); ok {
+				x["Value"] = s.Name(val)
+			}
+		}
+		for k, child := range x {
+			if k == "Value" {
+				continue
+			}
+			if isTagMap(k) {
+				if tags, ok := child.(map[string]interface{}); ok {
+					for tk, tv := range tags {
+						if str, ok := tv.(string); ok {
+							tags[tk] = s.Name(str)
+						}
+					}
+					continue
+				}
+			}
+			x[k] = s.rewriteNames(child, k)
+		}
+		return x
+	case []interface{}:
+		for i, child := range x {
+			x[i] = s.rewriteNames(child, key)
+		}
+		return x
+	case string:
+		if isNameKey(key) {
+			return s.Name(x)
+		}
+	}
+	return v
+}
+
+// text rewrites ARNs, account IDs, addresses and known names in every
+// string of v.
+func (s *Sanitizer) text(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, child := range x {
+			x[k] = s.text(child)
+		}
+		return x
+	case []interface{}:
+		for i, child := range x {
+			x[i] = s.text(child)
+		}
+		return x
+	case string:
+		return s.String(x)
+	}
+	return v
+}
+
+func isNameKey(key string) bool {
+	k := strings.ToLower(key)
+	if keepNameKeys[k] {
+		return false
+	}
+	return strings.HasSuffix(k, "name") || strings.HasSuffix(k, "identifier") || k == "description"
+}
+
+func isTagMap(key string) bool {
+	return strings.EqualFold(key, "tags") || strings.EqualFold(key, "taglist")
+}
+
+// Name rewrites a resource name, token by token, so "orders-db/replica"
+// becomes "anon-1a2b3c4d/anon-5e6f7a8b". IDs, numbers and the last label of
+// a domain name are kept, as are AWS-owned names (AWS..., aws:...,
+// com.amazonaws...). An ARN is rewritten as one.
+func (s *Sanitizer) Name(name string) string {
+	if strings.HasPrefix(name, "arn:") {
+		return s.arn(name)
+	}
+	if name == "" || strings.HasPrefix(name, "AWS") || strings.HasPrefix(name, "aws:") || strings.HasPrefix(name, "com.amazonaws.") {
+		return name
+	}
+	domain := strings.Contains(name, ".") && !strings.ContainsAny(name, " /")
+	tokens := tokenPattern.FindAllStringIndex(name, -1)
+	var b strings.Builder
+	last := 0
+	for i, loc := range tokens {
+		tok := name[loc[0]:loc[1]]
+		b.WriteString(name[last:loc[0]])
+		last = loc[1]
+		if domain && i == len(tokens)-1 {
+			b.WriteString(tok)
+			continue
+		}
+		b.WriteString(s.token(tok))
+	}
+	b.WriteString(name[last:])
+	return b.String()
+}
+
+// token rewrites one name token, unless it is an ID or a number.
+func (s *Sanitizer) token(tok string) string {
+	if awsID.MatchString(tok) || strings.Trim(tok, "0123456789") == "" || strings.HasPrefix(tok, "anon-") {
+		return tok
+	}
+	if len(tok) >= 3 {
+		s.names[tok] = true
+	}
+	return "anon-" + hex.EncodeToString(s.hash("name", tok))[:8]
+}
+
+// String rewrites the ARNs, account IDs, IP addresses and known names in
+// free text, such as a policy document or an endpoint hostname.
+func (s *Sanitizer) String(str string) string {
+	str = arnPattern.ReplaceAllStringFunc(str, s.arn)
+	str = accountPattern.ReplaceAllStringFunc(str, s.account)
+	str = ipv4Pattern.ReplaceAllStringFunc(str, s.address)
+	str = replaceDelimited(ipv6Pattern, str, s.address)
+	return tokenPattern.ReplaceAllStringFunc(str, func(tok string) string {
+		if s.names[tok] {
+			return s.token(tok)
+		}
+		return tok
+	})
+}
+
+// arn rewrites an ARN's account and the names in its resource part. The
+// resource type (function:, role/, db:) is kept, as are ARNs owned by AWS.
+func (s *Sanitizer) arn(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) < 6 || parts[4] == "aws" {
+		return arn
+	}
+	service, resource := parts[2], parts[5]
+	if parts[4] != "" {
+		parts[4] = s.account(parts[4])
+	}
+	segments := arnSeparator.Split(resource, -1)
+	seps := arnSeparator.FindAllString(resource, -1)
+	var b strings.Builder
+	for i, seg := range segments {
+		if i > 0 {
+			b.WriteString(seps[i-1])
+		}
+		keep := (i == 0 && len(segments) > 1 && service != "s3") || arnKeepWords[seg] || seg == "*"
+		if keep {
+			b.WriteString(seg)
+		} else {
+			b.WriteString(s.Name(seg))
+		}
+	}
+	parts[5] = b.String()
+	return strings.Join(parts, ":")
+}
+
+// account maps a 12-digit account ID to another.
+func (s *Sanitizer) account(id string) string {
+	if s.done[id] {
+		return id
+	}
+	h := s.hash("account", id)
+	out := make([]byte, 12)
+	for i := range out {
+		out[i] = '0' + h[i]%10
+	}
+	s.done[string(out)] = true
+	return string(out)
+}
+
+// replaceDelimited is ReplaceAllStringFunc for matches that are not part
+// of a longer word, such as the "::a" in "arn:aws:iam::aws:...".
+func replaceDelimited(re *regexp.Regexp, str string, repl func(string) string) string {
+	word := func(c byte) bool {
+		return c == '_' || c == '-' || c == '.' || c == ':' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(str, -1) {
+		if loc[0] > 0 && word(str[loc[0]-1]) || loc[1] < len(str) && word(str[loc[1]]) {
+			continue
+		}
+		b.WriteString(str[last:loc[0]])
+		b.WriteString(repl(str[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(str[last:])
+	return b.String()
+}
+
+// address rewrites an IPv4 or IPv6 address or CIDR block. Anything that
+// doesn't parse as one is returned as is.
+func (s *Sanitizer) address(str string) string {
+	if s.done[str] {
+		return str
+	}
+	addr, bits := str, -1
+	if a, _, ok := strings.Cut(str, "/"); ok {
+		p, err := netip.ParsePrefix(str)
+		if err != nil {
+			return str
+		}
+		addr, bits = a, p.Bits()
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil || ip.IsUnspecified() {
+		return str
+	}
+	if ip.Is4() && ip == netip.AddrFrom4([4]byte{255, 255, 255, 255}) {
+		return str
+	}
+	res := s.permute(ip).String()
+	if bits >= 0 {
+		p, _ := s.permute(ip).Prefix(bits)
+		res = p.String()
+	}
+	s.done[res] = true
+	return res
+}
+
+// permute maps ip prefix-preservingly: bit i of the result is bit i of ip
+// flipped by a keyed hash of the bits before it. The bits that make an
+// address private, link-local or loopback are kept.
+func (s *Sanitizer) permute(ip netip.Addr) netip.Addr {
+	if out, ok := s.addrs[ip]; ok {
+		return out
+	}
+	raw := ip.AsSlice()
+	keep := keptBits(ip)
+	out := make([]byte, len(raw))
+	copy(out, raw)
+	prefix := make([]byte, 0, len(raw)*8)
+	for i := 0; i < len(raw)*8; i++ {
+		bit := raw[i/8] >> (7 - i%8) & 1
+		if i >= keep && s.hash("ip", string(prefix))[0]&1 == 1 {
+			out[i/8] ^= 1 << (7 - i%8)
+		}
+		prefix = append(prefix, '0'+bit)
+	}
+	a, _ := netip.AddrFromSlice(out)
+	s.addrs[ip] = a
+	return a
+}
+
+func keptBits(ip netip.Addr) int {
+	switch {
+	case ip.Is6():
+		return 16
+	case netip.MustParsePrefix("192.168.0.0/16").Contains(ip), netip.MustParsePrefix("169.254.0.0/16").Contains(ip):
+		return 16
+	case netip.MustParsePrefix("172.16.0.0/12").Contains(ip):
+		return 12
+	case netip.MustParsePrefix("100.64.0.0/10").Contains(ip):
+		return 10
+	}
+	return 8
+}
+
+func (s *Sanitizer) hash(kind, value string) []byte {
+	m := hmac.New(sha256.New, s.salt)
+	m.Write([]byte(kind + "\x00" + value))
+	return m.Sum(nil)
+}
@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeBundle(t *testing.T) {
+	bundle := func() *Bundle {
+		return &Bundle{
+			Account: "123456789012",
+			Entries: []BundleEntry{
+				{Key: "eu-west-1:lambda", Value: `{"Functions": [{"FunctionName": "orders-api",
+					"FunctionArn": "arn:aws:lambda:eu-west-1:123456789012:function:orders-api",
+					"Role": "arn:aws:iam::123456789012:role/service-role/orders-api-role",
+					"VpcConfig": {"SubnetIds": ["subnet-0abc1234def567890"]},
+					"Policy": "{\"Principal\":{\"AWS\":\"arn:aws:iam::123456789012:root\"}}"}]}`},
+				{Key: "eu-west-1:rds", Value: `{"DBInstances": [{"DBInstanceIdentifier": "orders-api",
+					"Endpoint": {"Address": "orders-api.c1x2y3z4.eu-west-1.rds.amazonaws.com"},
+					"TagList": [{"Key": "owner", "Value": "alice"}]}]}`},
+				{Key: "eu-west-1:vpc", Value: `{"Vpcs": [{"VpcId": "vpc-0abc1234", "CidrBlock": "10.20.0.0/16"}],
+					"Subnets": [{"CidrBlock": "10.20.1.0/24", "Ip": "10.20.1.17", "Public": "54.12.1.9"}],
+					"Endpoint": {"ServiceName": "com.amazonaws.eu-west-1.s3"},
+					"Policy": "arn:aws:iam::aws:policy/ReadOnlyAccess"}`},
+			},
+			Tags: []TaggedResource{{Arn: "arn:aws:lambda:eu-west-1:123456789012:function:orders-api",
+				ResourceId: "orders-api", Tags: []ResourceTag{{Key: "team", Value: "payments"}}}},
+		}
+	}
+	b := bundle()
+	if err := newSanitizer("salt").Bundle(b); err != nil {
+		t.Fatal(err)
+	}
+	all := b.Account
+	for _, e := range b.Entries {
+		all += e.Value
+	}
+	tags, _ := json.Marshal(b.Tags)
+	all += string(tags)
+	for _, secret := range []string{"123456789012", "orders", "alice", "payments", "10.20.1.17", "54.12.1.9"} {
+		if strings.Contains(all, secret) {
+			t.Errorf("%q survived anonymizing", secret)
+		}
+	}
+	for _, kept := range []string{"subnet-0abc1234def567890", "vpc-0abc1234", "com.amazonaws.eu-west-1.s3",
+		"arn:aws:iam::aws:policy/ReadOnlyAccess", "rds.amazonaws.com", ":function:", ":role/service-role/", `"Key":"owner"`, `"Key":"team"`} {
+		if !strings.Contains(all, kept) {
+			t.Errorf("%q was rewritten", kept)
+		}
+	}
+
+	// The function's name, its ARN and the RDS endpoint still line up.
+	var fn struct {
+		Functions []struct{ FunctionName, FunctionArn string }
+	}
+	json.Unmarshal([]byte(b.Entries[0].Value), &fn)
+	name := fn.Functions[0].FunctionName
+	if !strings.HasSuffix(fn.Functions[0].FunctionArn, ":"+b.Account+":function:"+name) {
+		t.Errorf("ARN %s doesn't match name %s and account %s", fn.Functions[0].FunctionArn, name, b.Account)
+	}
+	if !strings.Contains(b.Entries[1].Value, name+".c1x2y3z4.eu-west-1") {
+		t.Errorf("endpoint not rewritten like the name: %s", b.Entries[1].Value)
+	}
+
+	// Addresses keep their subnets and private ranges.
+	var vpc struct {
+		Vpcs    []struct{ CidrBlock string }
+		Subnets []struct{ CidrBlock, Ip string }
+	}
+	json.Unmarshal([]byte(b.Entries[2].Value), &vpc)
+	vpcCIDR := netip.MustParsePrefix(vpc.Vpcs[0].CidrBlock)
+	subnet := netip.MustParsePrefix(vpc.Subnets[0].CidrBlock)
+	ip := netip.MustParseAddr(vpc.Subnets[0].Ip)
+	if vpcCIDR.Bits() != 16 || subnet.Bits() != 24 || !vpcCIDR.Contains(subnet.Addr()) || !subnet.Contains(ip) || !ip.IsPrivate() {
+		t.Errorf("addresses lost their structure: vpc %s subnet %s ip %s", vpcCIDR, subnet, ip)
+	}
+	if subnet.Masked() != subnet {
+		t.Errorf("subnet %s has host bits set", subnet)
+	}
+
+	// The same salt rewrites alike; another salt doesn't.
+	again := bundle()
+	newSanitizer("salt").Bundle(again)
+	againJSON, _ := json.Marshal(again)
+	if bJSON, _ := json.Marshal(b); string(againJSON) != string(bJSON) {
+		t.Error("anonymizing is not deterministic")
+	}
+	other := bundle()
+	newSanitizer("pepper").Bundle(other)
+	if other.Account == b.Account {
+		t.Error("a different salt gave the same account")
+	}
+}
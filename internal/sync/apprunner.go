@@ -0,0 +1,224 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// AppRunnerService is an App Runner service with its instance size,
+// auto scaling configuration and VPC connector resolved.
+type AppRunnerService struct {
+	Name           string   `json:"Name"`
+	Arn            string   `json:"Arn"`
+	Url            string   `json:"Url"`
+	Status         string   `json:"Status"`     // RUNNING, PAUSED, OPERATION_IN_PROGRESS, CREATE_FAILED, ...
+	Cpu            string   `json:"Cpu"`        // e.g. "1 vCPU"
+	Memory         string   `json:"Memory"`     // e.g. "2 GB"
+	SourceType     string   `json:"SourceType"` // ECR, ECR_PUBLIC or CODE
+	Source         string   `json:"Source"`     // image identifier or repository URL
+	AutoDeploy     bool     `json:"AutoDeploy"`
+	Public         bool     `json:"Public"`
+	EgressType     string   `json:"EgressType"` // DEFAULT or VPC
+	VpcConnector   string   `json:"VpcConnector"`
+	SubnetIds      []string `json:"SubnetIds"`
+	SecurityGroups []string `json:"SecurityGroups"`
+	InstanceRole   string   `json:"InstanceRole"`
+	CreatedAt      string   `json:"CreatedAt"`
+
+	AutoScalingConfig string `json:"AutoScalingConfig"`
+	AutoScalingArn    string `json:"AutoScalingArn"`
+	MinSize           int    `json:"MinSize"`
+	MaxSize           int    `json:"MaxSize"`
+	MaxConcurrency    int    `json:"MaxConcurrency"` // requests per instance before scaling out
+	VpcConnectorArn   string `json:"VpcConnectorArn"`
+}
+
+// Scaling renders the auto scaling range, e.g. "1–25 instances · 100 req/instance".
+func (s AppRunnerService) Scaling() string {
+	if s.MaxSize == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d–%d instances · %d req/instance", s.MinSize, s.MaxSize, s.MaxConcurrency)
+}
+
+// SyncAppRunnerData fetches App Runner services with their auto scaling
+// configurations and VPC connectors.
+func SyncAppRunnerData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("apprunner", "list-services", "--region", region)
+	if err != nil {
+		step("app runner")
+		return []SyncResult{{Service: "apprunner", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		ServiceSummaryList []struct {
+			ServiceName string `json:"ServiceName"`
+			ServiceArn  string `json:"ServiceArn"`
+			Status      string `json:"Status"`
+		} `json:"ServiceSummaryList"`
+	}
+	json.Unmarshal(data, &resp)
+
+	type connector struct {
+		name            string
+		subnets, groups []string
+	}
+	connectors := map[string]connector{}
+	if len(resp.ServiceSummaryList) > 0 {
+		if cData, err := awscli.Run("apprunner", "list-vpc-connectors", "--region", region); err == nil {
+			var cResp struct {
+				VpcConnectors []struct {
+					VpcConnectorName string   `json:"VpcConnectorName"`
+					VpcConnectorArn  string   `json:"VpcConnectorArn"`
+					Subnets          []string `json:"Subnets"`
+					SecurityGroups   []string `json:"SecurityGroups"`
+				} `json:"VpcConnectors"`
+			}
+			json.Unmarshal(cData, &cResp)
+			for _, c := range cResp.VpcConnectors {
+				connectors[c.VpcConnectorArn] = connector{c.VpcConnectorName, c.Subnets, c.SecurityGroups}
+			}
+		}
+	}
+
+	// Services commonly share the default auto scaling configuration,
+	// so each one is described once.
+	type scaling struct{ min, max, concurrency int }
+	scalings := map[string]scaling{}
+
+	services := []AppRunnerService{}
+	for _, s := range resp.ServiceSummaryList {
+		svc := AppRunnerService{Name: s.ServiceName, Arn: s.ServiceArn, Status: s.Status}
+		if desc, err := awscli.Run("apprunner", "describe-service", "--service-arn", s.ServiceArn, "--region", region); err == nil {
+			svc = parseAppRunnerService(desc)
+		}
+		if c, ok := connectors[svc.VpcConnectorArn]; ok {
+			svc.VpcConnector, svc.SubnetIds, svc.SecurityGroups = c.name, c.subnets, c.groups
+		}
+		if arn := svc.AutoScalingArn; arn != "" {
+			sc, ok := scalings[arn]
+			if !ok {
+				if asData, err := awscli.Run("apprunner", "describe-auto-scaling-configuration",
+					"--auto-scaling-configuration-arn", arn, "--region", region); err == nil {
+					var as struct {
+						AutoScalingConfiguration struct {
+							MinSize        int `json:"MinSize"`
+							MaxSize        int `json:"MaxSize"`
+							MaxConcurrency int `json:"MaxConcurrency"`
+						} `json:"AutoScalingConfiguration"`
+					}
+					json.Unmarshal(asData, &as)
+					c := as.AutoScalingConfiguration
+					sc = scaling{c.MinSize, c.MaxSize, c.MaxConcurrency}
+				}
+				scalings[arn] = sc
+			}
+			svc.MinSize, svc.MaxSize, svc.MaxConcurrency = sc.min, sc.max, sc.concurrency
+		}
+		services = append(services, svc)
+	}
+	step("app runner")
+
+	b, _ := json.Marshal(services)
+	st.WriteCache(region+":apprunner", b)
+	return []SyncResult{{Service: "apprunner", Count: len(services)}}, nil
+}
+
+// LoadAppRunnerServices returns the cached App Runner services for region.
+func LoadAppRunnerServices(region string) ([]AppRunnerService, error) {
+	raw, err := ReadCache(region + ":apprunner")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var services []AppRunnerService
+	json.Unmarshal(raw, &services)
+	return services, nil
+}
+
+// parseAppRunnerService reads describe-service output. Scaling limits and
+// VPC connector subnets are filled in separately.
+func parseAppRunnerService(raw json.RawMessage) AppRunnerService {
+	var resp struct {
+		Service struct {
+			ServiceName         string `json:"ServiceName"`
+			ServiceArn          string `json:"ServiceArn"`
+			ServiceUrl          string `json:"ServiceUrl"`
+			Status              string `json:"Status"`
+			CreatedAt           string `json:"CreatedAt"`
+			SourceConfiguration struct {
+				ImageRepository *struct {
+					ImageIdentifier     string `json:"ImageIdentifier"`
+					ImageRepositoryType string `json:"ImageRepositoryType"`
+				} `json:"ImageRepository"`
+				CodeRepository *struct {
+					RepositoryUrl string `json:"RepositoryUrl"`
+				} `json:"CodeRepository"`
+				AutoDeploymentsEnabled bool `json:"AutoDeploymentsEnabled"`
+			} `json:"SourceConfiguration"`
+			InstanceConfiguration struct {
+				Cpu             string `json:"Cpu"`
+				Memory          string `json:"Memory"`
+				InstanceRoleArn string `json:"InstanceRoleArn"`
+			} `json:"InstanceConfiguration"`
+			AutoScalingConfigurationSummary struct {
+				AutoScalingConfigurationArn      string `json:"AutoScalingConfigurationArn"`
+				AutoScalingConfigurationName     string `json:"AutoScalingConfigurationName"`
+				AutoScalingConfigurationRevision int    `json:"AutoScalingConfigurationRevision"`
+			} `json:"AutoScalingConfigurationSummary"`
+			NetworkConfiguration struct {
+				EgressConfiguration struct {
+					EgressType      string `json:"EgressType"`
+					VpcConnectorArn string `json:"VpcConnectorArn"`
+				} `json:"EgressConfiguration"`
+				IngressConfiguration struct {
+					IsPubliclyAccessible bool `json:"IsPubliclyAccessible"`
+				} `json:"IngressConfiguration"`
+			} `json:"NetworkConfiguration"`
+		} `json:"Service"`
+	}
+	json.Unmarshal(raw, &resp)
+	s := resp.Service
+
+	svc := AppRunnerService{
+		Name:            s.ServiceName,
+		Arn:             s.ServiceArn,
+		Url:             s.ServiceUrl,
+		Status:          s.Status,
+		Cpu:             appRunnerSize(s.InstanceConfiguration.Cpu, "vCPU"),
+		Memory:          appRunnerSize(s.InstanceConfiguration.Memory, "GB"),
+		AutoDeploy:      s.SourceConfiguration.AutoDeploymentsEnabled,
+		Public:          s.NetworkConfiguration.IngressConfiguration.IsPubliclyAccessible,
+		EgressType:      s.NetworkConfiguration.EgressConfiguration.EgressType,
+		InstanceRole:    extractRoleName(s.InstanceConfiguration.InstanceRoleArn),
+		CreatedAt:       formatCLITime(s.CreatedAt),
+		AutoScalingArn:  s.AutoScalingConfigurationSummary.AutoScalingConfigurationArn,
+		VpcConnectorArn: s.NetworkConfiguration.EgressConfiguration.VpcConnectorArn,
+	}
+	if name := s.AutoScalingConfigurationSummary.AutoScalingConfigurationName; name != "" {
+		svc.AutoScalingConfig = fmt.Sprintf("%s (rev %d)", name, s.AutoScalingConfigurationSummary.AutoScalingConfigurationRevision)
+	}
+	if img := s.SourceConfiguration.ImageRepository; img != nil {
+		svc.SourceType, svc.Source = img.ImageRepositoryType, img.ImageIdentifier
+	} else if code := s.SourceConfiguration.CodeRepository; code != nil {
+		svc.SourceType, svc.Source = "CODE", code.RepositoryUrl
+	}
+	return svc
+}
+
+// appRunnerSize renders CPU units or memory MiB ("1024") in vCPU or GB;
+// values already carrying a unit ("1 vCPU") are returned as-is.
+func appRunnerSize(v, unit string) string {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return v
+	}
+	return strconv.FormatFloat(float64(n)/1024, 'f', -1, 64) + " " + unit
+}
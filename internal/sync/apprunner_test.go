@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestSyncAppRunnerData(t *testing.T) {
+	fixture := filepath.Join(testdata, "parse", "parseAppRunnerService.json")
+	fake := awscli.NewFake().
+		On("apprunner list-services", `{"ServiceSummaryList": [{"ServiceName": "checkout-api", "ServiceArn": "arn:svc/a"}, {"ServiceName": "checkout-worker", "ServiceArn": "arn:svc/b"}]}`).
+		OnFile("apprunner describe-service", fixture).
+		On("apprunner list-vpc-connectors", `{"VpcConnectors": [{"VpcConnectorName": "private-db", "VpcConnectorArn": "arn:aws:apprunner:us-east-1:123456789012:vpcconnector/private-db/1/9f8e7d6c", "Subnets": ["subnet-1", "subnet-2"], "SecurityGroups": ["sg-1"]}]}`).
+		On("apprunner describe-auto-scaling-configuration", `{"AutoScalingConfiguration": {"MinSize": 1, "MaxSize": 25, "MaxConcurrency": 100}}`)
+	defer awscli.Use(fake)()
+
+	results, err := SyncAppRunnerData(nil, "us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error != "" || results[0].Count != 2 {
+		t.Fatalf("results = %+v, want 2 apprunner services", results)
+	}
+
+	services, err := LoadAppRunnerServices("us-east-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := services[0]
+	if svc.VpcConnector != "private-db" || len(svc.SubnetIds) != 2 || len(svc.SecurityGroups) != 1 {
+		t.Errorf("VPC connector not resolved: %+v", svc)
+	}
+	if got := svc.Scaling(); got != "1–25 instances · 100 req/instance" {
+		t.Errorf("scaling = %q", got)
+	}
+	described := 0
+	for _, c := range fake.Calls() {
+		if strings.Contains(strings.Join(c, " "), "describe-auto-scaling-configuration") {
+			described++
+		}
+	}
+	if described != 1 {
+		t.Errorf("shared auto scaling configuration described %d times, want 1", described)
+	}
+}
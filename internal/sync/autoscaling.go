@@ -0,0 +1,326 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// AutoScalingData holds Auto Scaling groups and the launch templates and
+// launch configurations they launch from.
+type AutoScalingData struct {
+	Groups               []AutoScalingGroup    `json:"groups"`
+	LaunchTemplates      []LaunchTemplate      `json:"launchTemplates"`
+	LaunchConfigurations []LaunchConfiguration `json:"launchConfigurations"`
+}
+
+type AutoScalingGroup struct {
+	Name                string          `json:"Name"`
+	Arn                 string          `json:"Arn"`
+	Status              string          `json:"Status"` // empty unless being deleted
+	DesiredCapacity     int             `json:"DesiredCapacity"`
+	MinSize             int             `json:"MinSize"`
+	MaxSize             int             `json:"MaxSize"`
+	HealthCheckType     string          `json:"HealthCheckType"`
+	SubnetIds           []string        `json:"SubnetIds"`
+	LaunchTemplate      string          `json:"LaunchTemplate"`        // template name, also set for mixed instances
+	LaunchTemplateVer   string          `json:"LaunchTemplateVersion"` // $Default, $Latest or a number
+	LaunchConfiguration string          `json:"LaunchConfiguration"`
+	MixedInstances      *MixedInstances `json:"MixedInstances,omitempty"`
+	TargetGroupArns     []string        `json:"TargetGroupArns"`
+	LoadBalancerNames   []string        `json:"LoadBalancerNames"` // Classic ELBs
+	Instances           []ASGInstance   `json:"Instances"`
+	CreatedTime         string          `json:"CreatedTime"`
+}
+
+// MixedInstances summarises a mixed instances policy: the instance type
+// overrides and the On-Demand/Spot split.
+type MixedInstances struct {
+	InstanceTypes          []string `json:"InstanceTypes"`
+	OnDemandBase           int      `json:"OnDemandBase"`
+	OnDemandPercentAbove   int      `json:"OnDemandPercentAbove"` // of capacity above the base
+	SpotAllocationStrategy string   `json:"SpotAllocationStrategy"`
+}
+
+type ASGInstance struct {
+	InstanceId           string `json:"InstanceId"`
+	InstanceType         string `json:"InstanceType"`
+	AvailabilityZone     string `json:"AvailabilityZone"`
+	LifecycleState       string `json:"LifecycleState"` // InService, Pending, Terminating, ...
+	HealthStatus         string `json:"HealthStatus"`
+	ProtectedFromScaleIn bool   `json:"ProtectedFromScaleIn"`
+}
+
+// TargetGroupNames returns the names of the group's target groups, taken
+// from their ARNs (arn:...:targetgroup/name/id).
+func (g AutoScalingGroup) TargetGroupNames() []string {
+	var out []string
+	for _, arn := range g.TargetGroupArns {
+		parts := strings.Split(arn, "/")
+		if len(parts) >= 2 {
+			out = append(out, parts[1])
+		} else {
+			out = append(out, arn)
+		}
+	}
+	return out
+}
+
+// LaunchSource describes what the group launches from, e.g.
+// "lt:web-template ($Latest)" or "lc:web-2019".
+func (g AutoScalingGroup) LaunchSource() string {
+	switch {
+	case g.LaunchTemplate != "":
+		return "lt:" + g.LaunchTemplate + " (" + g.LaunchTemplateVer + ")"
+	case g.LaunchConfiguration != "":
+		return "lc:" + g.LaunchConfiguration
+	}
+	return ""
+}
+
+// LaunchTemplate is a launch template summarised from its default version.
+type LaunchTemplate struct {
+	Id             string   `json:"Id"`
+	Name           string   `json:"Name"`
+	DefaultVersion int      `json:"DefaultVersion"`
+	LatestVersion  int      `json:"LatestVersion"`
+	ImageId        string   `json:"ImageId"`
+	InstanceType   string   `json:"InstanceType"`
+	KeyName        string   `json:"KeyName"`
+	IamProfile     string   `json:"IamProfile"`
+	SecurityGroups []string `json:"SecurityGroups"`
+	Spot           bool     `json:"Spot"`
+	CreateTime     string   `json:"CreateTime"`
+}
+
+// LaunchConfiguration is a legacy launch configuration.
+type LaunchConfiguration struct {
+	Name           string   `json:"LaunchConfigurationName"`
+	ImageId        string   `json:"ImageId"`
+	InstanceType   string   `json:"InstanceType"`
+	KeyName        string   `json:"KeyName"`
+	IamProfile     string   `json:"IamInstanceProfile"`
+	SecurityGroups []string `json:"SecurityGroups"`
+	SpotPrice      string   `json:"SpotPrice"`
+	CreatedTime    string   `json:"CreatedTime"`
+}
+
+// SyncAutoScalingData fetches Auto Scaling groups with their instances,
+// launch templates (default version) and launch configurations.
+func SyncAutoScalingData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	var results []SyncResult
+	data := AutoScalingData{}
+
+	if raw, err := awscli.Run("autoscaling", "describe-auto-scaling-groups", "--region", region); err == nil {
+		var resp struct {
+			AutoScalingGroups []json.RawMessage `json:"AutoScalingGroups"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, g := range resp.AutoScalingGroups {
+			data.Groups = append(data.Groups, parseAutoScalingGroup(g))
+		}
+		results = append(results, SyncResult{Service: "autoscaling", Count: len(data.Groups)})
+	} else {
+		results = append(results, SyncResult{Service: "autoscaling", Error: err.Error()})
+	}
+	step("auto scaling groups")
+
+	if raw, err := awscli.Run("ec2", "describe-launch-templates", "--region", region); err == nil {
+		var resp struct {
+			LaunchTemplates []struct {
+				LaunchTemplateId     string `json:"LaunchTemplateId"`
+				LaunchTemplateName   string `json:"LaunchTemplateName"`
+				DefaultVersionNumber int    `json:"DefaultVersionNumber"`
+				LatestVersionNumber  int    `json:"LatestVersionNumber"`
+				CreateTime           string `json:"CreateTime"`
+			} `json:"LaunchTemplates"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, t := range resp.LaunchTemplates {
+			lt := LaunchTemplate{
+				Id:             t.LaunchTemplateId,
+				Name:           t.LaunchTemplateName,
+				DefaultVersion: t.DefaultVersionNumber,
+				LatestVersion:  t.LatestVersionNumber,
+				CreateTime:     t.CreateTime,
+			}
+			if vData, err := awscli.Run("ec2", "describe-launch-template-versions",
+				"--launch-template-id", t.LaunchTemplateId, "--versions", "$Default", "--region", region); err == nil {
+				applyLaunchTemplateVersion(&lt, vData)
+			}
+			data.LaunchTemplates = append(data.LaunchTemplates, lt)
+		}
+		results = append(results, SyncResult{Service: "launch-templates", Count: len(data.LaunchTemplates)})
+	} else {
+		results = append(results, SyncResult{Service: "launch-templates", Error: err.Error()})
+	}
+	step("launch templates")
+
+	if raw, err := awscli.Run("autoscaling", "describe-launch-configurations", "--region", region); err == nil {
+		var resp struct {
+			LaunchConfigurations []LaunchConfiguration `json:"LaunchConfigurations"`
+		}
+		json.Unmarshal(raw, &resp)
+		data.LaunchConfigurations = resp.LaunchConfigurations
+	}
+	step("launch configurations")
+
+	b, _ := json.Marshal(data)
+	st.WriteCache(region+":autoscaling", b)
+	return results, nil
+}
+
+func parseAutoScalingGroup(raw json.RawMessage) AutoScalingGroup {
+	type ltSpec struct {
+		LaunchTemplateName string `json:"LaunchTemplateName"`
+		Version            string `json:"Version"`
+	}
+	var r struct {
+		AutoScalingGroupName    string   `json:"AutoScalingGroupName"`
+		AutoScalingGroupARN     string   `json:"AutoScalingGroupARN"`
+		Status                  string   `json:"Status"`
+		DesiredCapacity         int      `json:"DesiredCapacity"`
+		MinSize                 int      `json:"MinSize"`
+		MaxSize                 int      `json:"MaxSize"`
+		HealthCheckType         string   `json:"HealthCheckType"`
+		VPCZoneIdentifier       string   `json:"VPCZoneIdentifier"`
+		LaunchConfigurationName string   `json:"LaunchConfigurationName"`
+		LaunchTemplate          *ltSpec  `json:"LaunchTemplate"`
+		TargetGroupARNs         []string `json:"TargetGroupARNs"`
+		LoadBalancerNames       []string `json:"LoadBalancerNames"`
+		CreatedTime             string   `json:"CreatedTime"`
+		MixedInstancesPolicy    *struct {
+			LaunchTemplate struct {
+				LaunchTemplateSpecification ltSpec `json:"LaunchTemplateSpecification"`
+				Overrides                   []struct {
+					InstanceType string `json:"InstanceType"`
+				} `json:"Overrides"`
+			} `json:"LaunchTemplate"`
+			InstancesDistribution struct {
+				OnDemandBaseCapacity                int    `json:"OnDemandBaseCapacity"`
+				OnDemandPercentageAboveBaseCapacity int    `json:"OnDemandPercentageAboveBaseCapacity"`
+				SpotAllocationStrategy              string `json:"SpotAllocationStrategy"`
+			} `json:"InstancesDistribution"`
+		} `json:"MixedInstancesPolicy"`
+		Instances []ASGInstance `json:"Instances"`
+	}
+	json.Unmarshal(raw, &r)
+
+	g := AutoScalingGroup{
+		Name:                r.AutoScalingGroupName,
+		Arn:                 r.AutoScalingGroupARN,
+		Status:              r.Status,
+		DesiredCapacity:     r.DesiredCapacity,
+		MinSize:             r.MinSize,
+		MaxSize:             r.MaxSize,
+		HealthCheckType:     r.HealthCheckType,
+		LaunchConfiguration: r.LaunchConfigurationName,
+		TargetGroupArns:     r.TargetGroupARNs,
+		LoadBalancerNames:   r.LoadBalancerNames,
+		Instances:           r.Instances,
+		CreatedTime:         r.CreatedTime,
+	}
+	for _, s := range strings.Split(r.VPCZoneIdentifier, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			g.SubnetIds = append(g.SubnetIds, s)
+		}
+	}
+	if r.LaunchTemplate != nil {
+		g.LaunchTemplate = r.LaunchTemplate.LaunchTemplateName
+		g.LaunchTemplateVer = r.LaunchTemplate.Version
+	}
+	if p := r.MixedInstancesPolicy; p != nil {
+		spec := p.LaunchTemplate.LaunchTemplateSpecification
+		g.LaunchTemplate = spec.LaunchTemplateName
+		g.LaunchTemplateVer = spec.Version
+		mi := &MixedInstances{
+			OnDemandBase:           p.InstancesDistribution.OnDemandBaseCapacity,
+			OnDemandPercentAbove:   p.InstancesDistribution.OnDemandPercentageAboveBaseCapacity,
+			SpotAllocationStrategy: p.InstancesDistribution.SpotAllocationStrategy,
+		}
+		for _, o := range p.LaunchTemplate.Overrides {
+			if o.InstanceType != "" {
+				mi.InstanceTypes = append(mi.InstanceTypes, o.InstanceType)
+			}
+		}
+		g.MixedInstances = mi
+	}
+	if g.LaunchTemplate != "" && g.LaunchTemplateVer == "" {
+		g.LaunchTemplateVer = "$Default"
+	}
+	return g
+}
+
+func applyLaunchTemplateVersion(lt *LaunchTemplate, raw []byte) {
+	var resp struct {
+		LaunchTemplateVersions []struct {
+			LaunchTemplateData struct {
+				ImageId            string `json:"ImageId"`
+				InstanceType       string `json:"InstanceType"`
+				KeyName            string `json:"KeyName"`
+				IamInstanceProfile struct {
+					Arn  string `json:"Arn"`
+					Name string `json:"Name"`
+				} `json:"IamInstanceProfile"`
+				SecurityGroupIds  []string `json:"SecurityGroupIds"`
+				NetworkInterfaces []struct {
+					Groups []string `json:"Groups"`
+				} `json:"NetworkInterfaces"`
+				InstanceMarketOptions struct {
+					MarketType string `json:"MarketType"`
+				} `json:"InstanceMarketOptions"`
+			} `json:"LaunchTemplateData"`
+		} `json:"LaunchTemplateVersions"`
+	}
+	json.Unmarshal(raw, &resp)
+	if len(resp.LaunchTemplateVersions) == 0 {
+		return
+	}
+	d := resp.LaunchTemplateVersions[0].LaunchTemplateData
+	lt.ImageId = d.ImageId
+	lt.InstanceType = d.InstanceType
+	lt.KeyName = d.KeyName
+	lt.IamProfile = d.IamInstanceProfile.Name
+	if lt.IamProfile == "" && d.IamInstanceProfile.Arn != "" {
+		// arn:aws:iam::123456:instance-profile/MyProfile
+		lt.IamProfile = d.IamInstanceProfile.Arn[strings.LastIndex(d.IamInstanceProfile.Arn, "/")+1:]
+	}
+	lt.SecurityGroups = d.SecurityGroupIds
+	for _, ni := range d.NetworkInterfaces {
+		lt.SecurityGroups = append(lt.SecurityGroups, ni.Groups...)
+	}
+	lt.Spot = d.InstanceMarketOptions.MarketType == "spot"
+}
+
+// LoadAutoScalingData returns the cached Auto Scaling data for region.
+func LoadAutoScalingData(region string) (*AutoScalingData, error) {
+	raw, err := ReadCache(region + ":autoscaling")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var data AutoScalingData
+	json.Unmarshal(raw, &data)
+	return &data, nil
+}
+
+// GroupForInstance returns the name of the Auto Scaling group that owns
+// the instance, or "".
+func (d *AutoScalingData) GroupForInstance(instanceId string) string {
+	if d == nil {
+		return ""
+	}
+	for _, g := range d.Groups {
+		for _, inst := range g.Instances {
+			if inst.InstanceId == instanceId {
+				return g.Name
+			}
+		}
+	}
+	return ""
+}
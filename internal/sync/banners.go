@@ -0,0 +1,196 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// Banner is an account-wide condition worth showing on every page, such
+// as expiring certificates or expired credentials.
+type Banner struct {
+	Kind  string       `json:"kind"`
+	Level string       `json:"level"` // "error" or "warn"
+	Title string       `json:"title"`
+	Hint  string       `json:"hint,omitempty"`
+	Items []BannerItem `json:"items,omitempty"`
+}
+
+// BannerItem is one affected resource. Detail is the /detail/ path of the
+// resource, "" when it has no detail panel.
+type BannerItem struct {
+	Icon   string `json:"icon,omitempty"`
+	Label  string `json:"label"`
+	Tag    string `json:"tag,omitempty"`
+	Note   string `json:"note,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// BannerContext is what detectors can look at besides the cache.
+type BannerContext struct {
+	Region  string
+	AWS     awscli.Status
+	Offline bool // the AWS CLI isn't installed, so nothing can sync
+}
+
+// bannerDetectors run in order; errors come before warnings.
+var bannerDetectors = []func(BannerContext) *Banner{
+	ssoBanner,
+	syncFailureBanner,
+	offlineBanner,
+	trailBanner,
+	quotaBanner,
+	certBanner,
+}
+
+// Banners runs every detector and returns the conditions that apply.
+func Banners(ctx BannerContext) []Banner {
+	var out []Banner
+	for _, detect := range bannerDetectors {
+		if b := detect(ctx); b != nil {
+			out = append(out, *b)
+		}
+	}
+	return out
+}
+
+// ssoBanner trusts the latest sync over the status detected at startup,
+// so a successful sync after logging in clears it without a restart.
+func ssoBanner(ctx BannerContext) *Banner {
+	expired := ctx.AWS.SSOExpired
+	if errMsg, ok := lastSyncOutcome(); ok {
+		expired = awscli.SSOExpired(errMsg)
+	}
+	if !expired {
+		return nil
+	}
+	profile := ""
+	if ctx.AWS.Profile != "" {
+		profile = " --profile " + ctx.AWS.Profile
+	}
+	return &Banner{
+		Kind:  "sso",
+		Level: "error",
+		Title: "AWS SSO session expired",
+		Hint:  "Run 'aws sso login" + profile + "' and sync again; until then saws shows cached data.",
+	}
+}
+
+func syncFailureBanner(ctx BannerContext) *Banner {
+	failing := FailingSyncs(ctx.Region)
+	if len(failing) == 0 {
+		return nil
+	}
+	b := &Banner{
+		Kind:  "sync",
+		Level: "error",
+		Title: fmt.Sprintf("%d service%s failing to sync for %d+ days", len(failing), plural(len(failing)), SyncFailureDays),
+	}
+	for _, s := range failing {
+		last := "never synced"
+		if !s.LastSuccess.IsZero() {
+			last = "last synced " + s.LastSuccess.Format("2006-01-02")
+		}
+		b.Items = append(b.Items, BannerItem{
+			Label: s.Service,
+			Tag:   fmt.Sprintf("%dd", int(s.FailingFor()/(24*time.Hour))),
+			Note:  last + " · " + oneLine(s.LastError),
+		})
+	}
+	return b
+}
+
+func offlineBanner(ctx BannerContext) *Banner {
+	if !ctx.Offline {
+		return nil
+	}
+	hint := "Browsing, diffs, reports and exports use the local cache; syncing is disabled until the AWS CLI is installed."
+	title := "AWS CLI not found — offline mode"
+	if readOnly {
+		hint = "Browsing, diffs, reports and exports use the local cache; syncing and settings changes are disabled."
+		title = "Read-only mode"
+	}
+	if v := CacheUpdatedAt(); len(v) >= 10 {
+		hint = "Showing data cached up to " + v[:10] + ". " + hint
+	}
+	return &Banner{
+		Kind:  "offline",
+		Level: "warn",
+		Title: title,
+		Hint:  hint,
+	}
+}
+
+func trailBanner(ctx BannerContext) *Banner {
+	if active, ok := HasActiveTrail(ctx.Region); active || !ok {
+		return nil
+	}
+	return &Banner{
+		Kind:  "cloudtrail",
+		Level: "warn",
+		Title: "No active CloudTrail trail records " + ctx.Region,
+		Hint:  "API activity here is only kept in the 90-day event history. Enable a multi-region trail, or start logging on a stopped one.",
+	}
+}
+
+func quotaBanner(ctx BannerContext) *Banner {
+	quotas := QuotasNearLimit(ctx.Region)
+	if len(quotas) == 0 {
+		return nil
+	}
+	b := &Banner{
+		Kind:  "quota",
+		Level: "warn",
+		Title: fmt.Sprintf("%d service quota%s over %d%% used", len(quotas), plural(len(quotas)), QuotaWarningPct()),
+		Hint:  "Request an increase in the Service Quotas console before the next resource fails to create.",
+	}
+	for _, q := range quotas {
+		b.Items = append(b.Items, BannerItem{
+			Label: q.Name,
+			Tag:   fmt.Sprintf("%.0f%%", q.UsedPercent()),
+			Note:  fmt.Sprintf("%s · %s %s", q.UsageText(), q.ServiceCode, q.QuotaCode),
+		})
+	}
+	return b
+}
+
+func certBanner(ctx BannerContext) *Banner {
+	certs := ExpiringCertificates(ctx.Region)
+	if len(certs) == 0 {
+		return nil
+	}
+	b := &Banner{
+		Kind:  "certs",
+		Level: "warn",
+		Title: fmt.Sprintf("%d certificate%s expiring within %d days", len(certs), plural(len(certs)), CertWarningDays()),
+	}
+	for _, c := range certs {
+		tag := fmt.Sprintf("%dd left", c.DaysLeft())
+		if c.DaysLeft() < 0 {
+			tag = "expired"
+		}
+		b.Items = append(b.Items, BannerItem{
+			Icon:   "ACM",
+			Label:  c.DomainName,
+			Tag:    tag,
+			Note:   fmt.Sprintf("%d in use", len(c.InUseBy)),
+			Detail: "acm/" + c.CertificateArn,
+		})
+	}
+	return b
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// oneLine collapses CLI errors, which put the message on its own line
+// after the command name.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
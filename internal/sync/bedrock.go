@@ -0,0 +1,463 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+type BedrockAgent struct {
+	Id               string   `json:"Id"`
+	Name             string   `json:"Name"`
+	Status           string   `json:"Status"` // PREPARED, NOT_PREPARED, CREATING, FAILED, ...
+	Description      string   `json:"Description"`
+	FoundationModel  string   `json:"FoundationModel"`
+	RoleName         string   `json:"RoleName"`
+	IdleSessionTTL   int      `json:"IdleSessionTTL"` // seconds
+	GuardrailId      string   `json:"GuardrailId"`
+	GuardrailVersion string   `json:"GuardrailVersion"`
+	KnowledgeBaseIds []string `json:"KnowledgeBaseIds"`
+	UpdatedAt        string   `json:"UpdatedAt"`
+}
+
+type BedrockKnowledgeBase struct {
+	Id             string `json:"Id"`
+	Name           string `json:"Name"`
+	Status         string `json:"Status"` // ACTIVE, CREATING, FAILED, ...
+	EmbeddingModel string `json:"EmbeddingModel"`
+	RoleName       string `json:"RoleName"`
+	// StorageType is the vector store kind, e.g. OPENSEARCH_SERVERLESS,
+	// OPENSEARCH_MANAGED_CLUSTER, RDS or PINECONE.
+	StorageType string `json:"StorageType"`
+	// VectorStore names the store: the OpenSearch domain or collection,
+	// the RDS cluster, or the Pinecone/other endpoint.
+	VectorStore string              `json:"VectorStore"`
+	VectorIndex string              `json:"VectorIndex"`
+	DataSources []BedrockDataSource `json:"DataSources"`
+	UpdatedAt   string              `json:"UpdatedAt"`
+
+	// VectorStoreCached is set at load time when VectorStore is an
+	// OpenSearch domain in the local cache.
+	VectorStoreCached bool `json:"-"`
+}
+
+type BedrockDataSource struct {
+	Id       string   `json:"Id"`
+	Name     string   `json:"Name"`
+	Status   string   `json:"Status"`
+	Type     string   `json:"Type"` // S3, WEB, CONFLUENCE, SALESFORCE, SHAREPOINT, ...
+	Bucket   string   `json:"Bucket"`
+	Prefixes []string `json:"Prefixes"`
+
+	// BucketCached is set at load time when Bucket is in the local cache.
+	BucketCached bool `json:"-"`
+}
+
+type BedrockGuardrail struct {
+	Id             string   `json:"Id"`
+	Name           string   `json:"Name"`
+	Status         string   `json:"Status"`
+	Version        string   `json:"Version"`
+	Policies       []string `json:"Policies"` // configured policy kinds, e.g. content, topics
+	BlockedMessage string   `json:"BlockedMessage"`
+	KmsKeyId       string   `json:"KmsKeyId"`
+	UpdatedAt      string   `json:"UpdatedAt"`
+}
+
+type BedrockProvisionedThroughput struct {
+	Name              string `json:"Name"`
+	Arn               string `json:"Arn"`
+	Model             string `json:"Model"`
+	ModelUnits        int    `json:"ModelUnits"`
+	Status            string `json:"Status"`     // Creating, InService, Updating, Failed
+	Commitment        string `json:"Commitment"` // OneMonth, SixMonths, or "" for no commitment
+	CommitmentExpires string `json:"CommitmentExpires"`
+	CreationTime      string `json:"CreationTime"`
+}
+
+// syncBedrockResources caches agents, knowledge bases (with their data
+// sources), guardrails and provisioned throughput for region.
+func syncBedrockResources(st *Stage, region string, step func(string)) []SyncResult {
+	var results []SyncResult
+
+	// Agents, with their guardrail and knowledge base associations
+	if data, err := awscli.Run("bedrock-agent", "list-agents", "--region", region); err == nil {
+		var resp struct {
+			AgentSummaries []struct {
+				AgentId   string `json:"agentId"`
+				AgentName string `json:"agentName"`
+			} `json:"agentSummaries"`
+		}
+		json.Unmarshal(data, &resp)
+		agents := []BedrockAgent{}
+		for _, s := range resp.AgentSummaries {
+			agent := BedrockAgent{Id: s.AgentId, Name: s.AgentName}
+			if desc, err := awscli.Run("bedrock-agent", "get-agent",
+				"--agent-id", s.AgentId, "--region", region); err == nil {
+				agent = parseBedrockAgent(desc)
+			}
+			if kbData, err := awscli.Run("bedrock-agent", "list-agent-knowledge-bases",
+				"--agent-id", s.AgentId, "--agent-version", "DRAFT", "--region", region); err == nil {
+				var kbs struct {
+					Summaries []struct {
+						KnowledgeBaseId string `json:"knowledgeBaseId"`
+					} `json:"agentKnowledgeBaseSummaries"`
+				}
+				json.Unmarshal(kbData, &kbs)
+				for _, kb := range kbs.Summaries {
+					agent.KnowledgeBaseIds = append(agent.KnowledgeBaseIds, kb.KnowledgeBaseId)
+				}
+			}
+			agents = append(agents, agent)
+		}
+		enriched, _ := json.Marshal(agents)
+		st.WriteCache(region+":bedrock-agents", enriched)
+		results = append(results, SyncResult{Service: "bedrock-agents", Count: len(agents)})
+	} else {
+		results = append(results, SyncResult{Service: "bedrock-agents", Error: err.Error()})
+	}
+	step("bedrock agents")
+
+	// Knowledge bases and their data sources
+	if data, err := awscli.Run("bedrock-agent", "list-knowledge-bases", "--region", region); err == nil {
+		var resp struct {
+			Summaries []struct {
+				KnowledgeBaseId string `json:"knowledgeBaseId"`
+				Name            string `json:"name"`
+				Status          string `json:"status"`
+			} `json:"knowledgeBaseSummaries"`
+		}
+		json.Unmarshal(data, &resp)
+		kbs := []BedrockKnowledgeBase{}
+		for _, s := range resp.Summaries {
+			kb := BedrockKnowledgeBase{Id: s.KnowledgeBaseId, Name: s.Name, Status: s.Status}
+			if desc, err := awscli.Run("bedrock-agent", "get-knowledge-base",
+				"--knowledge-base-id", s.KnowledgeBaseId, "--region", region); err == nil {
+				kb = parseBedrockKnowledgeBase(desc)
+			}
+			if dsData, err := awscli.Run("bedrock-agent", "list-data-sources",
+				"--knowledge-base-id", s.KnowledgeBaseId, "--region", region); err == nil {
+				var ds struct {
+					Summaries []struct {
+						DataSourceId string `json:"dataSourceId"`
+						Name         string `json:"name"`
+						Status       string `json:"status"`
+					} `json:"dataSourceSummaries"`
+				}
+				json.Unmarshal(dsData, &ds)
+				for _, d := range ds.Summaries {
+					source := BedrockDataSource{Id: d.DataSourceId, Name: d.Name, Status: d.Status}
+					if desc, err := awscli.Run("bedrock-agent", "get-data-source", "--knowledge-base-id", s.KnowledgeBaseId,
+						"--data-source-id", d.DataSourceId, "--region", region); err == nil {
+						source = parseBedrockDataSource(desc)
+					}
+					kb.DataSources = append(kb.DataSources, source)
+				}
+			}
+			kbs = append(kbs, kb)
+		}
+		enriched, _ := json.Marshal(kbs)
+		st.WriteCache(region+":bedrock-knowledge-bases", enriched)
+		results = append(results, SyncResult{Service: "bedrock-knowledge-bases", Count: len(kbs)})
+	} else {
+		results = append(results, SyncResult{Service: "bedrock-knowledge-bases", Error: err.Error()})
+	}
+	step("bedrock knowledge bases")
+
+	// Guardrails (the working draft of each)
+	if data, err := awscli.Run("bedrock", "list-guardrails", "--region", region); err == nil {
+		var resp struct {
+			Guardrails []struct {
+				Id   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"guardrails"`
+		}
+		json.Unmarshal(data, &resp)
+		guardrails := []BedrockGuardrail{}
+		for _, g := range resp.Guardrails {
+			guardrail := BedrockGuardrail{Id: g.Id, Name: g.Name}
+			if desc, err := awscli.Run("bedrock", "get-guardrail",
+				"--guardrail-identifier", g.Id, "--region", region); err == nil {
+				guardrail = parseBedrockGuardrail(desc)
+			}
+			guardrails = append(guardrails, guardrail)
+		}
+		enriched, _ := json.Marshal(guardrails)
+		st.WriteCache(region+":bedrock-guardrails", enriched)
+		results = append(results, SyncResult{Service: "bedrock-guardrails", Count: len(guardrails)})
+	} else {
+		results = append(results, SyncResult{Service: "bedrock-guardrails", Error: err.Error()})
+	}
+	step("bedrock guardrails")
+
+	// Provisioned throughput
+	if data, err := awscli.Run("bedrock", "list-provisioned-model-throughputs", "--region", region); err == nil {
+		st.WriteCache(region+":bedrock-provisioned", data)
+		results = append(results, SyncResult{Service: "bedrock-provisioned", Count: countKey(data, "provisionedModelSummaries")})
+	} else {
+		results = append(results, SyncResult{Service: "bedrock-provisioned", Error: err.Error()})
+	}
+	step("bedrock provisioned throughput")
+
+	return results
+}
+
+// loadBedrockResources fills data from the cache written by
+// syncBedrockResources.
+func loadBedrockResources(region string, data *AIData) {
+	if raw, err := ReadCache(region + ":bedrock-agents"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.BedrockAgents)
+	}
+	if raw, err := ReadCache(region + ":bedrock-knowledge-bases"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.KnowledgeBases)
+	}
+	if raw, err := ReadCache(region + ":bedrock-guardrails"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.Guardrails)
+	}
+	if raw, err := ReadCache(region + ":bedrock-provisioned"); err == nil && raw != nil {
+		var resp struct {
+			Summaries []json.RawMessage `json:"provisionedModelSummaries"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, p := range resp.Summaries {
+			data.ProvisionedThroughput = append(data.ProvisionedThroughput, parseBedrockProvisionedThroughput(p))
+		}
+	}
+	if len(data.KnowledgeBases) > 0 {
+		resolveKnowledgeBaseSources(region, data.KnowledgeBases)
+	}
+}
+
+// resolveKnowledgeBaseSources marks the OpenSearch domains and S3 buckets
+// behind each knowledge base that are also in the local cache, so they can
+// link to their own detail.
+func resolveKnowledgeBaseSources(region string, kbs []BedrockKnowledgeBase) {
+	domains := map[string]bool{}
+	if dw, err := LoadDataWarehouseData(region); err == nil && dw != nil {
+		for _, d := range dw.OpenSearch {
+			domains[d.DomainName] = true
+		}
+	}
+	buckets := map[string]bool{}
+	if s3, err := LoadS3Data(); err == nil && s3 != nil {
+		for _, b := range s3.Buckets {
+			buckets[b.Name] = true
+		}
+	}
+	for i := range kbs {
+		kb := &kbs[i]
+		kb.VectorStoreCached = kb.StorageType == "OPENSEARCH_MANAGED_CLUSTER" && domains[kb.VectorStore]
+		for j := range kb.DataSources {
+			ds := &kb.DataSources[j]
+			ds.BucketCached = buckets[ds.Bucket]
+		}
+	}
+}
+
+// parseBedrockAgent reads get-agent output.
+func parseBedrockAgent(raw json.RawMessage) BedrockAgent {
+	var resp struct {
+		Agent struct {
+			AgentId                 string `json:"agentId"`
+			AgentName               string `json:"agentName"`
+			AgentStatus             string `json:"agentStatus"`
+			Description             string `json:"description"`
+			FoundationModel         string `json:"foundationModel"`
+			AgentResourceRoleArn    string `json:"agentResourceRoleArn"`
+			IdleSessionTTLInSeconds int    `json:"idleSessionTTLInSeconds"`
+			UpdatedAt               string `json:"updatedAt"`
+			GuardrailConfiguration  struct {
+				GuardrailIdentifier string `json:"guardrailIdentifier"`
+				GuardrailVersion    string `json:"guardrailVersion"`
+			} `json:"guardrailConfiguration"`
+		} `json:"agent"`
+	}
+	json.Unmarshal(raw, &resp)
+	a := resp.Agent
+
+	return BedrockAgent{
+		Id:               a.AgentId,
+		Name:             a.AgentName,
+		Status:           a.AgentStatus,
+		Description:      a.Description,
+		FoundationModel:  lastSegment(a.FoundationModel),
+		RoleName:         extractRoleName(a.AgentResourceRoleArn),
+		IdleSessionTTL:   a.IdleSessionTTLInSeconds,
+		GuardrailId:      lastSegment(a.GuardrailConfiguration.GuardrailIdentifier),
+		GuardrailVersion: a.GuardrailConfiguration.GuardrailVersion,
+		UpdatedAt:        formatCLITime(a.UpdatedAt),
+	}
+}
+
+// parseBedrockKnowledgeBase reads get-knowledge-base output; data sources
+// are filled in separately.
+func parseBedrockKnowledgeBase(raw json.RawMessage) BedrockKnowledgeBase {
+	var resp struct {
+		KnowledgeBase struct {
+			KnowledgeBaseId string `json:"knowledgeBaseId"`
+			Name            string `json:"name"`
+			Status          string `json:"status"`
+			RoleArn         string `json:"roleArn"`
+			UpdatedAt       string `json:"updatedAt"`
+			Configuration   struct {
+				Vector struct {
+					EmbeddingModelArn string `json:"embeddingModelArn"`
+				} `json:"vectorKnowledgeBaseConfiguration"`
+			} `json:"knowledgeBaseConfiguration"`
+			Storage struct {
+				Type       string `json:"type"`
+				Serverless struct {
+					CollectionArn   string `json:"collectionArn"`
+					VectorIndexName string `json:"vectorIndexName"`
+				} `json:"opensearchServerlessConfiguration"`
+				Managed struct {
+					DomainArn       string `json:"domainArn"`
+					VectorIndexName string `json:"vectorIndexName"`
+				} `json:"opensearchManagedClusterConfiguration"`
+				RDS struct {
+					ResourceArn string `json:"resourceArn"`
+					TableName   string `json:"tableName"`
+				} `json:"rdsConfiguration"`
+				Pinecone struct {
+					ConnectionString string `json:"connectionString"`
+					Namespace        string `json:"namespace"`
+				} `json:"pineconeConfiguration"`
+			} `json:"storageConfiguration"`
+		} `json:"knowledgeBase"`
+	}
+	json.Unmarshal(raw, &resp)
+	k := resp.KnowledgeBase
+
+	kb := BedrockKnowledgeBase{
+		Id:             k.KnowledgeBaseId,
+		Name:           k.Name,
+		Status:         k.Status,
+		EmbeddingModel: lastSegment(k.Configuration.Vector.EmbeddingModelArn),
+		RoleName:       extractRoleName(k.RoleArn),
+		StorageType:    k.Storage.Type,
+		UpdatedAt:      formatCLITime(k.UpdatedAt),
+	}
+	switch k.Storage.Type {
+	case "OPENSEARCH_SERVERLESS":
+		kb.VectorStore = lastSegment(k.Storage.Serverless.CollectionArn)
+		kb.VectorIndex = k.Storage.Serverless.VectorIndexName
+	case "OPENSEARCH_MANAGED_CLUSTER":
+		kb.VectorStore = lastSegment(k.Storage.Managed.DomainArn)
+		kb.VectorIndex = k.Storage.Managed.VectorIndexName
+	case "RDS":
+		// arn:aws:rds:us-east-1:123456789012:cluster:kb-vectors → kb-vectors
+		kb.VectorStore = k.Storage.RDS.ResourceArn[strings.LastIndex(k.Storage.RDS.ResourceArn, ":")+1:]
+		kb.VectorIndex = k.Storage.RDS.TableName
+	case "PINECONE":
+		kb.VectorStore = k.Storage.Pinecone.ConnectionString
+		kb.VectorIndex = k.Storage.Pinecone.Namespace
+	}
+	return kb
+}
+
+// parseBedrockDataSource reads get-data-source output.
+func parseBedrockDataSource(raw json.RawMessage) BedrockDataSource {
+	var resp struct {
+		DataSource struct {
+			DataSourceId  string `json:"dataSourceId"`
+			Name          string `json:"name"`
+			Status        string `json:"status"`
+			Configuration struct {
+				Type string `json:"type"`
+				S3   struct {
+					BucketArn         string   `json:"bucketArn"`
+					InclusionPrefixes []string `json:"inclusionPrefixes"`
+				} `json:"s3Configuration"`
+			} `json:"dataSourceConfiguration"`
+		} `json:"dataSource"`
+	}
+	json.Unmarshal(raw, &resp)
+	d := resp.DataSource
+
+	return BedrockDataSource{
+		Id:       d.DataSourceId,
+		Name:     d.Name,
+		Status:   d.Status,
+		Type:     d.Configuration.Type,
+		Bucket:   strings.TrimPrefix(d.Configuration.S3.BucketArn, "arn:aws:s3:::"),
+		Prefixes: d.Configuration.S3.InclusionPrefixes,
+	}
+}
+
+// parseBedrockGuardrail reads get-guardrail output.
+func parseBedrockGuardrail(raw json.RawMessage) BedrockGuardrail {
+	var g struct {
+		GuardrailId                string          `json:"guardrailId"`
+		Name                       string          `json:"name"`
+		Status                     string          `json:"status"`
+		Version                    string          `json:"version"`
+		BlockedInputMessaging      string          `json:"blockedInputMessaging"`
+		KmsKeyArn                  string          `json:"kmsKeyArn"`
+		UpdatedAt                  string          `json:"updatedAt"`
+		ContentPolicy              json.RawMessage `json:"contentPolicy"`
+		TopicPolicy                json.RawMessage `json:"topicPolicy"`
+		WordPolicy                 json.RawMessage `json:"wordPolicy"`
+		SensitiveInformationPolicy json.RawMessage `json:"sensitiveInformationPolicy"`
+		ContextualGroundingPolicy  json.RawMessage `json:"contextualGroundingPolicy"`
+	}
+	json.Unmarshal(raw, &g)
+
+	var policies []string
+	for _, p := range []struct {
+		name string
+		raw  json.RawMessage
+	}{
+		{"content", g.ContentPolicy},
+		{"topics", g.TopicPolicy},
+		{"words", g.WordPolicy},
+		{"sensitive info", g.SensitiveInformationPolicy},
+		{"grounding", g.ContextualGroundingPolicy},
+	} {
+		if len(p.raw) > 0 && string(p.raw) != "null" {
+			policies = append(policies, p.name)
+		}
+	}
+
+	return BedrockGuardrail{
+		Id:             g.GuardrailId,
+		Name:           g.Name,
+		Status:         g.Status,
+		Version:        g.Version,
+		Policies:       policies,
+		BlockedMessage: g.BlockedInputMessaging,
+		KmsKeyId:       lastSegment(g.KmsKeyArn),
+		UpdatedAt:      formatCLITime(g.UpdatedAt),
+	}
+}
+
+func parseBedrockProvisionedThroughput(raw json.RawMessage) BedrockProvisionedThroughput {
+	var p struct {
+		ProvisionedModelName     string `json:"provisionedModelName"`
+		ProvisionedModelArn      string `json:"provisionedModelArn"`
+		ModelArn                 string `json:"modelArn"`
+		ModelUnits               int    `json:"modelUnits"`
+		Status                   string `json:"status"`
+		CommitmentDuration       string `json:"commitmentDuration"`
+		CommitmentExpirationTime string `json:"commitmentExpirationTime"`
+		CreationTime             string `json:"creationTime"`
+	}
+	json.Unmarshal(raw, &p)
+
+	return BedrockProvisionedThroughput{
+		Name:              p.ProvisionedModelName,
+		Arn:               p.ProvisionedModelArn,
+		Model:             lastSegment(p.ModelArn),
+		ModelUnits:        p.ModelUnits,
+		Status:            p.Status,
+		Commitment:        p.CommitmentDuration,
+		CommitmentExpires: formatCLITime(p.CommitmentExpirationTime),
+		CreationTime:      formatCLITime(p.CreationTime),
+	}
+}
+
+// lastSegment returns what follows the last "/" of an ARN, e.g. the model
+// ID of a foundation model ARN, or s unchanged when it has none.
+func lastSegment(s string) string {
+	return s[strings.LastIndex(s, "/")+1:]
+}
@@ -0,0 +1,257 @@
+package sync
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// BundleVersion is the format version written into export bundles.
+const BundleVersion = 1
+
+// Bundle is a portable copy of the cache, as written by saws export and
+// read by saws import: gzipped JSON of the cache entries and tags.
+type Bundle struct {
+	Version   int              `json:"version"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Account   string           `json:"account,omitempty"`
+	Regions   []string         `json:"regions"`
+	Entries   []BundleEntry    `json:"entries"`
+	Tags      []TaggedResource `json:"tags,omitempty"`
+}
+
+// BundleEntry is one cache entry.
+type BundleEntry struct {
+	Key      string    `json:"key"`
+	Value    string    `json:"value"`
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+// BundleFilter narrows an export. Empty fields match everything.
+type BundleFilter struct {
+	Regions  []string
+	Services []string // cache key prefixes, e.g. "lambda", "rds", "iam"
+}
+
+// ImportResult counts what ImportBundle did.
+type ImportResult struct {
+	Imported int
+	Skipped  int // the local entry was synced more recently
+	Tags     int
+}
+
+// bundleSkipKeys are cache entries about this machine's syncs rather than
+// the account, which don't travel.
+var bundleSkipKeys = map[string]bool{"sync-status": true, "last_sync": true}
+
+// match reports whether the cache key passes f. Keys outside any region
+// (IAM, S3, CloudFront, ...) pass a region filter, as the regional views
+// need them.
+func (f BundleFilter) match(key string) bool {
+	name := key
+	if region, rest, ok := strings.Cut(key, ":"); ok {
+		if _, isRegion := awscli.RegionNames[region]; isRegion {
+			if len(f.Regions) > 0 && !contains(f.Regions, region) {
+				return false
+			}
+			name = rest
+		}
+	}
+	if len(f.Services) == 0 {
+		return true
+	}
+	for _, s := range f.Services {
+		if name == s || strings.HasPrefix(name, s+"-") || strings.HasPrefix(name, s+":") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportBundle writes the active account's cache entries and tags matching
+// f to w as a bundle, and returns it for reporting.
+func ExportBundle(w io.Writer, f BundleFilter) (*Bundle, error) {
+	b, err := BuildBundle(f)
+	if err != nil {
+		return nil, err
+	}
+	return b, WriteBundle(w, b)
+}
+
+// BuildBundle collects the active account's cache entries and tags
+// matching f, for changing before WriteBundle writes it.
+func BuildBundle(f BundleFilter) (*Bundle, error) {
+	b := &Bundle{Version: BundleVersion, CreatedAt: time.Now().UTC(), Account: Account()}
+
+	rows, err := db.Query(`SELECT key, value, synced_at FROM cache WHERE key GLOB ? ORDER BY key`, nsPattern())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	regions := map[string]bool{}
+	for rows.Next() {
+		var e BundleEntry
+		var syncedAt string
+		if err := rows.Scan(&e.Key, &e.Value, &syncedAt); err != nil {
+			return nil, err
+		}
+		e.Key = plainKey(e.Key)
+		if bundleSkipKeys[e.Key] || !f.match(e.Key) {
+			continue
+		}
+		if e.Value, err = unseal(e.Value); err != nil {
+			return nil, err
+		}
+		e.SyncedAt, _ = syncedAtTime(syncedAt)
+		b.Entries = append(b.Entries, e)
+		if region, _, ok := strings.Cut(e.Key, ":"); ok {
+			if _, isRegion := awscli.RegionNames[region]; isRegion {
+				regions[region] = true
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for r := range regions {
+		b.Regions = append(b.Regions, r)
+	}
+	sort.Strings(b.Regions)
+
+	tagged, err := QueryTags(TagFilter{})
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range tagged {
+		if len(f.Regions) > 0 && !contains(f.Regions, r.Region) {
+			continue
+		}
+		if len(f.Services) > 0 && !contains(f.Services, r.Service) {
+			continue
+		}
+		b.Tags = append(b.Tags, r)
+	}
+	return b, nil
+}
+
+// WriteBundle writes b to w in the bundle format.
+func WriteBundle(w io.Writer, b *Bundle) error {
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(b); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// ReadBundle decodes a bundle written by ExportBundle.
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a saws bundle: %w", err)
+	}
+	defer gz.Close()
+	var b Bundle
+	if err := json.NewDecoder(gz).Decode(&b); err != nil {
+		return nil, fmt.Errorf("not a saws bundle: %w", err)
+	}
+	if b.Version > BundleVersion {
+		return nil, fmt.Errorf("bundle format %d is newer than this saws supports (%d); upgrade saws", b.Version, BundleVersion)
+	}
+	return &b, nil
+}
+
+// ImportBundle merges b into the cache of the account it was exported
+// from, or the active account if it doesn't name one. An entry replaces
+// the local one unless the local one was synced more recently; the
+// bundle's tags replace the local tags of the regions it has tags for.
+// The bundle's regions are added to the region list and their resources
+// indexed.
+func ImportBundle(b *Bundle) (ImportResult, error) {
+	if err := writable(); err != nil {
+		return ImportResult{}, err
+	}
+	var res ImportResult
+	account := b.Account
+	if account == "" {
+		account = Account()
+	}
+	stored := func(key string) string {
+		if account == "" {
+			return key
+		}
+		return account + "/" + key
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	for _, e := range b.Entries {
+		var local string
+		err := tx.QueryRow(`SELECT synced_at FROM cache WHERE key = ?`, stored(e.Key)).Scan(&local)
+		if err == nil {
+			if t, ok := syncedAtTime(local); ok && t.After(e.SyncedAt) {
+				res.Skipped++
+				continue
+			}
+		}
+		value, err := seal(e.Value)
+		if err != nil {
+			return res, err
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO cache (key, value, synced_at) VALUES (?, ?, ?)
+			 ON CONFLICT(key) DO UPDATE SET value=excluded.value, synced_at=excluded.synced_at`,
+			stored(e.Key), value, e.SyncedAt); err != nil {
+			return res, err
+		}
+		res.Imported++
+	}
+
+	cleared := map[string]bool{}
+	for _, r := range b.Tags {
+		if !cleared[r.Region] {
+			if _, err := tx.Exec(`DELETE FROM tags WHERE account = ? AND region = ?`, account, r.Region); err != nil {
+				return res, err
+			}
+			cleared[r.Region] = true
+		}
+		for _, t := range r.Tags {
+			if _, err := tx.Exec(`INSERT INTO tags (account, region, arn, service, resource_type, resource_id, key, value) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				account, r.Region, r.Arn, r.Service, r.ResourceType, r.ResourceId, t.Key, t.Value); err != nil {
+				return res, err
+			}
+		}
+		res.Tags++
+	}
+	if err := tx.Commit(); err != nil {
+		return res, err
+	}
+	if err := SetRegions(b.Regions); err != nil {
+		return res, err
+	}
+	if account == Account() {
+		for _, r := range b.Regions {
+			if err := IndexResources(r); err != nil {
+				return res, err
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	const region = "il-central-1"
+	WriteCache(region+":lambda", []byte(`[{"FunctionName":"resize"}]`))
+	WriteCache(region+":rds", []byte(`[]`))
+	WriteCache("ap-south-2:lambda", []byte(`[]`))
+
+	var buf bytes.Buffer
+	b, err := ExportBundle(&buf, BundleFilter{Regions: []string{region}, Services: []string{"lambda"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Entries) != 1 || b.Entries[0].Key != region+":lambda" {
+		t.Fatalf("entries = %+v", b.Entries)
+	}
+	if len(b.Regions) != 1 || b.Regions[0] != region {
+		t.Errorf("regions = %v", b.Regions)
+	}
+
+	// A teammate's cache: an older copy of the function list.
+	db.Exec(`UPDATE cache SET value = '[]', synced_at = ? WHERE key = ?`, time.Now().Add(-48*time.Hour), region+":lambda")
+
+	read, err := ReadBundle(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen := CacheGeneration()
+	res, err := ImportBundle(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 1 || res.Skipped != 0 {
+		t.Errorf("result = %+v", res)
+	}
+	// Older than the newest entry cached, but still a change.
+	if CacheGeneration() == gen {
+		t.Error("import left the cache generation unchanged")
+	}
+	if v, _ := ReadCache(region + ":lambda"); string(v) != `[{"FunctionName":"resize"}]` {
+		t.Errorf("lambda after import = %s", v)
+	}
+
+	// Data synced locally after the export is kept.
+	WriteCache(region+":lambda", []byte(`[{"FunctionName":"thumbnail"}]`))
+	if res, _ := ImportBundle(read); res.Imported != 0 || res.Skipped != 1 {
+		t.Errorf("re-import result = %+v", res)
+	}
+
+	if _, err := ReadBundle(bytes.NewReader([]byte(`{}`))); err == nil {
+		t.Error("expected an error for a non-bundle")
+	}
+}
+
+func TestBundleFilterMatch(t *testing.T) {
+	f := BundleFilter{Regions: []string{"eu-west-1"}, Services: []string{"iam", "sagemaker", "load-balancers"}}
+	for key, want := range map[string]bool{
+		"iam:enriched":                  true,
+		"s3":                            false,
+		"eu-west-1:sagemaker-notebooks": true,
+		"eu-west-1:load-balancers":      true,
+		"eu-west-1:lambda":              false,
+		"us-east-1:sagemaker-notebooks": false,
+	} {
+		if got := f.match(key); got != want {
+			t.Errorf("match(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
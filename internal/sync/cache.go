@@ -3,71 +3,134 @@ package sync
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/estrados/simply-aws/internal/awscli"
+	_ "modernc.org/sqlite"
 )
 
-const dbDir = ".saws"
-const dbFile = ".saws/saws.db"
-
 var db *sql.DB
 
+// dbDir is the directory InitDB opened the cache in.
+var dbDir string
+
+// InitDB opens the cache in DataDir, creating it, or moving in the cache
+// of an older version from ./.saws, on first run.
 func InitDB() error {
-	if err := os.MkdirAll(dbDir, 0755); err != nil {
+	dir, err := DataDir()
+	if err != nil {
 		return err
 	}
+	dbDir = dir
+	path := filepath.Join(dir, "saws.db")
+	dsn := path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_time_format=sqlite&_txlock=immediate"
+	if readOnly {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("read-only mode needs an existing cache: %w", err)
+		}
+		if db, err = sql.Open("sqlite", dsn+"&_pragma=query_only(1)"); err != nil {
+			return err
+		}
+		if err := migrate(); err != nil {
+			return err
+		}
+		if err := unlockCache(); err != nil {
+			return err
+		}
+		return selectAccount()
+	}
 
-	var err error
-	db, err = sql.Open("sqlite3", dbFile+"?_journal_mode=WAL")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	legacy := legacyDB(dir)
+	if _, err := os.Stat(path); legacy != "" && os.IsNotExist(err) {
+		if err := moveLegacyDB(legacy, path); err != nil {
+			return err
+		}
+		legacy = ""
+	}
+
+	// _time_format=sqlite stores times as the cgo driver did, so caches
+	// written before the switch to the pure-Go driver compare and parse alike.
+	// _txlock=immediate takes the write lock when a transaction begins, so
+	// two processes writing at once wait for each other instead of failing.
+	db, err = sql.Open("sqlite", dsn)
 	if err != nil {
 		return err
 	}
 
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS cache (
-			key    TEXT PRIMARY KEY,
-			value  TEXT NOT NULL,
-			synced_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		);
-		CREATE TABLE IF NOT EXISTS settings (
-			key   TEXT PRIMARY KEY,
-			value TEXT NOT NULL
-		);
-		CREATE TABLE IF NOT EXISTS regions (
-			name     TEXT PRIMARY KEY,
-			enabled  INTEGER NOT NULL DEFAULT 1
-		);
-	`)
-	return err
+	if err := migrate(); err != nil {
+		return err
+	}
+	if legacy != "" {
+		if err := mergeLegacyDB(legacy); err != nil {
+			return err
+		}
+	}
+	if err := unlockCache(); err != nil {
+		return err
+	}
+	return selectAccount()
 }
 
+// WriteCache caches data under key, right away. A sync writes through its
+// Stage instead, so its writes land together.
 func WriteCache(key string, data []byte) error {
-	_, err := db.Exec(
-		`INSERT INTO cache (key, value, synced_at) VALUES (?, ?, ?)
-		 ON CONFLICT(key) DO UPDATE SET value=excluded.value, synced_at=excluded.synced_at`,
-		key, string(data), time.Now(),
-	)
-	return err
+	if err := writable(); err != nil {
+		return err
+	}
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	return writeCacheEntry(db, nsKey(key), string(data), time.Now())
+}
+
+// CacheEntry is a cached value with the time it was written.
+type CacheEntry struct {
+	Value    json.RawMessage
+	SyncedAt time.Time
+}
+
+// Age returns how long ago the entry was synced.
+func (e CacheEntry) Age() time.Duration {
+	return time.Since(e.SyncedAt)
 }
 
 func ReadCache(key string) (json.RawMessage, error) {
-	var value string
-	err := db.QueryRow(`SELECT value FROM cache WHERE key = ?`, key).Scan(&value)
+	e, err := ReadCacheEntry(key)
+	if e == nil {
+		return nil, err
+	}
+	return e.Value, nil
+}
+
+// ReadCacheEntry is ReadCache with the entry's sync time; nil when the key
+// is not cached.
+func ReadCacheEntry(key string) (*CacheEntry, error) {
+	var value, syncedAt string
+	err := db.QueryRow(`SELECT value, synced_at FROM cache WHERE key = ?`, nsKey(key)).Scan(&value, &syncedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	return json.RawMessage(value), nil
+	if value, err = unseal(value); err != nil {
+		return nil, err
+	}
+	t, _ := syncedAtTime(syncedAt)
+	return &CacheEntry{Value: json.RawMessage(value), SyncedAt: t}, nil
 }
 
 func CacheExists(key string) bool {
 	var count int
-	db.QueryRow(`SELECT COUNT(*) FROM cache WHERE key = ?`, key).Scan(&count)
+	db.QueryRow(`SELECT COUNT(*) FROM cache WHERE key = ?`, nsKey(key)).Scan(&count)
 	return count > 0
 }
 
@@ -105,26 +168,72 @@ func CacheSyncedAt(keys ...string) *time.Time {
 		return nil
 	}
 	query := `SELECT MAX(synced_at) FROM cache WHERE key IN (?` + repeatParam(len(keys)-1) + `)`
-	args := make([]interface{}, len(keys))
-	for i, k := range keys {
-		args[i] = k
-	}
+	var latest *time.Time
 	var raw *string
-	if err := db.QueryRow(query, args...).Scan(&raw); err != nil || raw == nil {
-		return nil
+	if err := db.QueryRow(query, nsKeys(keys)...).Scan(&raw); err == nil && raw != nil {
+		if t, ok := syncedAtTime(*raw); ok {
+			latest = &t
+		}
 	}
-	// SQLite stores as "2006-01-02 15:04:05.999999-07:00"
+	return latest
+}
+
+// syncedAtTime reads a synced_at value back; SQLite stores it as
+// "2006-01-02 15:04:05.999999-07:00".
+func syncedAtTime(raw string) (time.Time, bool) {
 	for _, layout := range []string{
 		"2006-01-02 15:04:05.999999999-07:00",
 		"2006-01-02 15:04:05-07:00",
 		"2006-01-02T15:04:05Z07:00",
 		"2006-01-02 15:04:05",
 	} {
-		if t, err := time.Parse(layout, *raw); err == nil {
-			return &t
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, true
 		}
 	}
-	return nil
+	return time.Time{}, false
+}
+
+// CacheGeneration changes whenever a cache entry is written, deleted or
+// imported, by this process or another, so parsed data kept in memory can
+// tell it is stale. Triggers on the cache table count every change.
+func CacheGeneration() int64 {
+	var n int64
+	db.QueryRow(`SELECT n FROM cache_generation`).Scan(&n)
+	return n
+}
+
+// CacheUpdatedAt returns when the active account's newest cache entry was
+// written, as stored, or "" when nothing is cached.
+func CacheUpdatedAt() string {
+	var raw sql.NullString
+	db.QueryRow(`SELECT MAX(synced_at) FROM cache WHERE key GLOB ?`, nsPattern()).Scan(&raw)
+	return raw.String
+}
+
+// CachedRegions returns the regions the active account has any cached
+// data for, sorted.
+func CachedRegions() ([]string, error) {
+	rows, err := db.Query(`SELECT key FROM cache WHERE key GLOB ?`, nsPattern())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := map[string]bool{}
+	var regions []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		if name, ok := keyRegion(plainKey(key)); ok && !seen[name] {
+			seen[name] = true
+			regions = append(regions, name)
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
 }
 
 func repeatParam(n int) string {
@@ -138,6 +247,9 @@ func repeatParam(n int) string {
 // --- Region settings ---
 
 func SetRegions(regions []string) error {
+	if err := writable(); err != nil {
+		return err
+	}
 	tx, err := db.Begin()
 	if err != nil {
 		return err
@@ -156,6 +268,8 @@ func SetRegions(regions []string) error {
 	return tx.Commit()
 }
 
+// GetRegions lists the known regions. When .saws.yaml lists regions, those
+// are the enabled ones, whatever the toggles say.
 func GetRegions() ([]RegionInfo, error) {
 	rows, err := db.Query(`SELECT name, enabled FROM regions ORDER BY name`)
 	if err != nil {
@@ -164,17 +278,36 @@ func GetRegions() ([]RegionInfo, error) {
 	defer rows.Close()
 
 	var regions []RegionInfo
+	seen := map[string]bool{}
+	configured := SyncRegions()
 	for rows.Next() {
 		var r RegionInfo
 		if err := rows.Scan(&r.Name, &r.Enabled); err != nil {
 			return nil, err
 		}
+		if len(configured) > 0 {
+			r.Enabled = contains(configured, r.Name)
+		}
+		seen[r.Name] = true
 		regions = append(regions, r)
 	}
-	return regions, nil
+	for _, name := range configured {
+		if !seen[name] {
+			regions = append(regions, RegionInfo{Name: name, Enabled: true})
+		}
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Name < regions[j].Name })
+	return regions, rows.Err()
 }
 
+// GetEnabledRegions returns the regions .saws.yaml lists, else the ones
+// toggled on.
 func GetEnabledRegions() ([]string, error) {
+	if configured := SyncRegions(); len(configured) > 0 {
+		regions := append([]string(nil), configured...)
+		sort.Strings(regions)
+		return regions, nil
+	}
 	rows, err := db.Query(`SELECT name FROM regions WHERE enabled = 1 ORDER BY name`)
 	if err != nil {
 		return nil, err
@@ -192,7 +325,26 @@ func GetEnabledRegions() ([]string, error) {
 	return regions, nil
 }
 
+// DefaultRegion picks the region to show when none is given: the one the
+// AWS CLI is configured for, else the first enabled region, else the first
+// region with cached data, so browsing still works without the CLI.
+func DefaultRegion(detected string) string {
+	if detected != "" {
+		return detected
+	}
+	if enabled, _ := GetEnabledRegions(); len(enabled) > 0 {
+		return enabled[0]
+	}
+	if cached, _ := CachedRegions(); len(cached) > 0 {
+		return cached[0]
+	}
+	return "us-east-1"
+}
+
 func SetRegionEnabled(name string, enabled bool) error {
+	if err := writable(); err != nil {
+		return err
+	}
 	val := 0
 	if enabled {
 		val = 1
@@ -201,6 +353,167 @@ func SetRegionEnabled(name string, enabled bool) error {
 	return err
 }
 
+// --- Settings ---
+
+// settingValidators lists the known settings and how to check their values.
+var settingValidators = map[string]func(string) error{
+	"allowed_regions":         validateRegionList,
+	"auto_resync":             validateBool,
+	"cert_warning_days":       validateNonNegativeInt,
+	"known_accounts":          validateAccountList,
+	"providers":               validateProviderList,
+	"quota_warning_pct":       validatePercent,
+	"server_cache_mb":         validateNonNegativeInt,
+	"snapshot_retention_days": validateNonNegativeInt,
+	"stale_after_hours":       validateNonNegativeInt,
+	"sync_metrics":            validateBool,
+	"warehouse_uri":           validateS3URI,
+}
+
+// RegisterSetting adds a setting from outside this package, such as a
+// provider's project or subscription. Call it from init.
+func RegisterSetting(key string, validate func(string) error) {
+	settingValidators[key] = validate
+}
+
+// SettingKeys returns the known setting names, sorted.
+func SettingKeys() []string {
+	keys := make([]string, 0, len(settingValidators))
+	for k := range settingValidators {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateSetting rejects unknown keys and malformed values.
+func ValidateSetting(key, value string) error {
+	check, ok := settingValidators[key]
+	if !ok {
+		return fmt.Errorf("unknown setting %q (known: %s)", key, strings.Join(SettingKeys(), ", "))
+	}
+	return check(value)
+}
+
+func validateNonNegativeInt(v string) error {
+	if n, err := strconv.Atoi(v); err != nil || n < 0 {
+		return fmt.Errorf("expected a non-negative integer, got %q", v)
+	}
+	return nil
+}
+
+func validatePercent(v string) error {
+	if n, err := strconv.Atoi(v); err != nil || n < 1 || n > 100 {
+		return fmt.Errorf("expected a percentage from 1 to 100, got %q", v)
+	}
+	return nil
+}
+
+func validateBool(v string) error {
+	if v != "true" && v != "false" {
+		return fmt.Errorf("expected true or false, got %q", v)
+	}
+	return nil
+}
+
+// validateRegionList accepts a comma-separated list of known region codes,
+// or "" to clear the restriction.
+func validateRegionList(v string) error {
+	for _, r := range strings.Split(v, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if _, ok := awscli.RegionNames[r]; !ok {
+			return fmt.Errorf("unknown region %q", r)
+		}
+	}
+	return nil
+}
+
+// validateS3URI accepts s3://bucket or s3://bucket/prefix, or "" to turn
+// the warehouse export off.
+func validateS3URI(v string) error {
+	if v == "" {
+		return nil
+	}
+	bucket, _, _ := strings.Cut(strings.TrimPrefix(v, "s3://"), "/")
+	if !strings.HasPrefix(v, "s3://") || bucket == "" {
+		return fmt.Errorf("expected s3://bucket/prefix, got %q", v)
+	}
+	return nil
+}
+
+func validateAccountList(v string) error {
+	for _, id := range strings.Split(v, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && !accountIDPattern.MatchString(id) {
+			return fmt.Errorf("expected 12-digit account IDs, got %q", id)
+		}
+	}
+	return nil
+}
+
+// GetSetting returns the value for key, or "" if it has not been set.
+func GetSetting(key string) (string, error) {
+	var value string
+	err := db.QueryRow(`SELECT value FROM settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+func SetSetting(key, value string) error {
+	if err := writable(); err != nil {
+		return err
+	}
+	_, err := db.Exec(
+		`INSERT INTO settings (key, value) VALUES (?, ?)
+		 ON CONFLICT(key) DO UPDATE SET value=excluded.value`,
+		key, value,
+	)
+	return err
+}
+
+// --- Annotations ---
+
+// Annotation is a free-form runbook note attached to a resource key
+// ("type/id", see InventoryItem.Key).
+type Annotation struct {
+	Resource  string    `json:"resource"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func AddAnnotation(resource, note string) error {
+	if err := writable(); err != nil {
+		return err
+	}
+	_, err := db.Exec(`INSERT INTO annotations (resource, note, created_at) VALUES (?, ?, ?)`,
+		resource, note, time.Now())
+	return err
+}
+
+// GetAnnotations returns all notes keyed by resource, oldest first.
+func GetAnnotations() (map[string][]Annotation, error) {
+	rows, err := db.Query(`SELECT resource, note, created_at FROM annotations ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := map[string][]Annotation{}
+	for rows.Next() {
+		var a Annotation
+		if err := rows.Scan(&a.Resource, &a.Note, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes[a.Resource] = append(notes[a.Resource], a)
+	}
+	return notes, nil
+}
+
 type RegionInfo struct {
 	Name    string `json:"name"`
 	Enabled bool   `json:"enabled"`
@@ -212,8 +525,7 @@ func CloseDB() {
 	}
 }
 
-// DBPath returns the path to the db dir (for cleanup of old flat files).
+// DBPath returns the directory the cache was opened in.
 func DBPath() string {
-	abs, _ := filepath.Abs(dbDir)
-	return abs
+	return dbDir
 }
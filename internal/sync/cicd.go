@@ -0,0 +1,430 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+type CICDData struct {
+	Pipelines []CodePipeline     `json:"pipelines"`
+	Projects  []CodeBuildProject `json:"projects"`
+	Stacks    []CFStack          `json:"stacks"`
+}
+
+type CodePipeline struct {
+	Name          string          `json:"Name"`
+	Version       int             `json:"Version"`
+	PipelineType  string          `json:"PipelineType"`  // V1 or V2
+	ExecutionMode string          `json:"ExecutionMode"` // SUPERSEDED, QUEUED or PARALLEL
+	RoleName      string          `json:"RoleName"`
+	ArtifactStore string          `json:"ArtifactStore"` // S3 bucket
+	Stages        []PipelineStage `json:"Stages"`
+	Updated       string          `json:"Updated"`
+
+	// Last execution, from list-pipeline-executions
+	LastStatus   string `json:"LastStatus"` // InProgress, Succeeded, Failed, Stopped, Superseded, ...
+	LastStarted  string `json:"LastStarted"`
+	LastTrigger  string `json:"LastTrigger"`  // e.g. Webhook, StartPipelineExecution, CloudWatchEvent
+	LastRevision string `json:"LastRevision"` // source revision summary, e.g. a commit message
+}
+
+type PipelineStage struct {
+	Name    string           `json:"Name"`
+	Status  string           `json:"Status"` // latest execution of the stage, "" when never run
+	Actions []PipelineAction `json:"Actions"`
+}
+
+type PipelineAction struct {
+	Name     string `json:"Name"`
+	Category string `json:"Category"` // Source, Build, Test, Deploy, Approval, Invoke
+	Provider string `json:"Provider"` // e.g. CodeStarSourceConnection, CodeBuild, ECS, CloudFormation
+	// Target is the resource the action works on, e.g. the CodeBuild
+	// project, ECS service, stack or repository.
+	Target string `json:"Target"`
+}
+
+// BuildProjects returns the CodeBuild projects the pipeline runs.
+func (p CodePipeline) BuildProjects() []string {
+	var out []string
+	for _, s := range p.Stages {
+		for _, a := range s.Actions {
+			if a.Provider == "CodeBuild" && a.Target != "" {
+				out = append(out, a.Target)
+			}
+		}
+	}
+	return out
+}
+
+// Summary renders the stage statuses of a pipeline, e.g. "Source ✓ · Build ✗".
+func (p CodePipeline) Summary() string {
+	out := ""
+	for i, s := range p.Stages {
+		if i > 0 {
+			out += " · "
+		}
+		mark := "–"
+		switch s.Status {
+		case "Succeeded":
+			mark = "✓"
+		case "Failed":
+			mark = "✗"
+		case "InProgress":
+			mark = "…"
+		}
+		out += fmt.Sprintf("%s %s", s.Name, mark)
+	}
+	return out
+}
+
+type CodeBuildProject struct {
+	Name           string        `json:"Name"`
+	Arn            string        `json:"Arn"`
+	Description    string        `json:"Description"`
+	SourceType     string        `json:"SourceType"` // GITHUB, CODECOMMIT, CODEPIPELINE, S3, NO_SOURCE, ...
+	SourceLocation string        `json:"SourceLocation"`
+	Image          string        `json:"Image"`
+	ComputeType    string        `json:"ComputeType"` // e.g. BUILD_GENERAL1_SMALL
+	Environment    string        `json:"Environment"` // e.g. LINUX_CONTAINER, ARM_CONTAINER
+	Privileged     bool          `json:"Privileged"`  // Docker-in-Docker
+	RoleName       string        `json:"RoleName"`
+	TimeoutMinutes int           `json:"TimeoutMinutes"`
+	VpcId          string        `json:"VpcId"`
+	SubnetIds      []string      `json:"SubnetIds"`
+	SecurityGroups []string      `json:"SecurityGroups"`
+	LastBuild      *CodeBuildRun `json:"LastBuild,omitempty"`
+}
+
+type CodeBuildRun struct {
+	Id              string `json:"Id"`
+	Number          int    `json:"Number"`
+	Status          string `json:"Status"` // SUCCEEDED, FAILED, FAULT, TIMED_OUT, IN_PROGRESS, STOPPED
+	Started         string `json:"Started"`
+	DurationSeconds int    `json:"DurationSeconds"`
+	SourceVersion   string `json:"SourceVersion"`
+	Initiator       string `json:"Initiator"`
+}
+
+// Duration renders how long the build ran, e.g. "4m12s".
+func (b CodeBuildRun) Duration() string {
+	if b.DurationSeconds <= 0 {
+		return ""
+	}
+	return (time.Duration(b.DurationSeconds) * time.Second).String()
+}
+
+func SyncCICDData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	var results []SyncResult
+
+	// CodePipeline: structure, per-stage state and the last execution
+	if data, err := awscli.Run("codepipeline", "list-pipelines", "--region", region); err == nil {
+		var resp struct {
+			Pipelines []struct {
+				Name string `json:"name"`
+			} `json:"pipelines"`
+		}
+		json.Unmarshal(data, &resp)
+		pipelines := []CodePipeline{}
+		for _, p := range resp.Pipelines {
+			pipeline := CodePipeline{Name: p.Name}
+			if desc, err := awscli.Run("codepipeline", "get-pipeline", "--name", p.Name, "--region", region); err == nil {
+				pipeline = parseCodePipeline(desc)
+			}
+			if stateData, err := awscli.Run("codepipeline", "get-pipeline-state", "--name", p.Name, "--region", region); err == nil {
+				var state struct {
+					StageStates []struct {
+						StageName       string `json:"stageName"`
+						LatestExecution struct {
+							Status string `json:"status"`
+						} `json:"latestExecution"`
+					} `json:"stageStates"`
+				}
+				json.Unmarshal(stateData, &state)
+				status := map[string]string{}
+				for _, s := range state.StageStates {
+					status[s.StageName] = s.LatestExecution.Status
+				}
+				for i := range pipeline.Stages {
+					pipeline.Stages[i].Status = status[pipeline.Stages[i].Name]
+				}
+			}
+			if exData, err := awscli.Run("codepipeline", "list-pipeline-executions",
+				"--pipeline-name", p.Name, "--max-results", "1", "--region", region); err == nil {
+				var ex struct {
+					Summaries []struct {
+						Status    string `json:"status"`
+						StartTime string `json:"startTime"`
+						Trigger   struct {
+							TriggerType string `json:"triggerType"`
+						} `json:"trigger"`
+						SourceRevisions []struct {
+							RevisionSummary string `json:"revisionSummary"`
+						} `json:"sourceRevisions"`
+					} `json:"pipelineExecutionSummaries"`
+				}
+				json.Unmarshal(exData, &ex)
+				if len(ex.Summaries) > 0 {
+					last := ex.Summaries[0]
+					pipeline.LastStatus = last.Status
+					pipeline.LastStarted = formatCLITime(last.StartTime)
+					pipeline.LastTrigger = last.Trigger.TriggerType
+					if len(last.SourceRevisions) > 0 {
+						pipeline.LastRevision = last.SourceRevisions[0].RevisionSummary
+					}
+				}
+			}
+			pipelines = append(pipelines, pipeline)
+		}
+		enriched, _ := json.Marshal(pipelines)
+		st.WriteCache(region+":codepipeline", enriched)
+		results = append(results, SyncResult{Service: "codepipeline", Count: len(pipelines)})
+	} else {
+		results = append(results, SyncResult{Service: "codepipeline", Error: err.Error()})
+	}
+	step("codepipeline")
+
+	// CodeBuild: projects in batches of 100, then each project's last build
+	if data, err := awscli.Run("codebuild", "list-projects", "--region", region); err == nil {
+		var resp struct {
+			Projects []string `json:"projects"`
+		}
+		json.Unmarshal(data, &resp)
+		projects := []CodeBuildProject{}
+		for i := 0; i < len(resp.Projects); i += 100 {
+			end := i + 100
+			if end > len(resp.Projects) {
+				end = len(resp.Projects)
+			}
+			args := append([]string{"codebuild", "batch-get-projects", "--names"}, resp.Projects[i:end]...)
+			batch, err := awscli.Run(append(args, "--region", region)...)
+			if err != nil {
+				continue
+			}
+			var b struct {
+				Projects []json.RawMessage `json:"projects"`
+			}
+			json.Unmarshal(batch, &b)
+			for _, raw := range b.Projects {
+				projects = append(projects, parseCodeBuildProject(raw))
+			}
+		}
+
+		var buildIds []string
+		for _, p := range projects {
+			if ids, err := awscli.Run("codebuild", "list-builds-for-project", "--project-name", p.Name,
+				"--sort-order", "DESCENDING", "--max-items", "1", "--region", region); err == nil {
+				var r struct {
+					Ids []string `json:"ids"`
+				}
+				json.Unmarshal(ids, &r)
+				if len(r.Ids) > 0 {
+					buildIds = append(buildIds, r.Ids[0])
+				}
+			}
+		}
+		last := map[string]*CodeBuildRun{}
+		for i := 0; i < len(buildIds); i += 100 {
+			end := i + 100
+			if end > len(buildIds) {
+				end = len(buildIds)
+			}
+			args := append([]string{"codebuild", "batch-get-builds", "--ids"}, buildIds[i:end]...)
+			batch, err := awscli.Run(append(args, "--region", region)...)
+			if err != nil {
+				continue
+			}
+			var b struct {
+				Builds []json.RawMessage `json:"builds"`
+			}
+			json.Unmarshal(batch, &b)
+			for _, raw := range b.Builds {
+				project, run := parseCodeBuildRun(raw)
+				last[project] = &run
+			}
+		}
+		for i := range projects {
+			projects[i].LastBuild = last[projects[i].Name]
+		}
+
+		enriched, _ := json.Marshal(projects)
+		st.WriteCache(region+":codebuild", enriched)
+		results = append(results, SyncResult{Service: "codebuild", Count: len(projects)})
+	} else {
+		results = append(results, SyncResult{Service: "codebuild", Error: err.Error()})
+	}
+	step("codebuild")
+
+	cfResults, _ := SyncCloudFormationData(st, region, onStep...)
+	results = append(results, cfResults...)
+
+	return results, nil
+}
+
+func LoadCICDData(region string) (*CICDData, error) {
+	data := &CICDData{}
+	if raw, err := ReadCache(region + ":codepipeline"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.Pipelines)
+	}
+	if raw, err := ReadCache(region + ":codebuild"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.Projects)
+	}
+	data.Stacks, _ = LoadCFStacks(region)
+	return data, nil
+}
+
+// parseCodePipeline reads get-pipeline output; stage status and the last
+// execution are filled in separately.
+func parseCodePipeline(raw json.RawMessage) CodePipeline {
+	var resp struct {
+		Pipeline struct {
+			Name          string `json:"name"`
+			Version       int    `json:"version"`
+			PipelineType  string `json:"pipelineType"`
+			ExecutionMode string `json:"executionMode"`
+			RoleArn       string `json:"roleArn"`
+			ArtifactStore struct {
+				Location string `json:"location"`
+			} `json:"artifactStore"`
+			Stages []struct {
+				Name    string `json:"name"`
+				Actions []struct {
+					Name         string `json:"name"`
+					ActionTypeId struct {
+						Category string `json:"category"`
+						Provider string `json:"provider"`
+					} `json:"actionTypeId"`
+					Configuration map[string]string `json:"configuration"`
+				} `json:"actions"`
+			} `json:"stages"`
+		} `json:"pipeline"`
+		Metadata struct {
+			Updated string `json:"updated"`
+		} `json:"metadata"`
+	}
+	json.Unmarshal(raw, &resp)
+	p := resp.Pipeline
+
+	pipeline := CodePipeline{
+		Name:          p.Name,
+		Version:       p.Version,
+		PipelineType:  p.PipelineType,
+		ExecutionMode: p.ExecutionMode,
+		RoleName:      extractRoleName(p.RoleArn),
+		ArtifactStore: p.ArtifactStore.Location,
+		Updated:       formatCLITime(resp.Metadata.Updated),
+	}
+	for _, s := range p.Stages {
+		stage := PipelineStage{Name: s.Name}
+		for _, a := range s.Actions {
+			stage.Actions = append(stage.Actions, PipelineAction{
+				Name:     a.Name,
+				Category: a.ActionTypeId.Category,
+				Provider: a.ActionTypeId.Provider,
+				Target:   actionTarget(a.Configuration),
+			})
+		}
+		pipeline.Stages = append(pipeline.Stages, stage)
+	}
+	return pipeline
+}
+
+// actionTarget picks the resource an action's configuration points at.
+func actionTarget(cfg map[string]string) string {
+	for _, key := range []string{"ProjectName", "FullRepositoryId", "RepositoryName", "S3Bucket", "BucketName",
+		"ServiceName", "StackName", "FunctionName", "ApplicationName", "ClusterName"} {
+		if v := cfg[key]; v != "" {
+			if key == "ServiceName" && cfg["ClusterName"] != "" {
+				return cfg["ClusterName"] + "/" + v
+			}
+			return v
+		}
+	}
+	return ""
+}
+
+// parseCodeBuildProject reads one batch-get-projects entry.
+func parseCodeBuildProject(raw json.RawMessage) CodeBuildProject {
+	var p struct {
+		Name        string `json:"name"`
+		Arn         string `json:"arn"`
+		Description string `json:"description"`
+		Source      struct {
+			Type     string `json:"type"`
+			Location string `json:"location"`
+		} `json:"source"`
+		Environment struct {
+			Type           string `json:"type"`
+			Image          string `json:"image"`
+			ComputeType    string `json:"computeType"`
+			PrivilegedMode bool   `json:"privilegedMode"`
+		} `json:"environment"`
+		ServiceRole      string `json:"serviceRole"`
+		TimeoutInMinutes int    `json:"timeoutInMinutes"`
+		VpcConfig        struct {
+			VpcId            string   `json:"vpcId"`
+			Subnets          []string `json:"subnets"`
+			SecurityGroupIds []string `json:"securityGroupIds"`
+		} `json:"vpcConfig"`
+	}
+	json.Unmarshal(raw, &p)
+
+	return CodeBuildProject{
+		Name:           p.Name,
+		Arn:            p.Arn,
+		Description:    p.Description,
+		SourceType:     p.Source.Type,
+		SourceLocation: p.Source.Location,
+		Image:          p.Environment.Image,
+		ComputeType:    p.Environment.ComputeType,
+		Environment:    p.Environment.Type,
+		Privileged:     p.Environment.PrivilegedMode,
+		RoleName:       extractRoleName(p.ServiceRole),
+		TimeoutMinutes: p.TimeoutInMinutes,
+		VpcId:          p.VpcConfig.VpcId,
+		SubnetIds:      p.VpcConfig.Subnets,
+		SecurityGroups: p.VpcConfig.SecurityGroupIds,
+	}
+}
+
+// parseCodeBuildRun reads one batch-get-builds entry and returns it with
+// the name of its project.
+func parseCodeBuildRun(raw json.RawMessage) (string, CodeBuildRun) {
+	var b struct {
+		Id                    string `json:"id"`
+		BuildNumber           int    `json:"buildNumber"`
+		ProjectName           string `json:"projectName"`
+		BuildStatus           string `json:"buildStatus"`
+		StartTime             string `json:"startTime"`
+		EndTime               string `json:"endTime"`
+		SourceVersion         string `json:"sourceVersion"`
+		ResolvedSourceVersion string `json:"resolvedSourceVersion"`
+		Initiator             string `json:"initiator"`
+	}
+	json.Unmarshal(raw, &b)
+
+	run := CodeBuildRun{
+		Id:            b.Id,
+		Number:        b.BuildNumber,
+		Status:        b.BuildStatus,
+		Started:       formatCLITime(b.StartTime),
+		SourceVersion: b.ResolvedSourceVersion,
+		Initiator:     b.Initiator,
+	}
+	if run.SourceVersion == "" {
+		run.SourceVersion = b.SourceVersion
+	}
+	start, err1 := time.Parse(time.RFC3339Nano, b.StartTime)
+	end, err2 := time.Parse(time.RFC3339Nano, b.EndTime)
+	if err1 == nil && err2 == nil {
+		run.DurationSeconds = int(end.Sub(start).Seconds())
+	}
+	return b.ProjectName, run
+}
@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestSyncCICDData(t *testing.T) {
+	fake := awscli.NewFake().
+		On("codepipeline list-pipelines", `{"pipelines": [{"name": "web-deploy"}]}`).
+		OnFile("codepipeline get-pipeline", filepath.Join(testdata, "parse", "parseCodePipeline.json")).
+		On("codepipeline get-pipeline-state", `{"stageStates": [{"stageName": "Source", "latestExecution": {"status": "Succeeded"}}, {"stageName": "Build", "latestExecution": {"status": "Failed"}}]}`).
+		On("codepipeline list-pipeline-executions", `{"pipelineExecutionSummaries": [{"status": "Failed", "startTime": "2024-05-02T14:30:58+00:00", "trigger": {"triggerType": "Webhook"}, "sourceRevisions": [{"revisionSummary": "Bump base image"}]}]}`).
+		On("codebuild list-projects", `{"projects": ["web-build", "migrations"]}`).
+		On("codebuild batch-get-projects", `{"projects": [{"name": "web-build"}, {"name": "migrations"}]}`).
+		On("codebuild list-builds-for-project", `{"ids": ["web-build:6b2c1f0e-8d4a-4c1b-9a53-2f7e1d0c9b11"]}`).
+		On("codebuild batch-get-builds", `{"builds": [{"projectName": "web-build", "buildStatus": "SUCCEEDED", "startTime": "2024-05-02T14:31:05.120000+00:00", "endTime": "2024-05-02T14:35:17.480000+00:00"}]}`).
+		On("cloudformation describe-stacks", `{"Stacks": []}`)
+	defer awscli.Use(fake)()
+
+	results, err := SyncCICDData(nil, "eu-west-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			t.Fatalf("results = %+v", results)
+		}
+	}
+
+	data, _ := LoadCICDData("eu-west-2")
+	if len(data.Pipelines) != 1 {
+		t.Fatalf("pipelines = %+v", data.Pipelines)
+	}
+	p := data.Pipelines[0]
+	if got := p.Summary(); got != "Source ✓ · Build ✗ · Deploy –" {
+		t.Errorf("summary = %q", got)
+	}
+	if p.LastStatus != "Failed" || p.LastTrigger != "Webhook" || p.LastRevision != "Bump base image" {
+		t.Errorf("last execution not resolved: %+v", p)
+	}
+	if got := p.BuildProjects(); len(got) != 1 || got[0] != "web-build" {
+		t.Errorf("build projects = %v", got)
+	}
+
+	if len(data.Projects) != 2 {
+		t.Fatalf("projects = %+v", data.Projects)
+	}
+	// batch-get-builds answers with web-build's build only.
+	if b := data.Projects[0].LastBuild; b == nil || b.Status != "SUCCEEDED" || b.Duration() != "4m12s" {
+		t.Errorf("web-build last build = %+v", b)
+	}
+	if b := data.Projects[1].LastBuild; b != nil {
+		t.Errorf("migrations last build = %+v, want none", b)
+	}
+	if !fake.Called("codebuild batch-get-projects --names web-build migrations") {
+		t.Error("projects not described in one batch")
+	}
+}
@@ -0,0 +1,190 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// CFStack is a CloudFormation stack with its parameters, outputs and the
+// resources it manages.
+type CFStack struct {
+	Name         string            `json:"Name"`
+	Id           string            `json:"Id"`
+	Status       string            `json:"Status"`
+	StatusReason string            `json:"StatusReason"`
+	Description  string            `json:"Description"`
+	Drift        string            `json:"Drift"`  // IN_SYNC, DRIFTED or NOT_CHECKED
+	Parent       string            `json:"Parent"` // parent stack name for nested stacks
+	Created      string            `json:"Created"`
+	Updated      string            `json:"Updated"`
+	Parameters   []CFParameter     `json:"Parameters"`
+	Outputs      []CFOutput        `json:"Outputs"`
+	Resources    []CFStackResource `json:"Resources"`
+}
+
+type CFParameter struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+type CFOutput struct {
+	Key         string `json:"Key"`
+	Value       string `json:"Value"`
+	Description string `json:"Description"`
+	Export      string `json:"Export"`
+}
+
+type CFStackResource struct {
+	LogicalId  string `json:"LogicalId"`
+	PhysicalId string `json:"PhysicalId"`
+	Type       string `json:"Type"` // e.g. AWS::EC2::SecurityGroup
+	Status     string `json:"Status"`
+}
+
+// Health buckets the stack status for display: "in-progress", "failed"
+// (including rolled back stacks) or "complete".
+func (s CFStack) Health() string {
+	switch {
+	case strings.HasSuffix(s.Status, "_IN_PROGRESS"):
+		return "in-progress"
+	case strings.Contains(s.Status, "FAILED"), strings.Contains(s.Status, "ROLLBACK"):
+		return "failed"
+	}
+	return "complete"
+}
+
+// SyncCloudFormationData fetches stacks with their parameters and outputs,
+// and the resources each one manages.
+func SyncCloudFormationData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("cloudformation", "describe-stacks", "--region", region)
+	if err != nil {
+		step("cloudformation")
+		return []SyncResult{{Service: "cloudformation", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		Stacks []json.RawMessage `json:"Stacks"`
+	}
+	json.Unmarshal(data, &resp)
+
+	stacks := []CFStack{}
+	for _, raw := range resp.Stacks {
+		stack := parseCFStack(raw)
+		if res, err := awscli.Run("cloudformation", "list-stack-resources", "--stack-name", stack.Name, "--region", region); err == nil {
+			var rResp struct {
+				StackResourceSummaries []json.RawMessage `json:"StackResourceSummaries"`
+			}
+			json.Unmarshal(res, &rResp)
+			for _, r := range rResp.StackResourceSummaries {
+				stack.Resources = append(stack.Resources, parseCFStackResource(r))
+			}
+		}
+		stacks = append(stacks, stack)
+	}
+	step("cloudformation")
+
+	b, _ := json.Marshal(stacks)
+	st.WriteCache(region+":cloudformation-stacks", b)
+	return []SyncResult{{Service: "cloudformation", Count: len(stacks)}}, nil
+}
+
+// LoadCFStacks returns the cached stacks for region.
+func LoadCFStacks(region string) ([]CFStack, error) {
+	raw, err := ReadCache(region + ":cloudformation-stacks")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var stacks []CFStack
+	json.Unmarshal(raw, &stacks)
+	return stacks, nil
+}
+
+// StackIndex maps the physical ID of every stack-managed resource in
+// region (an instance ID, bucket name, role name, ARN, ...) to the name
+// of the stack that manages it.
+func StackIndex(region string) map[string]string {
+	stacks, _ := LoadCFStacks(region)
+	index := map[string]string{}
+	for _, s := range stacks {
+		for _, r := range s.Resources {
+			if r.PhysicalId != "" && r.Type != "AWS::CloudFormation::Stack" {
+				index[r.PhysicalId] = s.Name
+			}
+		}
+	}
+	return index
+}
+
+// parseCFStack reads one describe-stacks entry; resources are listed
+// separately.
+func parseCFStack(raw json.RawMessage) CFStack {
+	var s struct {
+		StackName         string `json:"StackName"`
+		StackId           string `json:"StackId"`
+		Description       string `json:"Description"`
+		StackStatus       string `json:"StackStatus"`
+		StackStatusReason string `json:"StackStatusReason"`
+		CreationTime      string `json:"CreationTime"`
+		LastUpdatedTime   string `json:"LastUpdatedTime"`
+		ParentId          string `json:"ParentId"`
+		DriftInformation  struct {
+			StackDriftStatus string `json:"StackDriftStatus"`
+		} `json:"DriftInformation"`
+		Parameters []struct {
+			ParameterKey   string `json:"ParameterKey"`
+			ParameterValue string `json:"ParameterValue"`
+		} `json:"Parameters"`
+		Outputs []struct {
+			OutputKey   string `json:"OutputKey"`
+			OutputValue string `json:"OutputValue"`
+			Description string `json:"Description"`
+			ExportName  string `json:"ExportName"`
+		} `json:"Outputs"`
+	}
+	json.Unmarshal(raw, &s)
+
+	stack := CFStack{
+		Name:         s.StackName,
+		Id:           s.StackId,
+		Status:       s.StackStatus,
+		StatusReason: s.StackStatusReason,
+		Description:  s.Description,
+		Drift:        s.DriftInformation.StackDriftStatus,
+		Created:      formatCLITime(s.CreationTime),
+		Updated:      formatCLITime(s.LastUpdatedTime),
+	}
+	// arn:aws:cloudformation:<region>:<account>:stack/<name>/<uuid>
+	if parts := strings.Split(s.ParentId, "/"); len(parts) >= 2 {
+		stack.Parent = parts[1]
+	}
+	for _, p := range s.Parameters {
+		stack.Parameters = append(stack.Parameters, CFParameter{Key: p.ParameterKey, Value: p.ParameterValue})
+	}
+	for _, o := range s.Outputs {
+		stack.Outputs = append(stack.Outputs, CFOutput{Key: o.OutputKey, Value: o.OutputValue, Description: o.Description, Export: o.ExportName})
+	}
+	return stack
+}
+
+func parseCFStackResource(raw json.RawMessage) CFStackResource {
+	var r struct {
+		LogicalResourceId  string `json:"LogicalResourceId"`
+		PhysicalResourceId string `json:"PhysicalResourceId"`
+		ResourceType       string `json:"ResourceType"`
+		ResourceStatus     string `json:"ResourceStatus"`
+	}
+	json.Unmarshal(raw, &r)
+	return CFStackResource{
+		LogicalId:  r.LogicalResourceId,
+		PhysicalId: r.PhysicalResourceId,
+		Type:       r.ResourceType,
+		Status:     r.ResourceStatus,
+	}
+}
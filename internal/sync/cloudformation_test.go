@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestSyncCloudFormationData(t *testing.T) {
+	fake := awscli.NewFake().
+		On("cloudformation describe-stacks", `{"Stacks": [{"StackName": "web-app", "StackStatus": "UPDATE_IN_PROGRESS"}, {"StackName": "web-app-Database-1XK9Q2", "StackStatus": "UPDATE_ROLLBACK_COMPLETE"}]}`).
+		On("cloudformation list-stack-resources --stack-name web-app", `{"StackResourceSummaries": [
+			{"LogicalResourceId": "Database", "PhysicalResourceId": "arn:aws:cloudformation:ap-south-1:123456789012:stack/web-app-Database-1XK9Q2/4f1c2a90", "ResourceType": "AWS::CloudFormation::Stack", "ResourceStatus": "UPDATE_COMPLETE"},
+			{"LogicalResourceId": "TaskRole", "PhysicalResourceId": "web-app-TaskRole-8ZQ1", "ResourceType": "AWS::IAM::Role", "ResourceStatus": "CREATE_COMPLETE"}
+		]}`).
+		On("cloudformation list-stack-resources --stack-name web-app-Database-1XK9Q2", `{"StackResourceSummaries": [{"LogicalResourceId": "DBSecurityGroup", "PhysicalResourceId": "sg-0a1b2c3d4e5f60718", "ResourceType": "AWS::EC2::SecurityGroup", "ResourceStatus": "CREATE_COMPLETE"}]}`)
+	defer awscli.Use(fake)()
+
+	results, _ := SyncCloudFormationData(nil, "ap-south-1")
+	if len(results) != 1 || results[0].Error != "" || results[0].Count != 2 {
+		t.Fatalf("results = %+v", results)
+	}
+
+	stacks, _ := LoadCFStacks("ap-south-1")
+	if len(stacks) != 2 || len(stacks[0].Resources) != 2 || len(stacks[1].Resources) != 1 {
+		t.Fatalf("stacks = %+v", stacks)
+	}
+	if got := stacks[0].Health(); got != "in-progress" {
+		t.Errorf("web-app health = %q", got)
+	}
+	if got := stacks[1].Health(); got != "failed" {
+		t.Errorf("rolled back stack health = %q", got)
+	}
+
+	index := StackIndex("ap-south-1")
+	if index["web-app-TaskRole-8ZQ1"] != "web-app" || index["sg-0a1b2c3d4e5f60718"] != "web-app-Database-1XK9Q2" {
+		t.Errorf("index = %v", index)
+	}
+	// Nested stacks are members of their parent but are not attributed as resources.
+	if len(index) != 2 {
+		t.Errorf("index = %v, want nested stack skipped", index)
+	}
+}
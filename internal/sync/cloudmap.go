@@ -0,0 +1,152 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// CloudMapNamespace is a Cloud Map namespace (HTTP, DNS_PRIVATE or
+// DNS_PUBLIC) with its services.
+type CloudMapNamespace struct {
+	Id       string            `json:"Id"`
+	Arn      string            `json:"Arn"`
+	Name     string            `json:"Name"`
+	Type     string            `json:"Type"`
+	Services []CloudMapService `json:"Services,omitempty"`
+}
+
+// CloudMapService is a discoverable name in a namespace and the instances
+// registered under it.
+type CloudMapService struct {
+	Id        string             `json:"Id"`
+	Name      string             `json:"Name"`
+	Instances []CloudMapInstance `json:"Instances"`
+}
+
+// CloudMapInstance is one registration. ECS registers tasks with the task
+// ID as instance ID; EC2 and custom registrations carry their IP.
+type CloudMapInstance struct {
+	Id         string            `json:"Id"`
+	Attributes map[string]string `json:"Attributes"`
+}
+
+func (i CloudMapInstance) IP() string   { return i.Attributes["AWS_INSTANCE_IPV4"] }
+func (i CloudMapInstance) Port() string { return i.Attributes["AWS_INSTANCE_PORT"] }
+
+// Health returns the initial health status ECS or the caller registered,
+// "" when none was set.
+func (i CloudMapInstance) Health() string { return i.Attributes["AWS_INIT_HEALTH_STATUS"] }
+
+// Address returns ip:port, or just the IP when no port was registered.
+func (i CloudMapInstance) Address() string {
+	if i.Port() == "" {
+		return i.IP()
+	}
+	return i.IP() + ":" + i.Port()
+}
+
+// DNSName returns the name clients look up for the service.
+func (n CloudMapNamespace) DNSName(s CloudMapService) string {
+	return s.Name + "." + n.Name
+}
+
+// CloudMapTarget is the cached resource a registration resolves to.
+type CloudMapTarget struct {
+	Type    string // "ec2" or "ecs-task"
+	ID      string // instance ID, or task ID
+	Label   string
+	Cluster string // ECS only
+	Service string // ECS only
+}
+
+// ResolveCloudMapInstance matches a registration to a cached ECS task (by
+// task ID or private IP) or EC2 instance (by instance ID or private IP).
+// It returns nil when neither is cached.
+func (d *ComputeData) ResolveCloudMapInstance(inst CloudMapInstance) *CloudMapTarget {
+	if d == nil {
+		return nil
+	}
+	ip := inst.IP()
+	for _, cl := range d.ECS {
+		for _, t := range cl.Tasks {
+			taskId := t.TaskArn[strings.LastIndex(t.TaskArn, "/")+1:]
+			if taskId == inst.Id || (ip != "" && t.PrivateIP == ip) {
+				return &CloudMapTarget{Type: "ecs-task", ID: taskId, Label: cl.ClusterName + "/" + shortID(taskId),
+					Cluster: cl.ClusterName, Service: inst.Attributes["ECS_SERVICE_NAME"]}
+			}
+		}
+	}
+	ec2Id := inst.Attributes["EC2_INSTANCE_ID"]
+	for _, e := range d.EC2 {
+		if e.InstanceId == inst.Id || (ec2Id != "" && e.InstanceId == ec2Id) || (ip != "" && e.PrivateIP == ip) {
+			return &CloudMapTarget{Type: "ec2", ID: e.InstanceId, Label: nameOrID(e.Name, e.InstanceId)}
+		}
+	}
+	return nil
+}
+
+// CloudMapNamesFor returns the DNS names an IP is registered under.
+func (d *ServiceMeshData) CloudMapNamesFor(ip string) []string {
+	if d == nil || ip == "" {
+		return nil
+	}
+	var out []string
+	for _, n := range d.Namespaces {
+		for _, s := range n.Services {
+			for _, i := range s.Instances {
+				if i.IP() == ip {
+					out = append(out, n.DNSName(s))
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// syncCloudMapServices fills in the services of each namespace and their
+// registered instances.
+func syncCloudMapServices(region string, namespaces []CloudMapNamespace) {
+	for i := range namespaces {
+		ns := &namespaces[i]
+		out, err := awscli.Run("servicediscovery", "list-services", "--region", region,
+			"--filters", "Name=NAMESPACE_ID,Values="+ns.Id+",Condition=EQ")
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			Services []struct {
+				Id   string `json:"Id"`
+				Name string `json:"Name"`
+			} `json:"Services"`
+		}
+		json.Unmarshal(out, &resp)
+		for _, s := range resp.Services {
+			svc := CloudMapService{Id: s.Id, Name: s.Name}
+			if out, err := awscli.Run("servicediscovery", "list-instances", "--service-id", s.Id, "--region", region); err == nil {
+				var inst struct {
+					Instances []CloudMapInstance `json:"Instances"`
+				}
+				json.Unmarshal(out, &inst)
+				svc.Instances = inst.Instances
+			}
+			ns.Services = append(ns.Services, svc)
+		}
+	}
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+func nameOrID(name, id string) string {
+	if name != "" {
+		return name
+	}
+	return id
+}
@@ -0,0 +1,112 @@
+package sync
+
+import (
+	"encoding/json"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// Trail is a CloudTrail trail that records the region: one homed here, or
+// a multi-region or organization trail homed elsewhere.
+type Trail struct {
+	Name               string `json:"Name"`
+	Arn                string `json:"TrailARN"`
+	HomeRegion         string `json:"HomeRegion"`
+	IsMultiRegion      bool   `json:"IsMultiRegionTrail"`
+	IsOrganization     bool   `json:"IsOrganizationTrail"`
+	S3BucketName       string `json:"S3BucketName"`
+	S3KeyPrefix        string `json:"S3KeyPrefix"`
+	KmsKeyId           string `json:"KmsKeyId"`
+	LogFileValidation  bool   `json:"LogFileValidationEnabled"`
+	CloudWatchLogGroup string `json:"CloudWatchLogsLogGroupArn"`
+	IsLogging          bool   `json:"IsLogging"`
+	LatestDelivery     string `json:"LatestDeliveryTime"`
+	LatestDeliveryErr  string `json:"LatestDeliveryError"`
+}
+
+// Covers reports whether the trail records events in region.
+func (t Trail) Covers(region string) bool {
+	return t.IsMultiRegion || t.HomeRegion == region
+}
+
+// SyncCloudTrailData fetches the trails that apply to the region and
+// whether each is logging.
+func SyncCloudTrailData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("cloudtrail", "describe-trails", "--region", region)
+	if err != nil {
+		step("cloudtrail")
+		return []SyncResult{{Service: "cloudtrail", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		TrailList []Trail `json:"trailList"`
+	}
+	json.Unmarshal(data, &resp)
+
+	trails := resp.TrailList
+	for i, t := range trails {
+		// Trails homed in another region answer status queries by ARN.
+		status, err := awscli.Run("cloudtrail", "get-trail-status", "--name", t.Arn, "--region", t.HomeRegion)
+		if err != nil {
+			continue
+		}
+		var s struct {
+			IsLogging           bool   `json:"IsLogging"`
+			LatestDeliveryTime  string `json:"LatestDeliveryTime"`
+			LatestDeliveryError string `json:"LatestDeliveryError"`
+		}
+		json.Unmarshal(status, &s)
+		trails[i].IsLogging = s.IsLogging
+		trails[i].LatestDelivery = s.LatestDeliveryTime
+		trails[i].LatestDeliveryErr = s.LatestDeliveryError
+	}
+	step("cloudtrail")
+
+	b, _ := json.Marshal(trails)
+	st.WriteCache(region+":cloudtrail", b)
+	return []SyncResult{{Service: "cloudtrail", Count: len(trails)}}, nil
+}
+
+// LoadTrails returns the cached trails for region, or nil if CloudTrail
+// has not been synced.
+func LoadTrails(region string) ([]Trail, error) {
+	raw, err := ReadCache(region + ":cloudtrail")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	trails := []Trail{}
+	json.Unmarshal(raw, &trails)
+	return trails, nil
+}
+
+// HasActiveTrail reports whether a logging trail records region. ok is
+// false when CloudTrail has not been synced for it.
+func HasActiveTrail(region string) (active, ok bool) {
+	trails, err := LoadTrails(region)
+	if err != nil || trails == nil {
+		return false, false
+	}
+	for _, t := range trails {
+		if t.IsLogging && t.Covers(region) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// TrailsLoggingTo returns the trails in region delivering to bucket.
+func TrailsLoggingTo(region, bucket string) []Trail {
+	trails, _ := LoadTrails(region)
+	var out []Trail
+	for _, t := range trails {
+		if t.S3BucketName == bucket {
+			out = append(out, t)
+		}
+	}
+	return out
+}
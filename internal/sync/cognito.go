@@ -0,0 +1,229 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+type CognitoData struct {
+	UserPools     []CognitoUserPool     `json:"userPools"`
+	IdentityPools []CognitoIdentityPool `json:"identityPools"`
+}
+
+type CognitoUserPool struct {
+	Id               string             `json:"Id"`
+	Name             string             `json:"Name"`
+	Arn              string             `json:"Arn"`
+	Status           string             `json:"Status"`
+	EstimatedUsers   int                `json:"EstimatedNumberOfUsers"`
+	MfaConfiguration string             `json:"MfaConfiguration"` // OFF, ON, OPTIONAL
+	CreationDate     string             `json:"CreationDate"`
+	AppClients       []CognitoAppClient `json:"AppClients"`
+	UsedBy           []string           `json:"UsedBy"` // "API Gateway: name" / "AppSync: name"
+}
+
+type CognitoAppClient struct {
+	ClientId   string `json:"ClientId"`
+	ClientName string `json:"ClientName"`
+}
+
+type CognitoIdentityPool struct {
+	IdentityPoolId       string   `json:"IdentityPoolId"`
+	IdentityPoolName     string   `json:"IdentityPoolName"`
+	AllowUnauthenticated bool     `json:"AllowUnauthenticatedIdentities"`
+	UserPoolIds          []string `json:"UserPoolIds"`
+}
+
+// SyncCognitoData fetches user pools (with app clients), identity pools, and
+// the API Gateway authorizers / AppSync APIs that reference each user pool.
+func SyncCognitoData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	var results []SyncResult
+	data := CognitoData{}
+
+	// User pools
+	if list, err := awscli.Run("cognito-idp", "list-user-pools", "--max-results", "60", "--region", region); err == nil {
+		var resp struct {
+			UserPools []struct {
+				Id string `json:"Id"`
+			} `json:"UserPools"`
+		}
+		json.Unmarshal(list, &resp)
+		for _, p := range resp.UserPools {
+			desc, err := awscli.Run("cognito-idp", "describe-user-pool", "--user-pool-id", p.Id, "--region", region)
+			if err != nil {
+				continue
+			}
+			var d struct {
+				UserPool CognitoUserPool `json:"UserPool"`
+			}
+			json.Unmarshal(desc, &d)
+			pool := d.UserPool
+			if clients, err := awscli.Run("cognito-idp", "list-user-pool-clients", "--user-pool-id", p.Id,
+				"--max-results", "60", "--region", region); err == nil {
+				var c struct {
+					UserPoolClients []CognitoAppClient `json:"UserPoolClients"`
+				}
+				json.Unmarshal(clients, &c)
+				pool.AppClients = c.UserPoolClients
+			}
+			data.UserPools = append(data.UserPools, pool)
+		}
+		results = append(results, SyncResult{Service: "cognito-user-pools", Count: len(data.UserPools)})
+	} else {
+		results = append(results, SyncResult{Service: "cognito-user-pools", Error: err.Error()})
+	}
+	step("cognito user pools")
+
+	// Identity pools
+	if list, err := awscli.Run("cognito-identity", "list-identity-pools", "--max-results", "60", "--region", region); err == nil {
+		var resp struct {
+			IdentityPools []struct {
+				IdentityPoolId string `json:"IdentityPoolId"`
+			} `json:"IdentityPools"`
+		}
+		json.Unmarshal(list, &resp)
+		for _, p := range resp.IdentityPools {
+			desc, err := awscli.Run("cognito-identity", "describe-identity-pool", "--identity-pool-id", p.IdentityPoolId, "--region", region)
+			if err != nil {
+				continue
+			}
+			data.IdentityPools = append(data.IdentityPools, parseIdentityPool(desc))
+		}
+		results = append(results, SyncResult{Service: "cognito-identity-pools", Count: len(data.IdentityPools)})
+	} else {
+		results = append(results, SyncResult{Service: "cognito-identity-pools", Error: err.Error()})
+	}
+	step("cognito identity pools")
+
+	if len(data.UserPools) > 0 {
+		linkCognitoConsumers(region, data.UserPools)
+		step("cognito consumers")
+	}
+
+	enriched, _ := json.Marshal(data)
+	st.WriteCache(region+":cognito-enriched", enriched)
+	return results, nil
+}
+
+func LoadCognitoData(region string) (*CognitoData, error) {
+	raw, err := ReadCache(region + ":cognito-enriched")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var data CognitoData
+	json.Unmarshal(raw, &data)
+	return &data, nil
+}
+
+func parseIdentityPool(raw json.RawMessage) CognitoIdentityPool {
+	var r struct {
+		IdentityPoolId                 string `json:"IdentityPoolId"`
+		IdentityPoolName               string `json:"IdentityPoolName"`
+		AllowUnauthenticatedIdentities bool   `json:"AllowUnauthenticatedIdentities"`
+		CognitoIdentityProviders       []struct {
+			ProviderName string `json:"ProviderName"` // cognito-idp.<region>.amazonaws.com/<poolId>
+		} `json:"CognitoIdentityProviders"`
+	}
+	json.Unmarshal(raw, &r)
+	pool := CognitoIdentityPool{
+		IdentityPoolId:       r.IdentityPoolId,
+		IdentityPoolName:     r.IdentityPoolName,
+		AllowUnauthenticated: r.AllowUnauthenticatedIdentities,
+	}
+	for _, p := range r.CognitoIdentityProviders {
+		if i := strings.LastIndex(p.ProviderName, "/"); i >= 0 {
+			pool.UserPoolIds = append(pool.UserPoolIds, p.ProviderName[i+1:])
+		}
+	}
+	return pool
+}
+
+// linkCognitoConsumers records which REST API authorizers and AppSync APIs
+// authenticate against each user pool.
+func linkCognitoConsumers(region string, pools []CognitoUserPool) {
+	byArn := map[string]int{}
+	byId := map[string]int{}
+	for i, p := range pools {
+		byArn[p.Arn] = i
+		byId[p.Id] = i
+	}
+	addUse := func(i int, label string) {
+		for _, u := range pools[i].UsedBy {
+			if u == label {
+				return
+			}
+		}
+		pools[i].UsedBy = append(pools[i].UsedBy, label)
+	}
+
+	if apis, err := awscli.Run("apigateway", "get-rest-apis", "--region", region); err == nil {
+		var resp struct {
+			Items []struct {
+				Id   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"items"`
+		}
+		json.Unmarshal(apis, &resp)
+		for _, api := range resp.Items {
+			auths, err := awscli.Run("apigateway", "get-authorizers", "--rest-api-id", api.Id, "--region", region)
+			if err != nil {
+				continue
+			}
+			var a struct {
+				Items []struct {
+					Name         string   `json:"name"`
+					Type         string   `json:"type"`
+					ProviderARNs []string `json:"providerARNs"`
+				} `json:"items"`
+			}
+			json.Unmarshal(auths, &a)
+			for _, auth := range a.Items {
+				if auth.Type != "COGNITO_USER_POOLS" {
+					continue
+				}
+				for _, arn := range auth.ProviderARNs {
+					if i, ok := byArn[arn]; ok {
+						addUse(i, "API Gateway: "+api.Name+" ("+auth.Name+")")
+					}
+				}
+			}
+		}
+	}
+
+	if apis, err := awscli.Run("appsync", "list-graphql-apis", "--region", region); err == nil {
+		type poolConfig struct {
+			UserPoolId string `json:"userPoolId"`
+		}
+		var resp struct {
+			GraphqlApis []struct {
+				Name           string      `json:"name"`
+				UserPoolConfig *poolConfig `json:"userPoolConfig"`
+				Additional     []struct {
+					UserPoolConfig *poolConfig `json:"userPoolConfig"`
+				} `json:"additionalAuthenticationProviders"`
+			} `json:"graphqlApis"`
+		}
+		json.Unmarshal(apis, &resp)
+		for _, api := range resp.GraphqlApis {
+			configs := []*poolConfig{api.UserPoolConfig}
+			for _, a := range api.Additional {
+				configs = append(configs, a.UserPoolConfig)
+			}
+			for _, c := range configs {
+				if c == nil {
+					continue
+				}
+				if i, ok := byId[c.UserPoolId]; ok {
+					addUse(i, "AppSync: "+api.Name)
+				}
+			}
+		}
+	}
+}
@@ -2,15 +2,22 @@ package sync
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 
 	"github.com/estrados/simply-aws/internal/awscli"
 )
 
 type ComputeData struct {
-	EC2    []EC2Instance    `json:"ec2"`
-	ECS    []ECSCluster     `json:"ecs"`
-	Lambda []LambdaFunction `json:"lambda"`
+	EC2       []EC2Instance    `json:"ec2"`
+	ECS       []ECSCluster     `json:"ecs"`
+	Lambda    []LambdaFunction `json:"lambda"`
+	EBS       []EBSVolume      `json:"ebs"`
+	Snapshots []EBSSnapshot    `json:"snapshots"`
+	AutoScaling *AutoScalingData `json:"autoScaling,omitempty"`
+	AppRunner   []AppRunnerService          `json:"appRunner"`
+	Lightsail   []LightsailInstance         `json:"lightsail"`
+	LightsailContainers []LightsailContainerService `json:"lightsailContainers"`
 }
 
 type EC2Instance struct {
@@ -29,11 +36,38 @@ type EC2Instance struct {
 	KeyName        string       `json:"KeyName"`
 	ImageId        string       `json:"ImageId"`
 	Volumes        []EC2Volume  `json:"Volumes"`
+	Tags           map[string]string `json:"Tags,omitempty"`
+	Metrics        *EC2Metrics       `json:"Metrics,omitempty"`
+	AutoScalingGroup string          `json:"AutoScalingGroup,omitempty"` // set on load
 }
 
 type EC2Volume struct {
 	VolumeId   string `json:"VolumeId"`
 	DeviceName string `json:"DeviceName"`
+	Size       int    `json:"Size"` // GiB
+	Encrypted  bool   `json:"Encrypted"`
+}
+
+// EBSVolume is one volume from describe-volumes, attached or not.
+type EBSVolume struct {
+	VolumeId         string `json:"VolumeId"`
+	Name             string `json:"Name"`
+	Size             int    `json:"Size"`
+	VolumeType       string `json:"VolumeType"`
+	Iops             int    `json:"Iops"`
+	Throughput       int    `json:"Throughput"` // MiB/s, gp3 only
+	State            string `json:"State"`      // in-use, available, ...
+	AvailabilityZone string `json:"AvailabilityZone"`
+	CreateTime       string `json:"CreateTime"`
+	Encrypted        bool   `json:"Encrypted"`
+	KmsKeyId         string `json:"KmsKeyId"`
+	InstanceId       string `json:"InstanceId"`  // first attachment, "" when available
+	AttachState      string `json:"AttachState"` // attached, attaching, detaching, ""
+}
+
+// Unattached reports whether the volume is not attached to any instance.
+func (v EBSVolume) Unattached() bool {
+	return v.InstanceId == ""
 }
 
 type ECSCluster struct {
@@ -60,6 +94,11 @@ type ECSService struct {
 	SecurityGroups []string `json:"SecurityGroups"`
 	AssignPublicIP bool     `json:"AssignPublicIP"`
 	LBTargetGroups []string `json:"LBTargetGroups"`
+	Cpu            int      `json:"Cpu"`    // task-level CPU units from the task definition
+	Memory         int      `json:"Memory"` // task-level memory (MiB)
+	Images         []string `json:"Images"` // container images of the running task definition
+	Metrics        *UtilizationMetrics `json:"Metrics,omitempty"`
+	ServiceConnect *ServiceConnect     `json:"ServiceConnect,omitempty"`
 }
 
 type ECSTask struct {
@@ -91,6 +130,9 @@ type LambdaFunction struct {
 	Timeout        int      `json:"Timeout"`
 	CodeSize       int64    `json:"CodeSize"`
 	LastModified   string   `json:"LastModified"`
+	PackageType    string   `json:"PackageType"` // Zip or Image
+	ImageUri       string   `json:"ImageUri"`    // resolved (digest) URI for Image functions
+	KmsKeyArn      string   `json:"KmsKeyArn"`   // customer key for environment variables, "" for aws/lambda
 	FunctionUrl    string           `json:"FunctionUrl"`
 	Policies       []ResourcePolicy `json:"Policies"`
 	VpcId          string           `json:"VpcId"`
@@ -98,9 +140,14 @@ type LambdaFunction struct {
 	SecurityGroups []string         `json:"SecurityGroups"`
 	IamRole        string           `json:"IamRole"`
 	IamPolicies    []string         `json:"IamPolicies"`
+	Metrics        *LambdaMetrics   `json:"Metrics,omitempty"`
+	ReservedConcurrency *int                `json:"ReservedConcurrency,omitempty"` // nil when unreserved
+	Versions            []LambdaVersion     `json:"Versions,omitempty"`
+	Aliases             []LambdaAlias       `json:"Aliases,omitempty"`
+	Provisioned         []LambdaProvisioned `json:"Provisioned,omitempty"`
 }
 
-func SyncComputeData(region string, onStep ...func(string)) ([]SyncResult, error) {
+func SyncComputeData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -110,13 +157,13 @@ func SyncComputeData(region string, onStep ...func(string)) ([]SyncResult, error
 
 	// Sync security groups so SG detail links work from this tab
 	if data, err := awscli.Run("ec2", "describe-security-groups", "--region", region); err == nil {
-		WriteCache(region+":security-groups", data)
+		st.WriteCache(region+":security-groups", data)
 	}
 	step("security groups")
 
 	// EC2
 	if data, err := awscli.Run("ec2", "describe-instances", "--region", region); err == nil {
-		WriteCache(region+":ec2", data)
+		st.WriteCache(region+":ec2", data)
 		var resp struct {
 			Reservations []struct {
 				Instances []json.RawMessage `json:"Instances"`
@@ -129,14 +176,55 @@ func SyncComputeData(region string, onStep ...func(string)) ([]SyncResult, error
 				instances = append(instances, parseEC2Instance(inst))
 			}
 		}
+
+		// EBS volumes - block device mappings don't carry size or encryption
+		if vData, err := awscli.Run("ec2", "describe-volumes", "--region", region); err == nil {
+			st.WriteCache(region+":ebs-volumes", vData)
+			byId := map[string]EBSVolume{}
+			for _, v := range parseEBSVolumes(vData) {
+				byId[v.VolumeId] = v
+			}
+			for i := range instances {
+				for j, vol := range instances[i].Volumes {
+					if v, ok := byId[vol.VolumeId]; ok {
+						instances[i].Volumes[j].Size = v.Size
+						instances[i].Volumes[j].Encrypted = v.Encrypted
+					}
+				}
+			}
+			results = append(results, SyncResult{Service: "ebs", Count: len(byId)})
+			step("ebs volumes")
+		}
+		if MetricsEnabled() {
+			var ids []string
+			for _, inst := range instances {
+				if inst.State == "running" {
+					ids = append(ids, inst.InstanceId)
+				}
+			}
+			if len(ids) > 0 {
+				metrics := fetchEC2Metrics(region, ids)
+				for i := range instances {
+					instances[i].Metrics = metrics[instances[i].InstanceId]
+				}
+				step("ec2 metrics")
+			}
+		}
 		enriched, _ := json.Marshal(instances)
-		WriteCache(region+":ec2-enriched", enriched)
+		st.WriteCache(region+":ec2-enriched", enriched)
 		results = append(results, SyncResult{Service: "ec2", Count: len(instances)})
 	} else {
 		results = append(results, SyncResult{Service: "ec2", Error: err.Error()})
 	}
 	step("ec2")
 
+	// Auto Scaling groups, launch templates and launch configurations
+	asg, _ := SyncAutoScalingData(st, region, step)
+	results = append(results, asg...)
+
+	// AMIs and EBS snapshots shared publicly or with other accounts
+	results = append(results, syncImageSharing(st, region, step)...)
+
 	// ECS - list clusters, then describe
 	if data, err := awscli.Run("ecs", "list-clusters", "--region", region); err == nil {
 		var resp struct {
@@ -223,8 +311,27 @@ func SyncComputeData(region string, onStep ...func(string)) ([]SyncResult, error
 				}
 			}
 		}
+		resolveServiceReservations(region, clusters)
+		if MetricsEnabled() {
+			var svcs [][2]string
+			for _, cl := range clusters {
+				for _, svc := range cl.ECSServices {
+					svcs = append(svcs, [2]string{cl.ClusterName, svc.ServiceName})
+				}
+			}
+			if len(svcs) > 0 {
+				metrics := fetchECSServiceMetrics(region, svcs)
+				for i := range clusters {
+					for j := range clusters[i].ECSServices {
+						svc := &clusters[i].ECSServices[j]
+						svc.Metrics = metrics[clusters[i].ClusterName+"/"+svc.ServiceName]
+					}
+				}
+				step("ecs metrics")
+			}
+		}
 		enriched, _ := json.Marshal(clusters)
-		WriteCache(region+":ecs-enriched", enriched)
+		st.WriteCache(region+":ecs-enriched", enriched)
 		results = append(results, SyncResult{Service: "ecs", Count: len(clusters)})
 	} else {
 		results = append(results, SyncResult{Service: "ecs", Error: err.Error()})
@@ -240,6 +347,22 @@ func SyncComputeData(region string, onStep ...func(string)) ([]SyncResult, error
 		var functions []LambdaFunction
 		for _, f := range resp.Functions {
 			fn := parseLambdaFunction(f)
+			if fn.PackageType == "Image" {
+				if fnData, err := awscli.Run("lambda", "get-function",
+					"--function-name", fn.FunctionName, "--region", region); err == nil {
+					var fnResp struct {
+						Code struct {
+							ImageUri         string `json:"ImageUri"`
+							ResolvedImageUri string `json:"ResolvedImageUri"`
+						} `json:"Code"`
+					}
+					json.Unmarshal(fnData, &fnResp)
+					fn.ImageUri = fnResp.Code.ResolvedImageUri
+					if fn.ImageUri == "" {
+						fn.ImageUri = fnResp.Code.ImageUri
+					}
+				}
+			}
 			// Check for Function URL
 			if urlData, err := awscli.Run("lambda", "get-function-url-config",
 				"--function-name", fn.FunctionName, "--region", region); err == nil {
@@ -258,16 +381,38 @@ func SyncComputeData(region string, onStep ...func(string)) ([]SyncResult, error
 				json.Unmarshal(polData, &polResp)
 				fn.Policies = ParseResourcePolicies(polResp.Policy)
 			}
+			syncLambdaCapacity(region, &fn)
 			functions = append(functions, fn)
 		}
+		if MetricsEnabled() && len(functions) > 0 {
+			names := make([]string, len(functions))
+			for i, fn := range functions {
+				names[i] = fn.FunctionName
+			}
+			metrics := fetchLambdaMetrics(region, names)
+			for i := range functions {
+				functions[i].Metrics = metrics[functions[i].FunctionName]
+			}
+			step("lambda metrics")
+		}
 		enriched, _ := json.Marshal(functions)
-		WriteCache(region+":lambda", enriched)
+		st.WriteCache(region+":lambda", enriched)
 		results = append(results, SyncResult{Service: "lambda", Count: len(functions)})
 	} else {
 		results = append(results, SyncResult{Service: "lambda", Error: err.Error()})
 	}
 	step("lambda")
 
+	// ECR repositories and image scan results
+	ecr, _ := SyncECRData(st, region, step)
+	results = append(results, ecr...)
+
+	// App Runner and Lightsail
+	apprunner, _ := SyncAppRunnerData(st, region, step)
+	results = append(results, apprunner...)
+	lightsail, _ := SyncLightsailData(st, region, step)
+	results = append(results, lightsail...)
+
 	return results, nil
 }
 
@@ -302,6 +447,25 @@ func LoadComputeData(region string) (*ComputeData, error) {
 		json.Unmarshal(raw, &data.Lambda)
 	}
 
+	// EBS volumes and snapshots
+	data.EBS, _ = LoadEBSVolumes(region)
+	data.Snapshots, _ = LoadEBSSnapshots(region)
+
+	// Auto Scaling membership; the group tag covers instances synced
+	// before the groups were
+	data.AutoScaling, _ = LoadAutoScalingData(region)
+	for i, inst := range data.EC2 {
+		if name := data.AutoScaling.GroupForInstance(inst.InstanceId); name != "" {
+			data.EC2[i].AutoScalingGroup = name
+		} else {
+			data.EC2[i].AutoScalingGroup = inst.Tags["aws:autoscaling:groupName"]
+		}
+	}
+
+	// App Runner and Lightsail
+	data.AppRunner, _ = LoadAppRunnerServices(region)
+	data.Lightsail, data.LightsailContainers = LoadLightsailData(region)
+
 	return data, nil
 }
 
@@ -353,8 +517,11 @@ func parseEC2Instance(raw json.RawMessage) EC2Instance {
 	for _, tag := range r.Tags {
 		if tag.Key == "Name" {
 			inst.Name = tag.Value
-			break
 		}
+		if inst.Tags == nil {
+			inst.Tags = map[string]string{}
+		}
+		inst.Tags[tag.Key] = tag.Value
 	}
 	for _, sg := range r.SecurityGroups {
 		inst.SecurityGroups = append(inst.SecurityGroups, sg.GroupId)
@@ -374,6 +541,68 @@ func parseEC2Instance(raw json.RawMessage) EC2Instance {
 	return inst
 }
 
+// LoadEBSVolumes returns every cached EBS volume in a region.
+func LoadEBSVolumes(region string) ([]EBSVolume, error) {
+	raw, err := ReadCache(region + ":ebs-volumes")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	return parseEBSVolumes(raw), nil
+}
+
+func parseEBSVolumes(raw json.RawMessage) []EBSVolume {
+	var resp struct {
+		Volumes []struct {
+			VolumeId         string `json:"VolumeId"`
+			Size             int    `json:"Size"`
+			VolumeType       string `json:"VolumeType"`
+			Iops             int    `json:"Iops"`
+			Throughput       int    `json:"Throughput"`
+			State            string `json:"State"`
+			AvailabilityZone string `json:"AvailabilityZone"`
+			CreateTime       string `json:"CreateTime"`
+			Encrypted        bool   `json:"Encrypted"`
+			KmsKeyId         string `json:"KmsKeyId"`
+			Attachments      []struct {
+				InstanceId string `json:"InstanceId"`
+				State      string `json:"State"`
+			} `json:"Attachments"`
+			Tags []struct {
+				Key   string `json:"Key"`
+				Value string `json:"Value"`
+			} `json:"Tags"`
+		} `json:"Volumes"`
+	}
+	json.Unmarshal(raw, &resp)
+
+	var out []EBSVolume
+	for _, v := range resp.Volumes {
+		vol := EBSVolume{
+			VolumeId:         v.VolumeId,
+			Size:             v.Size,
+			VolumeType:       v.VolumeType,
+			Iops:             v.Iops,
+			Throughput:       v.Throughput,
+			State:            v.State,
+			AvailabilityZone: v.AvailabilityZone,
+			CreateTime:       v.CreateTime,
+			Encrypted:        v.Encrypted,
+			KmsKeyId:         v.KmsKeyId,
+		}
+		if len(v.Attachments) > 0 {
+			vol.InstanceId = v.Attachments[0].InstanceId
+			vol.AttachState = v.Attachments[0].State
+		}
+		for _, t := range v.Tags {
+			if t.Key == "Name" {
+				vol.Name = t.Value
+			}
+		}
+		out = append(out, vol)
+	}
+	return out
+}
+
 func resolveInstanceProfile(profileArn string) (roleName string, policies []string) {
 	// Extract instance profile name from ARN
 	// arn:aws:iam::123456:instance-profile/MyProfile
@@ -480,6 +709,62 @@ func parseECSTaskDef(raw json.RawMessage) ECSTaskDef {
 	return td
 }
 
+// resolveServiceReservations fills in task-level CPU/memory and container
+// images for each service from the exact task definition revision it runs.
+func resolveServiceReservations(region string, clusters []ECSCluster) {
+	type reservation struct {
+		cpu, memory int
+		images      []string
+	}
+	seen := map[string]reservation{}
+	for i := range clusters {
+		for j := range clusters[i].ECSServices {
+			svc := &clusters[i].ECSServices[j]
+			if svc.TaskDefinition == "" {
+				continue
+			}
+			res, ok := seen[svc.TaskDefinition]
+			if !ok {
+				if desc, err := awscli.Run("ecs", "describe-task-definition",
+					"--region", region, "--task-definition", svc.TaskDefinition); err == nil {
+					var r struct {
+						TaskDefinition struct {
+							Cpu                  string `json:"cpu"`
+							Memory               string `json:"memory"`
+							ContainerDefinitions []struct {
+								Image string `json:"image"`
+							} `json:"containerDefinitions"`
+						} `json:"taskDefinition"`
+					}
+					json.Unmarshal(desc, &r)
+					res.cpu, _ = strconv.Atoi(r.TaskDefinition.Cpu)
+					res.memory, _ = strconv.Atoi(r.TaskDefinition.Memory)
+					for _, c := range r.TaskDefinition.ContainerDefinitions {
+						res.images = append(res.images, c.Image)
+					}
+				}
+				seen[svc.TaskDefinition] = res
+			}
+			svc.Cpu, svc.Memory, svc.Images = res.cpu, res.memory, res.images
+		}
+	}
+}
+
+// TargetGroupNames returns the names of the service's target groups,
+// taken from their ARNs (arn:...:targetgroup/name/id).
+func (s ECSService) TargetGroupNames() []string {
+	var out []string
+	for _, arn := range s.LBTargetGroups {
+		parts := strings.Split(arn, "/")
+		if len(parts) >= 2 {
+			out = append(out, parts[1])
+		} else {
+			out = append(out, arn)
+		}
+	}
+	return out
+}
+
 func parseECSService(raw json.RawMessage) ECSService {
 	var r struct {
 		ServiceName    string `json:"serviceName"`
@@ -500,6 +785,21 @@ func parseECSService(raw json.RawMessage) ECSService {
 			ContainerName  string `json:"containerName"`
 			ContainerPort  int    `json:"containerPort"`
 		} `json:"loadBalancers"`
+		Deployments []struct {
+			Status                      string `json:"status"`
+			ServiceConnectConfiguration *struct {
+				Enabled   bool   `json:"enabled"`
+				Namespace string `json:"namespace"`
+				Services  []struct {
+					PortName      string `json:"portName"`
+					DiscoveryName string `json:"discoveryName"`
+					ClientAliases []struct {
+						Port    int    `json:"port"`
+						DnsName string `json:"dnsName"`
+					} `json:"clientAliases"`
+				} `json:"services"`
+			} `json:"serviceConnectConfiguration"`
+		} `json:"deployments"`
 	}
 	json.Unmarshal(raw, &r)
 
@@ -519,6 +819,33 @@ func parseECSService(raw json.RawMessage) ECSService {
 	for _, lb := range r.LoadBalancers {
 		svc.LBTargetGroups = append(svc.LBTargetGroups, lb.TargetGroupArn)
 	}
+	// Service Connect is configured per deployment; the primary one is
+	// what is serving traffic.
+	for _, d := range r.Deployments {
+		sc := d.ServiceConnectConfiguration
+		if d.Status != "PRIMARY" || sc == nil || !sc.Enabled {
+			continue
+		}
+		svc.ServiceConnect = &ServiceConnect{Namespace: sc.Namespace}
+		for _, s := range sc.Services {
+			discovery := s.DiscoveryName
+			if discovery == "" {
+				discovery = s.PortName
+			}
+			if len(s.ClientAliases) == 0 {
+				svc.ServiceConnect.Endpoints = append(svc.ServiceConnect.Endpoints,
+					ServiceConnectEndpoint{PortName: s.PortName, DiscoveryName: discovery, DNSName: discovery})
+			}
+			for _, a := range s.ClientAliases {
+				dns := a.DnsName
+				if dns == "" {
+					dns = discovery
+				}
+				svc.ServiceConnect.Endpoints = append(svc.ServiceConnect.Endpoints,
+					ServiceConnectEndpoint{PortName: s.PortName, DiscoveryName: discovery, DNSName: dns, Port: a.Port})
+			}
+		}
+	}
 	return svc
 }
 
@@ -595,6 +922,8 @@ func parseLambdaFunction(raw json.RawMessage) LambdaFunction {
 		Timeout      int    `json:"Timeout"`
 		CodeSize     int64  `json:"CodeSize"`
 		LastModified string `json:"LastModified"`
+		PackageType  string `json:"PackageType"`
+		KMSKeyArn    string `json:"KMSKeyArn"`
 		Role         string `json:"Role"`
 		VpcConfig    *struct {
 			VpcId            string   `json:"VpcId"`
@@ -613,6 +942,8 @@ func parseLambdaFunction(raw json.RawMessage) LambdaFunction {
 		Timeout:      r.Timeout,
 		CodeSize:     r.CodeSize,
 		LastModified: r.LastModified,
+		PackageType:  r.PackageType,
+		KmsKeyArn:    r.KMSKeyArn,
 	}
 	if r.VpcConfig != nil && r.VpcConfig.VpcId != "" {
 		fn.VpcId = r.VpcConfig.VpcId
@@ -0,0 +1,249 @@
+package sync
+
+import (
+	"encoding/json"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// ConfigData is the region's AWS Config rules with their compliance, the
+// compliance of every evaluated resource, and the rule-level detail of the
+// noncompliant ones.
+type ConfigData struct {
+	Rules       []ConfigRule         `json:"Rules"`
+	Resources   []ResourceCompliance `json:"Resources"`
+	Evaluations []ConfigEvaluation   `json:"Evaluations"`
+}
+
+// ConfigRule is a Config rule. Source is the managed rule identifier, or
+// "custom" for Lambda and Guard rules.
+type ConfigRule struct {
+	Name              string `json:"Name"`
+	Arn               string `json:"Arn"`
+	Source            string `json:"Source"`
+	State             string `json:"State"`
+	Compliance        string `json:"Compliance"`
+	NoncompliantCount int    `json:"NoncompliantCount"`
+	CapExceeded       bool   `json:"CapExceeded"`
+}
+
+// Noncompliant reports whether any resource fails the rule.
+func (r ConfigRule) Noncompliant() bool {
+	return r.Compliance == "NON_COMPLIANT"
+}
+
+// ResourceCompliance is the overall compliance of one evaluated resource.
+type ResourceCompliance struct {
+	ResourceType string `json:"ResourceType"`
+	ResourceId   string `json:"ResourceId"`
+	Compliance   string `json:"Compliance"`
+}
+
+// ConfigEvaluation is one rule's NON_COMPLIANT result for one resource.
+type ConfigEvaluation struct {
+	Rule         string `json:"Rule"`
+	ResourceType string `json:"ResourceType"`
+	ResourceId   string `json:"ResourceId"`
+	Annotation   string `json:"Annotation,omitempty"`
+	RecordedAt   string `json:"RecordedAt"`
+}
+
+// configDetailTypes maps Config resource types to /detail/ types whose ID
+// is the same as the Config resource ID. RDS and IAM are left out: Config
+// identifies them by resource ID rather than name.
+var configDetailTypes = map[string]string{
+	"AWS::EC2::Instance":      "ec2",
+	"AWS::EC2::SecurityGroup": "sg",
+	"AWS::EC2::VPC":           "vpc",
+	"AWS::EC2::Subnet":        "subnet",
+	"AWS::EC2::Volume":        "ebs",
+	"AWS::S3::Bucket":         "s3",
+	"AWS::Lambda::Function":   "lambda",
+	"AWS::DynamoDB::Table":    "dynamodb",
+}
+
+// DetailType returns the /detail/ type of the resource, "" when it has no
+// detail panel.
+func (e ConfigEvaluation) DetailType() string {
+	return configDetailTypes[e.ResourceType]
+}
+
+// NoncompliantResources returns the number of resources failing at least
+// one rule.
+func (d *ConfigData) NoncompliantResources() int {
+	n := 0
+	for _, r := range d.Resources {
+		if r.Compliance == "NON_COMPLIANT" {
+			n++
+		}
+	}
+	return n
+}
+
+// EvaluationsForRule returns the noncompliant resources of a rule.
+func (d *ConfigData) EvaluationsForRule(rule string) []ConfigEvaluation {
+	var out []ConfigEvaluation
+	for _, e := range d.Evaluations {
+		if e.Rule == rule {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// ComplianceFor returns the compliance of the resource with the given
+// Config resource ID and the rules it fails. ok is false when Config has
+// not evaluated it.
+func (d *ConfigData) ComplianceFor(resourceId string) (compliance string, failing []ConfigEvaluation, ok bool) {
+	for _, r := range d.Resources {
+		if r.ResourceId == resourceId {
+			compliance, ok = r.Compliance, true
+			break
+		}
+	}
+	for _, e := range d.Evaluations {
+		if e.ResourceId == resourceId {
+			failing = append(failing, e)
+		}
+	}
+	return compliance, failing, ok || len(failing) > 0
+}
+
+// SyncConfigData fetches Config rules, their compliance, per-resource
+// compliance and the failing resources of each noncompliant rule.
+func SyncConfigData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("configservice", "describe-config-rules", "--region", region)
+	if err != nil {
+		step("config rules")
+		return []SyncResult{{Service: "config", Error: err.Error()}}, nil
+	}
+	var rulesResp struct {
+		ConfigRules []struct {
+			ConfigRuleName  string `json:"ConfigRuleName"`
+			ConfigRuleArn   string `json:"ConfigRuleArn"`
+			ConfigRuleState string `json:"ConfigRuleState"`
+			Source          struct {
+				Owner            string `json:"Owner"`
+				SourceIdentifier string `json:"SourceIdentifier"`
+			} `json:"Source"`
+		} `json:"ConfigRules"`
+	}
+	json.Unmarshal(data, &rulesResp)
+
+	cfg := &ConfigData{}
+	for _, r := range rulesResp.ConfigRules {
+		source := r.Source.SourceIdentifier
+		if r.Source.Owner != "AWS" {
+			source = "custom"
+		}
+		cfg.Rules = append(cfg.Rules, ConfigRule{
+			Name:   r.ConfigRuleName,
+			Arn:    r.ConfigRuleArn,
+			Source: source,
+			State:  r.ConfigRuleState,
+		})
+	}
+	step("config rules")
+
+	if data, err := awscli.Run("configservice", "describe-compliance-by-config-rule", "--region", region); err == nil {
+		var resp struct {
+			ComplianceByConfigRules []struct {
+				ConfigRuleName string `json:"ConfigRuleName"`
+				Compliance     struct {
+					ComplianceType             string `json:"ComplianceType"`
+					ComplianceContributorCount struct {
+						CappedCount int  `json:"CappedCount"`
+						CapExceeded bool `json:"CapExceeded"`
+					} `json:"ComplianceContributorCount"`
+				} `json:"Compliance"`
+			} `json:"ComplianceByConfigRules"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, c := range resp.ComplianceByConfigRules {
+			for i := range cfg.Rules {
+				if cfg.Rules[i].Name == c.ConfigRuleName {
+					cfg.Rules[i].Compliance = c.Compliance.ComplianceType
+					cfg.Rules[i].NoncompliantCount = c.Compliance.ComplianceContributorCount.CappedCount
+					cfg.Rules[i].CapExceeded = c.Compliance.ComplianceContributorCount.CapExceeded
+				}
+			}
+		}
+	}
+
+	if data, err := awscli.Run("configservice", "describe-compliance-by-resource", "--region", region); err == nil {
+		var resp struct {
+			ComplianceByResources []struct {
+				ResourceType string `json:"ResourceType"`
+				ResourceId   string `json:"ResourceId"`
+				Compliance   struct {
+					ComplianceType string `json:"ComplianceType"`
+				} `json:"Compliance"`
+			} `json:"ComplianceByResources"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, r := range resp.ComplianceByResources {
+			cfg.Resources = append(cfg.Resources, ResourceCompliance{
+				ResourceType: r.ResourceType,
+				ResourceId:   r.ResourceId,
+				Compliance:   r.Compliance.ComplianceType,
+			})
+		}
+	}
+	step("config compliance")
+
+	for _, rule := range cfg.Rules {
+		if !rule.Noncompliant() {
+			continue
+		}
+		data, err := awscli.Run("configservice", "get-compliance-details-by-config-rule",
+			"--config-rule-name", rule.Name, "--compliance-types", "NON_COMPLIANT", "--region", region)
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			EvaluationResults []struct {
+				EvaluationResultIdentifier struct {
+					EvaluationResultQualifier struct {
+						ResourceType string `json:"ResourceType"`
+						ResourceId   string `json:"ResourceId"`
+					} `json:"EvaluationResultQualifier"`
+				} `json:"EvaluationResultIdentifier"`
+				Annotation         string `json:"Annotation"`
+				ResultRecordedTime string `json:"ResultRecordedTime"`
+			} `json:"EvaluationResults"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, e := range resp.EvaluationResults {
+			q := e.EvaluationResultIdentifier.EvaluationResultQualifier
+			cfg.Evaluations = append(cfg.Evaluations, ConfigEvaluation{
+				Rule:         rule.Name,
+				ResourceType: q.ResourceType,
+				ResourceId:   q.ResourceId,
+				Annotation:   e.Annotation,
+				RecordedAt:   e.ResultRecordedTime,
+			})
+		}
+	}
+	step("config evaluations")
+
+	b, _ := json.Marshal(cfg)
+	st.WriteCache(region+":config", b)
+	return []SyncResult{{Service: "config", Count: len(cfg.Rules)}}, nil
+}
+
+// LoadConfigData returns the cached Config rules and compliance for region.
+func LoadConfigData(region string) (*ConfigData, error) {
+	raw, err := ReadCache(region + ":config")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var cfg ConfigData
+	json.Unmarshal(raw, &cfg)
+	return &cfg, nil
+}
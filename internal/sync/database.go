@@ -8,6 +8,7 @@ import (
 
 type DatabaseData struct {
 	RDS         []RDSInstance    `json:"rds"`
+	RDSClusters []RDSCluster     `json:"rdsClusters"`
 	DynamoDB    []DynamoDBTable `json:"dynamodb"`
 	ElastiCache []ElastiCacheCluster `json:"elasticache"`
 }
@@ -27,15 +28,124 @@ type RDSInstance struct {
 	SubnetGroupName    string   `json:"SubnetGroupName"`
 	PubliclyAccessible bool     `json:"PubliclyAccessible"`
 	SecurityGroups     []string `json:"SecurityGroups"`
+	StorageEncrypted   bool     `json:"StorageEncrypted"`
+	KmsKeyId           string   `json:"KmsKeyId"`
+	ClusterId          string   `json:"DBClusterIdentifier"` // set for Aurora/Multi-AZ cluster members
+	Arn                string   `json:"DBInstanceArn"`
+	ReplicaOf          string   `json:"ReplicaOf"` // source instance ID or ARN when this is a read replica
+	Replicas           []string `json:"Replicas"`  // read replica IDs; cross-region replicas are ARNs
+	Tags               map[string]string `json:"Tags,omitempty"`
+}
+
+// RDSCluster is an Aurora (or Multi-AZ DB) cluster. Its member instances
+// also appear in RDS with ClusterId set.
+type RDSCluster struct {
+	ClusterId        string             `json:"DBClusterIdentifier"`
+	Arn              string             `json:"DBClusterArn"`
+	Engine           string             `json:"Engine"`
+	EngineVersion    string             `json:"EngineVersion"`
+	EngineMode       string             `json:"EngineMode"` // provisioned, serverless (v1), ...
+	Status           string             `json:"Status"`
+	Endpoint         string             `json:"Endpoint"`
+	ReaderEndpoint   string             `json:"ReaderEndpoint"`
+	Port             int                `json:"Port"`
+	MultiAZ          bool               `json:"MultiAZ"`
+	StorageEncrypted bool               `json:"StorageEncrypted"`
+	KmsKeyId         string             `json:"KmsKeyId"`
+	SubnetGroupName  string             `json:"SubnetGroupName"`
+	VpcId            string             `json:"VpcId"` // from the member instances
+	SecurityGroups   []string           `json:"SecurityGroups"`
+	Members          []RDSClusterMember `json:"Members"`
+	MinACU           float64            `json:"MinACU"` // Serverless v2 capacity, 0 when not configured
+	MaxACU           float64            `json:"MaxACU"`
+	GlobalClusterId  string             `json:"GlobalClusterId"`
+	GlobalPrimary    bool               `json:"GlobalPrimary"` // writer cluster of the global database
+	GlobalRegions    []string           `json:"GlobalRegions"` // regions of the other global database members
+}
+
+type RDSClusterMember struct {
+	InstanceId string `json:"DBInstanceIdentifier"`
+	IsWriter   bool   `json:"IsClusterWriter"`
+}
+
+// Family is the service that owns the cluster: "aurora", "docdb" or
+// "neptune". DocumentDB and Neptune share the RDS management API, so
+// describe-db-clusters returns their clusters and instances too.
+func (c RDSCluster) Family() string {
+	switch c.Engine {
+	case "docdb", "neptune":
+		return c.Engine
+	}
+	return "aurora"
+}
+
+// Writer returns the writer member's instance ID, or "".
+func (c RDSCluster) Writer() string {
+	for _, m := range c.Members {
+		if m.IsWriter {
+			return m.InstanceId
+		}
+	}
+	return ""
+}
+
+// Readers returns the reader members' instance IDs.
+func (c RDSCluster) Readers() []string {
+	var out []string
+	for _, m := range c.Members {
+		if !m.IsWriter {
+			out = append(out, m.InstanceId)
+		}
+	}
+	return out
+}
+
+// ClustersOf returns the clusters of one Family.
+func (d *DatabaseData) ClustersOf(family string) []RDSCluster {
+	var out []RDSCluster
+	for _, c := range d.RDSClusters {
+		if c.Family() == family {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// StandaloneRDS returns the instances that are not members of a cluster;
+// cluster members are shown under RDSClusters instead.
+func (d *DatabaseData) StandaloneRDS() []RDSInstance {
+	var out []RDSInstance
+	for _, inst := range d.RDS {
+		if inst.ClusterId == "" {
+			out = append(out, inst)
+		}
+	}
+	return out
 }
 
 type DynamoDBTable struct {
-	TableName    string `json:"TableName"`
-	Status       string `json:"TableStatus"`
-	ItemCount    int64  `json:"ItemCount"`
-	SizeBytes    int64  `json:"TableSizeBytes"`
-	BillingMode  string `json:"BillingMode"`
-	TableClass   string `json:"TableClass"`
+	TableName          string            `json:"TableName"`
+	Status             string            `json:"TableStatus"`
+	ItemCount          int64             `json:"ItemCount"`
+	SizeBytes          int64             `json:"TableSizeBytes"`
+	BillingMode        string            `json:"BillingMode"`
+	TableClass         string            `json:"TableClass"`
+	SSEType            string            `json:"SSEType"` // "KMS" for a KMS key, "" for the AWS-owned default
+	KmsKeyId           string            `json:"KmsKeyId"`
+	TableArn           string            `json:"TableArn"`
+	ReplicaRegions     []string          `json:"ReplicaRegions"` // global table replicas, excluding this region
+	PartitionKey       string            `json:"PartitionKey"`   // "name (S)"
+	SortKey            string            `json:"SortKey"`
+	ReadCapacity       int64             `json:"ReadCapacity"`  // provisioned mode only
+	WriteCapacity      int64             `json:"WriteCapacity"` // provisioned mode only
+	Indexes            []DynamoDBIndex   `json:"Indexes,omitempty"`
+	StreamViewType     string            `json:"StreamViewType"` // "" when streams are off
+	StreamArn          string            `json:"StreamArn"`
+	DeletionProtection bool              `json:"DeletionProtection"`
+	TTLAttribute       string            `json:"TTLAttribute"` // "" when TTL is off
+	TTLStatus          string            `json:"TTLStatus"`
+	PITR               string            `json:"PITR"` // "ENABLED", "DISABLED"; "" if not synced
+	Scaling            []DynamoDBScaling `json:"Scaling,omitempty"`
 }
 
 type ElastiCacheCluster struct {
@@ -52,7 +162,7 @@ type ElastiCacheCluster struct {
 	SecurityGroups   []string `json:"SecurityGroups"`
 }
 
-func SyncDatabaseData(region string, onStep ...func(string)) ([]SyncResult, error) {
+func SyncDatabaseData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -62,19 +172,43 @@ func SyncDatabaseData(region string, onStep ...func(string)) ([]SyncResult, erro
 
 	// Sync security groups so SG detail links work from this tab
 	if data, err := awscli.Run("ec2", "describe-security-groups", "--region", region); err == nil {
-		WriteCache(region+":security-groups", data)
+		st.WriteCache(region+":security-groups", data)
 	}
 	step("security groups")
 
 	// RDS
 	if data, err := awscli.Run("rds", "describe-db-instances", "--region", region); err == nil {
-		WriteCache(region+":rds", data)
+		st.WriteCache(region+":rds", data)
 		results = append(results, SyncResult{Service: "rds", Count: countKey(data, "DBInstances")})
 	} else {
 		results = append(results, SyncResult{Service: "rds", Error: err.Error()})
 	}
 	step("rds")
 
+	// RDS clusters (Aurora, DocumentDB, Neptune) and the global databases they belong to
+	if data, err := awscli.Run("rds", "describe-db-clusters", "--region", region); err == nil {
+		st.WriteCache(region+":rds-clusters", data)
+		results = append(results, SyncResult{Service: "rds-clusters", Count: countKey(data, "DBClusters")})
+	} else {
+		results = append(results, SyncResult{Service: "rds-clusters", Error: err.Error()})
+	}
+	if data, err := awscli.Run("rds", "describe-global-clusters", "--region", region); err == nil {
+		st.WriteCache(region+":rds-global-clusters", data)
+	}
+	step("rds clusters")
+
+	// Manual DB snapshots shared publicly or with other accounts
+	results = append(results, syncRDSSnapshotSharing(st, region, step)...)
+
+	// AWS Backup copy jobs - cross-region copies of any resource, used by the DR report
+	if data, err := awscli.Run("backup", "list-copy-jobs", "--by-state", "COMPLETED", "--region", region); err == nil {
+		st.WriteCache(region+":backup-copy-jobs", data)
+		results = append(results, SyncResult{Service: "backup-copy-jobs", Count: countKey(data, "CopyJobs")})
+	} else {
+		results = append(results, SyncResult{Service: "backup-copy-jobs", Error: err.Error()})
+	}
+	step("backup copy jobs")
+
 	// DynamoDB - list then describe each
 	if data, err := awscli.Run("dynamodb", "list-tables", "--region", region); err == nil {
 		var resp struct {
@@ -88,8 +222,9 @@ func SyncDatabaseData(region string, onStep ...func(string)) ([]SyncResult, erro
 				tables = append(tables, parseDynamoDBTable(tData))
 			}
 		}
+		syncDynamoDBSettings(region, tables)
 		tablesJSON, _ := json.Marshal(tables)
-		WriteCache(region+":dynamodb", tablesJSON)
+		st.WriteCache(region+":dynamodb", tablesJSON)
 		results = append(results, SyncResult{Service: "dynamodb", Count: len(tables)})
 	} else {
 		results = append(results, SyncResult{Service: "dynamodb", Error: err.Error()})
@@ -107,7 +242,7 @@ func SyncDatabaseData(region string, onStep ...func(string)) ([]SyncResult, erro
 			clusters = append(clusters, parseElastiCache(c, region))
 		}
 		enriched, _ := json.Marshal(clusters)
-		WriteCache(region+":elasticache-enriched", enriched)
+		st.WriteCache(region+":elasticache-enriched", enriched)
 		results = append(results, SyncResult{Service: "elasticache", Count: len(clusters)})
 	} else {
 		results = append(results, SyncResult{Service: "elasticache", Error: err.Error()})
@@ -131,6 +266,18 @@ func LoadDatabaseData(region string) (*DatabaseData, error) {
 		}
 	}
 
+	// RDS clusters
+	if raw, err := ReadCache(region + ":rds-clusters"); err == nil && raw != nil {
+		var resp struct {
+			DBClusters []json.RawMessage `json:"DBClusters"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, c := range resp.DBClusters {
+			data.RDSClusters = append(data.RDSClusters, parseRDSCluster(c))
+		}
+		resolveClusterTopology(region, data)
+	}
+
 	// DynamoDB
 	if raw, err := ReadCache(region + ":dynamodb"); err == nil && raw != nil {
 		json.Unmarshal(raw, &data.DynamoDB)
@@ -146,16 +293,22 @@ func LoadDatabaseData(region string) (*DatabaseData, error) {
 
 func parseRDSInstance(raw json.RawMessage) RDSInstance {
 	var r struct {
-		DBInstanceIdentifier string `json:"DBInstanceIdentifier"`
-		Engine               string `json:"Engine"`
-		EngineVersion        string `json:"EngineVersion"`
-		DBInstanceClass      string `json:"DBInstanceClass"`
-		DBInstanceStatus     string `json:"DBInstanceStatus"`
-		MultiAZ              bool   `json:"MultiAZ"`
-		StorageType          string `json:"StorageType"`
-		AllocatedStorage     int    `json:"AllocatedStorage"`
-		PubliclyAccessible   bool   `json:"PubliclyAccessible"`
-		Endpoint             *struct {
+		DBInstanceIdentifier                  string   `json:"DBInstanceIdentifier"`
+		Engine                                string   `json:"Engine"`
+		EngineVersion                         string   `json:"EngineVersion"`
+		DBInstanceClass                       string   `json:"DBInstanceClass"`
+		DBInstanceStatus                      string   `json:"DBInstanceStatus"`
+		MultiAZ                               bool     `json:"MultiAZ"`
+		StorageType                           string   `json:"StorageType"`
+		AllocatedStorage                      int      `json:"AllocatedStorage"`
+		PubliclyAccessible                    bool     `json:"PubliclyAccessible"`
+		StorageEncrypted                      bool     `json:"StorageEncrypted"`
+		KmsKeyId                              string   `json:"KmsKeyId"`
+		DBClusterIdentifier                   string   `json:"DBClusterIdentifier"`
+		DBInstanceArn                         string   `json:"DBInstanceArn"`
+		ReadReplicaSourceDBInstanceIdentifier string   `json:"ReadReplicaSourceDBInstanceIdentifier"`
+		ReadReplicaDBInstanceIdentifiers      []string `json:"ReadReplicaDBInstanceIdentifiers"`
+		Endpoint                              *struct {
 			Address string `json:"Address"`
 			Port    int    `json:"Port"`
 		} `json:"Endpoint"`
@@ -166,6 +319,10 @@ func parseRDSInstance(raw json.RawMessage) RDSInstance {
 		VpcSecurityGroups []struct {
 			VpcSecurityGroupId string `json:"VpcSecurityGroupId"`
 		} `json:"VpcSecurityGroups"`
+		TagList []struct {
+			Key   string `json:"Key"`
+			Value string `json:"Value"`
+		} `json:"TagList"`
 	}
 	json.Unmarshal(raw, &r)
 
@@ -179,6 +336,12 @@ func parseRDSInstance(raw json.RawMessage) RDSInstance {
 		StorageType:        r.StorageType,
 		AllocatedStorage:   r.AllocatedStorage,
 		PubliclyAccessible: r.PubliclyAccessible,
+		StorageEncrypted:   r.StorageEncrypted,
+		KmsKeyId:           r.KmsKeyId,
+		ClusterId:          r.DBClusterIdentifier,
+		Arn:                r.DBInstanceArn,
+		ReplicaOf:          r.ReadReplicaSourceDBInstanceIdentifier,
+		Replicas:           r.ReadReplicaDBInstanceIdentifiers,
 	}
 	if r.Endpoint != nil {
 		inst.Endpoint = r.Endpoint.Address
@@ -191,22 +354,157 @@ func parseRDSInstance(raw json.RawMessage) RDSInstance {
 	for _, sg := range r.VpcSecurityGroups {
 		inst.SecurityGroups = append(inst.SecurityGroups, sg.VpcSecurityGroupId)
 	}
+	for _, t := range r.TagList {
+		if inst.Tags == nil {
+			inst.Tags = map[string]string{}
+		}
+		inst.Tags[t.Key] = t.Value
+	}
 	return inst
 }
 
+func parseRDSCluster(raw json.RawMessage) RDSCluster {
+	var r struct {
+		DBClusterIdentifier string `json:"DBClusterIdentifier"`
+		DBClusterArn        string `json:"DBClusterArn"`
+		Engine              string `json:"Engine"`
+		EngineVersion       string `json:"EngineVersion"`
+		EngineMode          string `json:"EngineMode"`
+		Status              string `json:"Status"`
+		Endpoint            string `json:"Endpoint"`
+		ReaderEndpoint      string `json:"ReaderEndpoint"`
+		Port                int    `json:"Port"`
+		MultiAZ             bool   `json:"MultiAZ"`
+		StorageEncrypted    bool   `json:"StorageEncrypted"`
+		KmsKeyId            string `json:"KmsKeyId"`
+		DBSubnetGroup       string `json:"DBSubnetGroup"`
+		VpcSecurityGroups   []struct {
+			VpcSecurityGroupId string `json:"VpcSecurityGroupId"`
+		} `json:"VpcSecurityGroups"`
+		DBClusterMembers                 []RDSClusterMember `json:"DBClusterMembers"`
+		ServerlessV2ScalingConfiguration *struct {
+			MinCapacity float64 `json:"MinCapacity"`
+			MaxCapacity float64 `json:"MaxCapacity"`
+		} `json:"ServerlessV2ScalingConfiguration"`
+	}
+	json.Unmarshal(raw, &r)
+
+	c := RDSCluster{
+		ClusterId:        r.DBClusterIdentifier,
+		Arn:              r.DBClusterArn,
+		Engine:           r.Engine,
+		EngineVersion:    r.EngineVersion,
+		EngineMode:       r.EngineMode,
+		Status:           r.Status,
+		Endpoint:         r.Endpoint,
+		ReaderEndpoint:   r.ReaderEndpoint,
+		Port:             r.Port,
+		MultiAZ:          r.MultiAZ,
+		StorageEncrypted: r.StorageEncrypted,
+		KmsKeyId:         r.KmsKeyId,
+		SubnetGroupName:  r.DBSubnetGroup,
+		Members:          r.DBClusterMembers,
+	}
+	for _, sg := range r.VpcSecurityGroups {
+		c.SecurityGroups = append(c.SecurityGroups, sg.VpcSecurityGroupId)
+	}
+	if sv2 := r.ServerlessV2ScalingConfiguration; sv2 != nil {
+		c.MinACU = sv2.MinCapacity
+		c.MaxACU = sv2.MaxCapacity
+	}
+	return c
+}
+
+// resolveClusterTopology fills in each cluster's VPC from its members and
+// its global database membership from the cached global clusters.
+func resolveClusterTopology(region string, data *DatabaseData) {
+	vpcByInstance := map[string]string{}
+	for _, inst := range data.RDS {
+		vpcByInstance[inst.DBInstanceId] = inst.VpcId
+	}
+	type globalMember struct {
+		id      string
+		primary bool
+		members []string // ARNs of every cluster in the global database
+	}
+	global := map[string]globalMember{} // cluster ARN → global cluster
+	if raw, err := ReadCache(region + ":rds-global-clusters"); err == nil && raw != nil {
+		var resp struct {
+			GlobalClusters []struct {
+				GlobalClusterIdentifier string `json:"GlobalClusterIdentifier"`
+				GlobalClusterMembers    []struct {
+					DBClusterArn string `json:"DBClusterArn"`
+					IsWriter     bool   `json:"IsWriter"`
+				} `json:"GlobalClusterMembers"`
+			} `json:"GlobalClusters"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, g := range resp.GlobalClusters {
+			var arns []string
+			for _, m := range g.GlobalClusterMembers {
+				arns = append(arns, m.DBClusterArn)
+			}
+			for _, m := range g.GlobalClusterMembers {
+				global[m.DBClusterArn] = globalMember{g.GlobalClusterIdentifier, m.IsWriter, arns}
+			}
+		}
+	}
+	for i := range data.RDSClusters {
+		c := &data.RDSClusters[i]
+		for _, m := range c.Members {
+			if v := vpcByInstance[m.InstanceId]; v != "" {
+				c.VpcId = v
+				break
+			}
+		}
+		if g, ok := global[c.Arn]; ok {
+			c.GlobalClusterId = g.id
+			c.GlobalPrimary = g.primary
+			for _, arn := range g.members {
+				if r := arnRegion(arn); r != "" && r != region {
+					c.GlobalRegions = append(c.GlobalRegions, r)
+				}
+			}
+		}
+	}
+}
+
 func parseDynamoDBTable(raw json.RawMessage) DynamoDBTable {
 	var resp struct {
 		Table struct {
-			TableName      string `json:"TableName"`
-			TableStatus    string `json:"TableStatus"`
-			ItemCount      int64  `json:"ItemCount"`
-			TableSizeBytes int64  `json:"TableSizeBytes"`
+			TableName            string   `json:"TableName"`
+			TableStatus          string   `json:"TableStatus"`
+			ItemCount            int64    `json:"ItemCount"`
+			TableSizeBytes       int64    `json:"TableSizeBytes"`
+			TableArn             string   `json:"TableArn"`
+			KeySchema            []ddbKey `json:"KeySchema"`
+			AttributeDefinitions []struct {
+				AttributeName string `json:"AttributeName"`
+				AttributeType string `json:"AttributeType"`
+			} `json:"AttributeDefinitions"`
+			ProvisionedThroughput  ddbThroughput `json:"ProvisionedThroughput"`
+			GlobalSecondaryIndexes []ddbIndex    `json:"GlobalSecondaryIndexes"`
+			LocalSecondaryIndexes  []ddbIndex    `json:"LocalSecondaryIndexes"`
+			StreamSpecification    *struct {
+				StreamEnabled  bool   `json:"StreamEnabled"`
+				StreamViewType string `json:"StreamViewType"`
+			} `json:"StreamSpecification"`
+			LatestStreamArn           string `json:"LatestStreamArn"`
+			DeletionProtectionEnabled bool   `json:"DeletionProtectionEnabled"`
+			Replicas                  []struct {
+				RegionName string `json:"RegionName"`
+			} `json:"Replicas"`
 			BillingModeSummary *struct {
 				BillingMode string `json:"BillingMode"`
 			} `json:"BillingModeSummary"`
 			TableClassSummary *struct {
 				TableClass string `json:"TableClass"`
 			} `json:"TableClassSummary"`
+			SSEDescription *struct {
+				Status          string `json:"Status"`
+				SSEType         string `json:"SSEType"`
+				KMSMasterKeyArn string `json:"KMSMasterKeyArn"`
+			} `json:"SSEDescription"`
 		} `json:"Table"`
 	}
 	json.Unmarshal(raw, &resp)
@@ -221,14 +519,47 @@ func parseDynamoDBTable(raw json.RawMessage) DynamoDBTable {
 		class = t.TableClassSummary.TableClass
 	}
 
-	return DynamoDBTable{
-		TableName:   t.TableName,
-		Status:      t.TableStatus,
-		ItemCount:   t.ItemCount,
-		SizeBytes:   t.TableSizeBytes,
-		BillingMode: billing,
-		TableClass:  class,
+	table := DynamoDBTable{
+		TableName:          t.TableName,
+		Status:             t.TableStatus,
+		ItemCount:          t.ItemCount,
+		SizeBytes:          t.TableSizeBytes,
+		BillingMode:        billing,
+		TableClass:         class,
+		TableArn:           t.TableArn,
+		DeletionProtection: t.DeletionProtectionEnabled,
+	}
+	types := map[string]string{}
+	for _, a := range t.AttributeDefinitions {
+		types[a.AttributeName] = a.AttributeType
+	}
+	table.PartitionKey, table.SortKey = ddbKeys(t.KeySchema, types)
+	if billing == "PROVISIONED" {
+		table.ReadCapacity = t.ProvisionedThroughput.ReadCapacityUnits
+		table.WriteCapacity = t.ProvisionedThroughput.WriteCapacityUnits
+	}
+	for _, idx := range t.GlobalSecondaryIndexes {
+		table.Indexes = append(table.Indexes, idx.parse("GSI", types))
+	}
+	for _, idx := range t.LocalSecondaryIndexes {
+		table.Indexes = append(table.Indexes, idx.parse("LSI", types))
+	}
+	if t.StreamSpecification != nil && t.StreamSpecification.StreamEnabled {
+		table.StreamViewType = t.StreamSpecification.StreamViewType
+		table.StreamArn = t.LatestStreamArn
+	}
+	// Global tables list every replica; the ARN gives the table's own region.
+	for _, r := range t.Replicas {
+		if r.RegionName != arnRegion(t.TableArn) {
+			table.ReplicaRegions = append(table.ReplicaRegions, r.RegionName)
+		}
+	}
+	// Tables are always encrypted; SSEDescription only appears for KMS keys.
+	if t.SSEDescription != nil && t.SSEDescription.Status == "ENABLED" {
+		table.SSEType = t.SSEDescription.SSEType
+		table.KmsKeyId = t.SSEDescription.KMSMasterKeyArn
 	}
+	return table
 }
 
 func parseElastiCache(raw json.RawMessage, region string) ElastiCacheCluster {
@@ -2,15 +2,25 @@ package sync
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/estrados/simply-aws/internal/awscli"
 )
 
 type DataWarehouseData struct {
-	Redshift []RedshiftCluster `json:"redshift"`
-	Athena   []AthenaWorkgroup `json:"athena"`
-	Glue     []GlueDatabase    `json:"glue"`
+	Redshift   []RedshiftCluster  `json:"redshift"`
+	Athena     []AthenaWorkgroup  `json:"athena"`
+	Glue       []GlueDatabase     `json:"glue"`
+	OpenSearch []OpenSearchDomain `json:"opensearch"`
+
+	GlueCrawlers   []GlueCrawler   `json:"glueCrawlers"`
+	GlueJobs       []GlueJob       `json:"glueJobs"`
+	AthenaCatalogs []AthenaCatalog `json:"athenaCatalogs"`
+
+	RedshiftNamespaces []RedshiftNamespace `json:"redshiftNamespaces"`
+	RedshiftWorkgroups []RedshiftWorkgroup `json:"redshiftWorkgroups"`
 }
 
 type RedshiftCluster struct {
@@ -24,8 +34,10 @@ type RedshiftCluster struct {
 	VpcId              string              `json:"VpcId"`
 	SubnetGroupName    string              `json:"SubnetGroupName"`
 	Encrypted          bool                `json:"Encrypted"`
+	KmsKeyId           string              `json:"KmsKeyId"`
 	PubliclyAccessible bool                `json:"PubliclyAccessible"`
 	SecurityGroups     []RedshiftSG        `json:"SecurityGroups"`
+	Health             *RedshiftHealth     `json:"Health"` // set when metrics were synced
 }
 
 type RedshiftSG struct {
@@ -33,12 +45,118 @@ type RedshiftSG struct {
 	Status  string `json:"Status"`
 }
 
+// RedshiftNamespace is a Redshift Serverless namespace: the databases,
+// users and encryption settings that a workgroup's compute runs against.
+type RedshiftNamespace struct {
+	NamespaceName string   `json:"namespaceName"`
+	NamespaceId   string   `json:"namespaceId"`
+	Status        string   `json:"status"`
+	DbName        string   `json:"dbName"`
+	AdminUsername string   `json:"adminUsername"`
+	KmsKeyId      string   `json:"kmsKeyId"`
+	IamRoles      []string `json:"iamRoles"`
+	CreationDate  string   `json:"creationDate"`
+}
+
+// RedshiftWorkgroup is the compute side of Redshift Serverless. Capacity
+// is in Redshift Processing Units (RPUs); MaxCapacity is 0 when unset.
+type RedshiftWorkgroup struct {
+	WorkgroupName      string   `json:"WorkgroupName"`
+	NamespaceName      string   `json:"NamespaceName"`
+	Status             string   `json:"Status"`
+	BaseCapacity       int      `json:"BaseCapacity"`
+	MaxCapacity        int      `json:"MaxCapacity"`
+	Endpoint           string   `json:"Endpoint"`
+	Port               int      `json:"Port"`
+	VpcId              string   `json:"VpcId"`
+	SubnetIds          []string `json:"SubnetIds"`
+	SecurityGroups     []string `json:"SecurityGroups"`
+	PubliclyAccessible bool     `json:"PubliclyAccessible"`
+	EnhancedVpcRouting bool     `json:"EnhancedVpcRouting"`
+
+	Namespace *RedshiftNamespace `json:"-"` // resolved at load
+}
+
+// Capacity describes the workgroup's RPU range.
+func (w RedshiftWorkgroup) Capacity() string {
+	if w.MaxCapacity > 0 {
+		return fmt.Sprintf("%d–%d RPU", w.BaseCapacity, w.MaxCapacity)
+	}
+	return fmt.Sprintf("%d RPU", w.BaseCapacity)
+}
+
+type OpenSearchDomain struct {
+	DomainName          string   `json:"DomainName"`
+	Arn                 string   `json:"ARN"`
+	EngineVersion       string   `json:"EngineVersion"` // e.g. OpenSearch_2.11, Elasticsearch_7.10
+	Status              string   `json:"Status"`        // active, processing, deleted
+	InstanceType        string   `json:"InstanceType"`
+	InstanceCount       int      `json:"InstanceCount"`
+	MasterType          string   `json:"MasterType"`
+	MasterCount         int      `json:"MasterCount"`
+	WarmType            string   `json:"WarmType"`
+	WarmCount           int      `json:"WarmCount"`
+	ZoneAwareness       bool     `json:"ZoneAwareness"`
+	Endpoint            string   `json:"Endpoint"`
+	VpcId               string   `json:"VpcId"`
+	SubnetIds           []string `json:"SubnetIds"`
+	SecurityGroups      []string `json:"SecurityGroups"`
+	PubliclyAccessible  bool     `json:"PubliclyAccessible"` // internet endpoint instead of VPC
+	EncryptedAtRest     bool     `json:"EncryptedAtRest"`
+	KmsKeyId            string   `json:"KmsKeyId"`
+	NodeToNodeEncrypted bool     `json:"NodeToNodeEncrypted"`
+	EnforceHTTPS        bool     `json:"EnforceHTTPS"`
+}
+
 type AthenaWorkgroup struct {
-	Name          string `json:"Name"`
-	State         string `json:"State"`
-	Description   string `json:"Description"`
-	EngineVersion string `json:"EngineVersion"`
-	CreationTime  string `json:"CreationTime"`
+	Name               string             `json:"Name"`
+	State              string             `json:"State"`
+	Description        string             `json:"Description"`
+	EngineVersion      string             `json:"EngineVersion"`
+	CreationTime       string             `json:"CreationTime"`
+	OutputLocation     string             `json:"OutputLocation"`     // s3:// URI for query results
+	QueriedDatabases   []string           `json:"QueriedDatabases"`   // Glue databases of recent queries
+	BytesScannedCutoff int64              `json:"BytesScannedCutoff"` // per-query data limit, 0 if none
+	NamedQueries       []AthenaNamedQuery `json:"NamedQueries"`
+	Recent             *AthenaQueryStats  `json:"Recent"` // nil when no recent queries
+}
+
+// AthenaCatalog is a data catalog queries can run against: the Glue
+// catalog, a Hive metastore or a Lambda federated connector.
+type AthenaCatalog struct {
+	Name string `json:"Name"`
+	Type string `json:"Type"` // GLUE, HIVE, LAMBDA
+}
+
+// AthenaNamedQuery is a query saved in a workgroup.
+type AthenaNamedQuery struct {
+	Name        string `json:"Name"`
+	Description string `json:"Description"`
+	Database    string `json:"Database"`
+	Query       string `json:"Query"`
+}
+
+// AthenaQueryStats summarises a workgroup's most recent query executions.
+type AthenaQueryStats struct {
+	Queries      int    `json:"Queries"`
+	Succeeded    int    `json:"Succeeded"`
+	Failed       int    `json:"Failed"`
+	BytesScanned int64  `json:"BytesScanned"`
+	BilledBytes  int64  `json:"BilledBytes"` // with the 10 MB per-query minimum
+	LargestScan  int64  `json:"LargestScan"`
+	EngineMillis int64  `json:"EngineMillis"`
+	Since        string `json:"Since"` // submission time of the oldest query counted
+}
+
+// athenaPricePerTB is the on-demand price per TB scanned in most regions.
+const athenaPricePerTB = 5.0
+
+// athenaMinBilledBytes is the minimum charge per query.
+const athenaMinBilledBytes = 10 << 20
+
+// EstimatedCost returns the list price of the queries' scans in dollars.
+func (s AthenaQueryStats) EstimatedCost() float64 {
+	return float64(s.BilledBytes) / (1 << 40) * athenaPricePerTB
 }
 
 type GlueDatabase struct {
@@ -47,9 +165,64 @@ type GlueDatabase struct {
 	LocationUri  string `json:"LocationUri"`
 	CreateTime   string `json:"CreateTime"`
 	CatalogId    string `json:"CatalogId"`
+	Tables       []GlueTable `json:"Tables"`
+}
+
+// GlueTable is a Data Catalog table. Location is the s3:// URI of its data
+// for S3-backed tables.
+type GlueTable struct {
+	Name           string `json:"Name"`
+	Location       string `json:"Location"`
+	TableType      string `json:"TableType"`      // EXTERNAL_TABLE, GOVERNED, VIRTUAL_VIEW, ...
+	Classification string `json:"Classification"` // json, parquet, csv, ... when set by a crawler
 }
 
-func SyncDataWarehouseData(region string, onStep ...func(string)) ([]SyncResult, error) {
+// GlueCrawler populates tables in DatabaseName from its S3 targets.
+type GlueCrawler struct {
+	Name          string   `json:"Name"`
+	State         string   `json:"State"` // READY, RUNNING, STOPPING
+	DatabaseName  string   `json:"DatabaseName"`
+	Role          string   `json:"Role"`
+	Schedule      string   `json:"Schedule"` // cron expression, empty when run on demand
+	ScheduleState string   `json:"ScheduleState"`
+	S3Targets     []string `json:"S3Targets"`
+	LastStatus    string   `json:"LastStatus"` // SUCCEEDED, CANCELLED, FAILED
+	LastRun       string   `json:"LastRun"`
+	LastError     string   `json:"LastError"`
+}
+
+// GlueJob is an ETL job with its most recent run.
+type GlueJob struct {
+	Name            string  `json:"Name"`
+	Type            string  `json:"Type"` // glueetl, gluestreaming, pythonshell, glueray
+	GlueVersion     string  `json:"GlueVersion"`
+	WorkerType      string  `json:"WorkerType"`
+	NumberOfWorkers int     `json:"NumberOfWorkers"`
+	MaxCapacity     float64 `json:"MaxCapacity"` // DPUs, for Python shell jobs and jobs without a worker type
+	Role            string  `json:"Role"`
+	ScriptLocation  string  `json:"ScriptLocation"`
+	LastRunState    string  `json:"LastRunState"` // SUCCEEDED, FAILED, RUNNING, TIMEOUT, ...
+	LastRun         string  `json:"LastRun"`
+	LastRunSeconds  int     `json:"LastRunSeconds"`
+	LastError       string  `json:"LastError"`
+}
+
+// Capacity renders the job's workers, e.g. "10 × G.1X" or "0.0625 DPU".
+func (j GlueJob) Capacity() string {
+	if j.WorkerType != "" {
+		return fmt.Sprintf("%d × %s", j.NumberOfWorkers, j.WorkerType)
+	}
+	if j.MaxCapacity > 0 {
+		return fmt.Sprintf("%g DPU", j.MaxCapacity)
+	}
+	return ""
+}
+
+// athenaRecentQueries is how many recent query executions per workgroup
+// are checked for the databases they ran against.
+const athenaRecentQueries = 50
+
+func SyncDataWarehouseData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -59,19 +232,76 @@ func SyncDataWarehouseData(region string, onStep ...func(string)) ([]SyncResult,
 
 	// Also sync security groups so SG detail links work from this tab
 	if data, err := awscli.Run("ec2", "describe-security-groups", "--region", region); err == nil {
-		WriteCache(region+":security-groups", data)
+		st.WriteCache(region+":security-groups", data)
 	}
 	step("security groups")
 
 	// Redshift
 	if data, err := awscli.Run("redshift", "describe-clusters", "--region", region); err == nil {
-		WriteCache(region+":redshift", data)
+		st.WriteCache(region+":redshift", data)
 		results = append(results, SyncResult{Service: "redshift", Count: countKey(data, "Clusters")})
+		if MetricsEnabled() {
+			var resp struct {
+				Clusters []json.RawMessage `json:"Clusters"`
+			}
+			json.Unmarshal(data, &resp)
+			var clusters []RedshiftCluster
+			for _, c := range resp.Clusters {
+				clusters = append(clusters, parseRedshiftCluster(c))
+			}
+			if len(clusters) > 0 {
+				syncRedshiftHealth(st, region, clusters)
+				step("redshift health")
+			}
+		}
 	} else {
 		results = append(results, SyncResult{Service: "redshift", Error: err.Error()})
 	}
 	step("redshift")
 
+	// Redshift Serverless
+	if data, err := awscli.Run("redshift-serverless", "list-namespaces", "--region", region); err == nil {
+		st.WriteCache(region+":redshift-serverless-namespaces", data)
+		results = append(results, SyncResult{Service: "redshift-serverless", Count: countKey(data, "namespaces")})
+		if wData, err := awscli.Run("redshift-serverless", "list-workgroups", "--region", region); err == nil {
+			st.WriteCache(region+":redshift-serverless-workgroups", wData)
+		}
+	} else {
+		results = append(results, SyncResult{Service: "redshift-serverless", Error: err.Error()})
+	}
+	step("redshift serverless")
+
+	// OpenSearch - list names then describe in batches of 5 (API limit)
+	if data, err := awscli.Run("opensearch", "list-domain-names", "--region", region); err == nil {
+		var resp struct {
+			DomainNames []struct {
+				DomainName string `json:"DomainName"`
+			} `json:"DomainNames"`
+		}
+		json.Unmarshal(data, &resp)
+
+		var domains []json.RawMessage
+		for i := 0; i < len(resp.DomainNames); i += 5 {
+			args := []string{"opensearch", "describe-domains", "--region", region, "--domain-names"}
+			for _, d := range resp.DomainNames[i:min(i+5, len(resp.DomainNames))] {
+				args = append(args, d.DomainName)
+			}
+			if dData, err := awscli.Run(args...); err == nil {
+				var dResp struct {
+					DomainStatusList []json.RawMessage `json:"DomainStatusList"`
+				}
+				json.Unmarshal(dData, &dResp)
+				domains = append(domains, dResp.DomainStatusList...)
+			}
+		}
+		raw, _ := json.Marshal(map[string][]json.RawMessage{"DomainStatusList": domains})
+		st.WriteCache(region+":opensearch", raw)
+		results = append(results, SyncResult{Service: "opensearch", Count: len(domains)})
+	} else {
+		results = append(results, SyncResult{Service: "opensearch", Error: err.Error()})
+	}
+	step("opensearch")
+
 	// Athena - list workgroups then get details
 	if data, err := awscli.Run("athena", "list-work-groups", "--region", region); err == nil {
 		var resp struct {
@@ -80,17 +310,56 @@ func SyncDataWarehouseData(region string, onStep ...func(string)) ([]SyncResult,
 		json.Unmarshal(data, &resp)
 
 		var workgroups []AthenaWorkgroup
-		for _, wg := range resp.WorkGroups {
-			workgroups = append(workgroups, parseAthenaWorkgroup(wg))
+		for _, raw := range resp.WorkGroups {
+			wg := parseAthenaWorkgroup(raw)
+			if gData, err := awscli.Run("athena", "get-work-group", "--work-group", wg.Name, "--region", region); err == nil {
+				var gResp struct {
+					WorkGroup struct {
+						Configuration struct {
+							ResultConfiguration struct {
+								OutputLocation string `json:"OutputLocation"`
+							} `json:"ResultConfiguration"`
+							BytesScannedCutoffPerQuery int64 `json:"BytesScannedCutoffPerQuery"`
+						} `json:"Configuration"`
+					} `json:"WorkGroup"`
+				}
+				json.Unmarshal(gData, &gResp)
+				wg.OutputLocation = gResp.WorkGroup.Configuration.ResultConfiguration.OutputLocation
+				wg.BytesScannedCutoff = gResp.WorkGroup.Configuration.BytesScannedCutoffPerQuery
+			}
+			wg.QueriedDatabases, wg.Recent = athenaRecentQueryStats(region, wg.Name)
+			wg.NamedQueries = athenaNamedQueries(region, wg.Name)
+			workgroups = append(workgroups, wg)
 		}
 		wgJSON, _ := json.Marshal(workgroups)
-		WriteCache(region+":athena", wgJSON)
+		st.WriteCache(region+":athena", wgJSON)
 		results = append(results, SyncResult{Service: "athena", Count: len(workgroups)})
 	} else {
 		results = append(results, SyncResult{Service: "athena", Error: err.Error()})
 	}
 	step("athena")
 
+	// Athena data catalogs
+	if data, err := awscli.Run("athena", "list-data-catalogs", "--region", region); err == nil {
+		var resp struct {
+			DataCatalogsSummary []struct {
+				CatalogName string `json:"CatalogName"`
+				Type        string `json:"Type"`
+			} `json:"DataCatalogsSummary"`
+		}
+		json.Unmarshal(data, &resp)
+		var catalogs []AthenaCatalog
+		for _, c := range resp.DataCatalogsSummary {
+			catalogs = append(catalogs, AthenaCatalog{Name: c.CatalogName, Type: c.Type})
+		}
+		b, _ := json.Marshal(catalogs)
+		st.WriteCache(region+":athena-catalogs", b)
+		results = append(results, SyncResult{Service: "athena-catalogs", Count: len(catalogs)})
+	} else {
+		results = append(results, SyncResult{Service: "athena-catalogs", Error: err.Error()})
+	}
+	step("athena catalogs")
+
 	// Glue databases
 	if data, err := awscli.Run("glue", "get-databases", "--region", region); err == nil {
 		var resp struct {
@@ -99,20 +368,286 @@ func SyncDataWarehouseData(region string, onStep ...func(string)) ([]SyncResult,
 		json.Unmarshal(data, &resp)
 
 		var databases []GlueDatabase
-		for _, db := range resp.DatabaseList {
-			databases = append(databases, parseGlueDatabase(db))
+		for _, raw := range resp.DatabaseList {
+			db := parseGlueDatabase(raw)
+			db.Tables = syncGlueTables(region, db.Name)
+			databases = append(databases, db)
 		}
 		dbJSON, _ := json.Marshal(databases)
-		WriteCache(region+":glue", dbJSON)
+		st.WriteCache(region+":glue", dbJSON)
 		results = append(results, SyncResult{Service: "glue", Count: len(databases)})
 	} else {
 		results = append(results, SyncResult{Service: "glue", Error: err.Error()})
 	}
 	step("glue")
 
+	// Glue crawlers and jobs
+	if data, err := awscli.Run("glue", "get-crawlers", "--region", region); err == nil {
+		crawlers := parseGlueCrawlers(data)
+		b, _ := json.Marshal(crawlers)
+		st.WriteCache(region+":glue-crawlers", b)
+		results = append(results, SyncResult{Service: "glue-crawlers", Count: len(crawlers)})
+	} else {
+		results = append(results, SyncResult{Service: "glue-crawlers", Error: err.Error()})
+	}
+	step("glue crawlers")
+
+	if data, err := awscli.Run("glue", "get-jobs", "--region", region); err == nil {
+		jobs := parseGlueJobs(data)
+		for i := range jobs {
+			syncGlueJobLastRun(region, &jobs[i])
+		}
+		b, _ := json.Marshal(jobs)
+		st.WriteCache(region+":glue-jobs", b)
+		results = append(results, SyncResult{Service: "glue-jobs", Count: len(jobs)})
+	} else {
+		results = append(results, SyncResult{Service: "glue-jobs", Error: err.Error()})
+	}
+	step("glue jobs")
+
 	return results, nil
 }
 
+// glueTime formats a Glue timestamp as "2006-01-02 15:04", or returns it
+// unchanged if it does not parse.
+func glueTime(s string) string {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.Format("2006-01-02 15:04")
+	}
+	return s
+}
+
+func parseGlueCrawlers(data []byte) []GlueCrawler {
+	var resp struct {
+		Crawlers []struct {
+			Name         string `json:"Name"`
+			State        string `json:"State"`
+			DatabaseName string `json:"DatabaseName"`
+			Role         string `json:"Role"`
+			Schedule     *struct {
+				ScheduleExpression string `json:"ScheduleExpression"`
+				State              string `json:"State"`
+			} `json:"Schedule"`
+			Targets struct {
+				S3Targets []struct {
+					Path string `json:"Path"`
+				} `json:"S3Targets"`
+			} `json:"Targets"`
+			LastCrawl *struct {
+				Status       string `json:"Status"`
+				StartTime    string `json:"StartTime"`
+				ErrorMessage string `json:"ErrorMessage"`
+			} `json:"LastCrawl"`
+		} `json:"Crawlers"`
+	}
+	json.Unmarshal(data, &resp)
+	var out []GlueCrawler
+	for _, c := range resp.Crawlers {
+		crawler := GlueCrawler{Name: c.Name, State: c.State, DatabaseName: c.DatabaseName, Role: c.Role}
+		if c.Schedule != nil {
+			crawler.Schedule, crawler.ScheduleState = c.Schedule.ScheduleExpression, c.Schedule.State
+		}
+		for _, t := range c.Targets.S3Targets {
+			crawler.S3Targets = append(crawler.S3Targets, t.Path)
+		}
+		if c.LastCrawl != nil {
+			crawler.LastStatus = c.LastCrawl.Status
+			crawler.LastRun = glueTime(c.LastCrawl.StartTime)
+			crawler.LastError = c.LastCrawl.ErrorMessage
+		}
+		out = append(out, crawler)
+	}
+	return out
+}
+
+func parseGlueJobs(data []byte) []GlueJob {
+	var resp struct {
+		Jobs []struct {
+			Name    string `json:"Name"`
+			Role    string `json:"Role"`
+			Command struct {
+				Name           string `json:"Name"`
+				ScriptLocation string `json:"ScriptLocation"`
+			} `json:"Command"`
+			GlueVersion     string  `json:"GlueVersion"`
+			WorkerType      string  `json:"WorkerType"`
+			NumberOfWorkers int     `json:"NumberOfWorkers"`
+			MaxCapacity     float64 `json:"MaxCapacity"`
+		} `json:"Jobs"`
+	}
+	json.Unmarshal(data, &resp)
+	var out []GlueJob
+	for _, j := range resp.Jobs {
+		out = append(out, GlueJob{
+			Name:            j.Name,
+			Type:            j.Command.Name,
+			GlueVersion:     j.GlueVersion,
+			WorkerType:      j.WorkerType,
+			NumberOfWorkers: j.NumberOfWorkers,
+			MaxCapacity:     j.MaxCapacity,
+			Role:            j.Role,
+			ScriptLocation:  j.Command.ScriptLocation,
+		})
+	}
+	return out
+}
+
+// syncGlueJobLastRun fills in the state of the job's most recent run.
+func syncGlueJobLastRun(region string, job *GlueJob) {
+	data, err := awscli.Run("glue", "get-job-runs", "--job-name", job.Name, "--max-results", "1", "--region", region)
+	if err != nil {
+		return
+	}
+	var resp struct {
+		JobRuns []struct {
+			JobRunState   string `json:"JobRunState"`
+			StartedOn     string `json:"StartedOn"`
+			ExecutionTime int    `json:"ExecutionTime"`
+			ErrorMessage  string `json:"ErrorMessage"`
+		} `json:"JobRuns"`
+	}
+	json.Unmarshal(data, &resp)
+	if len(resp.JobRuns) == 0 {
+		return
+	}
+	run := resp.JobRuns[0]
+	job.LastRunState = run.JobRunState
+	job.LastRun = glueTime(run.StartedOn)
+	job.LastRunSeconds = run.ExecutionTime
+	job.LastError = run.ErrorMessage
+}
+
+// athenaRecentQueryStats returns the distinct databases the workgroup's
+// most recent query executions ran against, and their scan statistics.
+func athenaRecentQueryStats(region, workgroup string) ([]string, *AthenaQueryStats) {
+	data, err := awscli.Run("athena", "list-query-executions", "--work-group", workgroup,
+		"--max-items", fmt.Sprint(athenaRecentQueries), "--region", region)
+	if err != nil {
+		return nil, nil
+	}
+	var resp struct {
+		QueryExecutionIds []string `json:"QueryExecutionIds"`
+	}
+	json.Unmarshal(data, &resp)
+	if len(resp.QueryExecutionIds) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"athena", "batch-get-query-execution", "--region", region, "--query-execution-ids"}, resp.QueryExecutionIds...)
+	qData, err := awscli.Run(args...)
+	if err != nil {
+		return nil, nil
+	}
+	var qResp struct {
+		QueryExecutions []struct {
+			QueryExecutionContext struct {
+				Database string `json:"Database"`
+			} `json:"QueryExecutionContext"`
+			Status struct {
+				State              string `json:"State"`
+				SubmissionDateTime string `json:"SubmissionDateTime"`
+			} `json:"Status"`
+			Statistics struct {
+				DataScannedInBytes          int64 `json:"DataScannedInBytes"`
+				EngineExecutionTimeInMillis int64 `json:"EngineExecutionTimeInMillis"`
+			} `json:"Statistics"`
+		} `json:"QueryExecutions"`
+	}
+	json.Unmarshal(qData, &qResp)
+	seen := map[string]bool{}
+	var dbs []string
+	stats := &AthenaQueryStats{}
+	for _, q := range qResp.QueryExecutions {
+		if db := q.QueryExecutionContext.Database; db != "" && !seen[db] {
+			seen[db] = true
+			dbs = append(dbs, db)
+		}
+		stats.Queries++
+		switch q.Status.State {
+		case "SUCCEEDED":
+			stats.Succeeded++
+		case "FAILED":
+			stats.Failed++
+		}
+		scanned := q.Statistics.DataScannedInBytes
+		stats.BytesScanned += scanned
+		stats.EngineMillis += q.Statistics.EngineExecutionTimeInMillis
+		stats.LargestScan = max(stats.LargestScan, scanned)
+		// Failed queries are not charged; the rest pay at least the minimum.
+		if q.Status.State != "FAILED" {
+			stats.BilledBytes += max(scanned, athenaMinBilledBytes)
+		}
+		if at := glueTime(q.Status.SubmissionDateTime); stats.Since == "" || at < stats.Since {
+			stats.Since = at
+		}
+	}
+	sort.Strings(dbs)
+	return dbs, stats
+}
+
+// athenaNamedQueries returns the queries saved in a workgroup.
+func athenaNamedQueries(region, workgroup string) []AthenaNamedQuery {
+	data, err := awscli.Run("athena", "list-named-queries", "--work-group", workgroup, "--region", region)
+	if err != nil {
+		return nil
+	}
+	var resp struct {
+		NamedQueryIds []string `json:"NamedQueryIds"`
+	}
+	json.Unmarshal(data, &resp)
+	var out []AthenaNamedQuery
+	// batch-get-named-query takes up to 50 IDs
+	for i := 0; i < len(resp.NamedQueryIds); i += 50 {
+		args := append([]string{"athena", "batch-get-named-query", "--region", region, "--named-query-ids"}, resp.NamedQueryIds[i:min(i+50, len(resp.NamedQueryIds))]...)
+		qData, err := awscli.Run(args...)
+		if err != nil {
+			continue
+		}
+		var qResp struct {
+			NamedQueries []struct {
+				Name        string `json:"Name"`
+				Description string `json:"Description"`
+				Database    string `json:"Database"`
+				QueryString string `json:"QueryString"`
+			} `json:"NamedQueries"`
+		}
+		json.Unmarshal(qData, &qResp)
+		for _, q := range qResp.NamedQueries {
+			out = append(out, AthenaNamedQuery{Name: q.Name, Description: q.Description, Database: q.Database, Query: q.QueryString})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// syncGlueTables lists the tables of a Glue database.
+func syncGlueTables(region, database string) []GlueTable {
+	data, err := awscli.Run("glue", "get-tables", "--database-name", database, "--region", region)
+	if err != nil {
+		return nil
+	}
+	var resp struct {
+		TableList []struct {
+			Name              string `json:"Name"`
+			TableType         string `json:"TableType"`
+			StorageDescriptor struct {
+				Location string `json:"Location"`
+			} `json:"StorageDescriptor"`
+			Parameters map[string]string `json:"Parameters"`
+		} `json:"TableList"`
+	}
+	json.Unmarshal(data, &resp)
+	var tables []GlueTable
+	for _, t := range resp.TableList {
+		tables = append(tables, GlueTable{
+			Name:           t.Name,
+			Location:       t.StorageDescriptor.Location,
+			TableType:      t.TableType,
+			Classification: t.Parameters["classification"],
+		})
+	}
+	return tables
+}
+
 func LoadDataWarehouseData(region string) (*DataWarehouseData, error) {
 	data := &DataWarehouseData{}
 
@@ -122,8 +657,47 @@ func LoadDataWarehouseData(region string) (*DataWarehouseData, error) {
 			Clusters []json.RawMessage `json:"Clusters"`
 		}
 		json.Unmarshal(raw, &resp)
+		health := loadRedshiftHealth(region)
 		for _, c := range resp.Clusters {
-			data.Redshift = append(data.Redshift, parseRedshiftCluster(c))
+			cluster := parseRedshiftCluster(c)
+			cluster.Health = health[cluster.ClusterIdentifier]
+			data.Redshift = append(data.Redshift, cluster)
+		}
+	}
+
+	// Redshift Serverless
+	if raw, err := ReadCache(region + ":redshift-serverless-namespaces"); err == nil && raw != nil {
+		var resp struct {
+			Namespaces []RedshiftNamespace `json:"namespaces"`
+		}
+		json.Unmarshal(raw, &resp)
+		data.RedshiftNamespaces = resp.Namespaces
+	}
+	if raw, err := ReadCache(region + ":redshift-serverless-workgroups"); err == nil && raw != nil {
+		var resp struct {
+			Workgroups []json.RawMessage `json:"workgroups"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, w := range resp.Workgroups {
+			wg := parseRedshiftWorkgroup(w)
+			for i := range data.RedshiftNamespaces {
+				if data.RedshiftNamespaces[i].NamespaceName == wg.NamespaceName {
+					wg.Namespace = &data.RedshiftNamespaces[i]
+				}
+			}
+			data.RedshiftWorkgroups = append(data.RedshiftWorkgroups, wg)
+		}
+		resolveWorkgroupVPCs(region, data.RedshiftWorkgroups)
+	}
+
+	// OpenSearch
+	if raw, err := ReadCache(region + ":opensearch"); err == nil && raw != nil {
+		var resp struct {
+			DomainStatusList []json.RawMessage `json:"DomainStatusList"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, d := range resp.DomainStatusList {
+			data.OpenSearch = append(data.OpenSearch, parseOpenSearchDomain(d))
 		}
 	}
 
@@ -136,6 +710,15 @@ func LoadDataWarehouseData(region string) (*DataWarehouseData, error) {
 	if raw, err := ReadCache(region + ":glue"); err == nil && raw != nil {
 		json.Unmarshal(raw, &data.Glue)
 	}
+	if raw, err := ReadCache(region + ":athena-catalogs"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.AthenaCatalogs)
+	}
+	if raw, err := ReadCache(region + ":glue-crawlers"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.GlueCrawlers)
+	}
+	if raw, err := ReadCache(region + ":glue-jobs"); err == nil && raw != nil {
+		json.Unmarshal(raw, &data.GlueJobs)
+	}
 
 	return data, nil
 }
@@ -148,6 +731,7 @@ func parseRedshiftCluster(raw json.RawMessage) RedshiftCluster {
 		ClusterStatus      string `json:"ClusterStatus"`
 		DBName             string `json:"DBName"`
 		Encrypted          bool   `json:"Encrypted"`
+		KmsKeyId           string `json:"KmsKeyId"`
 		PubliclyAccessible bool   `json:"PubliclyAccessible"`
 		Endpoint           *struct {
 			Address string `json:"Address"`
@@ -166,6 +750,7 @@ func parseRedshiftCluster(raw json.RawMessage) RedshiftCluster {
 		Status:             r.ClusterStatus,
 		DBName:             r.DBName,
 		Encrypted:          r.Encrypted,
+		KmsKeyId:           r.KmsKeyId,
 		PubliclyAccessible: r.PubliclyAccessible,
 		VpcId:              r.VpcId,
 		SubnetGroupName:    r.ClusterSubnetGroupName,
@@ -178,6 +763,152 @@ func parseRedshiftCluster(raw json.RawMessage) RedshiftCluster {
 	return c
 }
 
+func parseRedshiftWorkgroup(raw json.RawMessage) RedshiftWorkgroup {
+	var w struct {
+		WorkgroupName      string   `json:"workgroupName"`
+		NamespaceName      string   `json:"namespaceName"`
+		Status             string   `json:"status"`
+		BaseCapacity       int      `json:"baseCapacity"`
+		MaxCapacity        int      `json:"maxCapacity"`
+		SubnetIds          []string `json:"subnetIds"`
+		SecurityGroupIds   []string `json:"securityGroupIds"`
+		PubliclyAccessible bool     `json:"publiclyAccessible"`
+		EnhancedVpcRouting bool     `json:"enhancedVpcRouting"`
+		Endpoint           *struct {
+			Address      string `json:"address"`
+			Port         int    `json:"port"`
+			VpcEndpoints []struct {
+				VpcId string `json:"vpcId"`
+			} `json:"vpcEndpoints"`
+		} `json:"endpoint"`
+	}
+	json.Unmarshal(raw, &w)
+
+	wg := RedshiftWorkgroup{
+		WorkgroupName:      w.WorkgroupName,
+		NamespaceName:      w.NamespaceName,
+		Status:             w.Status,
+		BaseCapacity:       w.BaseCapacity,
+		MaxCapacity:        w.MaxCapacity,
+		SubnetIds:          w.SubnetIds,
+		SecurityGroups:     w.SecurityGroupIds,
+		PubliclyAccessible: w.PubliclyAccessible,
+		EnhancedVpcRouting: w.EnhancedVpcRouting,
+	}
+	if w.Endpoint != nil {
+		wg.Endpoint = w.Endpoint.Address
+		wg.Port = w.Endpoint.Port
+		if len(w.Endpoint.VpcEndpoints) > 0 {
+			wg.VpcId = w.Endpoint.VpcEndpoints[0].VpcId
+		}
+	}
+	return wg
+}
+
+// resolveWorkgroupVPCs fills in the VPC of workgroups whose endpoint
+// didn't report one from the cached subnets they run in.
+func resolveWorkgroupVPCs(region string, workgroups []RedshiftWorkgroup) {
+	var vpc *VPCData
+	for i := range workgroups {
+		w := &workgroups[i]
+		if w.VpcId != "" || len(w.SubnetIds) == 0 {
+			continue
+		}
+		if vpc == nil {
+			if vpc, _ = LoadVPCData(region); vpc == nil {
+				return
+			}
+		}
+		for _, s := range vpc.Subnets {
+			if s.SubnetId == w.SubnetIds[0] {
+				w.VpcId = s.VpcId
+				break
+			}
+		}
+	}
+}
+
+func parseOpenSearchDomain(raw json.RawMessage) OpenSearchDomain {
+	var d struct {
+		DomainName    string            `json:"DomainName"`
+		ARN           string            `json:"ARN"`
+		EngineVersion string            `json:"EngineVersion"`
+		Processing    bool              `json:"Processing"`
+		Deleted       bool              `json:"Deleted"`
+		Endpoint      string            `json:"Endpoint"`
+		Endpoints     map[string]string `json:"Endpoints"`
+		ClusterConfig struct {
+			InstanceType           string `json:"InstanceType"`
+			InstanceCount          int    `json:"InstanceCount"`
+			DedicatedMasterEnabled bool   `json:"DedicatedMasterEnabled"`
+			DedicatedMasterType    string `json:"DedicatedMasterType"`
+			DedicatedMasterCount   int    `json:"DedicatedMasterCount"`
+			WarmEnabled            bool   `json:"WarmEnabled"`
+			WarmType               string `json:"WarmType"`
+			WarmCount              int    `json:"WarmCount"`
+			ZoneAwarenessEnabled   bool   `json:"ZoneAwarenessEnabled"`
+		} `json:"ClusterConfig"`
+		VPCOptions *struct {
+			VPCId            string   `json:"VPCId"`
+			SubnetIds        []string `json:"SubnetIds"`
+			SecurityGroupIds []string `json:"SecurityGroupIds"`
+		} `json:"VPCOptions"`
+		EncryptionAtRestOptions struct {
+			Enabled  bool   `json:"Enabled"`
+			KmsKeyId string `json:"KmsKeyId"`
+		} `json:"EncryptionAtRestOptions"`
+		NodeToNodeEncryptionOptions struct {
+			Enabled bool `json:"Enabled"`
+		} `json:"NodeToNodeEncryptionOptions"`
+		DomainEndpointOptions struct {
+			EnforceHTTPS bool `json:"EnforceHTTPS"`
+		} `json:"DomainEndpointOptions"`
+	}
+	json.Unmarshal(raw, &d)
+
+	cc := d.ClusterConfig
+	o := OpenSearchDomain{
+		DomainName:          d.DomainName,
+		Arn:                 d.ARN,
+		EngineVersion:       d.EngineVersion,
+		Status:              "active",
+		InstanceType:        cc.InstanceType,
+		InstanceCount:       cc.InstanceCount,
+		ZoneAwareness:       cc.ZoneAwarenessEnabled,
+		Endpoint:            d.Endpoint,
+		EncryptedAtRest:     d.EncryptionAtRestOptions.Enabled,
+		KmsKeyId:            d.EncryptionAtRestOptions.KmsKeyId,
+		NodeToNodeEncrypted: d.NodeToNodeEncryptionOptions.Enabled,
+		EnforceHTTPS:        d.DomainEndpointOptions.EnforceHTTPS,
+	}
+	if d.Deleted {
+		o.Status = "deleted"
+	} else if d.Processing {
+		o.Status = "processing"
+	}
+	if cc.DedicatedMasterEnabled {
+		o.MasterType = cc.DedicatedMasterType
+		o.MasterCount = cc.DedicatedMasterCount
+	}
+	if cc.WarmEnabled {
+		o.WarmType = cc.WarmType
+		o.WarmCount = cc.WarmCount
+	}
+	// Domains without VPCOptions get an internet endpoint; access is then
+	// only limited by the access policy.
+	if d.VPCOptions != nil && d.VPCOptions.VPCId != "" {
+		o.VpcId = d.VPCOptions.VPCId
+		o.SubnetIds = d.VPCOptions.SubnetIds
+		o.SecurityGroups = d.VPCOptions.SecurityGroupIds
+		if o.Endpoint == "" {
+			o.Endpoint = d.Endpoints["vpc"]
+		}
+	} else {
+		o.PubliclyAccessible = true
+	}
+	return o
+}
+
 func parseAthenaWorkgroup(raw json.RawMessage) AthenaWorkgroup {
 	var wg struct {
 		Name          string `json:"Name"`
@@ -214,16 +945,11 @@ func parseGlueDatabase(raw json.RawMessage) GlueDatabase {
 	}
 	json.Unmarshal(raw, &db)
 
-	created := db.CreateTime
-	if t, err := time.Parse(time.RFC3339Nano, db.CreateTime); err == nil {
-		created = t.Format("2006-01-02 15:04")
-	}
-
 	return GlueDatabase{
 		Name:        db.Name,
 		Description: db.Description,
 		LocationUri: db.LocationUri,
-		CreateTime:  created,
+		CreateTime:  glueTime(db.CreateTime),
 		CatalogId:   db.CatalogId,
 	}
 }
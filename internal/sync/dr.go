@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DRStatus is one data store and how it survives losing its region.
+// Copies are cross-region (replicas, global tables, backup copies);
+// SameRegion is redundancy that would be lost with the region.
+type DRStatus struct {
+	Type       string   `json:"type"` // inventory type: s3, rds, rds-cluster, dynamodb
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Copies     []string `json:"copies"`
+	SameRegion []string `json:"sameRegion"`
+}
+
+// CrossRegion reports whether the data store has a copy in another region.
+func (s DRStatus) CrossRegion() bool {
+	return len(s.Copies) > 0
+}
+
+// DRReadiness checks every cached data store in region for cross-region
+// redundancy. Read replicas and cluster members are covered by their
+// source instance or cluster rather than listed on their own.
+func DRReadiness(region string) ([]DRStatus, error) {
+	backups := backupCopyRegions(region)
+	var out []DRStatus
+	addBackups := func(st *DRStatus, arn string) {
+		for _, r := range backups[arn] {
+			st.Copies = append(st.Copies, "backup copy → "+r)
+		}
+	}
+
+	if s3, err := LoadS3DataEnriched(); err == nil && s3 != nil {
+		bucketRegion := map[string]string{}
+		for _, b := range s3.Buckets {
+			bucketRegion[b.Name] = b.Region
+		}
+		for _, b := range s3.Buckets {
+			if b.Region != region {
+				continue
+			}
+			st := DRStatus{Type: "s3", ID: b.Name, Name: b.Name}
+			for _, dest := range b.ReplicationTargets {
+				switch r := bucketRegion[dest]; {
+				case r == "":
+					st.Copies = append(st.Copies, "replication → "+dest+" (region unknown)")
+				case r != region:
+					st.Copies = append(st.Copies, "replication → "+dest+" ("+r+")")
+				default:
+					st.SameRegion = append(st.SameRegion, "replication → "+dest)
+				}
+			}
+			addBackups(&st, "arn:aws:s3:::"+b.Name)
+			out = append(out, st)
+		}
+	}
+
+	db, err := LoadDatabaseData(region)
+	if err != nil {
+		return nil, err
+	}
+	for _, inst := range db.RDS {
+		if inst.ClusterId != "" || inst.ReplicaOf != "" {
+			continue
+		}
+		st := DRStatus{Type: "rds", ID: inst.DBInstanceId, Name: inst.DBInstanceId}
+		for _, rep := range inst.Replicas {
+			if r := arnRegion(rep); r != "" && r != region {
+				st.Copies = append(st.Copies, "read replica → "+r)
+			} else {
+				st.SameRegion = append(st.SameRegion, "read replica "+arnResourceName(rep))
+			}
+		}
+		if inst.MultiAZ {
+			st.SameRegion = append(st.SameRegion, "multi-az")
+		}
+		addBackups(&st, inst.Arn)
+		out = append(out, st)
+	}
+	for _, c := range db.RDSClusters {
+		st := DRStatus{Type: "rds-cluster", ID: c.ClusterId, Name: c.ClusterId}
+		for _, r := range c.GlobalRegions {
+			st.Copies = append(st.Copies, "global database → "+r)
+		}
+		switch n := len(c.Readers()); {
+		case n == 1:
+			st.SameRegion = append(st.SameRegion, "1 reader")
+		case n > 1:
+			st.SameRegion = append(st.SameRegion, fmt.Sprintf("%d readers", n))
+		}
+		addBackups(&st, c.Arn)
+		out = append(out, st)
+	}
+	for _, t := range db.DynamoDB {
+		st := DRStatus{Type: "dynamodb", ID: t.TableName, Name: t.TableName}
+		for _, r := range t.ReplicaRegions {
+			st.Copies = append(st.Copies, "global table → "+r)
+		}
+		addBackups(&st, t.TableArn)
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// backupCopyRegions maps resource ARNs to the other regions AWS Backup
+// has copied them to, from the cached completed copy jobs.
+func backupCopyRegions(region string) map[string][]string {
+	out := map[string][]string{}
+	raw, err := ReadCache(region + ":backup-copy-jobs")
+	if err != nil || raw == nil {
+		return out
+	}
+	var resp struct {
+		CopyJobs []struct {
+			ResourceArn               string `json:"ResourceArn"`
+			DestinationBackupVaultArn string `json:"DestinationBackupVaultArn"`
+		} `json:"CopyJobs"`
+	}
+	json.Unmarshal(raw, &resp)
+	seen := map[string]bool{}
+	for _, j := range resp.CopyJobs {
+		r := arnRegion(j.DestinationBackupVaultArn)
+		if r == "" || r == region || seen[j.ResourceArn+"|"+r] {
+			continue
+		}
+		seen[j.ResourceArn+"|"+r] = true
+		out[j.ResourceArn] = append(out[j.ResourceArn], r)
+	}
+	for arn := range out {
+		sort.Strings(out[arn])
+	}
+	return out
+}
+
+// arnRegion returns the region field of an ARN, or "" for plain IDs and
+// global ARNs.
+func arnRegion(arn string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) < 5 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[3]
+}
+
+// arnResourceName returns the last ":"-separated field of an ARN, or the
+// input unchanged if it is not an ARN.
+func arnResourceName(arn string) string {
+	if !strings.HasPrefix(arn, "arn:") {
+		return arn
+	}
+	return arn[strings.LastIndex(arn, ":")+1:]
+}
@@ -0,0 +1,192 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// DynamoDBIndex is a global (GSI) or local (LSI) secondary index.
+type DynamoDBIndex struct {
+	Name          string `json:"Name"`
+	Kind          string `json:"Kind"` // "GSI" or "LSI"
+	PartitionKey  string `json:"PartitionKey"`
+	SortKey       string `json:"SortKey"`
+	Projection    string `json:"Projection"` // ALL, KEYS_ONLY, or INCLUDE with the attributes
+	Status        string `json:"Status"`     // GSI only
+	ReadCapacity  int64  `json:"ReadCapacity"`
+	WriteCapacity int64  `json:"WriteCapacity"`
+	ItemCount     int64  `json:"ItemCount"`
+	SizeBytes     int64  `json:"SizeBytes"`
+}
+
+// DynamoDBScaling is an Application Auto Scaling target on a table's or a
+// GSI's read or write capacity.
+type DynamoDBScaling struct {
+	Index       string  `json:"Index"`     // "" for the table itself
+	Dimension   string  `json:"Dimension"` // "read" or "write"
+	Min         int     `json:"Min"`
+	Max         int     `json:"Max"`
+	TargetValue float64 `json:"TargetValue"` // target utilization %, 0 without a target tracking policy
+}
+
+type ddbKey struct {
+	AttributeName string `json:"AttributeName"`
+	KeyType       string `json:"KeyType"` // HASH or RANGE
+}
+
+type ddbThroughput struct {
+	ReadCapacityUnits  int64 `json:"ReadCapacityUnits"`
+	WriteCapacityUnits int64 `json:"WriteCapacityUnits"`
+}
+
+type ddbIndex struct {
+	IndexName  string   `json:"IndexName"`
+	KeySchema  []ddbKey `json:"KeySchema"`
+	Projection struct {
+		ProjectionType   string   `json:"ProjectionType"`
+		NonKeyAttributes []string `json:"NonKeyAttributes"`
+	} `json:"Projection"`
+	IndexStatus           string        `json:"IndexStatus"`
+	ProvisionedThroughput ddbThroughput `json:"ProvisionedThroughput"`
+	ItemCount             int64         `json:"ItemCount"`
+	IndexSizeBytes        int64         `json:"IndexSizeBytes"`
+}
+
+func (i ddbIndex) parse(kind string, types map[string]string) DynamoDBIndex {
+	idx := DynamoDBIndex{
+		Name:          i.IndexName,
+		Kind:          kind,
+		Projection:    i.Projection.ProjectionType,
+		Status:        i.IndexStatus,
+		ReadCapacity:  i.ProvisionedThroughput.ReadCapacityUnits,
+		WriteCapacity: i.ProvisionedThroughput.WriteCapacityUnits,
+		ItemCount:     i.ItemCount,
+		SizeBytes:     i.IndexSizeBytes,
+	}
+	idx.PartitionKey, idx.SortKey = ddbKeys(i.KeySchema, types)
+	if len(i.Projection.NonKeyAttributes) > 0 {
+		idx.Projection += " (" + strings.Join(i.Projection.NonKeyAttributes, ", ") + ")"
+	}
+	return idx
+}
+
+// ddbKeys returns the partition and sort key as "name (type)".
+func ddbKeys(schema []ddbKey, types map[string]string) (partition, sort string) {
+	for _, k := range schema {
+		name := k.AttributeName
+		if t := types[name]; t != "" {
+			name += " (" + t + ")"
+		}
+		if k.KeyType == "HASH" {
+			partition = name
+		} else {
+			sort = name
+		}
+	}
+	return partition, sort
+}
+
+// ScalingFor returns the auto scaling target for the table ("") or an
+// index on the given dimension, or nil.
+func (t DynamoDBTable) ScalingFor(index, dimension string) *DynamoDBScaling {
+	for i, s := range t.Scaling {
+		if s.Index == index && s.Dimension == dimension {
+			return &t.Scaling[i]
+		}
+	}
+	return nil
+}
+
+// syncDynamoDBSettings fills in TTL and point-in-time recovery for each
+// table, and the auto scaling targets on their capacity.
+func syncDynamoDBSettings(region string, tables []DynamoDBTable) {
+	for i := range tables {
+		t := &tables[i]
+		if data, err := awscli.Run("dynamodb", "describe-time-to-live", "--table-name", t.TableName, "--region", region); err == nil {
+			var resp struct {
+				TimeToLiveDescription struct {
+					TimeToLiveStatus string `json:"TimeToLiveStatus"`
+					AttributeName    string `json:"AttributeName"`
+				} `json:"TimeToLiveDescription"`
+			}
+			json.Unmarshal(data, &resp)
+			t.TTLStatus = resp.TimeToLiveDescription.TimeToLiveStatus
+			t.TTLAttribute = resp.TimeToLiveDescription.AttributeName
+		}
+		if data, err := awscli.Run("dynamodb", "describe-continuous-backups", "--table-name", t.TableName, "--region", region); err == nil {
+			var resp struct {
+				ContinuousBackupsDescription struct {
+					PointInTimeRecoveryDescription struct {
+						PointInTimeRecoveryStatus string `json:"PointInTimeRecoveryStatus"`
+					} `json:"PointInTimeRecoveryDescription"`
+				} `json:"ContinuousBackupsDescription"`
+			}
+			json.Unmarshal(data, &resp)
+			t.PITR = resp.ContinuousBackupsDescription.PointInTimeRecoveryDescription.PointInTimeRecoveryStatus
+		}
+	}
+
+	// Scalable targets and their policies are listed once for the region.
+	data, err := awscli.Run("application-autoscaling", "describe-scalable-targets", "--service-namespace", "dynamodb", "--region", region)
+	if err != nil {
+		return
+	}
+	var targets struct {
+		ScalableTargets []struct {
+			ResourceId        string `json:"ResourceId"` // table/<name> or table/<name>/index/<index>
+			ScalableDimension string `json:"ScalableDimension"`
+			MinCapacity       int    `json:"MinCapacity"`
+			MaxCapacity       int    `json:"MaxCapacity"`
+		} `json:"ScalableTargets"`
+	}
+	json.Unmarshal(data, &targets)
+
+	targetValues := map[string]float64{} // resourceId|dimension -> target utilization
+	if data, err := awscli.Run("application-autoscaling", "describe-scaling-policies", "--service-namespace", "dynamodb", "--region", region); err == nil {
+		var policies struct {
+			ScalingPolicies []struct {
+				ResourceId                               string `json:"ResourceId"`
+				ScalableDimension                        string `json:"ScalableDimension"`
+				TargetTrackingScalingPolicyConfiguration *struct {
+					TargetValue float64 `json:"TargetValue"`
+				} `json:"TargetTrackingScalingPolicyConfiguration"`
+			} `json:"ScalingPolicies"`
+		}
+		json.Unmarshal(data, &policies)
+		for _, p := range policies.ScalingPolicies {
+			if p.TargetTrackingScalingPolicyConfiguration != nil {
+				targetValues[p.ResourceId+"|"+p.ScalableDimension] = p.TargetTrackingScalingPolicyConfiguration.TargetValue
+			}
+		}
+	}
+
+	byName := map[string]int{}
+	for i, t := range tables {
+		byName[t.TableName] = i
+	}
+	for _, st := range targets.ScalableTargets {
+		parts := strings.Split(st.ResourceId, "/")
+		if len(parts) < 2 {
+			continue
+		}
+		i, ok := byName[parts[1]]
+		if !ok {
+			continue
+		}
+		s := DynamoDBScaling{Min: st.MinCapacity, Max: st.MaxCapacity, TargetValue: targetValues[st.ResourceId+"|"+st.ScalableDimension]}
+		if len(parts) == 4 && parts[2] == "index" {
+			s.Index = parts[3]
+		}
+		switch {
+		case strings.HasSuffix(st.ScalableDimension, "ReadCapacityUnits"):
+			s.Dimension = "read"
+		case strings.HasSuffix(st.ScalableDimension, "WriteCapacityUnits"):
+			s.Dimension = "write"
+		default:
+			continue
+		}
+		tables[i].Scaling = append(tables[i].Scaling, s)
+	}
+}
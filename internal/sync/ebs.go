@@ -0,0 +1,97 @@
+package sync
+
+import "encoding/json"
+
+// EBSSnapshot is a snapshot owned by this account.
+type EBSSnapshot struct {
+	SnapshotId  string `json:"SnapshotId"`
+	Name        string `json:"Name"`
+	Description string `json:"Description"`
+	VolumeId    string `json:"VolumeId"`
+	VolumeSize  int    `json:"VolumeSize"` // GiB of the source volume
+	State       string `json:"State"`
+	StartTime   string `json:"StartTime"`
+	Encrypted   bool   `json:"Encrypted"`
+	KmsKeyId    string `json:"KmsKeyId"`
+}
+
+// LoadEBSSnapshots returns the account's cached snapshots in a region.
+func LoadEBSSnapshots(region string) ([]EBSSnapshot, error) {
+	raw, err := ReadCache(region + ":ebs-snapshots")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var resp struct {
+		Snapshots []struct {
+			EBSSnapshot
+			Tags []struct {
+				Key   string `json:"Key"`
+				Value string `json:"Value"`
+			} `json:"Tags"`
+		} `json:"Snapshots"`
+	}
+	json.Unmarshal(raw, &resp)
+	var out []EBSSnapshot
+	for _, s := range resp.Snapshots {
+		snap := s.EBSSnapshot
+		for _, t := range s.Tags {
+			if t.Key == "Name" {
+				snap.Name = t.Value
+			}
+		}
+		out = append(out, snap)
+	}
+	return out, nil
+}
+
+// EBSTotals sums the EBS volumes and snapshots cached for one region.
+// Snapshot sizes are source volume sizes; billed snapshot storage is
+// incremental and usually smaller.
+type EBSTotals struct {
+	Region        string `json:"region"`
+	Volumes       int    `json:"volumes"`
+	VolumeGiB     int    `json:"volumeGiB"`
+	Unattached    int    `json:"unattached"`
+	UnattachedGiB int    `json:"unattachedGiB"`
+	Unencrypted   int    `json:"unencrypted"`
+	Snapshots     int    `json:"snapshots"`
+	SnapshotGiB   int    `json:"snapshotGiB"`
+}
+
+// SumEBS totals the given volumes and snapshots.
+func SumEBS(region string, vols []EBSVolume, snaps []EBSSnapshot) EBSTotals {
+	t := EBSTotals{Region: region, Volumes: len(vols), Snapshots: len(snaps)}
+	for _, v := range vols {
+		t.VolumeGiB += v.Size
+		if v.Unattached() {
+			t.Unattached++
+			t.UnattachedGiB += v.Size
+		}
+		if !v.Encrypted {
+			t.Unencrypted++
+		}
+	}
+	for _, s := range snaps {
+		t.SnapshotGiB += s.VolumeSize
+	}
+	return t
+}
+
+// EBSTotalsByRegion returns totals for every region with cached volumes
+// or snapshots.
+func EBSTotalsByRegion() ([]EBSTotals, error) {
+	regions, err := CachedRegions()
+	if err != nil {
+		return nil, err
+	}
+	var out []EBSTotals
+	for _, r := range regions {
+		vols, _ := LoadEBSVolumes(r)
+		snaps, _ := LoadEBSSnapshots(r)
+		if len(vols) == 0 && len(snaps) == 0 {
+			continue
+		}
+		out = append(out, SumEBS(r, vols, snaps))
+	}
+	return out, nil
+}
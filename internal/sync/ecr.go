@@ -0,0 +1,272 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// ECRRepository is a repository with its images and their scan summaries.
+type ECRRepository struct {
+	Name       string     `json:"Name"`
+	Uri        string     `json:"Uri"` // registry/name, no tag
+	ScanOnPush bool       `json:"ScanOnPush"`
+	Images     []ECRImage `json:"Images"`
+}
+
+// ECRImage is one pushed image (manifest). Severity counts come from the
+// latest basic or enhanced scan; ScanStatus is empty when never scanned.
+type ECRImage struct {
+	Digest       string   `json:"Digest"`
+	Tags         []string `json:"Tags"`
+	PushedAt     string   `json:"PushedAt"`
+	LastPulled   string   `json:"LastPulled"`
+	ScanStatus   string   `json:"ScanStatus"`
+	Critical     int      `json:"Critical"`
+	High         int      `json:"High"`
+	Medium       int      `json:"Medium"`
+	CriticalCVEs []string `json:"CriticalCVEs"` // names of CRITICAL findings
+}
+
+// SyncECRData fetches ECR repositories, their images and scan summaries.
+// Critical finding names are fetched only for images that have any.
+func SyncECRData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("ecr", "describe-repositories", "--region", region)
+	if err != nil {
+		step("ecr")
+		return []SyncResult{{Service: "ecr", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		Repositories []struct {
+			RepositoryName             string `json:"repositoryName"`
+			RepositoryUri              string `json:"repositoryUri"`
+			ImageScanningConfiguration struct {
+				ScanOnPush bool `json:"scanOnPush"`
+			} `json:"imageScanningConfiguration"`
+		} `json:"repositories"`
+	}
+	json.Unmarshal(data, &resp)
+
+	var repos []ECRRepository
+	for _, r := range resp.Repositories {
+		repo := ECRRepository{Name: r.RepositoryName, Uri: r.RepositoryUri, ScanOnPush: r.ImageScanningConfiguration.ScanOnPush}
+		if imgData, err := awscli.Run("ecr", "describe-images", "--repository-name", r.RepositoryName, "--region", region); err == nil {
+			repo.Images = parseECRImages(imgData)
+		}
+		for i := range repo.Images {
+			img := &repo.Images[i]
+			if img.Critical == 0 {
+				continue
+			}
+			if fData, err := awscli.Run("ecr", "describe-image-scan-findings", "--repository-name", r.RepositoryName,
+				"--image-id", "imageDigest="+img.Digest, "--region", region); err == nil {
+				img.CriticalCVEs = parseCriticalFindings(fData)
+			}
+		}
+		repos = append(repos, repo)
+	}
+	step("ecr")
+
+	b, _ := json.Marshal(repos)
+	st.WriteCache(region+":ecr", b)
+	return []SyncResult{{Service: "ecr", Count: len(repos)}}, nil
+}
+
+func parseECRImages(raw []byte) []ECRImage {
+	var resp struct {
+		ImageDetails []struct {
+			ImageDigest          string   `json:"imageDigest"`
+			ImageTags            []string `json:"imageTags"`
+			ImagePushedAt        string   `json:"imagePushedAt"`
+			LastRecordedPullTime string   `json:"lastRecordedPullTime"`
+			ImageScanStatus      struct {
+				Status string `json:"status"`
+			} `json:"imageScanStatus"`
+			ImageScanFindingsSummary struct {
+				FindingSeverityCounts map[string]int `json:"findingSeverityCounts"`
+			} `json:"imageScanFindingsSummary"`
+		} `json:"imageDetails"`
+	}
+	json.Unmarshal(raw, &resp)
+	var out []ECRImage
+	for _, d := range resp.ImageDetails {
+		counts := d.ImageScanFindingsSummary.FindingSeverityCounts
+		out = append(out, ECRImage{
+			Digest:     d.ImageDigest,
+			Tags:       d.ImageTags,
+			PushedAt:   d.ImagePushedAt,
+			LastPulled: d.LastRecordedPullTime,
+			ScanStatus: d.ImageScanStatus.Status,
+			Critical:   counts["CRITICAL"],
+			High:       counts["HIGH"],
+			Medium:     counts["MEDIUM"],
+		})
+	}
+	return out
+}
+
+// parseCriticalFindings reads CRITICAL finding names from a basic scan
+// (findings) or an enhanced scan (enhancedFindings).
+func parseCriticalFindings(raw []byte) []string {
+	var resp struct {
+		ImageScanFindings struct {
+			Findings []struct {
+				Name     string `json:"name"`
+				Severity string `json:"severity"`
+			} `json:"findings"`
+			EnhancedFindings []struct {
+				Severity                    string `json:"severity"`
+				PackageVulnerabilityDetails struct {
+					VulnerabilityId string `json:"vulnerabilityId"`
+				} `json:"packageVulnerabilityDetails"`
+			} `json:"enhancedFindings"`
+		} `json:"imageScanFindings"`
+	}
+	json.Unmarshal(raw, &resp)
+	seen := map[string]bool{}
+	var out []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	for _, f := range resp.ImageScanFindings.Findings {
+		if f.Severity == "CRITICAL" {
+			add(f.Name)
+		}
+	}
+	for _, f := range resp.ImageScanFindings.EnhancedFindings {
+		if f.Severity == "CRITICAL" {
+			add(f.PackageVulnerabilityDetails.VulnerabilityId)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LoadECRRepositories returns the cached repositories for region.
+func LoadECRRepositories(region string) ([]ECRRepository, error) {
+	raw, err := ReadCache(region + ":ecr")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var repos []ECRRepository
+	json.Unmarshal(raw, &repos)
+	return repos, nil
+}
+
+// WorkloadImage is a container image referenced by a running workload,
+// joined with the ECR image it resolves to. Image is nil when the
+// reference is not in a synced ECR registry or the tag no longer exists.
+type WorkloadImage struct {
+	Workload string    `json:"workload"` // "ecs/cluster/service" or "lambda/function"
+	Ref      string    `json:"ref"`      // image reference as configured
+	Repo     string    `json:"repo"`     // ECR repository name, "" outside ECR
+	Image    *ECRImage `json:"image"`
+}
+
+// AgeDays returns days since the image was pushed, or -1 when unknown.
+func (w WorkloadImage) AgeDays(now time.Time) int {
+	if w.Image == nil {
+		return -1
+	}
+	t, err := time.Parse(time.RFC3339, w.Image.PushedAt)
+	if err != nil {
+		return -1
+	}
+	return int(now.Sub(t).Hours() / 24)
+}
+
+// ImageUsage lists the images run by ECS services and Lambda container
+// functions in region, resolved against the ECR cache of the registry's
+// region. EKS pod images are not visible through the AWS APIs and are
+// not included.
+func ImageUsage(region string) ([]WorkloadImage, error) {
+	c, err := LoadComputeData(region)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	repos := map[string]map[string]ECRRepository{} // region → uri → repo
+	resolve := func(ref string) (string, *ECRImage) {
+		repoURI, tag, digest := splitImageRef(ref)
+		regRegion := ecrRegistryRegion(repoURI)
+		if regRegion == "" {
+			return "", nil
+		}
+		if _, ok := repos[regRegion]; !ok {
+			repos[regRegion] = map[string]ECRRepository{}
+			list, _ := LoadECRRepositories(regRegion)
+			for _, r := range list {
+				repos[regRegion][r.Uri] = r
+			}
+		}
+		repo, ok := repos[regRegion][repoURI]
+		if !ok {
+			return "", nil
+		}
+		for i, img := range repo.Images {
+			if digest != "" && img.Digest == digest {
+				return repo.Name, &repo.Images[i]
+			}
+			for _, t := range img.Tags {
+				if digest == "" && t == tag {
+					return repo.Name, &repo.Images[i]
+				}
+			}
+		}
+		return repo.Name, nil
+	}
+
+	var out []WorkloadImage
+	for _, cl := range c.ECS {
+		for _, svc := range cl.ECSServices {
+			for _, ref := range svc.Images {
+				repo, img := resolve(ref)
+				out = append(out, WorkloadImage{Workload: "ecs/" + cl.ClusterName + "/" + svc.ServiceName, Ref: ref, Repo: repo, Image: img})
+			}
+		}
+	}
+	for _, fn := range c.Lambda {
+		if fn.ImageUri == "" {
+			continue
+		}
+		repo, img := resolve(fn.ImageUri)
+		out = append(out, WorkloadImage{Workload: "lambda/" + fn.FunctionName, Ref: fn.ImageUri, Repo: repo, Image: img})
+	}
+	return out, nil
+}
+
+// splitImageRef splits "registry/repo:tag" or "registry/repo@sha256:..."
+// into the repository URI, tag and digest. A missing tag means "latest".
+func splitImageRef(ref string) (repo, tag, digest string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		return ref[:i], "", ref[i+1:]
+	}
+	// A colon after the last slash separates the tag; one before it is a registry port.
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:], ""
+	}
+	return ref, "latest", ""
+}
+
+// ecrRegistryRegion returns the region of an ECR repository URI
+// (123456789012.dkr.ecr.eu-west-1.amazonaws.com/name), or "" for other
+// registries.
+func ecrRegistryRegion(repoURI string) string {
+	host, _, _ := strings.Cut(repoURI, "/")
+	parts := strings.Split(host, ".")
+	if len(parts) >= 5 && parts[1] == "dkr" && parts[2] == "ecr" {
+		return parts[3]
+	}
+	return ""
+}
@@ -0,0 +1,341 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Cache values (API responses with security group rules, IAM and resource
+// policies) can be encrypted at rest with AES-256-GCM. Keys, sync times,
+// settings, tags and the resources table's names, IDs and ARNs stay in the
+// clear so the cache can still be queried.
+// Encrypted values are stored as sealedPrefix + base64(nonce|ciphertext);
+// anything else is plaintext, so a cache can be read while it is being
+// converted.
+
+// Encryption modes, stored in the encryption setting.
+const (
+	EncryptionOff        = ""
+	EncryptionPassphrase = "passphrase"
+	EncryptionKeychain   = "keychain"
+)
+
+const (
+	sealedPrefix     = "enc1:"
+	encryptionCheck  = "saws"
+	keychainService  = "saws"
+	pbkdf2Iterations = 600000
+)
+
+// cacheKey is the AES key values are sealed with; nil when the cache is
+// not encrypted.
+var cacheKey []byte
+
+// PassphrasePrompt asks the user for the cache passphrase when
+// SAWS_PASSPHRASE is not set; confirm asks twice, for a new one. Nil
+// outside a terminal.
+var PassphrasePrompt func(confirm bool) (string, error)
+
+// EncryptionMode returns how the cache is encrypted: EncryptionOff,
+// EncryptionPassphrase or EncryptionKeychain.
+func EncryptionMode() string {
+	mode, _ := GetSetting("encryption")
+	return mode
+}
+
+// unlockCache loads the key for an encrypted cache and seals any values
+// still in plaintext (merged from a legacy cache, or left by an
+// interrupted conversion).
+func unlockCache() error {
+	mode := EncryptionMode()
+	if mode == EncryptionOff {
+		cacheKey = nil
+		return nil
+	}
+	key, err := loadKey(mode)
+	if err != nil {
+		return err
+	}
+	check, _ := GetSetting("encryption_check")
+	if v, err := openWith(key, check); err != nil || v != encryptionCheck {
+		if mode == EncryptionPassphrase {
+			return errors.New("wrong passphrase for the encrypted cache")
+		}
+		return fmt.Errorf("the keychain key does not decrypt the cache in %s", dbDir)
+	}
+	cacheKey = key
+	if readOnly {
+		return nil
+	}
+	return reseal(key, key, `NOT GLOB '`+sealedPrefix+`*'`, nil)
+}
+
+// SetEncryption converts the cache to mode: it derives or creates the new
+// key, re-encrypts (or decrypts) every cached value and snapshot, and
+// compacts the database so no plaintext is left in free pages. Switching
+// from passphrase to passphrase changes the passphrase.
+func SetEncryption(mode string) error {
+	if err := writable(); err != nil {
+		return err
+	}
+	if mode != EncryptionOff && mode != EncryptionPassphrase && mode != EncryptionKeychain {
+		return fmt.Errorf("unknown encryption mode %q (use passphrase, keychain or off)", mode)
+	}
+	if mode == EncryptionKeychain && EncryptionMode() == EncryptionKeychain {
+		return nil
+	}
+	settings := map[string]string{"encryption": mode, "encryption_salt": "", "encryption_check": ""}
+	var key []byte
+	var err error
+	switch mode {
+	case EncryptionPassphrase:
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		if key, err = passphraseKey(salt, true); err != nil {
+			return err
+		}
+		settings["encryption_salt"] = hex.EncodeToString(salt)
+	case EncryptionKeychain:
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		if err := keychainStore(hex.EncodeToString(key)); err != nil {
+			return err
+		}
+	}
+	if key != nil {
+		if settings["encryption_check"], err = sealWith(key, encryptionCheck); err != nil {
+			return err
+		}
+	}
+	if err := reseal(cacheKey, key, `NOT NULL`, settings); err != nil {
+		return err
+	}
+	cacheKey = key
+
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		return err
+	}
+	_, err = db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+	return err
+}
+
+// sealedColumns are the columns holding sealed values, by table.
+var sealedColumns = map[string]string{"cache": "value", "snapshot_entries": "value", "resources": "json"}
+
+// reseal rewrites the sealed columns' values matching cond, opening
+// them with from and sealing them with to (nil for plaintext either way),
+// and saves settings in the same transaction.
+func reseal(from, to []byte, cond string, settings map[string]string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for table, column := range sealedColumns {
+		rows, err := tx.Query(`SELECT rowid, ` + column + ` FROM ` + table + ` WHERE ` + column + ` ` + cond)
+		if err != nil {
+			return err
+		}
+		values := map[int64]string{}
+		for rows.Next() {
+			var id int64
+			var v string
+			if err := rows.Scan(&id, &v); err != nil {
+				rows.Close()
+				return err
+			}
+			values[id] = v
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for id, v := range values {
+			if v, err = openWith(from, v); err != nil {
+				return fmt.Errorf("%s: %w", table, err)
+			}
+			if v, err = sealWith(to, v); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE `+table+` SET `+column+` = ? WHERE rowid = ?`, v, id); err != nil {
+				return err
+			}
+		}
+	}
+	for k, v := range settings {
+		if _, err := tx.Exec(`INSERT INTO settings (key, value) VALUES (?, ?)
+			ON CONFLICT(key) DO UPDATE SET value=excluded.value`, k, v); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// seal encrypts a value for storage with the cache key.
+func seal(plain string) (string, error) {
+	return sealWith(cacheKey, plain)
+}
+
+// unseal decrypts a stored value with the cache key.
+func unseal(stored string) (string, error) {
+	return openWith(cacheKey, stored)
+}
+
+func sealWith(key []byte, plain string) (string, error) {
+	if key == nil {
+		return plain, nil
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openWith(key []byte, stored string) (string, error) {
+	b64, ok := strings.CutPrefix(stored, sealedPrefix)
+	if !ok {
+		return stored, nil
+	}
+	if key == nil {
+		return "", errors.New("the cache is encrypted but no key is loaded")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return "", fmt.Errorf("corrupt encrypted value: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("corrupt encrypted value")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt a cache value: wrong key")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadKey returns the key of an encrypted cache.
+func loadKey(mode string) ([]byte, error) {
+	switch mode {
+	case EncryptionPassphrase:
+		s, _ := GetSetting("encryption_salt")
+		salt, err := hex.DecodeString(s)
+		if err != nil || len(salt) == 0 {
+			return nil, errors.New("the encrypted cache has no key salt; it cannot be decrypted")
+		}
+		return passphraseKey(salt, false)
+	case EncryptionKeychain:
+		secret, err := keychainLookup()
+		if err != nil {
+			return nil, err
+		}
+		key, err := hex.DecodeString(secret)
+		if err != nil || len(key) != 32 {
+			return nil, errors.New("the keychain entry for the cache is not a saws key")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown encryption mode %q", mode)
+}
+
+// passphraseKey derives a key from SAWS_PASSPHRASE, or the passphrase the
+// user types (twice when it's a new one).
+func passphraseKey(salt []byte, confirm bool) ([]byte, error) {
+	pass := os.Getenv("SAWS_PASSPHRASE")
+	if pass == "" {
+		if PassphrasePrompt == nil {
+			return nil, errors.New("the cache is encrypted with a passphrase; set SAWS_PASSPHRASE")
+		}
+		var err error
+		if pass, err = PassphrasePrompt(confirm); err != nil {
+			return nil, err
+		}
+	}
+	if pass == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	return pbkdf2.Key(sha256.New, pass, salt, pbkdf2Iterations, 32)
+}
+
+// The key is kept in the macOS keychain (security) or the Secret Service
+// on Linux (secret-tool), under the cache directory so each cache has its
+// own.
+
+func keychainStore(secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// security -i reads its commands from stdin, which keeps the key
+		// out of the process list.
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %q -a %q -w %q\n", keychainService, dbDir, secret))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=saws cache key", "service", keychainService, "dir", dbDir)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return fmt.Errorf("no keychain support on %s; use a passphrase", runtime.GOOS)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return fmt.Errorf("no keychain: %s is not installed; use a passphrase", cmd.Args[0])
+		}
+		return fmt.Errorf("storing the key in the keychain: %v %s", err, bytes.TrimSpace(out))
+	}
+	// security -i can exit 0 when the command it ran failed: read the key
+	// back before anything is encrypted with it.
+	if got, err := keychainLookup(); err != nil || got != secret {
+		return errors.New("the keychain did not keep the new key; the cache was left as it was")
+	}
+	return nil
+}
+
+func keychainLookup() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", dbDir, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "dir", dbDir)
+	default:
+		return "", fmt.Errorf("no keychain support on %s", runtime.GOOS)
+	}
+	out, err := cmd.Output()
+	if err != nil || len(bytes.TrimSpace(out)) == 0 {
+		return "", fmt.Errorf("no key for the encrypted cache in %s in the keychain", dbDir)
+	}
+	return string(bytes.TrimSpace(out)), nil
+}
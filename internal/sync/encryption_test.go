@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEncryption(t *testing.T) {
+	const key = "il-central-1:security-groups"
+	const value = `[{"GroupId":"sg-1","IpPermissions":[{"IpRanges":[{"CidrIp":"0.0.0.0/0"}]}]}]`
+	WriteCache(key, []byte(value))
+	stored := func() string {
+		var v string
+		db.QueryRow(`SELECT value FROM cache WHERE key = ?`, nsKey(key)).Scan(&v)
+		return v
+	}
+	defer SetEncryption(EncryptionOff)
+
+	t.Setenv("SAWS_PASSPHRASE", "correct horse")
+	if err := SetEncryption(EncryptionPassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if s := stored(); !strings.HasPrefix(s, sealedPrefix) || strings.Contains(s, "0.0.0.0/0") {
+		t.Errorf("stored value is not encrypted: %s", s)
+	}
+	if v, _ := ReadCache(key); string(v) != value {
+		t.Errorf("ReadCache = %s", v)
+	}
+
+	// Reopening needs the passphrase.
+	t.Setenv("SAWS_PASSPHRASE", "wrong")
+	if err := unlockCache(); err == nil {
+		t.Error("expected an error for the wrong passphrase")
+	}
+	t.Setenv("SAWS_PASSPHRASE", "correct horse")
+	if err := unlockCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Plaintext merged in from elsewhere is sealed on open.
+	db.Exec(`UPDATE cache SET value = ? WHERE key = ?`, value, nsKey(key))
+	if err := unlockCache(); err != nil {
+		t.Fatal(err)
+	}
+	if s := stored(); !strings.HasPrefix(s, sealedPrefix) {
+		t.Errorf("plaintext left after unlock: %s", s)
+	}
+
+	if err := SetEncryption(EncryptionOff); err != nil {
+		t.Fatal(err)
+	}
+	if s := stored(); s != value {
+		t.Errorf("stored value after decrypting = %s", s)
+	}
+	if EncryptionMode() != EncryptionOff {
+		t.Errorf("mode = %q", EncryptionMode())
+	}
+}
+
+func TestKeychainStoreVerifies(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("fakes secret-tool")
+	}
+	// A keychain that accepts the key and then doesn't have it.
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte("#!/bin/sh\ncat >/dev/null\nexit 0\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin)
+
+	const key = "il-central-1:network-acls"
+	WriteCache(key, []byte(`[]`))
+	if err := SetEncryption(EncryptionKeychain); err == nil {
+		SetEncryption(EncryptionOff)
+		t.Fatal("expected an error when the keychain loses the key")
+	}
+	if EncryptionMode() != EncryptionOff {
+		t.Errorf("encryption = %s after a failed switch", EncryptionMode())
+	}
+	var v string
+	db.QueryRow(`SELECT value FROM cache WHERE key = ?`, nsKey(key)).Scan(&v)
+	if v != `[]` {
+		t.Errorf("stored value = %s, want it left in plaintext", v)
+	}
+}
@@ -0,0 +1,242 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// Endpoint is something reachable from the internet: a hostname or public
+// IP, and the URL to request when the service defines one.
+type Endpoint struct {
+	Kind     string `json:"kind"`     // alb, nlb, cloudfront, apigateway, lambda-url, ec2, ecs-task
+	Resource string `json:"resource"` // inventory "type/id" key
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	URL      string `json:"url"`
+}
+
+// CloudFrontDistribution is the subset of a distribution needed for the
+// endpoint inventory. Distributions are global.
+type CloudFrontDistribution struct {
+	Id         string   `json:"Id"`
+	DomainName string   `json:"DomainName"`
+	Aliases    []string `json:"Aliases"`
+	Enabled    bool     `json:"Enabled"`
+	Comment    string   `json:"Comment"`
+}
+
+// APIGatewayAPI is a REST (v1) or HTTP/WebSocket (v2) API.
+type APIGatewayAPI struct {
+	Id       string `json:"Id"`
+	Name     string `json:"Name"`
+	Protocol string `json:"Protocol"` // REST, HTTP, WEBSOCKET
+	Endpoint string `json:"Endpoint"` // base URL
+	Private  bool   `json:"Private"`  // REST API with a PRIVATE endpoint type
+}
+
+// SyncEndpointData fetches the edge services that only matter for the
+// endpoint inventory: CloudFront distributions (global) and API Gateway
+// APIs. Load balancers, function URLs and public IPs come from other syncs.
+func SyncEndpointData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	var results []SyncResult
+
+	if data, err := awscli.Run("cloudfront", "list-distributions"); err == nil {
+		var resp struct {
+			DistributionList struct {
+				Items []struct {
+					Id         string `json:"Id"`
+					DomainName string `json:"DomainName"`
+					Enabled    bool   `json:"Enabled"`
+					Comment    string `json:"Comment"`
+					Aliases    struct {
+						Items []string `json:"Items"`
+					} `json:"Aliases"`
+				} `json:"Items"`
+			} `json:"DistributionList"`
+		}
+		json.Unmarshal(data, &resp)
+		var dists []CloudFrontDistribution
+		for _, d := range resp.DistributionList.Items {
+			dists = append(dists, CloudFrontDistribution{
+				Id: d.Id, DomainName: d.DomainName, Aliases: d.Aliases.Items, Enabled: d.Enabled, Comment: d.Comment,
+			})
+		}
+		b, _ := json.Marshal(dists)
+		st.WriteCache("cloudfront:distributions", b)
+		results = append(results, SyncResult{Service: "cloudfront", Count: len(dists)})
+	} else {
+		results = append(results, SyncResult{Service: "cloudfront", Error: err.Error()})
+	}
+	step("cloudfront")
+
+	var apis []APIGatewayAPI
+	var apiErr error
+	if data, err := awscli.Run("apigateway", "get-rest-apis", "--region", region); err == nil {
+		var resp struct {
+			Items []struct {
+				Id                    string `json:"id"`
+				Name                  string `json:"name"`
+				EndpointConfiguration struct {
+					Types []string `json:"types"`
+				} `json:"endpointConfiguration"`
+			} `json:"items"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, a := range resp.Items {
+			api := APIGatewayAPI{
+				Id: a.Id, Name: a.Name, Protocol: "REST",
+				Endpoint: "https://" + a.Id + ".execute-api." + region + ".amazonaws.com",
+			}
+			for _, t := range a.EndpointConfiguration.Types {
+				if t == "PRIVATE" {
+					api.Private = true
+				}
+			}
+			apis = append(apis, api)
+		}
+	} else {
+		apiErr = err
+	}
+	if data, err := awscli.Run("apigatewayv2", "get-apis", "--region", region); err == nil {
+		var resp struct {
+			Items []struct {
+				ApiId        string `json:"ApiId"`
+				Name         string `json:"Name"`
+				ProtocolType string `json:"ProtocolType"`
+				ApiEndpoint  string `json:"ApiEndpoint"`
+			} `json:"Items"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, a := range resp.Items {
+			apis = append(apis, APIGatewayAPI{Id: a.ApiId, Name: a.Name, Protocol: a.ProtocolType, Endpoint: a.ApiEndpoint})
+		}
+	} else if apiErr == nil {
+		apiErr = err
+	}
+	if apiErr != nil && len(apis) == 0 {
+		results = append(results, SyncResult{Service: "apigateway", Error: apiErr.Error()})
+	} else {
+		b, _ := json.Marshal(apis)
+		st.WriteCache(region+":apigateway", b)
+		results = append(results, SyncResult{Service: "apigateway", Count: len(apis)})
+	}
+	step("api gateway")
+
+	return results, nil
+}
+
+// LoadCloudFrontDistributions returns the cached distributions (global).
+func LoadCloudFrontDistributions() ([]CloudFrontDistribution, error) {
+	raw, err := ReadCache("cloudfront:distributions")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var dists []CloudFrontDistribution
+	json.Unmarshal(raw, &dists)
+	return dists, nil
+}
+
+// LoadAPIGatewayAPIs returns the cached REST and HTTP/WebSocket APIs.
+func LoadAPIGatewayAPIs(region string) ([]APIGatewayAPI, error) {
+	raw, err := ReadCache(region + ":apigateway")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var apis []APIGatewayAPI
+	json.Unmarshal(raw, &apis)
+	return apis, nil
+}
+
+// LoadEndpoints collects the externally reachable endpoints of a region
+// from the cache: internet-facing load balancers, CloudFront, public API
+// Gateway APIs, Lambda function URLs, and public IPs of EC2 and ECS tasks.
+func LoadEndpoints(region string) ([]Endpoint, error) {
+	var out []Endpoint
+
+	if v, err := LoadVPCData(region); err == nil && v != nil {
+		for _, lb := range v.LoadBalancers {
+			if lb.Scheme != "internet-facing" || lb.DNSName == "" {
+				continue
+			}
+			kind := "alb"
+			if lb.Type == "network" {
+				kind = "nlb"
+			}
+			out = append(out, Endpoint{Kind: kind, Resource: "lb/" + lb.Name, Name: lb.Name, Host: lb.DNSName})
+		}
+	}
+
+	if dists, err := LoadCloudFrontDistributions(); err == nil {
+		for _, d := range dists {
+			if !d.Enabled {
+				continue
+			}
+			name := d.Comment
+			if name == "" {
+				name = d.Id
+			}
+			out = append(out, Endpoint{Kind: "cloudfront", Resource: "cloudfront/" + d.Id, Name: name,
+				Host: d.DomainName, URL: "https://" + d.DomainName})
+			for _, alias := range d.Aliases {
+				out = append(out, Endpoint{Kind: "cloudfront", Resource: "cloudfront/" + d.Id, Name: name,
+					Host: alias, URL: "https://" + alias})
+			}
+		}
+	}
+
+	if apis, err := LoadAPIGatewayAPIs(region); err == nil {
+		for _, a := range apis {
+			if a.Private || a.Endpoint == "" {
+				continue
+			}
+			host := a.Endpoint
+			if i := strings.Index(host, "://"); i >= 0 {
+				host = host[i+3:]
+			}
+			out = append(out, Endpoint{Kind: "apigateway", Resource: "apigateway/" + a.Id, Name: a.Name,
+				Host: host, URL: a.Endpoint})
+		}
+	}
+
+	if c, err := LoadComputeData(region); err == nil && c != nil {
+		for _, fn := range c.Lambda {
+			if fn.FunctionUrl == "" {
+				continue
+			}
+			host := strings.TrimSuffix(strings.TrimPrefix(fn.FunctionUrl, "https://"), "/")
+			out = append(out, Endpoint{Kind: "lambda-url", Resource: "lambda/" + fn.FunctionName, Name: fn.FunctionName,
+				Host: host, URL: fn.FunctionUrl})
+		}
+		for _, inst := range c.EC2 {
+			if inst.PublicIP == "" || inst.State != "running" {
+				continue
+			}
+			name := inst.Name
+			if name == "" {
+				name = inst.InstanceId
+			}
+			out = append(out, Endpoint{Kind: "ec2", Resource: "ec2/" + inst.InstanceId, Name: name, Host: inst.PublicIP})
+		}
+		for _, cl := range c.ECS {
+			for _, t := range cl.Tasks {
+				if t.PublicIP == "" {
+					continue
+				}
+				id := t.TaskArn[strings.LastIndex(t.TaskArn, "/")+1:]
+				out = append(out, Endpoint{Kind: "ecs-task", Resource: "ecs/" + cl.ClusterName, Name: cl.ClusterName + "/" + id,
+					Host: t.PublicIP})
+			}
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Kind < out[j].Kind })
+	return out, nil
+}
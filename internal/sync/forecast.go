@@ -0,0 +1,209 @@
+package sync
+
+import (
+	"encoding/json"
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// maxUsageSamples bounds region:usage-history; with one sample a day it
+// covers about three months.
+const maxUsageSamples = 90
+
+// UsageSample is the quota usage and subnet IPs in use as of one sync,
+// keyed by quota code and "subnet/<id>".
+type UsageSample struct {
+	At    string         `json:"at"`
+	Usage map[string]int `json:"usage"`
+}
+
+// recordUsageHistory appends the region's current quota usage and subnet
+// IP counts to region:usage-history. A later sync on the same day replaces
+// that day's sample so the trend isn't skewed by bursts of syncs.
+func recordUsageHistory(region string) {
+	usage := map[string]int{}
+	quotas, _ := LoadQuotas(region)
+	for _, q := range quotas {
+		usage[q.QuotaCode] = q.Usage
+	}
+	if vpc, _ := LoadVPCData(region); vpc != nil {
+		for _, s := range vpc.Subnets {
+			if size := subnetSize(s.CidrBlock); size > 0 {
+				usage["subnet/"+s.SubnetId] = size - s.AvailableIPs
+			}
+		}
+	}
+	if len(usage) == 0 {
+		return
+	}
+	history, _ := LoadUsageHistory(region)
+	now := time.Now().UTC()
+	sample := UsageSample{At: now.Format(time.RFC3339), Usage: usage}
+	if n := len(history); n > 0 && history[n-1].At[:10] == sample.At[:10] {
+		history[n-1] = sample
+	} else {
+		history = append(history, sample)
+	}
+	if len(history) > maxUsageSamples {
+		history = history[len(history)-maxUsageSamples:]
+	}
+	b, _ := json.Marshal(history)
+	WriteCache(region+":usage-history", b)
+}
+
+// LoadUsageHistory returns the region's usage samples, oldest first.
+func LoadUsageHistory(region string) ([]UsageSample, error) {
+	raw, err := ReadCache(region + ":usage-history")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var history []UsageSample
+	json.Unmarshal(raw, &history)
+	return history, nil
+}
+
+// subnetSize returns the usable IPv4 addresses of a subnet: AWS reserves
+// five in every subnet.
+func subnetSize(cidr string) int {
+	p, err := netip.ParsePrefix(cidr)
+	if err != nil || !p.Addr().Is4() {
+		return 0
+	}
+	return 1<<(32-p.Bits()) - 5
+}
+
+// Forecast projects when usage of a quota or subnet reaches its limit
+// from the linear trend of the usage history.
+type Forecast struct {
+	Key     string  `json:"key"` // quota code or "subnet/<id>"
+	Name    string  `json:"name"`
+	Usage   int     `json:"usage"`
+	Limit   int     `json:"limit"`
+	PerDay  float64 `json:"perDay"`           // fitted growth; <= 0 when flat or shrinking
+	FullBy  string  `json:"fullBy,omitempty"` // projected date (YYYY-MM-DD), empty if not growing
+	Samples int     `json:"samples"`
+}
+
+// Growing reports whether the trend points towards the limit.
+func (f Forecast) Growing() bool { return f.FullBy != "" }
+
+// DaysLeft returns the days until FullBy from now, or -1 when not growing.
+func (f Forecast) DaysLeft(now time.Time) int {
+	t, err := time.Parse("2006-01-02", f.FullBy)
+	if err != nil {
+		return -1
+	}
+	if d := int(t.Sub(now.UTC().Truncate(24*time.Hour)).Hours() / 24); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// minTrendDays is how much history a trend needs before it is projected.
+const minTrendDays = 1
+
+// UsageForecasts fits a least-squares line through the usage history of
+// each cached quota and subnet and projects the date it reaches the
+// limit. Keys with fewer than two samples a day apart are left out;
+// quotas come first, then subnets by soonest exhaustion.
+func UsageForecasts(region string) ([]Forecast, error) {
+	history, err := LoadUsageHistory(region)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var quotas, subnets []Forecast
+	qs, _ := LoadQuotas(region)
+	for _, q := range qs {
+		if q.Value <= 0 {
+			continue
+		}
+		if f, ok := fitForecast(history, q.QuotaCode, q.Usage, int(q.Value), now); ok {
+			f.Name = q.Name
+			quotas = append(quotas, f)
+		}
+	}
+	if vpc, _ := LoadVPCData(region); vpc != nil {
+		for _, s := range vpc.Subnets {
+			size := subnetSize(s.CidrBlock)
+			if size <= 0 {
+				continue
+			}
+			if f, ok := fitForecast(history, "subnet/"+s.SubnetId, size-s.AvailableIPs, size, now); ok {
+				f.Name = s.SubnetId + nameSuffix(s.Name)
+				subnets = append(subnets, f)
+			}
+		}
+	}
+	sort.SliceStable(subnets, func(i, j int) bool {
+		a, b := subnets[i], subnets[j]
+		if a.Growing() != b.Growing() {
+			return a.Growing()
+		}
+		return a.FullBy < b.FullBy
+	})
+	return append(quotas, subnets...), nil
+}
+
+func nameSuffix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return " (" + name + ")"
+}
+
+// fitForecast fits usage over time for key. The current usage is the
+// latest point, so a trend shows up before the next sync records it.
+func fitForecast(history []UsageSample, key string, usage, limit int, now time.Time) (Forecast, bool) {
+	f := Forecast{Key: key, Usage: usage, Limit: limit}
+	var xs, ys []float64
+	for _, s := range history {
+		v, ok := s.Usage[key]
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, s.At)
+		if err != nil {
+			continue
+		}
+		xs = append(xs, t.Sub(now).Hours()/24)
+		ys = append(ys, float64(v))
+	}
+	if len(xs) == 0 || xs[len(xs)-1] < -0.5 {
+		xs, ys = append(xs, 0), append(ys, float64(usage))
+	}
+	f.Samples = len(xs)
+	if len(xs) < 2 || xs[len(xs)-1]-xs[0] < minTrendDays {
+		return f, false
+	}
+	var mx, my float64
+	for i := range xs {
+		mx += xs[i]
+		my += ys[i]
+	}
+	mx /= float64(len(xs))
+	my /= float64(len(ys))
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - mx) * (ys[i] - my)
+		den += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if den == 0 {
+		return f, false
+	}
+	f.PerDay = num / den
+	if f.PerDay <= 0 {
+		return f, true
+	}
+	days := 0.0
+	if usage < limit {
+		days = float64(limit-usage) / f.PerDay
+	}
+	// Beyond ten years the date says nothing useful.
+	if days > 3650 {
+		return f, true
+	}
+	f.FullBy = now.UTC().Add(time.Duration(days * 24 * float64(time.Hour))).Format("2006-01-02")
+	return f, true
+}
@@ -0,0 +1,317 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// guardDutyMaxFindings caps how many findings are fetched in full; the
+// severity counts cover all of them.
+const guardDutyMaxFindings = 50
+
+// activeFindings selects findings that have not been archived.
+const activeFindings = `{"Criterion":{"service.archived":{"Eq":["false"]}}}`
+
+// GuardDutyData is the region's detector and its active findings, highest
+// severity first.
+type GuardDutyData struct {
+	DetectorId string             `json:"DetectorId"`
+	Status     string             `json:"Status"`
+	Counts     map[string]int     `json:"Counts"` // by SeverityLabel
+	Findings   []GuardDutyFinding `json:"Findings"`
+}
+
+// Total returns the number of active findings, including those not
+// fetched in full.
+func (d *GuardDutyData) Total() int {
+	n := 0
+	for _, c := range d.Counts {
+		n += c
+	}
+	return n
+}
+
+// SeverityCount is the number of active findings at one severity.
+type SeverityCount struct {
+	Label string
+	Count int
+}
+
+// SeverityCounts returns the non-zero counts, most severe first.
+func (d *GuardDutyData) SeverityCounts() []SeverityCount {
+	var out []SeverityCount
+	for _, label := range []string{"Critical", "High", "Medium", "Low"} {
+		if n := d.Counts[label]; n > 0 {
+			out = append(out, SeverityCount{label, n})
+		}
+	}
+	return out
+}
+
+// GuardDutyFinding is an active finding. Link is set on load when the
+// affected resource is in the cache.
+type GuardDutyFinding struct {
+	Id           string       `json:"Id"`
+	Type         string       `json:"Type"`
+	Title        string       `json:"Title"`
+	Description  string       `json:"Description"`
+	Severity     float64      `json:"Severity"`
+	Count        int          `json:"Count"`
+	ResourceType string       `json:"ResourceType"`
+	InstanceId   string       `json:"InstanceId,omitempty"`
+	AccessKeyId  string       `json:"AccessKeyId,omitempty"`
+	UserName     string       `json:"UserName,omitempty"`
+	UserType     string       `json:"UserType,omitempty"`
+	BucketName   string       `json:"BucketName,omitempty"`
+	FunctionName string       `json:"FunctionName,omitempty"`
+	FirstSeen    string       `json:"FirstSeen"`
+	LastSeen     string       `json:"LastSeen"`
+	Link         *FindingLink `json:"-"`
+}
+
+// FindingLink points a finding at the /detail/ panel of a cached resource.
+type FindingLink struct {
+	Type  string
+	ID    string
+	Label string
+}
+
+// SeverityLabel buckets the numeric severity the way the console does.
+func (f GuardDutyFinding) SeverityLabel() string {
+	return severityLabel(f.Severity)
+}
+
+func severityLabel(s float64) string {
+	switch {
+	case s >= 9:
+		return "Critical"
+	case s >= 7:
+		return "High"
+	case s >= 4:
+		return "Medium"
+	}
+	return "Low"
+}
+
+// Resource returns the affected resource as reported by GuardDuty.
+func (f GuardDutyFinding) Resource() string {
+	switch {
+	case f.InstanceId != "":
+		return f.InstanceId
+	case f.BucketName != "":
+		return f.BucketName
+	case f.FunctionName != "":
+		return f.FunctionName
+	case f.AccessKeyId != "":
+		if f.UserName != "" {
+			return f.AccessKeyId + " (" + f.UserName + ")"
+		}
+		return f.AccessKeyId
+	}
+	return f.ResourceType
+}
+
+// SyncGuardDutyData fetches the region's detector, finding counts by
+// severity and the highest-severity active findings.
+func SyncGuardDutyData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("guardduty", "list-detectors", "--region", region)
+	if err != nil {
+		step("guardduty")
+		return []SyncResult{{Service: "guardduty", Error: err.Error()}}, nil
+	}
+	var detectors struct {
+		DetectorIds []string `json:"DetectorIds"`
+	}
+	json.Unmarshal(data, &detectors)
+	gd := &GuardDutyData{Counts: map[string]int{}}
+	if len(detectors.DetectorIds) == 0 {
+		step("guardduty")
+		b, _ := json.Marshal(gd)
+		st.WriteCache(region+":guardduty", b)
+		return []SyncResult{{Service: "guardduty", Count: 0}}, nil
+	}
+	gd.DetectorId = detectors.DetectorIds[0]
+	if data, err := awscli.Run("guardduty", "get-detector", "--detector-id", gd.DetectorId, "--region", region); err == nil {
+		var resp struct {
+			Status string `json:"Status"`
+		}
+		json.Unmarshal(data, &resp)
+		gd.Status = resp.Status
+	}
+
+	if data, err := awscli.Run("guardduty", "get-findings-statistics", "--detector-id", gd.DetectorId,
+		"--finding-statistic-types", "COUNT_BY_SEVERITY", "--finding-criteria", activeFindings, "--region", region); err == nil {
+		var resp struct {
+			FindingStatistics struct {
+				CountBySeverity map[string]int `json:"CountBySeverity"`
+			} `json:"FindingStatistics"`
+		}
+		json.Unmarshal(data, &resp)
+		for sev, n := range resp.FindingStatistics.CountBySeverity {
+			s, _ := strconv.ParseFloat(sev, 64)
+			gd.Counts[severityLabel(s)] += n
+		}
+	}
+	step("guardduty detector")
+
+	data, err = awscli.Run("guardduty", "list-findings", "--detector-id", gd.DetectorId,
+		"--finding-criteria", activeFindings, "--sort-criteria", `{"AttributeName":"severity","OrderBy":"DESC"}`,
+		"--max-items", strconv.Itoa(guardDutyMaxFindings), "--region", region)
+	if err != nil {
+		step("guardduty findings")
+		return []SyncResult{{Service: "guardduty", Error: err.Error()}}, nil
+	}
+	var list struct {
+		FindingIds []string `json:"FindingIds"`
+	}
+	json.Unmarshal(data, &list)
+	if len(list.FindingIds) > 0 {
+		args := append([]string{"guardduty", "get-findings", "--detector-id", gd.DetectorId, "--finding-ids"}, list.FindingIds...)
+		data, err = awscli.Run(append(args, "--region", region)...)
+		if err != nil {
+			step("guardduty findings")
+			return []SyncResult{{Service: "guardduty", Error: err.Error()}}, nil
+		}
+		gd.Findings = parseGuardDutyFindings(data)
+	}
+	step("guardduty findings")
+
+	b, _ := json.Marshal(gd)
+	st.WriteCache(region+":guardduty", b)
+	return []SyncResult{{Service: "guardduty", Count: gd.Total()}}, nil
+}
+
+func parseGuardDutyFindings(data []byte) []GuardDutyFinding {
+	var resp struct {
+		Findings []struct {
+			Id          string  `json:"Id"`
+			Type        string  `json:"Type"`
+			Title       string  `json:"Title"`
+			Description string  `json:"Description"`
+			Severity    float64 `json:"Severity"`
+			CreatedAt   string  `json:"CreatedAt"`
+			UpdatedAt   string  `json:"UpdatedAt"`
+			Resource    struct {
+				ResourceType    string `json:"ResourceType"`
+				InstanceDetails struct {
+					InstanceId string `json:"InstanceId"`
+				} `json:"InstanceDetails"`
+				AccessKeyDetails struct {
+					AccessKeyId string `json:"AccessKeyId"`
+					UserName    string `json:"UserName"`
+					UserType    string `json:"UserType"`
+				} `json:"AccessKeyDetails"`
+				S3BucketDetails []struct {
+					Name string `json:"Name"`
+				} `json:"S3BucketDetails"`
+				LambdaDetails struct {
+					FunctionName string `json:"FunctionName"`
+				} `json:"LambdaDetails"`
+			} `json:"Resource"`
+			Service struct {
+				Count          int    `json:"Count"`
+				EventFirstSeen string `json:"EventFirstSeen"`
+				EventLastSeen  string `json:"EventLastSeen"`
+			} `json:"Service"`
+		} `json:"Findings"`
+	}
+	json.Unmarshal(data, &resp)
+
+	var findings []GuardDutyFinding
+	for _, f := range resp.Findings {
+		res := f.Resource
+		finding := GuardDutyFinding{
+			Id:           f.Id,
+			Type:         f.Type,
+			Title:        f.Title,
+			Description:  f.Description,
+			Severity:     f.Severity,
+			Count:        f.Service.Count,
+			ResourceType: res.ResourceType,
+			InstanceId:   res.InstanceDetails.InstanceId,
+			AccessKeyId:  res.AccessKeyDetails.AccessKeyId,
+			UserName:     res.AccessKeyDetails.UserName,
+			UserType:     res.AccessKeyDetails.UserType,
+			FunctionName: res.LambdaDetails.FunctionName,
+			FirstSeen:    firstNonEmpty(f.Service.EventFirstSeen, f.CreatedAt),
+			LastSeen:     firstNonEmpty(f.Service.EventLastSeen, f.UpdatedAt),
+		}
+		if len(res.S3BucketDetails) > 0 {
+			finding.BucketName = res.S3BucketDetails[0].Name
+		}
+		findings = append(findings, finding)
+	}
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Severity > findings[j].Severity })
+	return findings
+}
+
+func firstNonEmpty(s, fallback string) string {
+	if s != "" {
+		return s
+	}
+	return fallback
+}
+
+// LoadGuardDutyData returns the cached GuardDuty data for region, with
+// each finding linked to the affected resource when it is cached.
+func LoadGuardDutyData(region string) (*GuardDutyData, error) {
+	raw, err := ReadCache(region + ":guardduty")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var gd GuardDutyData
+	json.Unmarshal(raw, &gd)
+	linkFindings(region, gd.Findings)
+	return &gd, nil
+}
+
+func linkFindings(region string, findings []GuardDutyFinding) {
+	if len(findings) == 0 {
+		return
+	}
+	links := map[string]*FindingLink{}
+	if compute, _ := LoadComputeData(region); compute != nil {
+		for _, inst := range compute.EC2 {
+			links["ec2/"+inst.InstanceId] = &FindingLink{"ec2", inst.InstanceId, firstNonEmpty(inst.Name, inst.InstanceId)}
+		}
+		for _, fn := range compute.Lambda {
+			links["lambda/"+fn.FunctionName] = &FindingLink{"lambda", fn.FunctionName, fn.FunctionName}
+		}
+	}
+	if iam, _ := LoadIAMData(); iam != nil {
+		for _, role := range iam.Roles {
+			links["iam-role/"+role.RoleName] = &FindingLink{"iam-role", role.RoleName, role.RoleName}
+		}
+	}
+	if s3, _ := LoadS3Data(); s3 != nil {
+		for _, b := range s3.Buckets {
+			links["s3/"+b.Name] = &FindingLink{"s3", b.Name, b.Name}
+		}
+	}
+
+	for i, f := range findings {
+		var key string
+		switch {
+		case f.InstanceId != "":
+			key = "ec2/" + f.InstanceId
+		case f.FunctionName != "":
+			key = "lambda/" + f.FunctionName
+		case f.BucketName != "":
+			key = "s3/" + f.BucketName
+		case f.UserType == "AssumedRole":
+			// Access keys of assumed roles are temporary; the role is what
+			// can be looked at.
+			key = "iam-role/" + f.UserName
+		}
+		findings[i].Link = links[key]
+	}
+}
@@ -0,0 +1,372 @@
+package sync
+
+import (
+	"encoding/json"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// HybridData is the connectivity between the region and on-premises
+// networks: Site-to-Site VPN and Direct Connect.
+type HybridData struct {
+	VPNConnections   []VPNConnection      `json:"vpnConnections"`
+	CustomerGateways []CustomerGateway    `json:"customerGateways"`
+	VPNGateways      []VPNGateway         `json:"vpnGateways"`
+	DXConnections    []DXConnection       `json:"dxConnections"`
+	DXInterfaces     []DXVirtualInterface `json:"dxInterfaces"`
+}
+
+// VPNConnection terminates on either a virtual private gateway or a
+// transit gateway; exactly one of VpnGatewayId and TransitGatewayId is set.
+type VPNConnection struct {
+	VpnConnectionId   string      `json:"VpnConnectionId"`
+	Name              string      `json:"Name"`
+	State             string      `json:"State"`
+	Type              string      `json:"Type"`
+	CustomerGatewayId string      `json:"CustomerGatewayId"`
+	VpnGatewayId      string      `json:"VpnGatewayId"`
+	TransitGatewayId  string      `json:"TransitGatewayId"`
+	StaticRoutesOnly  bool        `json:"StaticRoutesOnly"`
+	Tunnels           []VPNTunnel `json:"Tunnels"`
+}
+
+// VPNTunnel is one of the two tunnels of a VPN connection, from the
+// connection's VGW telemetry.
+type VPNTunnel struct {
+	OutsideIpAddress   string `json:"OutsideIpAddress"`
+	Status             string `json:"Status"` // UP or DOWN
+	StatusMessage      string `json:"StatusMessage"`
+	LastStatusChange   string `json:"LastStatusChange"`
+	AcceptedRouteCount int    `json:"AcceptedRouteCount"`
+}
+
+// TunnelsUp counts tunnels reporting UP.
+func (v VPNConnection) TunnelsUp() int {
+	n := 0
+	for _, t := range v.Tunnels {
+		if t.Status == "UP" {
+			n++
+		}
+	}
+	return n
+}
+
+// Degraded reports an available connection with at least one tunnel down.
+func (v VPNConnection) Degraded() bool {
+	return v.State == "available" && v.TunnelsUp() < len(v.Tunnels)
+}
+
+type CustomerGateway struct {
+	CustomerGatewayId string `json:"CustomerGatewayId"`
+	Name              string `json:"Name"`
+	State             string `json:"State"`
+	IpAddress         string `json:"IpAddress"`
+	BgpAsn            string `json:"BgpAsn"`
+	DeviceName        string `json:"DeviceName"`
+}
+
+// VPNGateway is a virtual private gateway. VpcIds lists the VPCs it is
+// attached to.
+type VPNGateway struct {
+	VpnGatewayId  string   `json:"VpnGatewayId"`
+	Name          string   `json:"Name"`
+	State         string   `json:"State"`
+	AmazonSideAsn int64    `json:"AmazonSideAsn"`
+	VpcIds        []string `json:"VpcIds"`
+}
+
+type DXConnection struct {
+	ConnectionId string `json:"ConnectionId"`
+	Name         string `json:"Name"`
+	State        string `json:"State"`
+	Location     string `json:"Location"`
+	Bandwidth    string `json:"Bandwidth"`
+	ProviderName string `json:"ProviderName"`
+	Vlan         int    `json:"Vlan"`
+}
+
+// DXVirtualInterface is a private, public or transit VIF on a Direct
+// Connect connection. BGPStatus is "up" when any BGP peer is up.
+type DXVirtualInterface struct {
+	VirtualInterfaceId     string `json:"VirtualInterfaceId"`
+	Name                   string `json:"Name"`
+	Type                   string `json:"Type"` // private, public or transit
+	State                  string `json:"State"`
+	ConnectionId           string `json:"ConnectionId"`
+	Vlan                   int    `json:"Vlan"`
+	Asn                    int64  `json:"Asn"`
+	AmazonAddress          string `json:"AmazonAddress"`
+	CustomerAddress        string `json:"CustomerAddress"`
+	VirtualGatewayId       string `json:"VirtualGatewayId"`
+	DirectConnectGatewayId string `json:"DirectConnectGatewayId"`
+	BGPStatus              string `json:"BGPStatus"`
+}
+
+// InterfacesOn returns the virtual interfaces on a Direct Connect connection.
+func (h *HybridData) InterfacesOn(connectionId string) []DXVirtualInterface {
+	var out []DXVirtualInterface
+	for _, vif := range h.DXInterfaces {
+		if vif.ConnectionId == connectionId {
+			out = append(out, vif)
+		}
+	}
+	return out
+}
+
+// CustomerGateway returns the customer gateway with the ID, or nil.
+func (h *HybridData) CustomerGateway(id string) *CustomerGateway {
+	for i := range h.CustomerGateways {
+		if h.CustomerGateways[i].CustomerGatewayId == id {
+			return &h.CustomerGateways[i]
+		}
+	}
+	return nil
+}
+
+// SyncHybridData fetches Site-to-Site VPN connections with tunnel status,
+// customer and virtual private gateways, and Direct Connect connections
+// with their virtual interfaces.
+func SyncHybridData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	var results []SyncResult
+	var hybrid HybridData
+
+	if data, err := awscli.Run("ec2", "describe-vpn-connections", "--region", region); err == nil {
+		var resp struct {
+			VpnConnections []json.RawMessage `json:"VpnConnections"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, raw := range resp.VpnConnections {
+			var v struct {
+				VpnConnectionId   string `json:"VpnConnectionId"`
+				State             string `json:"State"`
+				Type              string `json:"Type"`
+				CustomerGatewayId string `json:"CustomerGatewayId"`
+				VpnGatewayId      string `json:"VpnGatewayId"`
+				TransitGatewayId  string `json:"TransitGatewayId"`
+				Options           struct {
+					StaticRoutesOnly bool `json:"StaticRoutesOnly"`
+				} `json:"Options"`
+				VgwTelemetry []VPNTunnel `json:"VgwTelemetry"`
+			}
+			json.Unmarshal(raw, &v)
+			if v.State == "deleted" {
+				continue
+			}
+			hybrid.VPNConnections = append(hybrid.VPNConnections, VPNConnection{
+				VpnConnectionId:   v.VpnConnectionId,
+				Name:              tagName(raw),
+				State:             v.State,
+				Type:              v.Type,
+				CustomerGatewayId: v.CustomerGatewayId,
+				VpnGatewayId:      v.VpnGatewayId,
+				TransitGatewayId:  v.TransitGatewayId,
+				StaticRoutesOnly:  v.Options.StaticRoutesOnly,
+				Tunnels:           v.VgwTelemetry,
+			})
+		}
+		results = append(results, SyncResult{Service: "vpn-connections", Count: len(hybrid.VPNConnections)})
+	} else {
+		results = append(results, SyncResult{Service: "vpn-connections", Error: err.Error()})
+	}
+	step("vpn connections")
+
+	if data, err := awscli.Run("ec2", "describe-customer-gateways", "--region", region); err == nil {
+		var resp struct {
+			CustomerGateways []json.RawMessage `json:"CustomerGateways"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, raw := range resp.CustomerGateways {
+			var g CustomerGateway
+			json.Unmarshal(raw, &g)
+			if g.State == "deleted" {
+				continue
+			}
+			g.Name = tagName(raw)
+			hybrid.CustomerGateways = append(hybrid.CustomerGateways, g)
+		}
+	}
+
+	if data, err := awscli.Run("ec2", "describe-vpn-gateways", "--region", region); err == nil {
+		var resp struct {
+			VpnGateways []json.RawMessage `json:"VpnGateways"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, raw := range resp.VpnGateways {
+			var g struct {
+				VpnGatewayId   string `json:"VpnGatewayId"`
+				State          string `json:"State"`
+				AmazonSideAsn  int64  `json:"AmazonSideAsn"`
+				VpcAttachments []struct {
+					VpcId string `json:"VpcId"`
+					State string `json:"State"`
+				} `json:"VpcAttachments"`
+			}
+			json.Unmarshal(raw, &g)
+			if g.State == "deleted" {
+				continue
+			}
+			vgw := VPNGateway{VpnGatewayId: g.VpnGatewayId, Name: tagName(raw), State: g.State, AmazonSideAsn: g.AmazonSideAsn}
+			for _, a := range g.VpcAttachments {
+				if a.State == "attached" || a.State == "attaching" {
+					vgw.VpcIds = append(vgw.VpcIds, a.VpcId)
+				}
+			}
+			hybrid.VPNGateways = append(hybrid.VPNGateways, vgw)
+		}
+	}
+	step("vpn gateways")
+
+	if data, err := awscli.Run("directconnect", "describe-connections", "--region", region); err == nil {
+		var resp struct {
+			Connections []struct {
+				ConnectionId    string `json:"connectionId"`
+				ConnectionName  string `json:"connectionName"`
+				ConnectionState string `json:"connectionState"`
+				Location        string `json:"location"`
+				Bandwidth       string `json:"bandwidth"`
+				Vlan            int    `json:"vlan"`
+				ProviderName    string `json:"providerName"`
+				PartnerName     string `json:"partnerName"`
+			} `json:"connections"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, c := range resp.Connections {
+			if c.ConnectionState == "deleted" {
+				continue
+			}
+			provider := c.ProviderName
+			if provider == "" {
+				provider = c.PartnerName
+			}
+			hybrid.DXConnections = append(hybrid.DXConnections, DXConnection{
+				ConnectionId: c.ConnectionId,
+				Name:         c.ConnectionName,
+				State:        c.ConnectionState,
+				Location:     c.Location,
+				Bandwidth:    c.Bandwidth,
+				ProviderName: provider,
+				Vlan:         c.Vlan,
+			})
+		}
+		results = append(results, SyncResult{Service: "dx-connections", Count: len(hybrid.DXConnections)})
+	} else {
+		results = append(results, SyncResult{Service: "dx-connections", Error: err.Error()})
+	}
+
+	if data, err := awscli.Run("directconnect", "describe-virtual-interfaces", "--region", region); err == nil {
+		var resp struct {
+			VirtualInterfaces []struct {
+				VirtualInterfaceId     string `json:"virtualInterfaceId"`
+				VirtualInterfaceName   string `json:"virtualInterfaceName"`
+				VirtualInterfaceType   string `json:"virtualInterfaceType"`
+				VirtualInterfaceState  string `json:"virtualInterfaceState"`
+				ConnectionId           string `json:"connectionId"`
+				Vlan                   int    `json:"vlan"`
+				Asn                    int64  `json:"asn"`
+				AmazonAddress          string `json:"amazonAddress"`
+				CustomerAddress        string `json:"customerAddress"`
+				VirtualGatewayId       string `json:"virtualGatewayId"`
+				DirectConnectGatewayId string `json:"directConnectGatewayId"`
+				BgpPeers               []struct {
+					BgpStatus string `json:"bgpStatus"`
+				} `json:"bgpPeers"`
+			} `json:"virtualInterfaces"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, v := range resp.VirtualInterfaces {
+			if v.VirtualInterfaceState == "deleted" {
+				continue
+			}
+			bgp := ""
+			for _, p := range v.BgpPeers {
+				if p.BgpStatus == "up" {
+					bgp = "up"
+					break
+				}
+				bgp = p.BgpStatus
+			}
+			hybrid.DXInterfaces = append(hybrid.DXInterfaces, DXVirtualInterface{
+				VirtualInterfaceId:     v.VirtualInterfaceId,
+				Name:                   v.VirtualInterfaceName,
+				Type:                   v.VirtualInterfaceType,
+				State:                  v.VirtualInterfaceState,
+				ConnectionId:           v.ConnectionId,
+				Vlan:                   v.Vlan,
+				Asn:                    v.Asn,
+				AmazonAddress:          v.AmazonAddress,
+				CustomerAddress:        v.CustomerAddress,
+				VirtualGatewayId:       v.VirtualGatewayId,
+				DirectConnectGatewayId: v.DirectConnectGatewayId,
+				BGPStatus:              bgp,
+			})
+		}
+	}
+	step("direct connect")
+
+	b, _ := json.Marshal(hybrid)
+	st.WriteCache(region+":hybrid", b)
+	return results, nil
+}
+
+// LoadHybridData returns the cached VPN and Direct Connect resources.
+func LoadHybridData(region string) (*HybridData, error) {
+	raw, err := ReadCache(region + ":hybrid")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var data HybridData
+	json.Unmarshal(raw, &data)
+	return &data, nil
+}
+
+// VPNGatewayLink is a virtual private gateway attached to a VPC with the
+// VPN connections and Direct Connect interfaces that terminate on it.
+type VPNGatewayLink struct {
+	Gateway    VPNGateway
+	VPNs       []VPNConnection
+	Interfaces []DXVirtualInterface
+}
+
+// VPNGatewaysForVPC returns the virtual private gateways attached to a VPC
+// along with what connects to them from on-premises.
+func (d *VPCData) VPNGatewaysForVPC(vpcId string) []VPNGatewayLink {
+	if d.Hybrid == nil {
+		return nil
+	}
+	var out []VPNGatewayLink
+	for _, g := range d.Hybrid.VPNGateways {
+		attached := false
+		for _, id := range g.VpcIds {
+			if id == vpcId {
+				attached = true
+			}
+		}
+		if !attached {
+			continue
+		}
+		link := VPNGatewayLink{Gateway: g}
+		for _, v := range d.Hybrid.VPNConnections {
+			if v.VpnGatewayId == g.VpnGatewayId {
+				link.VPNs = append(link.VPNs, v)
+			}
+		}
+		for _, vif := range d.Hybrid.DXInterfaces {
+			if vif.VirtualGatewayId == g.VpnGatewayId {
+				link.Interfaces = append(link.Interfaces, vif)
+			}
+		}
+		out = append(out, link)
+	}
+	return out
+}
+
+// Empty reports whether nothing hybrid was found.
+func (h *HybridData) Empty() bool {
+	return h == nil || len(h.VPNConnections)+len(h.CustomerGateways)+len(h.VPNGateways)+len(h.DXConnections)+len(h.DXInterfaces) == 0
+}
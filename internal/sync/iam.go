@@ -8,9 +8,17 @@ import (
 	"github.com/estrados/simply-aws/internal/awscli"
 )
 
+// AccessKeyStaleDays is how old, or how long unused, an active access key
+// can be before it is flagged.
+const AccessKeyStaleDays = 90
+
 type IAMData struct {
 	Roles  []IAMRole  `json:"roles"`
 	Groups []IAMGroup `json:"groups"`
+	Users  []IAMUser  `json:"users"`
+
+	// Providers are cached under their own key and attached on load.
+	Providers []IdentityProvider `json:"-"`
 }
 
 type IAMRole struct {
@@ -35,7 +43,78 @@ type IAMGroup struct {
 	Members          []string `json:"Members"`
 }
 
-func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
+// IAMUser is an IAM user with its credentials. Created and
+// PasswordLastUsed are kept as RFC 3339 so their age can be worked out.
+type IAMUser struct {
+	UserName         string         `json:"UserName"`
+	UserId           string         `json:"UserId"`
+	Arn              string         `json:"Arn"`
+	Created          string         `json:"Created"`
+	PasswordLastUsed string         `json:"PasswordLastUsed"`
+	ConsoleAccess    bool           `json:"ConsoleAccess"`
+	MFADevices       []string       `json:"MFADevices"` // serial numbers or ARNs
+	AccessKeys       []IAMAccessKey `json:"AccessKeys"`
+	Groups           []string       `json:"Groups"`
+	AttachedPolicies []string       `json:"AttachedPolicies"`
+	InlinePolicies   []string       `json:"InlinePolicies"`
+
+	AttachedPolicyArns []string `json:"-"`
+}
+
+type IAMAccessKey struct {
+	AccessKeyId     string `json:"AccessKeyId"`
+	Status          string `json:"Status"` // Active or Inactive
+	Created         string `json:"Created"`
+	LastUsed        string `json:"LastUsed"`
+	LastUsedService string `json:"LastUsedService"`
+	LastUsedRegion  string `json:"LastUsedRegion"`
+}
+
+// AgeDays returns whole days since the key was created. -1 when unknown.
+func (k IAMAccessKey) AgeDays(now time.Time) int {
+	return daysSince(now, k.Created)
+}
+
+// IdleDays returns whole days since the key was last used, or since
+// creation when it has never been used. -1 when unknown.
+func (k IAMAccessKey) IdleDays(now time.Time) int {
+	return daysSince(now, k.LastUsed, k.Created)
+}
+
+// Stale reports whether an active key is overdue for rotation or has sat
+// unused for AccessKeyStaleDays.
+func (k IAMAccessKey) Stale(now time.Time) bool {
+	return k.Status == "Active" && (k.AgeDays(now) >= AccessKeyStaleDays || k.IdleDays(now) >= AccessKeyStaleDays)
+}
+
+// StaleKeys returns the user's stale access keys.
+func (u IAMUser) StaleKeys(now time.Time) []IAMAccessKey {
+	var out []IAMAccessKey
+	for _, k := range u.AccessKeys {
+		if k.Stale(now) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// ConsoleWithoutMFA reports whether the user can sign in to the console
+// with a password alone.
+func (u IAMUser) ConsoleWithoutMFA() bool {
+	return u.ConsoleAccess && len(u.MFADevices) == 0
+}
+
+// ConsoleIdleDays returns whole days since the console password was last
+// used, or since the user was created when it never has been. -1 when the
+// user has no console access.
+func (u IAMUser) ConsoleIdleDays(now time.Time) int {
+	if !u.ConsoleAccess {
+		return -1
+	}
+	return daysSince(now, u.PasswordLastUsed, u.Created)
+}
+
+func SyncIAMData(st *Stage, onStep ...func(string)) ([]SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -43,10 +122,13 @@ func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
 	}
 	var results []SyncResult
 	data := &IAMData{}
+	// Policies attached anywhere, fetched once after listing
+	managed := map[string]bool{}
+	var inline []inlinePolicyRef
 
 	// Sync roles
 	if raw, err := awscli.Run("iam", "list-roles"); err == nil {
-		WriteCache("iam:roles", raw)
+		st.WriteCache("iam:roles", raw)
 		var resp struct {
 			Roles []struct {
 				RoleName                 string          `json:"RoleName"`
@@ -86,11 +168,13 @@ func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
 				var polResp struct {
 					AttachedPolicies []struct {
 						PolicyName string `json:"PolicyName"`
+						PolicyArn  string `json:"PolicyArn"`
 					} `json:"AttachedPolicies"`
 				}
 				json.Unmarshal(polData, &polResp)
 				for _, p := range polResp.AttachedPolicies {
 					role.AttachedPolicies = append(role.AttachedPolicies, p.PolicyName)
+					managed[p.PolicyArn] = true
 				}
 			}
 
@@ -101,6 +185,9 @@ func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
 				}
 				json.Unmarshal(polData, &polResp)
 				role.InlinePolicies = polResp.PolicyNames
+				for _, name := range polResp.PolicyNames {
+					inline = append(inline, inlinePolicyRef{"role", r.RoleName, name})
+				}
 			}
 
 			data.Roles = append(data.Roles, role)
@@ -113,7 +200,7 @@ func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
 
 	// Sync groups
 	if raw, err := awscli.Run("iam", "list-groups"); err == nil {
-		WriteCache("iam:groups", raw)
+		st.WriteCache("iam:groups", raw)
 		var resp struct {
 			Groups []struct {
 				GroupName  string `json:"GroupName"`
@@ -137,11 +224,13 @@ func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
 				var polResp struct {
 					AttachedPolicies []struct {
 						PolicyName string `json:"PolicyName"`
+						PolicyArn  string `json:"PolicyArn"`
 					} `json:"AttachedPolicies"`
 				}
 				json.Unmarshal(polData, &polResp)
 				for _, p := range polResp.AttachedPolicies {
 					group.AttachedPolicies = append(group.AttachedPolicies, p.PolicyName)
+					managed[p.PolicyArn] = true
 				}
 			}
 
@@ -152,6 +241,9 @@ func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
 				}
 				json.Unmarshal(polData, &polResp)
 				group.InlinePolicies = polResp.PolicyNames
+				for _, name := range polResp.PolicyNames {
+					inline = append(inline, inlinePolicyRef{"group", g.GroupName, name})
+				}
 			}
 
 			// Members
@@ -175,9 +267,55 @@ func SyncIAMData(onStep ...func(string)) ([]SyncResult, error) {
 	}
 	step("iam groups")
 
+	// Sync users with their credentials
+	if raw, err := awscli.Run("iam", "list-users"); err == nil {
+		var resp struct {
+			Users []json.RawMessage `json:"Users"`
+		}
+		json.Unmarshal(raw, &resp)
+
+		for _, r := range resp.Users {
+			user := parseIAMUser(r)
+			syncIAMUserCredentials(&user)
+			for _, name := range user.InlinePolicies {
+				inline = append(inline, inlinePolicyRef{"user", user.UserName, name})
+			}
+			for _, arn := range user.AttachedPolicyArns {
+				managed[arn] = true
+			}
+			for _, g := range data.Groups {
+				for _, m := range g.Members {
+					if m == user.UserName {
+						user.Groups = append(user.Groups, g.GroupName)
+					}
+				}
+			}
+			data.Users = append(data.Users, user)
+		}
+		results = append(results, SyncResult{Service: "iam-users", Count: len(resp.Users)})
+	} else {
+		results = append(results, SyncResult{Service: "iam-users", Error: err.Error()})
+	}
+	step("iam users")
+
+	if providers, err := syncIdentityProviders(); err == nil {
+		b, _ := json.Marshal(providers)
+		st.WriteCache("iam:identity-providers", b)
+		results = append(results, SyncResult{Service: "iam-identity-providers", Count: len(providers)})
+	} else {
+		results = append(results, SyncResult{Service: "iam-identity-providers", Error: err.Error()})
+	}
+	step("iam identity providers")
+
+	policies := syncIAMPolicies(managed, inline)
+	b, _ := json.Marshal(policies)
+	st.WriteCache("iam:policies", b)
+	results = append(results, SyncResult{Service: "iam-policies", Count: len(policies)})
+	step("iam policies")
+
 	// Cache enriched data
 	enriched, _ := json.Marshal(data)
-	WriteCache("iam:enriched", enriched)
+	st.WriteCache("iam:enriched", enriched)
 
 	return results, nil
 }
@@ -189,9 +327,107 @@ func LoadIAMData() (*IAMData, error) {
 	}
 	var data IAMData
 	json.Unmarshal(raw, &data)
+	data.Providers = loadIdentityProviders()
 	return &data, nil
 }
 
+// syncIAMUserCredentials fills in a user's access keys, MFA devices,
+// console password and policies.
+func syncIAMUserCredentials(user *IAMUser) {
+	if keyData, err := awscli.Run("iam", "list-access-keys", "--user-name", user.UserName); err == nil {
+		var keyResp struct {
+			AccessKeyMetadata []struct {
+				AccessKeyId string `json:"AccessKeyId"`
+				Status      string `json:"Status"`
+				CreateDate  string `json:"CreateDate"`
+			} `json:"AccessKeyMetadata"`
+		}
+		json.Unmarshal(keyData, &keyResp)
+		for _, k := range keyResp.AccessKeyMetadata {
+			key := IAMAccessKey{AccessKeyId: k.AccessKeyId, Status: k.Status, Created: k.CreateDate}
+			if usedData, err := awscli.Run("iam", "get-access-key-last-used", "--access-key-id", k.AccessKeyId); err == nil {
+				var usedResp struct {
+					AccessKeyLastUsed struct {
+						LastUsedDate string `json:"LastUsedDate"`
+						ServiceName  string `json:"ServiceName"`
+						Region       string `json:"Region"`
+					} `json:"AccessKeyLastUsed"`
+				}
+				json.Unmarshal(usedData, &usedResp)
+				used := usedResp.AccessKeyLastUsed
+				key.LastUsed = used.LastUsedDate
+				// AWS reports "N/A" for keys that have never been used
+				if used.ServiceName != "N/A" {
+					key.LastUsedService = used.ServiceName
+				}
+				if used.Region != "N/A" {
+					key.LastUsedRegion = used.Region
+				}
+			}
+			user.AccessKeys = append(user.AccessKeys, key)
+		}
+	}
+
+	if mfaData, err := awscli.Run("iam", "list-mfa-devices", "--user-name", user.UserName); err == nil {
+		var mfaResp struct {
+			MFADevices []struct {
+				SerialNumber string `json:"SerialNumber"`
+			} `json:"MFADevices"`
+		}
+		json.Unmarshal(mfaData, &mfaResp)
+		for _, d := range mfaResp.MFADevices {
+			user.MFADevices = append(user.MFADevices, d.SerialNumber)
+		}
+	}
+
+	// get-login-profile fails with NoSuchEntity when the user has no
+	// console password.
+	if _, err := awscli.Run("iam", "get-login-profile", "--user-name", user.UserName); err == nil {
+		user.ConsoleAccess = true
+	}
+
+	if polData, err := awscli.Run("iam", "list-attached-user-policies", "--user-name", user.UserName); err == nil {
+		var polResp struct {
+			AttachedPolicies []struct {
+				PolicyName string `json:"PolicyName"`
+				PolicyArn  string `json:"PolicyArn"`
+			} `json:"AttachedPolicies"`
+		}
+		json.Unmarshal(polData, &polResp)
+		for _, p := range polResp.AttachedPolicies {
+			user.AttachedPolicies = append(user.AttachedPolicies, p.PolicyName)
+			user.AttachedPolicyArns = append(user.AttachedPolicyArns, p.PolicyArn)
+		}
+	}
+	if polData, err := awscli.Run("iam", "list-user-policies", "--user-name", user.UserName); err == nil {
+		var polResp struct {
+			PolicyNames []string `json:"PolicyNames"`
+		}
+		json.Unmarshal(polData, &polResp)
+		user.InlinePolicies = polResp.PolicyNames
+	}
+}
+
+// parseIAMUser reads one list-users entry; credentials and group
+// membership are filled in separately.
+func parseIAMUser(raw json.RawMessage) IAMUser {
+	var u struct {
+		UserName         string `json:"UserName"`
+		UserId           string `json:"UserId"`
+		Arn              string `json:"Arn"`
+		CreateDate       string `json:"CreateDate"`
+		PasswordLastUsed string `json:"PasswordLastUsed"`
+	}
+	json.Unmarshal(raw, &u)
+	return IAMUser{
+		UserName:         u.UserName,
+		UserId:           u.UserId,
+		Arn:              u.Arn,
+		Created:          u.CreateDate,
+		PasswordLastUsed: u.PasswordLastUsed,
+	}
+}
+
 func formatIAMDate(s string) string {
 	if t, err := time.Parse(time.RFC3339, s); err == nil {
 		return t.Format("2006-01-02 15:04")
@@ -0,0 +1,135 @@
+package sync
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestSyncIAMDataUsers(t *testing.T) {
+	fake := awscli.NewFake().
+		On("iam list-roles", `{"Roles": []}`).
+		On("iam list-groups", `{"Groups": [{"GroupName": "deployers"}]}`).
+		On("iam get-group", `{"Users": [{"UserName": "deploy-bot"}]}`).
+		On("iam list-users", `{"Users": [{"UserName": "deploy-bot", "CreateDate": "2023-03-14T10:22:31+00:00"}]}`).
+		On("iam list-access-keys", `{"AccessKeyMetadata": [
+			{"AccessKeyId": "AKIAOLD", "Status": "Active", "CreateDate": "2023-03-14T10:25:00+00:00"},
+			{"AccessKeyId": "AKIANEW", "Status": "Active", "CreateDate": "2024-04-20T09:00:00+00:00"}]}`).
+		On("iam get-access-key-last-used", `{"AccessKeyLastUsed": {"ServiceName": "N/A", "Region": "N/A"}}`).
+		On("iam get-access-key-last-used --access-key-id AKIANEW", `{"AccessKeyLastUsed": {"LastUsedDate": "2024-05-01T12:00:00+00:00", "ServiceName": "s3", "Region": "us-east-1"}}`).
+		On("iam list-mfa-devices", `{"MFADevices": []}`).
+		On("iam get-login-profile", `{"LoginProfile": {"UserName": "deploy-bot"}}`).
+		Fail("iam list-attached-user-policies", errors.New("AccessDenied"))
+	defer awscli.Use(fake)()
+
+	results, err := SyncIAMData(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := resultFor(results, "iam-users"); r.Count != 1 || r.Error != "" {
+		t.Fatalf("results = %+v, want 1 iam user", results)
+	}
+
+	data, _ := LoadIAMData()
+	u := data.Users[0]
+	if len(u.Groups) != 1 || u.Groups[0] != "deployers" {
+		t.Errorf("groups = %v, want [deployers]", u.Groups)
+	}
+	if !u.ConsoleWithoutMFA() {
+		t.Error("console user without MFA not flagged")
+	}
+
+	now := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	stale := u.StaleKeys(now)
+	if len(stale) != 1 || stale[0].AccessKeyId != "AKIAOLD" {
+		t.Errorf("stale keys = %+v, want AKIAOLD only", stale)
+	}
+	if k := u.AccessKeys[0]; k.LastUsedService != "" || k.IdleDays(now) != k.AgeDays(now) {
+		t.Errorf("never-used key = %+v", k)
+	}
+	if k := u.AccessKeys[1]; k.LastUsedService != "s3" || k.IdleDays(now) != 8 {
+		t.Errorf("used key = %+v, idle %d", k, k.IdleDays(now))
+	}
+}
+
+func TestSyncIAMDataPolicies(t *testing.T) {
+	fake := awscli.NewFake().
+		On("iam list-roles", `{"Roles": [{"RoleName": "deploy-role"}]}`).
+		On("iam list-attached-role-policies", `{"AttachedPolicies": [{"PolicyName": "ReadOnlyAccess", "PolicyArn": "arn:aws:iam::aws:policy/ReadOnlyAccess"}]}`).
+		On("iam list-role-policies", `{"PolicyNames": ["artifacts"]}`).
+		On("iam list-groups", `{"Groups": []}`).
+		On("iam list-users", `{"Users": []}`).
+		On("iam get-policy", `{"Policy": {"PolicyName": "ReadOnlyAccess", "DefaultVersionId": "v112"}}`).
+		On("iam get-policy-version", `{"PolicyVersion": {"VersionId": "v112", "Document": {"Statement": {"Effect": "Allow", "Action": "s3:Get*", "Resource": "*"}}}}`).
+		// IAM returns inline documents URL-encoded.
+		On("iam get-role-policy", `{"PolicyDocument": "%7B%22Statement%22%3A%5B%7B%22Effect%22%3A%22Allow%22%2C%22Action%22%3A%22s3%3APutObject%22%2C%22Resource%22%3A%22arn%3Aaws%3As3%3A%3A%3Aartifacts%2F%2A%22%7D%5D%7D"}`)
+	defer awscli.Use(fake)()
+
+	results, _ := SyncIAMData(nil)
+	if r := resultFor(results, "iam-policies"); r.Count != 2 {
+		t.Fatalf("results = %+v, want 2 iam policies", results)
+	}
+	if !fake.Called("iam get-policy-version --policy-arn arn:aws:iam::aws:policy/ReadOnlyAccess --version-id v112") {
+		t.Error("default policy version not fetched")
+	}
+
+	policies, _ := LoadIAMPolicies()
+	byID := map[string]IAMPolicy{}
+	for _, p := range policies {
+		byID[p.ID()] = p
+	}
+	managed := byID["ReadOnlyAccess"]
+	if managed.Kind != "AWS managed" || len(managed.Statements) != 1 || managed.Statements[0].Actions[0] != "s3:Get*" {
+		t.Errorf("managed policy = %+v", managed)
+	}
+	inline := byID["role/deploy-role/artifacts"]
+	if len(inline.Statements) != 1 || inline.Statements[0].Resources[0] != "arn:aws:s3:::artifacts/*" {
+		t.Errorf("inline policy = %+v", inline)
+	}
+}
+
+func resultFor(results []SyncResult, service string) SyncResult {
+	for _, r := range results {
+		if r.Service == service {
+			return r
+		}
+	}
+	return SyncResult{}
+}
+
+func TestLoadIAMDataIdentityProviders(t *testing.T) {
+	github := "arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com"
+	fake := awscli.NewFake().
+		OnFile("iam list-roles", filepath.Join(testdata, "iam-roles-federated.json")).
+		On("iam list-groups", `{"Groups": []}`).
+		On("iam list-users", `{"Users": []}`).
+		On("iam list-open-id-connect-providers", `{"OpenIDConnectProviderList": [{"Arn": "`+github+`"}]}`).
+		OnFile("iam get-open-id-connect-provider", filepath.Join(testdata, "parse", "parseOIDCProvider.json")).
+		On("iam list-saml-providers", `{"SAMLProviderList": [{"Arn": "arn:aws:iam::123456789012:saml-provider/Okta"}]}`)
+	defer awscli.Use(fake)()
+
+	results, _ := SyncIAMData(nil)
+	if r := resultFor(results, "iam-identity-providers"); r.Count != 2 || r.Error != "" {
+		t.Fatalf("results = %+v, want 2 identity providers", results)
+	}
+
+	data, _ := LoadIAMData()
+	if len(data.Providers) != 2 {
+		t.Fatalf("providers = %+v", data.Providers)
+	}
+	oidc := data.Providers[0]
+	if oidc.Name != "token.actions.githubusercontent.com" || len(oidc.TrustingRoles) != 1 || oidc.TrustingRoles[0].RoleName != "github-deploy" {
+		t.Errorf("OIDC provider = %+v", oidc)
+	}
+	if len(oidc.TrustingRoles[0].Conditions) != 2 {
+		t.Errorf("conditions = %v", oidc.TrustingRoles[0].Conditions)
+	}
+	// The SAML trust is URL-encoded, as IAM returns it.
+	saml := data.Providers[1]
+	if saml.Name != "Okta" || len(saml.TrustingRoles) != 1 || saml.TrustingRoles[0].RoleName != "okta-admin" {
+		t.Errorf("SAML provider = %+v", saml)
+	}
+}
@@ -0,0 +1,204 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// IAMPolicy is a managed policy's default version, or an inline policy,
+// with its document broken into statements.
+type IAMPolicy struct {
+	Name       string            `json:"Name"`
+	Arn        string            `json:"Arn"`   // managed policies only
+	Kind       string            `json:"Kind"`  // "AWS managed", "customer managed" or "inline"
+	Owner      string            `json:"Owner"` // inline policies only, e.g. "role/web-role"
+	VersionId  string            `json:"VersionId"`
+	Statements []PolicyStatement `json:"Statements"`
+	Document   string            `json:"Document"` // indented JSON
+}
+
+// ID names the policy in detail URLs: the policy name for managed
+// policies, "<owner>/<name>" for inline ones. Policy names cannot contain
+// a slash, so the two never collide.
+func (p IAMPolicy) ID() string {
+	if p.Owner != "" {
+		return p.Owner + "/" + p.Name
+	}
+	return p.Name
+}
+
+type PolicyStatement struct {
+	Sid          string   `json:"Sid"`
+	Effect       string   `json:"Effect"`
+	Actions      []string `json:"Actions"`
+	NotActions   []string `json:"NotActions"`
+	Resources    []string `json:"Resources"`
+	NotResources []string `json:"NotResources"`
+	Conditions   []string `json:"Conditions"` // e.g. "StringEquals aws:SourceVpc = vpc-0a1b2c3d"
+}
+
+// inlinePolicyRef is an inline policy found while listing roles, groups
+// and users, fetched once listing is done.
+type inlinePolicyRef struct {
+	kind, owner, name string // kind is role, group or user
+}
+
+// syncIAMPolicies fetches the default version of every attached managed
+// policy and the document of every inline policy.
+func syncIAMPolicies(managed map[string]bool, inline []inlinePolicyRef) []IAMPolicy {
+	arns := make([]string, 0, len(managed))
+	for arn := range managed {
+		arns = append(arns, arn)
+	}
+	sort.Strings(arns)
+
+	policies := []IAMPolicy{}
+	for _, arn := range arns {
+		data, err := awscli.Run("iam", "get-policy", "--policy-arn", arn)
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			Policy struct {
+				PolicyName       string `json:"PolicyName"`
+				DefaultVersionId string `json:"DefaultVersionId"`
+			} `json:"Policy"`
+		}
+		json.Unmarshal(data, &resp)
+		policy := IAMPolicy{Name: resp.Policy.PolicyName, Arn: arn, Kind: "customer managed", VersionId: resp.Policy.DefaultVersionId}
+		if strings.Contains(arn, ":aws:policy/") {
+			policy.Kind = "AWS managed"
+		}
+		if version, err := awscli.Run("iam", "get-policy-version", "--policy-arn", arn, "--version-id", policy.VersionId); err == nil {
+			var vResp struct {
+				PolicyVersion struct {
+					Document json.RawMessage `json:"Document"`
+				} `json:"PolicyVersion"`
+			}
+			json.Unmarshal(version, &vResp)
+			policy.Statements, policy.Document = parseIAMPolicyDocument(vResp.PolicyVersion.Document)
+		}
+		policies = append(policies, policy)
+	}
+
+	for _, ref := range inline {
+		data, err := awscli.Run("iam", "get-"+ref.kind+"-policy", "--"+ref.kind+"-name", ref.owner, "--policy-name", ref.name)
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			PolicyDocument json.RawMessage `json:"PolicyDocument"`
+		}
+		json.Unmarshal(data, &resp)
+		policy := IAMPolicy{Name: ref.name, Kind: "inline", Owner: ref.kind + "/" + ref.owner}
+		policy.Statements, policy.Document = parseIAMPolicyDocument(resp.PolicyDocument)
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+// LoadIAMPolicies returns the cached policy documents.
+func LoadIAMPolicies() ([]IAMPolicy, error) {
+	raw, err := ReadCache("iam:policies")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var policies []IAMPolicy
+	json.Unmarshal(raw, &policies)
+	return policies, nil
+}
+
+// parseIAMPolicyDocument reads a policy document, given as JSON or as the
+// URL-encoded string the IAM API returns, into statements and an indented
+// copy of the document.
+func parseIAMPolicyDocument(raw json.RawMessage) ([]PolicyStatement, string) {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		if decoded, err := url.QueryUnescape(encoded); err == nil {
+			encoded = decoded
+		}
+		raw = json.RawMessage(encoded)
+	}
+
+	var doc struct {
+		Statement json.RawMessage `json:"Statement"`
+	}
+	json.Unmarshal(raw, &doc)
+	// A single statement may be given as an object instead of a list.
+	var statements []json.RawMessage
+	if err := json.Unmarshal(doc.Statement, &statements); err != nil && len(doc.Statement) > 0 {
+		statements = []json.RawMessage{doc.Statement}
+	}
+
+	var out []PolicyStatement
+	for _, s := range statements {
+		var st struct {
+			Sid         string                            `json:"Sid"`
+			Effect      string                            `json:"Effect"`
+			Action      interface{}                       `json:"Action"`
+			NotAction   interface{}                       `json:"NotAction"`
+			Resource    interface{}                       `json:"Resource"`
+			NotResource interface{}                       `json:"NotResource"`
+			Condition   map[string]map[string]interface{} `json:"Condition"`
+		}
+		json.Unmarshal(s, &st)
+		stmt := PolicyStatement{
+			Sid:          st.Sid,
+			Effect:       st.Effect,
+			Actions:      policyStrings(st.Action),
+			NotActions:   policyStrings(st.NotAction),
+			Resources:    policyStrings(st.Resource),
+			NotResources: policyStrings(st.NotResource),
+		}
+		stmt.Conditions = policyConditions(st.Condition)
+		out = append(out, stmt)
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, raw, "", "  "); err != nil {
+		return out, ""
+	}
+	return out, indented.String()
+}
+
+// policyConditions renders a statement's Condition block, one
+// "<operator> <key> = <values>" line per key, sorted.
+func policyConditions(cond map[string]map[string]interface{}) []string {
+	var out []string
+	for op, keys := range cond {
+		for key, v := range keys {
+			out = append(out, op+" "+key+" = "+strings.Join(policyStrings(v), ", "))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// policyStrings reads a policy element that may be a single value or a
+// list of values.
+func policyStrings(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case bool:
+		if t {
+			return []string{"true"}
+		}
+		return []string{"false"}
+	case float64:
+		b, _ := json.Marshal(t)
+		return []string{string(b)}
+	case []interface{}:
+		var out []string
+		for _, item := range t {
+			out = append(out, policyStrings(item)...)
+		}
+		return out
+	}
+	return nil
+}
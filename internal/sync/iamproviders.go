@@ -0,0 +1,173 @@
+package sync
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// IdentityProvider is an IAM OIDC or SAML identity provider, with the
+// roles whose trust policies let it assume them.
+type IdentityProvider struct {
+	Arn           string          `json:"Arn"`
+	Type          string          `json:"Type"` // OIDC or SAML
+	Name          string          `json:"Name"` // issuer URL for OIDC, provider name for SAML
+	Audiences     []string        `json:"Audiences"`
+	Thumbprints   []string        `json:"Thumbprints"`
+	ValidUntil    string          `json:"ValidUntil"` // SAML metadata expiry
+	Created       string          `json:"Created"`
+	TrustingRoles []ProviderTrust `json:"-"` // resolved from cached trust policies on load
+}
+
+// ProviderTrust is a role that federated users of a provider can assume,
+// with the trust policy conditions that narrow who, e.g.
+// "StringLike token.actions.githubusercontent.com:sub = repo:acme/web:*".
+type ProviderTrust struct {
+	RoleName   string   `json:"RoleName"`
+	Conditions []string `json:"Conditions"`
+}
+
+// FederatedTrust is one Allow statement in a trust policy naming a
+// federated principal.
+type FederatedTrust struct {
+	Provider   string   `json:"Provider"` // provider ARN, or a service like cognito-identity.amazonaws.com
+	Conditions []string `json:"Conditions"`
+}
+
+// syncIdentityProviders fetches OIDC and SAML identity providers.
+func syncIdentityProviders() ([]IdentityProvider, error) {
+	oidc, err := awscli.Run("iam", "list-open-id-connect-providers")
+	if err != nil {
+		return nil, err
+	}
+	var oidcResp struct {
+		OpenIDConnectProviderList []struct {
+			Arn string `json:"Arn"`
+		} `json:"OpenIDConnectProviderList"`
+	}
+	json.Unmarshal(oidc, &oidcResp)
+
+	providers := []IdentityProvider{}
+	for _, p := range oidcResp.OpenIDConnectProviderList {
+		provider := IdentityProvider{Arn: p.Arn, Type: "OIDC", Name: p.Arn[strings.Index(p.Arn, "/")+1:]}
+		if desc, err := awscli.Run("iam", "get-open-id-connect-provider", "--open-id-connect-provider-arn", p.Arn); err == nil {
+			provider = parseOIDCProvider(desc, p.Arn)
+		}
+		providers = append(providers, provider)
+	}
+
+	if saml, err := awscli.Run("iam", "list-saml-providers"); err == nil {
+		var samlResp struct {
+			SAMLProviderList []json.RawMessage `json:"SAMLProviderList"`
+		}
+		json.Unmarshal(saml, &samlResp)
+		for _, raw := range samlResp.SAMLProviderList {
+			providers = append(providers, parseSAMLProvider(raw))
+		}
+	}
+	return providers, nil
+}
+
+// loadIdentityProviders returns the cached identity providers, each with
+// the roles that trust it, read from the cached list-roles output.
+func loadIdentityProviders() []IdentityProvider {
+	raw, err := ReadCache("iam:identity-providers")
+	if err != nil || raw == nil {
+		return nil
+	}
+	var providers []IdentityProvider
+	json.Unmarshal(raw, &providers)
+
+	trusts := map[string][]ProviderTrust{}
+	if rolesRaw, err := ReadCache("iam:roles"); err == nil && rolesRaw != nil {
+		var resp struct {
+			Roles []struct {
+				RoleName                 string          `json:"RoleName"`
+				AssumeRolePolicyDocument json.RawMessage `json:"AssumeRolePolicyDocument"`
+			} `json:"Roles"`
+		}
+		json.Unmarshal(rolesRaw, &resp)
+		for _, r := range resp.Roles {
+			for _, t := range parseFederatedTrust(r.AssumeRolePolicyDocument) {
+				trusts[t.Provider] = append(trusts[t.Provider], ProviderTrust{RoleName: r.RoleName, Conditions: t.Conditions})
+			}
+		}
+	}
+	for i := range providers {
+		providers[i].TrustingRoles = trusts[providers[i].Arn]
+	}
+	return providers
+}
+
+func parseOIDCProvider(raw json.RawMessage, arn string) IdentityProvider {
+	var p struct {
+		Url            string   `json:"Url"`
+		ClientIDList   []string `json:"ClientIDList"`
+		ThumbprintList []string `json:"ThumbprintList"`
+		CreateDate     string   `json:"CreateDate"`
+	}
+	json.Unmarshal(raw, &p)
+	return IdentityProvider{
+		Arn:         arn,
+		Type:        "OIDC",
+		Name:        p.Url,
+		Audiences:   p.ClientIDList,
+		Thumbprints: p.ThumbprintList,
+		Created:     formatIAMDate(p.CreateDate),
+	}
+}
+
+// parseSAMLProvider reads one list-saml-providers entry.
+func parseSAMLProvider(raw json.RawMessage) IdentityProvider {
+	var p struct {
+		Arn        string `json:"Arn"`
+		ValidUntil string `json:"ValidUntil"`
+		CreateDate string `json:"CreateDate"`
+	}
+	json.Unmarshal(raw, &p)
+	return IdentityProvider{
+		Arn:        p.Arn,
+		Type:       "SAML",
+		Name:       p.Arn[strings.Index(p.Arn, "/")+1:],
+		ValidUntil: formatIAMDate(p.ValidUntil),
+		Created:    formatIAMDate(p.CreateDate),
+	}
+}
+
+// parseFederatedTrust reads a role's trust policy, as JSON or URL-encoded,
+// and returns the federated principals its Allow statements name.
+func parseFederatedTrust(raw json.RawMessage) []FederatedTrust {
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		if decoded, err := url.QueryUnescape(encoded); err == nil {
+			encoded = decoded
+		}
+		raw = json.RawMessage(encoded)
+	}
+	var doc struct {
+		Statement []struct {
+			Effect    string `json:"Effect"`
+			Principal struct {
+				Federated interface{} `json:"Federated"`
+			} `json:"Principal"`
+			Condition map[string]map[string]interface{} `json:"Condition"`
+		} `json:"Statement"`
+	}
+	json.Unmarshal(raw, &doc)
+
+	var out []FederatedTrust
+	for _, s := range doc.Statement {
+		if s.Effect != "Allow" {
+			continue
+		}
+		providers := policyStrings(s.Principal.Federated)
+		sort.Strings(providers)
+		for _, p := range providers {
+			out = append(out, FederatedTrust{Provider: p, Conditions: policyConditions(s.Condition)})
+		}
+	}
+	return out
+}
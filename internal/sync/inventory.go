@@ -0,0 +1,764 @@
+package sync
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InventoryItem is a flattened view of one cached resource. Type and ID
+// match the /detail/{type}/{id} routes used by the web UI.
+type InventoryItem struct {
+	Type    string            `json:"type"`
+	ID      string            `json:"id"`
+	Name    string            `json:"name"`
+	Region  string            `json:"region"`
+	Arn     string            `json:"arn,omitempty"`
+	VpcId   string            `json:"vpcId,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Refs    []string          `json:"refs,omitempty"` // "type/id" keys this resource depends on
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Key returns the "type/id" identifier used in Refs.
+func (it InventoryItem) Key() string {
+	return it.Type + "/" + it.ID
+}
+
+// Label returns the name if set, otherwise the ID.
+func (it InventoryItem) Label() string {
+	if it.Name != "" && it.Name != it.ID {
+		return it.Name + " (" + it.ID + ")"
+	}
+	return it.ID
+}
+
+// LoadInventories loads the inventory of each region. Global resources
+// like IAM roles and S3 buckets appear in every region's inventory and
+// are kept once.
+func LoadInventories(regions []string) ([]InventoryItem, error) {
+	var items []InventoryItem
+	seen := map[string]bool{}
+	for _, region := range regions {
+		inv, err := LoadInventory(region)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range inv {
+			key := it.Region + "/" + it.Key()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			items = append(items, it)
+		}
+	}
+	return items, nil
+}
+
+// LoadInventory flattens every cached resource for a region (plus global
+// S3 and IAM data, and the resources of enabled providers) into a single
+// list sorted by type and ID. Resources excluded in .saws.yaml are left
+// out.
+func LoadInventory(region string) ([]InventoryItem, error) {
+	var items []InventoryItem
+	cfg := projectConfig()
+	add := func(it InventoryItem) {
+		if it.Region == "" {
+			it.Region = region
+		}
+		if cfg.Excludes(it) {
+			return
+		}
+		items = append(items, it)
+	}
+	ref := func(refs []string, typ, id string) []string {
+		if id == "" {
+			return refs
+		}
+		return append(refs, typ+"/"+id)
+	}
+	sgRefs := func(refs []string, ids []string) []string {
+		for _, id := range ids {
+			refs = ref(refs, "sg", id)
+		}
+		return refs
+	}
+
+	if vpc, err := LoadVPCData(region); err == nil && vpc != nil {
+		for _, v := range vpc.VPCs {
+			add(InventoryItem{Type: "vpc", ID: v.VpcId, Name: v.Name, VpcId: v.VpcId,
+				Details: map[string]string{"CIDR": v.CidrBlock, "State": v.State}})
+		}
+		for _, s := range vpc.Subnets {
+			add(InventoryItem{Type: "subnet", ID: s.SubnetId, Name: s.Name, VpcId: s.VpcId,
+				Refs:    ref(nil, "vpc", s.VpcId),
+				Details: map[string]string{"CIDR": s.CidrBlock, "AZ": s.AvailabilityZone}})
+		}
+		for _, g := range vpc.IGWs {
+			var refs []string
+			for _, id := range g.AttachedVpcIds {
+				refs = ref(refs, "vpc", id)
+			}
+			add(InventoryItem{Type: "igw", ID: g.InternetGatewayId, Name: g.Name, Refs: refs})
+		}
+		for _, n := range vpc.NATGWs {
+			add(InventoryItem{Type: "natgw", ID: n.NatGatewayId, Name: n.Name, VpcId: n.VpcId,
+				Refs:    ref(ref(nil, "vpc", n.VpcId), "subnet", n.SubnetId),
+				Details: map[string]string{"State": n.State}})
+		}
+		for _, rt := range vpc.RouteTables {
+			refs := ref(nil, "vpc", rt.VpcId)
+			for _, id := range rt.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			add(InventoryItem{Type: "rt", ID: rt.RouteTableId, Name: rt.Name, VpcId: rt.VpcId, Refs: refs})
+		}
+		for _, sg := range vpc.SecurityGroups {
+			add(InventoryItem{Type: "sg", ID: sg.GroupId, Name: sg.GroupName, VpcId: sg.VpcId,
+				Refs:    ref(nil, "vpc", sg.VpcId),
+				Details: map[string]string{"Inbound rules": fmt.Sprint(sg.InboundCount), "Outbound rules": fmt.Sprint(sg.OutboundCount)}})
+		}
+		for _, acl := range vpc.NetworkACLs {
+			refs := ref(nil, "vpc", acl.VpcId)
+			for _, id := range acl.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			add(InventoryItem{Type: "nacl", ID: acl.NetworkAclId, Name: acl.Name, VpcId: acl.VpcId, Refs: refs,
+				Details: map[string]string{"Default": fmt.Sprint(acl.IsDefault), "Inbound rules": fmt.Sprint(len(acl.Inbound())), "Outbound rules": fmt.Sprint(len(acl.Outbound()))}})
+		}
+		for _, f := range vpc.FlowLogs {
+			vpcId := ""
+			if f.ResourceType() == "vpc" {
+				vpcId = f.ResourceId
+			}
+			add(InventoryItem{Type: "flowlog", ID: f.FlowLogId, Name: f.Destination, VpcId: vpcId,
+				Refs:    ref(nil, f.ResourceType(), f.ResourceId),
+				Details: map[string]string{"Status": f.Status, "Traffic": f.TrafficType, "Destination type": f.DestinationType, "Custom format": fmt.Sprint(f.CustomFormat())}})
+		}
+		lbByArn := map[string]string{}
+		for _, lb := range vpc.LoadBalancers {
+			lbByArn[lb.Arn] = lb.Name
+			add(InventoryItem{Type: "lb", ID: lb.Name, Name: lb.Name, Arn: lb.Arn, VpcId: lb.VpcId,
+				Refs:    sgRefs(ref(nil, "vpc", lb.VpcId), lb.SecurityGroups),
+				Details: map[string]string{"Type": lb.Type, "Scheme": lb.Scheme, "DNS": lb.DNSName}})
+		}
+		for _, tg := range vpc.TargetGroups {
+			add(InventoryItem{Type: "tg", ID: tg.Name, Name: tg.Name, Arn: tg.Arn, VpcId: tg.VpcId,
+				Refs:    ref(ref(nil, "vpc", tg.VpcId), "lb", lbByArn[tg.LoadBalancerArn]),
+				Details: map[string]string{"Protocol": tg.Protocol, "Port": fmt.Sprint(tg.Port)}})
+		}
+		for _, p := range vpc.Peerings {
+			refs := ref(ref(nil, "vpc", p.Requester.VpcId), "vpc", p.Accepter.VpcId)
+			add(InventoryItem{Type: "pcx", ID: p.PeeringId, Name: p.Name, Refs: refs,
+				Details: map[string]string{"Status": p.Status, "Requester": p.Requester.VpcId + " " + p.Requester.CidrBlock, "Accepter": p.Accepter.VpcId + " " + p.Accepter.CidrBlock}})
+		}
+		for _, e := range vpc.Endpoints {
+			refs := sgRefs(ref(nil, "vpc", e.VpcId), e.SecurityGroups)
+			for _, id := range e.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			for _, id := range e.RouteTableIds {
+				refs = ref(refs, "rt", id)
+			}
+			add(InventoryItem{Type: "vpce", ID: e.VpcEndpointId, Name: e.Name, VpcId: e.VpcId, Refs: refs,
+				Details: map[string]string{"Service": e.ServiceName, "Type": e.Type, "State": e.State}})
+		}
+		for _, g := range vpc.TransitGateways {
+			var refs []string
+			for _, a := range g.Attachments {
+				if a.ResourceType == "vpc" {
+					refs = ref(refs, "vpc", a.ResourceId)
+				}
+			}
+			add(InventoryItem{Type: "tgw", ID: g.TransitGatewayId, Name: g.Name, Arn: g.Arn, Refs: refs,
+				Details: map[string]string{"State": g.State, "Owner": g.OwnerId, "Attachments": fmt.Sprint(len(g.Attachments)), "Route tables": fmt.Sprint(len(g.RouteTables))}})
+		}
+		if h := vpc.Hybrid; h != nil {
+			for _, g := range h.VPNGateways {
+				var refs []string
+				for _, id := range g.VpcIds {
+					refs = ref(refs, "vpc", id)
+				}
+				add(InventoryItem{Type: "vgw", ID: g.VpnGatewayId, Name: g.Name, Refs: refs,
+					Details: map[string]string{"State": g.State, "Amazon side ASN": fmt.Sprint(g.AmazonSideAsn)}})
+			}
+			for _, v := range h.VPNConnections {
+				refs := ref(ref(nil, "vgw", v.VpnGatewayId), "tgw", v.TransitGatewayId)
+				add(InventoryItem{Type: "vpn", ID: v.VpnConnectionId, Name: v.Name, Refs: refs,
+					Details: map[string]string{"State": v.State, "Customer gateway": v.CustomerGatewayId, "Tunnels up": fmt.Sprintf("%d/%d", v.TunnelsUp(), len(v.Tunnels))}})
+			}
+			for _, c := range h.DXConnections {
+				add(InventoryItem{Type: "dx", ID: c.ConnectionId, Name: c.Name,
+					Details: map[string]string{"State": c.State, "Location": c.Location, "Bandwidth": c.Bandwidth, "Virtual interfaces": fmt.Sprint(len(h.InterfacesOn(c.ConnectionId)))}})
+			}
+		}
+		if vpc.WAF != nil {
+			for _, acl := range vpc.WAF.WebACLs {
+				var refs []string
+				for _, arn := range acl.Resources {
+					refs = ref(refs, "lb", lbByArn[arn])
+				}
+				add(InventoryItem{Type: "waf", ID: acl.Id, Name: acl.Name, Arn: acl.Arn, Refs: refs,
+					Details: map[string]string{"Scope": acl.Scope, "Rules": fmt.Sprint(len(acl.Rules)), "Default action": acl.DefaultAction}})
+			}
+		}
+		for _, c := range vpc.Certificates {
+			expires := ""
+			if !c.ExpiresAt().IsZero() {
+				expires = c.ExpiresAt().Format("2006-01-02")
+			}
+			add(InventoryItem{Type: "acm", ID: c.CertificateArn, Name: c.DomainName, Arn: c.CertificateArn,
+				Details: map[string]string{"Status": c.Status, "Expires": expires, "In use by": strings.Join(c.InUseBy, ", ")}})
+		}
+	}
+
+	if c, err := LoadComputeData(region); err == nil && c != nil {
+		for _, inst := range c.EC2 {
+			refs := ref(ref(nil, "vpc", inst.VpcId), "subnet", inst.SubnetId)
+			refs = ref(sgRefs(refs, inst.SecurityGroups), "iam-role", inst.IamRole)
+			refs = ref(refs, "asg", inst.AutoScalingGroup)
+			add(InventoryItem{Type: "ec2", ID: inst.InstanceId, Name: inst.Name, VpcId: inst.VpcId,
+				Tags: inst.Tags, Refs: refs,
+				Details: map[string]string{"Instance type": inst.InstanceType, "State": inst.State, "Image": inst.ImageId,
+					"Private IP": inst.PrivateIP, "Public IP": inst.PublicIP}})
+		}
+		if asg := c.AutoScaling; asg != nil {
+			for _, g := range asg.Groups {
+				var refs []string
+				for _, id := range g.SubnetIds {
+					refs = ref(refs, "subnet", id)
+				}
+				for _, tg := range g.TargetGroupNames() {
+					refs = ref(refs, "tg", tg)
+				}
+				add(InventoryItem{Type: "asg", ID: g.Name, Name: g.Name, Arn: g.Arn, Refs: refs,
+					Details: map[string]string{"Capacity": fmt.Sprintf("%d/%d/%d", g.DesiredCapacity, g.MinSize, g.MaxSize),
+						"Launch": g.LaunchSource(), "Instances": strconv.Itoa(len(g.Instances))}})
+			}
+		}
+		mesh, _ := LoadServiceMeshData(region)
+		for _, cl := range c.ECS {
+			add(InventoryItem{Type: "ecs", ID: cl.ClusterName, Name: cl.ClusterName, Arn: cl.ClusterArn,
+				Details: map[string]string{"Status": cl.Status, "Services": fmt.Sprint(cl.Services), "Running tasks": fmt.Sprint(cl.RunningTasks)}})
+			for _, svc := range cl.ECSServices {
+				refs := ref(nil, "ecs", cl.ClusterName)
+				for _, id := range svc.SubnetIds {
+					refs = ref(refs, "subnet", id)
+				}
+				refs = sgRefs(refs, svc.SecurityGroups)
+				for _, tg := range svc.TargetGroupNames() {
+					refs = ref(refs, "tg", tg)
+				}
+				details := map[string]string{"Status": svc.Status, "Tasks": fmt.Sprintf("%d/%d", svc.RunningCount, svc.DesiredCount), "Launch type": svc.LaunchType}
+				if svc.Cpu > 0 && svc.Memory > 0 {
+					details["Task size"] = fmt.Sprintf("%d CPU / %d MiB", svc.Cpu, svc.Memory)
+				}
+				if svc.ServiceConnect != nil {
+					details["Service Connect"] = mesh.NamespaceName(svc.ServiceConnect.Namespace)
+					refs = ref(refs, "cloudmap-namespace", namespaceKey(svc.ServiceConnect.Namespace))
+					// Any service in the namespace may call the endpoints
+					// the others publish.
+					for _, p := range c.ServiceConnectPeers(svc) {
+						refs = ref(refs, "ecs-service", p.Cluster+"/"+p.Service)
+					}
+				}
+				add(InventoryItem{Type: "ecs-service", ID: cl.ClusterName + "/" + svc.ServiceName, Name: svc.ServiceName, Refs: refs, Details: details})
+			}
+		}
+		for _, fn := range c.Lambda {
+			refs := ref(nil, "vpc", fn.VpcId)
+			for _, id := range fn.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			refs = ref(sgRefs(refs, fn.SecurityGroups), "iam-role", fn.IamRole)
+			add(InventoryItem{Type: "lambda", ID: fn.FunctionName, Name: fn.FunctionName, VpcId: fn.VpcId, Refs: refs,
+				Details: map[string]string{"Runtime": fn.Runtime, "Memory": fmt.Sprintf("%d MB", fn.MemorySize), "Timeout": fmt.Sprintf("%ds", fn.Timeout)}})
+		}
+		for _, v := range c.EBS {
+			add(InventoryItem{Type: "ebs", ID: v.VolumeId, Name: v.Name, Refs: ref(nil, "ec2", v.InstanceId),
+				Details: map[string]string{"Size": fmt.Sprintf("%d GiB", v.Size), "Type": v.VolumeType, "State": v.State, "Encrypted": fmt.Sprint(v.Encrypted)}})
+		}
+		for _, svc := range c.AppRunner {
+			var refs []string
+			for _, id := range svc.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			refs = ref(sgRefs(refs, svc.SecurityGroups), "iam-role", svc.InstanceRole)
+			add(InventoryItem{Type: "apprunner", ID: svc.Name, Name: svc.Name, Arn: svc.Arn, Refs: refs,
+				Details: map[string]string{"CPU": svc.Cpu, "Memory": svc.Memory, "Scaling": svc.Scaling()}})
+		}
+		for _, inst := range c.Lightsail {
+			add(InventoryItem{Type: "lightsail", ID: inst.Name, Name: inst.Name, Arn: inst.Arn,
+				Details: map[string]string{"Blueprint": inst.Blueprint, "Bundle": inst.Bundle, "Public IP": inst.PublicIP}})
+		}
+		for _, ct := range c.LightsailContainers {
+			add(InventoryItem{Type: "lightsail-container", ID: ct.Name, Name: ct.Name, Arn: ct.Arn,
+				Details: map[string]string{"Power": ct.Power, "Scale": fmt.Sprint(ct.Scale)}})
+		}
+	}
+
+	if d, err := LoadDatabaseData(region); err == nil && d != nil {
+		for _, r := range d.RDS {
+			add(InventoryItem{Type: "rds", ID: r.DBInstanceId, Name: r.DBInstanceId, VpcId: r.VpcId, Tags: r.Tags,
+				Refs:    sgRefs(ref(nil, "vpc", r.VpcId), r.SecurityGroups),
+				Details: map[string]string{"Engine": r.Engine + " " + r.EngineVersion, "Class": r.InstanceClass, "Multi-AZ": fmt.Sprint(r.MultiAZ)}})
+		}
+		for _, c := range d.RDSClusters {
+			refs := sgRefs(ref(nil, "vpc", c.VpcId), c.SecurityGroups)
+			for _, m := range c.Members {
+				refs = ref(refs, "rds", m.InstanceId)
+			}
+			details := map[string]string{"Engine": c.Engine + " " + c.EngineVersion, "Writer": c.Writer(), "Readers": fmt.Sprint(len(c.Readers()))}
+			if c.MaxACU > 0 {
+				details["Capacity"] = fmt.Sprintf("%g-%g ACU", c.MinACU, c.MaxACU)
+			}
+			if c.GlobalClusterId != "" {
+				details["Global"] = c.GlobalClusterId
+			}
+			typ := "rds-cluster"
+			if f := c.Family(); f != "aurora" {
+				typ = f
+			}
+			add(InventoryItem{Type: typ, ID: c.ClusterId, Name: c.ClusterId, Arn: c.Arn, VpcId: c.VpcId,
+				Refs: refs, Details: details})
+		}
+		for _, t := range d.DynamoDB {
+			add(InventoryItem{Type: "dynamodb", ID: t.TableName, Name: t.TableName,
+				Details: map[string]string{"Billing": t.BillingMode, "Items": fmt.Sprint(t.ItemCount)}})
+		}
+		for _, e := range d.ElastiCache {
+			add(InventoryItem{Type: "elasticache", ID: e.CacheClusterId, Name: e.CacheClusterId, VpcId: e.VpcId,
+				Refs:    sgRefs(ref(nil, "vpc", e.VpcId), e.SecurityGroups),
+				Details: map[string]string{"Engine": e.Engine + " " + e.EngineVersion, "Node type": e.CacheNodeType}})
+		}
+	}
+
+	if dw, err := LoadDataWarehouseData(region); err == nil && dw != nil {
+		for _, r := range dw.Redshift {
+			refs := ref(nil, "vpc", r.VpcId)
+			for _, sg := range r.SecurityGroups {
+				refs = ref(refs, "sg", sg.GroupId)
+			}
+			add(InventoryItem{Type: "redshift", ID: r.ClusterIdentifier, Name: r.ClusterIdentifier, VpcId: r.VpcId, Refs: refs,
+				Details: map[string]string{"Node type": r.NodeType, "Nodes": fmt.Sprint(r.NumberOfNodes)}})
+		}
+		for _, w := range dw.RedshiftWorkgroups {
+			add(InventoryItem{Type: "redshift-serverless", ID: w.WorkgroupName, Name: w.WorkgroupName, VpcId: w.VpcId,
+				Refs:    sgRefs(ref(nil, "vpc", w.VpcId), w.SecurityGroups),
+				Details: map[string]string{"Namespace": w.NamespaceName, "Capacity": w.Capacity()}})
+		}
+		for _, d := range dw.OpenSearch {
+			add(InventoryItem{Type: "opensearch", ID: d.DomainName, Name: d.DomainName, Arn: d.Arn, VpcId: d.VpcId,
+				Refs:    sgRefs(ref(nil, "vpc", d.VpcId), d.SecurityGroups),
+				Details: map[string]string{"Version": d.EngineVersion, "Instance type": d.InstanceType, "Nodes": fmt.Sprint(d.InstanceCount)}})
+		}
+		for _, wg := range dw.Athena {
+			details := map[string]string{"Saved queries": fmt.Sprint(len(wg.NamedQueries))}
+			if wg.Recent != nil {
+				details["Recent bytes scanned"] = fmt.Sprint(wg.Recent.BytesScanned)
+			}
+			add(InventoryItem{Type: "athena", ID: wg.Name, Name: wg.Name, Details: details})
+		}
+		for _, g := range dw.Glue {
+			add(InventoryItem{Type: "glue", ID: g.Name, Name: g.Name,
+				Details: map[string]string{"Tables": fmt.Sprint(len(g.Tables))}})
+		}
+		for _, c := range dw.GlueCrawlers {
+			add(InventoryItem{Type: "glue-crawler", ID: c.Name, Name: c.Name, Refs: ref(nil, "glue", c.DatabaseName),
+				Details: map[string]string{"Schedule": c.Schedule, "Last status": c.LastStatus}})
+		}
+		for _, j := range dw.GlueJobs {
+			add(InventoryItem{Type: "glue-job", ID: j.Name, Name: j.Name,
+				Details: map[string]string{"Type": j.Type, "Capacity": j.Capacity(), "Last run": j.LastRunState}})
+		}
+	}
+
+	if fs, err := LoadStorageData(region); err == nil && fs != nil {
+		for _, f := range fs.EFS {
+			refs := ref(nil, "vpc", f.VpcId())
+			for _, mt := range f.MountTargets {
+				refs = sgRefs(ref(refs, "subnet", mt.SubnetId), mt.SecurityGroups)
+			}
+			add(InventoryItem{Type: "efs", ID: f.FileSystemId, Name: f.Name, VpcId: f.VpcId(), Refs: refs,
+				Details: map[string]string{"Throughput": f.ThroughputMode, "Encrypted": fmt.Sprint(f.Encrypted)}})
+		}
+		for _, f := range fs.FSx {
+			refs := ref(nil, "vpc", f.VpcId)
+			for _, id := range f.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			add(InventoryItem{Type: "fsx", ID: f.FileSystemId, Name: f.Name, VpcId: f.VpcId, Refs: refs,
+				Details: map[string]string{"Type": f.Type, "Capacity": fmt.Sprintf("%d GiB", f.StorageCapacity)}})
+		}
+	}
+
+	if st, err := LoadStreamingData(region); err == nil && st != nil {
+		for _, q := range st.SQS {
+			var refs []string
+			if q.DLQCached {
+				refs = ref(refs, "sqs", q.DLQName)
+			}
+			add(InventoryItem{Type: "sqs", ID: q.QueueName, Name: q.QueueName, Arn: q.Arn, Refs: refs,
+				Details: map[string]string{"FIFO": fmt.Sprint(q.IsFIFO)}})
+		}
+		for _, t := range st.SNS {
+			var refs []string
+			for _, sub := range t.SubscriptionList {
+				if sub.TargetCached {
+					refs = ref(refs, sub.TargetType, sub.TargetId)
+				}
+			}
+			add(InventoryItem{Type: "sns", ID: t.Name, Name: t.Name, Arn: t.TopicArn, Refs: refs,
+				Details: map[string]string{"Subscriptions": fmt.Sprint(t.Subscriptions)}})
+		}
+		for _, k := range st.Kinesis {
+			add(InventoryItem{Type: "kinesis", ID: k.StreamName, Name: k.StreamName, Arn: k.StreamARN,
+				Details: map[string]string{"Mode": k.StreamMode, "Shards": fmt.Sprint(k.ShardCount)}})
+		}
+		for _, b := range st.EventBridge {
+			var refs []string
+			for _, r := range b.Rules {
+				for _, t := range r.Targets {
+					if t.TargetCached {
+						refs = ref(refs, t.TargetType, t.TargetId)
+					}
+				}
+			}
+			add(InventoryItem{Type: "eventbridge", ID: b.Name, Name: b.Name, Arn: b.Arn, Refs: refs,
+				Details: map[string]string{"Rules": fmt.Sprint(len(b.Rules))}})
+		}
+		for _, c := range st.MSK {
+			refs := sgRefs(nil, c.SecurityGroups)
+			for _, id := range c.ClientSubnets {
+				refs = ref(refs, "subnet", id)
+			}
+			add(InventoryItem{Type: "msk", ID: c.ClusterName, Name: c.ClusterName, Arn: c.ClusterArn, Refs: refs,
+				Details: map[string]string{"Kafka": c.KafkaVersion, "Brokers": fmt.Sprint(c.BrokerCount), "Instance type": c.InstanceType}})
+		}
+		for _, f := range st.Firehose {
+			var refs []string
+			if f.SourceCached {
+				refs = ref(refs, f.SourceType, f.SourceId)
+			}
+			if f.DestinationCached {
+				refs = ref(refs, f.DestinationType, f.DestinationId)
+			}
+			refs = ref(refs, "s3", f.BackupBucket)
+			add(InventoryItem{Type: "firehose", ID: f.Name, Name: f.Name, Arn: f.Arn, Refs: refs,
+				Details: map[string]string{"Destination": f.DestinationType + "/" + f.DestinationId}})
+		}
+		for _, b := range st.MQ {
+			refs := sgRefs(nil, b.SecurityGroups)
+			for _, id := range b.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			add(InventoryItem{Type: "mq", ID: b.BrokerId, Name: b.BrokerName, Arn: b.BrokerArn, Refs: refs,
+				Details: map[string]string{"Engine": b.EngineType + " " + b.EngineVersion, "Instance type": b.InstanceType}})
+		}
+	}
+
+	if ai, err := LoadAIData(region); err == nil && ai != nil {
+		for _, nb := range ai.SageMakerNotebooks {
+			refs := sgRefs(ref(nil, "subnet", nb.SubnetId), nb.SecurityGroups)
+			add(InventoryItem{Type: "sagemaker-notebook", ID: nb.Name, Name: nb.Name,
+				Refs:    ref(refs, "iam-role", nb.RoleName),
+				Details: map[string]string{"Instance type": nb.InstanceType, "Status": nb.Status}})
+		}
+		for _, m := range ai.SageMakerModels {
+			add(InventoryItem{Type: "sagemaker-model", ID: m.Name, Name: m.Name, Refs: ref(nil, "iam-role", m.RoleName)})
+		}
+		for _, ep := range ai.SageMakerEndpoints {
+			add(InventoryItem{Type: "sagemaker-endpoint", ID: ep.Name, Name: ep.Name,
+				Refs:    ref(nil, "sagemaker-model", ep.ModelName),
+				Details: map[string]string{"Instance type": ep.InstanceType, "Instances": fmt.Sprint(ep.InstanceCount)}})
+		}
+		for _, j := range ai.TrainingJobs {
+			add(InventoryItem{Type: "sagemaker-training-job", ID: j.Name, Name: j.Name, Refs: ref(nil, "iam-role", j.RoleName),
+				Details: map[string]string{"Instances": j.Capacity(), "Status": j.Status}})
+		}
+		for _, p := range ai.Pipelines {
+			add(InventoryItem{Type: "sagemaker-pipeline", ID: p.Name, Name: p.Name,
+				Details: map[string]string{"Last status": p.LastStatus}})
+		}
+		for _, d := range ai.Domains {
+			refs := ref(nil, "vpc", d.VpcId)
+			for _, id := range d.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			add(InventoryItem{Type: "sagemaker-domain", ID: d.Id, Name: d.Name, Refs: ref(refs, "iam-role", d.RoleName),
+				Details: map[string]string{"Network access": d.NetworkAccess, "User profiles": fmt.Sprint(len(d.UserProfiles))}})
+		}
+		for _, g := range ai.Guardrails {
+			add(InventoryItem{Type: "bedrock-guardrail", ID: g.Id, Name: g.Name, Refs: ref(nil, "kms", g.KmsKeyId),
+				Details: map[string]string{"Policies": strings.Join(g.Policies, ", ")}})
+		}
+		for _, kb := range ai.KnowledgeBases {
+			refs := ref(nil, "iam-role", kb.RoleName)
+			if kb.VectorStoreCached {
+				refs = ref(refs, "opensearch", kb.VectorStore)
+			}
+			for _, ds := range kb.DataSources {
+				refs = ref(refs, "s3", ds.Bucket)
+			}
+			add(InventoryItem{Type: "bedrock-knowledge-base", ID: kb.Id, Name: kb.Name, Refs: refs,
+				Details: map[string]string{"Vector store": kb.StorageType, "Data sources": fmt.Sprint(len(kb.DataSources))}})
+		}
+		for _, a := range ai.BedrockAgents {
+			refs := ref(ref(nil, "iam-role", a.RoleName), "bedrock-guardrail", a.GuardrailId)
+			for _, id := range a.KnowledgeBaseIds {
+				refs = ref(refs, "bedrock-knowledge-base", id)
+			}
+			add(InventoryItem{Type: "bedrock-agent", ID: a.Id, Name: a.Name, Refs: refs,
+				Details: map[string]string{"Model": a.FoundationModel, "Status": a.Status}})
+		}
+		for _, p := range ai.ProvisionedThroughput {
+			add(InventoryItem{Type: "bedrock-provisioned-throughput", ID: p.Name, Name: p.Name, Arn: p.Arn,
+				Details: map[string]string{"Model": p.Model, "Model units": fmt.Sprint(p.ModelUnits), "Commitment": p.Commitment}})
+		}
+	}
+
+	if cicd, err := LoadCICDData(region); err == nil && cicd != nil {
+		for _, p := range cicd.Pipelines {
+			refs := ref(ref(nil, "iam-role", p.RoleName), "s3", p.ArtifactStore)
+			for _, project := range p.BuildProjects() {
+				refs = ref(refs, "codebuild", project)
+			}
+			add(InventoryItem{Type: "codepipeline", ID: p.Name, Name: p.Name, Refs: refs,
+				Details: map[string]string{"Stages": fmt.Sprint(len(p.Stages)), "Last execution": p.LastStatus}})
+		}
+		for _, p := range cicd.Projects {
+			refs := sgRefs(ref(nil, "vpc", p.VpcId), p.SecurityGroups)
+			for _, id := range p.SubnetIds {
+				refs = ref(refs, "subnet", id)
+			}
+			refs = ref(refs, "iam-role", p.RoleName)
+			details := map[string]string{"Source": p.SourceType, "Image": p.Image, "Compute": p.ComputeType}
+			if p.LastBuild != nil {
+				details["Last build"] = p.LastBuild.Status
+			}
+			add(InventoryItem{Type: "codebuild", ID: p.Name, Name: p.Name, Arn: p.Arn, VpcId: p.VpcId, Refs: refs, Details: details})
+		}
+		for _, s := range cicd.Stacks {
+			add(InventoryItem{Type: "cfn-stack", ID: s.Name, Name: s.Name, Arn: s.Id, Refs: ref(nil, "cfn-stack", s.Parent),
+				Details: map[string]string{"Status": s.Status, "Resources": fmt.Sprint(len(s.Resources))}})
+		}
+	}
+
+	if s3, err := LoadS3DataEnriched(); err == nil && s3 != nil {
+		for _, b := range s3.Buckets {
+			add(InventoryItem{Type: "s3", ID: b.Name, Name: b.Name, Region: b.Region, Arn: "arn:aws:s3:::" + b.Name,
+				Details: map[string]string{"Access": b.Access, "Versioning": b.Versioning}})
+			for _, ap := range b.AccessPoints {
+				add(InventoryItem{Type: "s3-access-point", ID: ap.Name, Name: ap.Name, Region: b.Region, Arn: ap.Arn,
+					Refs:    ref(ref(nil, "s3", b.Name), "vpc", ap.VpcId),
+					Details: map[string]string{"Network origin": ap.NetworkOrigin, "Public policy": fmt.Sprint(ap.PolicyPublic)}})
+			}
+		}
+		for _, m := range s3.MRAPs {
+			var refs []string
+			for _, r := range m.Regions {
+				refs = ref(refs, "s3", r.Bucket)
+			}
+			add(InventoryItem{Type: "s3-mrap", ID: m.Name, Name: m.Name, Region: "global", Refs: refs,
+				Details: map[string]string{"Alias": m.Alias, "Status": m.Status, "Public policy": fmt.Sprint(m.PolicyPublic)}})
+		}
+	}
+
+	if dists, err := LoadCloudFrontDistributions(); err == nil {
+		for _, d := range dists {
+			add(InventoryItem{Type: "cloudfront", ID: d.Id, Name: d.DomainName, Region: "global",
+				Details: map[string]string{"Aliases": strings.Join(d.Aliases, ", "), "Enabled": fmt.Sprint(d.Enabled)}})
+		}
+	}
+	if apis, err := LoadAPIGatewayAPIs(region); err == nil {
+		for _, a := range apis {
+			add(InventoryItem{Type: "apigateway", ID: a.Id, Name: a.Name,
+				Details: map[string]string{"Protocol": a.Protocol, "Endpoint": a.Endpoint, "Private": fmt.Sprint(a.Private)}})
+		}
+	}
+
+	if iam, err := LoadIAMData(); err == nil && iam != nil {
+		for _, r := range iam.Roles {
+			add(InventoryItem{Type: "iam-role", ID: r.RoleName, Name: r.RoleName, Region: "global", Arn: r.Arn,
+				Details: map[string]string{"Policies": strings.Join(r.AttachedPolicies, ", ")}})
+		}
+		for _, g := range iam.Groups {
+			add(InventoryItem{Type: "iam-group", ID: g.GroupName, Name: g.GroupName, Region: "global", Arn: g.Arn,
+				Details: map[string]string{"Members": strings.Join(g.Members, ", ")}})
+		}
+		for _, p := range iam.Providers {
+			var refs []string
+			for _, t := range p.TrustingRoles {
+				refs = ref(refs, "iam-role", t.RoleName)
+			}
+			add(InventoryItem{Type: "iam-identity-provider", ID: p.Arn, Name: p.Name, Region: "global", Arn: p.Arn, Refs: refs,
+				Details: map[string]string{"Type": p.Type, "Audiences": strings.Join(p.Audiences, ", ")}})
+		}
+		for _, u := range iam.Users {
+			var refs []string
+			for _, g := range u.Groups {
+				refs = ref(refs, "iam-group", g)
+			}
+			add(InventoryItem{Type: "iam-user", ID: u.UserName, Name: u.UserName, Region: "global", Arn: u.Arn, Refs: refs,
+				Details: map[string]string{"Access keys": fmt.Sprint(len(u.AccessKeys)), "Console": fmt.Sprint(u.ConsoleAccess), "MFA": fmt.Sprint(len(u.MFADevices) > 0)}})
+		}
+	}
+
+	if cognito, err := LoadCognitoData(region); err == nil && cognito != nil {
+		for _, p := range cognito.UserPools {
+			add(InventoryItem{Type: "cognito-user-pool", ID: p.Id, Name: p.Name, Arn: p.Arn,
+				Details: map[string]string{"MFA": p.MfaConfiguration, "Users": strconv.Itoa(p.EstimatedUsers)}})
+		}
+		for _, p := range cognito.IdentityPools {
+			var refs []string
+			for _, id := range p.UserPoolIds {
+				refs = ref(refs, "cognito-user-pool", id)
+			}
+			add(InventoryItem{Type: "cognito-identity-pool", ID: p.IdentityPoolId, Name: p.IdentityPoolName, Refs: refs,
+				Details: map[string]string{"Guest Access": strconv.FormatBool(p.AllowUnauthenticated)}})
+		}
+	}
+
+	if secrets, err := LoadSecrets(region); err == nil {
+		for _, sec := range secrets {
+			var refs []string
+			if sec.RotationLambda != "" {
+				refs = ref(refs, "lambda", sec.RotationLambda[strings.LastIndex(sec.RotationLambda, ":")+1:])
+			}
+			add(InventoryItem{Type: "secret", ID: sec.Name, Name: sec.Name, Arn: sec.Arn, Refs: refs,
+				Details: map[string]string{"Rotation": strconv.FormatBool(sec.RotationEnabled), "Last Accessed": sec.LastAccessed}})
+		}
+	}
+
+	if gd, err := LoadGuardDutyData(region); err == nil && gd != nil {
+		for _, f := range gd.Findings {
+			var refs []string
+			if f.Link != nil {
+				refs = ref(refs, f.Link.Type, f.Link.ID)
+			}
+			add(InventoryItem{Type: "guardduty", ID: f.Id, Name: f.Title, Refs: refs,
+				Details: map[string]string{"Severity": f.SeverityLabel(), "Finding type": f.Type, "Resource": f.Resource()}})
+		}
+	}
+
+	if cfg, err := LoadConfigData(region); err == nil && cfg != nil {
+		for _, rule := range cfg.Rules {
+			var refs []string
+			for _, e := range cfg.EvaluationsForRule(rule.Name) {
+				if typ := e.DetailType(); typ != "" {
+					refs = ref(refs, typ, e.ResourceId)
+				}
+			}
+			add(InventoryItem{Type: "config-rule", ID: rule.Name, Name: rule.Name, Arn: rule.Arn, Refs: refs,
+				Details: map[string]string{"Source": rule.Source, "Compliance": rule.Compliance, "Noncompliant": strconv.Itoa(rule.NoncompliantCount)}})
+		}
+	}
+
+	if trails, err := LoadTrails(region); err == nil {
+		for _, t := range trails {
+			refs := ref(nil, "s3", t.S3BucketName)
+			if t.KmsKeyId != "" {
+				refs = ref(refs, "kms", t.KmsKeyId)
+			}
+			add(InventoryItem{Type: "cloudtrail", ID: t.Name, Name: t.Name, Arn: t.Arn, Refs: refs,
+				Details: map[string]string{"Logging": strconv.FormatBool(t.IsLogging), "Multi-region": strconv.FormatBool(t.IsMultiRegion), "Home region": t.HomeRegion}})
+		}
+	}
+
+	if mesh, err := LoadServiceMeshData(region); err == nil && mesh != nil {
+		compute, _ := LoadComputeData(region)
+		for _, n := range mesh.Namespaces {
+			add(InventoryItem{Type: "cloudmap-namespace", ID: n.Id, Name: n.Name, Arn: n.Arn,
+				Details: map[string]string{"Type": n.Type, "Services": strconv.Itoa(len(n.Services))}})
+			for _, s := range n.Services {
+				refs := ref(nil, "cloudmap-namespace", n.Id)
+				for _, inst := range s.Instances {
+					t := compute.ResolveCloudMapInstance(inst)
+					switch {
+					case t == nil:
+					case t.Type == "ec2":
+						refs = ref(refs, "ec2", t.ID)
+					case t.Service != "":
+						refs = ref(refs, "ecs-service", t.Cluster+"/"+t.Service)
+					}
+				}
+				add(InventoryItem{Type: "cloudmap-service", ID: s.Id, Name: n.DNSName(s), Refs: refs,
+					Details: map[string]string{"Instances": strconv.Itoa(len(s.Instances))}})
+			}
+		}
+		for _, m := range mesh.Meshes {
+			id := func(name string) string { return m.Name + "/" + name }
+			add(InventoryItem{Type: "mesh", ID: m.Name, Name: m.Name, Arn: m.Arn,
+				Details: map[string]string{"Virtual services": strconv.Itoa(len(m.Services)), "Virtual nodes": strconv.Itoa(len(m.Nodes))}})
+			for _, vs := range m.Services {
+				refs := ref(nil, "mesh", m.Name)
+				if vs.ProviderType != "" {
+					refs = ref(refs, "mesh-"+vs.ProviderType, id(vs.Provider))
+				}
+				add(InventoryItem{Type: "mesh-service", ID: id(vs.Name), Name: vs.Name, Refs: refs,
+					Details: map[string]string{"Provider": vs.Provider}})
+			}
+			for _, r := range m.Routers {
+				refs := ref(nil, "mesh", m.Name)
+				for _, route := range r.Routes {
+					for _, t := range route.Targets {
+						refs = ref(refs, "mesh-node", id(t.Node))
+					}
+				}
+				add(InventoryItem{Type: "mesh-router", ID: id(r.Name), Name: r.Name, Refs: refs,
+					Details: map[string]string{"Routes": strconv.Itoa(len(r.Routes))}})
+			}
+			for _, n := range m.Nodes {
+				refs := ref(nil, "mesh", m.Name)
+				for _, b := range n.Backends {
+					refs = ref(refs, "mesh-service", id(b))
+				}
+				add(InventoryItem{Type: "mesh-node", ID: id(n.Name), Name: n.Name, Refs: refs,
+					Details: map[string]string{"Discovery": n.Discovery, "Listeners": strings.Join(n.Listeners, ", ")}})
+			}
+		}
+	}
+
+	if access, err := LoadExternalAccess(region); err == nil {
+		for _, f := range access {
+			var refs []string
+			if typ := f.DetailType(); typ != "" {
+				refs = ref(refs, typ, f.DetailID())
+			}
+			add(InventoryItem{Type: "access-analyzer", ID: f.Id, Name: f.DetailID(), Refs: refs,
+				Details: map[string]string{"Resource": f.Resource, "Granted to": f.Grantee(), "Public": strconv.FormatBool(f.IsPublic)}})
+		}
+	}
+
+	for _, it := range providerInventory() {
+		if !cfg.Excludes(it) {
+			items = append(items, it)
+		}
+	}
+
+	// Attribute each item to the CloudFormation stack that manages it.
+	stacks := StackIndex(region)
+	for i, it := range items {
+		stack := stacks[it.ID]
+		if stack == "" {
+			stack = stacks[it.Arn]
+		}
+		if stack == "" || it.Type == "cfn-stack" {
+			continue
+		}
+		if it.Details == nil {
+			items[i].Details = map[string]string{}
+		}
+		items[i].Details["CloudFormation stack"] = stack
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Type != items[j].Type {
+			return items[i].Type < items[j].Type
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
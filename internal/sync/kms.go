@@ -0,0 +1,299 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// KMSKey is a KMS key with its aliases. KeyManager is AWS for the
+// aws/<service> default keys and CUSTOMER for keys the account created.
+type KMSKey struct {
+	KeyId        string   `json:"KeyId"`
+	Arn          string   `json:"Arn"`
+	Aliases      []string `json:"Aliases"` // alias/name
+	Description  string   `json:"Description"`
+	KeyManager   string   `json:"KeyManager"`
+	KeyState     string   `json:"KeyState"` // Enabled, Disabled, PendingDeletion, ...
+	KeySpec      string   `json:"KeySpec"`
+	CreationDate string   `json:"CreationDate"`
+	DeletionDate string   `json:"DeletionDate"` // set when PendingDeletion
+}
+
+// Label returns the key's first alias, or its ID.
+func (k KMSKey) Label() string {
+	if len(k.Aliases) > 0 {
+		return k.Aliases[0]
+	}
+	return k.KeyId
+}
+
+// SyncKMSData fetches KMS keys and their aliases.
+func SyncKMSData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("kms", "list-keys", "--region", region)
+	if err != nil {
+		step("kms")
+		return []SyncResult{{Service: "kms", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		Keys []struct {
+			KeyId string `json:"KeyId"`
+		} `json:"Keys"`
+	}
+	json.Unmarshal(data, &resp)
+
+	aliases := map[string][]string{} // key ID → alias names
+	if aData, err := awscli.Run("kms", "list-aliases", "--region", region); err == nil {
+		var aResp struct {
+			Aliases []struct {
+				AliasName   string `json:"AliasName"`
+				TargetKeyId string `json:"TargetKeyId"`
+			} `json:"Aliases"`
+		}
+		json.Unmarshal(aData, &aResp)
+		for _, a := range aResp.Aliases {
+			if a.TargetKeyId != "" {
+				aliases[a.TargetKeyId] = append(aliases[a.TargetKeyId], a.AliasName)
+			}
+		}
+	}
+
+	var keys []KMSKey
+	for _, k := range resp.Keys {
+		key := KMSKey{KeyId: k.KeyId, Aliases: aliases[k.KeyId]}
+		if dData, err := awscli.Run("kms", "describe-key", "--key-id", k.KeyId, "--region", region); err == nil {
+			var dResp struct {
+				KeyMetadata KMSKey `json:"KeyMetadata"`
+			}
+			json.Unmarshal(dData, &dResp)
+			dResp.KeyMetadata.Aliases = key.Aliases
+			key = dResp.KeyMetadata
+		}
+		keys = append(keys, key)
+	}
+	step("kms")
+
+	b, _ := json.Marshal(keys)
+	st.WriteCache(region+":kms", b)
+	return []SyncResult{{Service: "kms", Count: len(keys)}}, nil
+}
+
+// LoadKMSKeys returns the cached KMS keys for region.
+func LoadKMSKeys(region string) ([]KMSKey, error) {
+	raw, err := ReadCache(region + ":kms")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var keys []KMSKey
+	json.Unmarshal(raw, &keys)
+	return keys, nil
+}
+
+// KMSResource is a cached resource encrypted with a KMS key, in the
+// type/id scheme of the inventory.
+type KMSResource struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// KMSKeyUsage is one key and the cached resources that reference it. Key
+// holds only the ID or alias when the key itself has not been synced.
+type KMSKeyUsage struct {
+	Key       KMSKey        `json:"key"`
+	Synced    bool          `json:"synced"`
+	Resources []KMSResource `json:"resources"`
+}
+
+// KeyUsage maps every KMS key referenced by a cached resource in region
+// (EBS volumes and snapshots, RDS, DynamoDB, S3, EFS, FSx, Redshift,
+// OpenSearch, SQS, SNS, MSK, MQ, Lambda environment variables, Secrets
+// Manager and CloudTrail) to those resources. Aliases are resolved through the
+// synced keys; synced keys that protect nothing are included with no
+// resources.
+func KeyUsage(region string) ([]KMSKeyUsage, error) {
+	keys, err := LoadKMSKeys(region)
+	if err != nil {
+		return nil, err
+	}
+	byRef := map[string]*KMSKeyUsage{}
+	var order []string
+	for _, k := range keys {
+		u := &KMSKeyUsage{Key: k, Synced: true}
+		byRef[k.KeyId] = u
+		order = append(order, k.KeyId)
+		for _, a := range k.Aliases {
+			byRef[a] = u
+		}
+	}
+	add := func(keyRef, typ, id, name string) {
+		ref := kmsKeyRef(keyRef)
+		if ref == "" {
+			return
+		}
+		u, ok := byRef[ref]
+		if !ok {
+			u = &KMSKeyUsage{Key: KMSKey{KeyId: ref}}
+			if strings.HasPrefix(ref, "alias/") {
+				u.Key = KMSKey{Aliases: []string{ref}}
+			}
+			if strings.HasPrefix(ref, "alias/aws/") {
+				u.Key.KeyManager = "AWS"
+			}
+			byRef[ref] = u
+			order = append(order, ref)
+		}
+		u.Resources = append(u.Resources, KMSResource{Type: typ, ID: id, Name: name})
+	}
+
+	if vols, err := LoadEBSVolumes(region); err == nil {
+		for _, v := range vols {
+			add(v.KmsKeyId, "ebs", v.VolumeId, v.Name)
+		}
+	}
+	if snaps, err := LoadEBSSnapshots(region); err == nil {
+		for _, s := range snaps {
+			add(s.KmsKeyId, "ebs-snapshot", s.SnapshotId, s.Name)
+		}
+	}
+	if db, err := LoadDatabaseData(region); err == nil && db != nil {
+		for _, r := range db.RDS {
+			// Cluster members share the cluster's key.
+			if r.ClusterId == "" {
+				add(r.KmsKeyId, "rds", r.DBInstanceId, r.DBInstanceId)
+			}
+		}
+		for _, c := range db.RDSClusters {
+			typ := "rds-cluster"
+			if f := c.Family(); f != "aurora" {
+				typ = f
+			}
+			add(c.KmsKeyId, typ, c.ClusterId, c.ClusterId)
+		}
+		for _, t := range db.DynamoDB {
+			add(t.KmsKeyId, "dynamodb", t.TableName, t.TableName)
+		}
+	}
+	if s3, err := LoadS3DataEnriched(); err == nil && s3 != nil {
+		for _, b := range s3.Buckets {
+			if b.Region != region || (b.Encryption != "SSE-KMS" && b.Encryption != "DSSE-KMS") {
+				continue
+			}
+			key := b.KmsKeyId
+			if key == "" {
+				key = "alias/aws/s3"
+			}
+			add(key, "s3", b.Name, b.Name)
+		}
+	}
+	if fs, err := LoadStorageData(region); err == nil && fs != nil {
+		for _, f := range fs.EFS {
+			add(f.KmsKeyId, "efs", f.FileSystemId, f.Name)
+		}
+		for _, f := range fs.FSx {
+			add(f.KmsKeyId, "fsx", f.FileSystemId, f.Name)
+		}
+	}
+	if dw, err := LoadDataWarehouseData(region); err == nil && dw != nil {
+		for _, c := range dw.Redshift {
+			add(c.KmsKeyId, "redshift", c.ClusterIdentifier, c.ClusterIdentifier)
+		}
+		for _, d := range dw.OpenSearch {
+			add(d.KmsKeyId, "opensearch", d.DomainName, d.DomainName)
+		}
+	}
+	if st, err := LoadStreamingData(region); err == nil && st != nil {
+		for _, q := range st.SQS {
+			add(q.KmsKeyId, "sqs", q.QueueName, q.QueueName)
+		}
+		for _, t := range st.SNS {
+			add(t.KmsKeyId, "sns", t.Name, t.Name)
+		}
+		for _, c := range st.MSK {
+			add(c.KmsKeyId, "msk", c.ClusterName, c.ClusterName)
+		}
+		for _, b := range st.MQ {
+			add(b.KmsKeyId, "mq", b.BrokerId, b.BrokerName)
+		}
+	}
+	if c, err := LoadComputeData(region); err == nil && c != nil {
+		for _, fn := range c.Lambda {
+			add(fn.KmsKeyArn, "lambda", fn.FunctionName, fn.FunctionName)
+		}
+	}
+	if secrets, err := LoadSecrets(region); err == nil {
+		for _, s := range secrets {
+			key := s.KmsKeyId
+			if key == "" {
+				key = "alias/aws/secretsmanager"
+			}
+			add(key, "secret", s.Name, s.Name)
+		}
+	}
+
+	if trails, err := LoadTrails(region); err == nil {
+		for _, t := range trails {
+			add(t.KmsKeyId, "cloudtrail", t.Name, t.Name)
+		}
+	}
+
+	// Customer keys first, then by number of resources protected.
+	seen := map[*KMSKeyUsage]bool{}
+	var out []KMSKeyUsage
+	for _, ref := range order {
+		if u := byRef[ref]; !seen[u] {
+			seen[u] = true
+			out = append(out, *u)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		ci, cj := out[i].Key.KeyManager != "AWS", out[j].Key.KeyManager != "AWS"
+		if ci != cj {
+			return ci
+		}
+		return len(out[i].Resources) > len(out[j].Resources)
+	})
+	return out, nil
+}
+
+// Matches reports whether ref (a key ID, key ARN or alias) names this key.
+func (u KMSKeyUsage) Matches(ref string) bool {
+	ref = kmsKeyRef(ref)
+	if ref == "" {
+		return false
+	}
+	if ref == u.Key.KeyId {
+		return true
+	}
+	for _, a := range u.Key.Aliases {
+		if a == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// kmsKeyRef reduces the forms resources use to reference a key (key ID,
+// key ARN, alias name, alias ARN) to a key ID or "alias/name".
+func kmsKeyRef(v string) string {
+	if strings.HasPrefix(v, "arn:") {
+		// arn:aws:kms:region:account:key/id or .../alias/name
+		parts := strings.SplitN(v, ":", 6)
+		if len(parts) < 6 {
+			return v
+		}
+		v = parts[5]
+	}
+	if !strings.HasPrefix(v, "alias/") {
+		v = strings.TrimPrefix(v, "key/")
+	}
+	return v
+}
@@ -0,0 +1,133 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// LambdaVersion is a published, immutable version of a function.
+type LambdaVersion struct {
+	Version      string `json:"Version"`
+	Description  string `json:"Description"`
+	LastModified string `json:"LastModified"`
+}
+
+// LambdaAlias points at a version, optionally shifting a share of traffic
+// to a second one.
+type LambdaAlias struct {
+	Name              string             `json:"Name"`
+	FunctionVersion   string             `json:"FunctionVersion"`
+	Description       string             `json:"Description"`
+	AdditionalWeights map[string]float64 `json:"AdditionalWeights,omitempty"` // version -> share of traffic
+}
+
+// LambdaProvisioned is the provisioned concurrency configured on one alias
+// or version.
+type LambdaProvisioned struct {
+	Qualifier    string `json:"Qualifier"`
+	Requested    int    `json:"Requested"`
+	Allocated    int    `json:"Allocated"`
+	Available    int    `json:"Available"`
+	Status       string `json:"Status"` // IN_PROGRESS, READY, FAILED
+	StatusReason string `json:"StatusReason"`
+}
+
+// Routing describes where the alias sends traffic, e.g. "5 (90%), 6 (10%)".
+func (a LambdaAlias) Routing() string {
+	if len(a.AdditionalWeights) == 0 {
+		return a.FunctionVersion
+	}
+	versions := make([]string, 0, len(a.AdditionalWeights))
+	rest := 1.0
+	for v, w := range a.AdditionalWeights {
+		versions = append(versions, v)
+		rest -= w
+	}
+	sort.Strings(versions)
+	parts := []string{fmt.Sprintf("%s (%.0f%%)", a.FunctionVersion, rest*100)}
+	for _, v := range versions {
+		parts = append(parts, fmt.Sprintf("%s (%.0f%%)", v, a.AdditionalWeights[v]*100))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ProvisionedTotal is the provisioned concurrency requested across all
+// aliases and versions.
+func (fn LambdaFunction) ProvisionedTotal() int {
+	n := 0
+	for _, p := range fn.Provisioned {
+		n += p.Requested
+	}
+	return n
+}
+
+// syncLambdaCapacity fills in a function's reserved concurrency, published
+// versions, aliases and provisioned concurrency configs.
+func syncLambdaCapacity(region string, fn *LambdaFunction) {
+	if data, err := awscli.Run("lambda", "get-function-concurrency", "--function-name", fn.FunctionName, "--region", region); err == nil {
+		var resp struct {
+			ReservedConcurrentExecutions *int `json:"ReservedConcurrentExecutions"`
+		}
+		json.Unmarshal(data, &resp)
+		fn.ReservedConcurrency = resp.ReservedConcurrentExecutions
+	}
+
+	if data, err := awscli.Run("lambda", "list-versions-by-function", "--function-name", fn.FunctionName, "--region", region); err == nil {
+		var resp struct {
+			Versions []LambdaVersion `json:"Versions"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, v := range resp.Versions {
+			if v.Version != "$LATEST" {
+				fn.Versions = append(fn.Versions, v)
+			}
+		}
+	}
+
+	if data, err := awscli.Run("lambda", "list-aliases", "--function-name", fn.FunctionName, "--region", region); err == nil {
+		var resp struct {
+			Aliases []struct {
+				Name            string `json:"Name"`
+				FunctionVersion string `json:"FunctionVersion"`
+				Description     string `json:"Description"`
+				RoutingConfig   struct {
+					AdditionalVersionWeights map[string]float64 `json:"AdditionalVersionWeights"`
+				} `json:"RoutingConfig"`
+			} `json:"Aliases"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, a := range resp.Aliases {
+			fn.Aliases = append(fn.Aliases, LambdaAlias{
+				Name: a.Name, FunctionVersion: a.FunctionVersion, Description: a.Description,
+				AdditionalWeights: a.RoutingConfig.AdditionalVersionWeights,
+			})
+		}
+	}
+
+	if data, err := awscli.Run("lambda", "list-provisioned-concurrency-configs", "--function-name", fn.FunctionName, "--region", region); err == nil {
+		var resp struct {
+			ProvisionedConcurrencyConfigs []struct {
+				FunctionArn                              string `json:"FunctionArn"`
+				RequestedProvisionedConcurrentExecutions int    `json:"RequestedProvisionedConcurrentExecutions"`
+				AllocatedProvisionedConcurrentExecutions int    `json:"AllocatedProvisionedConcurrentExecutions"`
+				AvailableProvisionedConcurrentExecutions int    `json:"AvailableProvisionedConcurrentExecutions"`
+				Status                                   string `json:"Status"`
+				StatusReason                             string `json:"StatusReason"`
+			} `json:"ProvisionedConcurrencyConfigs"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, c := range resp.ProvisionedConcurrencyConfigs {
+			fn.Provisioned = append(fn.Provisioned, LambdaProvisioned{
+				Qualifier: c.FunctionArn[strings.LastIndex(c.FunctionArn, ":")+1:],
+				Requested: c.RequestedProvisionedConcurrentExecutions,
+				Allocated: c.AllocatedProvisionedConcurrentExecutions,
+				Available: c.AvailableProvisionedConcurrentExecutions,
+				Status:    c.Status, StatusReason: c.StatusReason,
+			})
+		}
+	}
+}
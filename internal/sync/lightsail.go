@@ -0,0 +1,219 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+type LightsailInstance struct {
+	Name             string  `json:"Name"`
+	Arn              string  `json:"Arn"`
+	Blueprint        string  `json:"Blueprint"` // e.g. ubuntu_22_04, wordpress
+	Bundle           string  `json:"Bundle"`    // e.g. small_3_0
+	State            string  `json:"State"`
+	PublicIP         string  `json:"PublicIP"`
+	PrivateIP        string  `json:"PrivateIP"`
+	StaticIP         bool    `json:"StaticIP"`
+	CPUs             int     `json:"CPUs"`
+	MemoryGB         float64 `json:"MemoryGB"`
+	DiskGB           int     `json:"DiskGB"`
+	AvailabilityZone string  `json:"AvailabilityZone"`
+	CreatedAt        string  `json:"CreatedAt"`
+}
+
+// Size renders the instance hardware, e.g. "2 vCPU · 2 GB · 60 GB disk".
+func (i LightsailInstance) Size() string {
+	return strconv.Itoa(i.CPUs) + " vCPU · " + strconv.FormatFloat(i.MemoryGB, 'f', -1, 64) + " GB · " +
+		strconv.Itoa(i.DiskGB) + " GB disk"
+}
+
+// LightsailContainerService runs a fixed number of nodes (Scale) of one
+// power; Lightsail does not auto scale container services.
+type LightsailContainerService struct {
+	Name      string   `json:"Name"`
+	Arn       string   `json:"Arn"`
+	State     string   `json:"State"` // RUNNING, READY, DEPLOYING, DISABLED, ...
+	Power     string   `json:"Power"` // nano, micro, small, medium, large, xlarge
+	Scale     int      `json:"Scale"`
+	CPUs      float64  `json:"CPUs"`     // per node, from the power
+	MemoryGB  float64  `json:"MemoryGB"` // per node, from the power
+	Url       string   `json:"Url"`
+	Disabled  bool     `json:"Disabled"`
+	Images    []string `json:"Images"` // container images of the current deployment
+	CreatedAt string   `json:"CreatedAt"`
+}
+
+// Size renders the per-node power, e.g. "0.25 vCPU · 1 GB", or just the
+// power name when its hardware is unknown.
+func (c LightsailContainerService) Size() string {
+	if c.CPUs == 0 {
+		return c.Power
+	}
+	return strconv.FormatFloat(c.CPUs, 'f', -1, 64) + " vCPU · " + strconv.FormatFloat(c.MemoryGB, 'f', -1, 64) + " GB"
+}
+
+// SyncLightsailData fetches Lightsail instances and container services.
+func SyncLightsailData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	var results []SyncResult
+
+	if data, err := awscli.Run("lightsail", "get-instances", "--region", region); err == nil {
+		st.WriteCache(region+":lightsail", data)
+		results = append(results, SyncResult{Service: "lightsail", Count: countKey(data, "instances")})
+	} else {
+		results = append(results, SyncResult{Service: "lightsail", Error: err.Error()})
+	}
+	step("lightsail instances")
+
+	// Container services name their power; the hardware behind each power
+	// comes from get-container-service-powers.
+	if data, err := awscli.Run("lightsail", "get-container-services", "--region", region); err == nil {
+		var resp struct {
+			ContainerServices []json.RawMessage `json:"containerServices"`
+		}
+		json.Unmarshal(data, &resp)
+		services := []LightsailContainerService{}
+		if len(resp.ContainerServices) > 0 {
+			powers := map[string][2]float64{}
+			if pData, err := awscli.Run("lightsail", "get-container-service-powers", "--region", region); err == nil {
+				var pResp struct {
+					Powers []struct {
+						Name        string  `json:"name"`
+						CpuCount    float64 `json:"cpuCount"`
+						RamSizeInGb float64 `json:"ramSizeInGb"`
+					} `json:"powers"`
+				}
+				json.Unmarshal(pData, &pResp)
+				for _, p := range pResp.Powers {
+					powers[p.Name] = [2]float64{p.CpuCount, p.RamSizeInGb}
+				}
+			}
+			for _, raw := range resp.ContainerServices {
+				svc := parseLightsailContainerService(raw)
+				if p, ok := powers[svc.Power]; ok {
+					svc.CPUs, svc.MemoryGB = p[0], p[1]
+				}
+				services = append(services, svc)
+			}
+		}
+		b, _ := json.Marshal(services)
+		st.WriteCache(region+":lightsail-containers", b)
+		results = append(results, SyncResult{Service: "lightsail-containers", Count: len(services)})
+	} else {
+		results = append(results, SyncResult{Service: "lightsail-containers", Error: err.Error()})
+	}
+	step("lightsail container services")
+
+	return results, nil
+}
+
+// LoadLightsailData returns the cached Lightsail instances and container
+// services for region.
+func LoadLightsailData(region string) ([]LightsailInstance, []LightsailContainerService) {
+	var instances []LightsailInstance
+	if raw, err := ReadCache(region + ":lightsail"); err == nil && raw != nil {
+		var resp struct {
+			Instances []json.RawMessage `json:"instances"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, inst := range resp.Instances {
+			instances = append(instances, parseLightsailInstance(inst))
+		}
+	}
+	var containers []LightsailContainerService
+	if raw, err := ReadCache(region + ":lightsail-containers"); err == nil && raw != nil {
+		json.Unmarshal(raw, &containers)
+	}
+	return instances, containers
+}
+
+func parseLightsailInstance(raw json.RawMessage) LightsailInstance {
+	var i struct {
+		Name             string `json:"name"`
+		Arn              string `json:"arn"`
+		BlueprintId      string `json:"blueprintId"`
+		BundleId         string `json:"bundleId"`
+		CreatedAt        string `json:"createdAt"`
+		PublicIpAddress  string `json:"publicIpAddress"`
+		PrivateIpAddress string `json:"privateIpAddress"`
+		IsStaticIp       bool   `json:"isStaticIp"`
+		State            struct {
+			Name string `json:"name"`
+		} `json:"state"`
+		Location struct {
+			AvailabilityZone string `json:"availabilityZone"`
+		} `json:"location"`
+		Hardware struct {
+			CpuCount    int     `json:"cpuCount"`
+			RamSizeInGb float64 `json:"ramSizeInGb"`
+			Disks       []struct {
+				SizeInGb int `json:"sizeInGb"`
+			} `json:"disks"`
+		} `json:"hardware"`
+	}
+	json.Unmarshal(raw, &i)
+
+	disk := 0
+	for _, d := range i.Hardware.Disks {
+		disk += d.SizeInGb
+	}
+	return LightsailInstance{
+		Name:             i.Name,
+		Arn:              i.Arn,
+		Blueprint:        i.BlueprintId,
+		Bundle:           i.BundleId,
+		State:            i.State.Name,
+		PublicIP:         i.PublicIpAddress,
+		PrivateIP:        i.PrivateIpAddress,
+		StaticIP:         i.IsStaticIp,
+		CPUs:             i.Hardware.CpuCount,
+		MemoryGB:         i.Hardware.RamSizeInGb,
+		DiskGB:           disk,
+		AvailabilityZone: i.Location.AvailabilityZone,
+		CreatedAt:        formatCLITime(i.CreatedAt),
+	}
+}
+
+// parseLightsailContainerService reads one get-container-services entry;
+// the power's hardware is filled in separately.
+func parseLightsailContainerService(raw json.RawMessage) LightsailContainerService {
+	var c struct {
+		ContainerServiceName string `json:"containerServiceName"`
+		Arn                  string `json:"arn"`
+		State                string `json:"state"`
+		Power                string `json:"power"`
+		Scale                int    `json:"scale"`
+		Url                  string `json:"url"`
+		IsDisabled           bool   `json:"isDisabled"`
+		CreatedAt            string `json:"createdAt"`
+		CurrentDeployment    struct {
+			Containers map[string]struct {
+				Image string `json:"image"`
+			} `json:"containers"`
+		} `json:"currentDeployment"`
+	}
+	json.Unmarshal(raw, &c)
+
+	svc := LightsailContainerService{
+		Name:      c.ContainerServiceName,
+		Arn:       c.Arn,
+		State:     c.State,
+		Power:     c.Power,
+		Scale:     c.Scale,
+		Url:       c.Url,
+		Disabled:  c.IsDisabled,
+		CreatedAt: formatCLITime(c.CreatedAt),
+	}
+	for _, ct := range c.CurrentDeployment.Containers {
+		svc.Images = append(svc.Images, ct.Image)
+	}
+	sort.Strings(svc.Images)
+	return svc
+}
@@ -0,0 +1,125 @@
+package sync
+
+import (
+	"sort"
+	"strings"
+)
+
+// LineagePath is one sketched flow through the data lake: an optional
+// Firehose stream (and its source) writing to an S3 location, the Glue
+// table reading that location, and the Athena workgroups that recently
+// queried the table's database. Any stage after S3 may be missing.
+type LineagePath struct {
+	SourceType string   `json:"sourceType"` // "kinesis", "msk" or "" for direct put
+	SourceId   string   `json:"sourceId"`
+	Stream     string   `json:"stream"` // Firehose delivery stream, "" when nothing cached writes here
+	Bucket     string   `json:"bucket"`
+	Prefix     string   `json:"prefix"`
+	Database   string   `json:"database"`
+	Table      string   `json:"table"`
+	Workgroups []string `json:"workgroups"`
+}
+
+// S3URI returns the path's S3 location as an s3:// URI.
+func (p LineagePath) S3URI() string {
+	return "s3://" + p.Bucket + "/" + p.Prefix
+}
+
+// DataLineage matches Firehose S3 destinations to Glue table locations and
+// Glue databases to the Athena workgroups that queried them. It is a
+// sketch from cached configuration: Glue jobs, crawlers and other writers
+// are not followed.
+func DataLineage(region string) ([]LineagePath, error) {
+	st, err := LoadStreamingData(region)
+	if err != nil {
+		return nil, err
+	}
+	dw, err := LoadDataWarehouseData(region)
+	if err != nil {
+		return nil, err
+	}
+	var streams []FirehoseStream
+	if st != nil {
+		for _, f := range st.Firehose {
+			if f.DestinationType == "s3" {
+				streams = append(streams, f)
+			}
+		}
+	}
+
+	var out []LineagePath
+	written := map[string]bool{}
+	for _, db := range dw.Glue {
+		var workgroups []string
+		for _, wg := range dw.Athena {
+			for _, q := range wg.QueriedDatabases {
+				if q == db.Name {
+					workgroups = append(workgroups, wg.Name)
+					break
+				}
+			}
+		}
+		for _, t := range db.Tables {
+			bucket, prefix, ok := splitS3URI(t.Location)
+			if !ok || t.TableType == "VIRTUAL_VIEW" {
+				continue
+			}
+			base := LineagePath{Bucket: bucket, Prefix: prefix, Database: db.Name, Table: t.Name, Workgroups: workgroups}
+			matched := false
+			for _, f := range streams {
+				if f.DestinationId != bucket || !s3PrefixFeeds(f.DestinationPrefix, prefix) {
+					continue
+				}
+				p := base
+				p.SourceType, p.SourceId, p.Stream = f.SourceType, f.SourceId, f.Name
+				out = append(out, p)
+				written[f.Name] = true
+				matched = true
+			}
+			if !matched {
+				out = append(out, base)
+			}
+		}
+	}
+	// Streams landing in S3 that no table reads yet.
+	for _, f := range streams {
+		if !written[f.Name] {
+			out = append(out, LineagePath{SourceType: f.SourceType, SourceId: f.SourceId, Stream: f.Name,
+				Bucket: f.DestinationId, Prefix: f.DestinationPrefix})
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Bucket != out[j].Bucket {
+			return out[i].Bucket < out[j].Bucket
+		}
+		return out[i].Prefix < out[j].Prefix
+	})
+	return out, nil
+}
+
+// splitS3URI splits s3://bucket/prefix (or s3a://, s3n://) into its parts.
+func splitS3URI(uri string) (bucket, prefix string, ok bool) {
+	for _, scheme := range []string{"s3://", "s3a://", "s3n://"} {
+		if rest, found := strings.CutPrefix(uri, scheme); found {
+			bucket, prefix, _ = strings.Cut(rest, "/")
+			return bucket, prefix, bucket != ""
+		}
+	}
+	return "", "", false
+}
+
+// s3PrefixFeeds reports whether objects written under a Firehose prefix
+// land inside a table location. Prefixes with !{...} expressions are
+// compared up to the first expression, so a dynamically partitioned
+// stream feeds every table under its static part.
+func s3PrefixFeeds(streamPrefix, tablePrefix string) bool {
+	static, _, dynamic := strings.Cut(streamPrefix, "!{")
+	if tablePrefix != "" && !strings.HasSuffix(tablePrefix, "/") {
+		tablePrefix += "/"
+	}
+	if strings.HasPrefix(static, tablePrefix) {
+		return true
+	}
+	return dynamic && strings.HasPrefix(tablePrefix, static)
+}
@@ -0,0 +1,331 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// Listener is a load balancer listener with its default action and, for
+// ALBs, its non-default rules in priority order.
+type Listener struct {
+	Arn           string         `json:"Arn"`
+	Protocol      string         `json:"Protocol"`
+	Port          int            `json:"Port"`
+	Certificate   string         `json:"Certificate"` // default certificate ARN, HTTPS/TLS only
+	SslPolicy     string         `json:"SslPolicy"`
+	DefaultAction ListenerAction `json:"DefaultAction"`
+	Rules         []ListenerRule `json:"Rules"`
+}
+
+type ListenerRule struct {
+	Priority   string         `json:"Priority"`
+	Conditions []string       `json:"Conditions"` // e.g. "host api.example.com", "path /v1/*"
+	Action     ListenerAction `json:"Action"`
+}
+
+// ListenerAction is the terminal action of a listener or rule. Forward
+// actions carry their target groups; others are summarised in Detail.
+type ListenerAction struct {
+	Type            string   `json:"Type"` // forward, redirect, fixed-response, authenticate-oidc, ...
+	TargetGroupArns []string `json:"TargetGroupArns"`
+	Detail          string   `json:"Detail"` // redirect target or fixed response status
+}
+
+// LBTarget is a registered target and its health from describe-target-health.
+type LBTarget struct {
+	Id     string `json:"Id"` // instance ID, IP address or Lambda ARN
+	Port   int    `json:"Port"`
+	AZ     string `json:"AZ"`
+	State  string `json:"State"` // healthy, unhealthy, initial, draining, unused, unavailable
+	Reason string `json:"Reason"`
+}
+
+// HealthyTargets counts targets reporting healthy.
+func (tg TargetGroup) HealthyTargets() int {
+	n := 0
+	for _, t := range tg.Targets {
+		if t.State == "healthy" {
+			n++
+		}
+	}
+	return n
+}
+
+// AttachedTo reports whether the target group is used by the load balancer.
+func (tg TargetGroup) AttachedTo(lbArn string) bool {
+	for _, arn := range tg.LoadBalancerArns {
+		if arn == lbArn {
+			return true
+		}
+	}
+	return tg.LoadBalancerArn == lbArn
+}
+
+// syncListeners fills in each load balancer's listeners and rules.
+func syncListeners(region string, lbs []LoadBalancer) {
+	for i := range lbs {
+		data, err := awscli.Run("elbv2", "describe-listeners", "--load-balancer-arn", lbs[i].Arn, "--region", region)
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			Listeners []struct {
+				ListenerArn  string `json:"ListenerArn"`
+				Protocol     string `json:"Protocol"`
+				Port         int    `json:"Port"`
+				SslPolicy    string `json:"SslPolicy"`
+				Certificates []struct {
+					CertificateArn string `json:"CertificateArn"`
+				} `json:"Certificates"`
+				DefaultActions []json.RawMessage `json:"DefaultActions"`
+			} `json:"Listeners"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, l := range resp.Listeners {
+			lis := Listener{
+				Arn:           l.ListenerArn,
+				Protocol:      l.Protocol,
+				Port:          l.Port,
+				SslPolicy:     l.SslPolicy,
+				DefaultAction: parseListenerActions(l.DefaultActions),
+			}
+			if len(l.Certificates) > 0 {
+				lis.Certificate = l.Certificates[0].CertificateArn
+			}
+			// NLB listeners have no rules beyond the default action.
+			if lbs[i].Type == "application" {
+				if rData, err := awscli.Run("elbv2", "describe-rules", "--listener-arn", l.ListenerArn, "--region", region); err == nil {
+					lis.Rules = parseListenerRules(rData)
+				}
+			}
+			lbs[i].Listeners = append(lbs[i].Listeners, lis)
+		}
+	}
+}
+
+func parseListenerRules(raw []byte) []ListenerRule {
+	var resp struct {
+		Rules []struct {
+			Priority   string `json:"Priority"`
+			IsDefault  bool   `json:"IsDefault"`
+			Conditions []struct {
+				Field            string   `json:"Field"`
+				Values           []string `json:"Values"`
+				HostHeaderConfig struct {
+					Values []string `json:"Values"`
+				} `json:"HostHeaderConfig"`
+				PathPatternConfig struct {
+					Values []string `json:"Values"`
+				} `json:"PathPatternConfig"`
+				HttpHeaderConfig struct {
+					HttpHeaderName string   `json:"HttpHeaderName"`
+					Values         []string `json:"Values"`
+				} `json:"HttpHeaderConfig"`
+				HttpRequestMethodConfig struct {
+					Values []string `json:"Values"`
+				} `json:"HttpRequestMethodConfig"`
+				SourceIpConfig struct {
+					Values []string `json:"Values"`
+				} `json:"SourceIpConfig"`
+			} `json:"Conditions"`
+			Actions []json.RawMessage `json:"Actions"`
+		} `json:"Rules"`
+	}
+	json.Unmarshal(raw, &resp)
+	var out []ListenerRule
+	for _, r := range resp.Rules {
+		if r.IsDefault {
+			continue
+		}
+		rule := ListenerRule{Priority: r.Priority, Action: parseListenerActions(r.Actions)}
+		for _, c := range r.Conditions {
+			values := c.Values
+			label := c.Field
+			switch c.Field {
+			case "host-header":
+				label = "host"
+				values = append(values, c.HostHeaderConfig.Values...)
+			case "path-pattern":
+				label = "path"
+				values = append(values, c.PathPatternConfig.Values...)
+			case "http-header":
+				label = "header " + c.HttpHeaderConfig.HttpHeaderName
+				values = append(values, c.HttpHeaderConfig.Values...)
+			case "http-request-method":
+				label = "method"
+				values = append(values, c.HttpRequestMethodConfig.Values...)
+			case "source-ip":
+				label = "source"
+				values = append(values, c.SourceIpConfig.Values...)
+			}
+			rule.Conditions = append(rule.Conditions, label+" "+strings.Join(dedupe(values), ","))
+		}
+		out = append(out, rule)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return rulePriority(out[i].Priority) < rulePriority(out[j].Priority)
+	})
+	return out
+}
+
+func rulePriority(p string) int {
+	var n int
+	fmt.Sscanf(p, "%d", &n)
+	return n
+}
+
+func dedupe(values []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseListenerActions returns the terminal action of an action list;
+// authenticate actions run first and are only kept when nothing follows.
+func parseListenerActions(actions []json.RawMessage) ListenerAction {
+	var out ListenerAction
+	for _, raw := range actions {
+		var a struct {
+			Type           string `json:"Type"`
+			TargetGroupArn string `json:"TargetGroupArn"`
+			ForwardConfig  struct {
+				TargetGroups []struct {
+					TargetGroupArn string `json:"TargetGroupArn"`
+				} `json:"TargetGroups"`
+			} `json:"ForwardConfig"`
+			RedirectConfig struct {
+				Protocol   string `json:"Protocol"`
+				Host       string `json:"Host"`
+				Port       string `json:"Port"`
+				Path       string `json:"Path"`
+				StatusCode string `json:"StatusCode"`
+			} `json:"RedirectConfig"`
+			FixedResponseConfig struct {
+				StatusCode string `json:"StatusCode"`
+			} `json:"FixedResponseConfig"`
+		}
+		json.Unmarshal(raw, &a)
+		out = ListenerAction{Type: a.Type}
+		switch a.Type {
+		case "forward":
+			if a.TargetGroupArn != "" {
+				out.TargetGroupArns = append(out.TargetGroupArns, a.TargetGroupArn)
+			}
+			for _, tg := range a.ForwardConfig.TargetGroups {
+				if tg.TargetGroupArn != a.TargetGroupArn {
+					out.TargetGroupArns = append(out.TargetGroupArns, tg.TargetGroupArn)
+				}
+			}
+		case "redirect":
+			rc := a.RedirectConfig
+			out.Detail = fmt.Sprintf("%s %s://%s:%s%s", strings.TrimPrefix(rc.StatusCode, "HTTP_"), strings.ToLower(rc.Protocol), rc.Host, rc.Port, rc.Path)
+		case "fixed-response":
+			out.Detail = a.FixedResponseConfig.StatusCode
+		}
+	}
+	return out
+}
+
+// syncTargetHealth fills in the registered targets of each target group.
+func syncTargetHealth(region string, tgs []TargetGroup) {
+	for i := range tgs {
+		data, err := awscli.Run("elbv2", "describe-target-health", "--target-group-arn", tgs[i].Arn, "--region", region)
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			TargetHealthDescriptions []struct {
+				Target struct {
+					Id               string `json:"Id"`
+					Port             int    `json:"Port"`
+					AvailabilityZone string `json:"AvailabilityZone"`
+				} `json:"Target"`
+				TargetHealth struct {
+					State  string `json:"State"`
+					Reason string `json:"Reason"`
+				} `json:"TargetHealth"`
+			} `json:"TargetHealthDescriptions"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, d := range resp.TargetHealthDescriptions {
+			tgs[i].Targets = append(tgs[i].Targets, LBTarget{
+				Id:     d.Target.Id,
+				Port:   d.Target.Port,
+				AZ:     d.Target.AvailabilityZone,
+				State:  d.TargetHealth.State,
+				Reason: d.TargetHealth.Reason,
+			})
+		}
+	}
+}
+
+// TargetGroupByArn returns the cached target group with the ARN, or nil.
+func (d *VPCData) TargetGroupByArn(arn string) *TargetGroup {
+	for i := range d.TargetGroups {
+		if d.TargetGroups[i].Arn == arn {
+			return &d.TargetGroups[i]
+		}
+	}
+	return nil
+}
+
+// ResolveTarget names what a target is, using the compute and ENI caches:
+// an EC2 instance, an ECS task (by IP) or service (by target group), a
+// Lambda function, or the ENI holding the IP. compute may be nil.
+func ResolveTarget(tg TargetGroup, t LBTarget, compute *ComputeData, enis []ENI) string {
+	switch tg.TargetType {
+	case "lambda":
+		return "lambda " + t.Id[strings.LastIndex(t.Id, ":")+1:]
+	case "alb":
+		// arn:...:loadbalancer/app/name/id
+		if parts := strings.Split(t.Id, "/"); len(parts) >= 3 {
+			return "alb " + parts[2]
+		}
+		return "alb " + t.Id
+	}
+	if compute != nil {
+		for _, inst := range compute.EC2 {
+			if inst.InstanceId == t.Id {
+				if inst.Name != "" {
+					return "ec2 " + inst.InstanceId + " (" + inst.Name + ")"
+				}
+				return "ec2 " + inst.InstanceId
+			}
+		}
+		for _, cl := range compute.ECS {
+			for _, task := range cl.Tasks {
+				if task.PrivateIP != "" && task.PrivateIP == t.Id {
+					return "ecs task " + cl.ClusterName + "/" + task.TaskArn[strings.LastIndex(task.TaskArn, "/")+1:]
+				}
+			}
+		}
+		for _, cl := range compute.ECS {
+			for _, svc := range cl.ECSServices {
+				for _, arn := range svc.LBTargetGroups {
+					if arn == tg.Arn {
+						return "ecs service " + cl.ClusterName + "/" + svc.ServiceName + " (" + t.Id + ")"
+					}
+				}
+			}
+		}
+	}
+	for _, n := range enis {
+		if n.PrivateIp == t.Id {
+			return "ip " + t.Id + " (" + n.AttachedTo + ")"
+		}
+	}
+	if tg.TargetType == "ip" {
+		return "ip " + t.Id
+	}
+	return t.Id
+}
@@ -0,0 +1,149 @@
+package sync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// legacyDBDir is where the cache lived before it moved to a per-user
+// directory: .saws under whatever directory saws was started from.
+const legacyDBDir = ".saws"
+
+// projectFile is the per-project config file, see ProjectConfig; its
+// data_dir keeps a project's cache apart from the per-user one.
+const projectFile = ".saws.yaml"
+
+// dataDirOverride is the --data-dir flag, set before InitDB.
+var dataDirOverride string
+
+// SetDataDir makes InitDB open the cache in dir instead of resolving it.
+func SetDataDir(dir string) {
+	dataDirOverride = dir
+}
+
+// DataDir returns the directory holding saws.db: the --data-dir flag, else
+// SAWS_DATA_DIR, else data_dir in the nearest .saws.yaml (relative to that
+// file), else $XDG_DATA_HOME/saws, an existing ~/.saws, ~/.local/share/saws
+// when ~/.local/share exists, or ~/.saws.
+func DataDir() (string, error) {
+	if dataDirOverride != "" {
+		return filepath.Abs(dataDirOverride)
+	}
+	if dir := os.Getenv("SAWS_DATA_DIR"); dir != "" {
+		return filepath.Abs(dir)
+	}
+	if dir, err := projectDataDir(); dir != "" || err != nil {
+		return dir, err
+	}
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "saws"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("no home directory for the cache; set SAWS_DATA_DIR or --data-dir: %w", err)
+	}
+	dotSaws := filepath.Join(home, ".saws")
+	if _, err := os.Stat(dotSaws); err == nil {
+		return dotSaws, nil
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "share")); err == nil {
+		return filepath.Join(home, ".local", "share", "saws"), nil
+	}
+	return dotSaws, nil
+}
+
+// projectDataDir returns data_dir from the nearest .saws.yaml, relative
+// to that file; "" when there is none or it sets no data_dir.
+func projectDataDir() (string, error) {
+	cfg, err := LoadProjectConfig()
+	return cfg.DataDir, err
+}
+
+// legacyDB returns the ./.saws/saws.db of a version before the per-user
+// cache when there is one outside dir, or "". A .saws that .saws.yaml
+// names as the project's cache is not legacy.
+func legacyDB(dir string) string {
+	legacy, err := filepath.Abs(filepath.Join(legacyDBDir, "saws.db"))
+	if err != nil || filepath.Dir(legacy) == dir {
+		return ""
+	}
+	if project, _ := projectDataDir(); project == filepath.Dir(legacy) {
+		return ""
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return ""
+	}
+	return legacy
+}
+
+// checkpoint folds a SQLite database's WAL into its main file, so the
+// file is complete on its own.
+func checkpoint(path string) {
+	if old, err := sql.Open("sqlite", path); err == nil {
+		old.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+		old.Close()
+	}
+}
+
+// moveLegacyDB moves a legacy database to target, which must not exist.
+func moveLegacyDB(legacy, target string) error {
+	checkpoint(legacy)
+	if err := os.Rename(legacy, target); err != nil {
+		return fmt.Errorf("moving %s to %s: %w", legacy, target, err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		os.Remove(legacy + suffix)
+	}
+	os.Remove(filepath.Dir(legacy)) // only if now empty
+	fmt.Fprintf(os.Stderr, "saws: moved the cache from %s to %s\n", legacy, target)
+	return nil
+}
+
+// mergeLegacyDB copies a legacy database's cache entries (where newer),
+// settings and regions (where missing) and annotations into the open
+// database, then renames it to saws.db.migrated so it is merged once.
+// Snapshots and tags are rebuilt by the next sync.
+func mergeLegacyDB(legacy string) error {
+	checkpoint(legacy)
+	ctx := context.Background()
+	conn, err := db.Conn(ctx) // ATTACH is per connection
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS legacy`, legacy); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `DETACH DATABASE legacy`)
+
+	for table, stmt := range map[string]string{
+		"cache": `INSERT INTO cache (key, value, synced_at) SELECT key, value, synced_at FROM legacy.cache WHERE true
+			ON CONFLICT(key) DO UPDATE SET value=excluded.value, synced_at=excluded.synced_at WHERE excluded.synced_at > cache.synced_at`,
+		"settings":    `INSERT OR IGNORE INTO settings (key, value) SELECT key, value FROM legacy.settings`,
+		"regions":     `INSERT OR IGNORE INTO regions (name, enabled) SELECT name, enabled FROM legacy.regions`,
+		"annotations": `INSERT INTO annotations (resource, note, created_at) SELECT resource, note, created_at FROM legacy.annotations`,
+	} {
+		// Older versions didn't create every table.
+		var n int
+		conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM legacy.sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+		if n == 0 {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("merging %s: %w", legacy, err)
+		}
+	}
+	conn.ExecContext(ctx, `DETACH DATABASE legacy`)
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		os.Remove(legacy + suffix)
+	}
+	if err := os.Rename(legacy, legacy+".migrated"); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "saws: merged the cache from %s (kept as saws.db.migrated)\n", legacy)
+	return nil
+}
@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDataDir(t *testing.T) {
+	withTempCache(t)
+	SetDataDir("")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("SAWS_DATA_DIR", "")
+	project := t.TempDir()
+	os.MkdirAll(filepath.Join(project, "infra", "net"), 0755)
+	os.Chdir(filepath.Join(project, "infra", "net"))
+
+	check := func(want string) {
+		t.Helper()
+		if got, err := DataDir(); err != nil || got != want {
+			t.Errorf("DataDir() = %q, %v, want %q", got, err, want)
+		}
+	}
+	check(filepath.Join(home, ".saws"))
+	os.MkdirAll(filepath.Join(home, ".local", "share"), 0755)
+	check(filepath.Join(home, ".local", "share", "saws"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "xdg"))
+	check(filepath.Join(home, "xdg", "saws"))
+
+	// The nearest .saws.yaml, relative to itself.
+	os.WriteFile(filepath.Join(project, ".saws.yaml"), []byte("data_dir: .cache/saws\n"), 0644)
+	check(filepath.Join(project, ".cache", "saws"))
+	t.Setenv("SAWS_DATA_DIR", filepath.Join(home, "env"))
+	check(filepath.Join(home, "env"))
+	SetDataDir(filepath.Join(home, "flag"))
+	check(filepath.Join(home, "flag"))
+}
+
+func TestMigrateLegacyDB(t *testing.T) {
+	withTempCache(t)
+	global := filepath.Join(t.TempDir(), "saws")
+
+	// A project cache from an older version, started in its directory.
+	legacyCache := func(value, warnDays string) string {
+		dir := t.TempDir()
+		os.Chdir(dir)
+		SetDataDir(".saws")
+		if err := InitDB(); err != nil {
+			t.Fatal(err)
+		}
+		WriteCache("us-east-1:lambda", []byte(value))
+		SetSetting("cert_warning_days", warnDays)
+		CloseDB()
+		return filepath.Join(dir, ".saws")
+	}
+
+	// First run: moved.
+	first := legacyCache(`["first"]`, "45")
+	SetDataDir(global)
+	if err := InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ReadCache("us-east-1:lambda"); string(v) != `["first"]` {
+		t.Errorf("moved lambda = %s", v)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("legacy dir still there: %v", err)
+	}
+	CloseDB()
+
+	// Another project: merged, newer entries win, settings already set stay.
+	second := legacyCache(`["second"]`, "60")
+	SetDataDir(global)
+	if err := InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ReadCache("us-east-1:lambda"); string(v) != `["second"]` {
+		t.Errorf("merged lambda = %s", v)
+	}
+	if v, _ := GetSetting("cert_warning_days"); v != "45" {
+		t.Errorf("setting = %s", v)
+	}
+	if _, err := os.Stat(filepath.Join(second, "saws.db.migrated")); err != nil {
+		t.Errorf("legacy db not kept: %v", err)
+	}
+	CloseDB()
+
+	// A project that keeps its cache in .saws on purpose is left alone.
+	third := legacyCache(`["third"]`, "90")
+	os.WriteFile(filepath.Join(filepath.Dir(third), ".saws.yaml"), []byte("data_dir: .saws\n"), 0644)
+	SetDataDir(global)
+	if err := InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(third, "saws.db")); err != nil {
+		t.Errorf("project cache touched: %v", err)
+	}
+}
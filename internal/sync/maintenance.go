@@ -0,0 +1,221 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// CacheStats describes what the cache file holds for the active account.
+type CacheStats struct {
+	Path      string
+	FileSize  int64 // the database and its write-ahead log
+	FreeBytes int64 // unused pages VACUUM would give back
+	Schema    int   // see migrations
+
+	Entries   int
+	Bytes     int64          // stored size of the cached values
+	Regions   []RegionStats  // by region, "global" for keys of no region
+	Keys      []CacheKeyStat // largest first
+	Resources int            // rows in the resources index
+
+	Snapshots       int
+	SnapshotEntries int
+	SnapshotBytes   int64
+}
+
+// RegionStats totals a region's cache entries.
+type RegionStats struct {
+	Region   string
+	Entries  int
+	Bytes    int64
+	LastSync time.Time
+}
+
+// CacheKeyStat is one cache entry's stored size and sync time.
+type CacheKeyStat struct {
+	Key      string
+	Bytes    int64
+	SyncedAt time.Time
+}
+
+// GetCacheStats measures the cache of the active account.
+func GetCacheStats() (*CacheStats, error) {
+	s := &CacheStats{Path: filepath.Join(dbDir, "saws.db"), FileSize: cacheFileSize(), Schema: SchemaVersion()}
+	var pageSize, free int64
+	db.QueryRow(`PRAGMA page_size`).Scan(&pageSize)
+	db.QueryRow(`PRAGMA freelist_count`).Scan(&free)
+	s.FreeBytes = pageSize * free
+
+	rows, err := db.Query(`SELECT key, length(value), synced_at FROM cache WHERE key GLOB ?`, nsPattern())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	regions := map[string]*RegionStats{}
+	for rows.Next() {
+		var k CacheKeyStat
+		var syncedAt string
+		if err := rows.Scan(&k.Key, &k.Bytes, &syncedAt); err != nil {
+			return nil, err
+		}
+		k.Key = plainKey(k.Key)
+		k.SyncedAt, _ = syncedAtTime(syncedAt)
+		s.Keys = append(s.Keys, k)
+		s.Entries++
+		s.Bytes += k.Bytes
+
+		name, ok := keyRegion(k.Key)
+		if !ok {
+			name = "global"
+		}
+		r := regions[name]
+		if r == nil {
+			r = &RegionStats{Region: name}
+			regions[name] = r
+		}
+		r.Entries++
+		r.Bytes += k.Bytes
+		if k.SyncedAt.After(r.LastSync) {
+			r.LastSync = k.SyncedAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(s.Keys, func(i, j int) bool {
+		if s.Keys[i].Bytes != s.Keys[j].Bytes {
+			return s.Keys[i].Bytes > s.Keys[j].Bytes
+		}
+		return s.Keys[i].Key < s.Keys[j].Key
+	})
+	for _, r := range regions {
+		s.Regions = append(s.Regions, *r)
+	}
+	sort.Slice(s.Regions, func(i, j int) bool { return s.Regions[i].Region < s.Regions[j].Region })
+
+	db.QueryRow(`SELECT COUNT(*) FROM resources WHERE account = ?`, Account()).Scan(&s.Resources)
+	db.QueryRow(`SELECT COUNT(*) FROM snapshots WHERE account = ?`, Account()).Scan(&s.Snapshots)
+	db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(length(value)), 0) FROM snapshot_entries WHERE key GLOB ?`,
+		nsPattern()).Scan(&s.SnapshotEntries, &s.SnapshotBytes)
+	return s, nil
+}
+
+// keyRegion returns the region a cache key (with or without its account
+// namespace) belongs to.
+func keyRegion(key string) (string, bool) {
+	if slash := strings.Index(key, "/"); slash >= 0 && slash < strings.Index(key, ":") {
+		key = key[slash+1:]
+	}
+	name, _, ok := strings.Cut(key, ":")
+	if !ok {
+		return "", false
+	}
+	_, isRegion := awscli.RegionNames[name]
+	return name, isRegion
+}
+
+// cacheFileSize returns the size of the database file and its WAL.
+func cacheFileSize() int64 {
+	var size int64
+	path := filepath.Join(dbDir, "saws.db")
+	for _, p := range []string{path, path + "-wal"} {
+		if fi, err := os.Stat(p); err == nil {
+			size += fi.Size()
+		}
+	}
+	return size
+}
+
+// PruneResult counts what PruneCache deleted.
+type PruneResult struct {
+	Entries   int
+	Resources int
+	Snapshots int
+}
+
+// PruneCache deletes, for every account, regional cache entries and
+// indexed resources not synced within olderThan (regions no longer
+// synced, services dropped from .saws.yaml) and snapshots started before
+// it. Data of no region (IAM, S3, sync status, CMDB imports) is rewritten
+// by every sync or kept on purpose, and is left alone.
+func PruneCache(olderThan time.Duration) (*PruneResult, error) {
+	if err := writable(); err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	res := &PruneResult{}
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT key, synced_at FROM cache`)
+	if err != nil {
+		return nil, err
+	}
+	var stale []string
+	for rows.Next() {
+		var key, syncedAt string
+		if err := rows.Scan(&key, &syncedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		t, ok := syncedAtTime(syncedAt)
+		if _, regional := keyRegion(key); regional && ok && t.Before(cutoff) {
+			stale = append(stale, key)
+		}
+	}
+	rows.Close()
+	for _, key := range stale {
+		if _, err := tx.Exec(`DELETE FROM cache WHERE key = ?`, key); err != nil {
+			return nil, err
+		}
+	}
+	res.Entries = len(stale)
+
+	r, err := tx.Exec(`DELETE FROM resources WHERE synced_at < ?`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := r.RowsAffected()
+	res.Resources = int(n)
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var before, after int
+	db.QueryRow(`SELECT COUNT(*) FROM snapshots`).Scan(&before)
+	if err := PruneSnapshots(olderThan); err != nil {
+		return res, err
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM snapshots`).Scan(&after)
+	res.Snapshots = before - after
+	return res, nil
+}
+
+// VacuumCache rebuilds the cache file without the space deleted data left
+// behind, and empties the WAL. It returns the file size before and after.
+func VacuumCache() (before, after int64, err error) {
+	if err := writable(); err != nil {
+		return 0, 0, err
+	}
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	before = cacheFileSize()
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		return before, before, err
+	}
+	if _, err := db.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return before, before, err
+	}
+	return before, cacheFileSize(), nil
+}
@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneCache(t *testing.T) {
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	for _, key := range []string{"ca-west-1:lambda", "ca-west-1:sqs", "ca-west-1:ecs", "cmdb:records"} {
+		WriteCache(key, []byte(`[]`))
+	}
+	// Lambda and ECS stopped syncing two months ago; so did the CMDB import,
+	// which is kept anyway.
+	for _, key := range []string{"ca-west-1:lambda", "ca-west-1:ecs", "cmdb:records"} {
+		db.Exec(`UPDATE cache SET synced_at = ? WHERE key = ?`, old, nsKey(key))
+	}
+
+	gen := CacheGeneration()
+	res, err := PruneCache(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Entries != 2 {
+		t.Errorf("pruned %d entries, want 2", res.Entries)
+	}
+	if CacheGeneration() == gen {
+		t.Error("prune left the cache generation unchanged")
+	}
+	for key, want := range map[string]bool{"ca-west-1:lambda": false, "ca-west-1:ecs": false, "ca-west-1:sqs": true, "cmdb:records": true} {
+		if CacheExists(key) != want {
+			t.Errorf("%s cached = %v after prune", key, !want)
+		}
+	}
+
+	s, err := GetCacheStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range s.Regions {
+		if r.Region == "ca-west-1" && r.Entries != 1 {
+			t.Errorf("ca-west-1 stats = %+v", r)
+		}
+	}
+	if _, _, err := VacuumCache(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,427 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// metricsOverride is set by `saws sync --metrics` to fetch metrics for one
+// run without changing the sync_metrics setting.
+var metricsOverride bool
+
+// EnableMetrics turns on CloudWatch metric collection for this process.
+func EnableMetrics() {
+	metricsOverride = true
+}
+
+// MetricsEnabled reports whether syncs should pull CloudWatch metrics.
+// Metrics are off by default since GetMetricData is billed per metric.
+func MetricsEnabled() bool {
+	if metricsOverride {
+		return true
+	}
+	v, _ := GetSetting("sync_metrics")
+	return v == "true"
+}
+
+// LambdaMetrics is a 24h snapshot taken at sync time.
+type LambdaMetrics struct {
+	Invocations float64 `json:"Invocations"`
+	Errors      float64 `json:"Errors"`
+	Throttles   float64 `json:"Throttles"`
+	DurationP95 float64 `json:"DurationP95"` // milliseconds
+	CollectedAt string  `json:"CollectedAt"`
+}
+
+// ErrorRate returns errors as a percentage of invocations.
+func (m *LambdaMetrics) ErrorRate() float64 {
+	if m == nil || m.Invocations == 0 {
+		return 0
+	}
+	return m.Errors / m.Invocations * 100
+}
+
+// metricQuery is one GetMetricData query. Dimensions are name/value pairs.
+type metricQuery struct {
+	Id         string
+	Namespace  string
+	MetricName string
+	Dimensions [][2]string
+	Period     int
+	Stat       string
+}
+
+// getMetricData runs the queries in batches of 500 (the API limit) and
+// returns the datapoint values keyed by query Id. Failed batches are skipped.
+func getMetricData(region string, start, end time.Time, queries []metricQuery) map[string][]float64 {
+	type dimension struct {
+		Name  string `json:"Name"`
+		Value string `json:"Value"`
+	}
+	type apiQuery struct {
+		Id         string `json:"Id"`
+		MetricStat struct {
+			Metric struct {
+				Namespace  string      `json:"Namespace"`
+				MetricName string      `json:"MetricName"`
+				Dimensions []dimension `json:"Dimensions"`
+			} `json:"Metric"`
+			Period int    `json:"Period"`
+			Stat   string `json:"Stat"`
+		} `json:"MetricStat"`
+	}
+
+	out := map[string][]float64{}
+	const batch = 500
+	for i := 0; i < len(queries); i += batch {
+		var api []apiQuery
+		for _, q := range queries[i:min(i+batch, len(queries))] {
+			var a apiQuery
+			a.Id = q.Id
+			a.MetricStat.Metric.Namespace = q.Namespace
+			a.MetricStat.Metric.MetricName = q.MetricName
+			for _, d := range q.Dimensions {
+				a.MetricStat.Metric.Dimensions = append(a.MetricStat.Metric.Dimensions, dimension{d[0], d[1]})
+			}
+			a.MetricStat.Period = q.Period
+			a.MetricStat.Stat = q.Stat
+			api = append(api, a)
+		}
+		qJSON, _ := json.Marshal(api)
+		data, err := awscli.Run("cloudwatch", "get-metric-data", "--region", region,
+			"--start-time", start.Format(time.RFC3339), "--end-time", end.Format(time.RFC3339),
+			"--metric-data-queries", string(qJSON))
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			MetricDataResults []struct {
+				Id     string    `json:"Id"`
+				Values []float64 `json:"Values"`
+			} `json:"MetricDataResults"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, r := range resp.MetricDataResults {
+			out[r.Id] = append(out[r.Id], r.Values...)
+		}
+	}
+	return out
+}
+
+// fetchLambdaMetrics pulls 24h Invocations/Errors/Throttles sums and the
+// Duration p95 for each function.
+func fetchLambdaMetrics(region string, names []string) map[string]*LambdaMetrics {
+	end := time.Now().UTC()
+	start := end.Add(-24 * time.Hour)
+	collected := end.Format(time.RFC3339)
+
+	stats := []struct{ metric, stat string }{
+		{"Invocations", "Sum"}, {"Errors", "Sum"}, {"Throttles", "Sum"}, {"Duration", "p95"},
+	}
+	var queries []metricQuery
+	for fi, name := range names {
+		for si, s := range stats {
+			queries = append(queries, metricQuery{
+				Id:         fmt.Sprintf("f%d_%d", fi, si),
+				Namespace:  "AWS/Lambda",
+				MetricName: s.metric,
+				Dimensions: [][2]string{{"FunctionName", name}},
+				Period:     86400,
+				Stat:       s.stat,
+			})
+		}
+	}
+	values := getMetricData(region, start, end, queries)
+
+	out := map[string]*LambdaMetrics{}
+	for fi, name := range names {
+		var m *LambdaMetrics
+		for si, s := range stats {
+			vals, ok := values[fmt.Sprintf("f%d_%d", fi, si)]
+			if !ok {
+				continue
+			}
+			if m == nil {
+				m = &LambdaMetrics{CollectedAt: collected}
+				out[name] = m
+			}
+			for _, v := range vals {
+				switch s.metric {
+				case "Invocations":
+					m.Invocations += v
+				case "Errors":
+					m.Errors += v
+				case "Throttles":
+					m.Throttles += v
+				case "Duration":
+					m.DurationP95 = max(m.DurationP95, v)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// FirehoseMetrics is a 24h delivery snapshot taken at sync time.
+type FirehoseMetrics struct {
+	IncomingRecords  float64 `json:"IncomingRecords"`
+	ThrottledRecords float64 `json:"ThrottledRecords"`
+	DeliverySuccess  float64 `json:"DeliverySuccess"` // average of the 0-1 success metric
+	HasDelivery      bool    `json:"HasDelivery"`     // any delivery attempts were reported
+	DataFreshness    float64 `json:"DataFreshness"`   // max age in seconds of the oldest undelivered record
+	CollectedAt      string  `json:"CollectedAt"`
+}
+
+// SuccessPercent returns the delivery success rate as a percentage.
+func (m *FirehoseMetrics) SuccessPercent() float64 {
+	if m == nil {
+		return 0
+	}
+	return m.DeliverySuccess * 100
+}
+
+// fetchFirehoseMetrics pulls 24h IncomingRecords and ThrottledRecords sums
+// and the destination's DeliveryTo*.Success and .DataFreshness for each
+// stream. Redshift streams report freshness on their S3 staging step.
+func fetchFirehoseMetrics(region string, streams []FirehoseStream) map[string]*FirehoseMetrics {
+	end := time.Now().UTC()
+	start := end.Add(-24 * time.Hour)
+	collected := end.Format(time.RFC3339)
+
+	var queries []metricQuery
+	for i, f := range streams {
+		dims := [][2]string{{"DeliveryStreamName", f.Name}}
+		stats := []struct{ key, metric, stat string }{
+			{"in", "IncomingRecords", "Sum"},
+			{"thr", "ThrottledRecords", "Sum"},
+		}
+		if f.deliveryMetric != "" {
+			freshness := f.deliveryMetric
+			if freshness == "DeliveryToRedshift" {
+				freshness = "DeliveryToS3"
+			}
+			stats = append(stats,
+				struct{ key, metric, stat string }{"ok", f.deliveryMetric + ".Success", "Average"},
+				struct{ key, metric, stat string }{"age", freshness + ".DataFreshness", "Maximum"},
+			)
+		}
+		for _, s := range stats {
+			queries = append(queries, metricQuery{
+				Id:         fmt.Sprintf("d%d_%s", i, s.key),
+				Namespace:  "AWS/Firehose",
+				MetricName: s.metric,
+				Dimensions: dims,
+				Period:     86400,
+				Stat:       s.stat,
+			})
+		}
+	}
+	values := getMetricData(region, start, end, queries)
+
+	out := map[string]*FirehoseMetrics{}
+	for i, f := range streams {
+		key := fmt.Sprintf("d%d_", i)
+		in, ok := values[key+"in"]
+		if !ok {
+			continue
+		}
+		m := &FirehoseMetrics{CollectedAt: collected}
+		for _, v := range in {
+			m.IncomingRecords += v
+		}
+		for _, v := range values[key+"thr"] {
+			m.ThrottledRecords += v
+		}
+		if success := values[key+"ok"]; len(success) > 0 {
+			m.HasDelivery = true
+			m.DeliverySuccess = mean(success)
+		}
+		m.DataFreshness = maxOf(values[key+"age"])
+		out[f.Name] = m
+	}
+	return out
+}
+
+// UtilizationMetrics summarizes CPU and memory utilization (percent) over
+// the lookback window using daily datapoints.
+type UtilizationMetrics struct {
+	CPUAvg      float64 `json:"CPUAvg"`
+	CPUMax      float64 `json:"CPUMax"`
+	MemoryAvg   float64 `json:"MemoryAvg"`
+	MemoryMax   float64 `json:"MemoryMax"`
+	Days        int     `json:"Days"`
+	CollectedAt string  `json:"CollectedAt"`
+}
+
+// utilizationDays is the lookback window for right-sizing metrics.
+const utilizationDays = 14
+
+// fetchECSServiceMetrics pulls 14-day CPU and memory utilization for each
+// service. Keys are "cluster/service".
+func fetchECSServiceMetrics(region string, services [][2]string) map[string]*UtilizationMetrics {
+	end := time.Now().UTC()
+	start := end.Add(-utilizationDays * 24 * time.Hour)
+	collected := end.Format(time.RFC3339)
+
+	var queries []metricQuery
+	for i, svc := range services {
+		dims := [][2]string{{"ClusterName", svc[0]}, {"ServiceName", svc[1]}}
+		for _, metric := range []string{"CPUUtilization", "MemoryUtilization"} {
+			for _, stat := range []string{"Average", "Maximum"} {
+				queries = append(queries, metricQuery{
+					Id:         fmt.Sprintf("s%d_%s_%s", i, strings.ToLower(metric[:3]), strings.ToLower(stat[:3])),
+					Namespace:  "AWS/ECS",
+					MetricName: metric,
+					Dimensions: dims,
+					Period:     86400,
+					Stat:       stat,
+				})
+			}
+		}
+	}
+	values := getMetricData(region, start, end, queries)
+
+	out := map[string]*UtilizationMetrics{}
+	for i, svc := range services {
+		id := fmt.Sprintf("s%d_", i)
+		cpuAvg, cpuMax := values[id+"cpu_ave"], values[id+"cpu_max"]
+		if len(cpuAvg) == 0 {
+			continue
+		}
+		out[svc[0]+"/"+svc[1]] = &UtilizationMetrics{
+			CPUAvg:      mean(cpuAvg),
+			CPUMax:      maxOf(cpuMax),
+			MemoryAvg:   mean(values[id+"mem_ave"]),
+			MemoryMax:   maxOf(values[id+"mem_max"]),
+			Days:        len(cpuAvg),
+			CollectedAt: collected,
+		}
+	}
+	return out
+}
+
+// EC2Metrics adds network throughput and (when the CloudWatch agent is
+// installed) memory to the utilization summary.
+type EC2Metrics struct {
+	UtilizationMetrics
+	NetworkIn  float64 `json:"NetworkIn"`  // average bytes per day
+	NetworkOut float64 `json:"NetworkOut"` // average bytes per day
+	HasMemory  bool    `json:"HasMemory"`  // CWAgent mem_used_percent was found
+}
+
+// fetchEC2Metrics pulls 14-day CPU, network and CWAgent memory statistics
+// for each instance. Memory is only found when the agent publishes
+// mem_used_percent with an InstanceId-only dimension set (the default).
+func fetchEC2Metrics(region string, ids []string) map[string]*EC2Metrics {
+	end := time.Now().UTC()
+	start := end.Add(-utilizationDays * 24 * time.Hour)
+	collected := end.Format(time.RFC3339)
+
+	stats := []struct{ key, namespace, metric, stat string }{
+		{"cpu_ave", "AWS/EC2", "CPUUtilization", "Average"},
+		{"cpu_max", "AWS/EC2", "CPUUtilization", "Maximum"},
+		{"net_in", "AWS/EC2", "NetworkIn", "Sum"},
+		{"net_out", "AWS/EC2", "NetworkOut", "Sum"},
+		{"mem_ave", "CWAgent", "mem_used_percent", "Average"},
+		{"mem_max", "CWAgent", "mem_used_percent", "Maximum"},
+	}
+	var queries []metricQuery
+	for i, id := range ids {
+		for _, s := range stats {
+			queries = append(queries, metricQuery{
+				Id:         fmt.Sprintf("i%d_%s", i, s.key),
+				Namespace:  s.namespace,
+				MetricName: s.metric,
+				Dimensions: [][2]string{{"InstanceId", id}},
+				Period:     86400,
+				Stat:       s.stat,
+			})
+		}
+	}
+	values := getMetricData(region, start, end, queries)
+
+	out := map[string]*EC2Metrics{}
+	for i, id := range ids {
+		key := fmt.Sprintf("i%d_", i)
+		cpuAvg := values[key+"cpu_ave"]
+		if len(cpuAvg) == 0 {
+			continue
+		}
+		m := &EC2Metrics{
+			UtilizationMetrics: UtilizationMetrics{
+				CPUAvg:      mean(cpuAvg),
+				CPUMax:      maxOf(values[key+"cpu_max"]),
+				Days:        len(cpuAvg),
+				CollectedAt: collected,
+			},
+			NetworkIn:  mean(values[key+"net_in"]),
+			NetworkOut: mean(values[key+"net_out"]),
+		}
+		if mem := values[key+"mem_ave"]; len(mem) > 0 {
+			m.HasMemory = true
+			m.MemoryAvg = mean(mem)
+			m.MemoryMax = maxOf(values[key+"mem_max"])
+		}
+		out[id] = m
+	}
+	return out
+}
+
+func mean(vals []float64) float64 {
+	if len(vals) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range vals {
+		sum += v
+	}
+	return sum / float64(len(vals))
+}
+
+func maxOf(vals []float64) float64 {
+	var m float64
+	for _, v := range vals {
+		m = max(m, v)
+	}
+	return m
+}
+
+// LambdaSortKeys are the accepted values for SortLambdas.
+var LambdaSortKeys = []string{"name", "errors", "error-rate", "throttles", "duration"}
+
+// SortLambdas orders functions in place. Metric sorts are descending so the
+// noisiest functions come first; functions without metrics sort last.
+func SortLambdas(fns []LambdaFunction, by string) {
+	metric := func(fn LambdaFunction) float64 {
+		m := fn.Metrics
+		if m == nil {
+			return -1
+		}
+		switch by {
+		case "errors":
+			return m.Errors
+		case "error-rate":
+			return m.ErrorRate()
+		case "throttles":
+			return m.Throttles
+		case "duration":
+			return m.DurationP95
+		}
+		return 0
+	}
+	sort.SliceStable(fns, func(i, j int) bool {
+		if by == "" || by == "name" {
+			return fns[i].FunctionName < fns[j].FunctionName
+		}
+		a, b := metric(fns[i]), metric(fns[j])
+		if a != b {
+			return a > b
+		}
+		return fns[i].FunctionName < fns[j].FunctionName
+	})
+}
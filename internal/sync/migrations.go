@@ -0,0 +1,185 @@
+package sync
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// A migration moves the cache schema up one version. InitDB runs those
+// newer than the file's schema_version, in order, in one transaction, so
+// a file is never left half-migrated and two processes starting at once
+// migrate it once.
+//
+// Append new migrations to the list; never edit, reorder or remove one
+// that has been released. Migrations up to 4 describe the schema as it
+// was before it was versioned: files written then already have some of
+// their tables, so they use IF NOT EXISTS and addColumn. Later ones can
+// assume exactly the schema the previous ones left.
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{1, "cache, settings, regions, annotations and tags", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS cache (
+				key    TEXT PRIMARY KEY,
+				value  TEXT NOT NULL,
+				synced_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE TABLE IF NOT EXISTS settings (
+				key   TEXT PRIMARY KEY,
+				value TEXT NOT NULL
+			);
+			CREATE TABLE IF NOT EXISTS regions (
+				name     TEXT PRIMARY KEY,
+				enabled  INTEGER NOT NULL DEFAULT 1
+			);
+			CREATE TABLE IF NOT EXISTS annotations (
+				resource   TEXT NOT NULL,
+				note       TEXT NOT NULL,
+				created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE TABLE IF NOT EXISTS tags (
+				region        TEXT NOT NULL,
+				arn           TEXT NOT NULL,
+				service       TEXT NOT NULL,
+				resource_type TEXT NOT NULL,
+				resource_id   TEXT NOT NULL,
+				key           TEXT NOT NULL,
+				value         TEXT NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS tags_key_value ON tags (key, value);
+		`)
+		return err
+	}},
+	{2, "sync snapshots", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS snapshots (
+				id          INTEGER PRIMARY KEY AUTOINCREMENT,
+				region      TEXT NOT NULL,
+				scope       TEXT NOT NULL,
+				started_at  DATETIME NOT NULL,
+				finished_at DATETIME
+			);
+			CREATE TABLE IF NOT EXISTS snapshot_entries (
+				sync_id   INTEGER NOT NULL,
+				key       TEXT NOT NULL,
+				value     TEXT NOT NULL,
+				synced_at DATETIME NOT NULL,
+				PRIMARY KEY (sync_id, key)
+			);
+		`)
+		return err
+	}},
+	{3, "account of tags and snapshots", func(tx *sql.Tx) error {
+		for _, table := range []string{"tags", "snapshots"} {
+			if err := addColumn(tx, table, "account", `TEXT NOT NULL DEFAULT ''`); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{4, "resources index", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE IF NOT EXISTS resources (
+				account   TEXT NOT NULL,
+				region    TEXT NOT NULL,
+				type      TEXT NOT NULL,
+				id        TEXT NOT NULL,
+				name      TEXT NOT NULL,
+				arn       TEXT NOT NULL,
+				vpc_id    TEXT NOT NULL,
+				refs      TEXT NOT NULL, -- JSON array of "type/id"
+				json      TEXT NOT NULL,
+				synced_at DATETIME NOT NULL,
+				PRIMARY KEY (account, region, type, id)
+			);
+			CREATE INDEX IF NOT EXISTS resources_type ON resources (account, type);
+			CREATE INDEX IF NOT EXISTS resources_arn ON resources (arn);
+		`)
+		return err
+	}},
+	{5, "cache generation", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			CREATE TABLE cache_generation (n INTEGER NOT NULL);
+			INSERT INTO cache_generation (n) VALUES (0);
+			CREATE TRIGGER cache_generation_insert AFTER INSERT ON cache
+				BEGIN UPDATE cache_generation SET n = n + 1; END;
+			CREATE TRIGGER cache_generation_update AFTER UPDATE ON cache
+				BEGIN UPDATE cache_generation SET n = n + 1; END;
+			CREATE TRIGGER cache_generation_delete AFTER DELETE ON cache
+				BEGIN UPDATE cache_generation SET n = n + 1; END;
+		`)
+		return err
+	}},
+}
+
+// SchemaVersion returns the cache's schema version.
+func SchemaVersion() int {
+	var v int
+	db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&v)
+	return v
+}
+
+// migrate brings the open cache up to the latest schema.
+func migrate() error {
+	latest := migrations[len(migrations)-1].version
+	if readOnly {
+		if v := SchemaVersion(); v != latest {
+			return fmt.Errorf("read-only mode: the cache has schema version %d, this saws needs %d; run it once without --read-only to migrate", v, latest)
+		}
+		return nil
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var current int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&current); err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("the cache was written by a newer saws (schema version %d, this one knows up to %d); upgrade saws or use another --data-dir", current, latest)
+	}
+	if current == latest {
+		return nil
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := m.up(tx); err != nil {
+			return fmt.Errorf("migrating the cache to schema version %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	if _, err := tx.Exec(`DELETE FROM schema_version`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_version (version) VALUES (?)`, latest); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addColumn adds column to table unless it already has it.
+func addColumn(tx *sql.Tx, table, column, def string) error {
+	var n int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	_, err := tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + def)
+	return err
+}
@@ -0,0 +1,54 @@
+package sync
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	saved := db
+	defer func() { db = saved }()
+	var err error
+	db, err = sql.Open("sqlite", filepath.Join(t.TempDir(), "saws.db")+"?_txlock=immediate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A cache from before snapshots, account namespaces and versioning.
+	db.Exec(`CREATE TABLE cache (key TEXT PRIMARY KEY, value TEXT NOT NULL, synced_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE tags (region TEXT NOT NULL, arn TEXT NOT NULL, service TEXT NOT NULL, resource_type TEXT NOT NULL,
+			resource_id TEXT NOT NULL, key TEXT NOT NULL, value TEXT NOT NULL);
+		INSERT INTO cache (key, value) VALUES ('us-east-1:vpcs', '[]');
+		INSERT INTO tags VALUES ('us-east-1', 'arn:aws:ec2:us-east-1:1:vpc/vpc-1', 'ec2', 'vpc', 'vpc-1', 'env', 'prod');`)
+
+	if err := migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if v := SchemaVersion(); v != migrations[len(migrations)-1].version {
+		t.Errorf("schema version = %d", v)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM tags WHERE account = '' AND value = 'prod'`).Scan(&n)
+	if n != 1 {
+		t.Errorf("tags not kept with an empty account")
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM cache`).Scan(&n)
+	if n != 1 {
+		t.Errorf("cache entries = %d after migrating", n)
+	}
+	if _, err := db.Exec(`INSERT INTO resources (account, region, type, id, name, arn, vpc_id, refs, json, synced_at)
+		VALUES ('', 'us-east-1', 'vpc', 'vpc-1', '', '', '', '[]', '{}', CURRENT_TIMESTAMP)`); err != nil {
+		t.Errorf("resources: %v", err)
+	}
+	if err := migrate(); err != nil {
+		t.Errorf("migrating again: %v", err)
+	}
+
+	db.Exec(`UPDATE schema_version SET version = version + 1`)
+	if err := migrate(); err == nil || !strings.Contains(err.Error(), "newer saws") {
+		t.Errorf("err = %v, want a newer schema refused", err)
+	}
+}
@@ -0,0 +1,243 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files under testdata")
+
+// testdata is resolved before TestMain moves into a scratch directory.
+var testdata string
+
+// TestMain runs the package's tests against a throwaway cache database and
+// a fake AWS CLI, so nothing reads ./.saws or calls AWS.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	time.Local = time.UTC
+
+	wd, _ := os.Getwd()
+	testdata = filepath.Join(wd, "testdata")
+	dir, err := os.MkdirTemp("", "saws-test")
+	if err != nil {
+		panic(err)
+	}
+	os.Chdir(dir)
+	detectAccount = func() string { return "" }
+	SetDataDir(dir)
+	if err := InitDB(); err != nil {
+		panic(err)
+	}
+	restore := awscli.Use(fakeAWS())
+
+	code := m.Run()
+
+	restore()
+	CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// withTempCache moves a test into an empty working directory with no cache
+// open and no account detected. When the test ends it closes whatever cache
+// the test opened and puts back the one TestMain opened, along with the
+// data dir, account, read-only mode and working directory.
+func withTempCache(t *testing.T) string {
+	t.Helper()
+	prevDB, prevDetect, prevDir, prevDBDir, prevAccount := db, detectAccount, dataDirOverride, dbDir, Account()
+	wd, _ := os.Getwd()
+	dir := t.TempDir()
+	os.Chdir(dir)
+	db = nil
+	detectAccount = func() string { return "" }
+	t.Cleanup(func() {
+		CloseDB()
+		readOnly = false
+		db, detectAccount, dbDir = prevDB, prevDetect, prevDBDir
+		SetDataDir(prevDir)
+		UseAccount(prevAccount)
+		os.Chdir(wd)
+	})
+	return dir
+}
+
+// fakeAWS answers the lookups that parse functions make while enriching
+// what they parse, e.g. the policies of an instance's IAM role.
+func fakeAWS() *awscli.Fake {
+	return awscli.NewFake().
+		On("iam get-instance-profile", `{"InstanceProfile": {"Roles": [{"RoleName": "web-role"}]}}`).
+		On("iam list-attached-role-policies", `{"AttachedPolicies": [{"PolicyName": "AmazonSSMManagedInstanceCore"}]}`).
+		On("iam list-role-policies", `{"PolicyNames": ["s3-read"]}`).
+		On("sagemaker describe-endpoint", `{"EndpointConfigName": "churn-config"}`).
+		On("sagemaker describe-endpoint-config", `{"ProductionVariants": [{"ModelName": "churn-xgb", "InstanceType": "ml.m5.large", "InitialInstanceCount": 2}]}`).
+		On("elasticache describe-cache-subnet-groups", `{"CacheSubnetGroups": [{"VpcId": "vpc-0a1b2c3d"}]}`)
+}
+
+// parsers maps every parse* function in the package to a call on its
+// fixture, testdata/parse/<name>.json.
+var parsers = map[string]func([]byte) any{
+	"parseAppRunnerService":             func(b []byte) any { return parseAppRunnerService(b) },
+	"parseAthenaWorkgroup":              func(b []byte) any { return parseAthenaWorkgroup(b) },
+	"parseAutoScalingGroup":             func(b []byte) any { return parseAutoScalingGroup(b) },
+	"parseBedrockAgent":                 func(b []byte) any { return parseBedrockAgent(b) },
+	"parseBedrockCustomModel":           func(b []byte) any { return parseBedrockCustomModel(b) },
+	"parseBedrockDataSource":            func(b []byte) any { return parseBedrockDataSource(b) },
+	"parseBedrockGuardrail":             func(b []byte) any { return parseBedrockGuardrail(b) },
+	"parseBedrockKnowledgeBase":         func(b []byte) any { return parseBedrockKnowledgeBase(b) },
+	"parseBedrockModel":                 func(b []byte) any { return parseBedrockModel(b) },
+	"parseBedrockProvisionedThroughput": func(b []byte) any { return parseBedrockProvisionedThroughput(b) },
+	"parseCFStack":                      func(b []byte) any { return parseCFStack(b) },
+	"parseCFStackResource":              func(b []byte) any { return parseCFStackResource(b) },
+	"parseCodeBuildProject":             func(b []byte) any { return parseCodeBuildProject(b) },
+	"parseCodeBuildRun": func(b []byte) any {
+		project, run := parseCodeBuildRun(b)
+		return map[string]any{"project": project, "run": run}
+	},
+	"parseCodePipeline":      func(b []byte) any { return parseCodePipeline(b) },
+	"parseCriticalFindings":  func(b []byte) any { return parseCriticalFindings(b) },
+	"parseDynamoDBTable":     func(b []byte) any { return parseDynamoDBTable(b) },
+	"parseEBSVolumes":        func(b []byte) any { return parseEBSVolumes(b) },
+	"parseEC2Instance":       func(b []byte) any { return parseEC2Instance(b) },
+	"parseECRImages":         func(b []byte) any { return parseECRImages(b) },
+	"parseECSCluster":        func(b []byte) any { return parseECSCluster(b) },
+	"parseECSService":        func(b []byte) any { return parseECSService(b) },
+	"parseECSTask":           func(b []byte) any { return parseECSTask(b) },
+	"parseECSTaskDef":        func(b []byte) any { return parseECSTaskDef(b) },
+	"parseEFSFileSystem":     func(b []byte) any { return parseEFSFileSystem(b) },
+	"parseENI":               func(b []byte) any { return parseENI(b) },
+	"parseElastiCache":       func(b []byte) any { return parseElastiCache(b, "us-east-1") },
+	"parseFSxFileSystem":     func(b []byte) any { return parseFSxFileSystem(b) },
+	"parseFederatedTrust":    func(b []byte) any { return parseFederatedTrust(b) },
+	"parseFirehoseStream":    func(b []byte) any { return parseFirehoseStream(b) },
+	"parseFlowLog":           func(b []byte) any { return parseFlowLog(b) },
+	"parseGlueCrawlers":      func(b []byte) any { return parseGlueCrawlers(b) },
+	"parseGlueDatabase":      func(b []byte) any { return parseGlueDatabase(b) },
+	"parseGlueJobs":          func(b []byte) any { return parseGlueJobs(b) },
+	"parseGuardDutyFindings": func(b []byte) any { return parseGuardDutyFindings(b) },
+	"parseIGW":               func(b []byte) any { return parseIGW(b) },
+	"parseIAMPolicyDocument": func(b []byte) any {
+		statements, document := parseIAMPolicyDocument(b)
+		return map[string]any{"statements": statements, "document": document}
+	},
+	"parseIAMUser":                   func(b []byte) any { return parseIAMUser(b) },
+	"parseIdentityPool":              func(b []byte) any { return parseIdentityPool(b) },
+	"parseLB":                        func(b []byte) any { return parseLB(b) },
+	"parseLambdaFunction":            func(b []byte) any { return parseLambdaFunction(b) },
+	"parseLightsailContainerService": func(b []byte) any { return parseLightsailContainerService(b) },
+	"parseLightsailInstance":         func(b []byte) any { return parseLightsailInstance(b) },
+	"parseListenerActions": func(b []byte) any {
+		var actions []json.RawMessage
+		json.Unmarshal(b, &actions)
+		return parseListenerActions(actions)
+	},
+	"parseListenerRules": func(b []byte) any { return parseListenerRules(b) },
+	"parseMQBroker":      func(b []byte) any { return parseMQBroker(b) },
+	"parseMSKCluster":    func(b []byte) any { return parseMSKCluster(b) },
+	"parseMeshRoute":     func(b []byte) any { return parseMeshRoute("api-route", b) },
+	"parseNATGW":         func(b []byte) any { return parseNATGW(b) },
+	"parseNetworkACL":    func(b []byte) any { return parseNetworkACL(b) },
+	"parseOIDCProvider": func(b []byte) any {
+		return parseOIDCProvider(b, "arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com")
+	},
+	"parseOpenSearchDomain":     func(b []byte) any { return parseOpenSearchDomain(b) },
+	"parsePeering":              func(b []byte) any { return parsePeering(b) },
+	"parseRDSCluster":           func(b []byte) any { return parseRDSCluster(b) },
+	"parseRDSInstance":          func(b []byte) any { return parseRDSInstance(b) },
+	"parseRecordSets":           func(b []byte) any { return parseRecordSets(b) },
+	"parseRedshiftCluster":      func(b []byte) any { return parseRedshiftCluster(b) },
+	"parseRedshiftWorkgroup":    func(b []byte) any { return parseRedshiftWorkgroup(b) },
+	"parseRouteTable":           func(b []byte) any { return parseRouteTable(b) },
+	"parseS3Bucket":             func(b []byte) any { return parseS3Bucket(b) },
+	"parseSAMLProvider":         func(b []byte) any { return parseSAMLProvider(b) },
+	"parseSG":                   func(b []byte) any { return parseSG(b) },
+	"parseSageMakerDomain":      func(b []byte) any { return parseSageMakerDomain(b) },
+	"parseSageMakerEndpoint":    func(b []byte) any { return parseSageMakerEndpoint(b, "us-east-1") },
+	"parseSageMakerModel":       func(b []byte) any { return parseSageMakerModel(b) },
+	"parseSageMakerNotebook":    func(b []byte) any { return parseSageMakerNotebook(b) },
+	"parseSageMakerPipeline":    func(b []byte) any { return parseSageMakerPipeline(b) },
+	"parseSageMakerTrainingJob": func(b []byte) any { return parseSageMakerTrainingJob(b) },
+	"parseSubnet":               func(b []byte) any { return parseSubnet(b) },
+	"parseTG":                   func(b []byte) any { return parseTG(b) },
+	"parseTagMapping":           func(b []byte) any { return parseTagMapping(b) },
+	"parseVPC":                  func(b []byte) any { return parseVPC(b) },
+	"parseVPCEndpoint":          func(b []byte) any { return parseVPCEndpoint(b) },
+	"parseWebACL":               func(b []byte) any { return parseWebACL(b) },
+}
+
+// TestParseGolden feeds each fixture through its parser and compares the
+// JSON result with testdata/parse/<name>.golden. Run with -update after
+// an intended change and review the diff.
+func TestParseGolden(t *testing.T) {
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			in, err := os.ReadFile(filepath.Join(testdata, "parse", name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(parse(in), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := filepath.Join(testdata, "parse", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("output differs from %s; got:\n%s", golden, got)
+			}
+		})
+	}
+}
+
+// TestParsersCovered fails when a parse* function is added without a
+// fixture, so new sync modules come with one.
+func TestParsersCovered(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(testdata), "*.go"))
+	if len(files) == 0 {
+		t.Fatal("no package sources found next to testdata")
+	}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !isParseFunc(fn.Name.Name) {
+				continue
+			}
+			if _, ok := parsers[fn.Name.Name]; !ok {
+				t.Errorf("%s has no entry in parsers and no fixture in testdata/parse", fn.Name.Name)
+			}
+		}
+	}
+}
+
+// isParseFunc matches names like parseVPC.
+func isParseFunc(name string) bool {
+	return strings.HasPrefix(name, "parse") && len(name) > 5 && name[5] >= 'A' && name[5] <= 'Z'
+}
@@ -2,7 +2,7 @@ package sync
 
 import (
 	"fmt"
-	"sync/atomic"
+	gosync "sync"
 	"time"
 )
 
@@ -10,70 +10,139 @@ import (
 type SyncJob struct {
 	ID          string `json:"id"`
 	Completed   int64  `json:"completed"`
-	Status      string `json:"status"` // "running", "done", "error"
+	Total       int64  `json:"total,omitempty"` // steps the tab's last sync took; 0 the first time
+	Status      string `json:"status"`          // "running", "done", "error"
 	Tab         string `json:"tab"`
 	Region      string `json:"region"`
 	CurrentStep string `json:"currentStep,omitempty"`
 	Error       string `json:"error,omitempty"`
 }
 
-// activeSyncJob holds the current sync job in memory (no need for SQLite).
-var activeSyncJob atomic.Pointer[SyncJob]
+var (
+	// progressMu guards activeSyncJob, its fields, lastTotals and
+	// progressSubs. The job lives in memory only (no need for SQLite).
+	progressMu    gosync.Mutex
+	activeSyncJob *SyncJob
+	// lastTotals is the number of steps each tab's last finished sync
+	// took, the best guess at how many the next one will.
+	lastTotals   = map[string]int64{}
+	progressSubs = map[chan SyncJob]bool{}
+)
 
-// StartSync creates a new sync job and returns its ID.
-func StartSync(tab, region string) string {
+// StartSync creates a new sync job and returns its ID. It returns false,
+// starting nothing, while another job is running, so concurrent requests
+// can't both start one.
+func StartSync(tab, region string) (string, bool) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if activeSyncJob != nil && activeSyncJob.Status == "running" {
+		return "", false
+	}
 	id := fmt.Sprintf("%d", time.Now().UnixNano())
-	job := &SyncJob{
+	activeSyncJob = &SyncJob{
 		ID:     id,
 		Status: "running",
 		Tab:    tab,
 		Region: region,
+		Total:  lastTotals[tab],
 	}
-	activeSyncJob.Store(job)
-	return id
+	publish()
+	return id, true
 }
 
-// IncrSync atomically increments the completed count and sets the current step label.
+// IncrSync increments the completed count and sets the current step label.
 func IncrSync(jobID string, label string) {
-	job := activeSyncJob.Load()
-	if job == nil || job.ID != jobID {
-		return
-	}
-	atomic.AddInt64(&job.Completed, 1)
-	job.CurrentStep = label
+	updateSync(jobID, func(job *SyncJob) {
+		job.Completed++
+		if job.Total > 0 && job.Completed > job.Total {
+			job.Total = job.Completed
+		}
+		job.CurrentStep = label
+	})
 }
 
 // FinishSync marks the active job as done.
 func FinishSync(jobID string) {
-	job := activeSyncJob.Load()
-	if job == nil || job.ID != jobID {
-		return
-	}
-	job.Status = "done"
+	updateSync(jobID, func(job *SyncJob) {
+		job.Status = "done"
+		job.CurrentStep = ""
+		lastTotals[job.Tab] = job.Completed
+	})
 }
 
 // ErrorSync marks the active job as errored.
 func ErrorSync(jobID string, errMsg string) {
-	job := activeSyncJob.Load()
-	if job == nil || job.ID != jobID {
+	updateSync(jobID, func(job *SyncJob) {
+		job.Status = "error"
+		job.Error = errMsg
+	})
+}
+
+// updateSync applies f to the active job if it is jobID, and tells the
+// subscribers.
+func updateSync(jobID string, f func(*SyncJob)) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if activeSyncJob == nil || activeSyncJob.ID != jobID {
 		return
 	}
-	job.Status = "error"
-	job.Error = errMsg
+	f(activeSyncJob)
+	publish()
 }
 
-// GetSyncProgress returns the current sync job (or nil if none).
+// publish sends the active job to every subscriber. A subscriber that has
+// not taken the previous update gets this one instead: only the latest
+// state matters. Call with progressMu held.
+func publish() {
+	job := *activeSyncJob
+	for ch := range progressSubs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- job
+	}
+}
+
+// SubscribeSync returns a channel receiving the active job each time it
+// changes, starting with its current state if there is one, and a
+// function that ends the subscription.
+func SubscribeSync() (<-chan SyncJob, func()) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	ch := make(chan SyncJob, 1)
+	if activeSyncJob != nil {
+		ch <- *activeSyncJob
+	}
+	progressSubs[ch] = true
+	return ch, func() {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		delete(progressSubs, ch)
+	}
+}
+
+// GetSyncProgress returns a copy of the current sync job (or nil if none).
 func GetSyncProgress() *SyncJob {
-	return activeSyncJob.Load()
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	if activeSyncJob == nil {
+		return nil
+	}
+	job := *activeSyncJob
+	return &job
 }
 
 // IsSyncing returns true if a sync is currently running.
 func IsSyncing() bool {
-	job := activeSyncJob.Load()
-	return job != nil && job.Status == "running"
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	return activeSyncJob != nil && activeSyncJob.Status == "running"
 }
 
 // ClearSync removes the active sync job.
 func ClearSync() {
-	activeSyncJob.Store(nil)
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	activeSyncJob = nil
 }
@@ -0,0 +1,50 @@
+package sync
+
+import "testing"
+
+func TestSubscribeSync(t *testing.T) {
+	defer ClearSync()
+
+	id, _ := StartSync("compute", "eu-west-1")
+	if _, ok := StartSync("database", "eu-west-1"); ok {
+		t.Fatal("started a second sync while one was running")
+	}
+	IncrSync(id, "ec2")
+	IncrSync(id, "lambda")
+	FinishSync(id)
+
+	updates, cancel := SubscribeSync()
+	defer cancel()
+	if job := <-updates; job.Status != "done" || job.Completed != 2 {
+		t.Fatalf("current state = %+v", job)
+	}
+
+	id, ok := StartSync("compute", "eu-west-1")
+	if !ok {
+		t.Fatal("could not start a sync after the last one finished")
+	}
+	if job := <-updates; job.ID != id || job.Total != 2 {
+		t.Errorf("start = %+v, want total from the last compute sync", job)
+	}
+	// A slow subscriber only sees the latest state.
+	IncrSync(id, "ec2")
+	IncrSync(id, "lambda")
+	IncrSync(id, "ecs")
+	if job := <-updates; job.Completed != 3 || job.Total != 3 || job.CurrentStep != "ecs" {
+		t.Errorf("latest = %+v", job)
+	}
+	IncrSync("stale", "ec2")
+	select {
+	case job := <-updates:
+		t.Errorf("update for another job: %+v", job)
+	default:
+	}
+
+	cancel()
+	ErrorSync(id, "boom")
+	select {
+	case job := <-updates:
+		t.Errorf("update after cancel: %+v", job)
+	default:
+	}
+}
@@ -0,0 +1,125 @@
+package sync
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfig is the nearest .saws.yaml: where the project's cache lives
+// and what saws syncs for it. Empty fields leave the default in place.
+//
+//	data_dir: .saws
+//	profile: prod-readonly
+//	regions: [us-east-1, eu-west-1]
+//	services: [network, compute, database, iam]
+//	concurrency: 4
+//	exclude: [ec2/i-0abc123, lambda/test-*]
+type ProjectConfig struct {
+	DataDir     string   `yaml:"data_dir"`
+	Profile     string   `yaml:"profile"`     // AWS CLI profile, unless AWS_PROFILE is set
+	Regions     []string `yaml:"regions"`     // regions to sync and show, instead of the region toggles
+	Services    []string `yaml:"services"`    // sync groups, see SyncGroups
+	Concurrency int      `yaml:"concurrency"` // sync groups run at once; default 1
+	Exclude     []string `yaml:"exclude"`     // "type/id" or "type/name" globs left out of the inventory
+
+	path string
+}
+
+// Path returns the file the config was read from, "" when there is none.
+func (c *ProjectConfig) Path() string {
+	return c.path
+}
+
+// LoadProjectConfig reads the nearest .saws.yaml in the working directory
+// or its parents, or returns an empty config when there is none.
+func LoadProjectConfig() (*ProjectConfig, error) {
+	cfg := &ProjectConfig{}
+	dir, err := os.Getwd()
+	if err != nil {
+		return cfg, nil
+	}
+	for {
+		p := filepath.Join(dir, projectFile)
+		if b, err := os.ReadFile(p); err == nil {
+			if err := yaml.Unmarshal(b, cfg); err != nil {
+				return &ProjectConfig{}, fmt.Errorf("%s: %w", p, err)
+			}
+			cfg.path = p
+			if err := cfg.validate(); err != nil {
+				return &ProjectConfig{}, fmt.Errorf("%s: %w", p, err)
+			}
+			if cfg.DataDir != "" && !filepath.IsAbs(cfg.DataDir) {
+				cfg.DataDir = filepath.Join(dir, cfg.DataDir)
+			}
+			return cfg, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return cfg, nil
+		}
+		dir = parent
+	}
+}
+
+// projectConfig is LoadProjectConfig for callers that have already
+// surfaced its errors: InitDB fails on a malformed file.
+func projectConfig() *ProjectConfig {
+	cfg, _ := LoadProjectConfig()
+	return cfg
+}
+
+func (c *ProjectConfig) validate() error {
+	for _, r := range c.Regions {
+		if _, ok := awscli.RegionNames[r]; !ok {
+			return fmt.Errorf("regions: unknown region %q", r)
+		}
+	}
+	for _, s := range c.Services {
+		if syncGroup(s) == nil {
+			return fmt.Errorf("services: unknown service group %q (known: %s)", s, strings.Join(SyncGroupNames(), ", "))
+		}
+	}
+	if c.Concurrency < 0 {
+		return fmt.Errorf("concurrency: expected a positive number, got %d", c.Concurrency)
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "/") {
+			return fmt.Errorf("exclude: %q is not a type/id pattern", pattern)
+		}
+	}
+	return nil
+}
+
+// Excludes reports whether .saws.yaml leaves it out of the inventory.
+func (c *ProjectConfig) Excludes(it InventoryItem) bool {
+	for _, pattern := range c.Exclude {
+		if ok, _ := path.Match(pattern, it.Key()); ok {
+			return true
+		}
+		if it.Name != "" {
+			if ok, _ := path.Match(pattern, it.Type+"/"+it.Name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UseProjectProfile points the AWS CLI at the profile .saws.yaml names,
+// unless AWS_PROFILE already picks one.
+func UseProjectProfile() error {
+	cfg, err := LoadProjectConfig()
+	if err != nil {
+		return err
+	}
+	if cfg.Profile != "" && os.Getenv("AWS_PROFILE") == "" {
+		return os.Setenv("AWS_PROFILE", cfg.Profile)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProjectConfig(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	project := t.TempDir()
+	os.MkdirAll(filepath.Join(project, "app"), 0755)
+	os.Chdir(filepath.Join(project, "app"))
+	write := func(yaml string) {
+		os.WriteFile(filepath.Join(project, ".saws.yaml"), []byte(yaml), 0644)
+	}
+
+	write(`profile: prod-readonly
+regions: [us-east-1, eu-west-1]
+services: [network, iam, compute]
+concurrency: 3
+exclude: [ec2/i-0abc, lambda/test-*]
+`)
+	cfg, err := LoadProjectConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Path() != filepath.Join(project, ".saws.yaml") || cfg.Profile != "prod-readonly" {
+		t.Errorf("config = %+v", cfg)
+	}
+	var names []string
+	for _, g := range SelectedSyncGroups() {
+		names = append(names, g.Name)
+	}
+	if got := strings.Join(names, " "); got != "network compute iam" {
+		t.Errorf("groups = %s, want sync order", got)
+	}
+	if SyncConcurrency() != 3 {
+		t.Errorf("concurrency = %d", SyncConcurrency())
+	}
+	if enabled, _ := GetEnabledRegions(); strings.Join(enabled, " ") != "eu-west-1 us-east-1" {
+		t.Errorf("enabled regions = %v", enabled)
+	}
+	for _, tc := range []struct {
+		it   InventoryItem
+		want bool
+	}{
+		{InventoryItem{Type: "ec2", ID: "i-0abc"}, true},
+		{InventoryItem{Type: "ec2", ID: "i-0def"}, false},
+		{InventoryItem{Type: "lambda", ID: "fn-1", Name: "test-users"}, true},
+		{InventoryItem{Type: "lambda", ID: "prod-users"}, false},
+	} {
+		if cfg.Excludes(tc.it) != tc.want {
+			t.Errorf("Excludes(%s) = %v", tc.it.Key(), !tc.want)
+		}
+	}
+
+	t.Setenv("AWS_PROFILE", "")
+	os.Unsetenv("AWS_PROFILE")
+	if err := UseProjectProfile(); err != nil || os.Getenv("AWS_PROFILE") != "prod-readonly" {
+		t.Errorf("AWS_PROFILE = %q, %v", os.Getenv("AWS_PROFILE"), err)
+	}
+	t.Setenv("AWS_PROFILE", "mine")
+	UseProjectProfile()
+	if os.Getenv("AWS_PROFILE") != "mine" {
+		t.Errorf("an explicit AWS_PROFILE was overridden")
+	}
+
+	for yaml, want := range map[string]string{
+		"services: [network, ec3]\n": `unknown service group "ec3"`,
+		"regions: [us-east-7]\n":     `unknown region "us-east-7"`,
+		"exclude: [i-0abc]\n":        `not a type/id pattern`,
+		"concurrency: -1\n":          `concurrency`,
+	} {
+		write(yaml)
+		if _, err := LoadProjectConfig(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err = %v, want %s", yaml, err, want)
+		}
+	}
+
+	// Without services, every group syncs.
+	write("data_dir: .saws\n")
+	if len(SelectedSyncGroups()) < len(SyncGroups)-1 {
+		t.Errorf("groups = %d", len(SelectedSyncGroups()))
+	}
+}
+
+func TestRunSyncGroups(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	dir := t.TempDir()
+	os.Chdir(dir)
+	os.WriteFile(filepath.Join(dir, ".saws.yaml"), []byte("concurrency: 2\n"), 0644)
+
+	var running, peak, done atomic.Int32
+	RunSyncGroups(SyncGroups[:5], func(SyncGroup) {
+		n := running.Add(1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		done.Add(1)
+	})
+	if done.Load() != 5 || peak.Load() != 2 {
+		t.Errorf("done = %d, peak = %d, want 5 and 2", done.Load(), peak.Load())
+	}
+}
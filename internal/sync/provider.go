@@ -0,0 +1,164 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Provider is a read-only inventory source for another cloud, synced
+// through that cloud's CLI into the same cache as AWS. Providers register
+// themselves from init (see internal/providers) and are turned on with the
+// "providers" setting. Their resources join LoadInventory, so the graph,
+// handoff, CMDB and warehouse exports pick them up, and each item is shown
+// on the tab Tab names.
+type Provider interface {
+	// Name is the short ID used in settings, cache keys and as the prefix
+	// of item types, e.g. "gcp" for "gcp-vm".
+	Name() string
+	// Title is the display name, e.g. "Google Cloud".
+	Title() string
+	// Available returns nil when the provider's CLI is installed and
+	// signed in, or an error explaining what is missing.
+	Available() error
+	// Sync pulls the provider's resources into the cache, writing through
+	// st. Providers are not tied to an AWS region; each item carries its
+	// own location.
+	Sync(st *Stage, step func(string)) ([]SyncResult, error)
+	// Inventory returns the cached resources.
+	Inventory() ([]InventoryItem, error)
+	// Tab returns the tab ("net", "compute", "database", "s3", ...) an item
+	// type belongs on.
+	Tab(itemType string) string
+}
+
+var providers = map[string]Provider{}
+
+// RegisterProvider makes a provider available to the "providers" setting.
+// It panics on a duplicate name, like database/sql drivers.
+func RegisterProvider(p Provider) {
+	if _, dup := providers[p.Name()]; dup || p.Name() == "aws" {
+		panic("sync: provider " + p.Name() + " registered twice")
+	}
+	providers[p.Name()] = p
+}
+
+// RegisteredProviders returns every compiled-in provider, sorted by name.
+func RegisteredProviders() []Provider {
+	out := make([]Provider, 0, len(providers))
+	for _, p := range providers {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// EnabledProviders returns the providers listed in the "providers"
+// setting. AWS is always on and not listed.
+func EnabledProviders() []Provider {
+	v, _ := GetSetting("providers")
+	var out []Provider
+	for _, name := range strings.Split(v, ",") {
+		if p, ok := providers[strings.TrimSpace(name)]; ok {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ProviderFor returns the enabled provider owning an item type, or nil.
+func ProviderFor(itemType string) Provider {
+	for _, p := range EnabledProviders() {
+		if strings.HasPrefix(itemType, p.Name()+"-") {
+			return p
+		}
+	}
+	return nil
+}
+
+// ProviderGroup is one provider's items on a tab.
+type ProviderGroup struct {
+	Title string
+	Items []InventoryItem
+}
+
+// ProviderItems returns the enabled providers' cached items for a tab.
+func ProviderItems(tab string) []ProviderGroup {
+	var out []ProviderGroup
+	for _, p := range EnabledProviders() {
+		items, _ := p.Inventory()
+		g := ProviderGroup{Title: p.Title()}
+		for _, it := range items {
+			if p.Tab(it.Type) == tab {
+				g.Items = append(g.Items, it)
+			}
+		}
+		if len(g.Items) > 0 {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+// ProviderItem looks up one cached provider item for the detail panel.
+func ProviderItem(itemType, id string) *InventoryItem {
+	p := ProviderFor(itemType)
+	if p == nil {
+		return nil
+	}
+	items, _ := p.Inventory()
+	for i := range items {
+		if items[i].Type == itemType && items[i].ID == id {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// providerInventory returns the items of every enabled provider.
+func providerInventory() []InventoryItem {
+	var out []InventoryItem
+	for _, p := range EnabledProviders() {
+		items, _ := p.Inventory()
+		out = append(out, items...)
+	}
+	return out
+}
+
+// RunCLI runs another cloud's CLI and returns its JSON output, the
+// counterpart of awscli.Run for providers. Callers pass the flag that
+// selects JSON output themselves since each CLI spells it differently.
+func RunCLI(bin string, args ...string) (json.RawMessage, error) {
+	if readOnly {
+		return nil, fmt.Errorf("read-only mode: saws does not call %s", bin)
+	}
+	out, err := exec.Command(bin, args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("%s %s: %s", bin, args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("%s %s: %w", bin, args[0], err)
+	}
+	return json.RawMessage(out), nil
+}
+
+// validateProviderList accepts a comma-separated list of registered
+// provider names, or "" for AWS only.
+func validateProviderList(v string) error {
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := providers[name]; !ok {
+			var known []string
+			for _, p := range RegisteredProviders() {
+				known = append(known, p.Name())
+			}
+			return fmt.Errorf("unknown provider %q (available: %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// DefaultQuotaWarnPct is used when the quota_warning_pct setting is unset.
+const DefaultQuotaWarnPct = 80
+
+// QuotaWarningPct returns the quota_warning_pct setting: the share of a
+// quota at which it is reported as near its limit.
+func QuotaWarningPct() int {
+	if v, err := GetSetting("quota_warning_pct"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			return n
+		}
+	}
+	return DefaultQuotaWarnPct
+}
+
+// Quota is an applied service quota with usage counted from the cache.
+type Quota struct {
+	ServiceCode string  `json:"ServiceCode"`
+	QuotaCode   string  `json:"QuotaCode"`
+	Name        string  `json:"Name"`
+	Value       float64 `json:"Value"`
+	Usage       int     `json:"Usage"`
+	Unit        string  `json:"Unit,omitempty"` // what usage counts when not resources, e.g. "vCPUs"
+}
+
+// UsageText returns e.g. "12 of 32 vCPUs".
+func (q Quota) UsageText() string {
+	s := fmt.Sprintf("%d of %.0f", q.Usage, q.Value)
+	if q.Unit != "" {
+		s += " " + q.Unit
+	}
+	return s
+}
+
+// UsedPercent returns usage as a percentage of the quota.
+func (q Quota) UsedPercent() float64 {
+	if q.Value <= 0 {
+		return 0
+	}
+	return float64(q.Usage) / q.Value * 100
+}
+
+// NearLimit reports whether usage is at or over QuotaWarningPct.
+func (q Quota) NearLimit() bool {
+	return q.NearLimitAt(QuotaWarningPct())
+}
+
+// NearLimitAt reports whether usage is at or over pct of the quota.
+func (q Quota) NearLimitAt(pct int) bool {
+	return q.Value > 0 && q.UsedPercent() >= float64(pct)
+}
+
+// quotaSources is the cached data quota usage is counted from. Fields are
+// never nil; missing caches are empty.
+type quotaSources struct {
+	vpc     *VPCData
+	compute *ComputeData
+	lambda  lambdaAccount
+}
+
+// lambdaAccount is the region's Lambda concurrency from
+// get-account-settings.
+type lambdaAccount struct {
+	ConcurrentExecutions           int `json:"ConcurrentExecutions"`
+	UnreservedConcurrentExecutions int `json:"UnreservedConcurrentExecutions"`
+}
+
+// quotaChecks are the quotas whose usage can be counted from cached data.
+var quotaChecks = []struct {
+	service, code, name, unit string
+	usage                     func(quotaSources) int
+}{
+	{"vpc", "L-F678F1CE", "VPCs per Region", "", func(s quotaSources) int { return len(s.vpc.VPCs) }},
+	{"vpc", "L-A4707A72", "Internet gateways per Region", "", func(s quotaSources) int { return len(s.vpc.IGWs) }},
+	{"vpc", "L-FE5A380F", "NAT gateways per Availability Zone", "", func(s quotaSources) int { return natGatewaysPerAZ(s.vpc) }},
+	{"vpc", "L-E79EC296", "VPC security groups per Region", "", func(s quotaSources) int { return len(s.vpc.SecurityGroups) }},
+	{"ec2", "L-0263D0A3", "EC2-VPC Elastic IPs", "", func(s quotaSources) int { return len(s.vpc.ElasticIPs) }},
+	{"ec2", "L-A2478D36", "Transit gateways per account", "", func(s quotaSources) int { return len(s.vpc.TransitGateways) }},
+	{"ec2", "L-1216C47A", "Running On-Demand Standard (A, C, D, H, I, M, R, T, Z) instances", "vCPUs", func(s quotaSources) int { return standardVCPUs(s.compute) }},
+	{"elasticloadbalancing", "L-53DA6B97", "Application Load Balancers per Region", "", func(s quotaSources) int {
+		n := 0
+		for _, lb := range s.vpc.LoadBalancers {
+			if lb.Type == "application" {
+				n++
+			}
+		}
+		return n
+	}},
+	// Reserved concurrency counts against the account limit, and AWS keeps
+	// 100 unreserved for functions without a reservation.
+	{"lambda", "L-B99A9384", "Concurrent executions", "reserved", func(s quotaSources) int {
+		return s.lambda.ConcurrentExecutions - s.lambda.UnreservedConcurrentExecutions
+	}},
+}
+
+// standardVCPUs estimates the vCPUs of running instances in the standard
+// families, which share one vCPU-based On-Demand quota. Spot instances in
+// the cache are counted too; unknown sizes (metal, family-specific) are
+// skipped.
+func standardVCPUs(d *ComputeData) int {
+	n := 0
+	for _, inst := range d.EC2 {
+		if inst.State != "running" || inst.InstanceType == "" || !strings.ContainsAny(inst.InstanceType[:1], "acdhimrtz") {
+			continue
+		}
+		n += ec2VCPUs(inst.InstanceType)
+	}
+	return n
+}
+
+// ec2VCPUs returns the vCPUs of an instance type from its size: 2 per
+// .large. Burstable types have at least 2 (1 for the smallest t2 sizes).
+func ec2VCPUs(instanceType string) int {
+	family, size, _ := strings.Cut(instanceType, ".")
+	i := ec2SizeIndex(size)
+	if i < 0 {
+		return 0
+	}
+	v := int(2 * ec2Sizes[i].factor)
+	if strings.HasPrefix(family, "t") {
+		switch {
+		case family == "t2" && ec2Sizes[i].factor < 0.5:
+			return 1
+		case v < 2:
+			return 2
+		}
+	}
+	if v < 1 {
+		v = 1
+	}
+	return v
+}
+
+// natGatewaysPerAZ returns the NAT gateway count of the busiest AZ.
+func natGatewaysPerAZ(d *VPCData) int {
+	azOf := map[string]string{}
+	for _, s := range d.Subnets {
+		azOf[s.SubnetId] = s.AvailabilityZone
+	}
+	perAZ := map[string]int{}
+	most := 0
+	for _, n := range d.NATGWs {
+		if n.State != "available" && n.State != "pending" {
+			continue
+		}
+		az := azOf[n.SubnetId]
+		perAZ[az]++
+		if perAZ[az] > most {
+			most = perAZ[az]
+		}
+	}
+	return most
+}
+
+// SyncQuotaData fetches the applied value of each quota in quotaChecks,
+// and the Lambda account settings its concurrency usage comes from.
+// Quotas without an applied value fall back to the AWS default.
+func SyncQuotaData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	var quotas []Quota
+	var lastErr error
+	for _, c := range quotaChecks {
+		args := []string{"--service-code", c.service, "--quota-code", c.code, "--region", region}
+		data, err := awscli.Run(append([]string{"service-quotas", "get-service-quota"}, args...)...)
+		if err != nil {
+			data, err = awscli.Run(append([]string{"service-quotas", "get-aws-default-service-quota"}, args...)...)
+		}
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var resp struct {
+			Quota struct {
+				QuotaName string  `json:"QuotaName"`
+				Value     float64 `json:"Value"`
+			} `json:"Quota"`
+		}
+		json.Unmarshal(data, &resp)
+		name := resp.Quota.QuotaName
+		if name == "" {
+			name = c.name
+		}
+		quotas = append(quotas, Quota{ServiceCode: c.service, QuotaCode: c.code, Name: name, Value: resp.Quota.Value, Unit: c.unit})
+	}
+	if data, err := awscli.Run("lambda", "get-account-settings", "--region", region); err == nil {
+		var resp struct {
+			AccountLimit lambdaAccount `json:"AccountLimit"`
+		}
+		json.Unmarshal(data, &resp)
+		b, _ := json.Marshal(resp.AccountLimit)
+		st.WriteCache(region+":lambda-account", b)
+	}
+	step("service quotas")
+
+	if len(quotas) == 0 && lastErr != nil {
+		if st == nil {
+			recordUsageHistory(region)
+		}
+		return []SyncResult{{Service: "service-quotas", Error: lastErr.Error()}}, nil
+	}
+	b, _ := json.Marshal(quotas)
+	st.WriteCache(region+":quotas", b)
+	if st == nil {
+		// A staged sync records usage once it commits; see FinishSnapshot.
+		recordUsageHistory(region)
+	}
+	return []SyncResult{{Service: "service-quotas", Count: len(quotas)}}, nil
+}
+
+// LoadQuotas returns the cached quotas with usage counted from the cached
+// network, compute and Lambda data.
+func LoadQuotas(region string) ([]Quota, error) {
+	raw, err := ReadCache(region + ":quotas")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var quotas []Quota
+	json.Unmarshal(raw, &quotas)
+
+	src := quotaSources{vpc: &VPCData{}, compute: &ComputeData{}}
+	if vpc, _ := LoadVPCData(region); vpc != nil {
+		src.vpc = vpc
+	}
+	if compute, _ := LoadComputeData(region); compute != nil {
+		src.compute = compute
+	}
+	if raw, _ := ReadCache(region + ":lambda-account"); raw != nil {
+		json.Unmarshal(raw, &src.lambda)
+	}
+	for i, q := range quotas {
+		for _, c := range quotaChecks {
+			if c.code == q.QuotaCode {
+				quotas[i].Usage = c.usage(src)
+			}
+		}
+	}
+	return quotas, nil
+}
+
+// QuotasNearLimit returns the cached quotas at or over QuotaWarningPct.
+func QuotasNearLimit(region string) []Quota {
+	quotas, _ := LoadQuotas(region)
+	pct := QuotaWarningPct()
+	var out []Quota
+	for _, q := range quotas {
+		if q.NearLimitAt(pct) {
+			out = append(out, q)
+		}
+	}
+	return out
+}
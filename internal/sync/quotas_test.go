@@ -0,0 +1,16 @@
+package sync
+
+import "testing"
+
+func TestStandardVCPUs(t *testing.T) {
+	d := &ComputeData{EC2: []EC2Instance{
+		{State: "running", InstanceType: "m5.xlarge"},
+		{State: "running", InstanceType: "t3.micro"},
+		{State: "running", InstanceType: "p4d.24xlarge"}, // not a standard family
+		{State: "stopped", InstanceType: "c5.large"},
+		{State: "running"}, // imported without a type
+	}}
+	if got := standardVCPUs(d); got != 6 {
+		t.Errorf("standardVCPUs = %d, want 6", got)
+	}
+}
@@ -0,0 +1,36 @@
+package sync
+
+import (
+	"errors"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// ErrReadOnly is returned by everything that would change the cache in
+// read-only mode.
+var ErrReadOnly = errors.New("read-only mode: the cache cannot be changed")
+
+// readOnly is set by SetReadOnly.
+var readOnly bool
+
+// SetReadOnly serves the cache as it is, for shared and demo deployments.
+// Call it before InitDB. saws then never runs the AWS CLI or other clouds'
+// CLIs, InitDB neither creates, migrates nor re-encrypts the cache, and
+// SQLite refuses any write that gets past the ErrReadOnly checks.
+func SetReadOnly() {
+	readOnly = true
+	awscli.SetReadOnly()
+}
+
+// ReadOnly reports whether SetReadOnly was called.
+func ReadOnly() bool {
+	return readOnly
+}
+
+// writable returns ErrReadOnly in read-only mode.
+func writable() error {
+	if readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package sync
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	withTempCache(t)
+	SetDataDir("cache")
+	if err := InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	WriteCache("us-east-1:lambda", []byte(`["resize"]`))
+	CloseDB()
+
+	readOnly = true
+	// Read-only never creates a cache.
+	SetDataDir("missing")
+	if err := InitDB(); err == nil {
+		t.Fatal("InitDB opened a cache that does not exist")
+	}
+	if _, err := os.Stat("missing"); !os.IsNotExist(err) {
+		t.Errorf("read-only InitDB created the data dir")
+	}
+
+	SetDataDir("cache")
+	if err := InitDB(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := ReadCache("us-east-1:lambda"); string(v) != `["resize"]` {
+		t.Errorf("lambda = %s", v)
+	}
+	if err := WriteCache("us-east-1:lambda", []byte(`[]`)); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("WriteCache = %v, want ErrReadOnly", err)
+	}
+	if err := SetSetting("stale_after_hours", "1"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetSetting = %v, want ErrReadOnly", err)
+	}
+	if _, err := ImportBundle(&Bundle{Regions: []string{"us-east-1"}}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("ImportBundle = %v, want ErrReadOnly", err)
+	}
+	if _, err := BeginSnapshot("us-east-1", "all"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("BeginSnapshot = %v, want ErrReadOnly", err)
+	}
+	// Writes that get past the checks are refused by SQLite.
+	if _, err := db.Exec(`INSERT INTO settings (key, value) VALUES ('k', 'v')`); err == nil {
+		t.Error("the read-only connection accepted a write")
+	}
+	if v, _ := ReadCache("us-east-1:lambda"); string(v) != `["resize"]` {
+		t.Errorf("lambda after refused writes = %s", v)
+	}
+}
@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// RedshiftDiskWarnPct is the disk usage at which a cluster is flagged as
+// near capacity. Redshift needs free space for sorts and vacuums, so
+// queries start failing well before 100%.
+const RedshiftDiskWarnPct = 80
+
+// redshiftLongQuerySeconds is how long a running query must have been
+// executing to count as long-running.
+const redshiftLongQuerySeconds = 300
+
+// RedshiftHealth is a performance snapshot taken at sync time. Disk usage
+// comes from CloudWatch; the WLM figures from a Data API query against
+// stv_wlm_query_state, which needs IAM access to the database.
+type RedshiftHealth struct {
+	DiskUsedPct   float64 `json:"DiskUsedPct"`
+	HasDisk       bool    `json:"HasDisk"`
+	QueuedQueries int     `json:"QueuedQueries"`
+	MaxQueueWait  float64 `json:"MaxQueueWait"` // seconds, longest current wait
+	LongRunning   int     `json:"LongRunning"`  // queries running over redshiftLongQuerySeconds
+	HasWLM        bool    `json:"HasWLM"`
+	WLMError      string  `json:"WLMError"`
+	CollectedAt   string  `json:"CollectedAt"`
+}
+
+// DiskWarning reports whether the cluster is near disk capacity.
+func (c RedshiftCluster) DiskWarning() bool {
+	return c.Health != nil && c.Health.HasDisk && c.Health.DiskUsedPct >= RedshiftDiskWarnPct
+}
+
+// syncRedshiftHealth collects disk usage and WLM state for each available
+// cluster and caches them under region:redshift-health by cluster ID.
+func syncRedshiftHealth(st *Stage, region string, clusters []RedshiftCluster) {
+	end := time.Now().UTC()
+	start := end.Add(-time.Hour)
+	var queries []metricQuery
+	for i, c := range clusters {
+		queries = append(queries, metricQuery{
+			Id:         fmt.Sprintf("r%d", i),
+			Namespace:  "AWS/Redshift",
+			MetricName: "PercentageDiskSpaceUsed",
+			Dimensions: [][2]string{{"ClusterIdentifier", c.ClusterIdentifier}},
+			Period:     3600,
+			Stat:       "Maximum",
+		})
+	}
+	values := getMetricData(region, start, end, queries)
+
+	health := map[string]*RedshiftHealth{}
+	for i, c := range clusters {
+		h := &RedshiftHealth{CollectedAt: end.Format(time.RFC3339)}
+		if v := values[fmt.Sprintf("r%d", i)]; len(v) > 0 {
+			h.HasDisk = true
+			h.DiskUsedPct = maxOf(v)
+		}
+		if c.Status == "available" && c.DBName != "" {
+			queryWLMState(region, c, h)
+		}
+		health[c.ClusterIdentifier] = h
+	}
+	b, _ := json.Marshal(health)
+	st.WriteCache(region+":redshift-health", b)
+}
+
+// queryWLMState runs one query through the Redshift Data API with the
+// caller's IAM identity and waits up to 15s for the result.
+func queryWLMState(region string, c RedshiftCluster, h *RedshiftHealth) {
+	sql := fmt.Sprintf(`SELECT
+  SUM(CASE WHEN state LIKE 'Queued%%' THEN 1 ELSE 0 END),
+  (MAX(CASE WHEN state LIKE 'Queued%%' THEN queue_time ELSE 0 END) / 1000000.0)::float8,
+  SUM(CASE WHEN state = 'Running' AND exec_time > %d THEN 1 ELSE 0 END)
+FROM stv_wlm_query_state`, redshiftLongQuerySeconds*1000000)
+	data, err := awscli.Run("redshift-data", "execute-statement", "--cluster-identifier", c.ClusterIdentifier,
+		"--database", c.DBName, "--sql", sql, "--region", region)
+	if err != nil {
+		h.WLMError = err.Error()
+		return
+	}
+	var exec struct {
+		Id string `json:"Id"`
+	}
+	json.Unmarshal(data, &exec)
+
+	status := ""
+	for i := 0; i < 30; i++ {
+		time.Sleep(500 * time.Millisecond)
+		dData, err := awscli.Run("redshift-data", "describe-statement", "--id", exec.Id, "--region", region)
+		if err != nil {
+			h.WLMError = err.Error()
+			return
+		}
+		var desc struct {
+			Status string `json:"Status"`
+			Error  string `json:"Error"`
+		}
+		json.Unmarshal(dData, &desc)
+		status = desc.Status
+		if status == "FAILED" || status == "ABORTED" {
+			h.WLMError = desc.Error
+			return
+		}
+		if status == "FINISHED" {
+			break
+		}
+	}
+	if status != "FINISHED" {
+		h.WLMError = "timed out waiting for query"
+		return
+	}
+
+	rData, err := awscli.Run("redshift-data", "get-statement-result", "--id", exec.Id, "--region", region)
+	if err != nil {
+		h.WLMError = err.Error()
+		return
+	}
+	var result struct {
+		Records [][]struct {
+			LongValue   *int64   `json:"longValue"`
+			DoubleValue *float64 `json:"doubleValue"`
+		} `json:"Records"`
+	}
+	json.Unmarshal(rData, &result)
+	if len(result.Records) == 0 || len(result.Records[0]) < 3 {
+		return
+	}
+	row := result.Records[0]
+	if row[0].LongValue != nil {
+		h.QueuedQueries = int(*row[0].LongValue)
+	}
+	if row[1].DoubleValue != nil {
+		h.MaxQueueWait = *row[1].DoubleValue
+	}
+	if row[2].LongValue != nil {
+		h.LongRunning = int(*row[2].LongValue)
+	}
+	h.HasWLM = true
+}
+
+// loadRedshiftHealth returns the cached health snapshots by cluster ID.
+func loadRedshiftHealth(region string) map[string]*RedshiftHealth {
+	raw, err := ReadCache(region + ":redshift-health")
+	if err != nil || raw == nil {
+		return nil
+	}
+	var health map[string]*RedshiftHealth
+	json.Unmarshal(raw, &health)
+	return health
+}
@@ -0,0 +1,34 @@
+package sync
+
+import "strings"
+
+// AllowedRegions returns the allowed_regions setting, or nil when no
+// residency policy is configured and every region is allowed.
+func AllowedRegions() []string {
+	v, err := GetSetting("allowed_regions")
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, r := range strings.Split(v, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// RegionAllowed reports whether region is permitted by the residency
+// policy. Global resources (IAM) are always allowed.
+func RegionAllowed(region string) bool {
+	allowed := AllowedRegions()
+	if len(allowed) == 0 || region == "global" {
+		return true
+	}
+	for _, r := range allowed {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// The resources table holds each region's inventory as of its last sync,
+// one row per resource, so it can be filtered and joined in SQL instead of
+// unmarshalling every cache entry. It is rebuilt from the cache whenever a
+// region's sync finishes or a bundle is imported.
+
+// Resource is an inventory item with the time its region was indexed.
+type Resource struct {
+	InventoryItem
+	SyncedAt time.Time `json:"syncedAt"`
+}
+
+// ResourceFilter narrows QueryResources. Empty fields match anything.
+type ResourceFilter struct {
+	Region   string
+	Types    []string // inventory types: vpc, ec2, lambda, rds, ...
+	VpcId    string
+	Search   string // substring of the name, ID or ARN, case-insensitive
+	Arn      string // exact ARN
+	RefersTo string // "type/id": only resources that depend on it
+}
+
+// writeResources replaces region's rows in the resources table with items,
+// its inventory. Global resources (IAM, CloudFront, ...) are part of every
+// region's inventory and are kept once, as of the last region indexed.
+func writeResources(region string, items []InventoryItem, at time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM resources WHERE account = ? AND region IN (?, 'global')`, Account(), region); err != nil {
+		return err
+	}
+	for _, it := range items {
+		b, _ := json.Marshal(it)
+		value, err := seal(string(b))
+		if err != nil {
+			return err
+		}
+		refs, _ := json.Marshal(it.Refs)
+		if it.Refs == nil {
+			refs = []byte("[]")
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO resources (account, region, type, id, name, arn, vpc_id, refs, json, synced_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			Account(), it.Region, it.Type, it.ID, it.Name, it.Arn, it.VpcId, string(refs), value, at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// IndexResources rebuilds region's rows in the resources table from the
+// cache.
+func IndexResources(region string) error {
+	items, err := LoadInventory(region)
+	if err != nil {
+		return err
+	}
+	return writeResources(region, items, time.Now())
+}
+
+// indexCachedRegions fills the resources table for an account cached
+// before the table existed.
+func indexCachedRegions() error {
+	if readOnly {
+		return nil
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM resources WHERE account = ?`, Account()).Scan(&n)
+	if n > 0 {
+		return nil
+	}
+	regions, err := CachedRegions()
+	if err != nil {
+		return err
+	}
+	for _, r := range regions {
+		if err := IndexResources(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueryResources returns the active account's resources matching f,
+// across all synced regions unless f.Region is set (global resources match
+// any region), sorted by type and name.
+func QueryResources(f ResourceFilter) ([]Resource, error) {
+	if err := indexCachedRegions(); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT json, synced_at FROM resources WHERE account = ?`
+	args := []interface{}{Account()}
+	if f.Region != "" {
+		query += ` AND region IN (?, 'global')`
+		args = append(args, f.Region)
+	}
+	if len(f.Types) > 0 {
+		query += ` AND type IN (?` + repeatParam(len(f.Types)-1) + `)`
+		for _, t := range f.Types {
+			args = append(args, t)
+		}
+	}
+	if f.VpcId != "" {
+		query += ` AND vpc_id = ?`
+		args = append(args, f.VpcId)
+	}
+	if f.Search != "" {
+		like := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(f.Search) + "%"
+		query += ` AND (name LIKE ? ESCAPE '\' OR id LIKE ? ESCAPE '\' OR arn LIKE ? ESCAPE '\')`
+		args = append(args, like, like, like)
+	}
+	if f.Arn != "" {
+		query += ` AND arn = ?`
+		args = append(args, f.Arn)
+	}
+	if f.RefersTo != "" {
+		query += ` AND EXISTS (SELECT 1 FROM json_each(resources.refs) WHERE value = ?)`
+		args = append(args, f.RefersTo)
+	}
+	query += ` ORDER BY type, name, id, region`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cfg := projectConfig()
+	var resources []Resource
+	for rows.Next() {
+		var value string
+		var r Resource
+		if err := rows.Scan(&value, &r.SyncedAt); err != nil {
+			return nil, err
+		}
+		if value, err = unseal(value); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(value), &r.InventoryItem); err != nil {
+			return nil, err
+		}
+		if cfg.Excludes(r.InventoryItem) {
+			continue // excluded since the region was indexed
+		}
+		resources = append(resources, r)
+	}
+	return resources, rows.Err()
+}
@@ -0,0 +1,61 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryResources(t *testing.T) {
+	const region = "me-central-1"
+	items := []InventoryItem{
+		{Type: "vpc", ID: "vpc-1", Name: "prod", Region: region, VpcId: "vpc-1"},
+		{Type: "subnet", ID: "subnet-1", Name: "prod-private_a", Region: region, VpcId: "vpc-1", Refs: []string{"vpc/vpc-1"}},
+		{Type: "lambda", ID: "resize", Name: "resize", Region: region,
+			Arn: "arn:aws:lambda:me-central-1:123456789012:function:resize", Details: map[string]string{"Runtime": "go1.x"}},
+	}
+	if err := writeResources(region, items, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(f ResourceFilter) []string {
+		t.Helper()
+		f.Region = region
+		res, err := QueryResources(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, r := range res {
+			out = append(out, r.Key())
+		}
+		return out
+	}
+	for name, tc := range map[string]struct {
+		f    ResourceFilter
+		want []string
+	}{
+		"all":        {ResourceFilter{}, []string{"lambda/resize", "subnet/subnet-1", "vpc/vpc-1"}},
+		"types":      {ResourceFilter{Types: []string{"vpc", "subnet"}}, []string{"subnet/subnet-1", "vpc/vpc-1"}},
+		"vpc":        {ResourceFilter{VpcId: "vpc-1"}, []string{"subnet/subnet-1", "vpc/vpc-1"}},
+		"search arn": {ResourceFilter{Search: "FUNCTION:resize"}, []string{"lambda/resize"}},
+		"search _":   {ResourceFilter{Search: "_a"}, []string{"subnet/subnet-1"}},
+		"refers to":  {ResourceFilter{RefersTo: "vpc/vpc-1"}, []string{"subnet/subnet-1"}},
+		"arn":        {ResourceFilter{Arn: "arn:aws:lambda:me-central-1:123456789012:function:resize"}, []string{"lambda/resize"}},
+	} {
+		if got := ids(tc.f); strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("%s: got %v, want %v", name, got, tc.want)
+		}
+	}
+
+	res, _ := QueryResources(ResourceFilter{Region: region, Types: []string{"lambda"}})
+	if len(res) != 1 || res[0].Details["Runtime"] != "go1.x" {
+		t.Errorf("lambda = %+v", res)
+	}
+
+	// A resync replaces the region's rows.
+	writeResources(region, items[:1], time.Now())
+	if got := ids(ResourceFilter{}); len(got) != 1 {
+		t.Errorf("after resync: %v", got)
+	}
+}
@@ -0,0 +1,320 @@
+package sync
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Fargate on-demand list prices (Linux/x86, us-east-1). Other regions differ
+// by a few percent, so savings are an estimate rather than a bill.
+const (
+	fargateVCPUHour = 0.04048
+	fargateGBHour   = 0.004445
+	hoursPerMonth   = 730
+)
+
+// rightsizeHeadroom is applied to peak utilization before picking a size,
+// so a service peaking at 40% of 1 vCPU is sized for 0.5 vCPU, not 0.4.
+const rightsizeHeadroom = 1.25
+
+// fargateSizes lists the valid task CPU units and their allowed memory (MiB).
+var fargateSizes = []struct {
+	cpu    int
+	memory []int
+}{
+	{256, []int{512, 1024, 2048}},
+	{512, memRange(1024, 4096, 1024)},
+	{1024, memRange(2048, 8192, 1024)},
+	{2048, memRange(4096, 16384, 1024)},
+	{4096, memRange(8192, 30720, 1024)},
+	{8192, memRange(16384, 61440, 4096)},
+	{16384, memRange(32768, 122880, 8192)},
+}
+
+func memRange(from, to, step int) []int {
+	var out []int
+	for m := from; m <= to; m += step {
+		out = append(out, m)
+	}
+	return out
+}
+
+// ECSRightsizing is a suggested task size for one ECS service.
+type ECSRightsizing struct {
+	Cluster         string  `json:"cluster"`
+	Service         string  `json:"service"`
+	LaunchType      string  `json:"launchType"`
+	Tasks           int     `json:"tasks"`
+	Cpu             int     `json:"cpu"`
+	Memory          int     `json:"memory"`
+	SuggestedCpu    int     `json:"suggestedCpu"`
+	SuggestedMemory int     `json:"suggestedMemory"`
+	CPUMax          float64 `json:"cpuMax"`
+	MemoryMax       float64 `json:"memoryMax"`
+	MonthlySavings  float64 `json:"monthlySavings"` // negative when the suggestion costs more
+}
+
+// Direction is "lower" when the suggested size is cheaper, otherwise "higher".
+func (r ECSRightsizing) Direction() string {
+	if fargateHourly(r.SuggestedCpu, r.SuggestedMemory) < fargateHourly(r.Cpu, r.Memory) {
+		return "lower"
+	}
+	return "higher"
+}
+
+// Priced reports whether MonthlySavings applies (Fargate only; EC2-backed
+// services are billed by the instance, not the task).
+func (r ECSRightsizing) Priced() bool {
+	return r.LaunchType != "EC2"
+}
+
+func fargateHourly(cpu, memory int) float64 {
+	return float64(cpu)/1024*fargateVCPUHour + float64(memory)/1024*fargateGBHour
+}
+
+// SuggestECSTaskSize compares a service's task-level reservation against its
+// peak utilization and returns the smallest Fargate size that fits with
+// headroom. It returns nil when metrics or reservations are missing, or the
+// current size is already right.
+func SuggestECSTaskSize(svc ECSService) *ECSRightsizing {
+	m := svc.Metrics
+	if m == nil || svc.Cpu == 0 || svc.Memory == 0 {
+		return nil
+	}
+	needCpu := float64(svc.Cpu) * m.CPUMax / 100 * rightsizeHeadroom
+	needMem := float64(svc.Memory) * m.MemoryMax / 100 * rightsizeHeadroom
+
+	cpu, memory := 0, 0
+	best := math.Inf(1)
+	for _, size := range fargateSizes {
+		if float64(size.cpu) < needCpu {
+			continue
+		}
+		for _, mem := range size.memory {
+			if float64(mem) < needMem {
+				continue
+			}
+			if cost := fargateHourly(size.cpu, mem); cost < best {
+				cpu, memory, best = size.cpu, mem, cost
+			}
+			break
+		}
+	}
+	if cpu == 0 || (cpu == svc.Cpu && memory == svc.Memory) {
+		return nil
+	}
+
+	r := &ECSRightsizing{
+		Service:         svc.ServiceName,
+		LaunchType:      svc.LaunchType,
+		Tasks:           svc.DesiredCount,
+		Cpu:             svc.Cpu,
+		Memory:          svc.Memory,
+		SuggestedCpu:    cpu,
+		SuggestedMemory: memory,
+		CPUMax:          m.CPUMax,
+		MemoryMax:       m.MemoryMax,
+	}
+	if r.Priced() {
+		delta := fargateHourly(svc.Cpu, svc.Memory) - best
+		r.MonthlySavings = delta * hoursPerMonth * float64(svc.DesiredCount)
+	}
+	return r
+}
+
+// ECSRightsizingSuggestions returns a suggestion for every service that has
+// synced metrics and a better-fitting size, biggest savings first.
+func ECSRightsizingSuggestions(data *ComputeData) []ECSRightsizing {
+	if data == nil {
+		return nil
+	}
+	var out []ECSRightsizing
+	for _, cl := range data.ECS {
+		for _, svc := range cl.ECSServices {
+			if r := SuggestECSTaskSize(svc); r != nil {
+				r.Cluster = cl.ClusterName
+				out = append(out, *r)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].MonthlySavings > out[j].MonthlySavings
+	})
+	return out
+}
+
+// ec2IdleCPU is the 14-day average CPU (percent) below which an instance is
+// flagged as oversized.
+const ec2IdleCPU = 10
+
+// ec2LargePrice is the on-demand Linux price of the .large size in
+// us-east-1 for common families. Other sizes scale by their ec2Sizes
+// factor; families not listed get suggestions without savings.
+var ec2LargePrice = map[string]float64{
+	"t2": 0.0928, "t3": 0.0832, "t3a": 0.0752, "t4g": 0.0672,
+	"m5": 0.096, "m5a": 0.086, "m6i": 0.096, "m6a": 0.0864, "m6g": 0.077, "m7i": 0.1008, "m7g": 0.0816,
+	"c5": 0.085, "c5a": 0.077, "c6i": 0.085, "c6a": 0.0765, "c6g": 0.068, "c7i": 0.08925, "c7g": 0.0725,
+	"r5": 0.126, "r5a": 0.113, "r6i": 0.126, "r6a": 0.1134, "r6g": 0.1008, "r7i": 0.1323, "r7g": 0.1071,
+}
+
+// ec2Sizes are the instance sizes most families share, in ascending order,
+// with their capacity relative to .large. CPU and memory scale by the same
+// factor within a family (burstable t-family vCPUs are an exception; the
+// price still scales). Family-specific sizes like c5.9xlarge are skipped.
+var ec2Sizes = []struct {
+	name   string
+	factor float64
+}{
+	{"nano", 0.0625}, {"micro", 0.125}, {"small", 0.25}, {"medium", 0.5}, {"large", 1},
+	{"xlarge", 2}, {"2xlarge", 4}, {"4xlarge", 8}, {"8xlarge", 16}, {"12xlarge", 24},
+	{"16xlarge", 32}, {"24xlarge", 48}, {"32xlarge", 64}, {"48xlarge", 96},
+}
+
+// ec2SmallestSize returns the smallest size offered for a family.
+func ec2SmallestSize(family string) string {
+	switch {
+	case strings.HasPrefix(family, "t"):
+		return "nano"
+	case strings.HasSuffix(family, "g"):
+		return "medium"
+	}
+	return "large"
+}
+
+func ec2SizeIndex(size string) int {
+	for i, s := range ec2Sizes {
+		if s.name == size {
+			return i
+		}
+	}
+	return -1
+}
+
+// ec2HourlyPrice estimates the on-demand price of an instance type.
+func ec2HourlyPrice(instanceType string) (float64, bool) {
+	family, size, _ := strings.Cut(instanceType, ".")
+	base, ok := ec2LargePrice[family]
+	i := ec2SizeIndex(size)
+	if !ok || i < 0 {
+		return 0, false
+	}
+	return base * ec2Sizes[i].factor, true
+}
+
+// EC2Rightsizing flags an underused instance with either a smaller type or,
+// when it is already as small as its peak allows, an office-hours schedule.
+type EC2Rightsizing struct {
+	InstanceId     string  `json:"instanceId"`
+	Name           string  `json:"name"`
+	InstanceType   string  `json:"instanceType"`
+	Action         string  `json:"action"` // "downsize" or "schedule"
+	SuggestedType  string  `json:"suggestedType,omitempty"`
+	CPUAvg         float64 `json:"cpuAvg"`
+	CPUMax         float64 `json:"cpuMax"`
+	MemoryMax      float64 `json:"memoryMax"`
+	HasMemory      bool    `json:"hasMemory"`
+	MonthlySavings float64 `json:"monthlySavings"`
+}
+
+// Priced reports whether MonthlySavings is known for this instance family.
+func (r EC2Rightsizing) Priced() bool {
+	_, ok := ec2HourlyPrice(r.InstanceType)
+	return ok
+}
+
+// SuggestEC2Size returns a suggestion for a running instance averaging under
+// ec2IdleCPU, or nil. Without CloudWatch agent memory data the instance is
+// only ever stepped down one size, since memory pressure is unknown.
+func SuggestEC2Size(inst EC2Instance) *EC2Rightsizing {
+	m := inst.Metrics
+	if m == nil || m.CPUAvg >= ec2IdleCPU {
+		return nil
+	}
+	family, size, _ := strings.Cut(inst.InstanceType, ".")
+	cur := ec2SizeIndex(size)
+	if cur < 0 {
+		return nil
+	}
+
+	r := &EC2Rightsizing{
+		InstanceId:   inst.InstanceId,
+		Name:         inst.Name,
+		InstanceType: inst.InstanceType,
+		Action:       "schedule",
+		CPUAvg:       m.CPUAvg,
+		CPUMax:       m.CPUMax,
+		MemoryMax:    m.MemoryMax,
+		HasMemory:    m.HasMemory,
+	}
+	floor := ec2SizeIndex(ec2SmallestSize(family))
+	if !m.HasMemory {
+		floor = max(floor, cur-1)
+	}
+	for i := cur - 1; i >= floor; i-- {
+		scale := ec2Sizes[cur].factor / ec2Sizes[i].factor
+		if m.CPUMax*scale*rightsizeHeadroom > 100 || m.MemoryMax*scale*rightsizeHeadroom > 100 {
+			break
+		}
+		r.Action = "downsize"
+		r.SuggestedType = family + "." + ec2Sizes[i].name
+	}
+
+	if price, ok := ec2HourlyPrice(inst.InstanceType); ok {
+		if r.Action == "downsize" {
+			next, _ := ec2HourlyPrice(r.SuggestedType)
+			r.MonthlySavings = (price - next) * hoursPerMonth
+		} else {
+			r.MonthlySavings = price * hoursPerMonth * (1 - float64(DefaultOfficeHours.HoursPerWeek())/168)
+		}
+	}
+	return r
+}
+
+// EC2RightsizingSuggestions returns suggestions for every underused
+// instance with synced metrics, biggest savings first.
+func EC2RightsizingSuggestions(data *ComputeData) []EC2Rightsizing {
+	if data == nil {
+		return nil
+	}
+	var out []EC2Rightsizing
+	for _, inst := range data.EC2 {
+		if r := SuggestEC2Size(inst); r != nil {
+			out = append(out, *r)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].MonthlySavings > out[j].MonthlySavings
+	})
+	return out
+}
+
+// MonthlyEstimate is the on-demand monthly price of an inventory item, from
+// the same list prices the right-sizing suggestions use. It covers EC2
+// instances and Fargate services; ok is false for anything else. Stopped
+// instances and scaled-to-zero services cost nothing to run.
+func MonthlyEstimate(it InventoryItem) (float64, bool) {
+	switch it.Type {
+	case "ec2":
+		hourly, ok := ec2HourlyPrice(it.Details["Instance type"])
+		if !ok {
+			return 0, false
+		}
+		if it.Details["State"] != "running" {
+			return 0, true
+		}
+		return hourly * hoursPerMonth, true
+	case "ecs-service":
+		if it.Details["Launch type"] == "EC2" {
+			return 0, false
+		}
+		var cpu, memory, running, desired int
+		if _, err := fmt.Sscanf(it.Details["Task size"], "%d CPU / %d MiB", &cpu, &memory); err != nil {
+			return 0, false
+		}
+		fmt.Sscanf(it.Details["Tasks"], "%d/%d", &running, &desired)
+		return fargateHourly(cpu, memory) * hoursPerMonth * float64(desired), true
+	}
+	return 0, false
+}
@@ -0,0 +1,181 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// HostedZone is a Route 53 zone with its records. Zones are global.
+type HostedZone struct {
+	Id      string      `json:"Id"`
+	Name    string      `json:"Name"`
+	Private bool        `json:"Private"`
+	Records []DNSRecord `json:"Records"`
+}
+
+// DNSRecord is one record set. Alias records have AliasTarget set and no
+// Values.
+type DNSRecord struct {
+	Name        string   `json:"Name"`
+	Type        string   `json:"Type"`
+	Values      []string `json:"Values"`
+	AliasTarget string   `json:"AliasTarget"`
+}
+
+// SyncRoute53Data fetches every hosted zone and its record sets.
+func SyncRoute53Data(st *Stage, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("route53", "list-hosted-zones")
+	if err != nil {
+		step("route53")
+		return []SyncResult{{Service: "route53", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		HostedZones []struct {
+			Id     string `json:"Id"`
+			Name   string `json:"Name"`
+			Config struct {
+				PrivateZone bool `json:"PrivateZone"`
+			} `json:"Config"`
+		} `json:"HostedZones"`
+	}
+	json.Unmarshal(data, &resp)
+
+	var zones []HostedZone
+	records := 0
+	for _, z := range resp.HostedZones {
+		zone := HostedZone{Id: strings.TrimPrefix(z.Id, "/hostedzone/"), Name: z.Name, Private: z.Config.PrivateZone}
+		if rData, err := awscli.Run("route53", "list-resource-record-sets", "--hosted-zone-id", zone.Id); err == nil {
+			zone.Records = parseRecordSets(rData)
+		}
+		records += len(zone.Records)
+		zones = append(zones, zone)
+	}
+	step("route53")
+
+	b, _ := json.Marshal(zones)
+	st.WriteCache("route53:zones", b)
+	return []SyncResult{{Service: "route53", Count: records}}, nil
+}
+
+func parseRecordSets(raw []byte) []DNSRecord {
+	var resp struct {
+		ResourceRecordSets []struct {
+			Name            string `json:"Name"`
+			Type            string `json:"Type"`
+			ResourceRecords []struct {
+				Value string `json:"Value"`
+			} `json:"ResourceRecords"`
+			AliasTarget *struct {
+				DNSName string `json:"DNSName"`
+			} `json:"AliasTarget"`
+		} `json:"ResourceRecordSets"`
+	}
+	json.Unmarshal(raw, &resp)
+	var out []DNSRecord
+	for _, rs := range resp.ResourceRecordSets {
+		rec := DNSRecord{Name: strings.TrimSuffix(rs.Name, "."), Type: rs.Type}
+		for _, v := range rs.ResourceRecords {
+			rec.Values = append(rec.Values, strings.TrimSuffix(v.Value, "."))
+		}
+		if rs.AliasTarget != nil {
+			rec.AliasTarget = strings.TrimSuffix(rs.AliasTarget.DNSName, ".")
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// LoadHostedZones returns the cached Route 53 zones.
+func LoadHostedZones() ([]HostedZone, error) {
+	raw, err := ReadCache("route53:zones")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var zones []HostedZone
+	json.Unmarshal(raw, &zones)
+	return zones, nil
+}
+
+// ElasticIP is an allocated Elastic IP address.
+type ElasticIP struct {
+	PublicIp           string `json:"PublicIp"`
+	AllocationId       string `json:"AllocationId"`
+	InstanceId         string `json:"InstanceId"`
+	AssociationId      string `json:"AssociationId"`
+	NetworkInterfaceId string `json:"NetworkInterfaceId"`
+	PrivateIpAddress   string `json:"PrivateIpAddress"`
+}
+
+// Associated reports whether the address is in use. Unassociated EIPs
+// are billed by the hour.
+func (e ElasticIP) Associated() bool {
+	return e.AssociationId != ""
+}
+
+// LoadElasticIPs returns the EIPs currently allocated in region.
+func LoadElasticIPs(region string) ([]ElasticIP, error) {
+	raw, err := ReadCache(region + ":eips")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var resp struct {
+		Addresses []ElasticIP `json:"Addresses"`
+	}
+	json.Unmarshal(raw, &resp)
+	return resp.Addresses, nil
+}
+
+// EIPSighting is an Elastic IP seen by an earlier sync. Addresses drop out
+// of describe-addresses once released, so this history is the only local
+// record that an IP used to belong to the account.
+type EIPSighting struct {
+	PublicIp     string `json:"publicIp"`
+	AllocationId string `json:"allocationId"`
+	LastSeen     string `json:"lastSeen"`
+}
+
+// recordEIPHistory merges the addresses from a describe-addresses result
+// into region:eip-history.
+func recordEIPHistory(st *Stage, region string, data []byte) {
+	var resp struct {
+		Addresses []ElasticIP `json:"Addresses"`
+	}
+	json.Unmarshal(data, &resp)
+	history, _ := LoadEIPHistory(region)
+	byIP := map[string]int{}
+	for i, h := range history {
+		byIP[h.PublicIp] = i
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, a := range resp.Addresses {
+		if i, ok := byIP[a.PublicIp]; ok {
+			history[i].LastSeen = now
+			history[i].AllocationId = a.AllocationId
+			continue
+		}
+		history = append(history, EIPSighting{PublicIp: a.PublicIp, AllocationId: a.AllocationId, LastSeen: now})
+	}
+	b, _ := json.Marshal(history)
+	st.WriteCache(region+":eip-history", b)
+}
+
+// LoadEIPHistory returns every Elastic IP seen in region, including
+// released ones.
+func LoadEIPHistory(region string) ([]EIPSighting, error) {
+	raw, err := ReadCache(region + ":eip-history")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var history []EIPSighting
+	json.Unmarshal(raw, &history)
+	return history, nil
+}
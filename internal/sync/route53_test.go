@@ -0,0 +1,51 @@
+package sync
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestSyncRoute53Data(t *testing.T) {
+	fake := awscli.NewFake().
+		On("route53 list-hosted-zones", `{"HostedZones": [{"Id": "/hostedzone/Z1", "Name": "example.com.", "Config": {"PrivateZone": false}}]}`).
+		OnFile("route53 list-resource-record-sets --hosted-zone-id Z1", filepath.Join(testdata, "parse", "parseRecordSets.json"))
+	defer awscli.Use(fake)()
+
+	results, err := SyncRoute53Data(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error != "" || results[0].Count != 3 {
+		t.Fatalf("results = %+v, want one route53 result with 3 records", results)
+	}
+
+	zones, err := LoadHostedZones()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 1 || zones[0].Id != "Z1" || len(zones[0].Records) != 3 {
+		t.Fatalf("zones = %+v, want Z1 with 3 records", zones)
+	}
+	if got := zones[0].Records[1].AliasTarget; got != "dualstack.prod-alb-123.us-east-1.elb.amazonaws.com" {
+		t.Errorf("alias target = %q", got)
+	}
+}
+
+func TestSyncRoute53DataError(t *testing.T) {
+	fake := awscli.NewFake().Fail("route53 list-hosted-zones", errors.New("AccessDenied"))
+	defer awscli.Use(fake)()
+
+	results, err := SyncRoute53Data(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Error == "" {
+		t.Fatalf("results = %+v, want the CLI error recorded", results)
+	}
+	if fake.Called("route53 list-resource-record-sets") {
+		t.Error("listed record sets after list-hosted-zones failed")
+	}
+}
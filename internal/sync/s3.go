@@ -2,13 +2,15 @@ package sync
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/estrados/simply-aws/internal/awscli"
 )
 
 type S3Data struct {
-	Buckets []S3Bucket `json:"buckets"`
+	Buckets []S3Bucket                 `json:"buckets"`
+	MRAPs   []S3MultiRegionAccessPoint `json:"mraps,omitempty"`
 }
 
 type S3Bucket struct {
@@ -17,10 +19,63 @@ type S3Bucket struct {
 	Region            string          `json:"Region"`
 	Access            string          `json:"Access"`            // "private", "public", "unknown"
 	Versioning        string          `json:"Versioning"`        // "Enabled", "Suspended", "Disabled"
+	Encryption        string          `json:"Encryption"`        // "SSE-S3", "SSE-KMS", "DSSE-KMS", "none"; "" if not synced
+	KmsKeyId          string          `json:"KmsKeyId"`          // default key for SSE-KMS; "" means aws/s3
 	PublicAccessBlock *S3PublicBlock  `json:"PublicAccessBlock"`
 	PolicyPublic      bool            `json:"PolicyPublic"`
 	ACLPublic         bool             `json:"ACLPublic"`
 	Policies          []ResourcePolicy `json:"Policies"`
+	ReplicationTargets []string        `json:"ReplicationTargets"` // destination bucket names of enabled rules
+	AccessPoints      []S3AccessPoint  `json:"AccessPoints,omitempty"`
+}
+
+// S3AccessPoint is a named network endpoint for a bucket with its own
+// policy. Access granted here never shows up in the bucket policy.
+type S3AccessPoint struct {
+	Name          string           `json:"Name"`
+	Arn           string           `json:"Arn"`
+	Alias         string           `json:"Alias"`
+	NetworkOrigin string           `json:"NetworkOrigin"` // "Internet" or "VPC"
+	VpcId         string           `json:"VpcId"`
+	PolicyPublic  bool             `json:"PolicyPublic"`
+	Policies      []ResourcePolicy `json:"Policies"`
+}
+
+// VPCOnly reports whether the access point only accepts requests from
+// its VPC.
+func (a S3AccessPoint) VPCOnly() bool { return a.NetworkOrigin == "VPC" }
+
+// S3MultiRegionAccessPoint is a global endpoint routing to buckets in
+// several regions.
+type S3MultiRegionAccessPoint struct {
+	Name         string           `json:"Name"`
+	Alias        string           `json:"Alias"`
+	Status       string           `json:"Status"`
+	Regions      []S3MRAPRegion   `json:"Regions"`
+	PolicyPublic bool             `json:"PolicyPublic"`
+	Policies     []ResourcePolicy `json:"Policies"`
+}
+
+type S3MRAPRegion struct {
+	Bucket string `json:"Bucket"`
+	Region string `json:"Region"`
+}
+
+// MRAPsFor returns the multi-region access points routing to a bucket.
+func (d *S3Data) MRAPsFor(bucket string) []S3MultiRegionAccessPoint {
+	if d == nil {
+		return nil
+	}
+	var out []S3MultiRegionAccessPoint
+	for _, m := range d.MRAPs {
+		for _, r := range m.Regions {
+			if r.Bucket == bucket {
+				out = append(out, m)
+				break
+			}
+		}
+	}
+	return out
 }
 
 type S3PublicBlock struct {
@@ -71,7 +126,7 @@ func parseS3Bucket(raw json.RawMessage) S3Bucket {
 }
 
 // SyncS3WithRegions syncs bucket list then fetches per-bucket details.
-func SyncS3WithRegions(onStep ...func(string)) (*SyncResult, error) {
+func SyncS3WithRegions(st *Stage, onStep ...func(string)) (*SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -159,17 +214,195 @@ func SyncS3WithRegions(onStep ...func(string)) (*SyncResult, error) {
 			}
 		}
 
+		// Default encryption
+		if encData, err := awscli.Run("s3api", "get-bucket-encryption", "--bucket", bucket.Name); err == nil {
+			var enc struct {
+				ServerSideEncryptionConfiguration struct {
+					Rules []struct {
+						ApplyServerSideEncryptionByDefault struct {
+							SSEAlgorithm   string `json:"SSEAlgorithm"`
+							KMSMasterKeyID string `json:"KMSMasterKeyID"`
+						} `json:"ApplyServerSideEncryptionByDefault"`
+					} `json:"Rules"`
+				} `json:"ServerSideEncryptionConfiguration"`
+			}
+			json.Unmarshal(encData, &enc)
+			s3Data.Buckets[i].Encryption = "none"
+			for _, r := range enc.ServerSideEncryptionConfiguration.Rules {
+				switch r.ApplyServerSideEncryptionByDefault.SSEAlgorithm {
+				case "AES256":
+					s3Data.Buckets[i].Encryption = "SSE-S3"
+				case "aws:kms":
+					s3Data.Buckets[i].Encryption = "SSE-KMS"
+					s3Data.Buckets[i].KmsKeyId = r.ApplyServerSideEncryptionByDefault.KMSMasterKeyID
+				case "aws:kms:dsse":
+					s3Data.Buckets[i].Encryption = "DSSE-KMS"
+					s3Data.Buckets[i].KmsKeyId = r.ApplyServerSideEncryptionByDefault.KMSMasterKeyID
+				}
+			}
+		} else if strings.Contains(err.Error(), "ServerSideEncryptionConfigurationNotFoundError") {
+			s3Data.Buckets[i].Encryption = "none"
+		}
+
+		// Replication rules - destination buckets, resolved to regions in the DR report
+		if repData, err := awscli.Run("s3api", "get-bucket-replication", "--bucket", bucket.Name); err == nil {
+			var rc struct {
+				ReplicationConfiguration struct {
+					Rules []struct {
+						Status      string `json:"Status"`
+						Destination struct {
+							Bucket string `json:"Bucket"`
+						} `json:"Destination"`
+					} `json:"Rules"`
+				} `json:"ReplicationConfiguration"`
+			}
+			json.Unmarshal(repData, &rc)
+			for _, r := range rc.ReplicationConfiguration.Rules {
+				if r.Status == "Enabled" {
+					dest := strings.TrimPrefix(r.Destination.Bucket, "arn:aws:s3:::")
+					s3Data.Buckets[i].ReplicationTargets = append(s3Data.Buckets[i].ReplicationTargets, dest)
+				}
+			}
+		}
+
 		// Determine overall access
 		s3Data.Buckets[i].Access = determineAccess(s3Data.Buckets[i])
 		step("s3:" + bucket.Name)
 	}
 
+	// Access points and MRAPs are listed per account through s3control
+	if account := callerAccountID(); account != "" {
+		syncS3AccessPoints(account, s3Data)
+		step("s3 access points")
+		s3Data.MRAPs = syncS3MRAPs(account)
+		step("s3 multi-region access points")
+	}
+
 	enriched, _ := json.Marshal(s3Data)
-	WriteCache("s3:enriched", enriched)
+	st.WriteCache("s3:enriched", enriched)
 
 	return result, nil
 }
 
+// syncS3AccessPoints lists access points in each region that holds a
+// bucket and attaches them, with their policies, to their bucket.
+func syncS3AccessPoints(account string, s3Data *S3Data) {
+	byName := map[string]int{}
+	regions := map[string]bool{}
+	for i, b := range s3Data.Buckets {
+		byName[b.Name] = i
+		if b.Region != "" {
+			regions[b.Region] = true
+		}
+	}
+	for region := range regions {
+		out, err := awscli.Run("s3control", "list-access-points", "--account-id", account, "--region", region)
+		if err != nil {
+			continue
+		}
+		var resp struct {
+			AccessPointList []struct {
+				Name             string `json:"Name"`
+				NetworkOrigin    string `json:"NetworkOrigin"`
+				VpcConfiguration struct {
+					VpcId string `json:"VpcId"`
+				} `json:"VpcConfiguration"`
+				Bucket         string `json:"Bucket"`
+				AccessPointArn string `json:"AccessPointArn"`
+				Alias          string `json:"Alias"`
+			} `json:"AccessPointList"`
+		}
+		json.Unmarshal(out, &resp)
+		for _, ap := range resp.AccessPointList {
+			i, ok := byName[ap.Bucket]
+			if !ok {
+				continue
+			}
+			point := S3AccessPoint{
+				Name:          ap.Name,
+				Arn:           ap.AccessPointArn,
+				Alias:         ap.Alias,
+				NetworkOrigin: ap.NetworkOrigin,
+				VpcId:         ap.VpcConfiguration.VpcId,
+			}
+			if polData, err := awscli.Run("s3control", "get-access-point-policy-status", "--account-id", account, "--name", ap.Name, "--region", region); err == nil {
+				var pol struct {
+					PolicyStatus struct {
+						IsPublic bool `json:"IsPublic"`
+					} `json:"PolicyStatus"`
+				}
+				json.Unmarshal(polData, &pol)
+				point.PolicyPublic = pol.PolicyStatus.IsPublic
+			}
+			if polData, err := awscli.Run("s3control", "get-access-point-policy", "--account-id", account, "--name", ap.Name, "--region", region); err == nil {
+				var polResp struct {
+					Policy string `json:"Policy"`
+				}
+				json.Unmarshal(polData, &polResp)
+				point.Policies = ParseResourcePolicies(polResp.Policy)
+			}
+			s3Data.Buckets[i].AccessPoints = append(s3Data.Buckets[i].AccessPoints, point)
+		}
+	}
+}
+
+// syncS3MRAPs lists multi-region access points. Their control plane only
+// answers in us-west-2.
+func syncS3MRAPs(account string) []S3MultiRegionAccessPoint {
+	out, err := awscli.Run("s3control", "list-multi-region-access-points", "--account-id", account, "--region", "us-west-2")
+	if err != nil {
+		return nil
+	}
+	var resp struct {
+		AccessPoints []struct {
+			Name    string         `json:"Name"`
+			Alias   string         `json:"Alias"`
+			Status  string         `json:"Status"`
+			Regions []S3MRAPRegion `json:"Regions"`
+		} `json:"AccessPoints"`
+	}
+	json.Unmarshal(out, &resp)
+
+	var mraps []S3MultiRegionAccessPoint
+	for _, ap := range resp.AccessPoints {
+		m := S3MultiRegionAccessPoint{Name: ap.Name, Alias: ap.Alias, Status: ap.Status, Regions: ap.Regions}
+		if polData, err := awscli.Run("s3control", "get-multi-region-access-point-policy-status", "--account-id", account, "--name", ap.Name, "--region", "us-west-2"); err == nil {
+			var pol struct {
+				Established struct {
+					IsPublic bool `json:"IsPublic"`
+				} `json:"Established"`
+			}
+			json.Unmarshal(polData, &pol)
+			m.PolicyPublic = pol.Established.IsPublic
+		}
+		if polData, err := awscli.Run("s3control", "get-multi-region-access-point-policy", "--account-id", account, "--name", ap.Name, "--region", "us-west-2"); err == nil {
+			var polResp struct {
+				Policy struct {
+					Established struct {
+						Policy string `json:"Policy"`
+					} `json:"Established"`
+				} `json:"Policy"`
+			}
+			json.Unmarshal(polData, &polResp)
+			m.Policies = ParseResourcePolicies(polResp.Policy.Established.Policy)
+		}
+		mraps = append(mraps, m)
+	}
+	return mraps
+}
+
+func callerAccountID() string {
+	out, err := awscli.Run("sts", "get-caller-identity")
+	if err != nil {
+		return ""
+	}
+	var identity struct {
+		Account string `json:"Account"`
+	}
+	json.Unmarshal(out, &identity)
+	return identity.Account
+}
+
 func determineAccess(b S3Bucket) string {
 	// If all public access blocks are on → definitely private
 	if b.PublicAccessBlock != nil {
@@ -0,0 +1,124 @@
+package sync
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// alwaysOnDays is how long an instance must have been running since its last
+// start before it counts as running 24/7. LaunchTime resets on every start,
+// so an instance launched this long ago has not been stopped since.
+const alwaysOnDays = 7
+
+// environmentTags are the tag keys checked (case-insensitively) for the
+// environment an instance belongs to.
+var environmentTags = []string{"environment", "env", "stage", "tier"}
+
+// nonProdWords mark an environment tag value or name token as non-production.
+var nonProdWords = map[string]bool{
+	"dev": true, "develop": true, "development": true, "test": true, "testing": true,
+	"qa": true, "uat": true, "stage": true, "staging": true, "stg": true, "sandbox": true,
+	"demo": true, "nonprod": true, "preprod": true, "sbx": true,
+}
+
+var prodWords = map[string]bool{"prod": true, "production": true, "prd": true, "live": true}
+
+// OfficeHours is a weekday start/stop window in local time of Timezone.
+type OfficeHours struct {
+	Start    int    // hour of day, 0-22
+	Stop     int    // hour of day, 1-23
+	Timezone string // IANA name, e.g. Europe/Berlin
+}
+
+// DefaultOfficeHours runs instances 08:00-20:00 on weekdays.
+var DefaultOfficeHours = OfficeHours{Start: 8, Stop: 20, Timezone: "UTC"}
+
+// HoursPerWeek returns how long a scheduled instance runs each week.
+func (o OfficeHours) HoursPerWeek() int {
+	return (o.Stop - o.Start) * 5
+}
+
+// ScheduleCandidate is a non-production instance that has been running
+// around the clock.
+type ScheduleCandidate struct {
+	InstanceId     string  `json:"instanceId"`
+	Name           string  `json:"name"`
+	InstanceType   string  `json:"instanceType"`
+	Reason         string  `json:"reason"` // why it was classed as non-production
+	RunningDays    int     `json:"runningDays"`
+	MonthlySavings float64 `json:"monthlySavings"`
+	Priced         bool    `json:"priced"`
+}
+
+// nonProdReason returns why an instance looks non-production, or "" if it
+// doesn't. An explicit production environment tag always wins over the name.
+func nonProdReason(inst EC2Instance) string {
+	for k, v := range inst.Tags {
+		for _, key := range environmentTags {
+			if !strings.EqualFold(k, key) {
+				continue
+			}
+			val := strings.ToLower(v)
+			if prodWords[val] {
+				return ""
+			}
+			if nonProdWords[val] {
+				return "tag " + k + "=" + v
+			}
+		}
+	}
+	tokens := strings.FieldsFunc(strings.ToLower(inst.Name), func(r rune) bool {
+		return !('a' <= r && r <= 'z' || '0' <= r && r <= '9')
+	})
+	for _, t := range tokens {
+		if nonProdWords[t] {
+			return "name contains " + t
+		}
+	}
+	return ""
+}
+
+// ScheduleCandidates finds running non-production instances that have not
+// been stopped for alwaysOnDays and estimates savings from running them only
+// during office hours. Auto Scaling group members are skipped since stopping
+// them just triggers a replacement.
+func ScheduleCandidates(data *ComputeData, hours OfficeHours) []ScheduleCandidate {
+	if data == nil {
+		return nil
+	}
+	var out []ScheduleCandidate
+	for _, inst := range data.EC2 {
+		if inst.State != "running" || inst.AutoScalingGroup != "" {
+			continue
+		}
+		launched, err := time.Parse(time.RFC3339, inst.LaunchTime)
+		if err != nil {
+			continue
+		}
+		days := int(time.Since(launched).Hours() / 24)
+		if days < alwaysOnDays {
+			continue
+		}
+		reason := nonProdReason(inst)
+		if reason == "" {
+			continue
+		}
+		c := ScheduleCandidate{
+			InstanceId:   inst.InstanceId,
+			Name:         inst.Name,
+			InstanceType: inst.InstanceType,
+			Reason:       reason,
+			RunningDays:  days,
+		}
+		if price, ok := ec2HourlyPrice(inst.InstanceType); ok {
+			c.Priced = true
+			c.MonthlySavings = price * hoursPerMonth * (1 - float64(hours.HoursPerWeek())/168)
+		}
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].MonthlySavings > out[j].MonthlySavings
+	})
+	return out
+}
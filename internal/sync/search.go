@@ -0,0 +1,78 @@
+package sync
+
+import (
+	"sort"
+	"strings"
+)
+
+// SearchHit is a resource found by SearchResources and the field that
+// matched: name, id, arn or ip.
+type SearchHit struct {
+	Resource
+	Match string `json:"match"`
+}
+
+// SearchResources finds the active account's resources, in every synced
+// region, whose name, ID, ARN or IP address contains q, ignoring case.
+// Exact matches come first, then prefixes, then the rest; at most limit
+// hits are returned when limit is positive.
+func SearchResources(q string, limit int) ([]SearchHit, error) {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if q == "" {
+		return nil, nil
+	}
+	// Only a query that could be part of an address needs the IP details,
+	// which aren't columns; anything else is narrowed in SQL.
+	f := ResourceFilter{Search: q}
+	if strings.Trim(q, "0123456789abcdef.:") == "" {
+		f.Search = ""
+	}
+	resources, err := QueryResources(f)
+	if err != nil {
+		return nil, err
+	}
+
+	type ranked struct {
+		hit  SearchHit
+		rank int
+	}
+	var found []ranked
+	for _, r := range resources {
+		fields := [][2]string{{"name", r.Name}, {"id", r.ID}, {"arn", r.Arn}}
+		for k, v := range r.Details {
+			if strings.HasSuffix(k, " IP") {
+				fields = append(fields, [2]string{"ip", v})
+			}
+		}
+		best := ranked{rank: -1}
+		for _, field := range fields {
+			v := strings.ToLower(field[1])
+			rank := -1
+			switch {
+			case v == "":
+			case v == q:
+				rank = 0
+			case strings.HasPrefix(v, q):
+				rank = 1
+			case strings.Contains(v, q):
+				rank = 2
+			}
+			if rank >= 0 && (best.rank < 0 || rank < best.rank) {
+				best = ranked{SearchHit{r, field[0]}, rank}
+			}
+		}
+		if best.rank >= 0 {
+			found = append(found, best)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].rank < found[j].rank })
+
+	hits := make([]SearchHit, 0, len(found))
+	for _, f := range found {
+		if limit > 0 && len(hits) == limit {
+			break
+		}
+		hits = append(hits, f.hit)
+	}
+	return hits, nil
+}
@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSearchResources(t *testing.T) {
+	const region = "ap-southeast-4"
+	items := []InventoryItem{
+		{Type: "ec2", ID: "i-0searchweb", Name: "searchweb", Region: region,
+			Details: map[string]string{"Private IP": "10.77.0.12", "Public IP": "203.0.113.77"}},
+		{Type: "lambda", ID: "searchweb-resize", Name: "searchweb-resize", Region: region,
+			Arn: "arn:aws:lambda:ap-southeast-4:123456789012:function:searchweb-resize"},
+		{Type: "rds", ID: "orders-searchweb", Name: "orders-searchweb", Region: region},
+	}
+	if err := writeResources(region, items, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := func(q string, limit int) []string {
+		t.Helper()
+		hits, err := SearchResources(q, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, h := range hits {
+			out = append(out, h.Key()+" "+h.Match)
+		}
+		return out
+	}
+	for q, want := range map[string][]string{
+		"SearchWeb":          {"ec2/i-0searchweb name", "lambda/searchweb-resize name", "rds/orders-searchweb name"},
+		"10.77.0":            {"ec2/i-0searchweb ip"},
+		"203.0.113.77":       {"ec2/i-0searchweb ip"},
+		"function:searchweb": {"lambda/searchweb-resize arn"},
+		"  ":                 nil,
+	} {
+		if got := keys(q, 0); !slices.Equal(got, want) {
+			t.Errorf("%q: got %v, want %v", q, got, want)
+		}
+	}
+	if got := keys("searchweb", 1); len(got) != 1 || got[0] != "ec2/i-0searchweb name" {
+		t.Errorf("limit 1: %v", got)
+	}
+}
@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// SecretStaleDays is how long a secret can go unread or unrotated before
+// it is flagged.
+const SecretStaleDays = 90
+
+// Secret is a Secrets Manager secret's metadata. The value is never
+// fetched. LastAccessed is tracked by AWS to the day.
+type Secret struct {
+	Name            string `json:"Name"`
+	Arn             string `json:"ARN"`
+	Description     string `json:"Description"`
+	KmsKeyId        string `json:"KmsKeyId"`
+	RotationEnabled bool   `json:"RotationEnabled"`
+	RotationDays    int    `json:"RotationDays"`
+	RotationLambda  string `json:"RotationLambdaARN"`
+	Created         string `json:"CreatedDate"`
+	LastChanged     string `json:"LastChangedDate"`
+	LastRotated     string `json:"LastRotatedDate"`
+	LastAccessed    string `json:"LastAccessedDate"`
+}
+
+// NeverRotated reports whether the secret has no recorded rotation.
+func (s Secret) NeverRotated() bool {
+	return s.LastRotated == ""
+}
+
+// DaysSinceAccess returns whole days since the secret was last read, or
+// days since creation when it has never been read. -1 when unknown.
+func (s Secret) DaysSinceAccess(now time.Time) int {
+	return daysSince(now, s.LastAccessed, s.Created)
+}
+
+// DaysSinceRotation returns whole days since the last rotation, or since
+// creation when never rotated. -1 when unknown.
+func (s Secret) DaysSinceRotation(now time.Time) int {
+	return daysSince(now, s.LastRotated, s.Created)
+}
+
+func daysSince(now time.Time, stamps ...string) int {
+	for _, v := range stamps {
+		if v == "" {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return int(now.Sub(t).Hours() / 24)
+		}
+	}
+	return -1
+}
+
+// SyncSecretsData fetches Secrets Manager secret metadata: rotation
+// settings and last rotated/accessed dates.
+func SyncSecretsData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("secretsmanager", "list-secrets", "--region", region)
+	if err != nil {
+		step("secrets")
+		return []SyncResult{{Service: "secrets", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		SecretList []json.RawMessage `json:"SecretList"`
+	}
+	json.Unmarshal(data, &resp)
+	var secrets []Secret
+	for _, raw := range resp.SecretList {
+		var s Secret
+		json.Unmarshal(raw, &s)
+		var r struct {
+			RotationRules struct {
+				AutomaticallyAfterDays int `json:"AutomaticallyAfterDays"`
+			} `json:"RotationRules"`
+		}
+		json.Unmarshal(raw, &r)
+		s.RotationDays = r.RotationRules.AutomaticallyAfterDays
+		secrets = append(secrets, s)
+	}
+	step("secrets")
+
+	b, _ := json.Marshal(secrets)
+	st.WriteCache(region+":secrets", b)
+	return []SyncResult{{Service: "secrets", Count: len(secrets)}}, nil
+}
+
+// LoadSecrets returns the cached secrets for region.
+func LoadSecrets(region string) ([]Secret, error) {
+	raw, err := ReadCache(region + ":secrets")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var secrets []Secret
+	json.Unmarshal(raw, &secrets)
+	return secrets, nil
+}
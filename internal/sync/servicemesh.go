@@ -0,0 +1,414 @@
+package sync
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// ServiceConnect is an ECS service's Service Connect configuration, taken
+// from its primary deployment. Every service in a namespace can call the
+// endpoints the others publish.
+type ServiceConnect struct {
+	Namespace string                   `json:"Namespace"` // Cloud Map namespace ARN or name
+	Endpoints []ServiceConnectEndpoint `json:"Endpoints"`
+}
+
+// ServiceConnectEndpoint is a port a service publishes to its namespace,
+// under the DNS name clients use.
+type ServiceConnectEndpoint struct {
+	PortName      string `json:"PortName"`
+	DiscoveryName string `json:"DiscoveryName"`
+	DNSName       string `json:"DNSName"`
+	Port          int    `json:"Port"`
+}
+
+// Address returns the name:port clients connect to.
+func (e ServiceConnectEndpoint) Address() string {
+	if e.Port == 0 {
+		return e.DNSName
+	}
+	return e.DNSName + ":" + strconv.Itoa(e.Port)
+}
+
+// ServiceConnectPeer is a service another service can reach over Service
+// Connect.
+type ServiceConnectPeer struct {
+	Cluster   string
+	Service   string
+	Endpoints []ServiceConnectEndpoint
+}
+
+// ServiceConnectPeers returns the other services in svc's namespace that
+// publish endpoints, across all clusters in the region.
+func (d *ComputeData) ServiceConnectPeers(svc ECSService) []ServiceConnectPeer {
+	if d == nil || svc.ServiceConnect == nil {
+		return nil
+	}
+	ns := namespaceKey(svc.ServiceConnect.Namespace)
+	var out []ServiceConnectPeer
+	for _, cl := range d.ECS {
+		for _, s := range cl.ECSServices {
+			if s.ServiceName == svc.ServiceName || s.ServiceConnect == nil || len(s.ServiceConnect.Endpoints) == 0 {
+				continue
+			}
+			if namespaceKey(s.ServiceConnect.Namespace) != ns {
+				continue
+			}
+			out = append(out, ServiceConnectPeer{Cluster: cl.ClusterName, Service: s.ServiceName, Endpoints: s.ServiceConnect.Endpoints})
+		}
+	}
+	return out
+}
+
+// namespaceKey reduces a namespace ARN to its ID so services configured
+// by ARN and by ID compare equal.
+func namespaceKey(ns string) string {
+	return ns[strings.LastIndex(ns, "/")+1:]
+}
+
+// ServiceMeshData is the region's Cloud Map namespaces (which also name
+// Service Connect namespaces) and App Mesh meshes.
+type ServiceMeshData struct {
+	Namespaces []CloudMapNamespace `json:"Namespaces"`
+	Meshes     []AppMesh           `json:"Meshes"`
+}
+
+// NamespaceName returns the name of a namespace given by ARN or ID, or
+// the ID when the namespace is not cached.
+func (d *ServiceMeshData) NamespaceName(ns string) string {
+	key := namespaceKey(ns)
+	if d != nil {
+		for _, n := range d.Namespaces {
+			if n.Id == key || n.Name == ns {
+				return n.Name
+			}
+		}
+	}
+	return key
+}
+
+// AppMesh is a mesh with its virtual services, routers and nodes.
+type AppMesh struct {
+	Name     string               `json:"Name"`
+	Arn      string               `json:"Arn"`
+	Services []MeshVirtualService `json:"Services"`
+	Routers  []MeshVirtualRouter  `json:"Routers"`
+	Nodes    []MeshVirtualNode    `json:"Nodes"`
+}
+
+// MeshVirtualService is the name callers use; Provider is the virtual node
+// or router that serves it.
+type MeshVirtualService struct {
+	Name         string `json:"Name"`
+	Provider     string `json:"Provider"`
+	ProviderType string `json:"ProviderType"` // "node", "router" or ""
+}
+
+type MeshVirtualRouter struct {
+	Name   string      `json:"Name"`
+	Routes []MeshRoute `json:"Routes"`
+}
+
+// MeshRoute sends matching traffic to weighted virtual nodes.
+type MeshRoute struct {
+	Name    string       `json:"Name"`
+	Type    string       `json:"Type"` // http, http2, grpc or tcp
+	Match   string       `json:"Match"`
+	Targets []MeshTarget `json:"Targets"`
+}
+
+type MeshTarget struct {
+	Node   string `json:"Node"`
+	Weight int    `json:"Weight"`
+}
+
+// MeshVirtualNode is a workload in the mesh. Backends are the virtual
+// services it is allowed to call.
+type MeshVirtualNode struct {
+	Name      string   `json:"Name"`
+	Listeners []string `json:"Listeners"` // protocol:port
+	Backends  []string `json:"Backends"`
+	Discovery string   `json:"Discovery"` // DNS hostname or Cloud Map service.namespace
+}
+
+// NodesFor resolves a virtual service to the nodes that serve it,
+// following its router's routes.
+func (m AppMesh) NodesFor(service string) []string {
+	for _, vs := range m.Services {
+		if vs.Name != service {
+			continue
+		}
+		if vs.ProviderType == "node" {
+			return []string{vs.Provider}
+		}
+		var nodes []string
+		seen := map[string]bool{}
+		for _, r := range m.Routers {
+			if r.Name != vs.Provider {
+				continue
+			}
+			for _, route := range r.Routes {
+				for _, t := range route.Targets {
+					if !seen[t.Node] {
+						seen[t.Node] = true
+						nodes = append(nodes, t.Node)
+					}
+				}
+			}
+		}
+		return nodes
+	}
+	return nil
+}
+
+// SyncServiceMeshData fetches Cloud Map namespaces with their registered
+// instances, and App Mesh meshes with their virtual services, routers,
+// routes and nodes. Service Connect itself is part of the ECS service
+// description synced with Compute.
+func SyncServiceMeshData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	data := &ServiceMeshData{}
+	var results []SyncResult
+
+	if out, err := awscli.Run("servicediscovery", "list-namespaces", "--region", region); err == nil {
+		var resp struct {
+			Namespaces []CloudMapNamespace `json:"Namespaces"`
+		}
+		json.Unmarshal(out, &resp)
+		data.Namespaces = resp.Namespaces
+		syncCloudMapServices(region, data.Namespaces)
+		results = append(results, SyncResult{Service: "cloudmap", Count: len(data.Namespaces)})
+	} else {
+		results = append(results, SyncResult{Service: "cloudmap", Error: err.Error()})
+	}
+	step("cloud map")
+
+	if out, err := awscli.Run("appmesh", "list-meshes", "--region", region); err == nil {
+		var resp struct {
+			Meshes []struct {
+				MeshName string `json:"meshName"`
+				Arn      string `json:"arn"`
+			} `json:"meshes"`
+		}
+		json.Unmarshal(out, &resp)
+		for _, m := range resp.Meshes {
+			data.Meshes = append(data.Meshes, syncMesh(region, m.MeshName, m.Arn))
+		}
+		results = append(results, SyncResult{Service: "appmesh", Count: len(data.Meshes)})
+	} else {
+		results = append(results, SyncResult{Service: "appmesh", Error: err.Error()})
+	}
+	step("app mesh")
+
+	b, _ := json.Marshal(data)
+	st.WriteCache(region+":servicemesh", b)
+	return results, nil
+}
+
+func syncMesh(region, name, arn string) AppMesh {
+	mesh := AppMesh{Name: name, Arn: arn}
+	meshArgs := func(args ...string) []string {
+		return append(append([]string{"appmesh"}, args...), "--mesh-name", name, "--region", region)
+	}
+
+	if out, err := awscli.Run(meshArgs("list-virtual-nodes")...); err == nil {
+		var resp struct {
+			VirtualNodes []struct {
+				VirtualNodeName string `json:"virtualNodeName"`
+			} `json:"virtualNodes"`
+		}
+		json.Unmarshal(out, &resp)
+		for _, n := range resp.VirtualNodes {
+			node := MeshVirtualNode{Name: n.VirtualNodeName}
+			if out, err := awscli.Run(meshArgs("describe-virtual-node", "--virtual-node-name", n.VirtualNodeName)...); err == nil {
+				var d struct {
+					VirtualNode struct {
+						Spec struct {
+							Listeners []struct {
+								PortMapping struct {
+									Port     int    `json:"port"`
+									Protocol string `json:"protocol"`
+								} `json:"portMapping"`
+							} `json:"listeners"`
+							Backends []struct {
+								VirtualService struct {
+									VirtualServiceName string `json:"virtualServiceName"`
+								} `json:"virtualService"`
+							} `json:"backends"`
+							ServiceDiscovery struct {
+								DNS *struct {
+									Hostname string `json:"hostname"`
+								} `json:"dns"`
+								AWSCloudMap *struct {
+									NamespaceName string `json:"namespaceName"`
+									ServiceName   string `json:"serviceName"`
+								} `json:"awsCloudMap"`
+							} `json:"serviceDiscovery"`
+						} `json:"spec"`
+					} `json:"virtualNode"`
+				}
+				json.Unmarshal(out, &d)
+				spec := d.VirtualNode.Spec
+				for _, l := range spec.Listeners {
+					node.Listeners = append(node.Listeners, l.PortMapping.Protocol+":"+strconv.Itoa(l.PortMapping.Port))
+				}
+				for _, b := range spec.Backends {
+					if b.VirtualService.VirtualServiceName != "" {
+						node.Backends = append(node.Backends, b.VirtualService.VirtualServiceName)
+					}
+				}
+				switch {
+				case spec.ServiceDiscovery.DNS != nil:
+					node.Discovery = spec.ServiceDiscovery.DNS.Hostname
+				case spec.ServiceDiscovery.AWSCloudMap != nil:
+					node.Discovery = spec.ServiceDiscovery.AWSCloudMap.ServiceName + "." + spec.ServiceDiscovery.AWSCloudMap.NamespaceName
+				}
+			}
+			mesh.Nodes = append(mesh.Nodes, node)
+		}
+	}
+
+	if out, err := awscli.Run(meshArgs("list-virtual-services")...); err == nil {
+		var resp struct {
+			VirtualServices []struct {
+				VirtualServiceName string `json:"virtualServiceName"`
+			} `json:"virtualServices"`
+		}
+		json.Unmarshal(out, &resp)
+		for _, s := range resp.VirtualServices {
+			vs := MeshVirtualService{Name: s.VirtualServiceName}
+			if out, err := awscli.Run(meshArgs("describe-virtual-service", "--virtual-service-name", s.VirtualServiceName)...); err == nil {
+				var d struct {
+					VirtualService struct {
+						Spec struct {
+							Provider struct {
+								VirtualNode *struct {
+									VirtualNodeName string `json:"virtualNodeName"`
+								} `json:"virtualNode"`
+								VirtualRouter *struct {
+									VirtualRouterName string `json:"virtualRouterName"`
+								} `json:"virtualRouter"`
+							} `json:"provider"`
+						} `json:"spec"`
+					} `json:"virtualService"`
+				}
+				json.Unmarshal(out, &d)
+				p := d.VirtualService.Spec.Provider
+				switch {
+				case p.VirtualNode != nil:
+					vs.Provider, vs.ProviderType = p.VirtualNode.VirtualNodeName, "node"
+				case p.VirtualRouter != nil:
+					vs.Provider, vs.ProviderType = p.VirtualRouter.VirtualRouterName, "router"
+				}
+			}
+			mesh.Services = append(mesh.Services, vs)
+		}
+	}
+
+	if out, err := awscli.Run(meshArgs("list-virtual-routers")...); err == nil {
+		var resp struct {
+			VirtualRouters []struct {
+				VirtualRouterName string `json:"virtualRouterName"`
+			} `json:"virtualRouters"`
+		}
+		json.Unmarshal(out, &resp)
+		for _, r := range resp.VirtualRouters {
+			router := MeshVirtualRouter{Name: r.VirtualRouterName}
+			if out, err := awscli.Run(meshArgs("list-routes", "--virtual-router-name", r.VirtualRouterName)...); err == nil {
+				var routes struct {
+					Routes []struct {
+						RouteName string `json:"routeName"`
+					} `json:"routes"`
+				}
+				json.Unmarshal(out, &routes)
+				for _, rt := range routes.Routes {
+					out, err := awscli.Run(meshArgs("describe-route", "--virtual-router-name", r.VirtualRouterName, "--route-name", rt.RouteName)...)
+					if err != nil {
+						router.Routes = append(router.Routes, MeshRoute{Name: rt.RouteName})
+						continue
+					}
+					router.Routes = append(router.Routes, parseMeshRoute(rt.RouteName, out))
+				}
+			}
+			mesh.Routers = append(mesh.Routers, router)
+		}
+	}
+	return mesh
+}
+
+// meshRouteSpec is the part shared by the http, http2, grpc and tcp route
+// types.
+type meshRouteSpec struct {
+	Action struct {
+		WeightedTargets []struct {
+			VirtualNode string `json:"virtualNode"`
+			Weight      int    `json:"weight"`
+		} `json:"weightedTargets"`
+	} `json:"action"`
+	Match *struct {
+		Prefix      string `json:"prefix"`
+		ServiceName string `json:"serviceName"`
+		Port        int    `json:"port"`
+	} `json:"match"`
+}
+
+func parseMeshRoute(name string, raw []byte) MeshRoute {
+	var d struct {
+		Route struct {
+			Spec struct {
+				HTTPRoute  *meshRouteSpec `json:"httpRoute"`
+				HTTP2Route *meshRouteSpec `json:"http2Route"`
+				GRPCRoute  *meshRouteSpec `json:"grpcRoute"`
+				TCPRoute   *meshRouteSpec `json:"tcpRoute"`
+			} `json:"spec"`
+		} `json:"route"`
+	}
+	json.Unmarshal(raw, &d)
+
+	route := MeshRoute{Name: name}
+	var spec *meshRouteSpec
+	switch s := d.Route.Spec; {
+	case s.HTTPRoute != nil:
+		route.Type, spec = "http", s.HTTPRoute
+	case s.HTTP2Route != nil:
+		route.Type, spec = "http2", s.HTTP2Route
+	case s.GRPCRoute != nil:
+		route.Type, spec = "grpc", s.GRPCRoute
+	case s.TCPRoute != nil:
+		route.Type, spec = "tcp", s.TCPRoute
+	default:
+		return route
+	}
+	if m := spec.Match; m != nil {
+		switch {
+		case m.Prefix != "":
+			route.Match = m.Prefix
+		case m.ServiceName != "":
+			route.Match = m.ServiceName
+		case m.Port != 0:
+			route.Match = "port " + strconv.Itoa(m.Port)
+		}
+	}
+	for _, t := range spec.Action.WeightedTargets {
+		route.Targets = append(route.Targets, MeshTarget{Node: t.VirtualNode, Weight: t.Weight})
+	}
+	return route
+}
+
+// LoadServiceMeshData returns the cached namespaces and meshes for region.
+func LoadServiceMeshData(region string) (*ServiceMeshData, error) {
+	raw, err := ReadCache(region + ":servicemesh")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var data ServiceMeshData
+	json.Unmarshal(raw, &data)
+	return &data, nil
+}
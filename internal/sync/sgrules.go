@@ -0,0 +1,166 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SGRule is one permission of a security group, flattened to a single
+// peer. Peer is a CIDR (IPv4 or IPv6), a security group ID or a prefix
+// list ID. FromPort and ToPort are -1 when the rule covers all ports.
+type SGRule struct {
+	Protocol string `json:"Protocol"` // tcp, udp, icmp, icmpv6, or "-1" for all
+	FromPort int    `json:"FromPort"`
+	ToPort   int    `json:"ToPort"`
+	Peer     string `json:"Peer"`
+}
+
+// SGRules is a security group with its full rule set, the input of the
+// reachability analysis.
+type SGRules struct {
+	GroupId  string   `json:"GroupId"`
+	Name     string   `json:"Name"`
+	VpcId    string   `json:"VpcId"`
+	Inbound  []SGRule `json:"Inbound"`
+	Outbound []SGRule `json:"Outbound"`
+}
+
+// Ports renders the port range as "22", "8000-8100" or "all".
+func (r SGRule) Ports() string {
+	switch {
+	case r.FromPort < 0 || r.Protocol == "-1":
+		return "all"
+	case r.FromPort == r.ToPort:
+		return strconv.Itoa(r.FromPort)
+	}
+	return fmt.Sprintf("%d-%d", r.FromPort, r.ToPort)
+}
+
+// ProtocolName renders "-1" as "all".
+func (r SGRule) ProtocolName() string {
+	if r.Protocol == "-1" {
+		return "all"
+	}
+	return r.Protocol
+}
+
+func (r SGRule) String() string {
+	return r.ProtocolName() + " " + r.Ports() + " " + r.Peer
+}
+
+// ParseSGRule reads a rule written as "<protocol> <ports> <peer>", e.g.
+// "tcp 22 10.0.0.0/8", "udp 8000-8100 sg-0abc" or "all all 0.0.0.0/0".
+func ParseSGRule(s string) (SGRule, error) {
+	f := strings.Fields(s)
+	if len(f) != 3 {
+		return SGRule{}, fmt.Errorf("expected \"<protocol> <ports> <source>\", got %q", s)
+	}
+	r := SGRule{Protocol: strings.ToLower(f[0]), FromPort: -1, ToPort: -1, Peer: f[2]}
+	switch r.Protocol {
+	case "all", "-1":
+		r.Protocol = "-1"
+	case "tcp", "udp", "icmp", "icmpv6":
+	default:
+		return SGRule{}, fmt.Errorf("unknown protocol %q (tcp, udp, icmp, icmpv6 or all)", f[0])
+	}
+	if p := strings.ToLower(f[1]); p != "all" && r.Protocol != "-1" {
+		from, to, ok := strings.Cut(p, "-")
+		if !ok {
+			to = from
+		}
+		var err1, err2 error
+		r.FromPort, err1 = strconv.Atoi(from)
+		r.ToPort, err2 = strconv.Atoi(to)
+		if err1 != nil || err2 != nil || r.FromPort < 0 || r.ToPort > 65535 || r.FromPort > r.ToPort {
+			return SGRule{}, fmt.Errorf("bad port range %q", f[1])
+		}
+	}
+	if !strings.HasPrefix(r.Peer, "sg-") && !strings.HasPrefix(r.Peer, "pl-") && !strings.Contains(r.Peer, "/") {
+		return SGRule{}, fmt.Errorf("source %q is not a CIDR, security group or prefix list", r.Peer)
+	}
+	return r, nil
+}
+
+type sgRulePermission struct {
+	IpProtocol string `json:"IpProtocol"`
+	FromPort   *int   `json:"FromPort"`
+	ToPort     *int   `json:"ToPort"`
+	IpRanges   []struct {
+		CidrIp string `json:"CidrIp"`
+	} `json:"IpRanges"`
+	Ipv6Ranges []struct {
+		CidrIpv6 string `json:"CidrIpv6"`
+	} `json:"Ipv6Ranges"`
+	UserIdGroupPairs []struct {
+		GroupId string `json:"GroupId"`
+	} `json:"UserIdGroupPairs"`
+	PrefixListIds []struct {
+		PrefixListId string `json:"PrefixListId"`
+	} `json:"PrefixListIds"`
+}
+
+func flattenSGPermissions(perms []sgRulePermission) []SGRule {
+	var out []SGRule
+	for _, p := range perms {
+		r := SGRule{Protocol: p.IpProtocol, FromPort: -1, ToPort: -1}
+		if p.FromPort != nil && p.ToPort != nil && p.IpProtocol != "-1" {
+			r.FromPort, r.ToPort = *p.FromPort, *p.ToPort
+		}
+		var peers []string
+		for _, c := range p.IpRanges {
+			peers = append(peers, c.CidrIp)
+		}
+		for _, c := range p.Ipv6Ranges {
+			peers = append(peers, c.CidrIpv6)
+		}
+		for _, g := range p.UserIdGroupPairs {
+			peers = append(peers, g.GroupId)
+		}
+		for _, pl := range p.PrefixListIds {
+			peers = append(peers, pl.PrefixListId)
+		}
+		for _, peer := range peers {
+			r.Peer = peer
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// LoadSGRules returns the cached security groups of a region with their
+// rules, keyed by group ID.
+func LoadSGRules(region string) (map[string]*SGRules, error) {
+	raw, err := ReadCache(region + ":security-groups")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var resp struct{ SecurityGroups []json.RawMessage }
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, err
+	}
+	out := map[string]*SGRules{}
+	for _, r := range resp.SecurityGroups {
+		var sg struct {
+			GroupId             string             `json:"GroupId"`
+			GroupName           string             `json:"GroupName"`
+			VpcId               string             `json:"VpcId"`
+			IpPermissions       []sgRulePermission `json:"IpPermissions"`
+			IpPermissionsEgress []sgRulePermission `json:"IpPermissionsEgress"`
+		}
+		json.Unmarshal(r, &sg)
+		name := tagName(r)
+		if name == "" {
+			name = sg.GroupName
+		}
+		out[sg.GroupId] = &SGRules{
+			GroupId:  sg.GroupId,
+			Name:     name,
+			VpcId:    sg.VpcId,
+			Inbound:  flattenSGPermissions(sg.IpPermissions),
+			Outbound: flattenSGPermissions(sg.IpPermissionsEgress),
+		}
+	}
+	return out, nil
+}
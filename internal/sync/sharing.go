@@ -0,0 +1,239 @@
+package sync
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// SharedArtifact is an AMI or snapshot owned by this account and who can
+// launch or restore it. Only artifacts that are public or shared with
+// another account are cached.
+type SharedArtifact struct {
+	Kind       string   `json:"kind"` // ami, ebs-snapshot, rds-snapshot, rds-cluster-snapshot
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Created    string   `json:"created"`
+	Public     bool     `json:"public"`
+	SharedWith []string `json:"sharedWith"` // account IDs
+}
+
+// Resource returns the "type/id" key used by audit findings.
+func (a SharedArtifact) Resource() string {
+	return a.Kind + "/" + a.ID
+}
+
+// syncImageSharing checks launch and volume permissions on the account's
+// AMIs and EBS snapshots. Called from SyncComputeData.
+func syncImageSharing(st *Stage, region string, step func(string)) []SyncResult {
+	var results []SyncResult
+
+	if data, err := awscli.Run("ec2", "describe-images", "--owners", "self", "--region", region); err == nil {
+		var resp struct {
+			Images []struct {
+				ImageId      string `json:"ImageId"`
+				Name         string `json:"Name"`
+				CreationDate string `json:"CreationDate"`
+				Public       bool   `json:"Public"`
+			} `json:"Images"`
+		}
+		json.Unmarshal(data, &resp)
+		var shared []SharedArtifact
+		for _, img := range resp.Images {
+			a := SharedArtifact{Kind: "ami", ID: img.ImageId, Name: img.Name, Created: img.CreationDate, Public: img.Public}
+			if attr, err := awscli.Run("ec2", "describe-image-attribute", "--image-id", img.ImageId,
+				"--attribute", "launchPermission", "--region", region); err == nil {
+				applyEC2Permissions(&a, attr, "LaunchPermissions")
+			}
+			if a.Public || len(a.SharedWith) > 0 {
+				shared = append(shared, a)
+			}
+		}
+		b, _ := json.Marshal(shared)
+		st.WriteCache(region+":ami-sharing", b)
+		results = append(results, SyncResult{Service: "ami-sharing", Count: len(shared)})
+	} else {
+		results = append(results, SyncResult{Service: "ami-sharing", Error: err.Error()})
+	}
+	step("ami sharing")
+
+	if data, err := awscli.Run("ec2", "describe-snapshots", "--owner-ids", "self", "--region", region); err == nil {
+		st.WriteCache(region+":ebs-snapshots", data)
+		var resp struct {
+			Snapshots []struct {
+				SnapshotId  string `json:"SnapshotId"`
+				Description string `json:"Description"`
+				StartTime   string `json:"StartTime"`
+			} `json:"Snapshots"`
+		}
+		json.Unmarshal(data, &resp)
+		var shared []SharedArtifact
+		for _, s := range resp.Snapshots {
+			a := SharedArtifact{Kind: "ebs-snapshot", ID: s.SnapshotId, Name: s.Description, Created: s.StartTime}
+			if attr, err := awscli.Run("ec2", "describe-snapshot-attribute", "--snapshot-id", s.SnapshotId,
+				"--attribute", "createVolumePermission", "--region", region); err == nil {
+				applyEC2Permissions(&a, attr, "CreateVolumePermissions")
+			}
+			if a.Public || len(a.SharedWith) > 0 {
+				shared = append(shared, a)
+			}
+		}
+		b, _ := json.Marshal(shared)
+		st.WriteCache(region+":ebs-snapshot-sharing", b)
+		results = append(results, SyncResult{Service: "ebs-snapshot-sharing", Count: len(shared)})
+	} else {
+		results = append(results, SyncResult{Service: "ebs-snapshot-sharing", Error: err.Error()})
+	}
+	step("snapshot sharing")
+
+	return results
+}
+
+// applyEC2Permissions reads a launch/create-volume permission list, where
+// {"Group":"all"} means public and {"UserId":...} is a shared account.
+func applyEC2Permissions(a *SharedArtifact, raw []byte, field string) {
+	var resp map[string][]struct {
+		Group  string `json:"Group"`
+		UserId string `json:"UserId"`
+	}
+	json.Unmarshal(raw, &resp)
+	for _, p := range resp[field] {
+		if p.Group == "all" {
+			a.Public = true
+		}
+		if p.UserId != "" {
+			a.SharedWith = append(a.SharedWith, p.UserId)
+		}
+	}
+}
+
+// syncRDSSnapshotSharing checks the restore attribute on manual DB and
+// cluster snapshots; automated snapshots cannot be shared. Called from
+// SyncDatabaseData.
+func syncRDSSnapshotSharing(st *Stage, region string, step func(string)) []SyncResult {
+	var shared []SharedArtifact
+	var syncErr error
+
+	if data, err := awscli.Run("rds", "describe-db-snapshots", "--snapshot-type", "manual", "--region", region); err == nil {
+		var resp struct {
+			DBSnapshots []struct {
+				DBSnapshotIdentifier string `json:"DBSnapshotIdentifier"`
+				DBInstanceIdentifier string `json:"DBInstanceIdentifier"`
+				SnapshotCreateTime   string `json:"SnapshotCreateTime"`
+			} `json:"DBSnapshots"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, s := range resp.DBSnapshots {
+			a := SharedArtifact{Kind: "rds-snapshot", ID: s.DBSnapshotIdentifier, Name: s.DBInstanceIdentifier, Created: s.SnapshotCreateTime}
+			if attr, err := awscli.Run("rds", "describe-db-snapshot-attributes",
+				"--db-snapshot-identifier", s.DBSnapshotIdentifier, "--region", region); err == nil {
+				var r struct {
+					Result struct {
+						Attributes []rdsSnapshotAttribute `json:"DBSnapshotAttributes"`
+					} `json:"DBSnapshotAttributesResult"`
+				}
+				json.Unmarshal(attr, &r)
+				applyRDSRestore(&a, r.Result.Attributes)
+			}
+			if a.Public || len(a.SharedWith) > 0 {
+				shared = append(shared, a)
+			}
+		}
+	} else {
+		syncErr = err
+	}
+
+	if data, err := awscli.Run("rds", "describe-db-cluster-snapshots", "--snapshot-type", "manual", "--region", region); err == nil {
+		var resp struct {
+			DBClusterSnapshots []struct {
+				DBClusterSnapshotIdentifier string `json:"DBClusterSnapshotIdentifier"`
+				DBClusterIdentifier         string `json:"DBClusterIdentifier"`
+				SnapshotCreateTime          string `json:"SnapshotCreateTime"`
+			} `json:"DBClusterSnapshots"`
+		}
+		json.Unmarshal(data, &resp)
+		for _, s := range resp.DBClusterSnapshots {
+			a := SharedArtifact{Kind: "rds-cluster-snapshot", ID: s.DBClusterSnapshotIdentifier, Name: s.DBClusterIdentifier, Created: s.SnapshotCreateTime}
+			if attr, err := awscli.Run("rds", "describe-db-cluster-snapshot-attributes",
+				"--db-cluster-snapshot-identifier", s.DBClusterSnapshotIdentifier, "--region", region); err == nil {
+				var r struct {
+					Result struct {
+						Attributes []rdsSnapshotAttribute `json:"DBClusterSnapshotAttributes"`
+					} `json:"DBClusterSnapshotAttributesResult"`
+				}
+				json.Unmarshal(attr, &r)
+				applyRDSRestore(&a, r.Result.Attributes)
+			}
+			if a.Public || len(a.SharedWith) > 0 {
+				shared = append(shared, a)
+			}
+		}
+	} else if syncErr == nil {
+		syncErr = err
+	}
+	step("rds snapshot sharing")
+
+	if syncErr != nil {
+		return []SyncResult{{Service: "rds-snapshot-sharing", Error: syncErr.Error()}}
+	}
+	b, _ := json.Marshal(shared)
+	st.WriteCache(region+":rds-snapshot-sharing", b)
+	return []SyncResult{{Service: "rds-snapshot-sharing", Count: len(shared)}}
+}
+
+type rdsSnapshotAttribute struct {
+	AttributeName   string   `json:"AttributeName"`
+	AttributeValues []string `json:"AttributeValues"`
+}
+
+// applyRDSRestore reads the "restore" attribute, where "all" means public.
+func applyRDSRestore(a *SharedArtifact, attrs []rdsSnapshotAttribute) {
+	for _, attr := range attrs {
+		if attr.AttributeName != "restore" {
+			continue
+		}
+		for _, v := range attr.AttributeValues {
+			if v == "all" {
+				a.Public = true
+			} else if v != "" {
+				a.SharedWith = append(a.SharedWith, v)
+			}
+		}
+	}
+}
+
+// LoadSharedArtifacts returns the cached public or shared AMIs and
+// snapshots for region.
+func LoadSharedArtifacts(region string) ([]SharedArtifact, error) {
+	var out []SharedArtifact
+	for _, key := range []string{"ami-sharing", "ebs-snapshot-sharing", "rds-snapshot-sharing"} {
+		raw, err := ReadCache(region + ":" + key)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			continue
+		}
+		var items []SharedArtifact
+		json.Unmarshal(raw, &items)
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+var accountIDPattern = regexp.MustCompile(`^\d{12}$`)
+
+// KnownAccounts returns the known_accounts setting: account IDs that AMIs
+// and snapshots may be shared with without being flagged.
+func KnownAccounts() map[string]bool {
+	v, _ := GetSetting("known_accounts")
+	out := map[string]bool{}
+	for _, id := range strings.Split(v, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			out[id] = true
+		}
+	}
+	return out
+}
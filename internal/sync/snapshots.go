@@ -0,0 +1,258 @@
+package sync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// DefaultSnapshotRetentionDays is used when the snapshot_retention_days
+// setting is unset.
+const DefaultSnapshotRetentionDays = 30
+
+// Snapshot is one sync of a region: every cache entry written while it was
+// open, plus the region's inventory when it finished.
+type Snapshot struct {
+	ID         int64     `json:"id"`
+	Region     string    `json:"region"`
+	Scope      string    `json:"scope"` // "all" or a tab
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"` // zero while running or if the sync died
+}
+
+// snapshotInventoryKey is the entry holding a region's inventory as of a
+// snapshot. It only exists in snapshot_entries, never in the cache.
+func snapshotInventoryKey(region string) string {
+	return region + ":inventory"
+}
+
+// BeginSnapshot opens a snapshot for a sync of scope in region and returns
+// the stage the sync writes through; its writes are held there until
+// FinishSnapshot commits them or AbortSnapshot drops them. While another
+// sync's snapshot is open it waits for that one to be committed or dropped.
+func BeginSnapshot(region, scope string) (*Stage, error) {
+	if err := writable(); err != nil {
+		return nil, err
+	}
+	stageMu.Lock()
+	defer stageMu.Unlock()
+	for openStage != nil {
+		stageFree.Wait()
+	}
+	res, err := db.Exec(`INSERT INTO snapshots (account, region, scope, started_at) VALUES (?, ?, ?, ?)`,
+		Account(), region, scope, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	openStage = &Stage{id: id, region: region, entries: map[string]stagedEntry{}}
+	return openStage, nil
+}
+
+// FinishSnapshot commits the sync's cache writes, records the region's
+// inventory in the snapshot and the resources table, closes the snapshot
+// and prunes snapshots past the retention period.
+func FinishSnapshot(s *Stage) error {
+	if s == nil {
+		return nil
+	}
+	usage := s.has(s.region+":quotas") || s.has(s.region+":subnets")
+	if err := CommitSnapshot(s); err != nil {
+		return err
+	}
+	if usage {
+		// Counted from what the whole sync fetched, now that it is in.
+		recordUsageHistory(s.region)
+	}
+
+	now := time.Now()
+	if items, err := LoadInventory(s.region); err == nil {
+		b, _ := json.Marshal(items)
+		if err := writeSnapshotEntry(db, s.id, nsKey(snapshotInventoryKey(s.region)), string(b), now); err != nil {
+			return err
+		}
+		if err := writeResources(s.region, items, now); err != nil {
+			return err
+		}
+	}
+	if _, err := db.Exec(`UPDATE snapshots SET finished_at = ? WHERE id = ?`, now, s.id); err != nil {
+		return err
+	}
+	return PruneSnapshots(SnapshotRetention())
+}
+
+// CommitSnapshot writes the cache entries staged by s's sync, all or
+// nothing, if FinishSnapshot has not yet. The next sync may begin only
+// once they are in. A snapshot that fails to commit is aborted.
+func CommitSnapshot(s *Stage) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	err := s.commit()
+	s.mu.Unlock()
+	if err != nil {
+		AbortSnapshot(s)
+		return err
+	}
+	s.release()
+	return nil
+}
+
+// AbortSnapshot rolls back a failed sync: its staged cache writes are
+// dropped and the snapshot deleted, as if it had not run.
+func AbortSnapshot(s *Stage) error {
+	if s == nil {
+		return nil
+	}
+	s.release()
+	_, err := db.Exec(`DELETE FROM snapshots WHERE id = ?`, s.id)
+	return err
+}
+
+// writeSnapshotEntry records value for the stored (namespaced) key in
+// snapshot id, unless it is unchanged since the key's last recorded value.
+func writeSnapshotEntry(q dbtx, id int64, key, value string, at time.Time) error {
+	var last string
+	err := q.QueryRow(`SELECT value FROM snapshot_entries WHERE key = ? AND sync_id <= ? ORDER BY sync_id DESC LIMIT 1`,
+		key, id).Scan(&last)
+	if err == nil {
+		if last, err = unseal(last); err == nil && last == value {
+			return nil
+		}
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if value, err = seal(value); err != nil {
+		return err
+	}
+	_, err = q.Exec(`INSERT INTO snapshot_entries (sync_id, key, value, synced_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(sync_id, key) DO UPDATE SET value=excluded.value, synced_at=excluded.synced_at`,
+		id, key, value, at)
+	return err
+}
+
+// ListSnapshots returns the active account's snapshots of region, or of
+// every region when region is empty, newest first.
+func ListSnapshots(region string) ([]Snapshot, error) {
+	query := `SELECT id, region, scope, started_at, finished_at FROM snapshots WHERE account = ?`
+	args := []interface{}{Account()}
+	if region != "" {
+		query += ` AND region = ?`
+		args = append(args, region)
+	}
+	query += ` ORDER BY id DESC`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snaps []Snapshot
+	for rows.Next() {
+		var s Snapshot
+		var finished sql.NullTime
+		if err := rows.Scan(&s.ID, &s.Region, &s.Scope, &s.StartedAt, &finished); err != nil {
+			return nil, err
+		}
+		s.FinishedAt = finished.Time
+		snaps = append(snaps, s)
+	}
+	return snaps, rows.Err()
+}
+
+// ReadCacheAsOf returns key as it was cached when snapshot id finished:
+// the value written by that sync or, for keys it did not touch, by the
+// last sync before it. Nil when the key had not been synced yet.
+func ReadCacheAsOf(key string, id int64) (*CacheEntry, error) {
+	var value string
+	var syncedAt time.Time
+	err := db.QueryRow(`SELECT value, synced_at FROM snapshot_entries WHERE key = ? AND sync_id <= ? ORDER BY sync_id DESC LIMIT 1`,
+		nsKey(key), id).Scan(&value, &syncedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if value, err = unseal(value); err != nil {
+		return nil, err
+	}
+	return &CacheEntry{Value: json.RawMessage(value), SyncedAt: syncedAt}, nil
+}
+
+// InventoryAsOf returns region's inventory as recorded by the last
+// finished snapshot up to id.
+func InventoryAsOf(region string, id int64) ([]InventoryItem, error) {
+	e, err := ReadCacheAsOf(snapshotInventoryKey(region), id)
+	if e == nil {
+		return nil, err
+	}
+	var items []InventoryItem
+	if err := json.Unmarshal(e.Value, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// SnapshotRetention returns how long snapshots are kept, from the
+// snapshot_retention_days setting; 0 keeps them forever.
+func SnapshotRetention() time.Duration {
+	days := DefaultSnapshotRetentionDays
+	if v, err := GetSetting("snapshot_retention_days"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			days = n
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// PruneSnapshots deletes snapshots started more than keep ago, always
+// keeping the newest. Entries that later snapshots still read through
+// (values unchanged since) are folded into the oldest snapshot kept.
+func PruneSnapshots(keep time.Duration) error {
+	if keep <= 0 {
+		return nil
+	}
+	var kept sql.NullInt64
+	if err := db.QueryRow(`SELECT MIN(id) FROM snapshots WHERE started_at >= ?`, time.Now().Add(-keep)).Scan(&kept); err != nil {
+		return err
+	}
+	oldest := kept.Int64
+	if !kept.Valid {
+		if err := db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM snapshots`).Scan(&oldest); err != nil || oldest == 0 {
+			return err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// Drop entries superseded at or before the oldest kept snapshot, then
+	// move the survivors, the values it reads through, into it.
+	if _, err := tx.Exec(`DELETE FROM snapshot_entries WHERE sync_id < ? AND EXISTS (
+		SELECT 1 FROM snapshot_entries newer
+		WHERE newer.key = snapshot_entries.key AND newer.sync_id > snapshot_entries.sync_id AND newer.sync_id <= ?)`,
+		oldest, oldest); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE snapshot_entries SET sync_id = ? WHERE sync_id < ?`, oldest, oldest); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM snapshots WHERE id < ?`, oldest); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,178 @@
+package sync
+
+import (
+	"fmt"
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+func TestSnapshotsAsOf(t *testing.T) {
+	const region = "me-south-1"
+	lambdas := func(names ...string) []byte {
+		b := `[`
+		for i, n := range names {
+			if i > 0 {
+				b += `,`
+			}
+			b += `{"FunctionName":"` + n + `"}`
+		}
+		return []byte(b + `]`)
+	}
+
+	st, err := BeginSnapshot(region, "all")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.WriteCache(region+":lambda", lambdas("resize"))
+	st.WriteCache(region+":sqs", []byte(`[]`))
+	if err := FinishSnapshot(st); err != nil {
+		t.Fatal(err)
+	}
+	first := st.ID()
+
+	// Only Compute is synced the second time, and SQS is untouched.
+	st, _ = BeginSnapshot(region, "compute")
+	st.WriteCache(region+":lambda", lambdas("resize", "thumbnail"))
+	FinishSnapshot(st)
+	second := st.ID()
+
+	// Writes outside a sync are not recorded.
+	WriteCache(region+":lambda", lambdas())
+
+	if e, _ := ReadCacheAsOf(region+":lambda", first); e == nil || string(e.Value) != string(lambdas("resize")) {
+		t.Errorf("lambda as of first = %+v", e)
+	}
+	if e, _ := ReadCacheAsOf(region+":lambda", second); e == nil || string(e.Value) != string(lambdas("resize", "thumbnail")) {
+		t.Errorf("lambda as of second = %+v", e)
+	}
+	if e, _ := ReadCacheAsOf(region+":sqs", second); e == nil || string(e.Value) != `[]` {
+		t.Errorf("sqs as of second = %+v, want the first sync's value", e)
+	}
+	if e, _ := ReadCacheAsOf(region+":lambda", first-1); e != nil {
+		t.Errorf("lambda before any sync = %+v", e)
+	}
+
+	before, _ := InventoryAsOf(region, first)
+	after, _ := InventoryAsOf(region, second)
+	// Global items (IAM, S3) other tests cached are in both.
+	hasThumbnail := func(items []InventoryItem) bool {
+		for _, it := range items {
+			if it.Type == "lambda" && it.ID == "thumbnail" {
+				return true
+			}
+		}
+		return false
+	}
+	if len(after) != len(before)+1 || hasThumbnail(before) || !hasThumbnail(after) {
+		t.Errorf("inventory = %d then %d items, want thumbnail added", len(before), len(after))
+	}
+
+	snaps, _ := ListSnapshots(region)
+	if len(snaps) != 2 || snaps[0].ID != second || snaps[0].Scope != "compute" || snaps[1].FinishedAt.IsZero() {
+		t.Errorf("snapshots = %+v", snaps)
+	}
+
+	// Age the first snapshot past retention: it goes, but the SQS value the
+	// second still reads through survives.
+	db.Exec(`UPDATE snapshots SET started_at = ? WHERE id = ?`, time.Now().Add(-40*24*time.Hour), first)
+	if err := PruneSnapshots(SnapshotRetention()); err != nil {
+		t.Fatal(err)
+	}
+	if snaps, _ := ListSnapshots(region); len(snaps) != 1 || snaps[0].ID != second {
+		t.Errorf("after prune = %+v", snaps)
+	}
+	if e, _ := ReadCacheAsOf(region+":sqs", second); e == nil || string(e.Value) != `[]` {
+		t.Errorf("sqs as of second after prune = %+v", e)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM snapshot_entries WHERE key = ?`, region+":lambda").Scan(&n)
+	if n != 1 {
+		t.Errorf("lambda entries after prune = %d, want 1", n)
+	}
+}
+
+func TestSnapshotStaging(t *testing.T) {
+	const region = "af-south-1"
+	WriteCache(region+":sqs", []byte(`["old"]`))
+
+	// Groups write side by side; nothing lands until the sync finishes.
+	st, _ := BeginSnapshot(region, "all")
+	var wg gosync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			st.WriteCache(fmt.Sprintf("%s:queue-%d", region, i), []byte(`[]`))
+		}()
+	}
+	wg.Wait()
+	st.WriteCache(region+":sqs", []byte(`["new"]`))
+	if raw, _ := ReadCache(region + ":sqs"); string(raw) != `["old"]` {
+		t.Errorf("read during the sync = %s, want the committed value", raw)
+	}
+	var n int
+	db.QueryRow(`SELECT COUNT(*) FROM cache WHERE key GLOB ?`, region+":queue-*").Scan(&n)
+	if n != 0 {
+		t.Errorf("%d entries written before the sync finished", n)
+	}
+	if err := FinishSnapshot(st); err != nil {
+		t.Fatal(err)
+	}
+	db.QueryRow(`SELECT COUNT(*) FROM cache WHERE key GLOB ?`, region+":queue-*").Scan(&n)
+	if n != 8 {
+		t.Errorf("%d entries after commit, want 8", n)
+	}
+
+	// A failed sync leaves the cache and the snapshots as they were, but
+	// not writes made outside it meanwhile.
+	failed, _ := BeginSnapshot(region, "all")
+	failed.WriteCache(region+":sqs", []byte(`["partial"]`))
+	WriteCache(region+":seen", []byte(`["kept"]`))
+	if err := AbortSnapshot(failed); err != nil {
+		t.Fatal(err)
+	}
+	if raw, _ := ReadCache(region + ":sqs"); string(raw) != `["new"]` {
+		t.Errorf("sqs after rollback = %s", raw)
+	}
+	if raw, _ := ReadCache(region + ":seen"); string(raw) != `["kept"]` {
+		t.Errorf("write outside the sync after rollback = %s", raw)
+	}
+	if snaps, _ := ListSnapshots(region); len(snaps) != 1 || snaps[0].ID != st.ID() {
+		t.Errorf("snapshots after rollback = %+v", snaps)
+	}
+	// Writes through a closed stage go straight to the cache.
+	failed.WriteCache(region+":sqs", []byte(`[]`))
+	db.QueryRow(`SELECT COUNT(*) FROM cache WHERE key = ? AND value = '[]'`, region+":sqs").Scan(&n)
+	if n != 1 {
+		t.Errorf("write after the sync was not stored")
+	}
+}
+
+func TestSnapshotWaitsForActiveSync(t *testing.T) {
+	const region = "ap-east-1"
+	first, _ := BeginSnapshot(region, "net")
+
+	begun := make(chan *Stage)
+	go func() {
+		st, _ := BeginSnapshot(region, "compute")
+		// The first sync's writes are in by the time the next one begins.
+		if raw, _ := ReadCache(region + ":vpcs"); string(raw) != `[]` {
+			t.Errorf("first sync's write = %s when the second began, want it committed", raw)
+		}
+		begun <- st
+	}()
+	select {
+	case <-begun:
+		t.Fatal("second sync began while the first was open")
+	case <-time.After(50 * time.Millisecond):
+	}
+	first.WriteCache(region+":vpcs", []byte(`[]`))
+
+	if err := FinishSnapshot(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := AbortSnapshot(<-begun); err != nil {
+		t.Fatal(err)
+	}
+}
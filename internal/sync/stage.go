@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"database/sql"
+	gosync "sync"
+	"time"
+)
+
+// A Stage is one sync's handle on the cache. The sync writes through it:
+// its writes are held in memory while its snapshot is open and committed
+// together, in one transaction, when it finishes, so nothing outside the
+// sync sees it half-written and a sync that fails is dropped, leaving the
+// cache as it was. Only the sync's own writes are staged; WriteCache from
+// anywhere else goes straight to the cache and readers never see staged
+// values. A nil *Stage writes straight through, for syncs run without a
+// snapshot.
+type Stage struct {
+	id      int64
+	region  string
+	mu      gosync.Mutex
+	entries map[string]stagedEntry
+	closed  bool // committed or dropped; later writes go straight to the cache
+}
+
+type stagedEntry struct {
+	value string // unsealed
+	at    time.Time
+}
+
+// openStage is the stage of the sync in progress, nil outside one. Syncs
+// in one process run one at a time: BeginSnapshot waits on stageFree until
+// the open one is committed or dropped. Their service groups may run side
+// by side.
+var (
+	stageMu   gosync.Mutex
+	stageFree = gosync.NewCond(&stageMu)
+	openStage *Stage
+)
+
+// writeMu serializes this process's cache writes, so concurrent service
+// groups queue instead of contending for SQLite's lock. Other processes
+// wait on that lock, which every transaction takes as it begins
+// (_txlock=immediate), for up to the busy timeout.
+var writeMu gosync.Mutex
+
+// dbtx is a *sql.DB or *sql.Tx.
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ID returns the snapshot the stage belongs to, or 0 for a nil stage.
+func (s *Stage) ID() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.id
+}
+
+// WriteCache caches data under key as part of the sync.
+func (s *Stage) WriteCache(key string, data []byte) error {
+	if s != nil && s.put(nsKey(key), string(data), time.Now()) {
+		return nil
+	}
+	return WriteCache(key, data)
+}
+
+// put stages value for key, reporting false if the stage has closed.
+func (s *Stage) put(key, value string, at time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.entries[key] = stagedEntry{value, at}
+	return true
+}
+
+// has reports whether the sync wrote key.
+func (s *Stage) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.entries[nsKey(key)]
+	return ok
+}
+
+// release closes s and lets the next sync begin.
+func (s *Stage) release() {
+	s.mu.Lock()
+	s.closed = true
+	s.entries = nil
+	s.mu.Unlock()
+	stageMu.Lock()
+	defer stageMu.Unlock()
+	if openStage == s {
+		openStage = nil
+		stageFree.Broadcast()
+	}
+}
+
+// commit writes the staged entries to the cache and records them in the
+// snapshot, all or nothing. The caller holds s.mu.
+func (s *Stage) commit() error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for key, e := range s.entries {
+		if err := writeCacheEntry(tx, key, e.value, e.at); err != nil {
+			return err
+		}
+		if err := writeSnapshotEntry(tx, s.id, key, e.value, e.at); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// writeCacheEntry upserts value for the stored key.
+func writeCacheEntry(q dbtx, key, value string, at time.Time) error {
+	value, err := seal(value)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(
+		`INSERT INTO cache (key, value, synced_at) VALUES (?, ?, ?)
+		 ON CONFLICT(key) DO UPDATE SET value=excluded.value, synced_at=excluded.synced_at`,
+		key, value, at,
+	)
+	return err
+}
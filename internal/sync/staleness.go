@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DefaultStaleAfterHours is used when the stale_after_hours setting is unset.
+const DefaultStaleAfterHours = 24
+
+// StaleAfter returns how old a tab's data may get before it is flagged as
+// stale, from the stale_after_hours setting; 0 turns the check off.
+func StaleAfter() time.Duration {
+	hours := DefaultStaleAfterHours
+	if v, err := GetSetting("stale_after_hours"); err == nil && v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			hours = n
+		}
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// AutoResync reports whether stale data should be re-synced without
+// asking first (the auto_resync setting).
+func AutoResync() bool {
+	v, _ := GetSetting("auto_resync")
+	return v == "true"
+}
+
+// TabCacheKeys lists the cache keys behind a web tab or CLI view section.
+func TabCacheKeys(tab, region string) []string {
+	var keys []string
+	switch tab {
+	case "net":
+		keys = []string{region + ":vpcs", region + ":subnets", region + ":security-groups", region + ":load-balancers", region + ":eips", region + ":enis", region + ":transit-gateways", region + ":vpc-peering", region + ":vpc-endpoints", region + ":network-acls", region + ":flow-logs"}
+	case "compute":
+		keys = []string{region + ":ec2-enriched", region + ":ecs-enriched", region + ":lambda", region + ":ebs-volumes", region + ":ebs-snapshots", region + ":autoscaling", region + ":servicemesh",
+			region + ":apprunner", region + ":lightsail", region + ":lightsail-containers"}
+	case "database":
+		keys = []string{region + ":rds", region + ":rds-clusters", region + ":dynamodb", region + ":elasticache-enriched"}
+	case "s3":
+		keys = []string{"s3", "s3:enriched", region + ":redshift", region + ":redshift-serverless-namespaces", region + ":opensearch", region + ":athena", region + ":storage-enriched"}
+	case "iam":
+		keys = []string{"iam:enriched", region + ":cognito-enriched", region + ":secrets", region + ":kms", region + ":guardduty", region + ":config", region + ":cloudtrail", region + ":access-analyzer"}
+	case "streaming":
+		keys = []string{region + ":streaming-enriched"}
+	case "ai":
+		keys = []string{region + ":sagemaker-notebooks", region + ":bedrock-models", region + ":sagemaker-training-jobs", region + ":sagemaker-pipelines", region + ":sagemaker-domains",
+			region + ":bedrock-agents", region + ":bedrock-knowledge-bases", region + ":bedrock-guardrails", region + ":bedrock-provisioned"}
+	case "cicd":
+		keys = []string{region + ":codepipeline", region + ":codebuild", region + ":cloudformation-stacks"}
+	}
+	return keys
+}
+
+// TabAge returns how long ago the tab's data was last synced, and false
+// when nothing for it is cached.
+func TabAge(tab, region string) (time.Duration, bool) {
+	keys := TabCacheKeys(tab, region)
+	if len(keys) == 0 {
+		return 0, false
+	}
+	t := CacheSyncedAt(keys...)
+	if t == nil {
+		return 0, false
+	}
+	return time.Since(*t), true
+}
+
+// IsStale reports whether data of the given age is past StaleAfter.
+func IsStale(age time.Duration) bool {
+	limit := StaleAfter()
+	return limit > 0 && age > limit
+}
+
+// FormatAge renders an age as "just now", "5m ago", "3h ago" or "2d ago".
+func FormatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
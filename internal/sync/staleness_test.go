@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadCacheEntryAge(t *testing.T) {
+	WriteCache("ca-central-1:lambda", []byte(`[]`))
+	e, err := ReadCacheEntry("ca-central-1:lambda")
+	if err != nil || e == nil {
+		t.Fatalf("entry = %v, %v", e, err)
+	}
+	if age := e.Age(); age < 0 || age > time.Minute {
+		t.Errorf("age = %v, want just written", age)
+	}
+	if e, _ := ReadCacheEntry("ca-central-1:missing"); e != nil {
+		t.Errorf("missing key = %+v", e)
+	}
+}
+
+func TestTabAgeStaleness(t *testing.T) {
+	WriteCache("ca-central-1:codepipeline", []byte(`[]`))
+	db.Exec(`UPDATE cache SET synced_at = ? WHERE key = ?`, time.Now().Add(-30*time.Hour), "ca-central-1:codepipeline")
+
+	age, ok := TabAge("cicd", "ca-central-1")
+	if !ok || age < 29*time.Hour {
+		t.Fatalf("age = %v, %v", age, ok)
+	}
+	if got := FormatAge(age); got != "30h ago" {
+		t.Errorf("FormatAge = %q", got)
+	}
+	if !IsStale(age) {
+		t.Error("30h old data not stale with the default threshold")
+	}
+
+	SetSetting("stale_after_hours", "48")
+	if IsStale(age) {
+		t.Error("30h old data stale with a 48h threshold")
+	}
+	SetSetting("stale_after_hours", "0")
+	if IsStale(age) {
+		t.Error("stale with the check turned off")
+	}
+	SetSetting("stale_after_hours", "")
+
+	if _, ok := TabAge("cicd", "ca-west-1"); ok {
+		t.Error("age reported for a region with nothing cached")
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		10 * time.Second: "just now",
+		5 * time.Minute:  "5m ago",
+		3 * time.Hour:    "3h ago",
+		72 * time.Hour:   "3d ago",
+	} {
+		if got := FormatAge(d); got != want {
+			t.Errorf("FormatAge(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
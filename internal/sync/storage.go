@@ -0,0 +1,240 @@
+package sync
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// StorageData holds file systems: EFS and FSx.
+type StorageData struct {
+	EFS []EFSFileSystem `json:"efs"`
+	FSx []FSxFileSystem `json:"fsx"`
+}
+
+type EFSFileSystem struct {
+	FileSystemId      string           `json:"FileSystemId"`
+	Name              string           `json:"Name"`
+	State             string           `json:"LifeCycleState"`
+	PerformanceMode   string           `json:"PerformanceMode"`
+	ThroughputMode    string           `json:"ThroughputMode"` // bursting, provisioned, elastic
+	ProvisionedMibps  float64          `json:"ProvisionedThroughputInMibps"`
+	SizeBytes         int64            `json:"SizeBytes"`
+	Encrypted         bool             `json:"Encrypted"`
+	KmsKeyId          string           `json:"KmsKeyId"`
+	LifecyclePolicies []string         `json:"LifecyclePolicies"` // e.g. "TransitionToIA AFTER_30_DAYS"
+	MountTargets      []EFSMountTarget `json:"MountTargets"`
+}
+
+// VpcId returns the VPC of the file system's mount targets, or "".
+func (fs EFSFileSystem) VpcId() string {
+	for _, mt := range fs.MountTargets {
+		if mt.VpcId != "" {
+			return mt.VpcId
+		}
+	}
+	return ""
+}
+
+type EFSMountTarget struct {
+	MountTargetId    string   `json:"MountTargetId"`
+	SubnetId         string   `json:"SubnetId"`
+	VpcId            string   `json:"VpcId"`
+	AvailabilityZone string   `json:"AvailabilityZoneName"`
+	IpAddress        string   `json:"IpAddress"`
+	State            string   `json:"LifeCycleState"`
+	SecurityGroups   []string `json:"SecurityGroups"`
+}
+
+type FSxFileSystem struct {
+	FileSystemId    string   `json:"FileSystemId"`
+	Name            string   `json:"Name"`
+	Type            string   `json:"FileSystemType"` // WINDOWS, LUSTRE, ONTAP, OPENZFS
+	State           string   `json:"Lifecycle"`
+	StorageCapacity int      `json:"StorageCapacity"` // GiB
+	StorageType     string   `json:"StorageType"`
+	DeploymentType  string   `json:"DeploymentType"`
+	Throughput      int      `json:"ThroughputCapacity"` // MB/s, 0 when not applicable
+	VpcId           string   `json:"VpcId"`
+	SubnetIds       []string `json:"SubnetIds"`
+	DNSName         string   `json:"DNSName"`
+	KmsKeyId        string   `json:"KmsKeyId"` // FSx always encrypts at rest
+}
+
+// SyncStorageData fetches EFS file systems with their mount targets and
+// lifecycle policies, and FSx file systems.
+func SyncStorageData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	var results []SyncResult
+	data := StorageData{}
+
+	// EFS - describe file systems, then mount targets and lifecycle per file system
+	if raw, err := awscli.Run("efs", "describe-file-systems", "--region", region); err == nil {
+		var resp struct {
+			FileSystems []json.RawMessage `json:"FileSystems"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, f := range resp.FileSystems {
+			fs := parseEFSFileSystem(f)
+			if mtData, err := awscli.Run("efs", "describe-mount-targets", "--file-system-id", fs.FileSystemId, "--region", region); err == nil {
+				var mtResp struct {
+					MountTargets []EFSMountTarget `json:"MountTargets"`
+				}
+				json.Unmarshal(mtData, &mtResp)
+				for _, mt := range mtResp.MountTargets {
+					if sgData, err := awscli.Run("efs", "describe-mount-target-security-groups",
+						"--mount-target-id", mt.MountTargetId, "--region", region); err == nil {
+						var sgResp struct {
+							SecurityGroups []string `json:"SecurityGroups"`
+						}
+						json.Unmarshal(sgData, &sgResp)
+						mt.SecurityGroups = sgResp.SecurityGroups
+					}
+					fs.MountTargets = append(fs.MountTargets, mt)
+				}
+			}
+			if lcData, err := awscli.Run("efs", "describe-lifecycle-configuration", "--file-system-id", fs.FileSystemId, "--region", region); err == nil {
+				var lcResp struct {
+					LifecyclePolicies []map[string]string `json:"LifecyclePolicies"`
+				}
+				json.Unmarshal(lcData, &lcResp)
+				for _, p := range lcResp.LifecyclePolicies {
+					for k, v := range p {
+						fs.LifecyclePolicies = append(fs.LifecyclePolicies, k+" "+v)
+					}
+				}
+			}
+			data.EFS = append(data.EFS, fs)
+		}
+		results = append(results, SyncResult{Service: "efs", Count: len(data.EFS)})
+	} else {
+		results = append(results, SyncResult{Service: "efs", Error: err.Error()})
+	}
+	step("efs")
+
+	// FSx
+	if raw, err := awscli.Run("fsx", "describe-file-systems", "--region", region); err == nil {
+		var resp struct {
+			FileSystems []json.RawMessage `json:"FileSystems"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, f := range resp.FileSystems {
+			data.FSx = append(data.FSx, parseFSxFileSystem(f))
+		}
+		results = append(results, SyncResult{Service: "fsx", Count: len(data.FSx)})
+	} else {
+		results = append(results, SyncResult{Service: "fsx", Error: err.Error()})
+	}
+	step("fsx")
+
+	enriched, _ := json.Marshal(data)
+	st.WriteCache(region+":storage-enriched", enriched)
+	return results, nil
+}
+
+func LoadStorageData(region string) (*StorageData, error) {
+	raw, err := ReadCache(region + ":storage-enriched")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var data StorageData
+	json.Unmarshal(raw, &data)
+	return &data, nil
+}
+
+// FileSystemsInSubnet returns the IDs of EFS file systems with a mount
+// target in the subnet and FSx file systems placed in it.
+func (d *StorageData) FileSystemsInSubnet(subnetId string) []string {
+	if d == nil {
+		return nil
+	}
+	var out []string
+	for _, fs := range d.EFS {
+		for _, mt := range fs.MountTargets {
+			if mt.SubnetId == subnetId {
+				out = append(out, fs.FileSystemId)
+				break
+			}
+		}
+	}
+	for _, fs := range d.FSx {
+		for _, s := range fs.SubnetIds {
+			if s == subnetId {
+				out = append(out, fs.FileSystemId)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func parseEFSFileSystem(raw json.RawMessage) EFSFileSystem {
+	var fs EFSFileSystem
+	json.Unmarshal(raw, &fs)
+	var r struct {
+		SizeInBytes struct {
+			Value int64 `json:"Value"`
+		} `json:"SizeInBytes"`
+	}
+	json.Unmarshal(raw, &r)
+	fs.SizeBytes = r.SizeInBytes.Value
+	return fs
+}
+
+func parseFSxFileSystem(raw json.RawMessage) FSxFileSystem {
+	var r struct {
+		FileSystemId    string   `json:"FileSystemId"`
+		FileSystemType  string   `json:"FileSystemType"`
+		Lifecycle       string   `json:"Lifecycle"`
+		StorageCapacity int      `json:"StorageCapacity"`
+		StorageType     string   `json:"StorageType"`
+		VpcId           string   `json:"VpcId"`
+		SubnetIds       []string `json:"SubnetIds"`
+		DNSName         string   `json:"DNSName"`
+		KmsKeyId        string   `json:"KmsKeyId"`
+		Tags            []struct {
+			Key   string `json:"Key"`
+			Value string `json:"Value"`
+		} `json:"Tags"`
+		// Deployment and throughput live in a per-type configuration block.
+		WindowsConfiguration *fsxTypeConfig `json:"WindowsConfiguration"`
+		LustreConfiguration  *fsxTypeConfig `json:"LustreConfiguration"`
+		OntapConfiguration   *fsxTypeConfig `json:"OntapConfiguration"`
+		OpenZFSConfiguration *fsxTypeConfig `json:"OpenZFSConfiguration"`
+	}
+	json.Unmarshal(raw, &r)
+
+	fs := FSxFileSystem{
+		FileSystemId:    r.FileSystemId,
+		Type:            r.FileSystemType,
+		State:           r.Lifecycle,
+		StorageCapacity: r.StorageCapacity,
+		StorageType:     r.StorageType,
+		VpcId:           r.VpcId,
+		SubnetIds:       r.SubnetIds,
+		DNSName:         r.DNSName,
+		KmsKeyId:        r.KmsKeyId,
+	}
+	for _, t := range r.Tags {
+		if t.Key == "Name" {
+			fs.Name = t.Value
+		}
+	}
+	for _, c := range []*fsxTypeConfig{r.WindowsConfiguration, r.LustreConfiguration, r.OntapConfiguration, r.OpenZFSConfiguration} {
+		if c != nil {
+			fs.DeploymentType = strings.ReplaceAll(c.DeploymentType, "_", " ")
+			fs.Throughput = c.ThroughputCapacity
+		}
+	}
+	return fs
+}
+
+type fsxTypeConfig struct {
+	DeploymentType     string `json:"DeploymentType"`
+	ThroughputCapacity int    `json:"ThroughputCapacity"`
+}
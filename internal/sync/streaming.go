@@ -2,6 +2,9 @@ package sync
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,6 +16,9 @@ type StreamingData struct {
 	SNS         []SNSTopic         `json:"sns"`
 	Kinesis     []KinesisStream    `json:"kinesis"`
 	EventBridge []EventBridgeBus   `json:"eventbridge"`
+	MSK         []MSKCluster       `json:"msk"`
+	MQ          []MQBroker         `json:"mq"`
+	Firehose    []FirehoseStream   `json:"firehose"`
 }
 
 type SQSQueue struct {
@@ -28,15 +34,51 @@ type SQSQueue struct {
 	DelaySeconds             string `json:"DelaySeconds"`
 	IsFIFO                   bool   `json:"IsFIFO"`
 	RedrivePolicy            string `json:"RedrivePolicy"`
+	Encryption               string `json:"Encryption"` // SSE-KMS, SSE-SQS or none
+	KmsKeyId                 string `json:"KmsKeyId"`
 	Policies                 []ResourcePolicy `json:"Policies"`
+
+	// Parsed from RedrivePolicy and resolved against the cache on load.
+	DLQArn          string   `json:"-"`
+	DLQName         string   `json:"-"`
+	MaxReceiveCount int      `json:"-"`
+	DLQCached       bool     `json:"-"`
+	DLQFor          []string `json:"-"` // names of queues that use this one as their DLQ
 }
 
 type SNSTopic struct {
-	TopicArn      string           `json:"TopicArn"`
-	Name          string           `json:"Name"`
-	DisplayName   string           `json:"DisplayName"`
-	Subscriptions int              `json:"Subscriptions"`
-	Policies      []ResourcePolicy `json:"Policies"`
+	TopicArn         string            `json:"TopicArn"`
+	Name             string            `json:"Name"`
+	DisplayName      string            `json:"DisplayName"`
+	Subscriptions    int               `json:"Subscriptions"`
+	SubscriptionList []SNSSubscription `json:"SubscriptionList"`
+	KmsKeyId         string            `json:"KmsKeyId"` // "" when server-side encryption is off
+	Policies         []ResourcePolicy  `json:"Policies"`
+}
+
+// SNSSubscription is one fan-out target of a topic. SQS and Lambda
+// endpoints are resolved to cached resources on load.
+type SNSSubscription struct {
+	SubscriptionArn string `json:"SubscriptionArn"` // "PendingConfirmation" until confirmed
+	Protocol        string `json:"Protocol"`        // sqs, lambda, https, email, sms, firehose, ...
+	Endpoint        string `json:"Endpoint"`
+
+	TargetType   string `json:"-"` // "sqs" or "lambda" when the endpoint is one
+	TargetId     string `json:"-"`
+	TargetCached bool   `json:"-"`
+}
+
+// Pending reports whether the subscription is awaiting confirmation.
+func (s SNSSubscription) Pending() bool {
+	return s.SubscriptionArn == "PendingConfirmation"
+}
+
+// Target is the cached queue or function name, or the raw endpoint.
+func (s SNSSubscription) Target() string {
+	if s.TargetCached {
+		return s.TargetId
+	}
+	return s.Endpoint
 }
 
 type KinesisStream struct {
@@ -57,13 +99,124 @@ type EventBridgeBus struct {
 }
 
 type EventBridgeRule struct {
-	Name        string `json:"Name"`
-	State       string `json:"State"`
-	Description string `json:"Description"`
-	Schedule    string `json:"ScheduleExpression"`
+	Name        string              `json:"Name"`
+	State       string              `json:"State"`
+	Description string              `json:"Description"`
+	Schedule    string              `json:"ScheduleExpression"`
+	Targets     []EventBridgeTarget `json:"Targets"`
+}
+
+// EventBridgeTarget is where a rule sends matching events. Lambda, SQS,
+// SNS, ECS, Kinesis and Firehose targets are resolved to cached resources
+// on load.
+type EventBridgeTarget struct {
+	Id             string `json:"Id"`
+	Arn            string `json:"Arn"`
+	DeadLetterArn  string `json:"DeadLetterArn"`
+	TaskDefinition string `json:"TaskDefinition"` // ECS targets only
+
+	TargetType   string `json:"-"`
+	TargetId     string `json:"-"`
+	TargetCached bool   `json:"-"`
+}
+
+// Target is the cached resource name, or the raw ARN.
+func (t EventBridgeTarget) Target() string {
+	if t.TargetCached {
+		return t.TargetId
+	}
+	return t.Arn
+}
+
+// ScheduledJob is a cron or rate rule with the bus it lives on.
+type ScheduledJob struct {
+	Bus  string
+	Rule EventBridgeRule
+}
+
+// ScheduledJobs returns every scheduled rule across buses, by name.
+func (d *StreamingData) ScheduledJobs() []ScheduledJob {
+	var out []ScheduledJob
+	for _, b := range d.EventBridge {
+		for _, r := range b.Rules {
+			if r.Schedule != "" {
+				out = append(out, ScheduledJob{Bus: b.Name, Rule: r})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Rule.Name < out[j].Rule.Name })
+	return out
+}
+
+type MSKCluster struct {
+	ClusterName    string   `json:"ClusterName"`
+	ClusterArn     string   `json:"ClusterArn"`
+	ClusterType    string   `json:"ClusterType"` // PROVISIONED or SERVERLESS
+	State          string   `json:"State"`
+	KafkaVersion   string   `json:"KafkaVersion"`
+	BrokerCount    int      `json:"BrokerCount"`
+	InstanceType   string   `json:"InstanceType"`
+	ClientSubnets  []string `json:"ClientSubnets"`
+	SecurityGroups []string `json:"SecurityGroups"`
+	KmsKeyId       string   `json:"KmsKeyId"`     // at-rest key; always encrypted, AWS-owned when empty
+	ClientBroker   string   `json:"ClientBroker"` // TLS, TLS_PLAINTEXT or PLAINTEXT
+	InClusterTLS   bool     `json:"InClusterTLS"`
+	PublicAccess   bool     `json:"PublicAccess"`
+}
+
+type MQBroker struct {
+	BrokerId           string   `json:"BrokerId"`
+	BrokerName         string   `json:"BrokerName"`
+	BrokerArn          string   `json:"BrokerArn"`
+	State              string   `json:"BrokerState"`
+	EngineType         string   `json:"EngineType"`
+	EngineVersion      string   `json:"EngineVersion"`
+	InstanceType       string   `json:"HostInstanceType"`
+	DeploymentMode     string   `json:"DeploymentMode"`
+	PubliclyAccessible bool     `json:"PubliclyAccessible"`
+	SubnetIds          []string `json:"SubnetIds"`
+	SecurityGroups     []string `json:"SecurityGroups"`
+	KmsKeyId           string   `json:"KmsKeyId"` // empty when using the AWS-owned key
+}
+
+// FirehoseStream is a delivery stream with its source and destination.
+// Source and Destination IDs use the same type/id scheme as the detail
+// views; the *Cached flags are resolved against the cache on load.
+type FirehoseStream struct {
+	Name              string `json:"Name"`
+	Arn               string `json:"Arn"`
+	Status            string `json:"Status"`
+	SourceType        string `json:"SourceType"` // "kinesis", "msk" or "" for direct put
+	SourceId          string `json:"SourceId"`
+	DestinationType   string `json:"DestinationType"` // "s3", "redshift", "opensearch", "http", "splunk", ...
+	DestinationId     string `json:"DestinationId"`
+	DestinationPrefix string `json:"DestinationPrefix"` // S3 key prefix for S3 destinations, may contain !{...} expressions
+	BackupBucket      string `json:"BackupBucket"` // S3 bucket for backup/staging, if not the destination
+	SourceCached      bool   `json:"-"`
+	DestinationCached bool   `json:"-"`
+
+	// Records that fail delivery or processing land in ErrorBucket under
+	// ErrorPrefix. ErrorObjects is -1 when the prefix could not be listed.
+	ErrorBucket      string           `json:"ErrorBucket"`
+	ErrorPrefix      string           `json:"ErrorPrefix"`
+	ErrorObjects     int              `json:"ErrorObjects"`
+	ErrorObjectsMore bool             `json:"ErrorObjectsMore"` // listing stopped at firehoseErrorListLimit
+	LastErrorObject  string           `json:"LastErrorObject"`  // newest LastModified among listed objects
+	Metrics          *FirehoseMetrics `json:"Metrics"`
+
+	deliveryMetric string // CloudWatch metric prefix, e.g. "DeliveryToS3"
 }
 
-func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, error) {
+// DeliveryFailing reports whether the stream shows signs of losing data:
+// objects under its error prefix or a 24h delivery success rate below 100%.
+func (f FirehoseStream) DeliveryFailing() bool {
+	if f.ErrorObjects > 0 {
+		return true
+	}
+	return f.Metrics != nil && f.Metrics.HasDelivery && f.Metrics.DeliverySuccess < 1
+}
+
+func SyncStreamingData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -74,7 +227,7 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 
 	// SQS
 	if raw, err := awscli.Run("sqs", "list-queues", "--region", region); err == nil {
-		WriteCache(region+":sqs", raw)
+		st.WriteCache(region+":sqs", raw)
 		var resp struct {
 			QueueUrls []string `json:"QueueUrls"`
 		}
@@ -105,6 +258,15 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 				queue.MessageRetention = a["MessageRetentionPeriod"]
 				queue.DelaySeconds = a["DelaySeconds"]
 				queue.RedrivePolicy = a["RedrivePolicy"]
+				queue.KmsKeyId = a["KmsMasterKeyId"]
+				switch {
+				case queue.KmsKeyId != "":
+					queue.Encryption = "SSE-KMS"
+				case a["SqsManagedSseEnabled"] == "true":
+					queue.Encryption = "SSE-SQS"
+				default:
+					queue.Encryption = "none"
+				}
 				if ts := a["CreatedTimestamp"]; ts != "" {
 					queue.CreatedTimestamp = formatUnixTimestamp(ts)
 				}
@@ -122,7 +284,7 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 
 	// SNS
 	if raw, err := awscli.Run("sns", "list-topics", "--region", region); err == nil {
-		WriteCache(region+":sns", raw)
+		st.WriteCache(region+":sns", raw)
 		var resp struct {
 			Topics []struct {
 				TopicArn string `json:"TopicArn"`
@@ -147,18 +309,20 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 				json.Unmarshal(attrData, &attrResp)
 				a := attrResp.Attributes
 				topic.DisplayName = a["DisplayName"]
+				topic.KmsKeyId = a["KmsMasterKeyId"]
 				if policy := a["Policy"]; policy != "" {
 					topic.Policies = ParseResourcePolicies(policy)
 				}
 			}
 
-			// Subscription count
+			// Subscriptions
 			if subData, err := awscli.Run("sns", "list-subscriptions-by-topic", "--topic-arn", t.TopicArn,
 				"--region", region); err == nil {
 				var subResp struct {
-					Subscriptions []json.RawMessage `json:"Subscriptions"`
+					Subscriptions []SNSSubscription `json:"Subscriptions"`
 				}
 				json.Unmarshal(subData, &subResp)
+				topic.SubscriptionList = subResp.Subscriptions
 				topic.Subscriptions = len(subResp.Subscriptions)
 			}
 
@@ -172,7 +336,7 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 
 	// Kinesis
 	if raw, err := awscli.Run("kinesis", "list-streams", "--region", region); err == nil {
-		WriteCache(region+":kinesis", raw)
+		st.WriteCache(region+":kinesis", raw)
 		var resp struct {
 			StreamSummaries []struct {
 				StreamName   string `json:"StreamName"`
@@ -225,7 +389,7 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 
 	// EventBridge
 	if raw, err := awscli.Run("events", "list-event-buses", "--region", region); err == nil {
-		WriteCache(region+":eventbridge", raw)
+		st.WriteCache(region+":eventbridge", raw)
 		var resp struct {
 			EventBuses []struct {
 				Name string `json:"Name"`
@@ -255,6 +419,7 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 						State:       r.State,
 						Description: r.Description,
 						Schedule:    r.ScheduleExpression,
+						Targets:     listRuleTargets(region, b.Name, r.Name),
 					})
 				}
 			}
@@ -267,9 +432,71 @@ func SyncStreamingData(region string, onStep ...func(string)) ([]SyncResult, err
 	}
 	step("eventbridge")
 
+	// MSK - v2 lists both provisioned and serverless clusters
+	if raw, err := awscli.Run("kafka", "list-clusters-v2", "--region", region); err == nil {
+		var resp struct {
+			ClusterInfoList []json.RawMessage `json:"ClusterInfoList"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, c := range resp.ClusterInfoList {
+			data.MSK = append(data.MSK, parseMSKCluster(c))
+		}
+		results = append(results, SyncResult{Service: "msk", Count: len(resp.ClusterInfoList)})
+	} else {
+		results = append(results, SyncResult{Service: "msk", Error: err.Error()})
+	}
+	step("msk")
+
+	// Amazon MQ - list then describe each for network and encryption settings
+	if raw, err := awscli.Run("mq", "list-brokers", "--region", region); err == nil {
+		var resp struct {
+			BrokerSummaries []struct {
+				BrokerId string `json:"BrokerId"`
+			} `json:"BrokerSummaries"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, b := range resp.BrokerSummaries {
+			if descData, err := awscli.Run("mq", "describe-broker", "--broker-id", b.BrokerId, "--region", region); err == nil {
+				data.MQ = append(data.MQ, parseMQBroker(descData))
+			}
+		}
+		results = append(results, SyncResult{Service: "mq", Count: len(resp.BrokerSummaries)})
+	} else {
+		results = append(results, SyncResult{Service: "mq", Error: err.Error()})
+	}
+	step("mq")
+
+	// Firehose - list then describe each for source and destination
+	if raw, err := awscli.Run("firehose", "list-delivery-streams", "--region", region); err == nil {
+		var resp struct {
+			DeliveryStreamNames []string `json:"DeliveryStreamNames"`
+		}
+		json.Unmarshal(raw, &resp)
+		for _, name := range resp.DeliveryStreamNames {
+			if descData, err := awscli.Run("firehose", "describe-delivery-stream",
+				"--delivery-stream-name", name, "--region", region); err == nil {
+				data.Firehose = append(data.Firehose, parseFirehoseStream(descData))
+			}
+		}
+		for i := range data.Firehose {
+			countFirehoseErrors(&data.Firehose[i])
+		}
+		if MetricsEnabled() && len(data.Firehose) > 0 {
+			metrics := fetchFirehoseMetrics(region, data.Firehose)
+			for i := range data.Firehose {
+				data.Firehose[i].Metrics = metrics[data.Firehose[i].Name]
+			}
+			step("firehose metrics")
+		}
+		results = append(results, SyncResult{Service: "firehose", Count: len(resp.DeliveryStreamNames)})
+	} else {
+		results = append(results, SyncResult{Service: "firehose", Error: err.Error()})
+	}
+	step("firehose")
+
 	// Cache enriched data
 	enriched, _ := json.Marshal(data)
-	WriteCache(region+":streaming-enriched", enriched)
+	st.WriteCache(region+":streaming-enriched", enriched)
 
 	return results, nil
 }
@@ -281,9 +508,503 @@ func LoadStreamingData(region string) (*StreamingData, error) {
 	}
 	var data StreamingData
 	json.Unmarshal(raw, &data)
+	if len(data.Firehose) > 0 {
+		resolveFirehoseLinks(region, &data)
+	}
+	resolveSQSRedrive(data.SQS)
+	resolveSNSFanout(region, &data)
+	resolveEventBridgeTargets(region, &data)
 	return &data, nil
 }
 
+// listRuleTargets returns the targets of one EventBridge rule.
+func listRuleTargets(region, bus, rule string) []EventBridgeTarget {
+	data, err := awscli.Run("events", "list-targets-by-rule", "--rule", rule, "--event-bus-name", bus, "--region", region)
+	if err != nil {
+		return nil
+	}
+	var resp struct {
+		Targets []struct {
+			Id               string `json:"Id"`
+			Arn              string `json:"Arn"`
+			DeadLetterConfig *struct {
+				Arn string `json:"Arn"`
+			} `json:"DeadLetterConfig"`
+			EcsParameters *struct {
+				TaskDefinitionArn string `json:"TaskDefinitionArn"`
+			} `json:"EcsParameters"`
+		} `json:"Targets"`
+	}
+	json.Unmarshal(data, &resp)
+	var out []EventBridgeTarget
+	for _, t := range resp.Targets {
+		target := EventBridgeTarget{Id: t.Id, Arn: t.Arn}
+		if t.DeadLetterConfig != nil {
+			target.DeadLetterArn = t.DeadLetterConfig.Arn
+		}
+		if t.EcsParameters != nil {
+			target.TaskDefinition = t.EcsParameters.TaskDefinitionArn
+		}
+		out = append(out, target)
+	}
+	return out
+}
+
+// arnTarget splits the ARN of a queue, topic, function, ECS cluster or
+// stream into the inventory type and ID it is cached under, plus its
+// region. typ is "" for anything else.
+func arnTarget(arn string) (typ, id, region string) {
+	parts := strings.SplitN(arn, ":", 7)
+	if len(parts) < 6 {
+		return "", "", ""
+	}
+	region = parts[3]
+	resource := parts[5]
+	switch parts[2] {
+	case "sqs", "sns":
+		return parts[2], resource, region
+	case "lambda":
+		if resource == "function" && len(parts) == 7 {
+			name, _, _ := strings.Cut(parts[6], ":") // drop a version or alias
+			return "lambda", name, region
+		}
+	case "ecs":
+		if name, ok := strings.CutPrefix(resource, "cluster/"); ok {
+			return "ecs", name, region
+		}
+	case "kinesis":
+		if name, ok := strings.CutPrefix(resource, "stream/"); ok {
+			return "kinesis", name, region
+		}
+	case "firehose":
+		if name, ok := strings.CutPrefix(resource, "deliverystream/"); ok {
+			return "firehose", name, region
+		}
+	}
+	return "", "", ""
+}
+
+// resolveSNSFanout points SQS and Lambda subscriptions at the queue or
+// function they deliver to, and marks which of those are cached.
+func resolveSNSFanout(region string, data *StreamingData) {
+	cached := streamingTargets(region, data)
+	for i := range data.SNS {
+		for j := range data.SNS[i].SubscriptionList {
+			sub := &data.SNS[i].SubscriptionList[j]
+			if sub.Protocol != "sqs" && sub.Protocol != "lambda" {
+				continue
+			}
+			typ, id, r := arnTarget(sub.Endpoint)
+			if typ == "" {
+				continue
+			}
+			sub.TargetType, sub.TargetId = typ, id
+			// Cross-region targets are never in this region's cache.
+			sub.TargetCached = r == region && cached(typ, id)
+		}
+	}
+}
+
+// resolveEventBridgeTargets points rule targets at the cached resources
+// they invoke.
+func resolveEventBridgeTargets(region string, data *StreamingData) {
+	cached := streamingTargets(region, data)
+	for i := range data.EventBridge {
+		for j := range data.EventBridge[i].Rules {
+			rule := &data.EventBridge[i].Rules[j]
+			for k := range rule.Targets {
+				t := &rule.Targets[k]
+				typ, id, r := arnTarget(t.Arn)
+				if typ == "" {
+					continue
+				}
+				t.TargetType, t.TargetId = typ, id
+				t.TargetCached = r == region && cached(typ, id)
+			}
+		}
+	}
+}
+
+// streamingTargets returns a lookup of the resources messages and events
+// can be delivered to. Compute data is only read when asked about a
+// function or cluster.
+func streamingTargets(region string, data *StreamingData) func(typ, id string) bool {
+	set := map[string]bool{}
+	for _, q := range data.SQS {
+		set["sqs/"+q.QueueName] = true
+	}
+	for _, t := range data.SNS {
+		set["sns/"+t.Name] = true
+	}
+	for _, k := range data.Kinesis {
+		set["kinesis/"+k.StreamName] = true
+	}
+	for _, f := range data.Firehose {
+		set["firehose/"+f.Name] = true
+	}
+	loadedCompute := false
+	return func(typ, id string) bool {
+		if (typ == "lambda" || typ == "ecs") && !loadedCompute {
+			loadedCompute = true
+			if compute, err := LoadComputeData(region); err == nil && compute != nil {
+				for _, fn := range compute.Lambda {
+					set["lambda/"+fn.FunctionName] = true
+				}
+				for _, c := range compute.ECS {
+					set["ecs/"+c.ClusterName] = true
+				}
+			}
+		}
+		return set[typ+"/"+id]
+	}
+}
+
+// resolveSQSRedrive parses each queue's RedrivePolicy, links it to its
+// dead-letter queue when that queue is cached, and fills in the reverse
+// "is DLQ for" list.
+func resolveSQSRedrive(queues []SQSQueue) {
+	byArn := map[string]int{}
+	for i, q := range queues {
+		byArn[q.Arn] = i
+	}
+	for i := range queues {
+		q := &queues[i]
+		if q.RedrivePolicy == "" {
+			continue
+		}
+		var rp struct {
+			DeadLetterTargetArn string          `json:"deadLetterTargetArn"`
+			MaxReceiveCount     json.RawMessage `json:"maxReceiveCount"` // a number, or a string in older queues
+		}
+		if json.Unmarshal([]byte(q.RedrivePolicy), &rp) != nil || rp.DeadLetterTargetArn == "" {
+			continue
+		}
+		q.DLQArn = rp.DeadLetterTargetArn
+		q.DLQName = q.DLQArn[strings.LastIndex(q.DLQArn, ":")+1:]
+		q.MaxReceiveCount, _ = strconv.Atoi(strings.Trim(string(rp.MaxReceiveCount), `"`))
+		if j, ok := byArn[q.DLQArn]; ok {
+			q.DLQCached = true
+			queues[j].DLQFor = append(queues[j].DLQFor, q.QueueName)
+		}
+	}
+}
+
+// resolveFirehoseLinks marks which delivery stream sources and destinations
+// are themselves in the cache, so views only link to resources they can show.
+func resolveFirehoseLinks(region string, data *StreamingData) {
+	cached := map[string]bool{}
+	for _, k := range data.Kinesis {
+		cached["kinesis/"+k.StreamName] = true
+	}
+	for _, c := range data.MSK {
+		cached["msk/"+c.ClusterName] = true
+	}
+	if s3, err := LoadS3DataEnriched(); err == nil && s3 != nil {
+		for _, b := range s3.Buckets {
+			cached["s3/"+b.Name] = true
+		}
+	}
+	if dw, err := LoadDataWarehouseData(region); err == nil && dw != nil {
+		for _, c := range dw.Redshift {
+			cached["redshift/"+c.ClusterIdentifier] = true
+		}
+		for _, d := range dw.OpenSearch {
+			cached["opensearch/"+d.DomainName] = true
+		}
+	}
+	for i := range data.Firehose {
+		f := &data.Firehose[i]
+		f.SourceCached = cached[f.SourceType+"/"+f.SourceId]
+		f.DestinationCached = cached[f.DestinationType+"/"+f.DestinationId]
+	}
+}
+
+func parseMSKCluster(raw json.RawMessage) MSKCluster {
+	var c struct {
+		ClusterName string `json:"ClusterName"`
+		ClusterArn  string `json:"ClusterArn"`
+		ClusterType string `json:"ClusterType"`
+		State       string `json:"State"`
+		Provisioned *struct {
+			NumberOfBrokerNodes       int `json:"NumberOfBrokerNodes"`
+			CurrentBrokerSoftwareInfo struct {
+				KafkaVersion string `json:"KafkaVersion"`
+			} `json:"CurrentBrokerSoftwareInfo"`
+			BrokerNodeGroupInfo struct {
+				InstanceType     string   `json:"InstanceType"`
+				ClientSubnets    []string `json:"ClientSubnets"`
+				SecurityGroups   []string `json:"SecurityGroups"`
+				ConnectivityInfo struct {
+					PublicAccess struct {
+						Type string `json:"Type"`
+					} `json:"PublicAccess"`
+				} `json:"ConnectivityInfo"`
+			} `json:"BrokerNodeGroupInfo"`
+			EncryptionInfo struct {
+				EncryptionAtRest struct {
+					DataVolumeKMSKeyId string `json:"DataVolumeKMSKeyId"`
+				} `json:"EncryptionAtRest"`
+				EncryptionInTransit struct {
+					ClientBroker string `json:"ClientBroker"`
+					InCluster    bool   `json:"InCluster"`
+				} `json:"EncryptionInTransit"`
+			} `json:"EncryptionInfo"`
+		} `json:"Provisioned"`
+		Serverless *struct {
+			VpcConfigs []struct {
+				SubnetIds        []string `json:"SubnetIds"`
+				SecurityGroupIds []string `json:"SecurityGroupIds"`
+			} `json:"VpcConfigs"`
+		} `json:"Serverless"`
+	}
+	json.Unmarshal(raw, &c)
+
+	m := MSKCluster{
+		ClusterName: c.ClusterName,
+		ClusterArn:  c.ClusterArn,
+		ClusterType: c.ClusterType,
+		State:       c.State,
+	}
+	if p := c.Provisioned; p != nil {
+		g := p.BrokerNodeGroupInfo
+		m.KafkaVersion = p.CurrentBrokerSoftwareInfo.KafkaVersion
+		m.BrokerCount = p.NumberOfBrokerNodes
+		m.InstanceType = g.InstanceType
+		m.ClientSubnets = g.ClientSubnets
+		m.SecurityGroups = g.SecurityGroups
+		m.PublicAccess = g.ConnectivityInfo.PublicAccess.Type == "SERVICE_PROVIDED_EIPS"
+		m.KmsKeyId = p.EncryptionInfo.EncryptionAtRest.DataVolumeKMSKeyId
+		m.ClientBroker = p.EncryptionInfo.EncryptionInTransit.ClientBroker
+		m.InClusterTLS = p.EncryptionInfo.EncryptionInTransit.InCluster
+	}
+	// Serverless clusters are always TLS-only and encrypted at rest.
+	if s := c.Serverless; s != nil {
+		m.ClientBroker = "TLS"
+		m.InClusterTLS = true
+		for _, vc := range s.VpcConfigs {
+			m.ClientSubnets = append(m.ClientSubnets, vc.SubnetIds...)
+			m.SecurityGroups = append(m.SecurityGroups, vc.SecurityGroupIds...)
+		}
+	}
+	return m
+}
+
+func parseMQBroker(raw json.RawMessage) MQBroker {
+	var b struct {
+		BrokerId           string   `json:"BrokerId"`
+		BrokerName         string   `json:"BrokerName"`
+		BrokerArn          string   `json:"BrokerArn"`
+		BrokerState        string   `json:"BrokerState"`
+		EngineType         string   `json:"EngineType"`
+		EngineVersion      string   `json:"EngineVersion"`
+		HostInstanceType   string   `json:"HostInstanceType"`
+		DeploymentMode     string   `json:"DeploymentMode"`
+		PubliclyAccessible bool     `json:"PubliclyAccessible"`
+		SubnetIds          []string `json:"SubnetIds"`
+		SecurityGroups     []string `json:"SecurityGroups"`
+		EncryptionOptions  struct {
+			KmsKeyId       string `json:"KmsKeyId"`
+			UseAwsOwnedKey bool   `json:"UseAwsOwnedKey"`
+		} `json:"EncryptionOptions"`
+	}
+	json.Unmarshal(raw, &b)
+
+	mq := MQBroker{
+		BrokerId:           b.BrokerId,
+		BrokerName:         b.BrokerName,
+		BrokerArn:          b.BrokerArn,
+		State:              b.BrokerState,
+		EngineType:         b.EngineType,
+		EngineVersion:      b.EngineVersion,
+		InstanceType:       b.HostInstanceType,
+		DeploymentMode:     b.DeploymentMode,
+		PubliclyAccessible: b.PubliclyAccessible,
+		SubnetIds:          b.SubnetIds,
+		SecurityGroups:     b.SecurityGroups,
+	}
+	if !b.EncryptionOptions.UseAwsOwnedKey {
+		mq.KmsKeyId = b.EncryptionOptions.KmsKeyId
+	}
+	return mq
+}
+
+func parseFirehoseStream(raw json.RawMessage) FirehoseStream {
+	type s3Dest struct {
+		BucketARN         string `json:"BucketARN"`
+		Prefix            string `json:"Prefix"`
+		ErrorOutputPrefix string `json:"ErrorOutputPrefix"`
+	}
+	var resp struct {
+		DeliveryStreamDescription struct {
+			DeliveryStreamName   string `json:"DeliveryStreamName"`
+			DeliveryStreamARN    string `json:"DeliveryStreamARN"`
+			DeliveryStreamStatus string `json:"DeliveryStreamStatus"`
+			Source               *struct {
+				KinesisStreamSourceDescription *struct {
+					KinesisStreamARN string `json:"KinesisStreamARN"`
+				} `json:"KinesisStreamSourceDescription"`
+				MSKSourceDescription *struct {
+					MSKClusterARN string `json:"MSKClusterARN"`
+				} `json:"MSKSourceDescription"`
+			} `json:"Source"`
+			Destinations []struct {
+				S3DestinationDescription         *s3Dest `json:"S3DestinationDescription"`
+				ExtendedS3DestinationDescription *s3Dest `json:"ExtendedS3DestinationDescription"`
+				RedshiftDestinationDescription   *struct {
+					ClusterJDBCURL           string  `json:"ClusterJDBCURL"`
+					S3DestinationDescription *s3Dest `json:"S3DestinationDescription"`
+				} `json:"RedshiftDestinationDescription"`
+				AmazonopensearchserviceDestinationDescription *struct {
+					DomainARN                string  `json:"DomainARN"`
+					S3DestinationDescription *s3Dest `json:"S3DestinationDescription"`
+				} `json:"AmazonopensearchserviceDestinationDescription"`
+				ElasticsearchDestinationDescription *struct {
+					DomainARN                string  `json:"DomainARN"`
+					S3DestinationDescription *s3Dest `json:"S3DestinationDescription"`
+				} `json:"ElasticsearchDestinationDescription"`
+				HttpEndpointDestinationDescription *struct {
+					EndpointConfiguration struct {
+						Url  string `json:"Url"`
+						Name string `json:"Name"`
+					} `json:"EndpointConfiguration"`
+					S3DestinationDescription *s3Dest `json:"S3DestinationDescription"`
+				} `json:"HttpEndpointDestinationDescription"`
+				SplunkDestinationDescription *struct {
+					HECEndpoint              string  `json:"HECEndpoint"`
+					S3DestinationDescription *s3Dest `json:"S3DestinationDescription"`
+				} `json:"SplunkDestinationDescription"`
+			} `json:"Destinations"`
+		} `json:"DeliveryStreamDescription"`
+	}
+	json.Unmarshal(raw, &resp)
+	d := resp.DeliveryStreamDescription
+
+	f := FirehoseStream{
+		Name:   d.DeliveryStreamName,
+		Arn:    d.DeliveryStreamARN,
+		Status: d.DeliveryStreamStatus,
+	}
+	if src := d.Source; src != nil {
+		if k := src.KinesisStreamSourceDescription; k != nil {
+			f.SourceType, f.SourceId = "kinesis", arnResource(k.KinesisStreamARN)
+		} else if m := src.MSKSourceDescription; m != nil {
+			// arn:aws:kafka:region:acct:cluster/name/uuid
+			f.SourceType = "msk"
+			if parts := strings.Split(m.MSKClusterARN, "/"); len(parts) >= 2 {
+				f.SourceId = parts[1]
+			}
+		}
+	}
+	bucket := func(s *s3Dest) string {
+		if s == nil {
+			return ""
+		}
+		return strings.TrimPrefix(s.BucketARN, "arn:aws:s3:::")
+	}
+	// Without an ErrorOutputPrefix, Firehose writes failed records under
+	// a per-destination default folder at the bucket root.
+	errorOutput := func(s *s3Dest, fallback string) {
+		if s == nil {
+			return
+		}
+		f.ErrorBucket, f.ErrorPrefix = bucket(s), s.ErrorOutputPrefix
+		if f.ErrorPrefix == "" {
+			f.ErrorPrefix = fallback
+		}
+	}
+	if len(d.Destinations) > 0 {
+		dst := d.Destinations[0]
+		switch {
+		case dst.RedshiftDestinationDescription != nil:
+			r := dst.RedshiftDestinationDescription
+			// jdbc:redshift://<cluster>.<hash>.<region>.redshift.amazonaws.com:5439/db
+			host := strings.TrimPrefix(r.ClusterJDBCURL, "jdbc:redshift://")
+			f.DestinationType, f.DestinationId = "redshift", strings.SplitN(host, ".", 2)[0]
+			f.BackupBucket = bucket(r.S3DestinationDescription)
+			f.deliveryMetric = "DeliveryToRedshift"
+			errorOutput(r.S3DestinationDescription, "errors/")
+		case dst.AmazonopensearchserviceDestinationDescription != nil:
+			o := dst.AmazonopensearchserviceDestinationDescription
+			f.DestinationType, f.DestinationId = "opensearch", arnResource(o.DomainARN)
+			f.BackupBucket = bucket(o.S3DestinationDescription)
+			f.deliveryMetric = "DeliveryToAmazonOpenSearchService"
+			errorOutput(o.S3DestinationDescription, "AmazonOpenSearchService-failed/")
+		case dst.ElasticsearchDestinationDescription != nil:
+			o := dst.ElasticsearchDestinationDescription
+			f.DestinationType, f.DestinationId = "opensearch", arnResource(o.DomainARN)
+			f.BackupBucket = bucket(o.S3DestinationDescription)
+			f.deliveryMetric = "DeliveryToElasticsearch"
+			errorOutput(o.S3DestinationDescription, "elasticsearch-failed/")
+		case dst.HttpEndpointDestinationDescription != nil:
+			h := dst.HttpEndpointDestinationDescription
+			f.DestinationType, f.DestinationId = "http", h.EndpointConfiguration.Name
+			if f.DestinationId == "" {
+				f.DestinationId = h.EndpointConfiguration.Url
+			}
+			f.BackupBucket = bucket(h.S3DestinationDescription)
+			f.deliveryMetric = "DeliveryToHttpEndpoint"
+			errorOutput(h.S3DestinationDescription, "http-endpoint-failed/")
+		case dst.SplunkDestinationDescription != nil:
+			f.DestinationType, f.DestinationId = "splunk", dst.SplunkDestinationDescription.HECEndpoint
+			f.BackupBucket = bucket(dst.SplunkDestinationDescription.S3DestinationDescription)
+			f.deliveryMetric = "DeliveryToSplunk"
+			errorOutput(dst.SplunkDestinationDescription.S3DestinationDescription, "splunk-failed/")
+		case dst.ExtendedS3DestinationDescription != nil:
+			f.DestinationType, f.DestinationId = "s3", bucket(dst.ExtendedS3DestinationDescription)
+			f.DestinationPrefix = dst.ExtendedS3DestinationDescription.Prefix
+			f.deliveryMetric = "DeliveryToS3"
+			errorOutput(dst.ExtendedS3DestinationDescription, "processing-failed/")
+		case dst.S3DestinationDescription != nil:
+			f.DestinationType, f.DestinationId = "s3", bucket(dst.S3DestinationDescription)
+			f.DestinationPrefix = dst.S3DestinationDescription.Prefix
+			f.deliveryMetric = "DeliveryToS3"
+			errorOutput(dst.S3DestinationDescription, "processing-failed/")
+		}
+	}
+	return f
+}
+
+// firehoseErrorListLimit caps how many error objects are listed per stream.
+const firehoseErrorListLimit = 1000
+
+// countFirehoseErrors lists the objects under the stream's error prefix.
+// Prefixes using !{...} expressions are listed up to the first expression.
+func countFirehoseErrors(f *FirehoseStream) {
+	if f.ErrorBucket == "" {
+		return
+	}
+	prefix, _, _ := strings.Cut(f.ErrorPrefix, "!{")
+	data, err := awscli.Run("s3api", "list-objects-v2", "--bucket", f.ErrorBucket,
+		"--prefix", prefix, "--max-items", fmt.Sprint(firehoseErrorListLimit))
+	if err != nil {
+		f.ErrorObjects = -1
+		return
+	}
+	var resp struct {
+		Contents []struct {
+			LastModified string `json:"LastModified"`
+		} `json:"Contents"`
+		NextToken string `json:"NextToken"`
+	}
+	json.Unmarshal(data, &resp)
+	f.ErrorObjects = len(resp.Contents)
+	f.ErrorObjectsMore = resp.NextToken != ""
+	for _, o := range resp.Contents {
+		if o.LastModified > f.LastErrorObject {
+			f.LastErrorObject = o.LastModified
+		}
+	}
+}
+
+// arnResource returns the part of an ARN after the first "/", e.g. the
+// stream name of arn:aws:kinesis:...:stream/name.
+func arnResource(arn string) string {
+	if _, after, ok := strings.Cut(arn, "/"); ok {
+		return after
+	}
+	return arn
+}
+
 func formatUnixTimestamp(ts string) string {
 	var sec int64
 	for _, c := range ts {
@@ -13,7 +13,7 @@ type SyncResult struct {
 }
 
 // SyncVPCData fetches all VPC-related resources for a region and caches them.
-func SyncVPCData(region string, onStep ...func(string)) ([]SyncResult, error) {
+func SyncVPCData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
 	step := func(label string) {
 		if len(onStep) > 0 && onStep[0] != nil {
 			onStep[0](label)
@@ -30,6 +30,12 @@ func SyncVPCData(region string, onStep ...func(string)) ([]SyncResult, error) {
 		{"nat-gws", []string{"ec2", "describe-nat-gateways", "--region", region}, "NatGateways"},
 		{"route-tables", []string{"ec2", "describe-route-tables", "--region", region}, "RouteTables"},
 		{"security-groups", []string{"ec2", "describe-security-groups", "--region", region}, "SecurityGroups"},
+		{"network-acls", []string{"ec2", "describe-network-acls", "--region", region}, "NetworkAcls"},
+		{"flow-logs", []string{"ec2", "describe-flow-logs", "--region", region}, "FlowLogs"},
+		{"eips", []string{"ec2", "describe-addresses", "--region", region}, "Addresses"},
+		{"enis", []string{"ec2", "describe-network-interfaces", "--region", region}, "NetworkInterfaces"},
+		{"vpc-peering", []string{"ec2", "describe-vpc-peering-connections", "--region", region}, "VpcPeeringConnections"},
+		{"vpc-endpoints", []string{"ec2", "describe-vpc-endpoints", "--region", region}, "VpcEndpoints"},
 	}
 
 	var results []SyncResult
@@ -41,8 +47,11 @@ func SyncVPCData(region string, onStep ...func(string)) ([]SyncResult, error) {
 			results = append(results, SyncResult{Service: job.name, Error: err.Error()})
 			continue
 		}
-		WriteCache(key, data)
+		st.WriteCache(key, data)
 		results = append(results, SyncResult{Service: job.name, Count: countKey(data, job.countKey)})
+		if job.name == "eips" {
+			recordEIPHistory(st, region, data)
+		}
 	}
 
 	// ELBv2 - Load Balancers
@@ -55,8 +64,9 @@ func SyncVPCData(region string, onStep ...func(string)) ([]SyncResult, error) {
 		for _, lb := range resp.LoadBalancers {
 			lbs = append(lbs, parseLB(lb))
 		}
+		syncListeners(region, lbs)
 		lbJSON, _ := json.Marshal(lbs)
-		WriteCache(region+":load-balancers", lbJSON)
+		st.WriteCache(region+":load-balancers", lbJSON)
 		results = append(results, SyncResult{Service: "load-balancers", Count: len(lbs)})
 	} else {
 		results = append(results, SyncResult{Service: "load-balancers", Error: err.Error()})
@@ -73,14 +83,43 @@ func SyncVPCData(region string, onStep ...func(string)) ([]SyncResult, error) {
 		for _, tg := range resp.TargetGroups {
 			tgs = append(tgs, parseTG(tg))
 		}
+		syncTargetHealth(region, tgs)
 		tgJSON, _ := json.Marshal(tgs)
-		WriteCache(region+":target-groups", tgJSON)
+		st.WriteCache(region+":target-groups", tgJSON)
 		results = append(results, SyncResult{Service: "target-groups", Count: len(tgs)})
 	} else {
 		results = append(results, SyncResult{Service: "target-groups", Error: err.Error()})
 	}
 	step("target groups")
 
+	// Transit gateways, attachments and route tables
+	tgw, _ := SyncTransitGatewayData(st, region, step)
+	results = append(results, tgw...)
+
+	// Site-to-Site VPN and Direct Connect
+	hybrid, _ := SyncHybridData(st, region, step)
+	results = append(results, hybrid...)
+
+	// Service quotas for the network resources counted above
+	quotas, _ := SyncQuotaData(st, region, step)
+	results = append(results, quotas...)
+
+	// ACM certificates (attached to load balancer listeners)
+	acm, _ := SyncACMData(st, region, step)
+	results = append(results, acm...)
+
+	// WAFv2 web ACLs and which load balancers they protect
+	waf, _ := SyncWAFData(st, region, step)
+	results = append(results, waf...)
+
+	// CloudFront and API Gateway for the external endpoint inventory
+	endpoints, _ := SyncEndpointData(st, region, step)
+	results = append(results, endpoints...)
+
+	// Route 53 hosted zones and records (global)
+	dns, _ := SyncRoute53Data(st, step)
+	results = append(results, dns...)
+
 	return results, nil
 }
 
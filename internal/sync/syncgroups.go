@@ -0,0 +1,143 @@
+package sync
+
+import (
+	gosync "sync"
+)
+
+// SyncGroup is a set of services synced together: a section of saws sync
+// and a step of the dashboard's Sync all. .saws.yaml selects groups by
+// Name under services.
+type SyncGroup struct {
+	Name  string
+	Title string
+	Sync  func(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error)
+}
+
+// SyncGroups lists every group, in the order a full sync runs them.
+var SyncGroups = []SyncGroup{
+	{"network", "Network", SyncVPCData},
+	{"s3", "S3 & Data", syncS3AndData},
+	{"database", "Database", SyncDatabaseData},
+	{"compute", "Compute", SyncComputeData},
+	{"servicemesh", "Service Mesh", SyncServiceMeshData},
+	{"streaming", "Queues & Streaming", SyncStreamingData},
+	{"ai", "AI & ML", SyncAIData},
+	{"cicd", "CI/CD", SyncCICDData},
+	{"iam", "IAM", func(st *Stage, _ string, onStep ...func(string)) ([]SyncResult, error) {
+		return SyncIAMData(st, onStep...)
+	}},
+	{"cognito", "Cognito", SyncCognitoData},
+	{"secrets", "Secrets Manager", SyncSecretsData},
+	{"kms", "KMS", SyncKMSData},
+	{"guardduty", "GuardDuty", SyncGuardDutyData},
+	{"config", "AWS Config", SyncConfigData},
+	{"cloudtrail", "CloudTrail", SyncCloudTrailData},
+	{"accessanalyzer", "Access Analyzer", SyncAccessAnalyzerData},
+	{"tags", "Tags", func(_ *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+		return SyncTags(region, onStep...)
+	}},
+	{"providers", "Other clouds", syncProviders},
+}
+
+// syncS3AndData syncs S3 (every bucket, whatever its region), the data
+// warehouses and file systems.
+func syncS3AndData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	var all []SyncResult
+	if r, err := SyncS3WithRegions(st, onStep...); err == nil {
+		all = append(all, *r)
+	} else {
+		all = append(all, SyncResult{Service: "s3", Error: err.Error()})
+	}
+	if dw, err := SyncDataWarehouseData(st, region, onStep...); err == nil {
+		all = append(all, dw...)
+	}
+	if fs, err := SyncStorageData(st, region, onStep...); err == nil {
+		all = append(all, fs...)
+	}
+	return all, nil
+}
+
+// syncProviders syncs the enabled providers for other clouds.
+func syncProviders(st *Stage, _ string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	var all []SyncResult
+	for _, p := range EnabledProviders() {
+		results, err := p.Sync(st, step)
+		if err != nil {
+			all = append(all, SyncResult{Service: p.Name(), Error: err.Error()})
+			continue
+		}
+		all = append(all, results...)
+	}
+	return all, nil
+}
+
+func syncGroup(name string) *SyncGroup {
+	for i := range SyncGroups {
+		if SyncGroups[i].Name == name {
+			return &SyncGroups[i]
+		}
+	}
+	return nil
+}
+
+// SyncGroupNames lists the group names .saws.yaml accepts.
+func SyncGroupNames() []string {
+	names := make([]string, len(SyncGroups))
+	for i, g := range SyncGroups {
+		names[i] = g.Name
+	}
+	return names
+}
+
+// SelectedSyncGroups returns the groups .saws.yaml lists under services,
+// in sync order, or every group when it lists none. Other clouds are only
+// synced when a provider is enabled.
+func SelectedSyncGroups() []SyncGroup {
+	cfg := projectConfig()
+	var groups []SyncGroup
+	for _, g := range SyncGroups {
+		if len(cfg.Services) > 0 && !contains(cfg.Services, g.Name) {
+			continue
+		}
+		if g.Name == "providers" && len(EnabledProviders()) == 0 {
+			continue
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// SyncConcurrency is how many sync groups run at once: concurrency in
+// .saws.yaml, default 1.
+func SyncConcurrency() int {
+	if n := projectConfig().Concurrency; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// SyncRegions returns the regions .saws.yaml lists, or nil.
+func SyncRegions() []string {
+	return projectConfig().Regions
+}
+
+// RunSyncGroups calls fn for each group, SyncConcurrency at a time, and
+// returns when all are done. With a concurrency of 1 they run in order.
+func RunSyncGroups(groups []SyncGroup, fn func(SyncGroup)) {
+	sem := make(chan struct{}, SyncConcurrency())
+	var wg gosync.WaitGroup
+	for _, g := range groups {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			fn(g)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// SyncFailureDays is how long a service must have failed every sync
+// before it is reported.
+const SyncFailureDays = 3
+
+// SyncStatus tracks the outcome of a service's syncs in one region.
+// FailingSince is when the current run of failures began, zero if the
+// last sync succeeded.
+type SyncStatus struct {
+	Region       string    `json:"region"`
+	Service      string    `json:"service"`
+	LastSuccess  time.Time `json:"lastSuccess"`
+	FailingSince time.Time `json:"failingSince"`
+	LastFailure  time.Time `json:"lastFailure"`
+	LastError    string    `json:"lastError,omitempty"`
+}
+
+// FailingFor returns how long the service has been failing.
+func (s SyncStatus) FailingFor() time.Duration {
+	if s.FailingSince.IsZero() {
+		return 0
+	}
+	return time.Since(s.FailingSince)
+}
+
+// RecordSyncResults updates the sync status of each service in results.
+// Statuses live under a key without a region prefix so CachedRegions does
+// not pick them up. They are written straight away, so a failure is
+// remembered even when the sync it failed in is rolled back.
+func RecordSyncResults(region string, results []SyncResult) {
+	statuses := loadSyncStatuses()
+	now := time.Now()
+	for _, r := range results {
+		key := region + "/" + r.Service
+		s := statuses[key]
+		s.Region, s.Service = region, r.Service
+		if r.Error == "" {
+			s.LastSuccess = now
+			s.FailingSince = time.Time{}
+			s.LastError = ""
+		} else {
+			if s.FailingSince.IsZero() {
+				s.FailingSince = now
+			}
+			s.LastFailure = now
+			s.LastError = r.Error
+		}
+		statuses[key] = s
+	}
+	b, _ := json.Marshal(statuses)
+	WriteCache("sync-status", b)
+}
+
+// FailingSyncs returns the services synced from region that have failed
+// every sync for at least SyncFailureDays, longest first.
+func FailingSyncs(region string) []SyncStatus {
+	var out []SyncStatus
+	for _, s := range loadSyncStatuses() {
+		if s.Region == region && s.FailingFor() >= SyncFailureDays*24*time.Hour {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FailingSince.Before(out[j].FailingSince) })
+	return out
+}
+
+// lastSyncOutcome returns the error of the most recent sync result in any
+// region, "" if it succeeded, and whether any sync was recorded.
+func lastSyncOutcome() (errMsg string, ok bool) {
+	var latest time.Time
+	for _, s := range loadSyncStatuses() {
+		if s.LastSuccess.After(latest) {
+			latest, errMsg, ok = s.LastSuccess, "", true
+		}
+		if s.LastFailure.After(latest) {
+			latest, errMsg, ok = s.LastFailure, s.LastError, true
+		}
+	}
+	return errMsg, ok
+}
+
+func loadSyncStatuses() map[string]SyncStatus {
+	statuses := map[string]SyncStatus{}
+	if raw, err := ReadCache("sync-status"); err == nil && raw != nil {
+		json.Unmarshal(raw, &statuses)
+	}
+	return statuses
+}
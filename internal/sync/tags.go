@@ -0,0 +1,236 @@
+package sync
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// TaggedResource is a resource returned by the Resource Groups Tagging
+// API, with its tags sorted by key.
+type TaggedResource struct {
+	Arn          string        `json:"Arn"`
+	Region       string        `json:"Region"`
+	Service      string        `json:"Service"`      // ARN service, e.g. ec2, lambda
+	ResourceType string        `json:"ResourceType"` // e.g. instance, function; empty for buckets
+	ResourceId   string        `json:"ResourceId"`
+	Tags         []ResourceTag `json:"Tags"`
+}
+
+type ResourceTag struct {
+	Key   string `json:"Key"`
+	Value string `json:"Value"`
+}
+
+// Tag returns the value of key, or "" when the resource lacks it.
+func (r TaggedResource) Tag(key string) string {
+	for _, t := range r.Tags {
+		if t.Key == key {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// TagFilter narrows QueryTags. Empty fields match anything; Value is only
+// used together with Key.
+type TagFilter struct {
+	Key     string
+	Value   string
+	Service string
+	Region  string
+}
+
+// TagKey is a tag key in use, with its distinct values and how many
+// resources carry it.
+type TagKey struct {
+	Key    string
+	Values []string
+	Count  int
+}
+
+// SyncTags replaces the region's rows in the tags table with the tags of
+// every resource the tagging API knows about.
+func SyncTags(region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+	defer step("tags")
+
+	data, err := awscli.Run("resourcegroupstaggingapi", "get-resources", "--region", region)
+	if err != nil {
+		return []SyncResult{{Service: "tags", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		ResourceTagMappingList []json.RawMessage `json:"ResourceTagMappingList"`
+	}
+	json.Unmarshal(data, &resp)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return []SyncResult{{Service: "tags", Error: err.Error()}}, nil
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM tags WHERE account = ? AND region = ?`, Account(), region); err != nil {
+		return []SyncResult{{Service: "tags", Error: err.Error()}}, nil
+	}
+	for _, raw := range resp.ResourceTagMappingList {
+		r := parseTagMapping(raw)
+		// Global resources such as buckets have no region in their ARN.
+		if r.Region == "" {
+			r.Region = region
+		}
+		for _, t := range r.Tags {
+			if _, err := tx.Exec(`INSERT INTO tags (account, region, arn, service, resource_type, resource_id, key, value) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				Account(), region, r.Arn, r.Service, r.ResourceType, r.ResourceId, t.Key, t.Value); err != nil {
+				return []SyncResult{{Service: "tags", Error: err.Error()}}, nil
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return []SyncResult{{Service: "tags", Error: err.Error()}}, nil
+	}
+	return []SyncResult{{Service: "tags", Count: len(resp.ResourceTagMappingList)}}, nil
+}
+
+// QueryTags returns the resources matching f, across all synced regions
+// unless f.Region is set, with all of their tags.
+func QueryTags(f TagFilter) ([]TaggedResource, error) {
+	query := `SELECT region, arn, service, resource_type, resource_id, key, value FROM tags WHERE account = ?`
+	args := []interface{}{Account()}
+	if f.Key != "" {
+		sub := `SELECT arn FROM tags WHERE account = ? AND key = ?`
+		args = append(args, Account(), f.Key)
+		if f.Value != "" {
+			sub += ` AND value = ?`
+			args = append(args, f.Value)
+		}
+		query += ` AND arn IN (` + sub + `)`
+	}
+	if f.Service != "" {
+		query += ` AND service = ?`
+		args = append(args, f.Service)
+	}
+	if f.Region != "" {
+		query += ` AND region = ?`
+		args = append(args, f.Region)
+	}
+	query += ` ORDER BY service, resource_type, resource_id, region, key`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []TaggedResource
+	for rows.Next() {
+		var r TaggedResource
+		var t ResourceTag
+		if err := rows.Scan(&r.Region, &r.Arn, &r.Service, &r.ResourceType, &r.ResourceId, &t.Key, &t.Value); err != nil {
+			return nil, err
+		}
+		if n := len(resources); n > 0 && resources[n-1].Arn == r.Arn && resources[n-1].Region == r.Region {
+			resources[n-1].Tags = append(resources[n-1].Tags, t)
+			continue
+		}
+		r.Tags = []ResourceTag{t}
+		resources = append(resources, r)
+	}
+	return resources, rows.Err()
+}
+
+// TagKeys lists the tag keys in use, in region or everywhere when region
+// is empty, sorted by key.
+func TagKeys(region string) ([]TagKey, error) {
+	query := `SELECT key, value, COUNT(DISTINCT arn) FROM tags WHERE account = ?`
+	args := []interface{}{Account()}
+	if region != "" {
+		query += ` AND region = ?`
+		args = append(args, region)
+	}
+	query += ` GROUP BY key, value ORDER BY key, value`
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []TagKey
+	for rows.Next() {
+		var key, value string
+		var n int
+		if err := rows.Scan(&key, &value, &n); err != nil {
+			return nil, err
+		}
+		if len(keys) == 0 || keys[len(keys)-1].Key != key {
+			keys = append(keys, TagKey{Key: key})
+		}
+		k := &keys[len(keys)-1]
+		k.Values = append(k.Values, value)
+		k.Count += n
+	}
+	return keys, rows.Err()
+}
+
+// TagServices returns the services with tagged resources, sorted.
+func TagServices() ([]string, error) {
+	return distinctTagColumn("service")
+}
+
+// TagRegions returns the regions whose tags have been synced, sorted.
+func TagRegions() ([]string, error) {
+	return distinctTagColumn("region")
+}
+
+func distinctTagColumn(column string) ([]string, error) {
+	rows, err := db.Query(`SELECT DISTINCT `+column+` FROM tags WHERE account = ? ORDER BY `+column, Account())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// parseTagMapping reads one get-resources entry and splits its ARN,
+// arn:partition:service:region:account:type/id, into service, type and id.
+func parseTagMapping(raw json.RawMessage) TaggedResource {
+	var m struct {
+		ResourceARN string `json:"ResourceARN"`
+		Tags        []struct {
+			Key   string `json:"Key"`
+			Value string `json:"Value"`
+		} `json:"Tags"`
+	}
+	json.Unmarshal(raw, &m)
+
+	r := TaggedResource{Arn: m.ResourceARN}
+	if parts := strings.SplitN(m.ResourceARN, ":", 6); len(parts) == 6 {
+		r.Service, r.Region = parts[2], parts[3]
+		resource := parts[5]
+		if i := strings.IndexAny(resource, "/:"); i >= 0 {
+			r.ResourceType, r.ResourceId = resource[:i], resource[i+1:]
+		} else {
+			r.ResourceId = resource
+		}
+	}
+	for _, t := range m.Tags {
+		r.Tags = append(r.Tags, ResourceTag{Key: t.Key, Value: t.Value})
+	}
+	sort.Slice(r.Tags, func(i, j int) bool { return r.Tags[i].Key < r.Tags[j].Key })
+	return r
+}
@@ -0,0 +1,67 @@
+package sync
+
+import (
+	"testing"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+func TestSyncTagsAndQuery(t *testing.T) {
+	fake := awscli.NewFake().
+		On("resourcegroupstaggingapi get-resources --region sa-east-1", `{"ResourceTagMappingList": [
+			{"ResourceARN": "arn:aws:ec2:sa-east-1:123456789012:instance/i-0abc", "Tags": [{"Key": "env", "Value": "production"}, {"Key": "team", "Value": "payments"}]},
+			{"ResourceARN": "arn:aws:lambda:sa-east-1:123456789012:function:resize", "Tags": [{"Key": "env", "Value": "staging"}]},
+			{"ResourceARN": "arn:aws:s3:::sa-assets", "Tags": [{"Key": "team", "Value": "payments"}]}
+		]}`).
+		On("resourcegroupstaggingapi get-resources --region sa-west-9", `{"ResourceTagMappingList": [
+			{"ResourceARN": "arn:aws:ec2:sa-west-9:123456789012:instance/i-0def", "Tags": [{"Key": "env", "Value": "production"}]}
+		]}`)
+	defer awscli.Use(fake)()
+
+	for _, region := range []string{"sa-east-1", "sa-west-9"} {
+		results, _ := SyncTags(region)
+		if len(results) != 1 || results[0].Error != "" {
+			t.Fatalf("%s results = %+v", region, results)
+		}
+	}
+
+	prod, err := QueryTags(TagFilter{Key: "env", Value: "production"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prod) != 2 || prod[0].ResourceId != "i-0abc" || prod[1].ResourceId != "i-0def" {
+		t.Fatalf("env=production = %+v", prod)
+	}
+	// Matching resources carry all of their tags, not just the filtered one.
+	if got := prod[0].Tag("team"); got != "payments" {
+		t.Errorf("i-0abc team = %q", got)
+	}
+
+	payments, _ := QueryTags(TagFilter{Key: "team", Region: "sa-east-1"})
+	if len(payments) != 2 {
+		t.Fatalf("team in sa-east-1 = %+v", payments)
+	}
+	if b := payments[1]; b.Service != "s3" || b.ResourceId != "sa-assets" || b.Region != "sa-east-1" {
+		t.Errorf("bucket = %+v", b)
+	}
+
+	lambdas, _ := QueryTags(TagFilter{Service: "lambda"})
+	if len(lambdas) != 1 || lambdas[0].ResourceType != "function" || lambdas[0].ResourceId != "resize" {
+		t.Errorf("lambda = %+v", lambdas)
+	}
+
+	keys, _ := TagKeys("")
+	if regions, _ := TagRegions(); len(regions) != 2 {
+		t.Errorf("regions = %v", regions)
+	}
+	if len(keys) != 2 || keys[0].Key != "env" || keys[0].Count != 3 || len(keys[0].Values) != 2 {
+		t.Errorf("keys = %+v", keys)
+	}
+
+	// A re-sync replaces the region's rows rather than adding to them.
+	fake.On("resourcegroupstaggingapi get-resources --region sa-west-9", `{"ResourceTagMappingList": []}`)
+	SyncTags("sa-west-9")
+	if prod, _ := QueryTags(TagFilter{Key: "env", Value: "production"}); len(prod) != 1 {
+		t.Errorf("after re-sync env=production = %+v", prod)
+	}
+}
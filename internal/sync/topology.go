@@ -0,0 +1,248 @@
+package sync
+
+import (
+	"sort"
+	"strings"
+)
+
+// Relationship kinds.
+const (
+	RelPlacement     = "placement"      // runs in a VPC or subnet
+	RelSecurityGroup = "security-group" // uses a group, or a group's rules admit another
+	RelTarget        = "target"         // load balancer, target group and target wiring
+	RelReference     = "reference"      // anything else: a role, key, cluster, member...
+)
+
+// Relationship is a typed edge between two cached resources, keyed
+// "type/id" like InventoryItem.Refs. From depends on To.
+type Relationship struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// Topology is the cached resources of a region and the relationships
+// between them. Dangling holds the references to resources that aren't
+// cached in the region, such as a role in another account, for exports
+// that show them; views built on the topology ignore them.
+type Topology struct {
+	Region        string          `json:"region"`
+	Items         []InventoryItem `json:"items"`
+	Relationships []Relationship  `json:"relationships"`
+	Dangling      []Relationship  `json:"dangling,omitempty"`
+}
+
+// LoadTopology builds the topology of a region: every inventory reference
+// typed by what it points at, plus the security groups each group's
+// inbound rules admit and the targets registered in each target group.
+// It is the one relationship model behind the Graph tab, the DOT output
+// and the Cypher and GraphML exports.
+func LoadTopology(region string) (*Topology, error) {
+	items, err := LoadInventory(region)
+	if err != nil {
+		return nil, err
+	}
+	t := &Topology{Region: region, Items: items}
+	cached := map[string]bool{}
+	for _, it := range items {
+		cached[it.Key()] = true
+	}
+	seen := map[Relationship]bool{}
+	link := func(from, to, kind string) {
+		r := Relationship{from, to, kind}
+		if from == to || !cached[from] || !cached[to] || seen[r] {
+			return
+		}
+		seen[r] = true
+		t.Relationships = append(t.Relationships, r)
+	}
+
+	for _, it := range items {
+		for _, ref := range it.Refs {
+			r := Relationship{it.Key(), ref, relationshipKind(ref)}
+			if !cached[ref] && ref != it.Key() && !seen[r] {
+				seen[r] = true
+				t.Dangling = append(t.Dangling, r)
+				continue
+			}
+			link(r.From, r.To, r.Kind)
+		}
+	}
+	if groups, err := LoadSGRules(region); err == nil {
+		for id, g := range groups {
+			for _, r := range g.Inbound {
+				if strings.HasPrefix(r.Peer, "sg-") {
+					link("sg/"+id, "sg/"+r.Peer, RelSecurityGroup)
+				}
+			}
+		}
+	}
+	if vpc, err := LoadVPCData(region); err == nil && vpc != nil {
+		for _, tg := range vpc.TargetGroups {
+			for _, target := range tg.Targets {
+				switch {
+				case strings.HasPrefix(target.Id, "i-"):
+					link("tg/"+tg.Name, "ec2/"+target.Id, RelTarget)
+				case strings.Contains(target.Id, ":function:"):
+					_, fn, _ := strings.Cut(target.Id, ":function:")
+					link("tg/"+tg.Name, "lambda/"+fn, RelTarget)
+				}
+			}
+		}
+	}
+	sortRelationships(t.Relationships)
+	sortRelationships(t.Dangling)
+	return t, nil
+}
+
+// relationshipKind types a "type/id" reference by what it points at.
+func relationshipKind(ref string) string {
+	typ, _, _ := strings.Cut(ref, "/")
+	switch typ {
+	case "vpc", "subnet":
+		return RelPlacement
+	case "sg":
+		return RelSecurityGroup
+	case "lb", "tg":
+		return RelTarget
+	}
+	return RelReference
+}
+
+// architectureLayers are the resource types drawn in architecture views
+// and the layer each is drawn in.
+var architectureLayers = map[string]string{
+	"vpc": "network", "subnet": "network", "igw": "network", "natgw": "network",
+	"lb": "load-balancing", "tg": "load-balancing",
+	"ec2": "compute", "asg": "compute", "ecs-service": "compute", "lambda": "compute", "apprunner": "compute",
+	"rds": "database", "rds-cluster": "database", "docdb": "database", "neptune": "database",
+	"dynamodb": "database", "elasticache": "database", "redshift": "database", "opensearch": "database",
+}
+
+// ArchitectureLayer returns the layer an architecture view draws a
+// resource type in: network, load-balancing, compute or database, or ""
+// if it is left out.
+func ArchitectureLayer(typ string) string {
+	return architectureLayers[typ]
+}
+
+// Architecture returns the topology as an architecture view draws it:
+// VPCs, subnets, compute, databases and load balancers. Security groups
+// are folded into edges from each resource a group admits to the
+// resources the group guards. A placement in a VPC is dropped when the
+// resource's subnet already shows it.
+func (t *Topology) Architecture() *Topology {
+	out := &Topology{Region: t.Region}
+	kept := map[string]bool{}
+	for _, it := range t.Items {
+		if ArchitectureLayer(it.Type) != "" {
+			out.Items = append(out.Items, it)
+			kept[it.Key()] = true
+		}
+	}
+
+	groups := map[string][]string{}  // resource -> the groups it uses
+	admits := map[string][]string{}  // group -> the groups its rules admit
+	members := map[string][]string{} // group -> the kept resources using it
+	inSubnet := map[string]bool{}
+	for _, r := range t.Relationships {
+		switch {
+		case r.Kind == RelSecurityGroup && strings.HasPrefix(r.From, "sg/"):
+			admits[r.From] = append(admits[r.From], r.To)
+		case r.Kind == RelSecurityGroup:
+			groups[r.From] = append(groups[r.From], r.To)
+			if kept[r.From] {
+				members[r.To] = append(members[r.To], r.From)
+			}
+		case r.Kind == RelPlacement && strings.HasPrefix(r.To, "subnet/"):
+			inSubnet[r.From] = true
+		}
+	}
+
+	seen := map[Relationship]bool{}
+	add := func(r Relationship) {
+		if r.From != r.To && !seen[r] {
+			seen[r] = true
+			out.Relationships = append(out.Relationships, r)
+		}
+	}
+	for _, r := range t.Relationships {
+		if !kept[r.From] || !kept[r.To] {
+			continue
+		}
+		if r.Kind == RelPlacement && strings.HasPrefix(r.To, "vpc/") && inSubnet[r.From] {
+			continue
+		}
+		add(r)
+	}
+	for _, it := range out.Items {
+		for _, g := range groups[it.Key()] {
+			for _, peer := range admits[g] {
+				for _, client := range members[peer] {
+					add(Relationship{client, it.Key(), RelSecurityGroup})
+				}
+			}
+		}
+	}
+	sortRelationships(out.Relationships)
+	return out
+}
+
+// InVPC returns the part of the topology inside a VPC: the VPC, the
+// resources placed in it or its subnets, and the relationships among them.
+func (t *Topology) InVPC(vpcId string) *Topology {
+	in := map[string]bool{}
+	for _, it := range t.Items {
+		if it.VpcId == vpcId || it.Key() == "vpc/"+vpcId {
+			in[it.Key()] = true
+		}
+	}
+	// ECS services and auto scaling groups only name their subnets.
+	for _, r := range t.Relationships {
+		if r.Kind == RelPlacement && in[r.To] {
+			in[r.From] = true
+		}
+	}
+	out := &Topology{Region: t.Region}
+	for _, it := range t.Items {
+		if in[it.Key()] {
+			out.Items = append(out.Items, it)
+		}
+	}
+	for _, r := range t.Relationships {
+		if in[r.From] && in[r.To] {
+			out.Relationships = append(out.Relationships, r)
+		}
+	}
+	return out
+}
+
+// VPCOf returns the VPC each item is in, following subnet placements for
+// resources that don't record one; items outside any VPC are absent.
+func (t *Topology) VPCOf() map[string]string {
+	vpcs := map[string]string{}
+	for _, it := range t.Items {
+		if it.VpcId != "" {
+			vpcs[it.Key()] = it.VpcId
+		}
+	}
+	for _, r := range t.Relationships {
+		if r.Kind == RelPlacement && vpcs[r.From] == "" && vpcs[r.To] != "" {
+			vpcs[r.From] = vpcs[r.To]
+		}
+	}
+	return vpcs
+}
+
+func sortRelationships(rels []Relationship) {
+	sort.Slice(rels, func(i, j int) bool {
+		a, b := rels[i], rels[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+}
@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"reflect"
+	"testing"
+)
+
+// seedTopology caches a VPC with a load balancer forwarding to an
+// instance that reaches a database, and an empty second VPC.
+func seedTopology(region string) {
+	WriteCache(region+":vpcs", []byte(`{"Vpcs": [{"VpcId": "vpc-1"}, {"VpcId": "vpc-2"}]}`))
+	WriteCache(region+":subnets", []byte(`{"Subnets": [{"SubnetId": "subnet-1", "VpcId": "vpc-1"}]}`))
+	WriteCache(region+":security-groups", []byte(`{"SecurityGroups": [
+		{"GroupId": "sg-lb", "VpcId": "vpc-1"},
+		{"GroupId": "sg-app", "VpcId": "vpc-1", "IpPermissions": [{"IpProtocol": "tcp", "FromPort": 80, "ToPort": 80, "UserIdGroupPairs": [{"GroupId": "sg-lb"}]}]},
+		{"GroupId": "sg-db", "VpcId": "vpc-1", "IpPermissions": [{"IpProtocol": "tcp", "FromPort": 5432, "ToPort": 5432, "UserIdGroupPairs": [{"GroupId": "sg-app"}]}]}]}`))
+	WriteCache(region+":load-balancers", []byte(`[{"Name": "web", "Arn": "arn:lb/web", "VpcId": "vpc-1", "SecurityGroups": ["sg-lb"]}]`))
+	WriteCache(region+":target-groups", []byte(`[{"Name": "web-tg", "VpcId": "vpc-1", "LoadBalancerArn": "arn:lb/web",
+		"Targets": [{"Id": "i-1", "Port": 80}, {"Id": "10.0.0.9", "Port": 80}]}]`))
+	WriteCache(region+":ec2-enriched", []byte(`[{"InstanceId": "i-1", "VpcId": "vpc-1", "SubnetId": "subnet-1", "SecurityGroups": ["sg-app"], "IamRole": "missing-role"}]`))
+	WriteCache(region+":rds", []byte(`{"DBInstances": [{"DBInstanceIdentifier": "orders",
+		"DBSubnetGroup": {"VpcId": "vpc-1"}, "VpcSecurityGroups": [{"VpcSecurityGroupId": "sg-db"}]}]}`))
+}
+
+func TestTopology(t *testing.T) {
+	seedTopology("af-south-1")
+	topo, err := LoadTopology("af-south-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	has := func(rels []Relationship, r Relationship) bool {
+		for _, x := range rels {
+			if x == r {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range []Relationship{
+		{"ec2/i-1", "subnet/subnet-1", RelPlacement},
+		{"ec2/i-1", "sg/sg-app", RelSecurityGroup},
+		{"sg/sg-db", "sg/sg-app", RelSecurityGroup},
+		{"tg/web-tg", "lb/web", RelTarget},
+		{"tg/web-tg", "ec2/i-1", RelTarget},
+	} {
+		if !has(topo.Relationships, r) {
+			t.Errorf("missing %+v", r)
+		}
+	}
+	for _, r := range topo.Relationships {
+		if r.To == "iam-role/missing-role" {
+			t.Errorf("relationship to an uncached resource: %+v", r)
+		}
+	}
+	if !has(topo.Dangling, Relationship{"ec2/i-1", "iam-role/missing-role", RelReference}) {
+		t.Errorf("dangling = %+v", topo.Dangling)
+	}
+
+	arch := topo.Architecture()
+	for _, it := range arch.Items {
+		if it.Type == "sg" {
+			t.Errorf("security group %s drawn", it.ID)
+		}
+	}
+	for _, r := range []Relationship{
+		{"lb/web", "ec2/i-1", RelSecurityGroup},
+		{"ec2/i-1", "rds/orders", RelSecurityGroup},
+		{"tg/web-tg", "ec2/i-1", RelTarget},
+	} {
+		if !has(arch.Relationships, r) {
+			t.Errorf("architecture missing %+v", r)
+		}
+	}
+	if has(arch.Relationships, Relationship{"ec2/i-1", "vpc/vpc-1", RelPlacement}) {
+		t.Error("instance placed in the VPC as well as its subnet")
+	}
+
+	var keys []string
+	for _, it := range arch.InVPC("vpc-2").Items {
+		keys = append(keys, it.Key())
+	}
+	if !reflect.DeepEqual(keys, []string{"vpc/vpc-2"}) {
+		t.Errorf("vpc-2 = %v", keys)
+	}
+	if got := topo.VPCOf()["ec2/i-1"]; got != "vpc-1" {
+		t.Errorf("instance VPC = %q", got)
+	}
+}
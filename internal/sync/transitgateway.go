@@ -0,0 +1,313 @@
+package sync
+
+import (
+	"encoding/json"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+// TransitGateway is a transit gateway with its attachments and route
+// tables. Gateways shared from another account through RAM are included
+// when they have attachments here; OwnerId tells them apart.
+type TransitGateway struct {
+	TransitGatewayId string          `json:"TransitGatewayId"`
+	Arn              string          `json:"Arn"`
+	Name             string          `json:"Name"`
+	Description      string          `json:"Description"`
+	State            string          `json:"State"`
+	OwnerId          string          `json:"OwnerId"`
+	AmazonSideAsn    int64           `json:"AmazonSideAsn"`
+	Attachments      []TGWAttachment `json:"Attachments"`
+	RouteTables      []TGWRouteTable `json:"RouteTables"`
+}
+
+// TGWAttachment connects a VPC, VPN, Direct Connect gateway or peer
+// transit gateway. RouteTableId is the route table it is associated with.
+type TGWAttachment struct {
+	AttachmentId    string   `json:"AttachmentId"`
+	Name            string   `json:"Name"`
+	ResourceType    string   `json:"ResourceType"` // vpc, vpn, direct-connect-gateway, peering, connect
+	ResourceId      string   `json:"ResourceId"`
+	ResourceOwnerId string   `json:"ResourceOwnerId"`
+	State           string   `json:"State"`
+	RouteTableId    string   `json:"RouteTableId"`
+	SubnetIds       []string `json:"SubnetIds"` // VPC attachments only
+}
+
+type TGWRouteTable struct {
+	RouteTableId       string     `json:"RouteTableId"`
+	Name               string     `json:"Name"`
+	State              string     `json:"State"`
+	DefaultAssociation bool       `json:"DefaultAssociation"`
+	DefaultPropagation bool       `json:"DefaultPropagation"`
+	Routes             []TGWRoute `json:"Routes"`
+}
+
+// TGWRoute is an active or blackhole route. AttachmentIds is empty for
+// blackholes and has several entries for ECMP routes.
+type TGWRoute struct {
+	Destination   string   `json:"Destination"` // CIDR or prefix list ID
+	Type          string   `json:"Type"`        // static or propagated
+	State         string   `json:"State"`       // active or blackhole
+	AttachmentIds []string `json:"AttachmentIds"`
+}
+
+// Blackholes counts routes that drop traffic.
+func (rt TGWRouteTable) Blackholes() int {
+	n := 0
+	for _, r := range rt.Routes {
+		if r.State == "blackhole" {
+			n++
+		}
+	}
+	return n
+}
+
+// Attachment returns the gateway's attachment with the ID, or nil.
+func (g *TransitGateway) Attachment(id string) *TGWAttachment {
+	for i := range g.Attachments {
+		if g.Attachments[i].AttachmentId == id {
+			return &g.Attachments[i]
+		}
+	}
+	return nil
+}
+
+// Reachable returns the attachments that the routes in the attachment's
+// associated route table lead to, excluding the attachment itself.
+func (g *TransitGateway) Reachable(attachmentId string) []TGWAttachment {
+	a := g.Attachment(attachmentId)
+	if a == nil || a.RouteTableId == "" {
+		return nil
+	}
+	seen := map[string]bool{attachmentId: true}
+	var out []TGWAttachment
+	for _, rt := range g.RouteTables {
+		if rt.RouteTableId != a.RouteTableId {
+			continue
+		}
+		for _, r := range rt.Routes {
+			for _, id := range r.AttachmentIds {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				if t := g.Attachment(id); t != nil {
+					out = append(out, *t)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// SyncTransitGatewayData fetches transit gateways, their attachments and
+// their route tables with active and blackhole routes.
+func SyncTransitGatewayData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	data, err := awscli.Run("ec2", "describe-transit-gateways", "--region", region)
+	if err != nil {
+		step("transit gateways")
+		return []SyncResult{{Service: "transit-gateways", Error: err.Error()}}, nil
+	}
+	var resp struct {
+		TransitGateways []json.RawMessage `json:"TransitGateways"`
+	}
+	json.Unmarshal(data, &resp)
+	var tgws []TransitGateway
+	byId := map[string]int{}
+	for _, raw := range resp.TransitGateways {
+		var g struct {
+			TransitGatewayId  string `json:"TransitGatewayId"`
+			TransitGatewayArn string `json:"TransitGatewayArn"`
+			Description       string `json:"Description"`
+			State             string `json:"State"`
+			OwnerId           string `json:"OwnerId"`
+			Options           struct {
+				AmazonSideAsn int64 `json:"AmazonSideAsn"`
+			} `json:"Options"`
+		}
+		json.Unmarshal(raw, &g)
+		if g.State == "deleted" {
+			continue
+		}
+		byId[g.TransitGatewayId] = len(tgws)
+		tgws = append(tgws, TransitGateway{
+			TransitGatewayId: g.TransitGatewayId,
+			Arn:              g.TransitGatewayArn,
+			Name:             tagName(raw),
+			Description:      g.Description,
+			State:            g.State,
+			OwnerId:          g.OwnerId,
+			AmazonSideAsn:    g.Options.AmazonSideAsn,
+		})
+	}
+	step("transit gateways")
+
+	// Subnets are only on the VPC attachment call.
+	subnets := map[string][]string{}
+	if vData, err := awscli.Run("ec2", "describe-transit-gateway-vpc-attachments", "--region", region); err == nil {
+		var vResp struct {
+			TransitGatewayVpcAttachments []struct {
+				TransitGatewayAttachmentId string   `json:"TransitGatewayAttachmentId"`
+				SubnetIds                  []string `json:"SubnetIds"`
+			} `json:"TransitGatewayVpcAttachments"`
+		}
+		json.Unmarshal(vData, &vResp)
+		for _, a := range vResp.TransitGatewayVpcAttachments {
+			subnets[a.TransitGatewayAttachmentId] = a.SubnetIds
+		}
+	}
+	attachments := 0
+	if aData, err := awscli.Run("ec2", "describe-transit-gateway-attachments", "--region", region); err == nil {
+		var aResp struct {
+			TransitGatewayAttachments []json.RawMessage `json:"TransitGatewayAttachments"`
+		}
+		json.Unmarshal(aData, &aResp)
+		for _, raw := range aResp.TransitGatewayAttachments {
+			var a struct {
+				TransitGatewayAttachmentId string `json:"TransitGatewayAttachmentId"`
+				TransitGatewayId           string `json:"TransitGatewayId"`
+				ResourceType               string `json:"ResourceType"`
+				ResourceId                 string `json:"ResourceId"`
+				ResourceOwnerId            string `json:"ResourceOwnerId"`
+				State                      string `json:"State"`
+				Association                struct {
+					TransitGatewayRouteTableId string `json:"TransitGatewayRouteTableId"`
+				} `json:"Association"`
+			}
+			json.Unmarshal(raw, &a)
+			if a.State == "deleted" {
+				continue
+			}
+			i, ok := byId[a.TransitGatewayId]
+			if !ok {
+				// Attachment to a shared gateway that describe-transit-gateways
+				// did not return.
+				i = len(tgws)
+				byId[a.TransitGatewayId] = i
+				tgws = append(tgws, TransitGateway{TransitGatewayId: a.TransitGatewayId})
+			}
+			tgws[i].Attachments = append(tgws[i].Attachments, TGWAttachment{
+				AttachmentId:    a.TransitGatewayAttachmentId,
+				Name:            tagName(raw),
+				ResourceType:    a.ResourceType,
+				ResourceId:      a.ResourceId,
+				ResourceOwnerId: a.ResourceOwnerId,
+				State:           a.State,
+				RouteTableId:    a.Association.TransitGatewayRouteTableId,
+				SubnetIds:       subnets[a.TransitGatewayAttachmentId],
+			})
+			attachments++
+		}
+	}
+	step("transit gateway attachments")
+
+	if rData, err := awscli.Run("ec2", "describe-transit-gateway-route-tables", "--region", region); err == nil {
+		var rResp struct {
+			TransitGatewayRouteTables []json.RawMessage `json:"TransitGatewayRouteTables"`
+		}
+		json.Unmarshal(rData, &rResp)
+		for _, raw := range rResp.TransitGatewayRouteTables {
+			var t struct {
+				TransitGatewayRouteTableId   string `json:"TransitGatewayRouteTableId"`
+				TransitGatewayId             string `json:"TransitGatewayId"`
+				State                        string `json:"State"`
+				DefaultAssociationRouteTable bool   `json:"DefaultAssociationRouteTable"`
+				DefaultPropagationRouteTable bool   `json:"DefaultPropagationRouteTable"`
+			}
+			json.Unmarshal(raw, &t)
+			i, ok := byId[t.TransitGatewayId]
+			if !ok || t.State == "deleted" {
+				continue
+			}
+			tgws[i].RouteTables = append(tgws[i].RouteTables, TGWRouteTable{
+				RouteTableId:       t.TransitGatewayRouteTableId,
+				Name:               tagName(raw),
+				State:              t.State,
+				DefaultAssociation: t.DefaultAssociationRouteTable,
+				DefaultPropagation: t.DefaultPropagationRouteTable,
+				Routes:             searchTGWRoutes(region, t.TransitGatewayRouteTableId),
+			})
+		}
+	}
+	step("transit gateway route tables")
+
+	b, _ := json.Marshal(tgws)
+	st.WriteCache(region+":transit-gateways", b)
+	return []SyncResult{
+		{Service: "transit-gateways", Count: len(tgws)},
+		{Service: "tgw-attachments", Count: attachments},
+	}, nil
+}
+
+// searchTGWRoutes returns the active and blackhole routes of a route table.
+func searchTGWRoutes(region, routeTableId string) []TGWRoute {
+	data, err := awscli.Run("ec2", "search-transit-gateway-routes",
+		"--transit-gateway-route-table-id", routeTableId,
+		"--filters", "Name=state,Values=active,blackhole", "--region", region)
+	if err != nil {
+		return nil
+	}
+	var resp struct {
+		Routes []struct {
+			DestinationCidrBlock      string `json:"DestinationCidrBlock"`
+			PrefixListId              string `json:"PrefixListId"`
+			Type                      string `json:"Type"`
+			State                     string `json:"State"`
+			TransitGatewayAttachments []struct {
+				TransitGatewayAttachmentId string `json:"TransitGatewayAttachmentId"`
+			} `json:"TransitGatewayAttachments"`
+		} `json:"Routes"`
+	}
+	json.Unmarshal(data, &resp)
+	var out []TGWRoute
+	for _, r := range resp.Routes {
+		route := TGWRoute{Destination: r.DestinationCidrBlock, Type: r.Type, State: r.State}
+		if route.Destination == "" {
+			route.Destination = r.PrefixListId
+		}
+		for _, a := range r.TransitGatewayAttachments {
+			route.AttachmentIds = append(route.AttachmentIds, a.TransitGatewayAttachmentId)
+		}
+		out = append(out, route)
+	}
+	return out
+}
+
+// LoadTransitGateways returns the cached transit gateways for region.
+func LoadTransitGateways(region string) ([]TransitGateway, error) {
+	raw, err := ReadCache(region + ":transit-gateways")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var tgws []TransitGateway
+	json.Unmarshal(raw, &tgws)
+	return tgws, nil
+}
+
+// TGWAttachmentsForVPC returns the transit gateway attachments of a VPC
+// along with the gateway each belongs to.
+func (d *VPCData) TGWAttachmentsForVPC(vpcId string) []TGWVPCLink {
+	var out []TGWVPCLink
+	for i := range d.TransitGateways {
+		g := &d.TransitGateways[i]
+		for _, a := range g.Attachments {
+			if a.ResourceType == "vpc" && a.ResourceId == vpcId {
+				out = append(out, TGWVPCLink{Gateway: g, Attachment: a})
+			}
+		}
+	}
+	return out
+}
+
+// TGWVPCLink pairs a VPC attachment with its transit gateway.
+type TGWVPCLink struct {
+	Gateway    *TransitGateway
+	Attachment TGWAttachment
+}
@@ -1,6 +1,11 @@
 package sync
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 type VPCData struct {
 	VPCs           []VPC           `json:"vpcs"`
@@ -11,6 +16,17 @@ type VPCData struct {
 	SecurityGroups []SecurityGroup `json:"securityGroups"`
 	LoadBalancers  []LoadBalancer  `json:"loadBalancers"`
 	TargetGroups   []TargetGroup   `json:"targetGroups"`
+	Certificates   []Certificate   `json:"certificates"`
+	WAF            *WAFData        `json:"waf,omitempty"`
+	ElasticIPs     []ElasticIP     `json:"elasticIps"`
+	ENIs           []ENI           `json:"enis"`
+	TransitGateways []TransitGateway `json:"transitGateways"`
+	Peerings        []VPCPeering     `json:"peerings"`
+	Endpoints       []VPCEndpoint    `json:"endpoints"`
+	NetworkACLs     []NetworkACL     `json:"networkAcls"`
+	FlowLogs        []FlowLog        `json:"flowLogs"`
+	FlowLogsSynced  bool             `json:"flowLogsSynced"`
+	Hybrid          *HybridData      `json:"hybrid,omitempty"`
 }
 
 type VPC struct {
@@ -58,6 +74,9 @@ type Route struct {
 	Destination  string `json:"DestinationCidrBlock"`
 	GatewayId    string `json:"GatewayId"`
 	NatGatewayId string `json:"NatGatewayId"`
+	TransitGatewayId string `json:"TransitGatewayId"`
+	VpcPeeringConnectionId string `json:"VpcPeeringConnectionId"`
+	PrefixListId     string `json:"DestinationPrefixListId"` // gateway endpoint routes
 	State        string `json:"State"`
 }
 
@@ -71,6 +90,184 @@ type SecurityGroup struct {
 	Name        string   `json:"Name"`
 }
 
+// ENI is an elastic network interface. AttachedTo is the instance ID, or
+// the interface description for requester-managed ENIs (Lambda, RDS, ...).
+type ENI struct {
+	NetworkInterfaceId string   `json:"NetworkInterfaceId"`
+	SubnetId           string   `json:"SubnetId"`
+	VpcId              string   `json:"VpcId"`
+	InterfaceType      string   `json:"InterfaceType"`
+	Status             string   `json:"Status"`
+	PrivateIp          string   `json:"PrivateIp"`
+	PublicIp           string   `json:"PublicIp"`
+	Description        string   `json:"Description"`
+	AttachedTo         string   `json:"AttachedTo"`
+	SecurityGroups     []string `json:"SecurityGroups"`
+}
+
+// VPCPeering is a peering connection. Either side may be in another
+// account or region.
+type VPCPeering struct {
+	PeeringId string      `json:"PeeringId"`
+	Name      string      `json:"Name"`
+	Status    string      `json:"Status"` // active, pending-acceptance, provisioning, ...
+	Requester PeeringSide `json:"Requester"`
+	Accepter  PeeringSide `json:"Accepter"`
+}
+
+type PeeringSide struct {
+	VpcId     string `json:"VpcId"`
+	CidrBlock string `json:"CidrBlock"`
+	OwnerId   string `json:"OwnerId"`
+	Region    string `json:"Region"`
+}
+
+// Peer returns the side of the connection that is not vpcId.
+func (p VPCPeering) Peer(vpcId string) PeeringSide {
+	if p.Requester.VpcId == vpcId {
+		return p.Accepter
+	}
+	return p.Requester
+}
+
+// VPCEndpoint is a gateway, interface or Gateway Load Balancer endpoint.
+// Gateway endpoints attach to route tables, the others to subnets.
+type VPCEndpoint struct {
+	VpcEndpointId  string   `json:"VpcEndpointId"`
+	VpcId          string   `json:"VpcId"`
+	Name           string   `json:"Name"`
+	ServiceName    string   `json:"ServiceName"` // e.g. com.amazonaws.us-east-1.s3
+	Type           string   `json:"Type"`        // Gateway, Interface, GatewayLoadBalancer
+	State          string   `json:"State"`
+	PrivateDns     bool     `json:"PrivateDns"`
+	SubnetIds      []string `json:"SubnetIds"`
+	RouteTableIds  []string `json:"RouteTableIds"`
+	SecurityGroups []string `json:"SecurityGroups"`
+}
+
+// Service returns the short service name, e.g. "s3" or "ecr.dkr", or the
+// full name for endpoint services outside com.amazonaws.<region>.
+func (e VPCEndpoint) Service() string {
+	parts := strings.SplitN(e.ServiceName, ".", 4)
+	if len(parts) == 4 && parts[0] == "com" && parts[1] == "amazonaws" {
+		return parts[3]
+	}
+	return e.ServiceName
+}
+
+// NetworkACL is a subnet-level stateless firewall. Every subnet is
+// associated with exactly one, the VPC default unless set otherwise.
+type NetworkACL struct {
+	NetworkAclId string      `json:"NetworkAclId"`
+	VpcId        string      `json:"VpcId"`
+	IsDefault    bool        `json:"IsDefault"`
+	Name         string      `json:"Name"`
+	SubnetIds    []string    `json:"SubnetIds"`
+	Entries      []NACLEntry `json:"Entries"`
+}
+
+// NACLEntry is one numbered rule. Rules are evaluated lowest number first
+// and the first match wins; rule 32767 is the implicit deny.
+type NACLEntry struct {
+	RuleNumber int    `json:"RuleNumber"`
+	Protocol   string `json:"Protocol"` // IANA number, "-1" for all
+	RuleAction string `json:"RuleAction"`
+	Egress     bool   `json:"Egress"`
+	Cidr       string `json:"Cidr"`
+	FromPort   int    `json:"FromPort"`
+	ToPort     int    `json:"ToPort"`
+	HasPorts   bool   `json:"HasPorts"`
+}
+
+// ProtocolName returns the rule's protocol as it reads in the console.
+func (e NACLEntry) ProtocolName() string {
+	switch e.Protocol {
+	case "-1":
+		return "All"
+	case "6":
+		return "TCP"
+	case "17":
+		return "UDP"
+	case "1":
+		return "ICMP"
+	case "58":
+		return "ICMPv6"
+	}
+	return e.Protocol
+}
+
+// Ports returns the rule's port range, "All" when it has none.
+func (e NACLEntry) Ports() string {
+	if !e.HasPorts {
+		return "All"
+	}
+	if e.FromPort == e.ToPort {
+		return fmt.Sprint(e.FromPort)
+	}
+	return fmt.Sprintf("%d-%d", e.FromPort, e.ToPort)
+}
+
+// Rule returns the rule number, "*" for the implicit deny.
+func (e NACLEntry) Rule() string {
+	if e.RuleNumber == 32767 {
+		return "*"
+	}
+	return fmt.Sprint(e.RuleNumber)
+}
+
+// Inbound returns the ingress rules in evaluation order.
+func (a NetworkACL) Inbound() []NACLEntry { return a.entries(false) }
+
+// Outbound returns the egress rules in evaluation order.
+func (a NetworkACL) Outbound() []NACLEntry { return a.entries(true) }
+
+func (a NetworkACL) entries(egress bool) []NACLEntry {
+	var out []NACLEntry
+	for _, e := range a.Entries {
+		if e.Egress == egress {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RuleNumber < out[j].RuleNumber })
+	return out
+}
+
+// FlowLog is a flow log configuration on a VPC, subnet, network interface
+// or transit gateway. Destination is the log group name for CloudWatch Logs
+// and the ARN otherwise.
+type FlowLog struct {
+	FlowLogId       string `json:"FlowLogId"`
+	ResourceId      string `json:"ResourceId"`
+	TrafficType     string `json:"TrafficType"` // ACCEPT, REJECT or ALL
+	DestinationType string `json:"DestinationType"`
+	Destination     string `json:"Destination"`
+	LogFormat       string `json:"LogFormat"`
+	Status          string `json:"Status"`
+	DeliverStatus   string `json:"DeliverStatus"`
+	DeliverError    string `json:"DeliverError"`
+}
+
+// ResourceType returns "vpc", "subnet", "eni" or "tgw" from the resource ID.
+func (f FlowLog) ResourceType() string {
+	prefix, _, _ := strings.Cut(f.ResourceId, "-")
+	return prefix
+}
+
+// Failing reports whether AWS could not deliver the logs, usually because
+// the role or bucket policy denies it.
+func (f FlowLog) Failing() bool {
+	return f.DeliverStatus == "FAILED"
+}
+
+// defaultFlowLogFormat is what AWS uses when no custom format is given.
+const defaultFlowLogFormat = "${version} ${account-id} ${interface-id} ${srcaddr} ${dstaddr} ${srcport} ${dstport} ${protocol} ${packets} ${bytes} ${start} ${end} ${action} ${log-status}"
+
+// CustomFormat reports whether the flow log records fields beyond the
+// default format.
+func (f FlowLog) CustomFormat() bool {
+	return f.LogFormat != "" && f.LogFormat != defaultFlowLogFormat
+}
+
 type LoadBalancer struct {
 	Name           string   `json:"Name"`
 	Arn            string   `json:"Arn"`
@@ -81,6 +278,7 @@ type LoadBalancer struct {
 	VpcId          string   `json:"VpcId"`
 	AvailZones     []string `json:"AvailZones"`
 	SecurityGroups []string `json:"SecurityGroups"`
+	Listeners      []Listener `json:"Listeners"`
 }
 
 type TargetGroup struct {
@@ -92,6 +290,8 @@ type TargetGroup struct {
 	VpcId           string `json:"VpcId"`
 	HealthCheckPath string `json:"HealthCheckPath"`
 	LoadBalancerArn string `json:"LoadBalancerArn"`
+	LoadBalancerArns []string `json:"LoadBalancerArns"`
+	Targets         []LBTarget `json:"Targets"`
 }
 
 func LoadVPCData(region string) (*VPCData, error) {
@@ -153,9 +353,234 @@ func LoadVPCData(region string) (*VPCData, error) {
 		json.Unmarshal(raw, &data.TargetGroups)
 	}
 
+	data.Certificates, _ = LoadCertificates(region)
+	data.WAF, _ = LoadWAFData(region)
+	data.ElasticIPs, _ = LoadElasticIPs(region)
+	data.TransitGateways, _ = LoadTransitGateways(region)
+	data.Hybrid, _ = LoadHybridData(region)
+
+	if raw, err := ReadCache(region + ":enis"); err == nil && raw != nil {
+		var resp struct{ NetworkInterfaces []json.RawMessage }
+		json.Unmarshal(raw, &resp)
+		for _, n := range resp.NetworkInterfaces {
+			data.ENIs = append(data.ENIs, parseENI(n))
+		}
+	}
+
+	if raw, err := ReadCache(region + ":vpc-peering"); err == nil && raw != nil {
+		var resp struct{ VpcPeeringConnections []json.RawMessage }
+		json.Unmarshal(raw, &resp)
+		for _, p := range resp.VpcPeeringConnections {
+			if pcx := parsePeering(p); !peeringGone[pcx.Status] {
+				data.Peerings = append(data.Peerings, pcx)
+			}
+		}
+	}
+
+	if raw, err := ReadCache(region + ":vpc-endpoints"); err == nil && raw != nil {
+		var resp struct{ VpcEndpoints []json.RawMessage }
+		json.Unmarshal(raw, &resp)
+		for _, e := range resp.VpcEndpoints {
+			data.Endpoints = append(data.Endpoints, parseVPCEndpoint(e))
+		}
+	}
+
+	if raw, err := ReadCache(region + ":network-acls"); err == nil && raw != nil {
+		var resp struct{ NetworkAcls []json.RawMessage }
+		json.Unmarshal(raw, &resp)
+		for _, a := range resp.NetworkAcls {
+			data.NetworkACLs = append(data.NetworkACLs, parseNetworkACL(a))
+		}
+	}
+
+	if raw, err := ReadCache(region + ":flow-logs"); err == nil && raw != nil {
+		var resp struct{ FlowLogs []json.RawMessage }
+		json.Unmarshal(raw, &resp)
+		for _, f := range resp.FlowLogs {
+			data.FlowLogs = append(data.FlowLogs, parseFlowLog(f))
+		}
+		data.FlowLogsSynced = true
+	}
+
 	return data, nil
 }
 
+// FlowLogsForVPC returns the flow logs on a VPC and on its subnets and
+// network interfaces.
+func (d *VPCData) FlowLogsForVPC(vpcId string) []FlowLog {
+	inVPC := map[string]bool{vpcId: true}
+	for _, s := range d.Subnets {
+		if s.VpcId == vpcId {
+			inVPC[s.SubnetId] = true
+		}
+	}
+	for _, n := range d.ENIs {
+		if n.VpcId == vpcId {
+			inVPC[n.NetworkInterfaceId] = true
+		}
+	}
+	var out []FlowLog
+	for _, f := range d.FlowLogs {
+		if inVPC[f.ResourceId] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// MissingFlowLogs reports whether flow logs have been synced and the VPC
+// has no active VPC-level flow log. Subnet and ENI flow logs do not count,
+// since they miss traffic in subnets added later.
+func (d *VPCData) MissingFlowLogs(vpcId string) bool {
+	if !d.FlowLogsSynced {
+		return false
+	}
+	for _, f := range d.FlowLogs {
+		if f.ResourceId == vpcId && f.Status == "ACTIVE" {
+			return false
+		}
+	}
+	return true
+}
+
+// NACLForSubnet returns the network ACL associated with a subnet, or nil
+// if ACLs have not been synced.
+func (d *VPCData) NACLForSubnet(subnetId string) *NetworkACL {
+	for i := range d.NetworkACLs {
+		for _, id := range d.NetworkACLs[i].SubnetIds {
+			if id == subnetId {
+				return &d.NetworkACLs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// RouteTableForSubnet returns the route table a subnet uses: its explicit
+// association, else the main table of its VPC, or nil.
+func (d *VPCData) RouteTableForSubnet(subnetId string) *RouteTable {
+	vpcId := ""
+	for _, s := range d.Subnets {
+		if s.SubnetId == subnetId {
+			vpcId = s.VpcId
+		}
+	}
+	var main *RouteTable
+	for i := range d.RouteTables {
+		rt := &d.RouteTables[i]
+		for _, id := range rt.SubnetIds {
+			if id == subnetId {
+				return rt
+			}
+		}
+		if rt.IsMain && rt.VpcId == vpcId {
+			main = rt
+		}
+	}
+	return main
+}
+
+// SubnetIsPublic reports whether a subnet routes to an internet gateway.
+func (d *VPCData) SubnetIsPublic(subnetId string) bool {
+	if rt := d.RouteTableForSubnet(subnetId); rt != nil {
+		for _, r := range rt.Routes {
+			if strings.HasPrefix(r.GatewayId, "igw-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SecurityGroupsInSubnet returns the IDs of the security groups on the
+// network interfaces in a subnet.
+func (d *VPCData) SecurityGroupsInSubnet(subnetId string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, n := range d.ENIsInSubnet(subnetId) {
+		for _, id := range n.SecurityGroups {
+			if !seen[id] {
+				seen[id] = true
+				out = append(out, id)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// peeringGone are the peering states that no longer connect anything.
+var peeringGone = map[string]bool{"deleted": true, "deleting": true, "rejected": true, "failed": true, "expired": true}
+
+// PeeringsForVPC returns the peering connections with vpcId on either side.
+func (d *VPCData) PeeringsForVPC(vpcId string) []VPCPeering {
+	var out []VPCPeering
+	for _, p := range d.Peerings {
+		if p.Requester.VpcId == vpcId || p.Accepter.VpcId == vpcId {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// EndpointsForVPC returns the VPC endpoints in a VPC.
+func (d *VPCData) EndpointsForVPC(vpcId string) []VPCEndpoint {
+	var out []VPCEndpoint
+	for _, e := range d.Endpoints {
+		if e.VpcId == vpcId {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// VPCName returns the Name tag of a cached VPC, or "".
+func (d *VPCData) VPCName(vpcId string) string {
+	for _, v := range d.VPCs {
+		if v.VpcId == vpcId {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// ENIsInSubnet returns the network interfaces placed in a subnet.
+func (d *VPCData) ENIsInSubnet(subnetId string) []ENI {
+	var out []ENI
+	for _, n := range d.ENIs {
+		if n.SubnetId == subnetId {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// EIPsInSubnet returns the Elastic IPs associated with an ENI in a subnet.
+func (d *VPCData) EIPsInSubnet(subnetId string) []ElasticIP {
+	subnetOf := map[string]string{}
+	for _, n := range d.ENIs {
+		subnetOf[n.NetworkInterfaceId] = n.SubnetId
+	}
+	var out []ElasticIP
+	for _, e := range d.ElasticIPs {
+		if e.NetworkInterfaceId != "" && subnetOf[e.NetworkInterfaceId] == subnetId {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// UnassociatedEIPs returns allocated Elastic IPs that are not in use.
+func (d *VPCData) UnassociatedEIPs() []ElasticIP {
+	var out []ElasticIP
+	for _, e := range d.ElasticIPs {
+		if !e.Associated() {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
 func tagName(raw json.RawMessage) string {
 	var obj struct {
 		Tags []struct {
@@ -204,6 +629,107 @@ func parseIGW(raw json.RawMessage) IGW {
 	return igw
 }
 
+func parseENI(raw json.RawMessage) ENI {
+	var n struct {
+		NetworkInterfaceId string `json:"NetworkInterfaceId"`
+		SubnetId           string `json:"SubnetId"`
+		VpcId              string `json:"VpcId"`
+		InterfaceType      string `json:"InterfaceType"`
+		Status             string `json:"Status"`
+		PrivateIpAddress   string `json:"PrivateIpAddress"`
+		Description        string `json:"Description"`
+		RequesterManaged   bool   `json:"RequesterManaged"`
+		Association        *struct {
+			PublicIp string `json:"PublicIp"`
+		} `json:"Association"`
+		Attachment *struct {
+			InstanceId string `json:"InstanceId"`
+		} `json:"Attachment"`
+		Groups []struct {
+			GroupId string `json:"GroupId"`
+		} `json:"Groups"`
+	}
+	json.Unmarshal(raw, &n)
+	eni := ENI{
+		NetworkInterfaceId: n.NetworkInterfaceId,
+		SubnetId:           n.SubnetId,
+		VpcId:              n.VpcId,
+		InterfaceType:      n.InterfaceType,
+		Status:             n.Status,
+		PrivateIp:          n.PrivateIpAddress,
+		Description:        n.Description,
+	}
+	if n.Association != nil {
+		eni.PublicIp = n.Association.PublicIp
+	}
+	if n.Attachment != nil && n.Attachment.InstanceId != "" {
+		eni.AttachedTo = n.Attachment.InstanceId
+	} else if n.RequesterManaged {
+		eni.AttachedTo = n.Description
+	}
+	for _, g := range n.Groups {
+		eni.SecurityGroups = append(eni.SecurityGroups, g.GroupId)
+	}
+	return eni
+}
+
+func parsePeering(raw json.RawMessage) VPCPeering {
+	type side struct {
+		VpcId     string `json:"VpcId"`
+		CidrBlock string `json:"CidrBlock"`
+		OwnerId   string `json:"OwnerId"`
+		Region    string `json:"Region"`
+	}
+	var p struct {
+		VpcPeeringConnectionId string `json:"VpcPeeringConnectionId"`
+		Status                 struct {
+			Code string `json:"Code"`
+		} `json:"Status"`
+		RequesterVpcInfo side `json:"RequesterVpcInfo"`
+		AccepterVpcInfo  side `json:"AccepterVpcInfo"`
+	}
+	json.Unmarshal(raw, &p)
+	return VPCPeering{
+		PeeringId: p.VpcPeeringConnectionId,
+		Name:      tagName(raw),
+		Status:    p.Status.Code,
+		Requester: PeeringSide(p.RequesterVpcInfo),
+		Accepter:  PeeringSide(p.AccepterVpcInfo),
+	}
+}
+
+func parseVPCEndpoint(raw json.RawMessage) VPCEndpoint {
+	var e struct {
+		VpcEndpointId     string   `json:"VpcEndpointId"`
+		VpcId             string   `json:"VpcId"`
+		ServiceName       string   `json:"ServiceName"`
+		VpcEndpointType   string   `json:"VpcEndpointType"`
+		State             string   `json:"State"`
+		PrivateDnsEnabled bool     `json:"PrivateDnsEnabled"`
+		SubnetIds         []string `json:"SubnetIds"`
+		RouteTableIds     []string `json:"RouteTableIds"`
+		Groups            []struct {
+			GroupId string `json:"GroupId"`
+		} `json:"Groups"`
+	}
+	json.Unmarshal(raw, &e)
+	ep := VPCEndpoint{
+		VpcEndpointId: e.VpcEndpointId,
+		VpcId:         e.VpcId,
+		Name:          tagName(raw),
+		ServiceName:   e.ServiceName,
+		Type:          e.VpcEndpointType,
+		State:         e.State,
+		PrivateDns:    e.PrivateDnsEnabled,
+		SubnetIds:     e.SubnetIds,
+		RouteTableIds: e.RouteTableIds,
+	}
+	for _, g := range e.Groups {
+		ep.SecurityGroups = append(ep.SecurityGroups, g.GroupId)
+	}
+	return ep
+}
+
 func parseNATGW(raw json.RawMessage) NATGW {
 	var n NATGW
 	json.Unmarshal(raw, &n)
@@ -260,6 +786,76 @@ func parseSG(raw json.RawMessage) SecurityGroup {
 	}
 }
 
+func parseNetworkACL(raw json.RawMessage) NetworkACL {
+	var a struct {
+		NetworkAclId string `json:"NetworkAclId"`
+		VpcId        string `json:"VpcId"`
+		IsDefault    bool   `json:"IsDefault"`
+		Associations []struct {
+			SubnetId string `json:"SubnetId"`
+		} `json:"Associations"`
+		Entries []struct {
+			RuleNumber    int    `json:"RuleNumber"`
+			Protocol      string `json:"Protocol"`
+			RuleAction    string `json:"RuleAction"`
+			Egress        bool   `json:"Egress"`
+			CidrBlock     string `json:"CidrBlock"`
+			Ipv6CidrBlock string `json:"Ipv6CidrBlock"`
+			PortRange     *struct {
+				From int `json:"From"`
+				To   int `json:"To"`
+			} `json:"PortRange"`
+		} `json:"Entries"`
+	}
+	json.Unmarshal(raw, &a)
+	acl := NetworkACL{NetworkAclId: a.NetworkAclId, VpcId: a.VpcId, IsDefault: a.IsDefault, Name: tagName(raw)}
+	for _, as := range a.Associations {
+		acl.SubnetIds = append(acl.SubnetIds, as.SubnetId)
+	}
+	for _, e := range a.Entries {
+		entry := NACLEntry{RuleNumber: e.RuleNumber, Protocol: e.Protocol, RuleAction: e.RuleAction, Egress: e.Egress, Cidr: e.CidrBlock}
+		if entry.Cidr == "" {
+			entry.Cidr = e.Ipv6CidrBlock
+		}
+		if e.PortRange != nil {
+			entry.FromPort, entry.ToPort, entry.HasPorts = e.PortRange.From, e.PortRange.To, true
+		}
+		acl.Entries = append(acl.Entries, entry)
+	}
+	return acl
+}
+
+func parseFlowLog(raw json.RawMessage) FlowLog {
+	var f struct {
+		FlowLogId               string `json:"FlowLogId"`
+		ResourceId              string `json:"ResourceId"`
+		TrafficType             string `json:"TrafficType"`
+		LogDestinationType      string `json:"LogDestinationType"`
+		LogDestination          string `json:"LogDestination"`
+		LogGroupName            string `json:"LogGroupName"`
+		LogFormat               string `json:"LogFormat"`
+		FlowLogStatus           string `json:"FlowLogStatus"`
+		DeliverLogsStatus       string `json:"DeliverLogsStatus"`
+		DeliverLogsErrorMessage string `json:"DeliverLogsErrorMessage"`
+	}
+	json.Unmarshal(raw, &f)
+	dest := f.LogDestination
+	if f.LogDestinationType == "cloud-watch-logs" && f.LogGroupName != "" {
+		dest = f.LogGroupName
+	}
+	return FlowLog{
+		FlowLogId:       f.FlowLogId,
+		ResourceId:      f.ResourceId,
+		TrafficType:     f.TrafficType,
+		DestinationType: f.LogDestinationType,
+		Destination:     dest,
+		LogFormat:       f.LogFormat,
+		Status:          f.FlowLogStatus,
+		DeliverStatus:   f.DeliverLogsStatus,
+		DeliverError:    f.DeliverLogsErrorMessage,
+	}
+}
+
 func parseLB(raw json.RawMessage) LoadBalancer {
 	var lb struct {
 		LoadBalancerName string `json:"LoadBalancerName"`
@@ -320,5 +916,6 @@ func parseTG(raw json.RawMessage) TargetGroup {
 		VpcId:           tg.VpcId,
 		HealthCheckPath: tg.HealthCheckPath,
 		LoadBalancerArn: lbArn,
+		LoadBalancerArns: tg.LoadBalancerArns,
 	}
 }
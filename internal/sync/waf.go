@@ -0,0 +1,238 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+)
+
+type WebACL struct {
+	Name          string    `json:"Name"`
+	Id            string    `json:"Id"`
+	Arn           string    `json:"ARN"`
+	Scope         string    `json:"Scope"` // REGIONAL or CLOUDFRONT
+	Description   string    `json:"Description"`
+	DefaultAction string    `json:"DefaultAction"`
+	Capacity      int64     `json:"Capacity"`
+	Rules         []WAFRule `json:"Rules"`
+	Resources     []string  `json:"Resources"` // associated resource ARNs
+}
+
+type WAFRule struct {
+	Name      string `json:"Name"`
+	Priority  int    `json:"Priority"`
+	Action    string `json:"Action"`
+	Statement string `json:"Statement"`
+}
+
+type WAFRuleGroup struct {
+	Name        string `json:"Name"`
+	Id          string `json:"Id"`
+	Arn         string `json:"ARN"`
+	Scope       string `json:"Scope"`
+	Description string `json:"Description"`
+}
+
+type WAFData struct {
+	WebACLs    []WebACL       `json:"webAcls"`
+	RuleGroups []WAFRuleGroup `json:"ruleGroups"`
+}
+
+// WebACLFor returns the web ACL associated with a resource ARN, if any.
+func (d *WAFData) WebACLFor(resourceArn string) *WebACL {
+	if d == nil {
+		return nil
+	}
+	for i := range d.WebACLs {
+		for _, r := range d.WebACLs[i].Resources {
+			if r == resourceArn {
+				return &d.WebACLs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// wafResourceTypes are the regional resource types a web ACL can protect.
+var wafResourceTypes = []string{"APPLICATION_LOAD_BALANCER", "API_GATEWAY", "APPSYNC", "COGNITO_USER_POOL"}
+
+// SyncWAFData fetches WAFv2 web ACLs (with rules and associations) and rule
+// groups. CloudFront-scoped ACLs are global and only listed from us-east-1.
+func SyncWAFData(st *Stage, region string, onStep ...func(string)) ([]SyncResult, error) {
+	step := func(label string) {
+		if len(onStep) > 0 && onStep[0] != nil {
+			onStep[0](label)
+		}
+	}
+
+	scopes := []string{"REGIONAL"}
+	if region == "us-east-1" {
+		scopes = append(scopes, "CLOUDFRONT")
+	}
+
+	data := WAFData{}
+	var firstErr error
+	for _, scope := range scopes {
+		list, err := awscli.Run("wafv2", "list-web-acls", "--scope", scope, "--region", region)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		var resp struct {
+			WebACLs []struct {
+				Name string `json:"Name"`
+				Id   string `json:"Id"`
+			} `json:"WebACLs"`
+		}
+		json.Unmarshal(list, &resp)
+		for _, s := range resp.WebACLs {
+			desc, err := awscli.Run("wafv2", "get-web-acl", "--scope", scope, "--region", region,
+				"--name", s.Name, "--id", s.Id)
+			if err != nil {
+				continue
+			}
+			acl := parseWebACL(desc)
+			acl.Scope = scope
+			if scope == "REGIONAL" {
+				for _, rt := range wafResourceTypes {
+					if out, err := awscli.Run("wafv2", "list-resources-for-web-acl", "--region", region,
+						"--web-acl-arn", acl.Arn, "--resource-type", rt); err == nil {
+						var r struct {
+							ResourceArns []string `json:"ResourceArns"`
+						}
+						json.Unmarshal(out, &r)
+						acl.Resources = append(acl.Resources, r.ResourceArns...)
+					}
+				}
+			}
+			data.WebACLs = append(data.WebACLs, acl)
+		}
+
+		if groups, err := awscli.Run("wafv2", "list-rule-groups", "--scope", scope, "--region", region); err == nil {
+			var resp struct {
+				RuleGroups []WAFRuleGroup `json:"RuleGroups"`
+			}
+			json.Unmarshal(groups, &resp)
+			for _, g := range resp.RuleGroups {
+				g.Scope = scope
+				data.RuleGroups = append(data.RuleGroups, g)
+			}
+		}
+	}
+	step("waf")
+
+	if firstErr != nil && len(data.WebACLs) == 0 {
+		return []SyncResult{{Service: "waf", Error: firstErr.Error()}}, nil
+	}
+	enriched, _ := json.Marshal(data)
+	st.WriteCache(region+":waf-enriched", enriched)
+	return []SyncResult{{Service: "waf", Count: len(data.WebACLs) + len(data.RuleGroups)}}, nil
+}
+
+func LoadWAFData(region string) (*WAFData, error) {
+	raw, err := ReadCache(region + ":waf-enriched")
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var data WAFData
+	json.Unmarshal(raw, &data)
+	return &data, nil
+}
+
+func parseWebACL(raw json.RawMessage) WebACL {
+	var r struct {
+		WebACL struct {
+			Name          string                     `json:"Name"`
+			Id            string                     `json:"Id"`
+			ARN           string                     `json:"ARN"`
+			Description   string                     `json:"Description"`
+			Capacity      int64                      `json:"Capacity"`
+			DefaultAction map[string]json.RawMessage `json:"DefaultAction"`
+			Rules         []struct {
+				Name           string                     `json:"Name"`
+				Priority       int                        `json:"Priority"`
+				Action         map[string]json.RawMessage `json:"Action"`
+				OverrideAction map[string]json.RawMessage `json:"OverrideAction"`
+				Statement      map[string]json.RawMessage `json:"Statement"`
+			} `json:"Rules"`
+		} `json:"WebACL"`
+	}
+	json.Unmarshal(raw, &r)
+
+	acl := WebACL{
+		Name:          r.WebACL.Name,
+		Id:            r.WebACL.Id,
+		Arn:           r.WebACL.ARN,
+		Description:   r.WebACL.Description,
+		Capacity:      r.WebACL.Capacity,
+		DefaultAction: firstKey(r.WebACL.DefaultAction),
+	}
+	for _, rule := range r.WebACL.Rules {
+		action := firstKey(rule.Action)
+		if action == "" {
+			// Rule group references use OverrideAction: None means "use the group's actions"
+			action = firstKey(rule.OverrideAction)
+			if action == "None" {
+				action = "Group"
+			}
+		}
+		acl.Rules = append(acl.Rules, WAFRule{
+			Name:      rule.Name,
+			Priority:  rule.Priority,
+			Action:    action,
+			Statement: summarizeWAFStatement(rule.Statement),
+		})
+	}
+	return acl
+}
+
+// summarizeWAFStatement turns a rule statement into a short label such as
+// "AWS/AWSManagedRulesCommonRuleSet" or "RateBased 2000".
+func summarizeWAFStatement(stmt map[string]json.RawMessage) string {
+	kind := firstKey(stmt)
+	switch kind {
+	case "ManagedRuleGroupStatement":
+		var m struct {
+			VendorName string `json:"VendorName"`
+			Name       string `json:"Name"`
+		}
+		json.Unmarshal(stmt[kind], &m)
+		return m.VendorName + "/" + m.Name
+	case "RuleGroupReferenceStatement":
+		var m struct {
+			ARN string `json:"ARN"`
+		}
+		json.Unmarshal(stmt[kind], &m)
+		parts := strings.Split(m.ARN, "/")
+		if len(parts) >= 3 {
+			return "RuleGroup " + parts[len(parts)-2]
+		}
+		return "RuleGroup"
+	case "RateBasedStatement":
+		var m struct {
+			Limit int64 `json:"Limit"`
+		}
+		json.Unmarshal(stmt[kind], &m)
+		return fmt.Sprintf("RateBased %d", m.Limit)
+	case "IPSetReferenceStatement":
+		return "IPSet"
+	case "GeoMatchStatement":
+		var m struct {
+			CountryCodes []string `json:"CountryCodes"`
+		}
+		json.Unmarshal(stmt[kind], &m)
+		return "Geo " + strings.Join(m.CountryCodes, ",")
+	}
+	return strings.TrimSuffix(kind, "Statement")
+}
+
+func firstKey(m map[string]json.RawMessage) string {
+	for k := range m {
+		return k
+	}
+	return ""
+}
@@ -0,0 +1,231 @@
+package warehouse
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/estrados/simply-aws/internal/awscli"
+	"github.com/estrados/simply-aws/internal/sync"
+)
+
+// DefaultDatabase and Table name the Glue table created over the export.
+const (
+	DefaultDatabase = "saws"
+	Table           = "resources"
+)
+
+// timestampFormat is what the Hive JSON SerDe parses into a timestamp.
+const timestampFormat = "2006-01-02 15:04:05"
+
+// Row is one resource as of one sync. The region the sync ran against is
+// the "region" partition; ResourceRegion is where the resource lives,
+// which differs for global resources such as S3 buckets.
+type Row struct {
+	SyncedAt       string            `json:"synced_at"`
+	Type           string            `json:"type"`
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	ResourceRegion string            `json:"resource_region"`
+	Arn            string            `json:"arn"`
+	VpcId          string            `json:"vpc_id"`
+	Tags           map[string]string `json:"tags"`
+	Refs           []string          `json:"refs"`
+	Details        map[string]string `json:"details"`
+}
+
+// Object is one uploaded file.
+type Object struct {
+	Region string
+	URI    string
+	Rows   int
+}
+
+// Export appends the cached inventory of each region as gzipped JSON lines
+// under uri/resources/dt=YYYY-MM-DD/region=<region>/. Each call writes new
+// objects, so repeated syncs build up a history rather than overwrite it.
+func Export(uri string, regions []string, at time.Time) ([]Object, error) {
+	base, err := tableLocation(uri)
+	if err != nil {
+		return nil, err
+	}
+	at = at.UTC()
+	var out []Object
+	for _, region := range regions {
+		items, err := sync.LoadInventory(region)
+		if err != nil {
+			return out, err
+		}
+		path, err := writeRows(items, at)
+		if err != nil {
+			return out, err
+		}
+		dest := fmt.Sprintf("%sdt=%s/region=%s/%d.json.gz", base, at.Format("2006-01-02"), region, at.Unix())
+		_, err = awscli.Run("s3", "cp", path, dest, "--content-type", "application/json", "--content-encoding", "gzip")
+		os.Remove(path)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, Object{Region: region, URI: dest, Rows: len(items)})
+	}
+	return out, nil
+}
+
+// writeRows writes the items to a temporary gzipped JSON lines file and
+// returns its path.
+func writeRows(items []sync.InventoryItem, at time.Time) (string, error) {
+	f, err := os.CreateTemp("", "saws-warehouse-*.json.gz")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	syncedAt := at.Format(timestampFormat)
+	for _, it := range items {
+		row := Row{
+			SyncedAt: syncedAt, Type: it.Type, ID: it.ID, Name: it.Name, ResourceRegion: it.Region,
+			Arn: it.Arn, VpcId: it.VpcId, Tags: it.Tags, Refs: it.Refs, Details: it.Details,
+		}
+		if err := enc.Encode(row); err != nil {
+			os.Remove(f.Name())
+			return "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// tableLocation validates an s3://bucket/prefix URI and returns the
+// table's data location under it, with a trailing slash.
+func tableLocation(uri string) (string, error) {
+	if uri == "" {
+		return "", fmt.Errorf("no warehouse location: pass --uri or 'saws config set warehouse_uri s3://bucket/prefix'")
+	}
+	if err := sync.ValidateSetting("warehouse_uri", uri); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(uri, "/") + "/" + Table + "/", nil
+}
+
+// columns are the table's data columns, in Row order.
+var columns = [][2]string{
+	{"synced_at", "timestamp"},
+	{"type", "string"},
+	{"id", "string"},
+	{"name", "string"},
+	{"resource_region", "string"},
+	{"arn", "string"},
+	{"vpc_id", "string"},
+	{"tags", "map<string,string>"},
+	{"refs", "array<string>"},
+	{"details", "map<string,string>"},
+}
+
+// tableParameters turn on partition projection, so new dt and region
+// partitions are queryable without MSCK REPAIR or a crawler.
+func tableParameters(location string) map[string]string {
+	regions := make([]string, 0, len(awscli.RegionNames))
+	for r := range awscli.RegionNames {
+		regions = append(regions, r)
+	}
+	sort.Strings(regions)
+	return map[string]string{
+		"classification":            "json",
+		"projection.enabled":        "true",
+		"projection.dt.type":        "date",
+		"projection.dt.format":      "yyyy-MM-dd",
+		"projection.dt.range":       "2020-01-01,NOW",
+		"projection.region.type":    "enum",
+		"projection.region.values":  strings.Join(regions, ","),
+		"storage.location.template": location + "dt=${dt}/region=${region}/",
+	}
+}
+
+// DDL returns the Athena statement that creates the table over uri, for
+// running by hand instead of CreateTable.
+func DDL(uri, database string) (string, error) {
+	location, err := tableLocation(uri)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE EXTERNAL TABLE IF NOT EXISTS `%s`.`%s` (\n", database, Table)
+	for i, c := range columns {
+		sep := ","
+		if i == len(columns)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(&b, "  `%s` %s%s\n", c[0], c[1], sep)
+	}
+	b.WriteString(")\nPARTITIONED BY (`dt` string, `region` string)\n")
+	b.WriteString("ROW FORMAT SERDE 'org.openx.data.jsonserde.JsonSerDe'\n")
+	fmt.Fprintf(&b, "LOCATION '%s'\nTBLPROPERTIES (\n", location)
+	params := tableParameters(location)
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		sep := ","
+		if i == len(keys)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(&b, "  '%s'='%s'%s\n", k, params[k], sep)
+	}
+	b.WriteString(");\n")
+	return b.String(), nil
+}
+
+// CreateTable creates the Glue database if missing and creates or updates
+// the table over uri in region's Data Catalog.
+func CreateTable(uri, database, region string) error {
+	location, err := tableLocation(uri)
+	if err != nil {
+		return err
+	}
+	dbInput, _ := json.Marshal(map[string]string{"Name": database, "Description": "saws historical inventory"})
+	if _, err := awscli.Run("glue", "create-database", "--region", region,
+		"--database-input", string(dbInput)); err != nil &&
+		!strings.Contains(err.Error(), "AlreadyExistsException") {
+		return err
+	}
+
+	type column struct {
+		Name string `json:"Name"`
+		Type string `json:"Type"`
+	}
+	var cols []column
+	for _, c := range columns {
+		cols = append(cols, column{c[0], c[1]})
+	}
+	input := map[string]interface{}{
+		"Name":          Table,
+		"TableType":     "EXTERNAL_TABLE",
+		"Parameters":    tableParameters(location),
+		"PartitionKeys": []column{{"dt", "string"}, {"region", "string"}},
+		"StorageDescriptor": map[string]interface{}{
+			"Columns":      cols,
+			"Location":     location,
+			"InputFormat":  "org.apache.hadoop.mapred.TextInputFormat",
+			"OutputFormat": "org.apache.hadoop.hive.ql.io.HiveIgnoreKeyTextOutputFormat",
+			"SerdeInfo": map[string]string{
+				"SerializationLibrary": "org.openx.data.jsonserde.JsonSerDe",
+			},
+		},
+	}
+	b, _ := json.Marshal(input)
+	_, err = awscli.Run("glue", "create-table", "--region", region, "--database-name", database, "--table-input", string(b))
+	if err != nil && strings.Contains(err.Error(), "AlreadyExistsException") {
+		_, err = awscli.Run("glue", "update-table", "--region", region, "--database-name", database, "--table-input", string(b))
+	}
+	return err
+}